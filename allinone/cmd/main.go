@@ -0,0 +1,234 @@
+// allinone запускает api-gateway и все сервисы магазина в одном процессе:
+// gRPC вызовы идут через сеть в памяти, события заказов - через шину в памяти вместо Kafka,
+// данные - во встроенном PostgreSQL или в памяти (STORAGE_DRIVER=memory)
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	gatewayapp "github.com/che1nov/tea-shop/api-gateway/app"
+	gatewayconfig "github.com/che1nov/tea-shop/api-gateway/config"
+	goodsapp "github.com/che1nov/tea-shop/goods-service/app"
+	notifyapp "github.com/che1nov/tea-shop/notify-service/app"
+	orderapp "github.com/che1nov/tea-shop/order-service/app"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/eventbus"
+	"github.com/che1nov/tea-shop/shared/pkg/grpcclient"
+	"github.com/che1nov/tea-shop/shared/pkg/inproc"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/server"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+
+	"github.com/che1nov/tea-shop/allinone/config"
+)
+
+// Имена сервисов в сети в памяти; под ними gateway и order-service находят соседей
+const (
+	usersService    = "users-service"
+	goodsService    = "goods-service"
+	ordersService   = "order-service"
+	paymentsService = "payment-service"
+	deliveryService = "delivery-service"
+)
+
+// inprocTarget - адрес сервиса в сети в памяти: passthrough отключает разрешение имени через DNS
+func inprocTarget(name string) string {
+	return "passthrough:///" + name
+}
+
+func main() {
+	// Инициализируем logger
+	logger.Init("allinone")
+
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// Подкоманды: allinone config
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Трейсинг: экспортер выбирается через TRACING_EXPORTER
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Error("Failed to initialize tracing", "error", err)
+		panic(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	if err := run(ctx, cfg); err != nil {
+		logger.Error("All-in-one stopped with error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run поднимает магазин и работает до отмены ctx
+func run(ctx context.Context, cfg *config.Config) error {
+	network := inproc.NewNetwork()
+	bus := eventbus.New()
+
+	// Клиенты собираются так же, как между отдельными сервисами, но соединяются через сеть в памяти
+	dial := func(name string) (*grpc.ClientConn, error) {
+		return grpcclient.Dial(name, inprocTarget(name), grpcclient.DefaultConfig(), network.DialOption())
+	}
+
+	var clients orderapp.Clients
+	for name, register := range map[string]func(*grpc.ClientConn){
+		goodsService:    func(conn *grpc.ClientConn) { clients.Goods = pb.NewGoodsServiceClient(conn) },
+		paymentsService: func(conn *grpc.ClientConn) { clients.Payments = pb.NewPaymentsServiceClient(conn) },
+		deliveryService: func(conn *grpc.ClientConn) { clients.Delivery = pb.NewDeliveryServiceClient(conn) },
+	} {
+		conn, err := dial(name)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		register(conn)
+	}
+
+	// Сервисы останавливаются после gateway: serviceCtx отменяется, когда HTTP серверы уже закрыты
+	serviceCtx, cancelServices := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelServices()
+
+	b, err := newBackends(serviceCtx, cfg, bus, clients)
+	if err != nil {
+		if cfg.Storage.Driver == config.StoragePostgres {
+			err = fmt.Errorf("%w (to run without PostgreSQL set STORAGE_DRIVER=memory)", err)
+		}
+		return err
+	}
+	defer b.close()
+
+	var wg sync.WaitGroup
+	serve := func(name string, register func(grpc.ServiceRegistrar)) {
+		serverCfg := server.Config{Name: name, DrainDelay: time.Millisecond}
+		if name == goodsService {
+			// Изображение товара загружается одним сообщением
+			serverCfg.MaxRecvMsgSize = goodsapp.MaxRecvMsgSize
+		}
+		srv := server.New(serverCfg, b.checks[name]...)
+		register(srv)
+
+		listener := network.Listen(name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := srv.Serve(serviceCtx, listener); err != nil {
+				logger.Error("Service stopped with error", "service", name, "error", err)
+			}
+		}()
+	}
+
+	serve(usersService, func(s grpc.ServiceRegistrar) { pb.RegisterUsersServiceServer(s, b.users.Server) })
+	serve(goodsService, func(s grpc.ServiceRegistrar) { pb.RegisterGoodsServiceServer(s, b.goods) })
+	serve(ordersService, func(s grpc.ServiceRegistrar) { pb.RegisterOrdersServiceServer(s, b.orders) })
+	serve(paymentsService, func(s grpc.ServiceRegistrar) { pb.RegisterPaymentsServiceServer(s, b.payments) })
+	serve(deliveryService, func(s grpc.ServiceRegistrar) { pb.RegisterDeliveryServiceServer(s, b.delivery) })
+
+	// notify-service читает события заказов из шины; письма пишутся в лог
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := notifyapp.RunInMemory(serviceCtx, bus, nil); err != nil && serviceCtx.Err() == nil {
+			logger.Error("Service stopped with error", "service", "notify-service", "error", err)
+		}
+	}()
+
+	// api-gateway ходит в сервисы по именам в сети в памяти, JWKS берёт с сервера метрик
+	gatewayCfg := gatewayconfig.Default()
+	gatewayCfg.Env = cfg.Env
+	gatewayCfg.Services.UsersService = inprocTarget(usersService)
+	gatewayCfg.Services.GoodsService = inprocTarget(goodsService)
+	gatewayCfg.Services.OrdersService = inprocTarget(ordersService)
+	gatewayCfg.Services.PaymentsService = inprocTarget(paymentsService)
+	gatewayCfg.Services.DeliveryService = inprocTarget(deliveryService)
+	gatewayCfg.JWT.JWKSURL = fmt.Sprintf("http://localhost:%d/.well-known/jwks.json", cfg.Server.MetricsPort)
+	gatewayCfg.Tracing.ServiceName = cfg.Tracing.ServiceName
+
+	gateway, err := gatewayapp.New(gatewayCfg, network.DialOption())
+	if err != nil {
+		return err
+	}
+	defer gateway.Close()
+
+	// HTTP сервер метрик Prometheus, уровня логов и публичных ключей users-service
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.Handler())
+	metricsMux.Handle("/loglevel", logger.LevelHandler())
+	metricsMux.Handle("/.well-known/jwks.json", b.users.JWKS)
+	if b.media != nil {
+		metricsMux.Handle("/media/", http.StripPrefix("/media/", b.media))
+	}
+
+	servers := []*http.Server{
+		{Addr: fmt.Sprintf(":%d", cfg.Server.Port), Handler: gateway.Handler},
+		{Addr: fmt.Sprintf(":%d", cfg.Server.MetricsPort), Handler: metricsMux},
+	}
+
+	errCh := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- err
+			}
+		}()
+	}
+	logger.Info("Tea shop started",
+		"api", fmt.Sprintf("http://localhost:%d", cfg.Server.Port),
+		"swagger", fmt.Sprintf("http://localhost:%d/swagger/index.html", cfg.Server.Port),
+		"metrics_port", cfg.Server.MetricsPort,
+		"storage", cfg.Storage.Driver,
+	)
+
+	select {
+	case <-ctx.Done():
+		err = nil
+	case err = <-errCh:
+	}
+	logger.Info("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, srv := range servers {
+		if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil {
+			logger.Error("Error shutting down HTTP server", "addr", srv.Addr, "error", shutdownErr)
+		}
+	}
+
+	cancelServices()
+	wg.Wait()
+
+	logger.Info("Tea shop stopped")
+	return err
+}
+
+// runCommand выполняет подкоманду вместо запуска магазина
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "config":
+		return sharedconfig.Print(os.Stdout, cfg)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], sharedconfig.Usage)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+
+	deliveryapp "github.com/che1nov/tea-shop/delivery-service/app"
+	deliverymigrations "github.com/che1nov/tea-shop/delivery-service/migrations"
+	goodsapp "github.com/che1nov/tea-shop/goods-service/app"
+	goodsmigrations "github.com/che1nov/tea-shop/goods-service/migrations"
+	orderapp "github.com/che1nov/tea-shop/order-service/app"
+	ordermigrations "github.com/che1nov/tea-shop/order-service/migrations"
+	paymentapp "github.com/che1nov/tea-shop/payment-service/app"
+	paymentmigrations "github.com/che1nov/tea-shop/payment-service/migrations"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/eventbus"
+	"github.com/che1nov/tea-shop/shared/pkg/health"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	usersapp "github.com/che1nov/tea-shop/users-service/app"
+	usersconfig "github.com/che1nov/tea-shop/users-service/config"
+	usersmigrations "github.com/che1nov/tea-shop/users-service/migrations"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/che1nov/tea-shop/allinone/config"
+	"github.com/che1nov/tea-shop/allinone/internal/storage"
+)
+
+// lowStockThreshold - остаток, при котором товар считается заканчивающимся (как в goods-service)
+const lowStockThreshold = 10
+
+// backends - gRPC API сервисов магазина поверх выбранного хранилища
+type backends struct {
+	users    *usersapp.Service
+	goods    pb.GoodsServiceServer
+	payments pb.PaymentsServiceServer
+	delivery pb.DeliveryServiceServer
+	orders   pb.OrdersServiceServer
+	// media раздает изображения товаров; nil, если они хранятся в памяти
+	media http.Handler
+
+	// checks - проверки готовности сервиса по имени (подключение к его БД)
+	checks map[string][]health.Check
+	// close закрывает подключения и останавливает хранилище
+	close func()
+}
+
+// newBackends собирает сервисы; события заказов публикуются в bus, order-service ходит в соседей через clients
+func newBackends(ctx context.Context, cfg *config.Config, bus *eventbus.Bus, clients orderapp.Clients) (*backends, error) {
+	if cfg.Storage.Driver == config.StorageMemory {
+		return newMemoryBackends(ctx, bus, clients)
+	}
+	return newPostgresBackends(ctx, cfg, bus, clients)
+}
+
+func newMemoryBackends(ctx context.Context, bus *eventbus.Bus, clients orderapp.Clients) (*backends, error) {
+	users, err := usersapp.NewInMemory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backends{
+		users:    users,
+		goods:    goodsapp.NewInMemory(),
+		payments: paymentapp.NewInMemory(nil),
+		delivery: deliveryapp.NewInMemory(),
+		orders:   orderapp.NewInMemory(bus, clients),
+		checks:   map[string][]health.Check{},
+		close:    func() {},
+	}, nil
+}
+
+func newPostgresBackends(ctx context.Context, cfg *config.Config, bus *eventbus.Bus, clients orderapp.Clients) (*backends, error) {
+	logger.Info("Starting embedded PostgreSQL", "data_dir", cfg.Storage.DataDir, "port", cfg.Storage.PostgresPort)
+	pg, err := storage.StartPostgres(cfg.Storage.DataDir, cfg.Storage.PostgresPort, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &backends{checks: map[string][]health.Check{}}
+
+	var dbs []*sql.DB
+	b.close = func() {
+		for _, db := range dbs {
+			db.Close()
+		}
+		if err := pg.Stop(); err != nil {
+			logger.Error("Failed to stop embedded PostgreSQL", "error", err)
+		}
+	}
+
+	// Та же схема, что в docker-compose: у каждого сервиса своя база и свои миграции
+	var usersDB, goodsDB, ordersDB, paymentsDB, deliveriesDB *sql.DB
+	for _, d := range []struct {
+		service string
+		name    string
+		fsys    fs.FS
+		db      **sql.DB
+	}{
+		{"users-service", "users_db", usersmigrations.FS, &usersDB},
+		{"goods-service", "goods_db", goodsmigrations.FS, &goodsDB},
+		{"order-service", "orders_db", ordermigrations.FS, &ordersDB},
+		{"payment-service", "payments_db", paymentmigrations.FS, &paymentsDB},
+		{"delivery-service", "deliveries_db", deliverymigrations.FS, &deliveriesDB},
+	} {
+		db, err := pg.Open(ctx, d.name, d.fsys)
+		if err != nil {
+			b.close()
+			return nil, err
+		}
+		dbs = append(dbs, db)
+		*d.db = db
+		b.checks[d.service] = []health.Check{health.Database(db)}
+	}
+
+	b.users, err = usersapp.New(ctx, usersDB, usersconfig.Default())
+	if err != nil {
+		b.close()
+		return nil, err
+	}
+	// Изображения товаров лежат рядом с данными PostgreSQL и раздаются сервером метрик
+	b.goods, b.media = goodsapp.NewWithImages(goodsDB, filepath.Join(cfg.Storage.DataDir, "images"),
+		fmt.Sprintf("http://localhost:%d/media", cfg.Server.MetricsPort))
+	// Остатки по SKU читаются из БД при каждом скрейпе /metrics
+	prometheus.MustRegister(goodsapp.NewStockCollector(goodsDB, lowStockThreshold))
+	b.payments = paymentapp.New(paymentsDB, nil)
+	b.delivery = deliveryapp.New(deliveriesDB)
+	b.orders = orderapp.New(ordersDB, bus, clients)
+	return b, nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
+
+// Хранилища данных сервисов
+const (
+	// StoragePostgres - встроенный PostgreSQL, данные сохраняются между запусками
+	StoragePostgres = "postgres"
+	// StorageMemory - репозитории в памяти, данные теряются при остановке
+	StorageMemory = "memory"
+)
+
+type Config struct {
+	sharedconfig.App `yaml:",inline"`
+	Server           struct {
+		// Порт HTTP API (api-gateway)
+		Port int `yaml:"port" env:"SERVER_PORT" validate:"port"`
+		// Порт HTTP сервера с /metrics, /loglevel, JWKS users-service и изображениями товаров (/media/)
+		MetricsPort int `yaml:"metrics_port" env:"METRICS_PORT" validate:"port"`
+	} `yaml:"server"`
+	Storage struct {
+		Driver string `yaml:"driver" env:"STORAGE_DRIVER" validate:"oneof=postgres memory"`
+		// Каталог встроенного PostgreSQL: бинарники и данные
+		DataDir string `yaml:"data_dir" env:"STORAGE_DATA_DIR" validate:"required"`
+		// Порт встроенного PostgreSQL
+		PostgresPort int `yaml:"postgres_port" env:"STORAGE_POSTGRES_PORT" validate:"port"`
+	} `yaml:"storage"`
+	// Трейсинг OpenTelemetry
+	Tracing tracing.Config `yaml:"tracing"`
+}
+
+// Load собирает конфигурацию: значения по умолчанию → YAML файл (--config или CONFIG_FILE) →
+// переменные окружения → флаги. Возвращает аргументы после флагов (подкоманду)
+func Load(args []string) (*Config, []string, error) {
+	cfg := &Config{}
+
+	cfg.Env = sharedconfig.EnvDevelopment
+	cfg.Server.Port = 8080
+	cfg.Server.MetricsPort = 9000
+	cfg.Storage.Driver = StoragePostgres
+	cfg.Storage.DataDir = ".allinone"
+	cfg.Storage.PostgresPort = 5440
+	cfg.Tracing = tracing.Config{
+		ServiceName: "tea-shop",
+		Exporter:    tracing.ExporterNone,
+		Endpoint:    "localhost:4317",
+		FilePath:    "traces.json",
+	}
+
+	args, err := sharedconfig.Load(cfg, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, args, nil
+}
@@ -0,0 +1,97 @@
+// Package storage поднимает встроенный PostgreSQL для allinone: один сервер, отдельная база на каждый сервис
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/lib/pq"
+
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/migrate"
+)
+
+const (
+	user     = "user"
+	password = "password"
+)
+
+// Postgres - встроенный сервер PostgreSQL. Бинарники скачиваются при первом запуске,
+// данные хранятся в dataDir и переживают перезапуск
+type Postgres struct {
+	server *embeddedpostgres.EmbeddedPostgres
+	port   int
+}
+
+// StartPostgres запускает сервер на порту port; вывод PostgreSQL пишется в log
+func StartPostgres(dataDir string, port int, log io.Writer) (*Postgres, error) {
+	dataDir, err := filepath.Abs(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	server := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Port(uint32(port)).
+		Username(user).
+		Password(password).
+		DataPath(filepath.Join(dataDir, "data")).
+		RuntimePath(filepath.Join(dataDir, "runtime")).
+		CachePath(filepath.Join(dataDir, "cache")).
+		Logger(log))
+	if err := server.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start embedded postgres: %w", err)
+	}
+	return &Postgres{server: server, port: port}, nil
+}
+
+// Open создаёт базу name, если её нет, подключается к ней и применяет миграции из fsys
+func (p *Postgres) Open(ctx context.Context, name string, fsys fs.FS) (*sql.DB, error) {
+	admin, err := database.Open(ctx, p.config("postgres"))
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close()
+
+	var exists bool
+	if err := admin.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name,
+	).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check database %s: %w", name, err)
+	}
+	if !exists {
+		if _, err := admin.ExecContext(ctx, "CREATE DATABASE "+pq.QuoteIdentifier(name)); err != nil {
+			return nil, fmt.Errorf("failed to create database %s: %w", name, err)
+		}
+	}
+
+	db, err := database.Open(ctx, p.config(name))
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate.Apply(ctx, db, fsys); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database %s: %w", name, err)
+	}
+	return db, nil
+}
+
+// Stop останавливает сервер
+func (p *Postgres) Stop() error {
+	return p.server.Stop()
+}
+
+func (p *Postgres) config(name string) database.Config {
+	return database.Config{
+		Host:     "localhost",
+		Port:     strconv.Itoa(p.port),
+		User:     user,
+		Password: password,
+		Name:     name,
+	}
+}
@@ -0,0 +1,184 @@
+// Package app собирает HTTP API api-gateway. Используется cmd/main.go и сборками,
+// где gateway работает в одном процессе с сервисами (allinone)
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/che1nov/tea-shop/api-gateway/config"
+	"github.com/che1nov/tea-shop/api-gateway/internal/handler"
+	"github.com/che1nov/tea-shop/api-gateway/internal/health"
+	"github.com/che1nov/tea-shop/api-gateway/internal/middleware"
+	"github.com/che1nov/tea-shop/shared/pkg/jwks"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/grpc"
+
+	_ "github.com/che1nov/tea-shop/api-gateway/docs"
+)
+
+// Gateway - собранный api-gateway
+type Gateway struct {
+	// Handler обслуживает HTTP API, пробы и swagger
+	Handler http.Handler
+
+	api *handler.APIHandler
+}
+
+// New подключается к сервисам из cfg.Services и собирает маршруты.
+// dialOptions добавляются к параметрам gRPC соединений, например чтобы направить их в сеть в памяти
+func New(cfg *config.Config, dialOptions ...grpc.DialOption) (*Gateway, error) {
+	h, err := handler.New(
+		cfg.Services.UsersService,
+		cfg.Services.GoodsService,
+		cfg.Services.OrdersService,
+		cfg.Services.PaymentsService,
+		cfg.Services.DeliveryService,
+		cfg.Clients,
+		dialOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка отзыва токенов с кешированием ответов users-service
+	revocationChecker := middleware.NewCachedRevocationChecker(h, cfg.JWT.RevocationCacheTTL)
+	jwksCache := jwks.NewCache(cfg.JWT.JWKSURL, cfg.JWT.JWKSRefreshInterval)
+	authMiddleware := middleware.AuthMiddleware(jwksCache, revocationChecker)
+
+	// Инициализируем Gin (вместо стандартного логгера gin - AccessLog со сквозными идентификаторами)
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	// Трейсинг HTTP запросов: каждый запрос - корневой спан, дальше контекст уходит в gRPC вызовы.
+	// Пробы и swagger не трейсятся
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/healthz" && r.URL.Path != "/readyz" && !strings.HasPrefix(r.URL.Path, "/swagger/")
+	})))
+
+	// X-Request-ID и access log (после otelgin, чтобы в логе был trace_id)
+	router.Use(middleware.RequestID(), middleware.AccessLog())
+	// RED метрики по маршрутам; пробы liveness/readiness в них не попадают
+	router.Use(middleware.Metrics("/healthz", "/readyz"))
+
+	// CORS middleware
+	router.Use(func(c *gin.Context) {
+		c.Writer.Header().Set("Access-Control-Allow-Origin", cfg.CORS.AllowedOrigin)
+		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	})
+
+	// Liveness/readiness пробы
+	readiness := health.NewReadinessChecker(h.HealthTargets(), cfg.Health.Timeout, cfg.Health.CacheTTL)
+	router.GET("/healthz", health.LivenessHandler)
+	router.GET("/readyz", readiness.ReadinessHandler)
+
+	// Swagger UI
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Public endpoints
+	router.POST("/api/v1/auth/register", h.RegisterUser)
+	router.POST("/api/v1/auth/login", h.Login)
+	router.POST("/api/v1/auth/refresh", h.RefreshToken)
+
+	// Goods endpoints (публичные - доступны всем)
+	router.GET("/api/v1/goods", h.ListGoods)
+	router.GET("/api/v1/goods/search", h.SearchGoods)
+	router.GET("/api/v1/goods/:id", h.GetGood)
+	router.GET("/api/v1/categories", h.ListCategories)
+	router.GET("/api/v1/categories/:slug", h.GetCategory)
+
+	// Admin endpoints (требуют аутентификацию и соответствующее право)
+	admin := router.Group("/api/v1/admin")
+	admin.Use(authMiddleware)
+	{
+		// Товары (склад)
+		admin.POST("/goods", middleware.RequirePermission(rbac.PermGoodsWrite), h.CreateGood)
+		admin.PUT("/goods/:id", middleware.RequirePermission(rbac.PermGoodsWrite), h.UpdateGood)
+		admin.DELETE("/goods/:id", middleware.RequirePermission(rbac.PermGoodsWrite), h.DeleteGood)
+		admin.GET("/goods/archived", middleware.RequirePermission(rbac.PermGoodsWrite), h.ListArchivedGoods)
+		admin.POST("/goods/:id/restore", middleware.RequirePermission(rbac.PermGoodsWrite), h.RestoreGood)
+		admin.PUT("/goods/:id/categories", middleware.RequirePermission(rbac.PermGoodsWrite), h.SetGoodCategories)
+		admin.POST("/goods/:id/variants", middleware.RequirePermission(rbac.PermGoodsWrite), h.CreateVariant)
+		admin.PUT("/variants/:id", middleware.RequirePermission(rbac.PermGoodsWrite), h.UpdateVariant)
+		admin.DELETE("/variants/:id", middleware.RequirePermission(rbac.PermGoodsWrite), h.DeleteVariant)
+		admin.POST("/goods/:id/images", middleware.RequirePermission(rbac.PermGoodsWrite), h.UploadGoodImage)
+		admin.PUT("/goods/:id/images/order", middleware.RequirePermission(rbac.PermGoodsWrite), h.ReorderGoodImages)
+		admin.DELETE("/images/:id", middleware.RequirePermission(rbac.PermGoodsWrite), h.DeleteGoodImage)
+
+		// Импорт и выгрузка каталога таблицами
+		admin.POST("/catalog/import", middleware.RequirePermission(rbac.PermGoodsWrite), h.ImportCatalog)
+		admin.GET("/catalog/import/:id", middleware.RequirePermission(rbac.PermGoodsWrite), h.GetImportJob)
+		admin.GET("/catalog/export", middleware.RequirePermission(rbac.PermGoodsRead), h.ExportCatalog)
+
+		// Складской журнал
+		admin.POST("/goods/:id/stock/adjustments", middleware.RequirePermission(rbac.PermStockWrite), h.AdjustStock)
+		admin.GET("/goods/:id/stock/movements", middleware.RequirePermission(rbac.PermStockWrite), h.ListStockMovements)
+		admin.GET("/inventory/discrepancies", middleware.RequirePermission(rbac.PermStockWrite), h.VerifyStock)
+
+		// Склады
+		admin.GET("/warehouses", middleware.RequirePermission(rbac.PermStockWrite), h.ListWarehouses)
+		admin.POST("/warehouses", middleware.RequirePermission(rbac.PermStockWrite), h.CreateWarehouse)
+		admin.PUT("/warehouses/:id", middleware.RequirePermission(rbac.PermStockWrite), h.UpdateWarehouse)
+
+		// Категории каталога
+		admin.POST("/categories", middleware.RequirePermission(rbac.PermGoodsWrite), h.CreateCategory)
+		admin.PUT("/categories/:id", middleware.RequirePermission(rbac.PermGoodsWrite), h.UpdateCategory)
+		admin.DELETE("/categories/:id", middleware.RequirePermission(rbac.PermGoodsWrite), h.DeleteCategory)
+
+		// Заказы и возвраты (поддержка)
+		admin.GET("/orders/:id", middleware.RequirePermission(rbac.PermOrdersRead), h.GetOrder)
+		admin.POST("/payments/:id/refund", middleware.RequirePermission(rbac.PermPaymentsRefund), h.RefundPayment)
+
+		// Доставки (без deliveries:assign курьеру доступны только назначенные ему доставки)
+		admin.GET("/deliveries", middleware.RequirePermission(rbac.PermDeliveriesRead), h.ListDeliveries)
+		admin.PUT("/deliveries/:id/status", middleware.RequirePermission(rbac.PermDeliveriesUpdateStatus), h.UpdateDeliveryStatus)
+		admin.PUT("/deliveries/:id/courier", middleware.RequirePermission(rbac.PermDeliveriesAssign), h.AssignCourier)
+
+		// Роли пользователей
+		admin.GET("/roles", middleware.RequirePermission(rbac.PermUsersManageRoles), h.ListRoles)
+		admin.PUT("/users/:id/role", middleware.RequirePermission(rbac.PermUsersManageRoles), h.AssignRole)
+	}
+
+	// Protected endpoints (требуют аутентификацию)
+	protected := router.Group("/api/v1")
+	protected.Use(authMiddleware)
+	{
+		// Auth endpoints
+		protected.POST("/auth/logout", h.Logout)
+
+		// User endpoints
+		protected.GET("/users/me", h.GetUser)
+
+		// Orders endpoints
+		protected.POST("/orders", h.CreateOrder)
+		protected.GET("/orders/:id", h.GetOrder)
+
+		// Payments endpoints
+		protected.GET("/payments/:id", h.GetPayment)
+
+		// Delivery endpoints
+		protected.POST("/deliveries", h.CreateDelivery)
+		protected.GET("/deliveries/:id", h.GetDelivery)
+	}
+
+	return &Gateway{Handler: router, api: h}, nil
+}
+
+// Close закрывает gRPC соединения с сервисами
+func (g *Gateway) Close() error {
+	return g.api.Close()
+}
@@ -25,112 +25,61 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/che1nov/tea-shop/api-gateway/app"
 	"github.com/che1nov/tea-shop/api-gateway/config"
-	"github.com/che1nov/tea-shop/api-gateway/internal/handler"
-	"github.com/che1nov/tea-shop/api-gateway/internal/middleware"
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	
-	_ "github.com/che1nov/tea-shop/api-gateway/docs"
 )
 
 func main() {
 	// Инициализируем logger
-	logger.Init()
-
-	cfg := config.Load()
-
-	// Инициализируем handler
-	h, err := handler.New(
-		cfg.Services.UsersService,
-		cfg.Services.GoodsService,
-		cfg.Services.OrdersService,
-		cfg.Services.PaymentsService,
-		cfg.Services.DeliveryService,
-	)
+	logger.Init("api-gateway")
+
+	cfg, args, err := config.Load(os.Args[1:])
 	if err != nil {
-		logger.Error("Failed to initialize handler", "error", err)
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	// Инициализируем Gin
-	router := gin.Default()
-
-	// CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+	// Подкоманды: api-gateway config
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
-
-		c.Next()
-	})
-
-	// Swagger UI
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// Public endpoints
-	router.POST("/api/v1/auth/register", h.RegisterUser)
-	router.POST("/api/v1/auth/login", h.Login)
-
-	// Goods endpoints (публичные - доступны всем)
-	router.GET("/api/v1/goods", h.ListGoods)
-	router.GET("/api/v1/goods/:id", h.GetGood)
-	
-	// Admin endpoints (требуют аутентификацию и роль администратора)
-	admin := router.Group("/api/v1/admin")
-	admin.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
-	admin.Use(middleware.AdminMiddleware())
-	{
-		admin.POST("/goods", h.CreateGood)
-		admin.PUT("/goods/:id", h.UpdateGood)
-		admin.DELETE("/goods/:id", h.DeleteGood)
-		
-		// Deliveries endpoints (только для админа)
-		admin.GET("/deliveries", h.ListDeliveries)
-		admin.PUT("/deliveries/:id/status", h.UpdateDeliveryStatus)
+		return
 	}
 
-	// Protected endpoints (требуют аутентификацию)
-	protected := router.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
-	{
-		// User endpoints
-		protected.GET("/users/me", h.GetUser)
-
-		// Orders endpoints
-		protected.POST("/orders", h.CreateOrder)
-		protected.GET("/orders/:id", h.GetOrder)
-
-		// Payments endpoints
-		protected.GET("/payments/:id", h.GetPayment)
+	// Трейсинг: экспортер выбирается через TRACING_EXPORTER
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Error("Failed to initialize tracing", "error", err)
+		panic(err)
+	}
+	defer shutdownTracing(context.Background())
 
-		// Delivery endpoints
-		protected.POST("/deliveries", h.CreateDelivery)
-		protected.GET("/deliveries/:id", h.GetDelivery)
+	// Подключаемся к сервисам и собираем маршруты
+	gateway, err := app.New(cfg)
+	if err != nil {
+		logger.Error("Failed to initialize gateway", "error", err)
+		panic(err)
 	}
 
 	// Запускаем HTTP сервер для метрик Prometheus
-	metricsPort := 9007
 	metricsMux := http.NewServeMux()
 	metricsMux.Handle("/metrics", promhttp.Handler())
+	metricsMux.Handle("/loglevel", logger.LevelHandler())
 	metricsServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", metricsPort),
+		Addr:    fmt.Sprintf(":%d", cfg.Server.MetricsPort),
 		Handler: metricsMux,
 	}
 
 	// Создаем HTTP сервер для API
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler: router,
+		Handler: gateway.Handler,
 	}
 
 	// Канал для получения сигналов ОС
@@ -139,7 +88,7 @@ func main() {
 
 	// Запускаем HTTP сервер для метрик в отдельной горутине
 	go func() {
-		logger.Info("API Gateway metrics server started", "port", metricsPort)
+		logger.Info("API Gateway metrics server started", "port", cfg.Server.MetricsPort)
 		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Error("Metrics server error", "error", err)
 		}
@@ -172,9 +121,19 @@ func main() {
 	}
 
 	// Закрываем gRPC соединения
-	if err := h.Close(); err != nil {
+	if err := gateway.Close(); err != nil {
 		logger.Error("Error closing gRPC connections", "error", err)
 	}
 
 	logger.Info("API Gateway stopped")
 }
+
+// runCommand выполняет подкоманду вместо запуска сервера
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "config":
+		return sharedconfig.Print(os.Stdout, cfg)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], sharedconfig.Usage)
+	}
+}
@@ -1,40 +1,87 @@
 package config
 
-import "os"
+import (
+	"time"
+
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/grpcclient"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
 
 type Config struct {
-	Server struct {
-		Port int
-	}
+	sharedconfig.App `yaml:",inline"`
+	Server           struct {
+		Port int `yaml:"port" env:"SERVER_PORT" validate:"port"`
+		// Порт HTTP сервера метрик (/metrics, /loglevel)
+		MetricsPort int `yaml:"metrics_port" env:"METRICS_PORT" validate:"port"`
+	} `yaml:"server"`
 	Services struct {
-		UsersService    string
-		GoodsService    string
-		OrdersService   string
-		PaymentsService string
-		DeliveryService string
-	}
-	JWT struct {
-		Secret string
-	}
+		UsersService    string `yaml:"users" env:"USERS_SERVICE_ADDR" validate:"required,hostport"`
+		GoodsService    string `yaml:"goods" env:"GOODS_SERVICE_ADDR" validate:"required,hostport"`
+		OrdersService   string `yaml:"orders" env:"ORDERS_SERVICE_ADDR" validate:"required,hostport"`
+		PaymentsService string `yaml:"payments" env:"PAYMENTS_SERVICE_ADDR" validate:"required,hostport"`
+		DeliveryService string `yaml:"delivery" env:"DELIVERY_SERVICE_ADDR" validate:"required,hostport"`
+	} `yaml:"services"`
+	// Повторы, hedging, circuit breaker и keepalive клиентов сервисов
+	Clients grpcclient.Config `yaml:"clients"`
+	JWT     struct {
+		// JWKS users-service с публичными ключами для проверки подписи токенов
+		JWKSURL string `yaml:"jwks_url" env:"JWKS_URL" validate:"required,url"`
+		// Период плановой перезагрузки JWKS (при неизвестном kid перезагружается сразу)
+		JWKSRefreshInterval time.Duration `yaml:"jwks_refresh_interval" env:"JWKS_REFRESH_INTERVAL" validate:"positive"`
+		// Как долго gateway кеширует ответ "токен не отозван"
+		RevocationCacheTTL time.Duration `yaml:"revocation_cache_ttl" env:"REVOCATION_CACHE_TTL"`
+	} `yaml:"jwt"`
+	CORS struct {
+		// Origin фронтенда, которому разрешены запросы из браузера
+		AllowedOrigin string `yaml:"allowed_origin" env:"CORS_ALLOWED_ORIGIN" validate:"required,url"`
+	} `yaml:"cors"`
+	Health struct {
+		// Таймаут проверки одного downstream сервиса
+		Timeout time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT" validate:"positive"`
+		// Как долго кешируется результат /readyz
+		CacheTTL time.Duration `yaml:"cache_ttl" env:"HEALTH_CACHE_TTL"`
+	} `yaml:"health"`
+	// Трейсинг OpenTelemetry
+	Tracing tracing.Config `yaml:"tracing"`
 }
 
-func Load() *Config {
+// Default возвращает конфигурацию со значениями по умолчанию
+func Default() *Config {
 	cfg := &Config{}
 
+	cfg.Env = sharedconfig.EnvDevelopment
 	cfg.Server.Port = 8080
+	cfg.Server.MetricsPort = 9007
 	cfg.Services.UsersService = "localhost:8001"
 	cfg.Services.GoodsService = "localhost:8002"
 	cfg.Services.OrdersService = "localhost:8003"
 	cfg.Services.PaymentsService = "localhost:8004"
 	cfg.Services.DeliveryService = "localhost:8005"
-	cfg.JWT.Secret = getEnv("JWT_SECRET", "your-secret-key-change-in-production")
-
+	cfg.Clients = grpcclient.DefaultConfig()
+	cfg.JWT.JWKSURL = "http://localhost:9001/.well-known/jwks.json"
+	cfg.JWT.JWKSRefreshInterval = 10 * time.Minute
+	cfg.JWT.RevocationCacheTTL = 5 * time.Second
+	cfg.CORS.AllowedOrigin = "http://localhost:5173"
+	cfg.Health.Timeout = 2 * time.Second
+	cfg.Health.CacheTTL = 5 * time.Second
+	cfg.Tracing = tracing.Config{
+		ServiceName: "api-gateway",
+		Exporter:    tracing.ExporterNone,
+		Endpoint:    "localhost:4317",
+		FilePath:    "traces.json",
+	}
 	return cfg
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Load собирает конфигурацию: значения по умолчанию → YAML файл (--config или CONFIG_FILE) →
+// переменные окружения → флаги. Возвращает аргументы после флагов (подкоманду)
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	args, err := sharedconfig.Load(cfg, args)
+	if err != nil {
+		return nil, nil, err
 	}
-	return defaultValue
+	return cfg, args, nil
 }
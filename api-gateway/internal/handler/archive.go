@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// RestoreGood возвращает товар из архива
+// @Summary      Восстановить товар
+// @Description  Возвращает архивный товар в каталог и поиск вместе с вариантами, остатками и изображениями. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int     true  "ID товара"
+// @Success      200  {object}  object  "Товар в каталоге"
+// @Failure      400  {object}  object  "Неверный ID"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404  {object}  object  "Товар не найден"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/goods/{id}/restore [post]
+func (h *APIHandler) RestoreGood(c *gin.Context) {
+	goodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid good id"})
+		return
+	}
+
+	good, err := h.goodsClient.RestoreGood(c.Request.Context(), &pb.RestoreGoodRequest{GoodId: goodID})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, good)
+}
+
+// ListArchivedGoods возвращает архивные товары
+// @Summary      Архив товаров
+// @Description  Возвращает товары, убранные в архив, с пагинацией, в порядке добавления. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        limit   query     int     false  "Количество товаров"  default(10)
+// @Param        offset  query     int     false  "Смещение"  default(0)
+// @Success      200     {object}  object  "Архивные товары"
+// @Failure      401     {object}  object  "Не авторизован"
+// @Failure      403     {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      500     {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/goods/archived [get]
+func (h *APIHandler) ListArchivedGoods(c *gin.Context) {
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 32)
+	offset, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 32)
+
+	goods, err := h.goodsClient.ListGoods(c.Request.Context(), &pb.ListGoodsRequest{
+		Limit:    int32(limit),
+		Offset:   int32(offset),
+		Archived: true,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, goods)
+}
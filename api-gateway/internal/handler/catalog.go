@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+const (
+	// maxCatalogUpload - наибольший размер файла импорта, как в goods-service
+	maxCatalogUpload       = 10 << 20
+	catalogFormField       = "file"
+	catalogTooLargeMessage = "catalog file must not exceed 10 MB"
+	// maxCatalogExport - наибольший размер выгрузки каталога в ответе goods-service
+	maxCatalogExport = 256 << 20
+)
+
+// ImportCatalog загружает таблицу каталога
+// @Summary      Импорт каталога
+// @Description  Загружает таблицу CSV или XLSX до 10 МБ в поле file формы multipart/form-data; формат определяется по расширению файла или параметру format. Строка таблицы - вариант товара, столбцы: sku (обязательный), good_sku, name, description, categories (слаги через «;»), attributes (code=value через «;»), price, stock, variant_attributes. Строки сопоставляются по sku: найденный вариант изменяется, новый добавляется товару good_sku (по умолчанию - с артикулом sku) или создается вместе с товаром. Пустая ячейка оставляет значение без изменений; stock - общий остаток: разница с остатком на момент обработки строки проводится корректировкой на складе по умолчанию, поэтому резервы заказов не затираются; столбцы stock_<код склада> из выгрузки пропускаются. Строки обрабатываются в фоне: ответ содержит задание, прогресс и ошибки строк - GET /admin/catalog/import/{id}. dry_run=true только проверяет строки. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file     formData  file    true   "Таблица каталога"
+// @Param        format   query     string  false  "Формат файла"  Enums(csv, xlsx)
+// @Param        dry_run  query     bool    false  "Только проверить строки"
+// @Success      202      {object}  object  "Задание импорта запущено"
+// @Failure      400      {object}  object  "Нет файла, неизвестный формат или ошибка в заголовке таблицы"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      413      {object}  object  "Файл больше 10 МБ"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/catalog/import [post]
+func (h *APIHandler) ImportCatalog(c *gin.Context) {
+	var dryRun bool
+	if value := c.Query("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dry_run"})
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCatalogUpload+1<<20)
+	header, err := c.FormFile(catalogFormField)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": catalogTooLargeMessage})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "catalog file is required in form field " + catalogFormField})
+		return
+	}
+	if header.Size > maxCatalogUpload {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": catalogTooLargeMessage})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = catalogFormat(header.Filename)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.goodsClient.ImportCatalog(c.Request.Context(), &pb.ImportCatalogRequest{
+		Data:   data,
+		Format: format,
+		DryRun: dryRun,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetImportJob возвращает задание импорта
+// @Summary      Статус импорта каталога
+// @Description  Возвращает задание импорта: status (running, completed, failed), прогресс processed_rows из total_rows, сколько строк создали (created) и изменили (updated) варианты, сколько строк с ошибками (failed) и первые 500 ошибок с номером строки, столбцом и артикулом. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int     true  "ID задания"
+// @Success      200  {object}  object  "Задание импорта"
+// @Failure      400  {object}  object  "Неверный ID"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404  {object}  object  "Задание не найдено"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/catalog/import/{id} [get]
+func (h *APIHandler) GetImportJob(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import job id"})
+		return
+	}
+
+	job, err := h.goodsClient.GetImportJob(c.Request.Context(), &pb.GetImportJobRequest{Id: jobID})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ExportCatalog выгружает каталог файлом
+// @Summary      Выгрузка каталога
+// @Description  Выгружает весь каталог таблицей CSV (UTF-8 с BOM, разделитель «;») или XLSX: строка на вариант товара с ценой, общим остатком и остатками по складам в столбцах stock_<код склада>. Архивные товары не выгружаются. Выгрузку можно изменить и загрузить обратно через импорт. Требует право goods:read.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      octet-stream
+// @Param        format  query     string  false  "Формат файла, по умолчанию csv"  Enums(csv, xlsx)
+// @Success      200     {file}    file    "Файл каталога"
+// @Failure      400     {object}  object  "Неизвестный формат"
+// @Failure      401     {object}  object  "Не авторизован"
+// @Failure      403     {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      500     {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/catalog/export [get]
+func (h *APIHandler) ExportCatalog(c *gin.Context) {
+	export, err := h.goodsClient.ExportCatalog(
+		c.Request.Context(),
+		&pb.ExportCatalogRequest{Format: c.Query("format")},
+		grpc.MaxCallRecvMsgSize(maxCatalogExport),
+	)
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+export.Filename+`"`)
+	c.Data(http.StatusOK, export.ContentType, export.Data)
+}
+
+// catalogFormat определяет формат таблицы по расширению файла; неизвестное расширение отклонит goods-service
+func catalogFormat(filename string) string {
+	filename = strings.ToLower(filename)
+	if i := strings.LastIndex(filename, "."); i >= 0 {
+		return filename[i+1:]
+	}
+	return ""
+}
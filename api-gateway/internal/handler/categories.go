@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// categoryRequest - тело создания и изменения категории
+type categoryRequest struct {
+	ParentID    int64  `json:"parent_id" binding:"min=0"`
+	Slug        string `json:"slug" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// Attributes - схема атрибутов товаров категории
+	Attributes []*pb.AttributeDefinition `json:"attributes"`
+}
+
+// ListCategories возвращает дерево категорий
+// @Summary      Получить категории
+// @Description  Возвращает все категории каталога списком: родитель идет перед подкатегориями, parent_id = 0 у корневых
+// @Tags         Categories
+// @Produce      json
+// @Success      200  {object}  object  "Список категорий"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /categories [get]
+func (h *APIHandler) ListCategories(c *gin.Context) {
+	response, err := h.goodsClient.ListCategories(c.Request.Context(), &pb.ListCategoriesRequest{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCategory возвращает категорию по slug
+// @Summary      Получить категорию
+// @Description  Возвращает категорию по slug. Товары категории - GET /goods?category_id=ID
+// @Tags         Categories
+// @Produce      json
+// @Param        slug  path      string  true  "Slug категории"
+// @Success      200   {object}  object  "Категория"
+// @Failure      404   {object}  object  "Категория не найдена"
+// @Failure      500   {object}  object  "Внутренняя ошибка сервера"
+// @Router       /categories/{slug} [get]
+func (h *APIHandler) GetCategory(c *gin.Context) {
+	category, err := h.goodsClient.GetCategory(c.Request.Context(), &pb.GetCategoryRequest{
+		Slug: c.Param("slug"),
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// CreateCategory создает категорию
+// @Summary      Создать категорию
+// @Description  Создает категорию каталога. Slug - латиница в нижнем регистре, цифры и дефисы. attributes - схема атрибутов товаров категории (type: string, number, boolean, enum; unit - только у number, allowed_values - только у enum), подкатегории наследуют её. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "Категория"  example({"parent_id":1,"slug":"green","name":"Зеленый чай","description":"Неферментированные чаи","attributes":[{"code":"brew_temp","name":"Температура заваривания","type":"number","unit":"°C","required":true},{"code":"caffeine","name":"Кофеин","type":"enum","allowed_values":["low","medium","high"]}]})
+// @Success      201      {object}  object  "Категория создана"
+// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      409      {object}  object  "Slug уже занят"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/categories [post]
+func (h *APIHandler) CreateCategory(c *gin.Context) {
+	var req categoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.goodsClient.CreateCategory(c.Request.Context(), &pb.CreateCategoryRequest{
+		ParentId:    req.ParentID,
+		Slug:        req.Slug,
+		Name:        req.Name,
+		Description: req.Description,
+		Attributes:  req.Attributes,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory изменяет категорию
+// @Summary      Изменить категорию
+// @Description  Заменяет все поля категории, включая схему атрибутов; атрибуты уже заведенных товаров не перепроверяются. Смена parent_id переносит категорию вместе с подкатегориями; нельзя перенести категорию в её же поддерево. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID категории"
+// @Param        request  body      object  true  "Категория"  example({"parent_id":1,"slug":"green","name":"Зеленый чай","description":""})
+// @Success      200      {object}  object  "Категория изменена"
+// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Категория не найдена"
+// @Failure      409      {object}  object  "Slug уже занят"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/categories/{id} [put]
+func (h *APIHandler) UpdateCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	var req categoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.goodsClient.UpdateCategory(c.Request.Context(), &pb.UpdateCategoryRequest{
+		Id:          categoryID,
+		ParentId:    req.ParentID,
+		Slug:        req.Slug,
+		Name:        req.Name,
+		Description: req.Description,
+		Attributes:  req.Attributes,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory удаляет категорию
+// @Summary      Удалить категорию
+// @Description  Удаляет категорию без подкатегорий. Товары остаются в каталоге. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "ID категории"
+// @Success      200  {object}  object  "Категория удалена"
+// @Failure      400  {object}  object  "Некорректный ID"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404  {object}  object  "Категория не найдена"
+// @Failure      409  {object}  object  "У категории есть подкатегории"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/categories/{id} [delete]
+func (h *APIHandler) DeleteCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	response, err := h.goodsClient.DeleteCategory(c.Request.Context(), &pb.DeleteCategoryRequest{
+		CategoryId: categoryID,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetGoodCategories задает категории товара
+// @Summary      Задать категории товара
+// @Description  Заменяет категории товара переданным списком; пустой список убирает товар из всех категорий. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID товара"
+// @Param        request  body      object  true  "Категории"  example({"category_ids":[2,5]})
+// @Success      200      {object}  object  "Товар с новыми категориями"
+// @Failure      400      {object}  object  "Ошибка валидации или неизвестная категория"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Товар не найден"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/goods/{id}/categories [put]
+func (h *APIHandler) SetGoodCategories(c *gin.Context) {
+	goodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid good id"})
+		return
+	}
+
+	var req struct {
+		CategoryIDs []int64 `json:"category_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	good, err := h.goodsClient.SetGoodCategories(c.Request.Context(), &pb.SetGoodCategoriesRequest{
+		GoodId:      goodID,
+		CategoryIds: req.CategoryIDs,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, good)
+}
+
+// writeCatalogError переводит gRPC статус goods-service в HTTP ответ: 400, 404, 409 или 500
+func writeCatalogError(c *gin.Context, err error) {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": status.Convert(err).Message()})
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		c.JSON(http.StatusConflict, gin.H{"error": status.Convert(err).Message()})
+	case codes.ResourceExhausted:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": status.Convert(err).Message()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -3,14 +3,22 @@ package handler
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/che1nov/tea-shop/api-gateway/internal/health"
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/grpcclient"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
 )
 
 type APIHandler struct {
@@ -34,39 +42,44 @@ func New(
 	ordersService string,
 	paymentsService string,
 	deliveryService string,
+	clientCfg grpcclient.Config,
+	extraDialOptions ...grpc.DialOption,
 ) (*APIHandler, error) {
-	usersConn, err := grpc.Dial(usersService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	var conns []*grpc.ClientConn
+	dial := func(name, target string) (*grpc.ClientConn, error) {
+		conn, err := grpcclient.Dial(name, target, clientCfg, extraDialOptions...)
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, err
+		}
+		conns = append(conns, conn)
+		return conn, nil
+	}
+
+	usersConn, err := dial("users-service", usersService)
 	if err != nil {
 		return nil, err
 	}
 
-	goodsConn, err := grpc.Dial(goodsService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	goodsConn, err := dial("goods-service", goodsService)
 	if err != nil {
-		usersConn.Close()
 		return nil, err
 	}
 
-	ordersConn, err := grpc.Dial(ordersService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	ordersConn, err := dial("order-service", ordersService)
 	if err != nil {
-		usersConn.Close()
-		goodsConn.Close()
 		return nil, err
 	}
 
-	paymentsConn, err := grpc.Dial(paymentsService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	paymentsConn, err := dial("payment-service", paymentsService)
 	if err != nil {
-		usersConn.Close()
-		goodsConn.Close()
-		ordersConn.Close()
 		return nil, err
 	}
 
-	deliveryConn, err := grpc.Dial(deliveryService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	deliveryConn, err := dial("delivery-service", deliveryService)
 	if err != nil {
-		usersConn.Close()
-		goodsConn.Close()
-		ordersConn.Close()
-		paymentsConn.Close()
 		return nil, err
 	}
 
@@ -84,6 +97,17 @@ func New(
 	}, nil
 }
 
+// HealthTargets возвращает соединения с downstream сервисами для проверки готовности
+func (h *APIHandler) HealthTargets() []health.Target {
+	return []health.Target{
+		{Name: "users-service", Service: "pb.UsersService", Conn: h.usersConn},
+		{Name: "goods-service", Service: "pb.GoodsService", Conn: h.goodsConn},
+		{Name: "orders-service", Service: "pb.OrdersService", Conn: h.ordersConn},
+		{Name: "payments-service", Service: "pb.PaymentsService", Conn: h.paymentsConn},
+		{Name: "delivery-service", Service: "pb.DeliveryService", Conn: h.deliveryConn},
+	}
+}
+
 // Close закрывает все gRPC соединения
 func (h *APIHandler) Close() error {
 	var errs []error
@@ -148,7 +172,7 @@ func (h *APIHandler) RegisterUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.usersClient.CreateUser(context.Background(), &pb.CreateUserRequest{
+	user, err := h.usersClient.CreateUser(c.Request.Context(), &pb.CreateUserRequest{
 		Email:    req.Email,
 		Name:     req.Name,
 		Password: req.Password,
@@ -183,7 +207,7 @@ func (h *APIHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.usersClient.Login(context.Background(), &pb.LoginRequest{
+	response, err := h.usersClient.Login(c.Request.Context(), &pb.LoginRequest{
 		Email:    req.Email,
 		Password: req.Password,
 	})
@@ -195,6 +219,98 @@ func (h *APIHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// RefreshToken обменивает refresh токен на новую пару токенов
+// @Summary      Обновление токенов
+// @Description  Обменивает refresh токен на новый access токен и новый refresh токен (ротация). Повторное использование старого refresh токена отзывает всю цепочку.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "Refresh токен"  example({"refresh_token":"..."})
+// @Success      200      {object}  object  "Новая пара токенов"
+// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      401      {object}  object  "Refresh токен недействителен, истёк или отозван"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /auth/refresh [post]
+func (h *APIHandler) RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.usersClient.RefreshToken(c.Request.Context(), &pb.RefreshTokenRequest{
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout завершает сессию пользователя
+// @Summary      Выход из системы
+// @Description  Отзывает текущий access токен и, если передан, всю цепочку refresh токена
+// @Tags         Auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  false  "Refresh токен"  example({"refresh_token":"..."})
+// @Success      200      {object}  object  "Сессия завершена"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /auth/logout [post]
+func (h *APIHandler) Logout(c *gin.Context) {
+	accessToken, exists := c.Get("access_token")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Тело запроса необязательно
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	response, err := h.usersClient.Logout(c.Request.Context(), &pb.LogoutRequest{
+		AccessToken:  accessToken.(string),
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// IsTokenRevoked проверяет jti access токена по denylist в users-service (используется AuthMiddleware)
+func (h *APIHandler) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	response, err := h.usersClient.CheckTokenRevoked(ctx, &pb.CheckTokenRevokedRequest{Jti: jti})
+	if err != nil {
+		return false, err
+	}
+	return response.Revoked, nil
+}
+
 // GetUser возвращает информацию о текущем пользователе
 // @Summary      Получить информацию о текущем пользователе
 // @Description  Возвращает информацию о авторизованном пользователе
@@ -212,7 +328,7 @@ func (h *APIHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.usersClient.GetUser(context.Background(), &pb.GetUserRequest{
+	user, err := h.usersClient.GetUser(c.Request.Context(), &pb.GetUserRequest{
 		UserId: userID.(int64),
 	})
 	if err != nil {
@@ -225,24 +341,27 @@ func (h *APIHandler) GetUser(c *gin.Context) {
 
 // CreateGood создает новый товар
 // @Summary      Создать товар
-// @Description  Создает новый товар. Требует роль администратора (role: "admin") в JWT токене.
+// @Description  Создает новый товар. attributes проверяются по схеме атрибутов категорий category_ids и их родителей: обязательные атрибуты должны быть заданы, значения - соответствовать типу. Требует право goods:write.
 // @Tags         Admin
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
-// @Param        request  body      object  true  "Данные товара"  example({"name":"Зеленый чай","description":"Высококачественный зеленый чай","price":299.99,"stock":50})
+// @Param        request  body      object  true  "Данные товара"  example({"name":"Зеленый чай","description":"Высококачественный зеленый чай","price":299.99,"stock":50,"category_ids":[2],"attributes":{"brew_temp":"80","caffeine":"medium"}})
 // @Success      201      {object}  object  "Товар создан"
-// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      400      {object}  object  "Ошибка валидации или атрибутов"
 // @Failure      401      {object}  object  "Не авторизован"
-// @Failure      403      {object}  object  "Доступ запрещен: требуется роль администратора"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      409      {object}  object  "Артикул уже занят"
 // @Failure      500      {object}  object  "Внутренняя ошибка сервера"
 // @Router       /admin/goods [post]
 func (h *APIHandler) CreateGood(c *gin.Context) {
 	var req struct {
-		Name        string  `json:"name" binding:"required"`
-		Description string  `json:"description" binding:"required"`
-		Price       float64 `json:"price" binding:"required,min=0"`
-		Stock       int32   `json:"stock" binding:"required,min=0"`
+		Name        string            `json:"name" binding:"required"`
+		Description string            `json:"description" binding:"required"`
+		Price       float64           `json:"price" binding:"required,min=0"`
+		Stock       int32             `json:"stock" binding:"required,min=0"`
+		CategoryIDs []int64           `json:"category_ids"`
+		Attributes  map[string]string `json:"attributes"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -250,14 +369,16 @@ func (h *APIHandler) CreateGood(c *gin.Context) {
 		return
 	}
 
-	good, err := h.goodsClient.CreateGood(context.Background(), &pb.CreateGoodRequest{
+	good, err := h.goodsClient.CreateGood(c.Request.Context(), &pb.CreateGoodRequest{
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
 		Stock:       req.Stock,
+		CategoryIds: req.CategoryIDs,
+		Attributes:  req.Attributes,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeCatalogError(c, err)
 		return
 	}
 
@@ -266,18 +387,18 @@ func (h *APIHandler) CreateGood(c *gin.Context) {
 
 // UpdateGood обновляет информацию о товаре
 // @Summary      Обновить товар
-// @Description  Обновляет информацию о товаре. Требует роль администратора (role: "admin") в JWT токене.
+// @Description  Обновляет информацию о товаре. Непустой attributes заменяет все атрибуты товара и проверяется по схеме его категорий. Остаток здесь не меняется: для этого есть POST /admin/goods/{id}/stock/adjustments. Требует право goods:write.
 // @Tags         Admin
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        id       path      int     true  "ID товара"
-// @Param        request  body      object  true  "Данные для обновления"  example({"name":"Зеленый чай","description":"Обновленное описание","price":349.99,"stock":60})
+// @Param        request  body      object  true  "Данные для обновления"  example({"name":"Зеленый чай","description":"Обновленное описание","price":349.99,"attributes":{"brew_temp":"75"}})
 // @Success      200      {object}  object  "Товар обновлен"
-// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      400      {object}  object  "Ошибка валидации или атрибутов"
 // @Failure      404      {object}  object  "Товар не найден"
 // @Failure      401      {object}  object  "Не авторизован"
-// @Failure      403      {object}  object  "Доступ запрещен: требуется роль администратора"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
 // @Failure      500      {object}  object  "Внутренняя ошибка сервера"
 // @Router       /admin/goods/{id} [put]
 func (h *APIHandler) UpdateGood(c *gin.Context) {
@@ -285,10 +406,10 @@ func (h *APIHandler) UpdateGood(c *gin.Context) {
 	goodIDInt, _ := strconv.ParseInt(goodID, 10, 64)
 
 	var req struct {
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price"`
-		Stock       int32   `json:"stock"`
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		Price       float64           `json:"price"`
+		Attributes  map[string]string `json:"attributes"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -296,15 +417,15 @@ func (h *APIHandler) UpdateGood(c *gin.Context) {
 		return
 	}
 
-	good, err := h.goodsClient.UpdateGood(context.Background(), &pb.UpdateGoodRequest{
+	good, err := h.goodsClient.UpdateGood(c.Request.Context(), &pb.UpdateGoodRequest{
 		Id:          goodIDInt,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
-		Stock:       req.Stock,
+		Attributes:  req.Attributes,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeCatalogError(c, err)
 		return
 	}
 
@@ -316,18 +437,19 @@ func (h *APIHandler) UpdateGood(c *gin.Context) {
 	c.JSON(http.StatusOK, good)
 }
 
-// DeleteGood удаляет товар
+// DeleteGood убирает товар в архив
 // @Summary      Удалить товар
-// @Description  Удаляет товар из каталога. Требует роль администратора (role: "admin") в JWT токене.
+// @Description  Убирает товар в архив: он пропадает из каталога и поиска, но по GET /goods/{id} остается доступен для истории заказов (с archived_at). Товар с незавершенными резервированиями (заказ не оплачен и не отменен) не архивируется. Вернуть товар в каталог - POST /admin/goods/{id}/restore. Требует право goods:write.
 // @Tags         Admin
 // @Security     BearerAuth
 // @Produce      json
 // @Param        id   path      int  true  "ID товара"
-// @Success      200  {object}  object  "Товар удален"
-// @Failure      400  {object}  object  "Ошибка удаления"
+// @Success      200  {object}  object  "Товар в архиве"
+// @Failure      400  {object}  object  "Неверный ID"
 // @Failure      404  {object}  object  "Товар не найден"
+// @Failure      409  {object}  object  "У товара есть незавершенные резервирования"
 // @Failure      401  {object}  object  "Не авторизован"
-// @Failure      403  {object}  object  "Доступ запрещен: требуется роль администратора"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
 // @Failure      500  {object}  object  "Внутренняя ошибка сервера"
 // @Router       /admin/goods/{id} [delete]
 func (h *APIHandler) DeleteGood(c *gin.Context) {
@@ -338,16 +460,11 @@ func (h *APIHandler) DeleteGood(c *gin.Context) {
 		return
 	}
 
-	response, err := h.goodsClient.DeleteGood(context.Background(), &pb.DeleteGoodRequest{
+	response, err := h.goodsClient.DeleteGood(c.Request.Context(), &pb.DeleteGoodRequest{
 		GoodId: goodIDInt,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	if !response.Success {
-		c.JSON(http.StatusBadRequest, gin.H{"error": response.Message})
+		writeCatalogError(c, err)
 		return
 	}
 
@@ -356,13 +473,21 @@ func (h *APIHandler) DeleteGood(c *gin.Context) {
 
 // ListGoods возвращает список товаров
 // @Summary      Получить список товаров
-// @Description  Возвращает список товаров с пагинацией
+// @Description  Возвращает список товаров с пагинацией, фильтрами и сортировкой. category_id можно передать несколько раз: подходят товары любой из категорий и их подкатегорий. Фильтры по атрибутам: attr.<code>=значение (можно несколько раз, подходит любое), attr.<code>.min и attr.<code>.max - диапазон числового атрибута, например attr.origin=Юньнань&attr.brew_temp.max=90. С facets=true в ответе есть facets - количество товаров по категориям (с подкатегориями), диапазонам цены и наличию; каждый фасет считается без учета собственного фильтра
 // @Tags         Goods
 // @Produce      json
-// @Param        limit   query     int     false  "Количество товаров"  default(10)
-// @Param        offset  query     int     false  "Смещение"  default(0)
-// @Success      200     {object}  object  "Список товаров"
-// @Failure      500     {object}  object  "Внутренняя ошибка сервера"
+// @Param        limit        query     int      false  "Количество товаров"  default(10)
+// @Param        offset       query     int      false  "Смещение"  default(0)
+// @Param        category_id  query     []int    false  "ID категорий"  collectionFormat(multi)
+// @Param        min_price    query     number   false  "Минимальная цена"
+// @Param        max_price    query     number   false  "Максимальная цена"
+// @Param        in_stock     query     bool     false  "Только товары в наличии"
+// @Param        sort         query     string   false  "Сортировка"  Enums(price_asc, price_desc, newest, name, popularity)
+// @Param        facets       query     bool     false  "Посчитать фасеты"
+// @Success      200          {object}  object   "Список товаров"
+// @Failure      400          {object}  object   "Некорректный фильтр или сортировка"
+// @Failure      404          {object}  object   "Категория не найдена"
+// @Failure      500          {object}  object   "Внутренняя ошибка сервера"
 // @Router       /goods [get]
 func (h *APIHandler) ListGoods(c *gin.Context) {
 	limit := c.DefaultQuery("limit", "10")
@@ -371,16 +496,139 @@ func (h *APIHandler) ListGoods(c *gin.Context) {
 	limitInt, _ := strconv.ParseInt(limit, 10, 32)
 	offsetInt, _ := strconv.ParseInt(offset, 10, 32)
 
-	goods, err := h.goodsClient.ListGoods(context.Background(), &pb.ListGoodsRequest{
+	req := &pb.ListGoodsRequest{
+		Limit:  int32(limitInt),
+		Offset: int32(offsetInt),
+		Sort:   c.Query("sort"),
+	}
+
+	for _, value := range c.QueryArray("category_id") {
+		categoryID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || categoryID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+			return
+		}
+		req.CategoryIds = append(req.CategoryIds, categoryID)
+	}
+
+	var err error
+	if value := c.Query("min_price"); value != "" {
+		if req.MinPrice, err = strconv.ParseFloat(value, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid min_price"})
+			return
+		}
+	}
+	if value := c.Query("max_price"); value != "" {
+		if req.MaxPrice, err = strconv.ParseFloat(value, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid max_price"})
+			return
+		}
+	}
+	if value := c.Query("in_stock"); value != "" {
+		if req.InStock, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid in_stock"})
+			return
+		}
+	}
+	if value := c.Query("facets"); value != "" {
+		if req.IncludeFacets, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid facets"})
+			return
+		}
+	}
+	if req.Attributes, err = attributeFilters(c.Request.URL.Query()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	goods, err := h.goodsClient.ListGoods(c.Request.Context(), req)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": status.Convert(err).Message()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, goods)
+}
+
+// attributeFilters разбирает фильтры по атрибутам из параметров attr.<code>, attr.<code>.min и attr.<code>.max
+func attributeFilters(query url.Values) ([]*pb.AttributeFilter, error) {
+	byCode := make(map[string]*pb.AttributeFilter)
+	filter := func(code string) *pb.AttributeFilter {
+		if byCode[code] == nil {
+			byCode[code] = &pb.AttributeFilter{Code: code}
+		}
+		return byCode[code]
+	}
+
+	for key, values := range query {
+		code, ok := strings.CutPrefix(key, "attr.")
+		if !ok {
+			continue
+		}
+		code, bound, _ := strings.Cut(code, ".")
+		switch bound {
+		case "":
+			filter(code).Values = append(filter(code).Values, values...)
+		case "min", "max":
+			number, err := strconv.ParseFloat(values[0], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s", key)
+			}
+			if bound == "min" {
+				filter(code).Min = &number
+			} else {
+				filter(code).Max = &number
+			}
+		default:
+			return nil, fmt.Errorf("unknown attribute filter %s", key)
+		}
+	}
+
+	filters := make([]*pb.AttributeFilter, 0, len(byCode))
+	for _, code := range slices.Sorted(maps.Keys(byCode)) {
+		filters = append(filters, byCode[code])
+	}
+	return filters, nil
+}
+
+// SearchGoods ищет товары
+// @Summary      Поиск товаров
+// @Description  Полнотекстовый поиск по названию и описанию с учетом русской и английской морфологии, результаты упорядочены по релевантности. Поддерживает кавычки для фраз, "or" и "-" для исключения слов. Совпадения в name_highlight и snippet обрамлены <b></b>, остальной текст экранирован. Если точных совпадений нет, возвращаются товары с похожими названиями и fuzzy = true
+// @Tags         Goods
+// @Produce      json
+// @Param        q       query     string  true   "Поисковый запрос"
+// @Param        limit   query     int     false  "Количество товаров"  default(20)
+// @Param        offset  query     int     false  "Смещение"  default(0)
+// @Success      200     {object}  object  "Найденные товары"
+// @Failure      400     {object}  object  "Пустой или слишком длинный запрос"
+// @Failure      500     {object}  object  "Внутренняя ошибка сервера"
+// @Router       /goods/search [get]
+func (h *APIHandler) SearchGoods(c *gin.Context) {
+	limitInt, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 32)
+	offsetInt, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 32)
+
+	result, err := h.goodsClient.SearchGoods(c.Request.Context(), &pb.SearchGoodsRequest{
+		Query:  c.Query("q"),
 		Limit:  int32(limitInt),
 		Offset: int32(offsetInt),
 	})
 	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, goods)
+	c.JSON(http.StatusOK, result)
 }
 
 // GetGood возвращает товар по ID
@@ -397,7 +645,7 @@ func (h *APIHandler) GetGood(c *gin.Context) {
 	goodID := c.Param("id")
 	goodIDInt, _ := strconv.ParseInt(goodID, 10, 64)
 
-	good, err := h.goodsClient.GetGood(context.Background(), &pb.GetGoodRequest{
+	good, err := h.goodsClient.GetGood(c.Request.Context(), &pb.GetGoodRequest{
 		GoodId: goodIDInt,
 	})
 	if err != nil {
@@ -415,14 +663,14 @@ func (h *APIHandler) GetGood(c *gin.Context) {
 
 // CreateOrder создает новый заказ
 // @Summary      Создать заказ
-// @Description  Создает новый заказ для текущего пользователя
+// @Description  Создает новый заказ для текущего пользователя. variant_id - вариант товара (фасовка, цвет); можно не указывать, если у товара один вариант. Цена берется из варианта
 // @Tags         Orders
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
-// @Param        request  body      object  true  "Данные заказа"  example({"items":[{"good_id":1,"quantity":2,"price":299.99}],"address":"г. Москва, ул. Примерная, д. 1, кв. 10"})
+// @Param        request  body      object  true  "Данные заказа"  example({"items":[{"good_id":1,"variant_id":3,"quantity":2}],"address":"г. Москва, ул. Примерная, д. 1, кв. 10"})
 // @Success      201      {object}  object  "Заказ создан"
-// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      400      {object}  object  "Ошибка валидации или вариант не указан"
 // @Failure      401      {object}  object  "Не авторизован"
 // @Failure      500      {object}  object  "Внутренняя ошибка сервера"
 // @Router       /orders [post]
@@ -435,9 +683,10 @@ func (h *APIHandler) CreateOrder(c *gin.Context) {
 
 	var req struct {
 		Items []struct {
-			GoodID   int64   `json:"good_id" binding:"required"`
-			Quantity int32   `json:"quantity" binding:"required"`
-			Price    float64 `json:"price"`
+			GoodID    int64   `json:"good_id" binding:"required"`
+			VariantID int64   `json:"variant_id"`
+			Quantity  int32   `json:"quantity" binding:"required"`
+			Price     float64 `json:"price"`
 		} `json:"items" binding:"required"`
 		Address string `json:"address" binding:"required"`
 	}
@@ -450,18 +699,23 @@ func (h *APIHandler) CreateOrder(c *gin.Context) {
 	items := make([]*pb.OrderItem, len(req.Items))
 	for i, item := range req.Items {
 		items[i] = &pb.OrderItem{
-			GoodId:   item.GoodID,
-			Quantity: item.Quantity,
-			Price:    item.Price,
+			GoodId:    item.GoodID,
+			VariantId: item.VariantID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
 		}
 	}
 
-	order, err := h.ordersClient.CreateOrder(context.Background(), &pb.CreateOrderRequest{
+	order, err := h.ordersClient.CreateOrder(c.Request.Context(), &pb.CreateOrderRequest{
 		UserId:  userID.(int64),
 		Items:   items,
 		Address: req.Address,
 	})
 	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -471,7 +725,7 @@ func (h *APIHandler) CreateOrder(c *gin.Context) {
 
 // GetOrder возвращает заказ по ID
 // @Summary      Получить заказ по ID
-// @Description  Возвращает информацию о заказе
+// @Description  Возвращает информацию о заказе. Без права orders:read доступны только свои заказы, чужой заказ отвечает 404.
 // @Tags         Orders
 // @Security     BearerAuth
 // @Produce      json
@@ -481,11 +735,12 @@ func (h *APIHandler) CreateOrder(c *gin.Context) {
 // @Failure      401  {object}  object  "Не авторизован"
 // @Failure      500  {object}  object  "Внутренняя ошибка сервера"
 // @Router       /orders/{id} [get]
+// @Router       /admin/orders/{id} [get]
 func (h *APIHandler) GetOrder(c *gin.Context) {
 	orderID := c.Param("id")
 	orderIDInt, _ := strconv.ParseInt(orderID, 10, 64)
 
-	order, err := h.ordersClient.GetOrder(context.Background(), &pb.GetOrderRequest{
+	order, err := h.ordersClient.GetOrder(c.Request.Context(), &pb.GetOrderRequest{
 		OrderId: orderIDInt,
 	})
 	if err != nil {
@@ -493,7 +748,7 @@ func (h *APIHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	if order == nil {
+	if order == nil || !canRead(c, rbac.PermOrdersRead, order) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
 		return
 	}
@@ -503,7 +758,7 @@ func (h *APIHandler) GetOrder(c *gin.Context) {
 
 // GetPayment возвращает информацию о платеже
 // @Summary      Получить информацию о платеже
-// @Description  Возвращает информацию о платеже по ID
+// @Description  Возвращает информацию о платеже по ID. Без права orders:read доступны только платежи своих заказов, чужой платеж отвечает 404.
 // @Tags         Payments
 // @Security     BearerAuth
 // @Produce      json
@@ -517,7 +772,7 @@ func (h *APIHandler) GetPayment(c *gin.Context) {
 	paymentID := c.Param("id")
 	paymentIDInt, _ := strconv.ParseInt(paymentID, 10, 64)
 
-	payment, err := h.paymentsClient.GetPayment(context.Background(), &pb.GetPaymentRequest{
+	payment, err := h.paymentsClient.GetPayment(c.Request.Context(), &pb.GetPaymentRequest{
 		PaymentId: paymentIDInt,
 	})
 	if err != nil {
@@ -529,6 +784,53 @@ func (h *APIHandler) GetPayment(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
 		return
 	}
+	owned, err := h.canReadOrderOf(c, rbac.PermOrdersRead, payment.OrderId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !owned {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// RefundPayment возвращает деньги по платежу
+// @Summary      Вернуть платеж
+// @Description  Возвращает деньги по проведенному платежу и переводит его в статус refunded. Требует право payments:refund.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int     true  "ID платежа"
+// @Success      200  {object}  object  "Платеж возвращен"
+// @Failure      400  {object}  object  "Неверный ID"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404  {object}  object  "Платеж не найден"
+// @Failure      409  {object}  object  "Платеж не проведен или уже возвращен"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/payments/{id}/refund [post]
+func (h *APIHandler) RefundPayment(c *gin.Context) {
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
+		return
+	}
+
+	payment, err := h.paymentsClient.RefundPayment(c.Request.Context(), &pb.RefundPaymentRequest{PaymentId: paymentID})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": status.Convert(err).Message()})
+		case codes.FailedPrecondition:
+			c.JSON(http.StatusConflict, gin.H{"error": status.Convert(err).Message()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
 
 	c.JSON(http.StatusOK, payment)
 }
@@ -557,7 +859,7 @@ func (h *APIHandler) CreateDelivery(c *gin.Context) {
 		return
 	}
 
-	delivery, err := h.deliveryClient.CreateDelivery(context.Background(), &pb.CreateDeliveryRequest{
+	delivery, err := h.deliveryClient.CreateDelivery(c.Request.Context(), &pb.CreateDeliveryRequest{
 		OrderId: req.OrderID,
 		Address: req.Address,
 	})
@@ -571,7 +873,7 @@ func (h *APIHandler) CreateDelivery(c *gin.Context) {
 
 // GetDelivery возвращает информацию о доставке
 // @Summary      Получить информацию о доставке
-// @Description  Возвращает информацию о доставке по ID
+// @Description  Возвращает информацию о доставке по ID. Без права deliveries:read доступны только доставки своих заказов, чужая доставка отвечает 404.
 // @Tags         Deliveries
 // @Security     BearerAuth
 // @Produce      json
@@ -585,7 +887,7 @@ func (h *APIHandler) GetDelivery(c *gin.Context) {
 	deliveryID := c.Param("id")
 	deliveryIDInt, _ := strconv.ParseInt(deliveryID, 10, 64)
 
-	delivery, err := h.deliveryClient.GetDelivery(context.Background(), &pb.GetDeliveryRequest{
+	delivery, err := h.deliveryClient.GetDelivery(c.Request.Context(), &pb.GetDeliveryRequest{
 		DeliveryId: deliveryIDInt,
 	})
 	if err != nil {
@@ -597,13 +899,22 @@ func (h *APIHandler) GetDelivery(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
 		return
 	}
+	owned, err := h.canReadOrderOf(c, rbac.PermDeliveriesRead, delivery.OrderId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !owned {
+		c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+		return
+	}
 
 	c.JSON(http.StatusOK, delivery)
 }
 
-// ListDeliveries возвращает список доставок (только для админа)
+// ListDeliveries возвращает список доставок (право deliveries:read)
 // @Summary      Получить список доставок
-// @Description  Возвращает список всех доставок с возможностью фильтрации по статусу. Требует роль администратора.
+// @Description  Возвращает список доставок с возможностью фильтрации по статусу. Требует право deliveries:read; без права deliveries:assign возвращаются только доставки, назначенные текущему курьеру.
 // @Tags         Admin
 // @Security     BearerAuth
 // @Produce      json
@@ -612,7 +923,7 @@ func (h *APIHandler) GetDelivery(c *gin.Context) {
 // @Param        offset  query     int     false  "Смещение для пагинации (по умолчанию: 0)"
 // @Success      200     {object}  object  "Список доставок"
 // @Failure      401     {object}  object  "Не авторизован"
-// @Failure      403     {object}  object  "Доступ запрещен: требуется роль администратора"
+// @Failure      403     {object}  object  "Доступ запрещен: недостаточно прав"
 // @Failure      500     {object}  object  "Внутренняя ошибка сервера"
 // @Router       /admin/deliveries [get]
 func (h *APIHandler) ListDeliveries(c *gin.Context) {
@@ -623,10 +934,11 @@ func (h *APIHandler) ListDeliveries(c *gin.Context) {
 	limit, _ := strconv.ParseInt(limitStr, 10, 32)
 	offset, _ := strconv.ParseInt(offsetStr, 10, 32)
 
-	response, err := h.deliveryClient.ListDeliveries(context.Background(), &pb.ListDeliveriesRequest{
-		Limit:  int32(limit),
-		Offset: int32(offset),
-		Status: status,
+	response, err := h.deliveryClient.ListDeliveries(c.Request.Context(), &pb.ListDeliveriesRequest{
+		Limit:     int32(limit),
+		Offset:    int32(offset),
+		Status:    status,
+		CourierId: courierScope(c),
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -636,9 +948,9 @@ func (h *APIHandler) ListDeliveries(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// UpdateDeliveryStatus обновляет статус доставки (только для админа)
+// UpdateDeliveryStatus обновляет статус доставки (право deliveries:update_status)
 // @Summary      Обновить статус доставки
-// @Description  Обновляет статус доставки. Требует роль администратора.
+// @Description  Обновляет статус доставки. Требует право deliveries:update_status; без права deliveries:assign можно менять статус только назначенных текущему курьеру доставок.
 // @Tags         Admin
 // @Security     BearerAuth
 // @Accept       json
@@ -648,7 +960,8 @@ func (h *APIHandler) ListDeliveries(c *gin.Context) {
 // @Success      200      {object}  object  "Статус доставки обновлен"
 // @Failure      400      {object}  object  "Ошибка валидации"
 // @Failure      401      {object}  object  "Не авторизован"
-// @Failure      403      {object}  object  "Доступ запрещен: требуется роль администратора"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав или доставка назначена другому курьеру"
+// @Failure      404      {object}  object  "Доставка не найдена"
 // @Failure      500      {object}  object  "Внутренняя ошибка сервера"
 // @Router       /admin/deliveries/{id}/status [put]
 func (h *APIHandler) UpdateDeliveryStatus(c *gin.Context) {
@@ -668,14 +981,199 @@ func (h *APIHandler) UpdateDeliveryStatus(c *gin.Context) {
 		return
 	}
 
-	delivery, err := h.deliveryClient.UpdateDeliveryStatus(context.Background(), &pb.UpdateDeliveryStatusRequest{
+	delivery, err := h.deliveryClient.UpdateDeliveryStatus(c.Request.Context(), &pb.UpdateDeliveryStatusRequest{
 		DeliveryId: deliveryIDInt,
 		Status:     req.Status,
+		CourierId:  courierScope(c),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeDeliveryError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, delivery)
 }
+
+// AssignCourier назначает доставку курьеру (право deliveries:assign)
+// @Summary      Назначить курьера
+// @Description  Назначает доставку курьеру; курьер видит и обновляет только назначенные ему доставки. Требует право deliveries:assign.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID доставки"
+// @Param        request  body      object  true  "ID пользователя-курьера"  example({"courier_id":7})
+// @Success      200      {object}  object  "Курьер назначен"
+// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Доставка не найдена"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/deliveries/{id}/courier [put]
+func (h *APIHandler) AssignCourier(c *gin.Context) {
+	deliveryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+
+	var req struct {
+		CourierID int64 `json:"courier_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	delivery, err := h.deliveryClient.AssignCourier(c.Request.Context(), &pb.AssignCourierRequest{
+		DeliveryId: deliveryID,
+		CourierId:  req.CourierID,
+	})
+	if err != nil {
+		writeDeliveryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// courierScope возвращает ID курьера, которым ограничиваются доставки: пользователь без права
+// deliveries:assign работает только с назначенными ему доставками. 0 - без ограничения
+func courierScope(c *gin.Context) int64 {
+	if hasPermission(c, rbac.PermDeliveriesAssign) {
+		return 0
+	}
+	return currentUserID(c)
+}
+
+// canRead сообщает, что текущий пользователь может видеть заказ: он его владелец или у него есть permission
+func canRead(c *gin.Context, permission string, order *pb.Order) bool {
+	return hasPermission(c, permission) || ownsOrder(c, order)
+}
+
+// canReadOrderOf проверяет доступ к платежу или доставке по их заказу. Заказ запрашивается,
+// только если у пользователя нет permission; ненайденный заказ считается чужим
+func (h *APIHandler) canReadOrderOf(c *gin.Context, permission string, orderID int64) (bool, error) {
+	if hasPermission(c, permission) {
+		return true, nil
+	}
+	order, err := h.ordersClient.GetOrder(c.Request.Context(), &pb.GetOrderRequest{OrderId: orderID})
+	if err != nil {
+		return false, err
+	}
+	return order != nil && ownsOrder(c, order), nil
+}
+
+// ownsOrder сообщает, что заказ оформлен текущим пользователем
+func ownsOrder(c *gin.Context, order *pb.Order) bool {
+	userID := currentUserID(c)
+	return userID != 0 && order.UserId == userID
+}
+
+// hasPermission проверяет право из токена текущего пользователя
+func hasPermission(c *gin.Context, permission string) bool {
+	permissions, _ := c.Get("permissions")
+	granted, _ := permissions.([]string)
+	return rbac.HasPermission(granted, permission)
+}
+
+// currentUserID возвращает id пользователя из токена; 0 - пользователь не определен
+func currentUserID(c *gin.Context) int64 {
+	userID, _ := c.Get("user_id")
+	userIDInt, _ := userID.(int64)
+	return userIDInt
+}
+
+// writeDeliveryError переводит gRPC статус delivery-service в HTTP ответ: 400, 403, 404 или 500
+func writeDeliveryError(c *gin.Context, err error) {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+	case codes.PermissionDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": status.Convert(err).Message()})
+	case codes.NotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": status.Convert(err).Message()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListRoles возвращает роли и их права
+// @Summary      Получить список ролей
+// @Description  Возвращает все роли и назначенные им права. Требует право users:manage_roles.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  object  "Список ролей"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/roles [get]
+func (h *APIHandler) ListRoles(c *gin.Context) {
+	response, err := h.usersClient.ListRoles(c.Request.Context(), &pb.ListRolesRequest{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AssignRole назначает пользователю роль
+// @Summary      Назначить роль пользователю
+// @Description  Меняет роль пользователя. Новые права вступают в силу после обновления токена. Требует право users:manage_roles.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID пользователя"
+// @Param        request  body      object  true  "Роль"  example({"role":"courier"})
+// @Success      200      {object}  object  "Роль назначена"
+// @Failure      400      {object}  object  "Ошибка валидации или неизвестная роль"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Пользователь не найден"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/users/{id}/role [put]
+func (h *APIHandler) AssignRole(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// users-service берет автора назначения из токена администратора (устанавливается в AuthMiddleware)
+	accessToken := c.GetString("access_token")
+
+	user, err := h.usersClient.AssignRole(c.Request.Context(), &pb.AssignRoleRequest{
+		UserId:      userID,
+		Role:        req.Role,
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			c.JSON(http.StatusBadRequest, gin.H{"error": status.Convert(err).Message()})
+		case codes.NotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": status.Convert(err).Message()})
+		case codes.Unauthenticated:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": status.Convert(err).Message()})
+		case codes.PermissionDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": status.Convert(err).Message()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
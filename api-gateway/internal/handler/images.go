@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+const (
+	// maxImageUpload - наибольший размер изображения, как в goods-service
+	maxImageUpload = 10 << 20
+	// maxUploadBody - наибольший размер тела запроса: изображение и запас на заголовки multipart
+	maxUploadBody        = maxImageUpload + 1<<20
+	imageFormField       = "image"
+	imageTooLargeMessage = "image must not exceed 10 MB"
+)
+
+// imageOrderRequest - тело изменения порядка галереи
+type imageOrderRequest struct {
+	ImageIDs []int64 `json:"image_ids" binding:"required"`
+}
+
+// UploadGoodImage загружает изображение товара
+// @Summary      Загрузить изображение товара
+// @Description  Загружает JPEG, PNG или GIF до 10 МБ в поле image формы multipart/form-data. Изображение добавляется в конец галереи товара; goods-service сохраняет исходник, копию для карточки (до 800 пикселей) и миниатюру (до 200 пикселей) и возвращает их адреса. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id     path      int   true  "ID товара"
+// @Param        image  formData  file  true  "Изображение"
+// @Success      201    {object}  object  "Изображение загружено"
+// @Failure      400    {object}  object  "Нет файла или файл не изображение"
+// @Failure      401    {object}  object  "Не авторизован"
+// @Failure      403    {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404    {object}  object  "Товар не найден"
+// @Failure      413    {object}  object  "Файл больше 10 МБ"
+// @Failure      500    {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/goods/{id}/images [post]
+func (h *APIHandler) UploadGoodImage(c *gin.Context) {
+	goodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid good id"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBody)
+	header, err := c.FormFile(imageFormField)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": imageTooLargeMessage})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image file is required in form field " + imageFormField})
+		return
+	}
+	if header.Size > maxImageUpload {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": imageTooLargeMessage})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	image, err := h.goodsClient.UploadGoodImage(c.Request.Context(), &pb.UploadGoodImageRequest{
+		GoodId: goodID,
+		Data:   data,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, image)
+}
+
+// DeleteGoodImage удаляет изображение товара
+// @Summary      Удалить изображение товара
+// @Description  Удаляет изображение из галереи товара вместе с файлами в хранилище. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "ID изображения"
+// @Success      200  {object}  object  "Изображение удалено"
+// @Failure      400  {object}  object  "Некорректный ID"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404  {object}  object  "Изображение не найдено"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/images/{id} [delete]
+func (h *APIHandler) DeleteGoodImage(c *gin.Context) {
+	imageID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image id"})
+		return
+	}
+
+	response, err := h.goodsClient.DeleteGoodImage(c.Request.Context(), &pb.DeleteGoodImageRequest{
+		ImageId: imageID,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReorderGoodImages задает порядок галереи товара
+// @Summary      Изменить порядок изображений товара
+// @Description  Расставляет изображения галереи в порядке image_ids; первое становится обложкой товара. Список должен содержать все изображения товара ровно по разу. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID товара"
+// @Param        request  body      object  true  "Порядок изображений"  example({"image_ids":[3,1,2]})
+// @Success      200      {object}  object  "Товар с галереей в новом порядке"
+// @Failure      400      {object}  object  "Список не совпадает с изображениями товара"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Товар не найден"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/goods/{id}/images/order [put]
+func (h *APIHandler) ReorderGoodImages(c *gin.Context) {
+	goodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid good id"})
+		return
+	}
+
+	var req imageOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	good, err := h.goodsClient.ReorderGoodImages(c.Request.Context(), &pb.ReorderGoodImagesRequest{
+		GoodId:   goodID,
+		ImageIds: req.ImageIDs,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, good)
+}
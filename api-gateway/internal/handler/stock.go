@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// stockAdjustmentRequest - тело складского движения
+type stockAdjustmentRequest struct {
+	VariantID   int64  `json:"variant_id"`
+	WarehouseID int64  `json:"warehouse_id"`
+	Type        string `json:"type" binding:"required"`
+	Quantity    int32  `json:"quantity" binding:"required"`
+	Reason      string `json:"reason"`
+	OrderID     int64  `json:"order_id"`
+}
+
+// AdjustStock проводит складское движение по товару
+// @Summary      Провести складское движение
+// @Description  Записывает в складской журнал поступление (receipt), ручную корректировку (adjustment) или возврат (return) и меняет остаток варианта на складе warehouse_id. Поступление и возврат принимают положительное количество, корректировка - любое ненулевое и требует причины. variant_id можно не передавать, если у товара один вариант, warehouse_id - если склад один. Требует право stock:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID товара"
+// @Param        request  body      object  true  "Движение"  example({"type":"adjustment","warehouse_id":1,"quantity":-2,"reason":"брак при приемке"})
+// @Success      201      {object}  object  "Запись журнала с остатком после движения"
+// @Failure      400      {object}  object  "Некорректное движение или не указан вариант или склад"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Товар, вариант или склад не найден"
+// @Failure      409      {object}  object  "Остаток стал бы отрицательным"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/goods/{id}/stock/adjustments [post]
+func (h *APIHandler) AdjustStock(c *gin.Context) {
+	goodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid good id"})
+		return
+	}
+
+	var req stockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movement, err := h.goodsClient.AdjustStock(c.Request.Context(), &pb.AdjustStockRequest{
+		GoodId:      goodID,
+		VariantId:   req.VariantID,
+		Type:        req.Type,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		OrderId:     req.OrderID,
+		WarehouseId: req.WarehouseID,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, movement)
+}
+
+// ListStockMovements возвращает историю складских движений товара
+// @Summary      История складских движений товара
+// @Description  Возвращает записи складского журнала товара, новые первыми: поступления, резервы, снятия резервов, продажи, корректировки и возвраты. balance - остаток варианта после движения. Требует право stock:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id          path      int  true   "ID товара"
+// @Param        variant_id  query     int  false  "Только движения варианта"
+// @Param        limit       query     int  false  "Количество записей"  default(50)
+// @Param        offset      query     int  false  "Смещение"  default(0)
+// @Success      200         {object}  object  "Движения и их общее количество"
+// @Failure      400         {object}  object  "Некорректный ID"
+// @Failure      401         {object}  object  "Не авторизован"
+// @Failure      403         {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404         {object}  object  "Товар или вариант не найден"
+// @Failure      500         {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/goods/{id}/stock/movements [get]
+func (h *APIHandler) ListStockMovements(c *gin.Context) {
+	goodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid good id"})
+		return
+	}
+	variantID, err := strconv.ParseInt(c.DefaultQuery("variant_id", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid variant id"})
+		return
+	}
+	limitInt, _ := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 32)
+	offsetInt, _ := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 32)
+
+	response, err := h.goodsClient.ListStockMovements(c.Request.Context(), &pb.ListStockMovementsRequest{
+		GoodId:    goodID,
+		VariantId: variantID,
+		Limit:     int32(limitInt),
+		Offset:    int32(offsetInt),
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyStock сверяет остатки со складским журналом
+// @Summary      Сверка остатков с журналом
+// @Description  Сравнивает остаток и резерв каждого варианта с суммой движений складского журнала и возвращает расходящиеся варианты. Пустой список означает, что все остатки объяснены журналом. Требует право stock:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  object  "Расхождения"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/inventory/discrepancies [get]
+func (h *APIHandler) VerifyStock(c *gin.Context) {
+	response, err := h.goodsClient.VerifyStock(c.Request.Context(), &pb.VerifyStockRequest{})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// variantRequest - тело создания и изменения варианта товара
+type variantRequest struct {
+	SKU        string            `json:"sku"`
+	Price      float64           `json:"price" binding:"required,gt=0"`
+	Stock      int32             `json:"stock" binding:"min=0"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// CreateVariant добавляет вариант товару
+// @Summary      Добавить вариант товара
+// @Description  Добавляет товару вариант (фасовку, цвет) со своим артикулом, ценой и остатком. Без sku артикул генерируется из артикула товара. Цена товара становится минимальной ценой вариантов, остаток - суммой остатков. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID товара"
+// @Param        request  body      object  true  "Вариант"  example({"sku":"SENCHA-250","price":1100,"stock":20,"attributes":{"weight":"250 г"}})
+// @Success      201      {object}  object  "Вариант создан"
+// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Товар не найден"
+// @Failure      409      {object}  object  "Артикул уже занят"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/goods/{id}/variants [post]
+func (h *APIHandler) CreateVariant(c *gin.Context) {
+	goodID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid good id"})
+		return
+	}
+
+	var req variantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := h.goodsClient.CreateVariant(c.Request.Context(), &pb.CreateVariantRequest{
+		GoodId:     goodID,
+		Sku:        req.SKU,
+		Price:      req.Price,
+		Stock:      req.Stock,
+		Attributes: req.Attributes,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+// UpdateVariant изменяет вариант товара
+// @Summary      Изменить вариант товара
+// @Description  Заменяет цену, остаток и атрибуты варианта; без sku артикул не меняется. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID варианта"
+// @Param        request  body      object  true  "Вариант"  example({"price":1050,"stock":15,"attributes":{"weight":"250 г"}})
+// @Success      200      {object}  object  "Вариант изменен"
+// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Вариант не найден"
+// @Failure      409      {object}  object  "Артикул уже занят"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/variants/{id} [put]
+func (h *APIHandler) UpdateVariant(c *gin.Context) {
+	variantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid variant id"})
+		return
+	}
+
+	var req variantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := h.goodsClient.UpdateVariant(c.Request.Context(), &pb.UpdateVariantRequest{
+		Id:         variantID,
+		Sku:        req.SKU,
+		Price:      req.Price,
+		Stock:      req.Stock,
+		Attributes: req.Attributes,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// DeleteVariant удаляет вариант товара
+// @Summary      Удалить вариант товара
+// @Description  Убирает вариант в архив: он пропадает у товара, но остается в складском журнале и истории заказов; артикул остается занят. Последний вариант товара и вариант с незавершенными резервированиями (заказ не оплачен и не отменен) удалить нельзя. Требует право goods:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "ID варианта"
+// @Success      200  {object}  object  "Вариант удален"
+// @Failure      400  {object}  object  "Некорректный ID"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404  {object}  object  "Вариант не найден"
+// @Failure      409  {object}  object  "Последний вариант товара или есть незавершенные резервирования"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/variants/{id} [delete]
+func (h *APIHandler) DeleteVariant(c *gin.Context) {
+	variantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid variant id"})
+		return
+	}
+
+	response, err := h.goodsClient.DeleteVariant(c.Request.Context(), &pb.DeleteVariantRequest{
+		VariantId: variantID,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// warehouseRequest - тело создания и изменения склада; code при изменении не учитывается
+type warehouseRequest struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name" binding:"required"`
+	Address  string   `json:"address"`
+	Regions  []string `json:"regions"`
+	Priority int32    `json:"priority"`
+}
+
+// ListWarehouses возвращает склады
+// @Summary      Список складов
+// @Description  Возвращает склады в порядке приоритета. Первый склад - склад по умолчанию: на него поступает остаток, заданный при создании товара или варианта. Требует право stock:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  object  "Склады"
+// @Failure      401  {object}  object  "Не авторизован"
+// @Failure      403  {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      500  {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/warehouses [get]
+func (h *APIHandler) ListWarehouses(c *gin.Context) {
+	response, err := h.goodsClient.ListWarehouses(c.Request.Context(), &pb.ListWarehousesRequest{})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateWarehouse добавляет склад
+// @Summary      Добавить склад
+// @Description  Добавляет склад с уникальным кодом (латиница в нижнем регистре, цифры и дефисы). regions - города и регионы, которые склад обслуживает в первую очередь: при стратегии nearest они ищутся в адресе доставки. Меньший priority - выше приоритет. Остаток на новый склад поступает складским движением receipt с warehouse_id. Требует право stock:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "Склад"  example({"code":"spb","name":"Склад в Петербурге","address":"Санкт-Петербург, ул. Складская, 1","regions":["Санкт-Петербург","Ленинградская область"],"priority":10})
+// @Success      201      {object}  object  "Склад создан"
+// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      409      {object}  object  "Код склада уже занят"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/warehouses [post]
+func (h *APIHandler) CreateWarehouse(c *gin.Context) {
+	var req warehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse, err := h.goodsClient.CreateWarehouse(c.Request.Context(), &pb.CreateWarehouseRequest{
+		Code:     req.Code,
+		Name:     req.Name,
+		Address:  req.Address,
+		Regions:  req.Regions,
+		Priority: req.Priority,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, warehouse)
+}
+
+// UpdateWarehouse изменяет склад
+// @Summary      Изменить склад
+// @Description  Заменяет название, адрес, регионы и приоритет склада; код склада не меняется. Требует право stock:write.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "ID склада"
+// @Param        request  body      object  true  "Склад"  example({"name":"Склад в Петербурге","regions":["Санкт-Петербург"],"priority":5})
+// @Success      200      {object}  object  "Склад изменен"
+// @Failure      400      {object}  object  "Ошибка валидации"
+// @Failure      401      {object}  object  "Не авторизован"
+// @Failure      403      {object}  object  "Доступ запрещен: недостаточно прав"
+// @Failure      404      {object}  object  "Склад не найден"
+// @Failure      500      {object}  object  "Внутренняя ошибка сервера"
+// @Router       /admin/warehouses/{id} [put]
+func (h *APIHandler) UpdateWarehouse(c *gin.Context) {
+	warehouseID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid warehouse id"})
+		return
+	}
+
+	var req warehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warehouse, err := h.goodsClient.UpdateWarehouse(c.Request.Context(), &pb.UpdateWarehouseRequest{
+		Id:       warehouseID,
+		Name:     req.Name,
+		Address:  req.Address,
+		Regions:  req.Regions,
+		Priority: req.Priority,
+	})
+	if err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
@@ -0,0 +1,139 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	statusOK       = "ok"
+	statusReady    = "ready"
+	statusNotReady = "not_ready"
+)
+
+// Target - downstream сервис, готовность которого проверяет gateway
+type Target struct {
+	// Name - имя зависимости в ответе /readyz
+	Name string
+	// Service - имя gRPC сервиса в протоколе health ("" - сервер целиком)
+	Service string
+	Conn    grpc.ClientConnInterface
+}
+
+// DependencyStatus - результат проверки одной зависимости
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report - агрегированный ответ /readyz
+type Report struct {
+	Status    string                      `json:"status"`
+	CheckedAt time.Time                   `json:"checked_at"`
+	Checks    map[string]DependencyStatus `json:"checks"`
+}
+
+// Ready возвращает true, если все зависимости в статусе SERVING
+func (r *Report) Ready() bool {
+	return r.Status == statusReady
+}
+
+// ReadinessChecker опрашивает downstream сервисы по gRPC health протоколу.
+// Результат кешируется на cacheTTL, чтобы частые пробы не нагружали сервисы.
+type ReadinessChecker struct {
+	targets  []Target
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	cached *Report
+}
+
+func NewReadinessChecker(targets []Target, timeout, cacheTTL time.Duration) *ReadinessChecker {
+	return &ReadinessChecker{
+		targets:  targets,
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Check возвращает закешированный отчет или опрашивает все зависимости параллельно.
+// Отчет кешируется и отдается другим пробам, поэтому отмена ctx вызывающего проверки не прерывает
+func (c *ReadinessChecker) Check(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && time.Since(c.cached.CheckedAt) < c.cacheTTL {
+		return c.cached
+	}
+
+	report := &Report{
+		Status:    statusReady,
+		CheckedAt: time.Now(),
+		Checks:    make(map[string]DependencyStatus, len(c.targets)),
+	}
+
+	results := make([]DependencyStatus, len(c.targets))
+	var wg sync.WaitGroup
+	for i, target := range c.targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			results[i] = c.checkTarget(ctx, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	for i, target := range c.targets {
+		report.Checks[target.Name] = results[i]
+		if results[i].Status != grpc_health_v1.HealthCheckResponse_SERVING.String() {
+			report.Status = statusNotReady
+		}
+	}
+
+	c.cached = report
+	return report
+}
+
+// checkTarget опрашивает зависимость с собственным таймаутом: ошибка отмененного запроса пробы
+// не должна попасть в кеш как неготовность зависимости
+func (c *ReadinessChecker) checkTarget(ctx context.Context, target Target) DependencyStatus {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := grpc_health_v1.NewHealthClient(target.Conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{
+		Service: target.Service,
+	})
+	result := DependencyStatus{LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = grpc_health_v1.HealthCheckResponse_UNKNOWN.String()
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = resp.GetStatus().String()
+	return result
+}
+
+// LivenessHandler отвечает 200, пока процесс gateway жив, не трогая зависимости
+func LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": statusOK})
+}
+
+// ReadinessHandler отвечает 200, если все зависимости готовы, иначе 503
+func (c *ReadinessChecker) ReadinessHandler(ctx *gin.Context) {
+	report := c.Check(ctx.Request.Context())
+	if !report.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
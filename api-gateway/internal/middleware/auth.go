@@ -4,11 +4,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware проверяет подпись access токена по ключам из JWKS users-service и его отзыв
+func AuthMiddleware(keys KeySource, revocation RevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,9 +30,8 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		token, err := jwt.ParseWithClaims(
 			tokenString,
 			jwt.MapClaims{},
-			func(token *jwt.Token) (interface{}, error) {
-				return []byte(jwtSecret), nil
-			},
+			keyfunc(c.Request.Context(), keys),
+			jwt.WithValidMethods(validSigningMethods),
 		)
 
 		if err != nil || !token.Valid {
@@ -46,9 +47,37 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", int64(claims["user_id"].(float64)))
-		c.Set("email", claims["email"].(string))
-		
+		userID, ok := claims["user_id"].(float64)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			c.Abort()
+			return
+		}
+		email, _ := claims["email"].(string)
+
+		// Проверяем jti по denylist отозванных токенов
+		jti, _ := claims["jti"].(string)
+		if jti != "" && revocation != nil {
+			revoked, err := revocation.IsTokenRevoked(c.Request.Context(), jti)
+			if err != nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to check token revocation"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("user_id", int64(userID))
+		// user_id попадает в логи gateway и передается downstream сервисам в metadata
+		c.Request = c.Request.WithContext(correlation.WithUserID(c.Request.Context(), int64(userID)))
+		c.Set("email", email)
+		c.Set("jti", jti)
+		c.Set("access_token", tokenString)
+
 		// Добавляем роль в контекст (если есть в токене)
 		if role, ok := claims["role"].(string); ok {
 			c.Set("role", role)
@@ -57,6 +86,17 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			c.Set("role", "user")
 		}
 
+		// Права роли, по которым RequirePermission проверяет доступ
+		permissions := []string{}
+		if values, ok := claims["permissions"].([]interface{}); ok {
+			for _, v := range values {
+				if permission, ok := v.(string); ok {
+					permissions = append(permissions, permission)
+				}
+			}
+		}
+		c.Set("permissions", permissions)
+
 		c.Next()
 	}
 }
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySource возвращает публичный ключ проверки подписи по kid (реализуется jwks.Cache)
+type KeySource interface {
+	Lookup(ctx context.Context, kid string) (crypto.PublicKey, string, error)
+}
+
+// validSigningMethods - алгоритмы, которыми users-service подписывает токены
+var validSigningMethods = []string{"EdDSA", "RS256"}
+
+var errMissingKeyID = errors.New("token has no kid header")
+
+// keyfunc выбирает ключ из JWKS по kid и проверяет, что алгоритм токена совпадает с алгоритмом ключа
+func keyfunc(ctx context.Context, keys KeySource) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errMissingKeyID
+		}
+
+		publicKey, alg, err := keys.Lookup(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if alg != token.Method.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return publicKey, nil
+	}
+}
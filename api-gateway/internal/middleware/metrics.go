@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RED метрики HTTP: лейбл route - шаблон маршрута gin (/api/v1/orders/:id), а не сырой путь,
+// чтобы число временных рядов не росло с каждым id
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests handled by the gateway, by route and status code.",
+	}, []string{"method", "route", "code"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests handled by the gateway.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method", "route"})
+
+	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being handled by the gateway.",
+	})
+)
+
+// Metrics считает запросы, ошибки и длительность по маршрутам; служебные пути не учитываются
+func Metrics(skip ...string) gin.HandlerFunc {
+	skipped := make(map[string]bool, len(skip))
+	for _, path := range skip {
+		skipped[path] = true
+	}
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if skipped[route] {
+			c.Next()
+			return
+		}
+		if route == "" {
+			// Несуществующий маршрут: не плодим ряды по произвольным путям
+			route = "unmatched"
+		}
+
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		httpRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+		httpRequestsTotal.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission пропускает запрос, только если в токене есть все перечисленные права.
+// Должен подключаться после AuthMiddleware, который кладёт права в контекст
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("permissions")
+		if !exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "permissions not found in token"})
+			c.Abort()
+			return
+		}
+
+		granted, ok := value.([]string)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid permissions type"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !rbac.HasPermission(granted, permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "access denied: missing permission " + permission})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
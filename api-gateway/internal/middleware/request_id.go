@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID принимает X-Request-ID клиента или генерирует новый. Идентификатор возвращается
+// в ответе и кладется в контекст запроса, откуда уходит в логи и gRPC metadata downstream сервисов
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(correlation.HeaderRequestID)
+		if !correlation.ValidRequestID(requestID) {
+			requestID = correlation.NewRequestID()
+		}
+
+		c.Header(correlation.HeaderRequestID, requestID)
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(correlation.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
+
+// AccessLog пишет строку на каждый HTTP запрос; request_id, user_id и trace_id берутся из контекста
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		logger.LogResponse(c.Request.Context(), c.Request.Method+" "+route,
+			float64(time.Since(start).Microseconds())/1000,
+			"status", c.Writer.Status(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
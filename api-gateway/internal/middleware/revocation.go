@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationChecker проверяет, отозван ли access токен с указанным jti
+type RevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type revocationEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// CachedRevocationChecker кеширует ответы RevocationChecker, чтобы не ходить в users-service на каждый запрос.
+// Отозванные токены кешируются надолго (отзыв необратим), неотозванные - на короткое время.
+type CachedRevocationChecker struct {
+	next       RevocationChecker
+	ttl        time.Duration
+	revokedTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]revocationEntry
+}
+
+func NewCachedRevocationChecker(next RevocationChecker, ttl time.Duration) *CachedRevocationChecker {
+	return &CachedRevocationChecker{
+		next:       next,
+		ttl:        ttl,
+		revokedTTL: time.Hour,
+		entries:    make(map[string]revocationEntry),
+	}
+}
+
+func (c *CachedRevocationChecker) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[jti]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := c.next.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := c.ttl
+	if revoked {
+		ttl = c.revokedTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[jti] = revocationEntry{revoked: revoked, expiresAt: now.Add(ttl)}
+	// Периодически вычищаем устаревшие записи, чтобы кеш не рос бесконечно
+	if len(c.entries) > 10000 {
+		for key, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	return revoked, nil
+}
@@ -0,0 +1,22 @@
+// Package app собирает слои delivery-service для запуска внутри другого процесса:
+// e2e тестов и сборок, где сервисы работают в одном бинарнике
+package app
+
+import (
+	"database/sql"
+
+	"github.com/che1nov/tea-shop/delivery-service/internal/handler"
+	"github.com/che1nov/tea-shop/delivery-service/internal/repository"
+	"github.com/che1nov/tea-shop/delivery-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// New собирает gRPC API delivery-service поверх БД
+func New(db *sql.DB) pb.DeliveryServiceServer {
+	return handler.New(service.New(repository.New(db)))
+}
+
+// NewInMemory собирает gRPC API delivery-service поверх репозитория в памяти
+func NewInMemory() pb.DeliveryServiceServer {
+	return handler.New(service.New(repository.NewMemory()))
+}
@@ -1,74 +1,70 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
-
-	_ "github.com/lib/pq"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
-	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/che1nov/tea-shop/delivery-service/config"
 	"github.com/che1nov/tea-shop/delivery-service/internal/handler"
 	"github.com/che1nov/tea-shop/delivery-service/internal/repository"
 	"github.com/che1nov/tea-shop/delivery-service/internal/service"
+	"github.com/che1nov/tea-shop/delivery-service/migrations"
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/health"
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/migrate"
+	"github.com/che1nov/tea-shop/shared/pkg/server"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
 )
 
 func main() {
 	// Инициализируем logger
-	logger.Init()
+	logger.Init("delivery-service")
+
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	cfg := config.Load()
+	// Подкоманды: delivery-service migrate up|down|status, config
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Подключение к БД
-	dbConnStr := fmt.Sprintf(
-		"user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Name,
-		cfg.Database.Host,
-		cfg.Database.Port,
-	)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	db, err := sql.Open("postgres", dbConnStr)
+	// Трейсинг: экспортер выбирается через TRACING_EXPORTER
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
 	if err != nil {
-		logger.Error("Failed to open database", "error", err)
+		logger.Error("Failed to initialize tracing", "error", err)
 		panic(err)
 	}
+	defer shutdownTracing(context.Background())
 
-	// Проверяем подключение к БД
-	if err := db.Ping(); err != nil {
-		logger.Error("Failed to connect to database", "error", err)
-		panic(fmt.Sprintf("failed to connect to database: %v", err))
+	db, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		logger.Error("Failed to initialize database", "error", err)
+		panic(err)
 	}
 	logger.Info("Database connection established")
 
-	// Создаём таблицы
-	createTablesSQL := `
-		CREATE TABLE IF NOT EXISTS deliveries (
-			id SERIAL PRIMARY KEY,
-			order_id INT NOT NULL UNIQUE,
-			address VARCHAR(500) NOT NULL,
-			status VARCHAR(50) NOT NULL,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_deliveries_order ON deliveries(order_id);
-	`
-	if _, err := db.Exec(createTablesSQL); err != nil {
-		panic(err)
+	// Схема БД: версионированные миграции из migrations/
+	if cfg.Database.AutoMigrate {
+		if err := migrate.Apply(ctx, db, migrations.FS); err != nil {
+			logger.Error("Failed to apply migrations", "error", err)
+			panic(err)
+		}
 	}
 
 	// Инициализируем слои
@@ -76,82 +72,27 @@ func main() {
 	svc := service.New(repo)
 	hdlr := handler.New(svc)
 
-	// Запускаем HTTP сервер для метрик Prometheus ПЕРВЫМ
-	metricsPort := 9005
-	metricsMux := http.NewServeMux()
-	metricsMux.Handle("/metrics", promhttp.Handler())
-	metricsServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", metricsPort),
-		Handler: metricsMux,
-	}
-
-	// Канал для получения сигналов ОС
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	// Запускаем HTTP сервер для метрик в отдельной горутине (ПЕРВЫМ)
-	go func() {
-		logger.Info("Delivery Service metrics server starting", "port", metricsPort)
-		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Metrics server error", "error", err)
-		}
-	}()
-
-	// Небольшая задержка для запуска HTTP сервера метрик
-	time.Sleep(100 * time.Millisecond)
-
-	// Запускаем gRPC сервер
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
-	if err != nil {
-		logger.Error("Failed to create gRPC listener", "error", err, "port", cfg.Server.Port)
-		logger.Warn("gRPC server will not start, but metrics server is running")
-		<-sigChan
-		logger.Info("Shutting down Delivery Service...")
-		if err := metricsServer.Close(); err != nil {
-			logger.Error("Error closing metrics server", "error", err)
-		}
-		if err := db.Close(); err != nil {
-			logger.Error("Error closing database", "error", err)
-		}
-		logger.Info("Delivery Service stopped")
-		return
-	}
-
-	grpcServer := grpc.NewServer()
-	pb.RegisterDeliveryServiceServer(grpcServer, hdlr)
+	srv := server.New(server.Config{
+		Name:        "delivery-service",
+		Port:        cfg.Server.Port,
+		MetricsPort: cfg.Server.MetricsPort,
+	}, health.Database(db))
+	srv.OnShutdown("database", db.Close)
+	pb.RegisterDeliveryServiceServer(srv, hdlr)
 
-	// Health check
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("pb.DeliveryService", grpc_health_v1.HealthCheckResponse_SERVING)
-
-	// Enable gRPC reflection for easier testing
-	reflection.Register(grpcServer)
-
-	// Запускаем gRPC сервер в отдельной горутине
-	go func() {
-		logger.Info("Delivery Service gRPC server started", "port", cfg.Server.Port)
-		if err := grpcServer.Serve(listener); err != nil {
-			logger.Error("gRPC server error", "error", err)
-		}
-	}()
-
-	// Ожидаем сигнал для graceful shutdown
-	<-sigChan
-	logger.Info("Shutting down Delivery Service...")
-
-	// Graceful shutdown HTTP сервера метрик
-	if err := metricsServer.Close(); err != nil {
-		logger.Error("Error closing metrics server", "error", err)
+	if err := srv.Run(ctx); err != nil {
+		logger.Error("Delivery Service stopped with error", "error", err)
 	}
+}
 
-	// Graceful shutdown gRPC сервера
-	grpcServer.GracefulStop()
-
-	// Закрываем соединение с БД
-	if err := db.Close(); err != nil {
-		logger.Error("Error closing database", "error", err)
+// runCommand выполняет подкоманду вместо запуска сервера
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "config":
+		return sharedconfig.Print(os.Stdout, cfg)
+	case "migrate":
+		return migrate.RunCommand(context.Background(), cfg.Database, migrations.FS, args[1:], os.Stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s\n%s", args[0], migrate.Usage, sharedconfig.Usage)
 	}
-
-	logger.Info("Delivery Service stopped")
 }
@@ -1,40 +1,52 @@
 package config
 
-import "os"
+import (
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
 
 type Config struct {
-	Database struct {
-		Host     string
-		Port     string
-		User     string
-		Password string
-		Name     string
-	}
-	Server struct {
-		Port int
-	}
+	sharedconfig.App `yaml:",inline"`
+	Database         database.Config `yaml:"database"`
+	Server           struct {
+		Port int `yaml:"port" env:"SERVER_PORT" validate:"port"`
+		// Порт HTTP сервера метрик (/metrics, /loglevel)
+		MetricsPort int `yaml:"metrics_port" env:"METRICS_PORT" validate:"port"`
+	} `yaml:"server"`
 	Services struct {
-		PaymentService string
-	}
+		PaymentService string `yaml:"payments" env:"PAYMENTS_SERVICE_ADDR" validate:"required,hostport"`
+	} `yaml:"services"`
+	// Трейсинг OpenTelemetry
+	Tracing tracing.Config `yaml:"tracing"`
 }
 
-func Load() *Config {
+// Load собирает конфигурацию: значения по умолчанию → YAML файл (--config или CONFIG_FILE) →
+// переменные окружения → флаги. Возвращает аргументы после флагов (подкоманду)
+func Load(args []string) (*Config, []string, error) {
 	cfg := &Config{}
 
-	cfg.Database.Host = getEnv("DB_HOST", "localhost")
-	cfg.Database.Port = getEnv("DB_PORT", "5436")
-	cfg.Database.User = getEnv("DB_USER", "user")
-	cfg.Database.Password = getEnv("DB_PASSWORD", "password")
-	cfg.Database.Name = getEnv("DB_NAME", "deliveries_db")
+	cfg.Env = sharedconfig.EnvDevelopment
+	cfg.Database.Host = "localhost"
+	cfg.Database.Port = "5436"
+	cfg.Database.User = "user"
+	cfg.Database.Password = "password"
+	cfg.Database.Name = "deliveries_db"
+	cfg.Database.AutoMigrate = true
 	cfg.Server.Port = 8005
+	cfg.Server.MetricsPort = 9005
 	cfg.Services.PaymentService = "localhost:8004"
 
-	return cfg
-}
+	cfg.Tracing = tracing.Config{
+		ServiceName: "delivery-service",
+		Exporter:    tracing.ExporterNone,
+		Endpoint:    "localhost:4317",
+		FilePath:    "traces.json",
+	}
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	args, err := sharedconfig.Load(cfg, args)
+	if err != nil {
+		return nil, nil, err
 	}
-	return defaultValue
+	return cfg, args, nil
 }
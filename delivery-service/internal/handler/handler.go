@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -32,20 +33,23 @@ func (h *DeliveryHandler) CreateDelivery(ctx context.Context, req *pb.CreateDeli
 	}
 
 	delivery, err := h.service.CreateDelivery(ctx, &model.CreateDeliveryRequest{
-		OrderID: req.OrderId,
-		Address: req.Address,
+		OrderID:    req.OrderId,
+		Address:    req.Address,
+		Warehouses: req.Warehouses,
 	})
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create delivery: %v", err)
 	}
 
 	return &pb.Delivery{
-		Id:        delivery.ID,
-		OrderId:   delivery.OrderID,
-		Address:   delivery.Address,
-		Status:    delivery.Status,
-		CreatedAt: delivery.CreatedAt.Unix(),
-		UpdatedAt: delivery.UpdatedAt.Unix(),
+		Id:         delivery.ID,
+		OrderId:    delivery.OrderID,
+		Address:    delivery.Address,
+		Status:     delivery.Status,
+		CreatedAt:  delivery.CreatedAt.Unix(),
+		UpdatedAt:  delivery.UpdatedAt.Unix(),
+		Warehouses: delivery.Warehouses,
+		CourierId:  delivery.CourierID,
 	}, nil
 }
 
@@ -64,12 +68,14 @@ func (h *DeliveryHandler) GetDelivery(ctx context.Context, req *pb.GetDeliveryRe
 	}
 
 	return &pb.Delivery{
-		Id:        delivery.ID,
-		OrderId:   delivery.OrderID,
-		Address:   delivery.Address,
-		Status:    delivery.Status,
-		CreatedAt: delivery.CreatedAt.Unix(),
-		UpdatedAt: delivery.UpdatedAt.Unix(),
+		Id:         delivery.ID,
+		OrderId:    delivery.OrderID,
+		Address:    delivery.Address,
+		Status:     delivery.Status,
+		CreatedAt:  delivery.CreatedAt.Unix(),
+		UpdatedAt:  delivery.UpdatedAt.Unix(),
+		Warehouses: delivery.Warehouses,
+		CourierId:  delivery.CourierID,
 	}, nil
 }
 
@@ -81,7 +87,10 @@ func (h *DeliveryHandler) UpdateDeliveryStatus(ctx context.Context, req *pb.Upda
 		return nil, status.Errorf(codes.InvalidArgument, "status is required")
 	}
 
-	delivery, err := h.service.UpdateDeliveryStatus(ctx, req.DeliveryId, req.Status)
+	delivery, err := h.service.UpdateDeliveryStatus(ctx, req.DeliveryId, req.Status, req.CourierId)
+	if errors.Is(err, service.ErrNotAssigned) {
+		return nil, status.Errorf(codes.PermissionDenied, "%v", err)
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to update delivery status: %v", err)
 	}
@@ -91,12 +100,43 @@ func (h *DeliveryHandler) UpdateDeliveryStatus(ctx context.Context, req *pb.Upda
 	}
 
 	return &pb.Delivery{
-		Id:        delivery.ID,
-		OrderId:   delivery.OrderID,
-		Address:   delivery.Address,
-		Status:    delivery.Status,
-		CreatedAt: delivery.CreatedAt.Unix(),
-		UpdatedAt: delivery.UpdatedAt.Unix(),
+		Id:         delivery.ID,
+		OrderId:    delivery.OrderID,
+		Address:    delivery.Address,
+		Status:     delivery.Status,
+		CreatedAt:  delivery.CreatedAt.Unix(),
+		UpdatedAt:  delivery.UpdatedAt.Unix(),
+		Warehouses: delivery.Warehouses,
+		CourierId:  delivery.CourierID,
+	}, nil
+}
+
+func (h *DeliveryHandler) AssignCourier(ctx context.Context, req *pb.AssignCourierRequest) (*pb.Delivery, error) {
+	if req.DeliveryId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "delivery_id is required")
+	}
+	if req.CourierId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "courier_id is required")
+	}
+
+	delivery, err := h.service.AssignCourier(ctx, req.DeliveryId, req.CourierId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to assign courier: %v", err)
+	}
+
+	if delivery == nil {
+		return nil, status.Errorf(codes.NotFound, "delivery with id %d not found", req.DeliveryId)
+	}
+
+	return &pb.Delivery{
+		Id:         delivery.ID,
+		OrderId:    delivery.OrderID,
+		Address:    delivery.Address,
+		Status:     delivery.Status,
+		CreatedAt:  delivery.CreatedAt.Unix(),
+		UpdatedAt:  delivery.UpdatedAt.Unix(),
+		Warehouses: delivery.Warehouses,
+		CourierId:  delivery.CourierID,
 	}, nil
 }
 
@@ -106,7 +146,7 @@ func (h *DeliveryHandler) ListDeliveries(ctx context.Context, req *pb.ListDelive
 		limit = 100
 	}
 
-	deliveries, total, err := h.service.ListDeliveries(ctx, limit, req.Offset, req.Status)
+	deliveries, total, err := h.service.ListDeliveries(ctx, limit, req.Offset, req.Status, req.CourierId)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to list deliveries: %v", err)
 	}
@@ -114,12 +154,14 @@ func (h *DeliveryHandler) ListDeliveries(ctx context.Context, req *pb.ListDelive
 	pbDeliveries := make([]*pb.Delivery, len(deliveries))
 	for i, delivery := range deliveries {
 		pbDeliveries[i] = &pb.Delivery{
-			Id:        delivery.ID,
-			OrderId:   delivery.OrderID,
-			Address:   delivery.Address,
-			Status:    delivery.Status,
-			CreatedAt: delivery.CreatedAt.Unix(),
-			UpdatedAt: delivery.UpdatedAt.Unix(),
+			Id:         delivery.ID,
+			OrderId:    delivery.OrderID,
+			Address:    delivery.Address,
+			Status:     delivery.Status,
+			CreatedAt:  delivery.CreatedAt.Unix(),
+			UpdatedAt:  delivery.UpdatedAt.Unix(),
+			Warehouses: delivery.Warehouses,
+			CourierId:  delivery.CourierID,
 		}
 	}
 
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/che1nov/tea-shop/delivery-service/internal/model"
+	"github.com/che1nov/tea-shop/delivery-service/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	pb "github.com/che1nov/tea-shop/shared/pb"
@@ -42,14 +43,30 @@ func (m *MockDeliveryService) GetDeliveryByOrderID(ctx context.Context, orderID
 	return args.Get(0).(*model.Delivery), args.Error(1)
 }
 
-func (m *MockDeliveryService) UpdateDeliveryStatus(ctx context.Context, id int64, status string) (*model.Delivery, error) {
-	args := m.Called(ctx, id, status)
+func (m *MockDeliveryService) UpdateDeliveryStatus(ctx context.Context, id int64, status string, courierID int64) (*model.Delivery, error) {
+	args := m.Called(ctx, id, status, courierID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.Delivery), args.Error(1)
 }
 
+func (m *MockDeliveryService) AssignCourier(ctx context.Context, id, courierID int64) (*model.Delivery, error) {
+	args := m.Called(ctx, id, courierID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Delivery), args.Error(1)
+}
+
+func (m *MockDeliveryService) ListDeliveries(ctx context.Context, limit, offset int32, status string, courierID int64) ([]*model.Delivery, int32, error) {
+	args := m.Called(ctx, limit, offset, status, courierID)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*model.Delivery), args.Get(1).(int32), args.Error(2)
+}
+
 func TestCreateDelivery_Success(t *testing.T) {
 	mockService := new(MockDeliveryService)
 	handler := New(mockService)
@@ -213,7 +230,7 @@ func TestUpdateDeliveryStatus_Success(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	mockService.On("UpdateDeliveryStatus", ctx, int64(1), "delivered").Return(expectedDelivery, nil)
+	mockService.On("UpdateDeliveryStatus", ctx, int64(1), "delivered", int64(0)).Return(expectedDelivery, nil)
 
 	resp, err := handler.UpdateDeliveryStatus(ctx, req)
 
@@ -273,7 +290,7 @@ func TestUpdateDeliveryStatus_NotFound(t *testing.T) {
 		Status:     "delivered",
 	}
 
-	mockService.On("UpdateDeliveryStatus", ctx, int64(999), "delivered").Return(nil, nil)
+	mockService.On("UpdateDeliveryStatus", ctx, int64(999), "delivered", int64(0)).Return(nil, nil)
 
 	resp, err := handler.UpdateDeliveryStatus(ctx, req)
 
@@ -285,3 +302,37 @@ func TestUpdateDeliveryStatus_NotFound(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+
+func TestUpdateDeliveryStatus_NotAssigned(t *testing.T) {
+	mockService := new(MockDeliveryService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("UpdateDeliveryStatus", ctx, int64(1), "delivered", int64(7)).Return(nil, service.ErrNotAssigned)
+
+	resp, err := handler.UpdateDeliveryStatus(ctx, &pb.UpdateDeliveryStatusRequest{DeliveryId: 1, Status: "delivered", CourierId: 7})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestAssignCourier(t *testing.T) {
+	mockService := new(MockDeliveryService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("AssignCourier", ctx, int64(1), int64(7)).Return(&model.Delivery{ID: 1, OrderID: 1, Status: "pending", CourierID: 7}, nil)
+	mockService.On("AssignCourier", ctx, int64(999), int64(7)).Return(nil, nil)
+
+	resp, err := handler.AssignCourier(ctx, &pb.AssignCourierRequest{DeliveryId: 1, CourierId: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), resp.CourierId)
+
+	_, err = handler.AssignCourier(ctx, &pb.AssignCourierRequest{DeliveryId: 999, CourierId: 7})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = handler.AssignCourier(ctx, &pb.AssignCourierRequest{DeliveryId: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,21 @@
+// Package metrics - бизнес метрики доставок для Prometheus.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// DeliveriesCreated - созданные доставки
+	DeliveriesCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "deliveries_created_total",
+		Help: "Total number of created deliveries.",
+	})
+
+	// StatusTransitions - переходы доставок в статус (preparing, in_transit, delivered, cancelled)
+	StatusTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "delivery_status_transitions_total",
+		Help: "Total number of delivery status transitions, by target status.",
+	}, []string{"status"})
+)
@@ -3,15 +3,20 @@ package model
 import "time"
 
 type Delivery struct {
-	ID        int64
-	OrderID   int64
-	Address   string
-	Status    string
+	ID      int64
+	OrderID int64
+	Address string
+	Status  string
+	// Коды складов, с которых отгружается заказ
+	Warehouses []string
+	// ID курьера, назначенного на доставку; 0 - не назначен
+	CourierID int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
 type CreateDeliveryRequest struct {
-	OrderID int64
-	Address string
+	OrderID    int64
+	Address    string
+	Warehouses []string
 }
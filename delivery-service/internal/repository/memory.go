@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/che1nov/tea-shop/delivery-service/internal/model"
+)
+
+// errDuplicateOrderID - аналог нарушения уникальности deliveries.order_id
+var errDuplicateOrderID = errors.New("delivery for this order already exists")
+
+// MemoryRepository - репозиторий доставок в памяти (для тестов и запуска без БД)
+type MemoryRepository struct {
+	mu         sync.Mutex
+	deliveries map[int64]*model.Delivery
+	lastID     int64
+}
+
+func NewMemory() *MemoryRepository {
+	return &MemoryRepository{deliveries: make(map[int64]*model.Delivery)}
+}
+
+func (r *MemoryRepository) CreateDelivery(ctx context.Context, delivery *model.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.deliveries {
+		if existing.OrderID == delivery.OrderID {
+			return errDuplicateOrderID
+		}
+	}
+
+	r.lastID++
+	delivery.ID = r.lastID
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	copied := *delivery
+	r.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) GetDelivery(ctx context.Context, id int64) (*model.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (r *MemoryRepository) GetDeliveryByOrderID(ctx context.Context, orderID int64) (*model.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivery := range r.filtered("", 0) {
+		if delivery.OrderID == orderID {
+			return delivery, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) UpdateDeliveryStatus(ctx context.Context, id int64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if delivery, ok := r.deliveries[id]; ok {
+		delivery.Status = status
+		delivery.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *MemoryRepository) AssignCourier(ctx context.Context, id, courierID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if delivery, ok := r.deliveries[id]; ok {
+		delivery.CourierID = courierID
+		delivery.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListDeliveries(ctx context.Context, limit, offset int32, statusFilter string, courierID int64) ([]*model.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := r.filtered(statusFilter, courierID)
+	if int(offset) >= len(deliveries) {
+		return nil, nil
+	}
+	deliveries = deliveries[offset:]
+	if int(limit) < len(deliveries) {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *MemoryRepository) GetTotalDeliveries(ctx context.Context, statusFilter string, courierID int64) (int32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int32(len(r.filtered(statusFilter, courierID))), nil
+}
+
+// filtered возвращает копии доставок с указанным статусом и курьером (пустой фильтр
+// не ограничивает выборку), новые первыми; вызывается под r.mu
+func (r *MemoryRepository) filtered(statusFilter string, courierID int64) []*model.Delivery {
+	var deliveries []*model.Delivery
+	for _, delivery := range r.deliveries {
+		if (statusFilter == "" || delivery.Status == statusFilter) && (courierID == 0 || delivery.CourierID == courierID) {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	return deliveries
+}
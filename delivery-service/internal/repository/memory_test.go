@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/delivery-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_Deliveries(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	for orderID := int64(1); orderID <= 3; orderID++ {
+		require.NoError(t, repo.CreateDelivery(ctx, &model.Delivery{OrderID: orderID, Address: "Москва", Status: "pending"}))
+	}
+	require.NoError(t, repo.UpdateDeliveryStatus(ctx, 2, "shipped"))
+
+	pending, err := repo.ListDeliveries(ctx, 10, 0, "pending", 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, int64(3), pending[0].ID)
+
+	total, err := repo.GetTotalDeliveries(ctx, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), total)
+
+	byOrder, err := repo.GetDeliveryByOrderID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "shipped", byOrder.Status)
+
+	missing, err := repo.GetDeliveryByOrderID(ctx, 42)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	assert.Error(t, repo.CreateDelivery(ctx, &model.Delivery{OrderID: 1, Address: "Тула", Status: "pending"}))
+
+	require.NoError(t, repo.AssignCourier(ctx, 1, 7))
+	assigned, err := repo.ListDeliveries(ctx, 10, 0, "", 7)
+	require.NoError(t, err)
+	require.Len(t, assigned, 1)
+	assert.Equal(t, int64(1), assigned[0].ID)
+
+	assignedTotal, err := repo.GetTotalDeliveries(ctx, "shipped", 7)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), assignedTotal)
+}
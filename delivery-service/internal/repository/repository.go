@@ -5,7 +5,7 @@ import (
 	"database/sql"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	
 	"github.com/che1nov/tea-shop/delivery-service/internal/model"
 )
@@ -16,8 +16,9 @@ type DeliveryRepositoryInterface interface {
 	GetDelivery(ctx context.Context, id int64) (*model.Delivery, error)
 	GetDeliveryByOrderID(ctx context.Context, orderID int64) (*model.Delivery, error)
 	UpdateDeliveryStatus(ctx context.Context, id int64, status string) error
-	ListDeliveries(ctx context.Context, limit, offset int32, status string) ([]*model.Delivery, error)
-	GetTotalDeliveries(ctx context.Context, status string) (int32, error)
+	AssignCourier(ctx context.Context, id, courierID int64) error
+	ListDeliveries(ctx context.Context, limit, offset int32, status string, courierID int64) ([]*model.Delivery, error)
+	GetTotalDeliveries(ctx context.Context, status string, courierID int64) (int32, error)
 }
 
 type DeliveryRepository struct {
@@ -30,8 +31,8 @@ func New(db *sql.DB) *DeliveryRepository {
 
 func (r *DeliveryRepository) CreateDelivery(ctx context.Context, delivery *model.Delivery) error {
 	query := `
-		INSERT INTO deliveries (order_id, address, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO deliveries (order_id, address, status, warehouses, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`
 	now := time.Now()
@@ -41,13 +42,14 @@ func (r *DeliveryRepository) CreateDelivery(ctx context.Context, delivery *model
 		delivery.OrderID,
 		delivery.Address,
 		delivery.Status,
+		pq.Array(delivery.Warehouses),
 		now,
 		now,
 	).Scan(&delivery.ID)
 }
 
 func (r *DeliveryRepository) GetDelivery(ctx context.Context, id int64) (*model.Delivery, error) {
-	query := `SELECT id, order_id, address, status, created_at, updated_at FROM deliveries WHERE id = $1`
+	query := `SELECT id, order_id, address, status, warehouses, COALESCE(courier_id, 0), created_at, updated_at FROM deliveries WHERE id = $1`
 
 	delivery := &model.Delivery{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
@@ -55,6 +57,8 @@ func (r *DeliveryRepository) GetDelivery(ctx context.Context, id int64) (*model.
 		&delivery.OrderID,
 		&delivery.Address,
 		&delivery.Status,
+		(*pq.StringArray)(&delivery.Warehouses),
+		&delivery.CourierID,
 		&delivery.CreatedAt,
 		&delivery.UpdatedAt,
 	)
@@ -70,7 +74,7 @@ func (r *DeliveryRepository) GetDelivery(ctx context.Context, id int64) (*model.
 }
 
 func (r *DeliveryRepository) GetDeliveryByOrderID(ctx context.Context, orderID int64) (*model.Delivery, error) {
-	query := `SELECT id, order_id, address, status, created_at, updated_at FROM deliveries WHERE order_id = $1`
+	query := `SELECT id, order_id, address, status, warehouses, COALESCE(courier_id, 0), created_at, updated_at FROM deliveries WHERE order_id = $1`
 
 	delivery := &model.Delivery{}
 	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
@@ -78,6 +82,8 @@ func (r *DeliveryRepository) GetDeliveryByOrderID(ctx context.Context, orderID i
 		&delivery.OrderID,
 		&delivery.Address,
 		&delivery.Status,
+		(*pq.StringArray)(&delivery.Warehouses),
+		&delivery.CourierID,
 		&delivery.CreatedAt,
 		&delivery.UpdatedAt,
 	)
@@ -98,19 +104,23 @@ func (r *DeliveryRepository) UpdateDeliveryStatus(ctx context.Context, id int64,
 	return err
 }
 
-func (r *DeliveryRepository) ListDeliveries(ctx context.Context, limit, offset int32, statusFilter string) ([]*model.Delivery, error) {
-	var query string
-	var rows *sql.Rows
-	var err error
-
-	if statusFilter != "" {
-		query = `SELECT id, order_id, address, status, created_at, updated_at FROM deliveries WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
-		rows, err = r.db.QueryContext(ctx, query, statusFilter, limit, offset)
-	} else {
-		query = `SELECT id, order_id, address, status, created_at, updated_at FROM deliveries ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-		rows, err = r.db.QueryContext(ctx, query, limit, offset)
-	}
+func (r *DeliveryRepository) AssignCourier(ctx context.Context, id, courierID int64) error {
+	query := `UPDATE deliveries SET courier_id = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, courierID, time.Now(), id)
+	return err
+}
 
+// ListDeliveries возвращает доставки, новые первыми. Пустой statusFilter и нулевой courierID
+// выборку не ограничивают
+func (r *DeliveryRepository) ListDeliveries(ctx context.Context, limit, offset int32, statusFilter string, courierID int64) ([]*model.Delivery, error) {
+	query := `
+		SELECT id, order_id, address, status, warehouses, COALESCE(courier_id, 0), created_at, updated_at
+		FROM deliveries
+		WHERE ($1::text = '' OR status = $1) AND ($2::bigint = 0 OR courier_id = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.db.QueryContext(ctx, query, statusFilter, courierID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +134,8 @@ func (r *DeliveryRepository) ListDeliveries(ctx context.Context, limit, offset i
 			&delivery.OrderID,
 			&delivery.Address,
 			&delivery.Status,
+			(*pq.StringArray)(&delivery.Warehouses),
+			&delivery.CourierID,
 			&delivery.CreatedAt,
 			&delivery.UpdatedAt,
 		); err != nil {
@@ -135,17 +147,10 @@ func (r *DeliveryRepository) ListDeliveries(ctx context.Context, limit, offset i
 	return deliveries, rows.Err()
 }
 
-func (r *DeliveryRepository) GetTotalDeliveries(ctx context.Context, statusFilter string) (int32, error) {
-	var query string
-	var total int32
-
-	if statusFilter != "" {
-		query = `SELECT COUNT(*) FROM deliveries WHERE status = $1`
-		err := r.db.QueryRowContext(ctx, query, statusFilter).Scan(&total)
-		return total, err
-	}
+func (r *DeliveryRepository) GetTotalDeliveries(ctx context.Context, statusFilter string, courierID int64) (int32, error) {
+	query := `SELECT COUNT(*) FROM deliveries WHERE ($1::text = '' OR status = $1) AND ($2::bigint = 0 OR courier_id = $2)`
 
-	query = `SELECT COUNT(*) FROM deliveries`
-	err := r.db.QueryRowContext(ctx, query).Scan(&total)
+	var total int32
+	err := r.db.QueryRowContext(ctx, query, statusFilter, courierID).Scan(&total)
 	return total, err
 }
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 
+	"github.com/che1nov/tea-shop/delivery-service/internal/metrics"
 	"github.com/che1nov/tea-shop/delivery-service/internal/model"
 	"github.com/che1nov/tea-shop/delivery-service/internal/repository"
 )
@@ -12,10 +14,14 @@ type DeliveryServiceInterface interface {
 	CreateDelivery(ctx context.Context, req *model.CreateDeliveryRequest) (*model.Delivery, error)
 	GetDelivery(ctx context.Context, id int64) (*model.Delivery, error)
 	GetDeliveryByOrderID(ctx context.Context, orderID int64) (*model.Delivery, error)
-	UpdateDeliveryStatus(ctx context.Context, id int64, status string) (*model.Delivery, error)
-	ListDeliveries(ctx context.Context, limit, offset int32, status string) ([]*model.Delivery, int32, error)
+	UpdateDeliveryStatus(ctx context.Context, id int64, status string, courierID int64) (*model.Delivery, error)
+	AssignCourier(ctx context.Context, id, courierID int64) (*model.Delivery, error)
+	ListDeliveries(ctx context.Context, limit, offset int32, status string, courierID int64) ([]*model.Delivery, int32, error)
 }
 
+// ErrNotAssigned - курьер меняет статус доставки, назначенной не ему
+var ErrNotAssigned = errors.New("delivery is not assigned to this courier")
+
 type DeliveryService struct {
 	repo repository.DeliveryRepositoryInterface
 }
@@ -28,14 +34,16 @@ func New(repo repository.DeliveryRepositoryInterface) *DeliveryService {
 
 func (s *DeliveryService) CreateDelivery(ctx context.Context, req *model.CreateDeliveryRequest) (*model.Delivery, error) {
 	delivery := &model.Delivery{
-		OrderID: req.OrderID,
-		Address: req.Address,
-		Status:  "pending",
+		OrderID:    req.OrderID,
+		Address:    req.Address,
+		Status:     "pending",
+		Warehouses: req.Warehouses,
 	}
 
 	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
 		return nil, err
 	}
+	metrics.DeliveriesCreated.Inc()
 
 	return delivery, nil
 }
@@ -48,21 +56,57 @@ func (s *DeliveryService) GetDeliveryByOrderID(ctx context.Context, orderID int6
 	return s.repo.GetDeliveryByOrderID(ctx, orderID)
 }
 
-func (s *DeliveryService) UpdateDeliveryStatus(ctx context.Context, id int64, status string) (*model.Delivery, error) {
+// UpdateDeliveryStatus меняет статус доставки. Ненулевой courierID ограничивает смену статуса
+// доставками, назначенными этому курьеру
+func (s *DeliveryService) UpdateDeliveryStatus(ctx context.Context, id int64, status string, courierID int64) (*model.Delivery, error) {
+	if courierID != 0 {
+		delivery, err := s.repo.GetDelivery(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if delivery == nil {
+			return nil, nil
+		}
+		if delivery.CourierID != courierID {
+			return nil, ErrNotAssigned
+		}
+	}
+
 	if err := s.repo.UpdateDeliveryStatus(ctx, id, status); err != nil {
 		return nil, err
 	}
+	metrics.StatusTransitions.WithLabelValues(status).Inc()
 
 	return s.repo.GetDelivery(ctx, id)
 }
 
-func (s *DeliveryService) ListDeliveries(ctx context.Context, limit, offset int32, status string) ([]*model.Delivery, int32, error) {
-	deliveries, err := s.repo.ListDeliveries(ctx, limit, offset, status)
+// AssignCourier назначает доставку курьеру; повторное назначение передает ее другому курьеру
+func (s *DeliveryService) AssignCourier(ctx context.Context, id, courierID int64) (*model.Delivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, nil
+	}
+
+	if err := s.repo.AssignCourier(ctx, id, courierID); err != nil {
+		return nil, err
+	}
+	delivery.CourierID = courierID
+
+	return delivery, nil
+}
+
+// ListDeliveries возвращает страницу доставок и их общее число; ненулевой courierID
+// оставляет только доставки этого курьера
+func (s *DeliveryService) ListDeliveries(ctx context.Context, limit, offset int32, status string, courierID int64) ([]*model.Delivery, int32, error) {
+	deliveries, err := s.repo.ListDeliveries(ctx, limit, offset, status, courierID)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	total, err := s.repo.GetTotalDeliveries(ctx, status)
+	total, err := s.repo.GetTotalDeliveries(ctx, status, courierID)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -45,6 +45,24 @@ func (m *MockRepository) UpdateDeliveryStatus(ctx context.Context, id int64, sta
 	return args.Error(0)
 }
 
+func (m *MockRepository) AssignCourier(ctx context.Context, id, courierID int64) error {
+	args := m.Called(ctx, id, courierID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListDeliveries(ctx context.Context, limit, offset int32, status string, courierID int64) ([]*model.Delivery, error) {
+	args := m.Called(ctx, limit, offset, status, courierID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Delivery), args.Error(1)
+}
+
+func (m *MockRepository) GetTotalDeliveries(ctx context.Context, status string, courierID int64) (int32, error) {
+	args := m.Called(ctx, status, courierID)
+	return args.Get(0).(int32), args.Error(1)
+}
+
 func TestNew(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := New(mockRepo)
@@ -160,7 +178,7 @@ func TestUpdateDeliveryStatus_Success(t *testing.T) {
 	mockRepo.On("UpdateDeliveryStatus", ctx, int64(1), "delivered").Return(nil)
 	mockRepo.On("GetDelivery", ctx, int64(1)).Return(updatedDelivery, nil)
 
-	delivery, err := service.UpdateDeliveryStatus(ctx, 1, "delivered")
+	delivery, err := service.UpdateDeliveryStatus(ctx, 1, "delivered", 0)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, delivery)
@@ -176,7 +194,7 @@ func TestUpdateDeliveryStatus_NotFound(t *testing.T) {
 	mockRepo.On("UpdateDeliveryStatus", ctx, int64(999), "delivered").Return(nil)
 	mockRepo.On("GetDelivery", ctx, int64(999)).Return(nil, nil)
 
-	delivery, err := service.UpdateDeliveryStatus(ctx, 999, "delivered")
+	delivery, err := service.UpdateDeliveryStatus(ctx, 999, "delivered", 0)
 
 	assert.NoError(t, err)
 	assert.Nil(t, delivery)
@@ -190,10 +208,47 @@ func TestUpdateDeliveryStatus_UpdateError(t *testing.T) {
 
 	mockRepo.On("UpdateDeliveryStatus", ctx, int64(1), "delivered").Return(errors.New("database error"))
 
-	delivery, err := service.UpdateDeliveryStatus(ctx, 1, "delivered")
+	delivery, err := service.UpdateDeliveryStatus(ctx, 1, "delivered", 0)
 
 	assert.Error(t, err)
 	assert.Nil(t, delivery)
 	mockRepo.AssertExpectations(t)
 }
 
+
+func TestUpdateDeliveryStatus_CourierScope(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(&model.Delivery{ID: 1, Status: "pending", CourierID: 7}, nil).Once()
+	mockRepo.On("UpdateDeliveryStatus", ctx, int64(1), "delivered").Return(nil)
+	mockRepo.On("GetDelivery", ctx, int64(1)).Return(&model.Delivery{ID: 1, Status: "delivered", CourierID: 7}, nil).Once()
+
+	delivery, err := service.UpdateDeliveryStatus(ctx, 1, "delivered", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "delivered", delivery.Status)
+
+	// Доставка другого курьера не меняется
+	mockRepo.On("GetDelivery", ctx, int64(2)).Return(&model.Delivery{ID: 2, Status: "pending", CourierID: 8}, nil)
+	_, err = service.UpdateDeliveryStatus(ctx, 2, "delivered", 7)
+	assert.ErrorIs(t, err, ErrNotAssigned)
+	mockRepo.AssertNotCalled(t, "UpdateDeliveryStatus", ctx, int64(2), "delivered")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListDeliveries_ByCourier(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("ListDeliveries", ctx, int32(10), int32(0), "", int64(7)).Return([]*model.Delivery{{ID: 1, CourierID: 7}}, nil)
+	mockRepo.On("GetTotalDeliveries", ctx, "", int64(7)).Return(int32(1), nil)
+
+	deliveries, total, err := service.ListDeliveries(ctx, 10, 0, "", 7)
+
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, int32(1), total)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS deliveries (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL UNIQUE,
+    address VARCHAR(500) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_order ON deliveries(order_id);
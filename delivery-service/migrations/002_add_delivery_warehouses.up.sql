@@ -0,0 +1,2 @@
+-- Склады goods-service, с которых отгружается заказ: заказ может собираться на нескольких складах
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS warehouses TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_deliveries_courier;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS courier_id;
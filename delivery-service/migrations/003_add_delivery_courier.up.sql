@@ -0,0 +1,4 @@
+-- Курьер, назначенный на доставку (id пользователя users-service); NULL - пока не назначен
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS courier_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_courier ON deliveries(courier_id);
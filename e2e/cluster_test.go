@@ -0,0 +1,164 @@
+package e2e
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	deliveryapp "github.com/che1nov/tea-shop/delivery-service/app"
+	goodsapp "github.com/che1nov/tea-shop/goods-service/app"
+	notifyapp "github.com/che1nov/tea-shop/notify-service/app"
+	orderapp "github.com/che1nov/tea-shop/order-service/app"
+	paymentapp "github.com/che1nov/tea-shop/payment-service/app"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/eventbus"
+	"github.com/che1nov/tea-shop/shared/pkg/grpcclient"
+	"github.com/che1nov/tea-shop/shared/pkg/inproc"
+	"github.com/che1nov/tea-shop/shared/pkg/server"
+	usersapp "github.com/che1nov/tea-shop/users-service/app"
+	"google.golang.org/grpc"
+)
+
+// declineAbove - платежи на сумму больше этой отклоняются, остальные проходят
+const declineAbove = 10_000
+
+// notification - письмо, "отправленное" notify-service
+type notification struct {
+	UserID  int64
+	Subject string
+	Body    string
+}
+
+// recordingSender запоминает уведомления вместо отправки email
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []notification
+}
+
+func (s *recordingSender) Send(ctx context.Context, userID int64, subject, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, notification{UserID: userID, Subject: subject, Body: body})
+	return nil
+}
+
+func (s *recordingSender) Sent() []notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]notification(nil), s.sent...)
+}
+
+// cluster - все сервисы магазина в одном процессе: gRPC через сеть в памяти, Kafka заменена шиной
+type cluster struct {
+	Users    pb.UsersServiceClient
+	Goods    pb.GoodsServiceClient
+	Orders   pb.OrdersServiceClient
+	Payments pb.PaymentsServiceClient
+	Delivery pb.DeliveryServiceClient
+
+	Bus           *eventbus.Bus
+	Notifications *recordingSender
+	// JWKSURL - адрес публичных ключей users-service, как его видит api-gateway
+	JWKSURL string
+}
+
+// startCluster поднимает шесть сервисов и останавливает их по завершении теста
+func startCluster(t *testing.T) *cluster {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	network := inproc.NewNetwork()
+
+	// serve запускает сервис в сети в памяти с общей серверной обвязкой и возвращает соединение с ним
+	serve := func(name string, register func(grpc.ServiceRegistrar)) *grpc.ClientConn {
+		srv := server.New(server.Config{Name: name, DrainDelay: time.Millisecond})
+		register(srv)
+
+		listener := network.Listen(name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := srv.Serve(ctx, listener); err != nil {
+				t.Errorf("%s stopped with error: %v", name, err)
+			}
+		}()
+
+		conn, err := grpcclient.Dial(name, "passthrough:///"+name, grpcclient.DefaultConfig(), network.DialOption())
+		if err != nil {
+			t.Fatalf("dial %s: %v", name, err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	users, err := usersapp.NewInMemory(ctx)
+	if err != nil {
+		t.Fatalf("users-service: %v", err)
+	}
+	jwks := httptest.NewServer(users.JWKS)
+	t.Cleanup(jwks.Close)
+
+	c := &cluster{
+		Bus:           eventbus.New(),
+		Notifications: &recordingSender{},
+		JWKSURL:       jwks.URL,
+	}
+
+	c.Users = pb.NewUsersServiceClient(serve("users-service", func(s grpc.ServiceRegistrar) {
+		pb.RegisterUsersServiceServer(s, users.Server)
+	}))
+	c.Goods = pb.NewGoodsServiceClient(serve("goods-service", func(s grpc.ServiceRegistrar) {
+		pb.RegisterGoodsServiceServer(s, goodsapp.NewInMemory())
+	}))
+	c.Payments = pb.NewPaymentsServiceClient(serve("payment-service", func(s grpc.ServiceRegistrar) {
+		processor := paymentapp.ProcessorFunc(func(ctx context.Context, orderID int64, amount float64, method string) (bool, error) {
+			return amount <= declineAbove, nil
+		})
+		pb.RegisterPaymentsServiceServer(s, paymentapp.NewInMemory(processor))
+	}))
+	c.Delivery = pb.NewDeliveryServiceClient(serve("delivery-service", func(s grpc.ServiceRegistrar) {
+		pb.RegisterDeliveryServiceServer(s, deliveryapp.NewInMemory())
+	}))
+	c.Orders = pb.NewOrdersServiceClient(serve("order-service", func(s grpc.ServiceRegistrar) {
+		pb.RegisterOrdersServiceServer(s, orderapp.NewInMemory(c.Bus, orderapp.Clients{
+			Goods:    c.Goods,
+			Payments: c.Payments,
+			Delivery: c.Delivery,
+		}))
+	}))
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := notifyapp.RunInMemory(ctx, c.Bus, c.Notifications); err != nil && ctx.Err() == nil {
+			t.Errorf("notify-service stopped with error: %v", err)
+		}
+	}()
+
+	return c
+}
+
+// waitNotifications ждёт, пока notify-service обработает не меньше n событий
+func (c *cluster) waitNotifications(t *testing.T, n int) []notification {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sent := c.Notifications.Sent()
+		if len(sent) >= n {
+			return sent
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d notifications, got %d: %+v", n, len(sent), sent)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
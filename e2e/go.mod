@@ -0,0 +1,67 @@
+module github.com/che1nov/tea-shop/e2e
+
+go 1.25.1
+
+require (
+	github.com/che1nov/tea-shop/delivery-service v0.0.0-00010101000000-000000000000
+	github.com/che1nov/tea-shop/goods-service v0.0.0-00010101000000-000000000000
+	github.com/che1nov/tea-shop/notify-service v0.0.0-00010101000000-000000000000
+	github.com/che1nov/tea-shop/order-service v0.0.0-00010101000000-000000000000
+	github.com/che1nov/tea-shop/payment-service v0.0.0-00010101000000-000000000000
+	github.com/che1nov/tea-shop/shared v0.0.0
+	github.com/che1nov/tea-shop/users-service v0.0.0-00010101000000-000000000000
+	github.com/golang-jwt/jwt/v5 v5.3.0
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/grpc v1.77.0
+)
+
+require (
+	github.com/XSAM/otelsql v0.41.0 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cenkalti/backoff/v5 v5.0.3 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.3 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/lib/pq v1.10.9 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/client_golang v1.23.2 // indirect
+	github.com/prometheus/client_model v0.6.2 // indirect
+	github.com/prometheus/common v0.66.1 // indirect
+	github.com/prometheus/procfs v0.16.1 // indirect
+	github.com/segmentio/kafka-go v0.4.49 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.64.0 // indirect
+	go.opentelemetry.io/otel v1.39.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.39.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.39.0 // indirect
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.39.0 // indirect
+	go.opentelemetry.io/otel/metric v1.39.0 // indirect
+	go.opentelemetry.io/otel/sdk v1.39.0 // indirect
+	go.opentelemetry.io/otel/trace v1.39.0 // indirect
+	go.opentelemetry.io/proto/otlp v1.9.0 // indirect
+	go.yaml.in/yaml/v2 v2.4.2 // indirect
+	golang.org/x/crypto v0.44.0 // indirect
+	golang.org/x/net v0.47.0 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20251202230838-ff82c1b0f217 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251202230838-ff82c1b0f217 // indirect
+	google.golang.org/protobuf v1.36.10 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	github.com/che1nov/tea-shop/delivery-service => ../delivery-service
+	github.com/che1nov/tea-shop/goods-service => ../goods-service
+	github.com/che1nov/tea-shop/notify-service => ../notify-service
+	github.com/che1nov/tea-shop/order-service => ../order-service
+	github.com/che1nov/tea-shop/payment-service => ../payment-service
+	github.com/che1nov/tea-shop/shared => ../shared
+	github.com/che1nov/tea-shop/users-service => ../users-service
+)
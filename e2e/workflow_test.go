@@ -0,0 +1,676 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"github.com/che1nov/tea-shop/shared/pkg/jwks"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestCompleteWorkflow повторяет test_complete_workflow.sh без docker:
+// регистрация → вход → каталог → заказ → оплата → доставка → уведомление
+func TestCompleteWorkflow(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Склад: товары заводятся напрямую в goods-service
+	puer, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Шу Пуэр", Description: "Выдержанный", Price: 1200, Stock: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "GOOD-000001", puer.Sku)
+	_, err = c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Те Гуань Инь", Price: 900, Stock: 3})
+	require.NoError(t, err)
+
+	// Регистрация
+	user, err := c.Users.CreateUser(ctx, &pb.CreateUserRequest{Email: "buyer@example.com", Name: "Buyer", Password: "password123"})
+	require.NoError(t, err)
+	assert.Equal(t, "user", user.Role)
+
+	// Вход: токен проверяется так же, как в api-gateway - по ключу из JWKS users-service
+	login, err := c.Users.Login(ctx, &pb.LoginRequest{Email: "buyer@example.com", Password: "password123"})
+	require.NoError(t, err)
+	require.NotEmpty(t, login.RefreshToken)
+	userID := verifyAccessToken(t, c.JWKSURL, login.Token)
+	assert.Equal(t, user.Id, userID)
+
+	validated, err := c.Users.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: login.Token})
+	require.NoError(t, err)
+	assert.True(t, validated.Valid)
+	assert.Equal(t, userID, validated.UserId)
+	assert.Equal(t, "user", validated.Role)
+
+	// Каталог
+	catalog, err := c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, catalog.Goods, 2)
+	assert.Equal(t, int32(2), catalog.Total)
+
+	// Заказ: резерв товара, оплата и создание доставки выполняет order-service
+	order, err := c.Orders.CreateOrder(ctx, &pb.CreateOrderRequest{
+		UserId:  userID,
+		Items:   []*pb.OrderItem{{GoodId: puer.Id, Quantity: 2}},
+		Address: "Москва, ул. Чайная, 1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "paid", order.Status)
+	assert.Equal(t, 2400.0, order.TotalPrice)
+
+	stored, err := c.Orders.GetOrder(ctx, &pb.GetOrderRequest{OrderId: order.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "paid", stored.Status)
+
+	good, err := c.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: puer.Id})
+	require.NoError(t, err)
+	assert.Equal(t, int32(8), good.Stock)
+
+	// Оплата
+	payment, err := c.Payments.GetPaymentByOrderID(ctx, &pb.GetPaymentByOrderIDRequest{OrderId: order.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", payment.Status)
+	assert.Equal(t, 2400.0, payment.Amount)
+
+	// Доставка: курьер проводит её по статусам
+	delivery := findDelivery(ctx, t, c, order.Id)
+	assert.Equal(t, "pending", delivery.Status)
+	assert.Equal(t, "Москва, ул. Чайная, 1", delivery.Address)
+	for _, status := range []string{"in_transit", "delivered"} {
+		delivery, err = c.Delivery.UpdateDeliveryStatus(ctx, &pb.UpdateDeliveryStatusRequest{DeliveryId: delivery.Id, Status: status})
+		require.NoError(t, err)
+		assert.Equal(t, status, delivery.Status)
+	}
+
+	// Уведомление о заказе приходит через шину событий
+	sent := c.waitNotifications(t, 1)
+	assert.Equal(t, userID, sent[0].UserID)
+	assert.Equal(t, fmt.Sprintf("Заказ #%d оформлен", order.Id), sent[0].Subject)
+	assert.Contains(t, sent[0].Body, "paid")
+
+	events := c.Bus.Messages("order-events")
+	require.Len(t, events, 1)
+	// request id вызова CreateOrder доходит до notify-service в заголовках события
+	assert.NotEmpty(t, events[0].Headers[correlation.HeaderRequestID])
+}
+
+// TestPaymentDeclined: при отказе платёжного шлюза заказ не оплачивается и доставка не создаётся
+func TestPaymentDeclined(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	samovar, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Самовар", Price: declineAbove + 1, Stock: 1})
+	require.NoError(t, err)
+
+	order, err := c.Orders.CreateOrder(ctx, &pb.CreateOrderRequest{
+		UserId:  1,
+		Items:   []*pb.OrderItem{{GoodId: samovar.Id, Quantity: 1}},
+		Address: "Тула",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "payment_failed", order.Status)
+
+	payment, err := c.Payments.GetPaymentByOrderID(ctx, &pb.GetPaymentByOrderIDRequest{OrderId: order.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "failed", payment.Status)
+
+	// Резерв неоплаченного заказа возвращается в остаток
+	good, err := c.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: samovar.Id})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), good.Stock)
+
+	deliveries, err := c.Delivery.ListDeliveries(ctx, &pb.ListDeliveriesRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, deliveries.Deliveries)
+
+	sent := c.waitNotifications(t, 1)
+	assert.Contains(t, sent[0].Body, "payment_failed")
+}
+
+// verifyAccessToken проверяет подпись access токена по JWKS и возвращает id пользователя
+func TestReplayOrderEvents(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sencha, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Сенча", Price: 700, Stock: 5})
+	require.NoError(t, err)
+
+	order, err := c.Orders.CreateOrder(ctx, &pb.CreateOrderRequest{
+		UserId:  1,
+		Items:   []*pb.OrderItem{{GoodId: sencha.Id, Quantity: 1}},
+		Address: "Казань",
+	})
+	require.NoError(t, err)
+	c.waitNotifications(t, 1)
+
+	_, err = c.Orders.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{OrderId: order.Id, Status: "completed"})
+	require.NoError(t, err)
+
+	// Повтор публикует событие по текущему состоянию заказа, notify-service обрабатывает его ещё раз
+	resp, err := c.Orders.ReplayOrderEvents(ctx, &pb.ReplayOrderEventsRequest{OrderIds: []int64{order.Id}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), resp.Published)
+
+	sent := c.waitNotifications(t, 2)
+	assert.Equal(t, sent[0].UserID, sent[1].UserID)
+	assert.Equal(t, fmt.Sprintf("Заказ #%d выполнен", order.Id), sent[1].Subject)
+
+	_, err = c.Orders.ReplayOrderEvents(ctx, &pb.ReplayOrderEventsRequest{OrderIds: []int64{order.Id, 999}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func verifyAccessToken(t *testing.T, jwksURL, token string) int64 {
+	t.Helper()
+
+	keys := jwks.NewCache(jwksURL, time.Minute)
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, _, err := keys.Lookup(context.Background(), kid)
+		return key, err
+	})
+	require.NoError(t, err)
+
+	userID, ok := claims["user_id"].(float64)
+	require.True(t, ok, "user_id claim is missing: %v", claims)
+	return int64(userID)
+}
+
+// findDelivery ищет доставку заказа: отдельного RPC поиска по заказу в delivery-service нет
+func findDelivery(ctx context.Context, t *testing.T, c *cluster, orderID int64) *pb.Delivery {
+	t.Helper()
+
+	resp, err := c.Delivery.ListDeliveries(ctx, &pb.ListDeliveriesRequest{})
+	require.NoError(t, err)
+	for _, delivery := range resp.Deliveries {
+		if delivery.OrderId == orderID {
+			return delivery
+		}
+	}
+	t.Fatalf("delivery for order %d not found", orderID)
+	return nil
+}
+
+// TestCatalogCategories - дерево категорий и выборка товаров ветки каталога
+func TestCatalogCategories(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tea, err := c.Goods.CreateCategory(ctx, &pb.CreateCategoryRequest{Slug: "tea", Name: "Чай"})
+	require.NoError(t, err)
+	puerCategory, err := c.Goods.CreateCategory(ctx, &pb.CreateCategoryRequest{ParentId: tea.Id, Slug: "pu-erh", Name: "Пуэр"})
+	require.NoError(t, err)
+	teaware, err := c.Goods.CreateCategory(ctx, &pb.CreateCategoryRequest{Slug: "teaware", Name: "Посуда"})
+	require.NoError(t, err)
+
+	_, err = c.Goods.CreateCategory(ctx, &pb.CreateCategoryRequest{Slug: "tea", Name: "Дубль"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	puer, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Шу Пуэр", Price: 1200, Stock: 10})
+	require.NoError(t, err)
+	gaiwan, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Гайвань", Price: 900, Stock: 3})
+	require.NoError(t, err)
+
+	puer, err = c.Goods.SetGoodCategories(ctx, &pb.SetGoodCategoriesRequest{GoodId: puer.Id, CategoryIds: []int64{puerCategory.Id}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{puerCategory.Id}, puer.CategoryIds)
+	_, err = c.Goods.SetGoodCategories(ctx, &pb.SetGoodCategoriesRequest{GoodId: gaiwan.Id, CategoryIds: []int64{teaware.Id}})
+	require.NoError(t, err)
+
+	// Фильтр по корневой категории включает товары подкатегорий
+	catalog, err := c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10, CategoryId: tea.Id})
+	require.NoError(t, err)
+	require.Len(t, catalog.Goods, 1)
+	assert.Equal(t, puer.Id, catalog.Goods[0].Id)
+	assert.Equal(t, int32(1), catalog.Total)
+
+	// Фильтры по цене и сортировка; фасет категорий не сужается фильтром по категории
+	catalog, err = c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{
+		Limit:         10,
+		CategoryIds:   []int64{tea.Id, teaware.Id},
+		MaxPrice:      1500,
+		Sort:          "price_desc",
+		IncludeFacets: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, catalog.Goods, 2)
+	assert.Equal(t, puer.Id, catalog.Goods[0].Id)
+	require.NotNil(t, catalog.Facets)
+	assert.Len(t, catalog.Facets.Categories, 3)
+	assert.Equal(t, int32(2), catalog.Facets.InStock)
+
+	_, err = c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10, Sort: "rating"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Категорию с подкатегориями удалить нельзя
+	_, err = c.Goods.DeleteCategory(ctx, &pb.DeleteCategoryRequest{CategoryId: tea.Id})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	found, err := c.Goods.GetCategory(ctx, &pb.GetCategoryRequest{Slug: "pu-erh"})
+	require.NoError(t, err)
+	assert.Equal(t, tea.Id, found.ParentId)
+}
+
+// TestSearchGoods - поиск по каталогу с подсветкой и поиск по опечаткам
+func TestSearchGoods(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Шу Пуэр", Description: "Выдержанный чай из Юньнани", Price: 1200, Stock: 10})
+	require.NoError(t, err)
+	_, err = c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Da Hong Pao", Description: "Утёсный улун", Price: 2500, Stock: 2})
+	require.NoError(t, err)
+
+	found, err := c.Goods.SearchGoods(ctx, &pb.SearchGoodsRequest{Query: "улун", Limit: 10})
+	require.NoError(t, err)
+	assert.False(t, found.Fuzzy)
+	require.Len(t, found.Hits, 1)
+	assert.Equal(t, "Da Hong Pao", found.Hits[0].Good.Name)
+	assert.Equal(t, "Утёсный <b>улун</b>", found.Hits[0].Snippet)
+
+	typo, err := c.Goods.SearchGoods(ctx, &pb.SearchGoodsRequest{Query: "пуер", Limit: 10})
+	require.NoError(t, err)
+	assert.True(t, typo.Fuzzy)
+	require.Len(t, typo.Hits, 1)
+	assert.Equal(t, "Шу Пуэр", typo.Hits[0].Good.Name)
+
+	_, err = c.Goods.SearchGoods(ctx, &pb.SearchGoodsRequest{Query: " "})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestGoodVariants - заказ конкретной фасовки: цена и остаток берутся из варианта
+func TestGoodVariants(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sencha, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Сенча", Price: 700, Stock: 5})
+	require.NoError(t, err)
+	require.Len(t, sencha.Variants, 1)
+
+	large, err := c.Goods.CreateVariant(ctx, &pb.CreateVariantRequest{
+		GoodId:     sencha.Id,
+		Price:      1300,
+		Stock:      2,
+		Attributes: map[string]string{"weight": "250 г"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, sencha.Sku+"-2", large.Sku)
+
+	good, err := c.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: sencha.Id})
+	require.NoError(t, err)
+	assert.Equal(t, 700.0, good.Price)
+	assert.Equal(t, int32(7), good.Stock)
+
+	user, err := c.Users.CreateUser(ctx, &pb.CreateUserRequest{Email: "variants@example.com", Name: "Buyer", Password: "password123"})
+	require.NoError(t, err)
+
+	// У товара два варианта - без variant_id заказ не принимается
+	_, err = c.Orders.CreateOrder(ctx, &pb.CreateOrderRequest{
+		UserId:  user.Id,
+		Items:   []*pb.OrderItem{{GoodId: sencha.Id, Quantity: 1}},
+		Address: "Москва, ул. Чайная, 1",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	order, err := c.Orders.CreateOrder(ctx, &pb.CreateOrderRequest{
+		UserId:  user.Id,
+		Items:   []*pb.OrderItem{{GoodId: sencha.Id, VariantId: large.Id, Quantity: 2}},
+		Address: "Москва, ул. Чайная, 1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2600.0, order.TotalPrice)
+	require.Len(t, order.Items, 1)
+	assert.Equal(t, large.Id, order.Items[0].VariantId)
+
+	check, err := c.Goods.CheckStock(ctx, &pb.CheckStockRequest{GoodId: sencha.Id, VariantId: large.Id, Quantity: 1})
+	require.NoError(t, err)
+	assert.False(t, check.Available)
+
+	good, err = c.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: sencha.Id})
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), good.Stock)
+}
+
+// TestGoodAttributes - атрибуты по схеме категории: проверка при создании и фильтр каталога
+func TestGoodAttributes(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tea, err := c.Goods.CreateCategory(ctx, &pb.CreateCategoryRequest{
+		Slug: "tea",
+		Name: "Чай",
+		Attributes: []*pb.AttributeDefinition{
+			{Code: "brew_temp", Name: "Температура заваривания", Type: "number", Unit: "°C", Required: true},
+			{Code: "origin", Name: "Происхождение", Type: "string"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, tea.Attributes, 2)
+
+	_, err = c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{
+		Name:        "Шу Пуэр",
+		Price:       1200,
+		Stock:       10,
+		CategoryIds: []int64{tea.Id},
+		Attributes:  map[string]string{"origin": "Юньнань"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	puer, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{
+		Name:        "Шу Пуэр",
+		Price:       1200,
+		Stock:       10,
+		CategoryIds: []int64{tea.Id},
+		Attributes:  map[string]string{"brew_temp": "95.0", "origin": "Юньнань"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"brew_temp": "95", "origin": "Юньнань"}, puer.Attributes)
+	_, err = c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{
+		Name:        "Сенча",
+		Price:       700,
+		Stock:       5,
+		CategoryIds: []int64{tea.Id},
+		Attributes:  map[string]string{"brew_temp": "80", "origin": "Япония"},
+	})
+	require.NoError(t, err)
+
+	maxTemp := 90.0
+	cool, err := c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{
+		Limit:      10,
+		Attributes: []*pb.AttributeFilter{{Code: "brew_temp", Max: &maxTemp}},
+	})
+	require.NoError(t, err)
+	require.Len(t, cool.Goods, 1)
+	assert.Equal(t, "Сенча", cool.Goods[0].Name)
+
+	yunnan, err := c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{
+		Limit:      10,
+		Attributes: []*pb.AttributeFilter{{Code: "origin", Values: []string{"Юньнань"}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, yunnan.Goods, 1)
+	assert.Equal(t, puer.Id, yunnan.Goods[0].Id)
+
+	_, err = c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{
+		Limit:      10,
+		Attributes: []*pb.AttributeFilter{{Code: "color", Values: []string{"green"}}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestGoodImages - галерея товара: загрузка с уменьшенными копиями, порядок, удаление
+func TestGoodImages(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	good, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Шу Пуэр", Price: 1200, Stock: 10})
+	require.NoError(t, err)
+	assert.Empty(t, good.Images)
+
+	var photo bytes.Buffer
+	require.NoError(t, jpeg.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 1200, 900)), nil))
+
+	cover, err := c.Goods.UploadGoodImage(ctx, &pb.UploadGoodImageRequest{GoodId: good.Id, Data: photo.Bytes()})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), cover.Position)
+	assert.Equal(t, int32(1200), cover.Width)
+	assert.Contains(t, cover.ThumbnailUrl, "thumbnail.jpg")
+	assert.Contains(t, cover.MediumUrl, "medium.jpg")
+	assert.Contains(t, cover.Url, "original.jpg")
+
+	back, err := c.Goods.UploadGoodImage(ctx, &pb.UploadGoodImageRequest{GoodId: good.Id, Data: photo.Bytes()})
+	require.NoError(t, err)
+
+	_, err = c.Goods.UploadGoodImage(ctx, &pb.UploadGoodImageRequest{GoodId: good.Id, Data: []byte("not an image")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	reordered, err := c.Goods.ReorderGoodImages(ctx, &pb.ReorderGoodImagesRequest{GoodId: good.Id, ImageIds: []int64{back.Id, cover.Id}})
+	require.NoError(t, err)
+	require.Len(t, reordered.Images, 2)
+	assert.Equal(t, back.Id, reordered.Images[0].Id)
+
+	_, err = c.Goods.ReorderGoodImages(ctx, &pb.ReorderGoodImagesRequest{GoodId: good.Id, ImageIds: []int64{back.Id}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = c.Goods.DeleteGoodImage(ctx, &pb.DeleteGoodImageRequest{ImageId: back.Id})
+	require.NoError(t, err)
+
+	// Каталог отдает галерею вместе с товаром
+	page, err := c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Goods, 1)
+	require.Len(t, page.Goods[0].Images, 1)
+	assert.Equal(t, cover.ThumbnailUrl, page.Goods[0].Images[0].ThumbnailUrl)
+}
+
+// TestInventoryLedger - каждое изменение остатка попадает в складской журнал, и остатки сходятся с ним
+func TestInventoryLedger(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	oolong, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Да Хун Пао", Price: 1500, Stock: 10})
+	require.NoError(t, err)
+
+	order, err := c.Orders.CreateOrder(ctx, &pb.CreateOrderRequest{
+		UserId:  1,
+		Items:   []*pb.OrderItem{{GoodId: oolong.Id, Quantity: 2}},
+		Address: "Москва, ул. Чайная, 1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "paid", order.Status)
+
+	adjusted, err := c.Goods.AdjustStock(ctx, &pb.AdjustStockRequest{GoodId: oolong.Id, Type: "adjustment", Quantity: -1, Reason: "брак при приемке"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), adjusted.Balance)
+	_, err = c.Goods.AdjustStock(ctx, &pb.AdjustStockRequest{GoodId: oolong.Id, Type: "receipt", Quantity: 5})
+	require.NoError(t, err)
+
+	_, err = c.Goods.AdjustStock(ctx, &pb.AdjustStockRequest{GoodId: oolong.Id, Type: "adjustment", Quantity: -100, Reason: "инвентаризация"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = c.Goods.AdjustStock(ctx, &pb.AdjustStockRequest{GoodId: oolong.Id, Type: "adjustment", Quantity: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	history, err := c.Goods.ListStockMovements(ctx, &pb.ListStockMovementsRequest{GoodId: oolong.Id})
+	require.NoError(t, err)
+	require.Len(t, history.Movements, 5)
+	var types []string
+	for _, movement := range history.Movements {
+		types = append(types, movement.Type)
+	}
+	assert.Equal(t, []string{"receipt", "adjustment", "sale", "reservation", "receipt"}, types)
+	assert.Equal(t, order.Id, history.Movements[2].OrderId)
+	assert.Equal(t, int32(12), history.Movements[0].Balance)
+
+	good, err := c.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: oolong.Id})
+	require.NoError(t, err)
+	assert.Equal(t, int32(12), good.Stock)
+
+	verified, err := c.Goods.VerifyStock(ctx, &pb.VerifyStockRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, verified.Discrepancies)
+}
+
+func TestWarehouses(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	spb, err := c.Goods.CreateWarehouse(ctx, &pb.CreateWarehouseRequest{
+		Code:     "spb",
+		Name:     "Склад в Петербурге",
+		Regions:  []string{"Санкт-Петербург"},
+		Priority: 10,
+	})
+	require.NoError(t, err)
+
+	// Начальный остаток - на основном складе, поступление - на складе в Петербурге
+	puer, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Шу Пуэр", Price: 900, Stock: 2})
+	require.NoError(t, err)
+	_, err = c.Goods.AdjustStock(ctx, &pb.AdjustStockRequest{GoodId: puer.Id, Type: "receipt", Quantity: 5})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.Goods.AdjustStock(ctx, &pb.AdjustStockRequest{GoodId: puer.Id, WarehouseId: spb.Id, Type: "receipt", Quantity: 5})
+	require.NoError(t, err)
+
+	availability, err := c.Goods.CheckStock(ctx, &pb.CheckStockRequest{GoodId: puer.Id, Quantity: 6})
+	require.NoError(t, err)
+	assert.True(t, availability.Available)
+	assert.Equal(t, int32(7), availability.Stock)
+	require.Len(t, availability.Warehouses, 2)
+	assert.Equal(t, "main", availability.Warehouses[0].WarehouseCode)
+	assert.Equal(t, int32(5), availability.Warehouses[1].Stock)
+
+	// Заказ в Петербург собирается сначала с петербургского склада, недостающее - с основного
+	order, err := c.Orders.CreateOrder(ctx, &pb.CreateOrderRequest{
+		UserId:  1,
+		Items:   []*pb.OrderItem{{GoodId: puer.Id, Quantity: 6}},
+		Address: "Санкт-Петербург, Невский пр., 10",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "paid", order.Status)
+
+	delivery := findDelivery(ctx, t, c, order.Id)
+	assert.Equal(t, []string{"spb", "main"}, delivery.Warehouses)
+
+	good, err := c.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: puer.Id})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), good.Stock)
+	assert.Equal(t, int32(1), good.Variants[0].Warehouses[0].Stock)
+	assert.Equal(t, int32(0), good.Variants[0].Warehouses[1].Stock)
+
+	verified, err := c.Goods.VerifyStock(ctx, &pb.VerifyStockRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, verified.Discrepancies)
+}
+
+// waitImportJob опрашивает задание импорта, пока оно выполняется
+func waitImportJob(ctx context.Context, t *testing.T, c *cluster, id int64) *pb.ImportJob {
+	t.Helper()
+	var job *pb.ImportJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = c.Goods.GetImportJob(ctx, &pb.GetImportJobRequest{Id: id})
+		require.NoError(t, err)
+		return job.Status != "running"
+	}, 5*time.Second, 20*time.Millisecond)
+	return job
+}
+
+// TestCatalogImportExport - закупщики ведут ассортимент таблицей: пробный импорт находит ошибки,
+// исправленный файл заводит товары, выгрузка возвращает их с ценами и остатками
+func TestCatalogImportExport(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := c.Goods.ImportCatalog(ctx, &pb.ImportCatalogRequest{Format: "csv", Data: []byte("sku;cost\nPUER;1\n")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	draft := "sku;good_sku;name;price;stock;variant_attributes\n" +
+		"PUER-100;PUER;Шу Пуэр;1200;10;weight=100 г\n" +
+		"PUER-357;PUER;;;2;weight=357 г\n"
+	job, err := c.Goods.ImportCatalog(ctx, &pb.ImportCatalogRequest{Format: "csv", Data: []byte(draft), DryRun: true})
+	require.NoError(t, err)
+	job = waitImportJob(ctx, t, c, job.Id)
+	assert.Equal(t, "completed", job.Status)
+	assert.Equal(t, int32(1), job.Created)
+	require.Len(t, job.Errors, 1)
+	assert.Equal(t, int32(3), job.Errors[0].Row)
+	assert.Equal(t, "price", job.Errors[0].Column)
+
+	fixed := strings.Replace(draft, ";;2;", ";3500;2;", 1)
+	job, err = c.Goods.ImportCatalog(ctx, &pb.ImportCatalogRequest{Format: "csv", Data: []byte(fixed)})
+	require.NoError(t, err)
+	job = waitImportJob(ctx, t, c, job.Id)
+	assert.Empty(t, job.Errors)
+	assert.Equal(t, int32(2), job.Created)
+
+	page, err := c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Goods, 1)
+	assert.Equal(t, int32(12), page.Goods[0].Stock)
+	require.Len(t, page.Goods[0].Variants, 2)
+
+	export, err := c.Goods.ExportCatalog(ctx, &pb.ExportCatalogRequest{Format: "csv"})
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv; charset=utf-8", export.ContentType)
+	assert.Contains(t, string(export.Data), "PUER-357;PUER;Шу Пуэр;;;;3500;2;weight=357 г;2\n")
+}
+
+// TestArchiveGood: удаленный товар уходит в архив, но остается доступен для истории заказов
+func TestArchiveGood(t *testing.T) {
+	c := startCluster(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sencha, err := c.Goods.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Сенча", Price: 500, Stock: 5})
+	require.NoError(t, err)
+
+	// Пока резерв не снят и не продан, товар не архивируется
+	reserved, err := c.Goods.ReserveOrder(ctx, &pb.ReserveOrderRequest{
+		OrderId: 100,
+		Items:   []*pb.ReserveOrderItem{{GoodId: sencha.Id, Quantity: 1}},
+	})
+	require.NoError(t, err)
+	require.True(t, reserved.Success)
+	_, err = c.Goods.DeleteGood(ctx, &pb.DeleteGoodRequest{GoodId: sencha.Id})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = c.Goods.ReleaseStock(ctx, &pb.ReleaseStockRequest{OrderId: 100})
+	require.NoError(t, err)
+
+	order, err := c.Orders.CreateOrder(ctx, &pb.CreateOrderRequest{
+		UserId:  1,
+		Items:   []*pb.OrderItem{{GoodId: sencha.Id, Quantity: 2}},
+		Address: "Москва",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "paid", order.Status)
+
+	deleted, err := c.Goods.DeleteGood(ctx, &pb.DeleteGoodRequest{GoodId: sencha.Id})
+	require.NoError(t, err)
+	assert.True(t, deleted.Success)
+
+	catalog, err := c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, catalog.Goods)
+	archive, err := c.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10, Archived: true})
+	require.NoError(t, err)
+	require.Len(t, archive.Goods, 1)
+
+	// Позиция заказа по-прежнему ссылается на товар
+	stored, err := c.Orders.GetOrder(ctx, &pb.GetOrderRequest{OrderId: order.Id})
+	require.NoError(t, err)
+	good, err := c.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: stored.Items[0].GoodId})
+	require.NoError(t, err)
+	assert.Equal(t, "Сенча", good.Name)
+	assert.NotZero(t, good.ArchivedAt)
+
+	reserved, err = c.Goods.ReserveOrder(ctx, &pb.ReserveOrderRequest{
+		OrderId: 101,
+		Items:   []*pb.ReserveOrderItem{{GoodId: sencha.Id, Quantity: 1}},
+	})
+	require.NoError(t, err)
+	assert.False(t, reserved.Success)
+
+	restored, err := c.Goods.RestoreGood(ctx, &pb.RestoreGoodRequest{GoodId: sencha.Id})
+	require.NoError(t, err)
+	assert.Zero(t, restored.ArchivedAt)
+	assert.Equal(t, int32(3), restored.Stock)
+}
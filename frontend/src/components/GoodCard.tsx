@@ -8,11 +8,16 @@ interface GoodCardProps {
 
 export function GoodCard({ good }: GoodCardProps) {
   const addItem = useCartStore((state) => state.addItem)
+  const cover = good.images?.[0]
 
   return (
     <div className="bg-white rounded-lg shadow-md overflow-hidden hover:shadow-xl transition-shadow">
       <div className="h-48 bg-gradient-to-br from-tea-100 to-tea-200 flex items-center justify-center">
-        <span className="text-6xl">🍵</span>
+        {cover ? (
+          <img src={cover.thumbnail_url} alt={good.name} loading="lazy" className="h-full w-full object-cover" />
+        ) : (
+          <span className="text-6xl">🍵</span>
+        )}
       </div>
       <div className="p-4">
         <h3 className="text-xl font-semibold text-gray-800 mb-2">{good.name}</h3>
@@ -6,6 +6,17 @@ export interface Good {
   price: number
   stock: number
   created_at: number
+  images?: GoodImage[] // галерея по порядку, первое изображение - обложка
+}
+
+export interface GoodImage {
+  id: number
+  position: number
+  url: string
+  medium_url: string
+  thumbnail_url: string
+  width: number
+  height: number
 }
 
 export interface User {
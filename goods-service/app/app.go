@@ -0,0 +1,43 @@
+// Package app собирает слои goods-service для запуска внутри другого процесса:
+// e2e тестов и сборок, где сервисы работают в одном бинарнике
+package app
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/handler"
+	"github.com/che1nov/tea-shop/goods-service/internal/metrics"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/che1nov/tea-shop/goods-service/internal/storage"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MaxRecvMsgSize - наибольший размер входящего gRPC сообщения: изображение товара и файл импорта
+// каталога загружаются одним сообщением
+const MaxRecvMsgSize = max(service.MaxImageSize, service.MaxCatalogFileSize) + 1<<20
+
+// New собирает gRPC API goods-service поверх БД; изображения хранятся в памяти
+func New(db *sql.DB) pb.GoodsServiceServer {
+	return handler.New(service.New(repository.New(db)))
+}
+
+// NewWithImages собирает gRPC API поверх БД с изображениями в каталоге dir. Возвращаемый
+// обработчик раздает файлы и монтируется с http.StripPrefix по адресу baseURL
+func NewWithImages(db *sql.DB, dir, baseURL string) (pb.GoodsServiceServer, http.Handler) {
+	images := storage.NewLocal(dir, baseURL)
+	return handler.New(service.NewWithStorage(repository.New(db), images)), images.Handler()
+}
+
+// NewStockCollector возвращает коллектор остатков по SKU из БД;
+// товар считается заканчивающимся при остатке <= threshold
+func NewStockCollector(db *sql.DB, threshold int32) prometheus.Collector {
+	return metrics.NewStockCollector(repository.New(db), threshold)
+}
+
+// NewInMemory собирает gRPC API goods-service поверх репозитория в памяти
+func NewInMemory() pb.GoodsServiceServer {
+	return handler.New(service.New(repository.NewMemory()))
+}
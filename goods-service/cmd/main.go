@@ -1,178 +1,136 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
-	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
-
-	_ "github.com/lib/pq"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
-	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/che1nov/tea-shop/goods-service/config"
 	"github.com/che1nov/tea-shop/goods-service/internal/handler"
+	"github.com/che1nov/tea-shop/goods-service/internal/metrics"
 	"github.com/che1nov/tea-shop/goods-service/internal/repository"
 	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/che1nov/tea-shop/goods-service/internal/storage"
+	"github.com/che1nov/tea-shop/goods-service/migrations"
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/health"
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/migrate"
+	"github.com/che1nov/tea-shop/shared/pkg/server"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func main() {
 	// Инициализируем logger
-	logger.Init()
+	logger.Init("goods-service")
+
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	cfg := config.Load()
+	// Подкоманды: goods-service migrate up|down|status, config
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Подключение к БД
-	dbConnStr := fmt.Sprintf(
-		"user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Name,
-		cfg.Database.Host,
-		cfg.Database.Port,
-	)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	db, err := sql.Open("postgres", dbConnStr)
+	// Трейсинг: экспортер выбирается через TRACING_EXPORTER
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
 	if err != nil {
-		logger.Error("Failed to open database", "error", err)
+		logger.Error("Failed to initialize tracing", "error", err)
 		panic(err)
 	}
+	defer shutdownTracing(context.Background())
 
-	// Проверяем подключение к БД
-	if err := db.Ping(); err != nil {
-		logger.Error("Failed to connect to database", "error", err)
-		panic(fmt.Sprintf("failed to connect to database: %v", err))
+	db, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		logger.Error("Failed to initialize database", "error", err)
+		panic(err)
 	}
 	logger.Info("Database connection established")
 
-	// Создаём таблицы
-	createTablesSQL := `
-		CREATE TABLE IF NOT EXISTS goods (
-			id SERIAL PRIMARY KEY,
-			sku VARCHAR(50) UNIQUE NOT NULL,
-			name VARCHAR(255) NOT NULL,
-			description TEXT,
-			price DECIMAL(10, 2) NOT NULL,
-			stock INT NOT NULL DEFAULT 0,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
-
-		CREATE TABLE IF NOT EXISTS stock_reservations (
-			id SERIAL PRIMARY KEY,
-			good_id INT NOT NULL REFERENCES goods(id),
-			order_id INT NOT NULL,
-			quantity INT NOT NULL,
-			created_at TIMESTAMP NOT NULL
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_goods_name ON goods(name);
-		CREATE INDEX IF NOT EXISTS idx_goods_sku ON goods(sku);
-		CREATE INDEX IF NOT EXISTS idx_reservations_order ON stock_reservations(order_id);
-
-		-- Миграция: добавляем колонку sku для существующих товаров, если её нет
-		DO $$
-		BEGIN
-			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='goods' AND column_name='sku') THEN
-				ALTER TABLE goods ADD COLUMN sku VARCHAR(50);
-				UPDATE goods SET sku = 'GOOD-' || LPAD(id::text, 6, '0') WHERE sku IS NULL;
-				ALTER TABLE goods ALTER COLUMN sku SET NOT NULL;
-				ALTER TABLE goods ADD CONSTRAINT goods_sku_unique UNIQUE (sku);
-				CREATE INDEX IF NOT EXISTS idx_goods_sku ON goods(sku);
-			END IF;
-		END $$;
-	`
-	if _, err := db.Exec(createTablesSQL); err != nil {
-		panic(err)
+	// Схема БД: версионированные миграции из migrations/
+	if cfg.Database.AutoMigrate {
+		if err := migrate.Apply(ctx, db, migrations.FS); err != nil {
+			logger.Error("Failed to apply migrations", "error", err)
+			panic(err)
+		}
 	}
 
 	// Инициализируем слои
 	repo := repository.New(db)
-	svc := service.New(repo)
+	// Остатки по SKU читаются из БД при каждом скрейпе /metrics
+	prometheus.MustRegister(metrics.NewStockCollector(repo, int32(cfg.Stock.LowStockThreshold)))
+	images, imagesHandler := newImageStorage(cfg)
+	svc := service.NewWithStorage(repo, images)
+	svc.SetFulfilmentStrategy(cfg.Stock.Fulfilment)
 	hdlr := handler.New(svc)
 
-	// Запускаем HTTP сервер для метрик Prometheus ПЕРВЫМ
-	metricsPort := 9002
-	metricsMux := http.NewServeMux()
-	metricsMux.Handle("/metrics", promhttp.Handler())
-	metricsServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", metricsPort),
-		Handler: metricsMux,
+	srv := server.New(server.Config{
+		Name:        "goods-service",
+		Port:        cfg.Server.Port,
+		MetricsPort: cfg.Server.MetricsPort,
+		// Изображение товара и файл импорта каталога загружаются одним сообщением
+		MaxRecvMsgSize: max(service.MaxImageSize, service.MaxCatalogFileSize) + 1<<20,
+	}, health.Database(db))
+	srv.OnShutdown("database", db.Close)
+	// Задания импорта сохраняют статус до закрытия БД
+	srv.OnShutdown("catalog imports", svc.Close)
+	if imagesHandler != nil {
+		srv.HandleHTTP("/media/", http.StripPrefix("/media/", imagesHandler))
 	}
+	pb.RegisterGoodsServiceServer(srv, hdlr)
 
-	// Канал для получения сигналов ОС
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	// Запускаем HTTP сервер для метрик в отдельной горутине (ПЕРВЫМ)
-	go func() {
-		logger.Info("Goods Service metrics server starting", "port", metricsPort)
-		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Metrics server error", "error", err)
-		}
-	}()
-
-	// Небольшая задержка для запуска HTTP сервера метрик
-	time.Sleep(100 * time.Millisecond)
-
-	// Запускаем gRPC сервер
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
-	if err != nil {
-		logger.Error("Failed to create gRPC listener", "error", err, "port", cfg.Server.Port)
-		logger.Warn("gRPC server will not start, but metrics server is running")
-		<-sigChan
-		logger.Info("Shutting down Goods Service...")
-		if err := metricsServer.Close(); err != nil {
-			logger.Error("Error closing metrics server", "error", err)
-		}
-		logger.Info("Goods Service stopped")
-		return
+	if err := srv.Run(ctx); err != nil {
+		logger.Error("Goods Service stopped with error", "error", err)
 	}
+}
 
-	grpcServer := grpc.NewServer()
-	pb.RegisterGoodsServiceServer(grpcServer, hdlr)
-
-	// Health check
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("pb.GoodsService", grpc_health_v1.HealthCheckResponse_SERVING)
-
-	// Enable gRPC reflection for easier testing
-	reflection.Register(grpcServer)
-
-	// Запускаем gRPC сервер в отдельной горутине
-	go func() {
-		logger.Info("Goods Service gRPC server started", "port", cfg.Server.Port)
-		if err := grpcServer.Serve(listener); err != nil {
-			logger.Error("gRPC server error", "error", err)
-		}
-	}()
-
-	// Ожидаем сигнал для graceful shutdown
-	<-sigChan
-	logger.Info("Shutting down Goods Service...")
-
-	// Graceful shutdown HTTP сервера метрик
-	if err := metricsServer.Close(); err != nil {
-		logger.Error("Error closing metrics server", "error", err)
+// newImageStorage создает хранилище изображений по конфигурации. Для локального хранилища
+// возвращает и обработчик, раздающий файлы с сервера метрик по /media/
+func newImageStorage(cfg *config.Config) (storage.Storage, http.Handler) {
+	images := cfg.Images
+	if images.Storage == "s3" {
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  images.S3.Endpoint,
+			Region:    images.S3.Region,
+			Bucket:    images.S3.Bucket,
+			AccessKey: images.S3.AccessKey,
+			SecretKey: images.S3.SecretKey,
+			PublicURL: images.PublicURL,
+		}), nil
 	}
 
-	// Graceful shutdown gRPC сервера
-	grpcServer.GracefulStop()
-
-	// Закрываем соединение с БД
-	if err := db.Close(); err != nil {
-		logger.Error("Error closing database", "error", err)
+	publicURL := images.PublicURL
+	if publicURL == "" {
+		publicURL = fmt.Sprintf("http://localhost:%d/media", cfg.Server.MetricsPort)
 	}
+	local := storage.NewLocal(images.Dir, publicURL)
+	return local, local.Handler()
+}
 
-	logger.Info("Goods Service stopped")
+// runCommand выполняет подкоманду вместо запуска сервера
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "config":
+		return sharedconfig.Print(os.Stdout, cfg)
+	case "migrate":
+		return migrate.RunCommand(context.Background(), cfg.Database, migrations.FS, args[1:], os.Stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s\n%s", args[0], migrate.Usage, sharedconfig.Usage)
+	}
 }
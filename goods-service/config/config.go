@@ -1,36 +1,76 @@
 package config
 
-import "os"
+import (
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
 
 type Config struct {
-	Database struct {
-		Host     string
-		Port     string
-		User     string
-		Password string
-		Name     string
-	}
-	Server struct {
-		Port int
-	}
+	sharedconfig.App `yaml:",inline"`
+	Database         database.Config `yaml:"database"`
+	Server           struct {
+		Port int `yaml:"port" env:"SERVER_PORT" validate:"port"`
+		// Порт HTTP сервера метрик (/metrics, /loglevel)
+		MetricsPort int `yaml:"metrics_port" env:"METRICS_PORT" validate:"port"`
+	} `yaml:"server"`
+	Stock struct {
+		// Товар с остатком не больше порога считается заканчивающимся (метрика goods_low_stock)
+		LowStockThreshold int `yaml:"low_stock_threshold" env:"LOW_STOCK_THRESHOLD"`
+		// Стратегия выбора склада для резерва: nearest - склады региона адреса доставки, затем по приоритету;
+		// most_stock - склад с наибольшим остатком; fewest_splits - меньше всего отправлений
+		Fulfilment string `yaml:"fulfilment" env:"FULFILMENT_STRATEGY" validate:"oneof=nearest most_stock fewest_splits"`
+	} `yaml:"stock"`
+	// Хранилище изображений товаров
+	Images struct {
+		// local - каталог на диске, раздается сервером метрик по /media/; s3 - S3-совместимое хранилище
+		Storage string `yaml:"storage" env:"IMAGES_STORAGE" validate:"oneof=local s3"`
+		Dir     string `yaml:"dir" env:"IMAGES_DIR"`
+		// Адрес, по которому клиенты получают файлы. По умолчанию для local -
+		// http://localhost:<metrics_port>/media, для s3 - endpoint/bucket
+		PublicURL string `yaml:"public_url" env:"IMAGES_PUBLIC_URL"`
+		S3        struct {
+			Endpoint  string `yaml:"endpoint" env:"IMAGES_S3_ENDPOINT"`
+			Region    string `yaml:"region" env:"IMAGES_S3_REGION"`
+			Bucket    string `yaml:"bucket" env:"IMAGES_S3_BUCKET"`
+			AccessKey string `yaml:"access_key" env:"IMAGES_S3_ACCESS_KEY"`
+			SecretKey string `yaml:"secret_key" env:"IMAGES_S3_SECRET_KEY" secret:"true"`
+		} `yaml:"s3"`
+	} `yaml:"images"`
+	// Трейсинг OpenTelemetry
+	Tracing tracing.Config `yaml:"tracing"`
 }
 
-func Load() *Config {
+// Load собирает конфигурацию: значения по умолчанию → YAML файл (--config или CONFIG_FILE) →
+// переменные окружения → флаги. Возвращает аргументы после флагов (подкоманду)
+func Load(args []string) (*Config, []string, error) {
 	cfg := &Config{}
 
-	cfg.Database.Host = getEnv("DB_HOST", "localhost")
-	cfg.Database.Port = getEnv("DB_PORT", "5433")
-	cfg.Database.User = getEnv("DB_USER", "user")
-	cfg.Database.Password = getEnv("DB_PASSWORD", "password")
-	cfg.Database.Name = getEnv("DB_NAME", "goods_db")
+	cfg.Env = sharedconfig.EnvDevelopment
+	cfg.Database.Host = "localhost"
+	cfg.Database.Port = "5433"
+	cfg.Database.User = "user"
+	cfg.Database.Password = "password"
+	cfg.Database.Name = "goods_db"
+	cfg.Database.AutoMigrate = true
 	cfg.Server.Port = 8002
+	cfg.Server.MetricsPort = 9002
+	cfg.Stock.LowStockThreshold = 10
+	cfg.Stock.Fulfilment = "nearest"
+	cfg.Images.Storage = "local"
+	cfg.Images.Dir = "data/images"
+	cfg.Images.S3.Region = "us-east-1"
 
-	return cfg
-}
+	cfg.Tracing = tracing.Config{
+		ServiceName: "goods-service",
+		Exporter:    tracing.ExporterNone,
+		Endpoint:    "localhost:4317",
+		FilePath:    "traces.json",
+	}
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	args, err := sharedconfig.Load(cfg, args)
+	if err != nil {
+		return nil, nil, err
 	}
-	return defaultValue
+	return cfg, args, nil
 }
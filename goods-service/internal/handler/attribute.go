@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func attributeDefinitionsFromProto(definitions []*pb.AttributeDefinition) []model.AttributeDefinition {
+	if len(definitions) == 0 {
+		return nil
+	}
+
+	result := make([]model.AttributeDefinition, len(definitions))
+	for i, definition := range definitions {
+		result[i] = model.AttributeDefinition{
+			Code:          definition.Code,
+			Name:          definition.Name,
+			Type:          definition.Type,
+			Unit:          definition.Unit,
+			AllowedValues: definition.AllowedValues,
+			Required:      definition.Required,
+		}
+	}
+	return result
+}
+
+func attributeDefinitionsToProto(definitions []model.AttributeDefinition) []*pb.AttributeDefinition {
+	result := make([]*pb.AttributeDefinition, len(definitions))
+	for i, definition := range definitions {
+		result[i] = &pb.AttributeDefinition{
+			Code:          definition.Code,
+			Name:          definition.Name,
+			Type:          definition.Type,
+			Unit:          definition.Unit,
+			AllowedValues: definition.AllowedValues,
+			Required:      definition.Required,
+		}
+	}
+	return result
+}
+
+func attributeFiltersFromProto(filters []*pb.AttributeFilter) []model.AttributeFilter {
+	if len(filters) == 0 {
+		return nil
+	}
+
+	result := make([]model.AttributeFilter, len(filters))
+	for i, filter := range filters {
+		result[i] = model.AttributeFilter{
+			Code:   filter.Code,
+			Values: filter.Values,
+			Min:    filter.Min,
+			Max:    filter.Max,
+		}
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func TestCreateGood_InvalidAttribute(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	req := &model.CreateGoodRequest{
+		Name:        "Сенча",
+		Price:       700,
+		CategoryIDs: []int64{1},
+		Attributes:  map[string]string{"brew_temp": "горячо"},
+	}
+	mockService.On("CreateGood", ctx, req).
+		Return(nil, fmt.Errorf("%w: brew_temp must be a number", service.ErrInvalidAttribute))
+
+	_, err := handler.CreateGood(ctx, &pb.CreateGoodRequest{
+		Name:        "Сенча",
+		Price:       700,
+		CategoryIds: []int64{1},
+		Attributes:  map[string]string{"brew_temp": "горячо"},
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestListGoods_AttributeFilters(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	minTemp := 80.0
+	mockService.On("ListGoods", ctx, &model.GoodsFilter{
+		Limit:      10,
+		Attributes: []model.AttributeFilter{{Code: "brew_temp", Min: &minTemp}, {Code: "origin", Values: []string{"Юньнань"}}},
+	}).Return(&model.GoodsPage{Goods: []*model.Good{{ID: 1, Attributes: map[string]string{"brew_temp": "90"}}}, Total: 1}, nil)
+
+	resp, err := handler.ListGoods(ctx, &pb.ListGoodsRequest{
+		Limit: 10,
+		Attributes: []*pb.AttributeFilter{
+			{Code: "brew_temp", Min: &minTemp},
+			{Code: "origin", Values: []string{"Юньнань"}},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "90", resp.Goods[0].Attributes["brew_temp"])
+	mockService.AssertExpectations(t)
+}
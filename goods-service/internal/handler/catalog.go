@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/che1nov/tea-shop/goods-service/internal/spreadsheet"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func (h *GoodsHandler) ImportCatalog(ctx context.Context, req *pb.ImportCatalogRequest) (*pb.ImportJob, error) {
+	job, err := h.service.ImportCatalog(ctx, req.Format, req.Data, req.DryRun)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return importJobToProto(job), nil
+}
+
+func (h *GoodsHandler) GetImportJob(ctx context.Context, req *pb.GetImportJobRequest) (*pb.ImportJob, error) {
+	job, err := h.service.GetImportJob(ctx, req.Id)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return importJobToProto(job), nil
+}
+
+// ExportCatalog выгружает каталог; без формата - в CSV
+func (h *GoodsHandler) ExportCatalog(ctx context.Context, req *pb.ExportCatalogRequest) (*pb.ExportCatalogResponse, error) {
+	format := req.Format
+	if format == "" {
+		format = spreadsheet.FormatCSV
+	}
+	data, err := h.service.ExportCatalog(ctx, format)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	return &pb.ExportCatalogResponse{
+		Data:        data,
+		ContentType: spreadsheet.ContentType(format),
+		Filename:    fmt.Sprintf("catalog-%s.%s", time.Now().Format("20060102"), format),
+	}, nil
+}
+
+func importJobToProto(job *model.ImportJob) *pb.ImportJob {
+	pbJob := &pb.ImportJob{
+		Id:            job.ID,
+		Format:        job.Format,
+		DryRun:        job.DryRun,
+		Status:        job.Status,
+		TotalRows:     job.TotalRows,
+		ProcessedRows: job.ProcessedRows,
+		Created:       job.Created,
+		Updated:       job.Updated,
+		Failed:        job.Failed,
+		Errors:        make([]*pb.ImportRowError, len(job.Errors)),
+		Error:         job.Error,
+		CreatedAt:     job.CreatedAt.Unix(),
+		UpdatedAt:     job.UpdatedAt.Unix(),
+	}
+	if !job.FinishedAt.IsZero() {
+		pbJob.FinishedAt = job.FinishedAt.Unix()
+	}
+	for i, rowErr := range job.Errors {
+		pbJob.Errors[i] = &pb.ImportRowError{
+			Row:     rowErr.Row,
+			Column:  rowErr.Column,
+			Sku:     rowErr.SKU,
+			Message: rowErr.Message,
+		}
+	}
+	return pbJob
+}
+
+// catalogError переводит ошибки импорта и выгрузки каталога в gRPC статусы
+func catalogError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrImportJobNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, service.ErrUnsupportedCatalog), errors.Is(err, service.ErrInvalidCatalogFile),
+		errors.Is(err, service.ErrCatalogFileTooLarge):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	default:
+		return err
+	}
+}
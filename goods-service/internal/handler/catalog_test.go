@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func TestImportCatalog(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	data := []byte("sku;price\nPUER;100\n")
+	createdAt := time.Unix(1700000000, 0)
+	mockService.On("ImportCatalog", ctx, "csv", data, true).Return(&model.ImportJob{
+		ID:        7,
+		Format:    "csv",
+		DryRun:    true,
+		Status:    model.ImportRunning,
+		TotalRows: 1,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}, nil)
+	mockService.On("ImportCatalog", ctx, "xls", data, false).Return(nil, service.ErrUnsupportedCatalog)
+	mockService.On("GetImportJob", ctx, int64(7)).Return(&model.ImportJob{
+		ID:            7,
+		Status:        model.ImportCompleted,
+		TotalRows:     1,
+		ProcessedRows: 1,
+		Failed:        1,
+		Errors:        []model.ImportRowError{{Row: 2, Column: "price", SKU: "PUER", Message: "price is required"}},
+		FinishedAt:    createdAt.Add(time.Second),
+	}, nil)
+	mockService.On("GetImportJob", ctx, int64(8)).Return(nil, service.ErrImportJobNotFound)
+
+	job, err := handler.ImportCatalog(ctx, &pb.ImportCatalogRequest{Data: data, Format: "csv", DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), job.Id)
+	assert.Equal(t, "running", job.Status)
+	assert.Equal(t, int64(0), job.FinishedAt)
+
+	_, err = handler.ImportCatalog(ctx, &pb.ImportCatalogRequest{Data: data, Format: "xls"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	job, err = handler.GetImportJob(ctx, &pb.GetImportJobRequest{Id: 7})
+	require.NoError(t, err)
+	require.Len(t, job.Errors, 1)
+	assert.Equal(t, int32(2), job.Errors[0].Row)
+	assert.Equal(t, "PUER", job.Errors[0].Sku)
+	assert.Equal(t, createdAt.Unix()+1, job.FinishedAt)
+
+	_, err = handler.GetImportJob(ctx, &pb.GetImportJobRequest{Id: 8})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestExportCatalog(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ExportCatalog", ctx, "csv").Return([]byte("sku\n"), nil)
+	mockService.On("ExportCatalog", ctx, "xlsx").Return([]byte("PK"), nil)
+
+	resp, err := handler.ExportCatalog(ctx, &pb.ExportCatalogRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("sku\n"), resp.Data)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.ContentType)
+	assert.Regexp(t, `^catalog-\d{8}\.csv$`, resp.Filename)
+
+	resp, err = handler.ExportCatalog(ctx, &pb.ExportCatalogRequest{Format: "xlsx"})
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", resp.ContentType)
+	mockService.AssertExpectations(t)
+}
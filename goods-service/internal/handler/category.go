@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func (h *GoodsHandler) CreateCategory(ctx context.Context, req *pb.CreateCategoryRequest) (*pb.Category, error) {
+	category, err := h.service.CreateCategory(ctx, &model.CreateCategoryRequest{
+		ParentID:    req.ParentId,
+		Slug:        req.Slug,
+		Name:        req.Name,
+		Description: req.Description,
+		Attributes:  attributeDefinitionsFromProto(req.Attributes),
+	})
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	return h.categoryToProto(category), nil
+}
+
+func (h *GoodsHandler) GetCategory(ctx context.Context, req *pb.GetCategoryRequest) (*pb.Category, error) {
+	var category *model.Category
+	var err error
+	switch {
+	case req.CategoryId != 0:
+		category, err = h.service.GetCategory(ctx, req.CategoryId)
+	case req.Slug != "":
+		category, err = h.service.GetCategoryBySlug(ctx, req.Slug)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "category_id or slug is required")
+	}
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	return h.categoryToProto(category), nil
+}
+
+func (h *GoodsHandler) ListCategories(ctx context.Context, req *pb.ListCategoriesRequest) (*pb.ListCategoriesResponse, error) {
+	categories, err := h.service.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pbCategories := make([]*pb.Category, len(categories))
+	for i, category := range categories {
+		pbCategories[i] = h.categoryToProto(category)
+	}
+
+	return &pb.ListCategoriesResponse{Categories: pbCategories}, nil
+}
+
+func (h *GoodsHandler) UpdateCategory(ctx context.Context, req *pb.UpdateCategoryRequest) (*pb.Category, error) {
+	category, err := h.service.UpdateCategory(ctx, req.Id, &model.UpdateCategoryRequest{
+		ParentID:    req.ParentId,
+		Slug:        req.Slug,
+		Name:        req.Name,
+		Description: req.Description,
+		Attributes:  attributeDefinitionsFromProto(req.Attributes),
+	})
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	return h.categoryToProto(category), nil
+}
+
+func (h *GoodsHandler) DeleteCategory(ctx context.Context, req *pb.DeleteCategoryRequest) (*pb.DeleteCategoryResponse, error) {
+	if err := h.service.DeleteCategory(ctx, req.CategoryId); err != nil {
+		return nil, categoryError(err)
+	}
+
+	return &pb.DeleteCategoryResponse{Success: true}, nil
+}
+
+func (h *GoodsHandler) SetGoodCategories(ctx context.Context, req *pb.SetGoodCategoriesRequest) (*pb.Good, error) {
+	good, err := h.service.SetGoodCategories(ctx, req.GoodId, req.CategoryIds)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrGoodNotFound):
+			return nil, status.Errorf(codes.NotFound, "good with id %d not found", req.GoodId)
+		case errors.Is(err, service.ErrCategoryNotFound):
+			// Неизвестная категория - ошибка в запросе, а не отсутствие самого товара
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		default:
+			return nil, err
+		}
+	}
+
+	return h.goodToProto(good), nil
+}
+
+func (h *GoodsHandler) categoryToProto(category *model.Category) *pb.Category {
+	return &pb.Category{
+		Id:          category.ID,
+		ParentId:    category.ParentID,
+		Slug:        category.Slug,
+		Name:        category.Name,
+		Description: category.Description,
+		Attributes:  attributeDefinitionsToProto(category.Attributes),
+		CreatedAt:   category.CreatedAt.Unix(),
+	}
+}
+
+// categoryError переводит ошибки сервиса по категориям в gRPC статусы
+func categoryError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, service.ErrSlugTaken):
+		return status.Errorf(codes.AlreadyExists, "%v", err)
+	case errors.Is(err, service.ErrCategoryHasChildren):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	case errors.Is(err, service.ErrParentNotFound),
+		errors.Is(err, service.ErrCategoryCycle),
+		errors.Is(err, service.ErrCategoryNameRequired),
+		errors.Is(err, service.ErrInvalidSlug),
+		errors.Is(err, service.ErrInvalidAttributeSchema):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func TestGetCategory_BySlug(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("GetCategoryBySlug", ctx, "green").
+		Return(&model.Category{ID: 2, ParentID: 1, Slug: "green", Name: "Зелёный"}, nil)
+
+	resp, err := handler.GetCategory(ctx, &pb.GetCategoryRequest{Slug: "green"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Id)
+	assert.Equal(t, int64(1), resp.ParentId)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetCategory_NoKey(t *testing.T) {
+	handler := New(new(MockGoodsService))
+
+	_, err := handler.GetCategory(context.Background(), &pb.GetCategoryRequest{})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCategoryErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{service.ErrCategoryNotFound, codes.NotFound},
+		{service.ErrSlugTaken, codes.AlreadyExists},
+		{service.ErrCategoryHasChildren, codes.FailedPrecondition},
+		{service.ErrCategoryCycle, codes.InvalidArgument},
+		{service.ErrInvalidSlug, codes.InvalidArgument},
+		{service.ErrParentNotFound, codes.InvalidArgument},
+		{fmt.Errorf("%w: origin has no name", service.ErrInvalidAttributeSchema), codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			mockService := new(MockGoodsService)
+			handler := New(mockService)
+			mockService.On("CreateCategory", mock.Anything, mock.Anything).Return(nil, tt.err)
+
+			_, err := handler.CreateCategory(context.Background(), &pb.CreateCategoryRequest{Slug: "green", Name: "Зелёный"})
+
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestSetGoodCategories_UnknownCategory(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("SetGoodCategories", ctx, int64(1), []int64{42}).Return(nil, service.ErrCategoryNotFound)
+
+	_, err := handler.SetGoodCategories(ctx, &pb.SetGoodCategoriesRequest{GoodId: 1, CategoryIds: []int64{42}})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListGoods_UnknownCategory(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ListGoods", ctx, &model.GoodsFilter{Limit: 10, CategoryIDs: []int64{42}}).
+		Return(nil, service.ErrCategoryNotFound)
+
+	_, err := handler.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10, CategoryId: 42})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"github.com/che1nov/tea-shop/goods-service/internal/model"
 	"github.com/che1nov/tea-shop/goods-service/internal/service"
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type GoodsHandler struct {
@@ -21,26 +24,28 @@ func New(svc service.GoodsServiceInterface) *GoodsHandler {
 
 func (h *GoodsHandler) CreateGood(ctx context.Context, req *pb.CreateGoodRequest) (*pb.Good, error) {
 	createReq := &model.CreateGoodRequest{
+		SKU:         req.Sku,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
 		Stock:       req.Stock,
+		CategoryIDs: req.CategoryIds,
+		Attributes:  req.Attributes,
 	}
 
 	good, err := h.service.CreateGood(ctx, createReq)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, service.ErrSKUTaken):
+			return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+		case errors.Is(err, service.ErrCategoryNotFound), errors.Is(err, service.ErrInvalidAttribute):
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		default:
+			return nil, err
+		}
 	}
 
-	return &pb.Good{
-		Id:          good.ID,
-		Sku:         good.SKU,
-		Name:        good.Name,
-		Description: good.Description,
-		Price:       good.Price,
-		Stock:       good.Stock,
-		CreatedAt:   good.CreatedAt.Unix(),
-	}, nil
+	return h.goodToProto(good), nil
 }
 
 func (h *GoodsHandler) GetGood(ctx context.Context, req *pb.GetGoodRequest) (*pb.Good, error) {
@@ -53,60 +58,94 @@ func (h *GoodsHandler) GetGood(ctx context.Context, req *pb.GetGoodRequest) (*pb
 		return nil, nil
 	}
 
-	return &pb.Good{
-		Id:          good.ID,
-		Sku:         good.SKU,
-		Name:        good.Name,
-		Description: good.Description,
-		Price:       good.Price,
-		Stock:       good.Stock,
-		CreatedAt:   good.CreatedAt.Unix(),
-	}, nil
+	return h.goodToProto(good), nil
 }
 
 func (h *GoodsHandler) ListGoods(ctx context.Context, req *pb.ListGoodsRequest) (*pb.ListGoodsResponse, error) {
-	goods, err := h.service.ListGoods(ctx, req.Limit, req.Offset)
-	if err != nil {
-		return nil, err
+	categoryIDs := req.CategoryIds
+	if req.CategoryId != 0 {
+		categoryIDs = append([]int64{req.CategoryId}, categoryIDs...)
 	}
 
-	total, err := h.service.GetTotalGoods(ctx)
+	page, err := h.service.ListGoods(ctx, &model.GoodsFilter{
+		Limit:         req.Limit,
+		Offset:        req.Offset,
+		CategoryIDs:   categoryIDs,
+		MinPrice:      req.MinPrice,
+		MaxPrice:      req.MaxPrice,
+		InStock:       req.InStock,
+		Sort:          req.Sort,
+		Attributes:    attributeFiltersFromProto(req.Attributes),
+		IncludeFacets: req.IncludeFacets,
+		Archived:      req.Archived,
+	})
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, service.ErrCategoryNotFound):
+			return nil, status.Errorf(codes.NotFound, "%v", err)
+		case errors.Is(err, service.ErrInvalidPriceRange),
+			errors.Is(err, service.ErrInvalidSort),
+			errors.Is(err, service.ErrInvalidAttributeFilter):
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		default:
+			return nil, err
+		}
 	}
 
-	pbGoods := make([]*pb.Good, len(goods))
-	for i, good := range goods {
-		pbGoods[i] = &pb.Good{
-			Id:          good.ID,
-			Sku:         good.SKU,
-			Name:        good.Name,
-			Description: good.Description,
-			Price:       good.Price,
-			Stock:       good.Stock,
-			CreatedAt:   good.CreatedAt.Unix(),
-		}
+	pbGoods := make([]*pb.Good, len(page.Goods))
+	for i, good := range page.Goods {
+		pbGoods[i] = h.goodToProto(good)
 	}
 
 	return &pb.ListGoodsResponse{
-		Goods: pbGoods,
-		Total: total,
+		Goods:  pbGoods,
+		Total:  page.Total,
+		Facets: facetsToProto(page.Facets),
 	}, nil
 }
 
+// facetsToProto переводит фасеты в ответ; nil, если фасеты не запрашивали
+func facetsToProto(facets *model.GoodsFacets) *pb.GoodsFacets {
+	if facets == nil {
+		return nil
+	}
+
+	pbFacets := &pb.GoodsFacets{
+		Categories: make([]*pb.CategoryFacet, len(facets.Categories)),
+		Prices:     make([]*pb.PriceFacet, len(facets.Prices)),
+		InStock:    facets.InStock,
+		OutOfStock: facets.OutOfStock,
+	}
+	for i, facet := range facets.Categories {
+		pbFacets.Categories[i] = &pb.CategoryFacet{CategoryId: facet.CategoryID, Count: facet.Count}
+	}
+	for i, facet := range facets.Prices {
+		pbFacets.Prices[i] = &pb.PriceFacet{Min: facet.Min, Max: facet.Max, Count: facet.Count}
+	}
+	return pbFacets
+}
+
 func (h *GoodsHandler) CheckStock(ctx context.Context, req *pb.CheckStockRequest) (*pb.CheckStockResponse, error) {
-	available, err := h.service.CheckStock(ctx, req.GoodId, req.Quantity)
+	availability, err := h.service.CheckStock(ctx, req.GoodId, req.VariantId, req.Quantity)
+	if errors.Is(err, service.ErrVariantRequired) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return &pb.CheckStockResponse{
-		Available: available,
+		Available:  availability.Available,
+		Stock:      availability.Stock,
+		Warehouses: warehouseStockToProto(availability.Warehouses),
 	}, nil
 }
 
 func (h *GoodsHandler) ReserveStock(ctx context.Context, req *pb.ReserveStockRequest) (*pb.ReserveStockResponse, error) {
-	success, err := h.service.ReserveStock(ctx, req.GoodId, req.Quantity, req.OrderId)
+	success, err := h.service.ReserveStock(ctx, req.GoodId, req.VariantId, req.Quantity, req.OrderId)
+	if errors.Is(err, service.ErrVariantRequired) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
 	if err != nil {
 		return &pb.ReserveStockResponse{
 			Success: false,
@@ -131,41 +170,86 @@ func (h *GoodsHandler) UpdateGood(ctx context.Context, req *pb.UpdateGoodRequest
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
-		Stock:       req.Stock,
 		SKU:         req.Sku,
+		Attributes:  req.Attributes,
 	}
 
 	good, err := h.service.UpdateGood(ctx, req.Id, updateReq)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, service.ErrSKUTaken):
+			return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+		case errors.Is(err, service.ErrInvalidAttribute):
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		default:
+			return nil, err
+		}
 	}
 
 	if good == nil {
 		return nil, nil
 	}
 
-	return &pb.Good{
-		Id:          good.ID,
-		Sku:         good.SKU,
-		Name:        good.Name,
-		Description: good.Description,
-		Price:       good.Price,
-		Stock:       good.Stock,
-		CreatedAt:   good.CreatedAt.Unix(),
-	}, nil
+	return h.goodToProto(good), nil
 }
 
 func (h *GoodsHandler) DeleteGood(ctx context.Context, req *pb.DeleteGoodRequest) (*pb.DeleteGoodResponse, error) {
-	err := h.service.DeleteGood(ctx, req.GoodId)
-	if err != nil {
-		return &pb.DeleteGoodResponse{
-			Success: false,
-			Message: err.Error(),
-		}, nil
+	if err := h.service.DeleteGood(ctx, req.GoodId); err != nil {
+		return nil, archiveError(err)
 	}
 
 	return &pb.DeleteGoodResponse{
 		Success: true,
-		Message: "Good deleted successfully",
+		Message: "Good archived successfully",
 	}, nil
 }
+
+func (h *GoodsHandler) RestoreGood(ctx context.Context, req *pb.RestoreGoodRequest) (*pb.Good, error) {
+	good, err := h.service.RestoreGood(ctx, req.GoodId)
+	if err != nil {
+		return nil, archiveError(err)
+	}
+
+	return h.goodToProto(good), nil
+}
+
+// archiveError переводит ошибки архивирования и восстановления товара в gRPC статусы
+func archiveError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrGoodNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, service.ErrGoodReserved):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	default:
+		return err
+	}
+}
+
+func (h *GoodsHandler) goodToProto(good *model.Good) *pb.Good {
+	variants := make([]*pb.Variant, len(good.Variants))
+	for i, variant := range good.Variants {
+		variants[i] = h.variantToProto(variant)
+	}
+	images := make([]*pb.GoodImage, len(good.Images))
+	for i, image := range good.Images {
+		images[i] = h.imageToProto(image)
+	}
+
+	pbGood := &pb.Good{
+		Id:          good.ID,
+		Sku:         good.SKU,
+		Name:        good.Name,
+		Description: good.Description,
+		Price:       good.Price,
+		Stock:       good.Stock,
+		CreatedAt:   good.CreatedAt.Unix(),
+		CategoryIds: good.CategoryIDs,
+		Variants:    variants,
+		Attributes:  good.Attributes,
+		Images:      images,
+	}
+	if !good.ArchivedAt.IsZero() {
+		pbGood.ArchivedAt = good.ArchivedAt.Unix()
+	}
+	return pbGood
+}
@@ -6,8 +6,12 @@ import (
 	"testing"
 
 	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
 )
@@ -33,29 +37,252 @@ func (m *MockGoodsService) GetGood(ctx context.Context, id int64) (*model.Good,
 	return args.Get(0).(*model.Good), args.Error(1)
 }
 
-func (m *MockGoodsService) ListGoods(ctx context.Context, limit, offset int32) ([]*model.Good, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockGoodsService) ListGoods(ctx context.Context, filter *model.GoodsFilter) (*model.GoodsPage, error) {
+	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*model.Good), args.Error(1)
+	return args.Get(0).(*model.GoodsPage), args.Error(1)
 }
 
-func (m *MockGoodsService) GetTotalGoods(ctx context.Context) (int32, error) {
+func (m *MockGoodsService) UpdateGood(ctx context.Context, id int64, req *model.UpdateGoodRequest) (*model.Good, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Good), args.Error(1)
+}
+
+func (m *MockGoodsService) DeleteGood(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockGoodsService) RestoreGood(ctx context.Context, id int64) (*model.Good, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Good), args.Error(1)
+}
+
+func (m *MockGoodsService) SearchGoods(ctx context.Context, query string, limit, offset int32) (*model.SearchResult, error) {
+	args := m.Called(ctx, query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SearchResult), args.Error(1)
+}
+
+func (m *MockGoodsService) CreateCategory(ctx context.Context, req *model.CreateCategoryRequest) (*model.Category, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockGoodsService) GetCategory(ctx context.Context, id int64) (*model.Category, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockGoodsService) GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	args := m.Called(ctx, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockGoodsService) ListCategories(ctx context.Context) ([]*model.Category, error) {
 	args := m.Called(ctx)
-	return args.Get(0).(int32), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Category), args.Error(1)
 }
 
-func (m *MockGoodsService) CheckStock(ctx context.Context, goodID int64, quantity int32) (bool, error) {
-	args := m.Called(ctx, goodID, quantity)
-	return args.Bool(0), args.Error(1)
+func (m *MockGoodsService) UpdateCategory(ctx context.Context, id int64, req *model.UpdateCategoryRequest) (*model.Category, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockGoodsService) DeleteCategory(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
 }
 
-func (m *MockGoodsService) ReserveStock(ctx context.Context, goodID int64, quantity int32, orderID int64) (bool, error) {
-	args := m.Called(ctx, goodID, quantity, orderID)
+func (m *MockGoodsService) SetGoodCategories(ctx context.Context, goodID int64, categoryIDs []int64) (*model.Good, error) {
+	args := m.Called(ctx, goodID, categoryIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Good), args.Error(1)
+}
+
+func (m *MockGoodsService) CheckStock(ctx context.Context, goodID, variantID int64, quantity int32) (*model.StockAvailability, error) {
+	args := m.Called(ctx, goodID, variantID, quantity)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StockAvailability), args.Error(1)
+}
+
+func (m *MockGoodsService) ReserveStock(ctx context.Context, goodID, variantID int64, quantity int32, orderID int64) (bool, error) {
+	args := m.Called(ctx, goodID, variantID, quantity, orderID)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockGoodsService) CreateVariant(ctx context.Context, goodID int64, req *model.CreateVariantRequest) (*model.Variant, error) {
+	args := m.Called(ctx, goodID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Variant), args.Error(1)
+}
+
+func (m *MockGoodsService) UpdateVariant(ctx context.Context, id int64, req *model.UpdateVariantRequest) (*model.Variant, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Variant), args.Error(1)
+}
+
+func (m *MockGoodsService) DeleteVariant(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockGoodsService) UploadGoodImage(ctx context.Context, goodID int64, data []byte) (*model.GoodImage, error) {
+	args := m.Called(ctx, goodID, data)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.GoodImage), args.Error(1)
+}
+
+func (m *MockGoodsService) DeleteGoodImage(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockGoodsService) ReorderGoodImages(ctx context.Context, goodID int64, imageIDs []int64) (*model.Good, error) {
+	args := m.Called(ctx, goodID, imageIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Good), args.Error(1)
+}
+
+// ImageURL не записывает вызов: адреса строятся для каждого изображения в ответе
+func (m *MockGoodsService) ImageURL(key string) string {
+	return "http://cdn.test/" + key
+}
+
+func (m *MockGoodsService) AdjustStock(ctx context.Context, req *model.StockAdjustmentRequest) (*model.StockMovement, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StockMovement), args.Error(1)
+}
+
+func (m *MockGoodsService) ReleaseStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StockMovement), args.Error(1)
+}
+
+func (m *MockGoodsService) CommitStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StockMovement), args.Error(1)
+}
+
+func (m *MockGoodsService) ListStockMovements(ctx context.Context, goodID, variantID int64, limit, offset int32) (*model.StockMovementsPage, error) {
+	args := m.Called(ctx, goodID, variantID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StockMovementsPage), args.Error(1)
+}
+
+func (m *MockGoodsService) VerifyStock(ctx context.Context) ([]*model.StockDiscrepancy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StockDiscrepancy), args.Error(1)
+}
+
+func (m *MockGoodsService) ReserveOrder(ctx context.Context, req *model.ReserveOrderRequest) ([]*model.Allocation, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Allocation), args.Error(1)
+}
+
+func (m *MockGoodsService) CreateWarehouse(ctx context.Context, req *model.CreateWarehouseRequest) (*model.Warehouse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Warehouse), args.Error(1)
+}
+
+func (m *MockGoodsService) UpdateWarehouse(ctx context.Context, id int64, req *model.UpdateWarehouseRequest) (*model.Warehouse, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Warehouse), args.Error(1)
+}
+
+func (m *MockGoodsService) ListWarehouses(ctx context.Context) ([]*model.Warehouse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Warehouse), args.Error(1)
+}
+
+func (m *MockGoodsService) ImportCatalog(ctx context.Context, format string, data []byte, dryRun bool) (*model.ImportJob, error) {
+	args := m.Called(ctx, format, data, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ImportJob), args.Error(1)
+}
+
+func (m *MockGoodsService) GetImportJob(ctx context.Context, id int64) (*model.ImportJob, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ImportJob), args.Error(1)
+}
+
+func (m *MockGoodsService) ExportCatalog(ctx context.Context, format string) ([]byte, error) {
+	args := m.Called(ctx, format)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
 func TestNew(t *testing.T) {
 	mockService := new(MockGoodsService)
 	handler := New(mockService)
@@ -125,8 +352,7 @@ func TestListGoods_Success(t *testing.T) {
 		{ID: 2, Name: "Good 2", Price: 20.0, Stock: 30},
 	}
 
-	mockService.On("ListGoods", ctx, int32(10), int32(0)).Return(goods, nil)
-	mockService.On("GetTotalGoods", ctx).Return(int32(2), nil)
+	mockService.On("ListGoods", ctx, &model.GoodsFilter{Limit: 10}).Return(&model.GoodsPage{Goods: goods, Total: 2}, nil)
 
 	resp, err := handler.ListGoods(ctx, req)
 
@@ -134,9 +360,68 @@ func TestListGoods_Success(t *testing.T) {
 	assert.NotNil(t, resp)
 	assert.Equal(t, 2, len(resp.Goods))
 	assert.Equal(t, int32(2), resp.Total)
+	assert.Nil(t, resp.Facets)
 	mockService.AssertExpectations(t)
 }
 
+func TestListGoods_FiltersAndFacets(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	filter := &model.GoodsFilter{
+		Limit:         10,
+		CategoryIDs:   []int64{1, 2},
+		MinPrice:      100,
+		MaxPrice:      900,
+		InStock:       true,
+		Sort:          model.SortPriceDesc,
+		IncludeFacets: true,
+	}
+	mockService.On("ListGoods", ctx, filter).Return(&model.GoodsPage{
+		Goods: []*model.Good{{ID: 1, Name: "Good 1", Price: 500, Stock: 5}},
+		Total: 1,
+		Facets: &model.GoodsFacets{
+			Categories: []model.CategoryFacet{{CategoryID: 1, Count: 1}},
+			Prices:     []model.PriceFacet{{Max: 500}, {Min: 500, Count: 1}},
+			InStock:    1,
+			OutOfStock: 3,
+		},
+	}, nil)
+
+	resp, err := handler.ListGoods(ctx, &pb.ListGoodsRequest{
+		Limit:         10,
+		CategoryId:    1,
+		CategoryIds:   []int64{2},
+		MinPrice:      100,
+		MaxPrice:      900,
+		InStock:       true,
+		Sort:          model.SortPriceDesc,
+		IncludeFacets: true,
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, resp.Facets)
+	assert.Equal(t, int64(1), resp.Facets.Categories[0].CategoryId)
+	assert.Len(t, resp.Facets.Prices, 2)
+	assert.Equal(t, int32(1), resp.Facets.Prices[1].Count)
+	assert.Equal(t, int32(3), resp.Facets.OutOfStock)
+	mockService.AssertExpectations(t)
+}
+
+func TestListGoods_InvalidFilter(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ListGoods", ctx, &model.GoodsFilter{Limit: 10, Sort: "rating"}).
+		Return(nil, service.ErrInvalidSort)
+
+	_, err := handler.ListGoods(ctx, &pb.ListGoodsRequest{Limit: 10, Sort: "rating"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestCheckStock_Available(t *testing.T) {
 	mockService := new(MockGoodsService)
 	handler := New(mockService)
@@ -147,13 +432,19 @@ func TestCheckStock_Available(t *testing.T) {
 		Quantity: 10,
 	}
 
-	mockService.On("CheckStock", ctx, int64(1), int32(10)).Return(true, nil)
+	mockService.On("CheckStock", ctx, int64(1), int64(0), int32(10)).Return(&model.StockAvailability{
+		Available:  true,
+		Stock:      12,
+		Warehouses: []*model.WarehouseStock{{WarehouseID: 1, WarehouseCode: "main", Stock: 12}},
+	}, nil)
 
 	resp, err := handler.CheckStock(ctx, req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.True(t, resp.Available)
+	assert.Equal(t, int32(12), resp.Stock)
+	assert.Equal(t, "main", resp.Warehouses[0].WarehouseCode)
 	mockService.AssertExpectations(t)
 }
 
@@ -167,7 +458,7 @@ func TestCheckStock_NotAvailable(t *testing.T) {
 		Quantity: 100,
 	}
 
-	mockService.On("CheckStock", ctx, int64(1), int32(100)).Return(false, nil)
+	mockService.On("CheckStock", ctx, int64(1), int64(0), int32(100)).Return(&model.StockAvailability{Stock: 12}, nil)
 
 	resp, err := handler.CheckStock(ctx, req)
 
@@ -188,7 +479,7 @@ func TestReserveStock_Success(t *testing.T) {
 		OrderId:  100,
 	}
 
-	mockService.On("ReserveStock", ctx, int64(1), int32(10), int64(100)).Return(true, nil)
+	mockService.On("ReserveStock", ctx, int64(1), int64(0), int32(10), int64(100)).Return(true, nil)
 
 	resp, err := handler.ReserveStock(ctx, req)
 
@@ -210,7 +501,7 @@ func TestReserveStock_InsufficientStock(t *testing.T) {
 		OrderId:  100,
 	}
 
-	mockService.On("ReserveStock", ctx, int64(1), int32(100), int64(100)).Return(false, nil)
+	mockService.On("ReserveStock", ctx, int64(1), int64(0), int32(100), int64(100)).Return(false, nil)
 
 	resp, err := handler.ReserveStock(ctx, req)
 
@@ -232,7 +523,7 @@ func TestReserveStock_Error(t *testing.T) {
 		OrderId:  100,
 	}
 
-	mockService.On("ReserveStock", ctx, int64(1), int32(10), int64(100)).Return(false, errors.New("database error"))
+	mockService.On("ReserveStock", ctx, int64(1), int64(0), int32(10), int64(100)).Return(false, errors.New("database error"))
 
 	resp, err := handler.ReserveStock(ctx, req)
 
@@ -243,3 +534,41 @@ func TestReserveStock_Error(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+
+func TestDeleteGood_Archives(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("DeleteGood", ctx, int64(1)).Return(nil)
+	mockService.On("DeleteGood", ctx, int64(2)).Return(service.ErrGoodReserved)
+	mockService.On("DeleteGood", ctx, int64(3)).Return(service.ErrGoodNotFound)
+
+	resp, err := handler.DeleteGood(ctx, &pb.DeleteGoodRequest{GoodId: 1})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+
+	_, err = handler.DeleteGood(ctx, &pb.DeleteGoodRequest{GoodId: 2})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = handler.DeleteGood(ctx, &pb.DeleteGoodRequest{GoodId: 3})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestRestoreGood(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("RestoreGood", ctx, int64(1)).Return(&model.Good{ID: 1, Name: "Сенча"}, nil)
+	mockService.On("RestoreGood", ctx, int64(2)).Return(nil, service.ErrGoodNotFound)
+
+	good, err := handler.RestoreGood(ctx, &pb.RestoreGoodRequest{GoodId: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Сенча", good.Name)
+	assert.Zero(t, good.ArchivedAt)
+
+	_, err = handler.RestoreGood(ctx, &pb.RestoreGoodRequest{GoodId: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mockService.AssertExpectations(t)
+}
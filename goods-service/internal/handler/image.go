@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func (h *GoodsHandler) UploadGoodImage(ctx context.Context, req *pb.UploadGoodImageRequest) (*pb.GoodImage, error) {
+	image, err := h.service.UploadGoodImage(ctx, req.GoodId, req.Data)
+	if err != nil {
+		return nil, imageError(err)
+	}
+
+	return h.imageToProto(image), nil
+}
+
+func (h *GoodsHandler) DeleteGoodImage(ctx context.Context, req *pb.DeleteGoodImageRequest) (*pb.DeleteGoodImageResponse, error) {
+	if err := h.service.DeleteGoodImage(ctx, req.ImageId); err != nil {
+		return nil, imageError(err)
+	}
+
+	return &pb.DeleteGoodImageResponse{Success: true}, nil
+}
+
+func (h *GoodsHandler) ReorderGoodImages(ctx context.Context, req *pb.ReorderGoodImagesRequest) (*pb.Good, error) {
+	good, err := h.service.ReorderGoodImages(ctx, req.GoodId, req.ImageIds)
+	if err != nil {
+		return nil, imageError(err)
+	}
+
+	return h.goodToProto(good), nil
+}
+
+func (h *GoodsHandler) imageToProto(image *model.GoodImage) *pb.GoodImage {
+	return &pb.GoodImage{
+		Id:           image.ID,
+		GoodId:       image.GoodID,
+		Position:     image.Position,
+		Url:          h.service.ImageURL(image.OriginalKey),
+		MediumUrl:    h.service.ImageURL(image.MediumKey),
+		ThumbnailUrl: h.service.ImageURL(image.ThumbnailKey),
+		Width:        image.Width,
+		Height:       image.Height,
+	}
+}
+
+// imageError переводит ошибки сервиса по изображениям в gRPC статусы
+func imageError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrGoodNotFound), errors.Is(err, service.ErrImageNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, service.ErrUnsupportedImage), errors.Is(err, service.ErrInvalidImageOrder):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, service.ErrImageTooLarge):
+		return status.Errorf(codes.ResourceExhausted, "%v", err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func TestUploadGoodImage_Success(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	data := []byte("png")
+	mockService.On("UploadGoodImage", ctx, int64(1), data).Return(&model.GoodImage{
+		ID:           3,
+		GoodID:       1,
+		Position:     2,
+		OriginalKey:  "goods/1/a/original.png",
+		MediumKey:    "goods/1/a/medium.png",
+		ThumbnailKey: "goods/1/a/thumbnail.png",
+		Width:        1600,
+		Height:       1200,
+	}, nil)
+
+	resp, err := handler.UploadGoodImage(ctx, &pb.UploadGoodImageRequest{GoodId: 1, Data: data})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.Id)
+	assert.Equal(t, int32(2), resp.Position)
+	assert.Equal(t, "http://cdn.test/goods/1/a/original.png", resp.Url)
+	assert.Equal(t, "http://cdn.test/goods/1/a/medium.png", resp.MediumUrl)
+	assert.Equal(t, "http://cdn.test/goods/1/a/thumbnail.png", resp.ThumbnailUrl)
+	assert.Equal(t, int32(1600), resp.Width)
+	mockService.AssertExpectations(t)
+}
+
+func TestReorderGoodImages_Success(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ReorderGoodImages", ctx, int64(1), []int64{4, 3}).Return(&model.Good{
+		ID: 1,
+		Images: []*model.GoodImage{
+			{ID: 4, GoodID: 1, Position: 1, ThumbnailKey: "goods/1/b/thumbnail.jpg"},
+			{ID: 3, GoodID: 1, Position: 2, ThumbnailKey: "goods/1/a/thumbnail.jpg"},
+		},
+	}, nil)
+
+	resp, err := handler.ReorderGoodImages(ctx, &pb.ReorderGoodImagesRequest{GoodId: 1, ImageIds: []int64{4, 3}})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Images, 2)
+	assert.Equal(t, int64(4), resp.Images[0].Id)
+	assert.Equal(t, "http://cdn.test/goods/1/b/thumbnail.jpg", resp.Images[0].ThumbnailUrl)
+	mockService.AssertExpectations(t)
+}
+
+func TestImageErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{service.ErrGoodNotFound, codes.NotFound},
+		{service.ErrImageNotFound, codes.NotFound},
+		{service.ErrUnsupportedImage, codes.InvalidArgument},
+		{service.ErrInvalidImageOrder, codes.InvalidArgument},
+		{service.ErrImageTooLarge, codes.ResourceExhausted},
+	}
+	for _, tt := range tests {
+		mockService := new(MockGoodsService)
+		handler := New(mockService)
+		ctx := context.Background()
+		mockService.On("DeleteGoodImage", ctx, int64(3)).Return(tt.err)
+
+		_, err := handler.DeleteGoodImage(ctx, &pb.DeleteGoodImageRequest{ImageId: 3})
+
+		assert.Equal(t, tt.code, status.Code(err), tt.err.Error())
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func (h *GoodsHandler) SearchGoods(ctx context.Context, req *pb.SearchGoodsRequest) (*pb.SearchGoodsResponse, error) {
+	result, err := h.service.SearchGoods(ctx, req.Query, req.Limit, req.Offset)
+	if err != nil {
+		if errors.Is(err, service.ErrEmptySearchQuery) || errors.Is(err, service.ErrSearchQueryTooLong) {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, err
+	}
+
+	hits := make([]*pb.SearchHit, len(result.Hits))
+	for i, hit := range result.Hits {
+		hits[i] = &pb.SearchHit{
+			Good:          h.goodToProto(hit.Good),
+			Rank:          hit.Rank,
+			NameHighlight: hit.NameHighlight,
+			Snippet:       hit.Snippet,
+		}
+	}
+
+	return &pb.SearchGoodsResponse{
+		Hits:  hits,
+		Total: result.Total,
+		Fuzzy: result.Fuzzy,
+	}, nil
+}
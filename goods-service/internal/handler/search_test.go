@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func TestSearchGoods_EmptyQuery(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("SearchGoods", ctx, "", int32(10), int32(0)).Return(nil, service.ErrEmptySearchQuery)
+
+	_, err := handler.SearchGoods(ctx, &pb.SearchGoodsRequest{Limit: 10})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSearchGoods_Hits(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("SearchGoods", ctx, "пуер", int32(10), int32(0)).Return(&model.SearchResult{
+		Hits:  []*model.SearchHit{{Good: &model.Good{ID: 1, Name: "Шу Пуэр"}, Rank: 0.4, NameHighlight: "Шу Пуэр"}},
+		Total: 1,
+		Fuzzy: true,
+	}, nil)
+
+	resp, err := handler.SearchGoods(ctx, &pb.SearchGoodsRequest{Query: "пуер", Limit: 10})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.Fuzzy)
+	assert.Equal(t, int64(1), resp.Hits[0].Good.Id)
+	assert.Equal(t, "Шу Пуэр", resp.Hits[0].NameHighlight)
+}
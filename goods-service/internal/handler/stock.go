@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func (h *GoodsHandler) ReleaseStock(ctx context.Context, req *pb.ReleaseStockRequest) (*pb.ReleaseStockResponse, error) {
+	movements, err := h.service.ReleaseStock(ctx, req.OrderId)
+	if err != nil {
+		return nil, stockError(err)
+	}
+
+	var released int32
+	for _, movement := range movements {
+		released += movement.Quantity
+	}
+	return &pb.ReleaseStockResponse{Success: true, Released: released}, nil
+}
+
+func (h *GoodsHandler) CommitStock(ctx context.Context, req *pb.CommitStockRequest) (*pb.CommitStockResponse, error) {
+	movements, err := h.service.CommitStock(ctx, req.OrderId)
+	if err != nil {
+		return nil, stockError(err)
+	}
+
+	var sold int32
+	for _, movement := range movements {
+		sold -= movement.Reserved
+	}
+	return &pb.CommitStockResponse{Success: true, Sold: sold}, nil
+}
+
+func (h *GoodsHandler) AdjustStock(ctx context.Context, req *pb.AdjustStockRequest) (*pb.StockMovement, error) {
+	movement, err := h.service.AdjustStock(ctx, &model.StockAdjustmentRequest{
+		GoodID:      req.GoodId,
+		VariantID:   req.VariantId,
+		Type:        req.Type,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		OrderID:     req.OrderId,
+		WarehouseID: req.WarehouseId,
+	})
+	if err != nil {
+		return nil, stockError(err)
+	}
+
+	return movementToProto(movement), nil
+}
+
+func (h *GoodsHandler) ListStockMovements(ctx context.Context, req *pb.ListStockMovementsRequest) (*pb.ListStockMovementsResponse, error) {
+	page, err := h.service.ListStockMovements(ctx, req.GoodId, req.VariantId, req.Limit, req.Offset)
+	if err != nil {
+		return nil, stockError(err)
+	}
+
+	movements := make([]*pb.StockMovement, len(page.Movements))
+	for i, movement := range page.Movements {
+		movements[i] = movementToProto(movement)
+	}
+	return &pb.ListStockMovementsResponse{Movements: movements, Total: page.Total}, nil
+}
+
+func (h *GoodsHandler) VerifyStock(ctx context.Context, req *pb.VerifyStockRequest) (*pb.VerifyStockResponse, error) {
+	discrepancies, err := h.service.VerifyStock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.VerifyStockResponse{Discrepancies: make([]*pb.StockDiscrepancy, len(discrepancies))}
+	for i, discrepancy := range discrepancies {
+		resp.Discrepancies[i] = &pb.StockDiscrepancy{
+			GoodId:         discrepancy.GoodID,
+			VariantId:      discrepancy.VariantID,
+			Sku:            discrepancy.SKU,
+			Stock:          discrepancy.Stock,
+			LedgerStock:    discrepancy.LedgerStock,
+			Reserved:       discrepancy.Reserved,
+			LedgerReserved: discrepancy.LedgerReserved,
+			WarehouseId:    discrepancy.WarehouseID,
+		}
+	}
+	return resp, nil
+}
+
+func movementToProto(movement *model.StockMovement) *pb.StockMovement {
+	return &pb.StockMovement{
+		Id:          movement.ID,
+		GoodId:      movement.GoodID,
+		VariantId:   movement.VariantID,
+		Type:        movement.Type,
+		Quantity:    movement.Quantity,
+		Reserved:    movement.Reserved,
+		Balance:     movement.Balance,
+		OrderId:     movement.OrderID,
+		Reason:      movement.Reason,
+		CreatedAt:   movement.CreatedAt.Unix(),
+		WarehouseId: movement.WarehouseID,
+	}
+}
+
+// stockError переводит ошибки складского журнала в gRPC статусы
+func stockError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrGoodNotFound), errors.Is(err, service.ErrVariantNotFound),
+		errors.Is(err, service.ErrWarehouseNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, service.ErrInvalidMovement), errors.Is(err, service.ErrVariantRequired),
+		errors.Is(err, service.ErrWarehouseRequired):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, service.ErrInsufficientStock):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	default:
+		return err
+	}
+}
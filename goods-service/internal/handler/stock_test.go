@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func TestAdjustStock_Success(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	createdAt := time.Unix(1700000000, 0)
+	req := &model.StockAdjustmentRequest{GoodID: 1, Type: model.MovementAdjustment, Quantity: -2, Reason: "брак"}
+	mockService.On("AdjustStock", ctx, req).Return(&model.StockMovement{
+		ID:        7,
+		GoodID:    1,
+		VariantID: 3,
+		Type:      model.MovementAdjustment,
+		Quantity:  -2,
+		Balance:   8,
+		Reason:    "брак",
+		CreatedAt: createdAt,
+	}, nil)
+
+	resp, err := handler.AdjustStock(ctx, &pb.AdjustStockRequest{GoodId: 1, Type: "adjustment", Quantity: -2, Reason: "брак"})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), resp.Id)
+	assert.Equal(t, int64(3), resp.VariantId)
+	assert.Equal(t, int32(8), resp.Balance)
+	assert.Equal(t, createdAt.Unix(), resp.CreatedAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdjustStock_Errors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{service.ErrInvalidMovement, codes.InvalidArgument},
+		{service.ErrVariantRequired, codes.InvalidArgument},
+		{service.ErrInsufficientStock, codes.FailedPrecondition},
+		{service.ErrGoodNotFound, codes.NotFound},
+		{service.ErrVariantNotFound, codes.NotFound},
+	}
+	for _, tt := range tests {
+		mockService := new(MockGoodsService)
+		handler := New(mockService)
+		ctx := context.Background()
+
+		mockService.On("AdjustStock", ctx, &model.StockAdjustmentRequest{GoodID: 1}).Return(nil, tt.err)
+
+		_, err := handler.AdjustStock(ctx, &pb.AdjustStockRequest{GoodId: 1})
+
+		assert.Equal(t, tt.code, status.Code(err), tt.err)
+	}
+}
+
+func TestReleaseAndCommitStock(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ReleaseStock", ctx, int64(5)).Return([]*model.StockMovement{
+		{Type: model.MovementRelease, Quantity: 2, Reserved: -2},
+		{Type: model.MovementRelease, Quantity: 1, Reserved: -1},
+	}, nil)
+	mockService.On("CommitStock", ctx, int64(6)).Return([]*model.StockMovement{
+		{Type: model.MovementSale, Reserved: -4},
+	}, nil)
+
+	released, err := handler.ReleaseStock(ctx, &pb.ReleaseStockRequest{OrderId: 5})
+	require.NoError(t, err)
+	assert.True(t, released.Success)
+	assert.Equal(t, int32(3), released.Released)
+
+	committed, err := handler.CommitStock(ctx, &pb.CommitStockRequest{OrderId: 6})
+	require.NoError(t, err)
+	assert.True(t, committed.Success)
+	assert.Equal(t, int32(4), committed.Sold)
+	mockService.AssertExpectations(t)
+}
+
+func TestListStockMovements_Success(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ListStockMovements", ctx, int64(1), int64(0), int32(10), int32(0)).Return(&model.StockMovementsPage{
+		Movements: []*model.StockMovement{
+			{ID: 2, Type: model.MovementReservation, Quantity: -1, Reserved: 1, OrderID: 9},
+			{ID: 1, Type: model.MovementReceipt, Quantity: 5},
+		},
+		Total: 2,
+	}, nil)
+
+	resp, err := handler.ListStockMovements(ctx, &pb.ListStockMovementsRequest{GoodId: 1, Limit: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Total)
+	require.Len(t, resp.Movements, 2)
+	assert.Equal(t, int64(9), resp.Movements[0].OrderId)
+	assert.Equal(t, "receipt", resp.Movements[1].Type)
+	mockService.AssertExpectations(t)
+}
+
+func TestListStockMovements_NotFound(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ListStockMovements", ctx, int64(42), int64(0), int32(0), int32(0)).Return(nil, service.ErrGoodNotFound)
+
+	_, err := handler.ListStockMovements(ctx, &pb.ListStockMovementsRequest{GoodId: 42})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestVerifyStock_Success(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("VerifyStock", ctx).Return([]*model.StockDiscrepancy{
+		{GoodID: 1, VariantID: 2, SKU: "GOOD-000001", Stock: 5, LedgerStock: 4},
+	}, nil)
+
+	resp, err := handler.VerifyStock(ctx, &pb.VerifyStockRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Discrepancies, 1)
+	assert.Equal(t, "GOOD-000001", resp.Discrepancies[0].Sku)
+	assert.Equal(t, int32(4), resp.Discrepancies[0].LedgerStock)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func (h *GoodsHandler) CreateVariant(ctx context.Context, req *pb.CreateVariantRequest) (*pb.Variant, error) {
+	variant, err := h.service.CreateVariant(ctx, req.GoodId, &model.CreateVariantRequest{
+		SKU:        req.Sku,
+		Price:      req.Price,
+		Stock:      req.Stock,
+		Attributes: req.Attributes,
+	})
+	if err != nil {
+		return nil, variantError(err)
+	}
+
+	return h.variantToProto(variant), nil
+}
+
+func (h *GoodsHandler) UpdateVariant(ctx context.Context, req *pb.UpdateVariantRequest) (*pb.Variant, error) {
+	variant, err := h.service.UpdateVariant(ctx, req.Id, &model.UpdateVariantRequest{
+		SKU:        req.Sku,
+		Price:      req.Price,
+		Stock:      req.Stock,
+		Attributes: req.Attributes,
+	})
+	if err != nil {
+		return nil, variantError(err)
+	}
+
+	return h.variantToProto(variant), nil
+}
+
+func (h *GoodsHandler) DeleteVariant(ctx context.Context, req *pb.DeleteVariantRequest) (*pb.DeleteVariantResponse, error) {
+	if err := h.service.DeleteVariant(ctx, req.VariantId); err != nil {
+		return nil, variantError(err)
+	}
+
+	return &pb.DeleteVariantResponse{Success: true}, nil
+}
+
+func (h *GoodsHandler) variantToProto(variant *model.Variant) *pb.Variant {
+	return &pb.Variant{
+		Id:         variant.ID,
+		GoodId:     variant.GoodID,
+		Sku:        variant.SKU,
+		Price:      variant.Price,
+		Stock:      variant.Stock,
+		Attributes: variant.Attributes,
+		Warehouses: warehouseStockToProto(variant.Warehouses),
+	}
+}
+
+// variantError переводит ошибки сервиса по вариантам в gRPC статусы
+func variantError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrGoodNotFound), errors.Is(err, service.ErrVariantNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, service.ErrSKUTaken):
+		return status.Errorf(codes.AlreadyExists, "%v", err)
+	case errors.Is(err, service.ErrLastVariant), errors.Is(err, service.ErrInsufficientStock), errors.Is(err, service.ErrVariantReserved):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	case errors.Is(err, service.ErrInvalidVariant):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	default:
+		return err
+	}
+}
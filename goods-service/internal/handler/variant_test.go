@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func TestCreateVariant_Success(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	attributes := map[string]string{"weight": "100 г"}
+	mockService.On("CreateVariant", ctx, int64(1), &model.CreateVariantRequest{Price: 600, Stock: 5, Attributes: attributes}).
+		Return(&model.Variant{ID: 7, GoodID: 1, SKU: "GOOD-000001-2", Price: 600, Stock: 5, Attributes: attributes}, nil)
+
+	resp, err := handler.CreateVariant(ctx, &pb.CreateVariantRequest{GoodId: 1, Price: 600, Stock: 5, Attributes: attributes})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), resp.Id)
+	assert.Equal(t, "100 г", resp.Attributes["weight"])
+	mockService.AssertExpectations(t)
+}
+
+func TestVariantErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{service.ErrGoodNotFound, codes.NotFound},
+		{service.ErrVariantNotFound, codes.NotFound},
+		{service.ErrSKUTaken, codes.AlreadyExists},
+		{service.ErrLastVariant, codes.FailedPrecondition},
+		{service.ErrVariantReserved, codes.FailedPrecondition},
+		{service.ErrInvalidVariant, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		mockService := new(MockGoodsService)
+		handler := New(mockService)
+		ctx := context.Background()
+		mockService.On("DeleteVariant", ctx, int64(7)).Return(tt.err)
+
+		_, err := handler.DeleteVariant(ctx, &pb.DeleteVariantRequest{VariantId: 7})
+
+		assert.Equal(t, tt.code, status.Code(err), tt.err.Error())
+	}
+}
+
+func TestReserveStock_VariantRequired(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ReserveStock", ctx, int64(1), int64(0), int32(1), int64(100)).Return(false, service.ErrVariantRequired)
+
+	_, err := handler.ReserveStock(ctx, &pb.ReserveStockRequest{GoodId: 1, Quantity: 1, OrderId: 100})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
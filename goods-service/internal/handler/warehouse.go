@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func (h *GoodsHandler) CreateWarehouse(ctx context.Context, req *pb.CreateWarehouseRequest) (*pb.Warehouse, error) {
+	warehouse, err := h.service.CreateWarehouse(ctx, &model.CreateWarehouseRequest{
+		Code:     req.Code,
+		Name:     req.Name,
+		Address:  req.Address,
+		Regions:  req.Regions,
+		Priority: req.Priority,
+	})
+	if err != nil {
+		return nil, warehouseError(err)
+	}
+
+	return warehouseToProto(warehouse), nil
+}
+
+func (h *GoodsHandler) UpdateWarehouse(ctx context.Context, req *pb.UpdateWarehouseRequest) (*pb.Warehouse, error) {
+	warehouse, err := h.service.UpdateWarehouse(ctx, req.Id, &model.UpdateWarehouseRequest{
+		Name:     req.Name,
+		Address:  req.Address,
+		Regions:  req.Regions,
+		Priority: req.Priority,
+	})
+	if err != nil {
+		return nil, warehouseError(err)
+	}
+
+	return warehouseToProto(warehouse), nil
+}
+
+func (h *GoodsHandler) ListWarehouses(ctx context.Context, req *pb.ListWarehousesRequest) (*pb.ListWarehousesResponse, error) {
+	warehouses, err := h.service.ListWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListWarehousesResponse{Warehouses: make([]*pb.Warehouse, len(warehouses))}
+	for i, warehouse := range warehouses {
+		resp.Warehouses[i] = warehouseToProto(warehouse)
+	}
+	return resp, nil
+}
+
+func (h *GoodsHandler) ReserveOrder(ctx context.Context, req *pb.ReserveOrderRequest) (*pb.ReserveOrderResponse, error) {
+	items := make([]*model.ReservationItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = &model.ReservationItem{GoodID: item.GoodId, VariantID: item.VariantId, Quantity: item.Quantity}
+	}
+
+	allocations, err := h.service.ReserveOrder(ctx, &model.ReserveOrderRequest{
+		OrderID:  req.OrderId,
+		Items:    items,
+		Address:  req.Address,
+		Strategy: req.Strategy,
+	})
+	if err != nil {
+		return nil, warehouseError(err)
+	}
+	if allocations == nil {
+		return &pb.ReserveOrderResponse{Success: false}, nil
+	}
+
+	resp := &pb.ReserveOrderResponse{Success: true, Allocations: make([]*pb.Allocation, len(allocations))}
+	for i, allocation := range allocations {
+		resp.Allocations[i] = &pb.Allocation{
+			GoodId:        allocation.GoodID,
+			VariantId:     allocation.VariantID,
+			WarehouseId:   allocation.WarehouseID,
+			WarehouseCode: allocation.WarehouseCode,
+			Quantity:      allocation.Quantity,
+		}
+	}
+	return resp, nil
+}
+
+func warehouseToProto(warehouse *model.Warehouse) *pb.Warehouse {
+	return &pb.Warehouse{
+		Id:        warehouse.ID,
+		Code:      warehouse.Code,
+		Name:      warehouse.Name,
+		Address:   warehouse.Address,
+		Regions:   warehouse.Regions,
+		Priority:  warehouse.Priority,
+		CreatedAt: warehouse.CreatedAt.Unix(),
+		UpdatedAt: warehouse.UpdatedAt.Unix(),
+	}
+}
+
+func warehouseStockToProto(levels []*model.WarehouseStock) []*pb.WarehouseStock {
+	pbLevels := make([]*pb.WarehouseStock, len(levels))
+	for i, level := range levels {
+		pbLevels[i] = &pb.WarehouseStock{
+			WarehouseId:   level.WarehouseID,
+			WarehouseCode: level.WarehouseCode,
+			Stock:         level.Stock,
+		}
+	}
+	return pbLevels
+}
+
+// warehouseError переводит ошибки складов и резервирования заказа в gRPC статусы
+func warehouseError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrWarehouseNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, service.ErrWarehouseCodeTaken):
+		return status.Errorf(codes.AlreadyExists, "%v", err)
+	case errors.Is(err, service.ErrInvalidWarehouse), errors.Is(err, service.ErrInvalidStrategy),
+		errors.Is(err, service.ErrInvalidReservation), errors.Is(err, service.ErrVariantRequired):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	default:
+		return err
+	}
+}
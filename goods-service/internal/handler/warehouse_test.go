@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+func TestCreateWarehouse_Success(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	createdAt := time.Unix(1700000000, 0)
+	req := &model.CreateWarehouseRequest{Code: "spb", Name: "Петербург", Regions: []string{"Санкт-Петербург"}, Priority: 10}
+	mockService.On("CreateWarehouse", ctx, req).Return(&model.Warehouse{
+		ID:        2,
+		Code:      "spb",
+		Name:      "Петербург",
+		Regions:   []string{"Санкт-Петербург"},
+		Priority:  10,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}, nil)
+
+	resp, err := handler.CreateWarehouse(ctx, &pb.CreateWarehouseRequest{
+		Code:     "spb",
+		Name:     "Петербург",
+		Regions:  []string{"Санкт-Петербург"},
+		Priority: 10,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Id)
+	assert.Equal(t, []string{"Санкт-Петербург"}, resp.Regions)
+	assert.Equal(t, createdAt.Unix(), resp.CreatedAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestWarehouseErrors(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("CreateWarehouse", ctx, &model.CreateWarehouseRequest{Code: "main", Name: "Основной"}).Return(nil, service.ErrWarehouseCodeTaken)
+	mockService.On("UpdateWarehouse", ctx, int64(42), &model.UpdateWarehouseRequest{Name: "Нет"}).Return(nil, service.ErrWarehouseNotFound)
+
+	_, err := handler.CreateWarehouse(ctx, &pb.CreateWarehouseRequest{Code: "main", Name: "Основной"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = handler.UpdateWarehouse(ctx, &pb.UpdateWarehouseRequest{Id: 42, Name: "Нет"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestReserveOrder(t *testing.T) {
+	mockService := new(MockGoodsService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ReserveOrder", ctx, &model.ReserveOrderRequest{
+		OrderID: 1,
+		Items:   []*model.ReservationItem{{GoodID: 1, Quantity: 5}},
+		Address: "Москва",
+	}).Return([]*model.Allocation{
+		{GoodID: 1, VariantID: 11, WarehouseID: 1, WarehouseCode: "main", Quantity: 3},
+		{GoodID: 1, VariantID: 11, WarehouseID: 2, WarehouseCode: "spb", Quantity: 2},
+	}, nil)
+	mockService.On("ReserveOrder", ctx, &model.ReserveOrderRequest{
+		OrderID: 2,
+		Items:   []*model.ReservationItem{{GoodID: 1, Quantity: 50}},
+	}).Return(nil, nil)
+	mockService.On("ReserveOrder", ctx, &model.ReserveOrderRequest{
+		OrderID:  3,
+		Items:    []*model.ReservationItem{{GoodID: 1, Quantity: 1}},
+		Strategy: "cheapest",
+	}).Return(nil, service.ErrInvalidStrategy)
+
+	resp, err := handler.ReserveOrder(ctx, &pb.ReserveOrderRequest{
+		OrderId: 1,
+		Items:   []*pb.ReserveOrderItem{{GoodId: 1, Quantity: 5}},
+		Address: "Москва",
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+	require.Len(t, resp.Allocations, 2)
+	assert.Equal(t, "spb", resp.Allocations[1].WarehouseCode)
+	assert.Equal(t, int32(2), resp.Allocations[1].Quantity)
+
+	resp, err = handler.ReserveOrder(ctx, &pb.ReserveOrderRequest{OrderId: 2, Items: []*pb.ReserveOrderItem{{GoodId: 1, Quantity: 50}}})
+	require.NoError(t, err)
+	assert.False(t, resp.Success)
+	assert.Empty(t, resp.Allocations)
+
+	_, err = handler.ReserveOrder(ctx, &pb.ReserveOrderRequest{OrderId: 3, Items: []*pb.ReserveOrderItem{{GoodId: 1, Quantity: 1}}, Strategy: "cheapest"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,156 @@
+// Package imaging декодирует загруженные изображения товаров и уменьшает их до размеров витрины
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// ThumbnailSize - наибольшая сторона миниатюры для списков товаров
+	ThumbnailSize = 200
+	// MediumSize - наибольшая сторона изображения для карточки товара
+	MediumSize = 800
+	// MaxPixels ограничивает размер декодируемого изображения: 50 мегапикселей
+	MaxPixels = 50_000_000
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// Image - декодированное изображение и формат, в котором сохраняются его копии
+type Image struct {
+	RGBA   *image.RGBA
+	Format string // jpeg или png
+}
+
+// Decode декодирует JPEG, PNG или GIF. Размер проверяется по заголовку до декодирования,
+// чтобы небольшой файл не развернулся в гигабайты пикселей
+func Decode(data []byte) (*Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	// Прозрачность JPEG не поддерживает, поэтому PNG и GIF сохраняются в PNG
+	if format != "jpeg" {
+		format = "png"
+	}
+	return &Image{RGBA: rgba, Format: format}, nil
+}
+
+// Width возвращает ширину изображения
+func (i *Image) Width() int { return i.RGBA.Bounds().Dx() }
+
+// Height возвращает высоту изображения
+func (i *Image) Height() int { return i.RGBA.Bounds().Dy() }
+
+// ContentType возвращает MIME-тип копий изображения
+func (i *Image) ContentType() string {
+	return "image/" + i.Format
+}
+
+// Extension возвращает расширение файлов копий изображения
+func (i *Image) Extension() string {
+	if i.Format == "jpeg" {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// Fit уменьшает изображение так, чтобы наибольшая сторона не превышала size, с сохранением
+// пропорций. Изображения меньше size не увеличиваются
+func (i *Image) Fit(size int) *Image {
+	width, height := i.Width(), i.Height()
+	if width <= size && height <= size {
+		return i
+	}
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+	return &Image{RGBA: downscale(i.RGBA, width, height), Format: i.Format}
+}
+
+// Encode кодирует изображение в его формат
+func (i *Image) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if i.Format == "jpeg" {
+		err = jpeg.Encode(&buf, i.RGBA, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, i.RGBA)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// downscale уменьшает изображение усреднением: каждый пиксель результата - среднее
+// пикселей исходника, которые на него приходятся
+func downscale(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, (y+1)*srcHeight/height
+		y1 = max(y1, y0+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, (x+1)*srcWidth/width
+			x1 = max(x1, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					pixel := src.Pix[offset : offset+4 : offset+4]
+					r += uint64(pixel[0])
+					g += uint64(pixel[1])
+					b += uint64(pixel[2])
+					a += uint64(pixel[3])
+					n++
+					offset += 4
+				}
+			}
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / n)
+			dst.Pix[offset+1] = uint8(g / n)
+			dst.Pix[offset+2] = uint8(b / n)
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,85 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDecodeAndFit(t *testing.T) {
+	img, err := Decode(encodePNG(t, 1600, 400))
+	require.NoError(t, err)
+	assert.Equal(t, "png", img.Format)
+	assert.Equal(t, "image/png", img.ContentType())
+	assert.Equal(t, ".png", img.Extension())
+
+	medium := img.Fit(MediumSize)
+	assert.Equal(t, 800, medium.Width())
+	assert.Equal(t, 200, medium.Height())
+	thumbnail := img.Fit(ThumbnailSize)
+	assert.Equal(t, 200, thumbnail.Width())
+	assert.Equal(t, 50, thumbnail.Height())
+
+	// Усреднение однотонного изображения сохраняет цвет
+	assert.Equal(t, color.RGBA{R: 200, G: 100, B: 50, A: 255}, thumbnail.RGBA.RGBAAt(10, 10))
+
+	data, err := thumbnail.Encode()
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 200, 50), decoded.Bounds())
+}
+
+func TestFit_NoUpscale(t *testing.T) {
+	img, err := Decode(encodePNG(t, 120, 300))
+	require.NoError(t, err)
+	assert.Same(t, img, img.Fit(MediumSize))
+
+	thumbnail := img.Fit(ThumbnailSize)
+	assert.Equal(t, 80, thumbnail.Width())
+	assert.Equal(t, 200, thumbnail.Height())
+}
+
+func TestDecode_JPEG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil))
+
+	img, err := Decode(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", img.ContentType())
+	assert.Equal(t, ".jpg", img.Extension())
+}
+
+func TestDecode_Errors(t *testing.T) {
+	_, err := Decode([]byte("not an image"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	// Заголовок PNG с размером 100000x100000 без данных изображения
+	data := encodePNG(t, 1, 1)
+	data[16], data[17], data[18], data[19] = 0, 1, 0x86, 0xa0
+	data[20], data[21], data[22], data[23] = 0, 1, 0x86, 0xa0
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	_, err = Decode(data)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
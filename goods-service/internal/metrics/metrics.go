@@ -0,0 +1,44 @@
+// Package metrics - бизнес метрики каталога и склада для Prometheus.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reservations - попытки резервирования по результату (reserved, insufficient, error)
+var Reservations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "stock_reservations_total",
+	Help: "Total number of stock reservation attempts, by result.",
+}, []string{"result"})
+
+// ReservedUnits - количество зарезервированных единиц товара
+var ReservedUnits = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "stock_reserved_units_total",
+	Help: "Total number of reserved stock units.",
+})
+
+// Searches - поисковые запросы по результату (found - полнотекстовый поиск, fuzzy - по опечаткам, empty - ничего)
+var Searches = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "goods_search_requests_total",
+	Help: "Total number of catalog search requests, by result.",
+}, []string{"result"})
+
+// StockMovements - движения по складскому журналу по типу (receipt, reservation, release, sale, adjustment, return)
+var StockMovements = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "stock_movements_total",
+	Help: "Total number of inventory ledger movements, by type.",
+}, []string{"type"})
+
+// ReservationWarehouses - число складов, с которых собирается зарезервированный заказ
+var ReservationWarehouses = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "stock_reservation_warehouses",
+	Help:    "Number of warehouses a reserved order is shipped from.",
+	Buckets: []float64{1, 2, 3, 4, 5},
+})
+
+// CatalogImportRows - строки импорта каталога по результату (created, updated, failed)
+var CatalogImportRows = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "catalog_import_rows_total",
+	Help: "Total number of catalog import rows, by result.",
+}, []string{"result"})
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+)
+
+// collectTimeout ограничивает запрос остатков при скрейпе
+const collectTimeout = 5 * time.Second
+
+// StockSource отдает текущие остатки; реализуется репозиторием товаров
+type StockSource interface {
+	StockLevels(ctx context.Context) ([]*model.StockLevel, error)
+}
+
+// StockCollector читает остатки из БД в момент скрейпа, поэтому значения не расходятся
+// с базой при изменениях в обход сервиса (ручные правки, другие инстансы)
+type StockCollector struct {
+	source    StockSource
+	threshold int32
+
+	stockLevel *prometheus.Desc
+	lowStock   *prometheus.Desc
+	lowItems   *prometheus.Desc
+	up         *prometheus.Desc
+}
+
+// NewStockCollector создает коллектор; товар считается заканчивающимся при остатке <= threshold
+func NewStockCollector(source StockSource, threshold int32) *StockCollector {
+	return &StockCollector{
+		source:    source,
+		threshold: threshold,
+		stockLevel: prometheus.NewDesc(
+			"goods_stock_level",
+			"Current stock level per SKU.",
+			[]string{"sku", "name"}, nil,
+		),
+		lowStock: prometheus.NewDesc(
+			"goods_low_stock",
+			"1 if the SKU stock is at or below the low-stock threshold, 0 otherwise.",
+			[]string{"sku", "name"}, nil,
+		),
+		lowItems: prometheus.NewDesc(
+			"goods_low_stock_items",
+			"Number of SKUs at or below the low-stock threshold.",
+			nil, prometheus.Labels{"threshold": strconv.Itoa(int(threshold))},
+		),
+		up: prometheus.NewDesc(
+			"goods_stock_collector_up",
+			"1 if stock levels were read from the database successfully.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *StockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.stockLevel
+	ch <- c.lowStock
+	ch <- c.lowItems
+	ch <- c.up
+}
+
+func (c *StockCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	levels, err := c.source.StockLevels(ctx)
+	if err != nil {
+		logger.Error("Failed to collect stock levels", "error", err)
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+
+	var low int
+	for _, level := range levels {
+		ch <- prometheus.MustNewConstMetric(c.stockLevel, prometheus.GaugeValue, float64(level.Stock), level.SKU, level.Name)
+
+		isLow := 0.0
+		if level.Stock <= c.threshold {
+			isLow = 1
+			low++
+		}
+		ch <- prometheus.MustNewConstMetric(c.lowStock, prometheus.GaugeValue, isLow, level.SKU, level.Name)
+	}
+	ch <- prometheus.MustNewConstMetric(c.lowItems, prometheus.GaugeValue, float64(low))
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+type fakeStockSource struct {
+	levels []*model.StockLevel
+	err    error
+}
+
+func (f *fakeStockSource) StockLevels(ctx context.Context) ([]*model.StockLevel, error) {
+	return f.levels, f.err
+}
+
+func TestStockCollector_Collect(t *testing.T) {
+	collector := NewStockCollector(&fakeStockSource{levels: []*model.StockLevel{
+		{SKU: "GOOD-000001", Name: "Пуэр", Stock: 42},
+		{SKU: "GOOD-000002", Name: "Улун", Stock: 3},
+	}}, 10)
+
+	expected := `
+# HELP goods_low_stock 1 if the SKU stock is at or below the low-stock threshold, 0 otherwise.
+# TYPE goods_low_stock gauge
+goods_low_stock{name="Пуэр",sku="GOOD-000001"} 0
+goods_low_stock{name="Улун",sku="GOOD-000002"} 1
+# HELP goods_low_stock_items Number of SKUs at or below the low-stock threshold.
+# TYPE goods_low_stock_items gauge
+goods_low_stock_items{threshold="10"} 1
+# HELP goods_stock_collector_up 1 if stock levels were read from the database successfully.
+# TYPE goods_stock_collector_up gauge
+goods_stock_collector_up 1
+# HELP goods_stock_level Current stock level per SKU.
+# TYPE goods_stock_level gauge
+goods_stock_level{name="Пуэр",sku="GOOD-000001"} 42
+goods_stock_level{name="Улун",sku="GOOD-000002"} 3
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected))
+
+	assert.NoError(t, err)
+}
+
+func TestStockCollector_SourceError(t *testing.T) {
+	collector := NewStockCollector(&fakeStockSource{err: errors.New("connection refused")}, 10)
+
+	expected := `
+# HELP goods_stock_collector_up 1 if stock levels were read from the database successfully.
+# TYPE goods_stock_collector_up gauge
+goods_stock_collector_up 0
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected))
+
+	assert.NoError(t, err)
+}
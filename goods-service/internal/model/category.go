@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// Category - категория каталога. Категории образуют дерево, ParentID = 0 у корневых
+type Category struct {
+	ID          int64
+	ParentID    int64
+	Slug        string
+	Name        string
+	Description string
+	// Attributes - схема атрибутов товаров категории; подкатегории наследуют её
+	Attributes []AttributeDefinition
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Типы атрибутов
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeBoolean = "boolean"
+	AttributeEnum    = "enum"
+)
+
+// AttributeDefinition - атрибут в схеме категории: температура заваривания, происхождение, год сбора
+type AttributeDefinition struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Unit - единица измерения числового атрибута: "°C", "мин", "%"
+	Unit string `json:"unit,omitempty"`
+	// AllowedValues - допустимые значения атрибута типа enum
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	Required      bool     `json:"required,omitempty"`
+}
+
+type CreateCategoryRequest struct {
+	ParentID    int64
+	Slug        string
+	Name        string
+	Description string
+	Attributes  []AttributeDefinition
+}
+
+type UpdateCategoryRequest struct {
+	ParentID    int64
+	Slug        string
+	Name        string
+	Description string
+	Attributes  []AttributeDefinition
+}
@@ -2,6 +2,7 @@ package model
 
 import "time"
 
+// Good - товар каталога. Price и Stock считаются по вариантам: минимальная цена и суммарный остаток
 type Good struct {
 	ID          int64
 	SKU         string
@@ -9,8 +10,87 @@ type Good struct {
 	Description string
 	Price       float64
 	Stock       int32
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	CategoryIDs []int64
+	Variants    []*Variant
+	// Attributes - значения атрибутов по схеме категорий товара в каноническом виде: {"brew_temp": "85"}
+	Attributes map[string]string
+	// Images - галерея товара по порядку Position
+	Images    []*GoodImage
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// ArchivedAt - когда товар убран из каталога; нулевое время - товар в каталоге
+	ArchivedAt time.Time
+}
+
+// Порядок выдачи товаров; пустая строка - по id
+const (
+	SortPriceAsc   = "price_asc"
+	SortPriceDesc  = "price_desc"
+	SortNewest     = "newest"
+	SortName       = "name"
+	SortPopularity = "popularity"
+)
+
+// GoodsFilter - параметры выборки товаров
+type GoodsFilter struct {
+	Limit  int32
+	Offset int32
+	// CategoryIDs - категории из запроса: подходят товары любой из них или их подкатегорий
+	CategoryIDs []int64
+	// SubtreeCategoryIDs заполняет сервис: CategoryIDs вместе с подкатегориями. По нему фильтрует репозиторий
+	SubtreeCategoryIDs []int64
+	// MinPrice и MaxPrice - диапазон цены включительно, 0 - без ограничения
+	MinPrice float64
+	MaxPrice float64
+	InStock  bool
+	Sort     string
+	// Attributes - фильтры по атрибутам, товар должен подходить под все
+	Attributes []AttributeFilter
+	// IncludeFacets - посчитать фасеты для панели фильтров
+	IncludeFacets bool
+	// Archived - выбрать архивные товары вместо товаров каталога
+	Archived bool
+	// AfterID - выбрать товары с id больше AfterID. С сортировкой по умолчанию (по id) позволяет
+	// обходить каталог страницами без пропусков и повторов, когда товары добавляются или архивируются
+	AfterID int64
+}
+
+// AttributeFilter - фильтр по атрибуту: значение из Values (любое) и, для числовых атрибутов, диапазон [Min, Max].
+// nil в Min и Max - без ограничения
+type AttributeFilter struct {
+	Code   string
+	Values []string
+	Min    *float64
+	Max    *float64
+}
+
+// GoodsPage - страница каталога
+type GoodsPage struct {
+	Goods  []*Good
+	Total  int32
+	Facets *GoodsFacets
+}
+
+// GoodsFacets - счетчики для панели фильтров. Каждый фасет считается без своего фильтра:
+// категории - без фильтра по категориям, цены - без диапазона цены, наличие - без InStock
+type GoodsFacets struct {
+	Categories []CategoryFacet
+	Prices     []PriceFacet
+	InStock    int32
+	OutOfStock int32
+}
+
+// CategoryFacet - количество товаров категории вместе с подкатегориями
+type CategoryFacet struct {
+	CategoryID int64
+	Count      int32
+}
+
+// PriceFacet - количество товаров с ценой в [Min, Max); Max = 0 - без верхней границы
+type PriceFacet struct {
+	Min   float64
+	Max   float64
+	Count int32
 }
 
 type CreateGoodRequest struct {
@@ -18,20 +98,49 @@ type CreateGoodRequest struct {
 	Description string
 	Price       float64
 	Stock       int32
+	SKU         string
+	CategoryIDs []int64
+	Attributes  map[string]string
 }
 
 type UpdateGoodRequest struct {
 	Name        string
 	Description string
 	Price       float64
-	Stock       int32
 	SKU         string
+	Attributes  map[string]string
 }
 
 type StockReservation struct {
-	ID        int64
-	GoodID    int64
-	OrderID   int64
-	Quantity  int32
+	ID          int64
+	GoodID      int64
+	VariantID   int64
+	WarehouseID int64
+	OrderID     int64
+	Quantity    int32
+	// Status - reserved, released или sold
+	Status    string
 	CreatedAt time.Time
 }
+
+// StockLevel - остаток варианта товара для метрик склада
+type StockLevel struct {
+	SKU   string
+	Name  string
+	Stock int32
+}
+
+// SearchHit - найденный товар. NameHighlight и Snippet содержат совпадения, обрамлённые <b></b>
+type SearchHit struct {
+	Good          *Good
+	Rank          float64
+	NameHighlight string
+	Snippet       string
+}
+
+// SearchResult - страница результатов поиска. Fuzzy - точных совпадений нет, найдены похожие названия
+type SearchResult struct {
+	Hits  []*SearchHit
+	Total int32
+	Fuzzy bool
+}
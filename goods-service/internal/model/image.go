@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// GoodImage - изображение из галереи товара. Файлы хранятся в хранилище под ключами:
+// исходник, копия для карточки товара (Medium) и миниатюра для списков (Thumbnail)
+type GoodImage struct {
+	ID     int64
+	GoodID int64
+	// Position - порядок в галерее, первое изображение - обложка товара
+	Position     int32
+	OriginalKey  string
+	MediumKey    string
+	ThumbnailKey string
+	ContentType  string
+	// Width и Height - размер исходника в пикселях
+	Width     int32
+	Height    int32
+	CreatedAt time.Time
+}
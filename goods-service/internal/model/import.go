@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// Статусы задания импорта
+const (
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// ImportJob - задание импорта каталога из таблицы. Строки обрабатываются в фоне,
+// ProcessedRows из TotalRows - прогресс. При DryRun строки только проверяются:
+// Created и Updated - сколько товаров было бы создано и изменено
+type ImportJob struct {
+	ID            int64
+	Format        string
+	DryRun        bool
+	Status        string
+	TotalRows     int32
+	ProcessedRows int32
+	Created       int32
+	Updated       int32
+	Failed        int32
+	// Errors - ошибки строк; хранятся первые из них, Failed - сколько строк не прошло всего
+	Errors []ImportRowError
+	// Error - причина, по которой задание завершилось статусом failed
+	Error      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ImportRowError - ошибка строки таблицы. Row - номер строки, как в редакторе таблиц,
+// Column - столбец с ошибкой, если она относится к одному столбцу
+type ImportRowError struct {
+	Row     int32  `json:"row"`
+	Column  string `json:"column,omitempty"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
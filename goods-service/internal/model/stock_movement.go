@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// Типы складских движений
+const (
+	// MovementReceipt - поступление на склад
+	MovementReceipt = "receipt"
+	// MovementReservation - резерв под заказ: остаток уменьшается, резерв растет
+	MovementReservation = "reservation"
+	// MovementRelease - снятие резерва неоплаченного заказа: количество возвращается в остаток
+	MovementRelease = "release"
+	// MovementSale - продажа оплаченного заказа: зарезервированное количество уходит со склада
+	MovementSale = "sale"
+	// MovementAdjustment - ручная корректировка с причиной (инвентаризация, брак)
+	MovementAdjustment = "adjustment"
+	// MovementReturn - возврат покупателем
+	MovementReturn = "return"
+)
+
+// Статусы резервирования
+const (
+	ReservationReserved = "reserved"
+	ReservationReleased = "released"
+	ReservationSold     = "sold"
+)
+
+// StockMovement - запись журнала складских движений. Журнал только дополняется; остаток варианта
+// на складе равен сумме Quantity его движений по складу, резерв - сумме Reserved
+type StockMovement struct {
+	ID          int64
+	GoodID      int64
+	VariantID   int64
+	WarehouseID int64
+	Type        string
+	// Quantity - изменение остатка, Reserved - изменение зарезервированного количества
+	Quantity int32
+	Reserved int32
+	// Balance - остаток варианта на складе после движения
+	Balance   int32
+	OrderID   int64
+	Reason    string
+	CreatedAt time.Time
+}
+
+// StockAdjustmentRequest - движение, которое проводит сотрудник склада: поступление, корректировка или возврат
+type StockAdjustmentRequest struct {
+	GoodID    int64
+	VariantID int64
+	// WarehouseID можно не указывать, если склад один
+	WarehouseID int64
+	Type        string
+	// Quantity - изменение остатка; у поступления и возврата положительное
+	Quantity int32
+	Reason   string
+	OrderID  int64
+}
+
+// StockMovementsPage - страница истории движений, новые первыми
+type StockMovementsPage struct {
+	Movements []*StockMovement
+	Total     int32
+}
+
+// StockDiscrepancy - вариант, остаток или резерв которого на складе расходится с журналом
+type StockDiscrepancy struct {
+	GoodID         int64
+	VariantID      int64
+	WarehouseID    int64
+	SKU            string
+	Stock          int32
+	LedgerStock    int32
+	Reserved       int32
+	LedgerReserved int32
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Variant - вариант товара (фасовка, цвет) со своим артикулом, ценой и остатком
+type Variant struct {
+	ID     int64
+	GoodID int64
+	SKU    string
+	Price  float64
+	// Stock - сумма остатков по складам
+	Stock int32
+	// Attributes - чем вариант отличается от соседних: {"weight": "100 г"}
+	Attributes map[string]string
+	// Warehouses - остатки по складам в порядке приоритета складов
+	Warehouses []*WarehouseStock
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type CreateVariantRequest struct {
+	SKU        string
+	Price      float64
+	Stock      int32
+	Attributes map[string]string
+}
+
+type UpdateVariantRequest struct {
+	SKU        string
+	Price      float64
+	Stock      int32
+	Attributes map[string]string
+}
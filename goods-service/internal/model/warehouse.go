@@ -0,0 +1,81 @@
+package model
+
+import "time"
+
+// Стратегии выбора склада для резервирования заказа
+const (
+	// FulfilmentNearest - сначала склады, обслуживающие регион адреса доставки, затем по приоритету
+	FulfilmentNearest = "nearest"
+	// FulfilmentMostStock - для каждой позиции склад с наибольшим остатком
+	FulfilmentMostStock = "most_stock"
+	// FulfilmentFewestSplits - наименьшее число складов, то есть отдельных отправлений
+	FulfilmentFewestSplits = "fewest_splits"
+)
+
+// Warehouse - склад, с которого отгружаются заказы
+type Warehouse struct {
+	ID      int64
+	Code    string
+	Name    string
+	Address string
+	// Regions - города и регионы, которые склад обслуживает ближе других: "Москва", "Санкт-Петербург"
+	Regions []string
+	// Priority - порядок складов при прочих равных, меньше - раньше
+	Priority  int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type CreateWarehouseRequest struct {
+	Code     string
+	Name     string
+	Address  string
+	Regions  []string
+	Priority int32
+}
+
+type UpdateWarehouseRequest struct {
+	Name     string
+	Address  string
+	Regions  []string
+	Priority int32
+}
+
+// WarehouseStock - остаток варианта на складе
+type WarehouseStock struct {
+	WarehouseID   int64
+	WarehouseCode string
+	Stock         int32
+}
+
+// StockAvailability - наличие варианта всего и по складам
+type StockAvailability struct {
+	Available  bool
+	Stock      int32
+	Warehouses []*WarehouseStock
+}
+
+// ReservationItem - позиция заказа для резервирования
+type ReservationItem struct {
+	GoodID    int64
+	VariantID int64
+	Quantity  int32
+}
+
+type ReserveOrderRequest struct {
+	OrderID int64
+	Items   []*ReservationItem
+	// Address - адрес доставки для стратегии nearest
+	Address string
+	// Strategy - стратегия выбора склада; пустая - стратегия сервиса по умолчанию
+	Strategy string
+}
+
+// Allocation - количество варианта, зарезервированное на складе
+type Allocation struct {
+	GoodID        int64
+	VariantID     int64
+	WarehouseID   int64
+	WarehouseCode string
+	Quantity      int32
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// ErrSlugTaken - slug уже занят другой категорией
+var ErrSlugTaken = errors.New("category slug already exists")
+
+// isUniqueViolation сообщает, что запись нарушила уникальный индекс
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+const categoryColumns = "id, COALESCE(parent_id, 0), slug, name, description, attribute_schema, created_at, updated_at"
+
+func scanCategory(row scanner) (*model.Category, error) {
+	category := &model.Category{}
+	var schema []byte
+	if err := row.Scan(
+		&category.ID,
+		&category.ParentID,
+		&category.Slug,
+		&category.Name,
+		&category.Description,
+		&schema,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(schema, &category.Attributes); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// attributeSchemaJSON сериализует схему атрибутов; nil сохраняется как пустой массив
+func attributeSchemaJSON(definitions []model.AttributeDefinition) ([]byte, error) {
+	if definitions == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(definitions)
+}
+
+func (r *GoodsRepository) CreateCategory(ctx context.Context, category *model.Category) error {
+	schema, err := attributeSchemaJSON(category.Attributes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO categories (parent_id, slug, name, description, attribute_schema, created_at, updated_at)
+		VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	now := time.Now()
+	err = r.db.QueryRowContext(
+		ctx,
+		query,
+		category.ParentID,
+		category.Slug,
+		category.Name,
+		category.Description,
+		schema,
+		now,
+		now,
+	).Scan(&category.ID)
+	if isUniqueViolation(err) {
+		return ErrSlugTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	return nil
+}
+
+// GetCategory возвращает категорию или nil, если её нет
+func (r *GoodsRepository) GetCategory(ctx context.Context, id int64) (*model.Category, error) {
+	return r.getCategory(ctx, "id = $1", id)
+}
+
+// GetCategoryBySlug возвращает категорию или nil, если её нет
+func (r *GoodsRepository) GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	return r.getCategory(ctx, "slug = $1", slug)
+}
+
+func (r *GoodsRepository) getCategory(ctx context.Context, condition string, arg any) (*model.Category, error) {
+	query := "SELECT " + categoryColumns + " FROM categories WHERE " + condition
+
+	category, err := scanCategory(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *GoodsRepository) ListCategories(ctx context.Context) ([]*model.Category, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+categoryColumns+" FROM categories ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*model.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *GoodsRepository) UpdateCategory(ctx context.Context, category *model.Category) error {
+	schema, err := attributeSchemaJSON(category.Attributes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE categories
+		SET parent_id = NULLIF($1, 0), slug = $2, name = $3, description = $4, attribute_schema = $5, updated_at = $6
+		WHERE id = $7
+	`
+	category.UpdatedAt = time.Now()
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		category.ParentID,
+		category.Slug,
+		category.Name,
+		category.Description,
+		schema,
+		category.UpdatedAt,
+		category.ID,
+	)
+	if isUniqueViolation(err) {
+		return ErrSlugTaken
+	}
+	return err
+}
+
+// DeleteCategory удаляет категорию; связи с товарами удаляются каскадно
+func (r *GoodsRepository) DeleteCategory(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
+	return err
+}
+
+// SetGoodCategories заменяет категории товара
+func (r *GoodsRepository) SetGoodCategories(ctx context.Context, goodID int64, categoryIDs []int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM good_categories WHERE good_id = $1", goodID); err != nil {
+		return err
+	}
+
+	for _, categoryID := range categoryIDs {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO good_categories (good_id, category_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			goodID,
+			categoryID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// newMockRepository создает репозиторий поверх sqlmock: запросы проверяются без живой БД
+func newMockRepository(t *testing.T) (*GoodsRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+	return New(db), mock
+}
+
+// sqlPattern экранирует фрагмент запроса для сравнения в sqlmock
+func sqlPattern(fragment string) string {
+	return regexp.QuoteMeta(fragment)
+}
+
+var categoryMockColumns = []string{"id", "parent_id", "slug", "name", "description", "attribute_schema", "created_at", "updated_at"}
+
+func TestCategories_CreateSlugTaken(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectQuery(sqlPattern("INSERT INTO categories (parent_id, slug, name, description, attribute_schema, created_at, updated_at)")).
+		WithArgs(int64(0), "green-tea", "Зеленый чай", "", []byte("[]"), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	err := repo.CreateCategory(context.Background(), &model.Category{Slug: "green-tea", Name: "Зеленый чай"})
+	assert.ErrorIs(t, err, ErrSlugTaken)
+}
+
+func TestCategories_GetBySlug(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	now := time.Now()
+
+	mock.ExpectQuery(sqlPattern("FROM categories WHERE slug = $1")).
+		WithArgs("puer").
+		WillReturnRows(sqlmock.NewRows(categoryMockColumns).
+			AddRow(3, 1, "puer", "Пуэр", "", []byte(`[{"code":"year","name":"Год сбора","type":"number"}]`), now, now))
+	mock.ExpectQuery(sqlPattern("FROM categories WHERE slug = $1")).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	category, err := repo.GetCategoryBySlug(context.Background(), "puer")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), category.ParentID)
+	require.Len(t, category.Attributes, 1)
+	assert.Equal(t, "year", category.Attributes[0].Code)
+
+	missing, err := repo.GetCategoryBySlug(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestCategories_SetGoodCategoriesRollsBack(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlPattern("DELETE FROM good_categories WHERE good_id = $1")).
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(sqlPattern("INSERT INTO good_categories (good_id, category_id)")).
+		WithArgs(int64(5), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlPattern("INSERT INTO good_categories (good_id, category_id)")).
+		WithArgs(int64(5), int64(99)).
+		WillReturnError(errors.New("foreign key violation"))
+	mock.ExpectRollback()
+
+	err := repo.SetGoodCategories(context.Background(), 5, []int64{1, 99})
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/lib/pq"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// GoodsFacets считает фасеты каталога. priceBounds - возрастающие границы диапазонов цены:
+// для {500, 1000} диапазоны [0, 500), [500, 1000) и [1000, ∞)
+func (r *GoodsRepository) GoodsFacets(ctx context.Context, filter *model.GoodsFilter, priceBounds []float64) (*model.GoodsFacets, error) {
+	facets := &model.GoodsFacets{}
+
+	// Категории: товар учитывается в своей категории и во всех её предках
+	withoutCategories := *filter
+	withoutCategories.SubtreeCategoryIDs = nil
+	where, args := goodsWhere(&withoutCategories)
+	rows, err := r.db.QueryContext(ctx, `
+		WITH RECURSIVE tree AS (
+			SELECT id AS root, id FROM categories
+			UNION ALL
+			SELECT tree.root, c.id FROM categories c JOIN tree ON c.parent_id = tree.id
+		)
+		SELECT tree.root, COUNT(DISTINCT gc.good_id)
+		FROM tree
+		JOIN good_categories gc ON gc.category_id = tree.id
+		WHERE gc.good_id IN (SELECT id FROM goods `+where+`)
+		GROUP BY tree.root
+		ORDER BY tree.root
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var facet model.CategoryFacet
+		if err := rows.Scan(&facet.CategoryID, &facet.Count); err != nil {
+			return nil, err
+		}
+		facets.Categories = append(facets.Categories, facet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Цены: width_bucket возвращает номер диапазона от 0 до len(priceBounds)
+	facets.Prices = priceFacets(priceBounds)
+	withoutPrice := *filter
+	withoutPrice.MinPrice, withoutPrice.MaxPrice = 0, 0
+	where, args = goodsWhere(&withoutPrice)
+	args = append(args, pq.Array(priceBounds))
+	bucketRows, err := r.db.QueryContext(ctx, `
+		SELECT width_bucket(price::float8, $`+strconv.Itoa(len(args))+`::float8[]) AS bucket, COUNT(*)
+		FROM goods `+where+`
+		GROUP BY bucket
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer bucketRows.Close()
+	for bucketRows.Next() {
+		var bucket int
+		var count int32
+		if err := bucketRows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		facets.Prices[bucket].Count = count
+	}
+	if err := bucketRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Наличие
+	withoutStock := *filter
+	withoutStock.InStock = false
+	where, args = goodsWhere(&withoutStock)
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE stock > 0), COUNT(*) FILTER (WHERE stock <= 0)
+		FROM goods `+where, args...).Scan(&facets.InStock, &facets.OutOfStock)
+	if err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// priceFacets создает пустые диапазоны цены по возрастающим границам
+func priceFacets(bounds []float64) []model.PriceFacet {
+	prices := make([]model.PriceFacet, len(bounds)+1)
+	for i, bound := range bounds {
+		prices[i].Max = bound
+		prices[i+1].Min = bound
+	}
+	return prices
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+func TestGoodsFacets_EachFacetIgnoresItsOwnFilter(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	filter := &model.GoodsFilter{SubtreeCategoryIDs: []int64{1}, MinPrice: 100, InStock: true}
+	bounds := []float64{500, 1000}
+
+	// Категории считаются без фильтра по категории, по дереву потомков
+	mock.ExpectQuery(sqlPattern("WITH RECURSIVE tree AS")).
+		WithArgs(100.0).
+		WillReturnRows(sqlmock.NewRows([]string{"root", "count"}).AddRow(1, 4).AddRow(2, 1))
+	// Цены - без фильтра по цене; границы диапазонов передаются последним параметром
+	mock.ExpectQuery(sqlPattern("SELECT width_bucket(price::float8, $2::float8[]) AS bucket")).
+		WithArgs(pq.Array([]int64{1}), pq.Array(bounds)).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(0, 3).AddRow(2, 1))
+	// Наличие - без фильтра по наличию
+	mock.ExpectQuery(sqlPattern("COUNT(*) FILTER (WHERE stock > 0)")).
+		WithArgs(pq.Array([]int64{1}), 100.0).
+		WillReturnRows(sqlmock.NewRows([]string{"in_stock", "out_of_stock"}).AddRow(3, 2))
+
+	facets, err := repo.GoodsFacets(context.Background(), filter, bounds)
+	require.NoError(t, err)
+
+	assert.Equal(t, []model.CategoryFacet{{CategoryID: 1, Count: 4}, {CategoryID: 2, Count: 1}}, facets.Categories)
+	assert.Equal(t, []model.PriceFacet{
+		{Max: 500, Count: 3},
+		{Min: 500, Max: 1000},
+		{Min: 1000, Count: 1},
+	}, facets.Prices)
+	assert.Equal(t, int32(3), facets.InStock)
+	assert.Equal(t, int32(2), facets.OutOfStock)
+}
+
+func TestGoodsFacets_PriceQueryError(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectQuery(sqlPattern("WITH RECURSIVE tree AS")).
+		WillReturnRows(sqlmock.NewRows([]string{"root", "count"}))
+	mock.ExpectQuery(sqlPattern("width_bucket")).
+		WillReturnError(errors.New("connection reset"))
+
+	_, err := repo.GoodsFacets(context.Background(), &model.GoodsFilter{}, []float64{500})
+	assert.Error(t, err)
+}
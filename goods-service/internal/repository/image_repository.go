@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+const imageColumns = "id, good_id, position, original_key, medium_key, thumbnail_key, content_type, width, height, created_at"
+
+func scanImage(row scanner) (*model.GoodImage, error) {
+	image := &model.GoodImage{}
+	if err := row.Scan(
+		&image.ID,
+		&image.GoodID,
+		&image.Position,
+		&image.OriginalKey,
+		&image.MediumKey,
+		&image.ThumbnailKey,
+		&image.ContentType,
+		&image.Width,
+		&image.Height,
+		&image.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// CreateGoodImage добавляет изображение в конец галереи товара image.GoodID
+func (r *GoodsRepository) CreateGoodImage(ctx context.Context, image *model.GoodImage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Блокировка товара не дает двум одновременным загрузкам занять одну позицию
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM goods WHERE id = $1 FOR UPDATE", image.GoodID); err != nil {
+		return err
+	}
+
+	image.CreatedAt = time.Now()
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO good_images (good_id, position, original_key, medium_key, thumbnail_key, content_type, width, height, created_at)
+		VALUES ($1, (SELECT COALESCE(MAX(position), 0) + 1 FROM good_images WHERE good_id = $1), $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, position`,
+		image.GoodID,
+		image.OriginalKey,
+		image.MediumKey,
+		image.ThumbnailKey,
+		image.ContentType,
+		image.Width,
+		image.Height,
+		image.CreatedAt,
+	).Scan(&image.ID, &image.Position)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetGoodImage возвращает изображение или nil, если его нет
+func (r *GoodsRepository) GetGoodImage(ctx context.Context, id int64) (*model.GoodImage, error) {
+	image, err := scanImage(r.db.QueryRowContext(ctx, "SELECT "+imageColumns+" FROM good_images WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return image, err
+}
+
+func (r *GoodsRepository) DeleteGoodImage(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM good_images WHERE id = $1", id)
+	return err
+}
+
+// SetGoodImageOrder расставляет изображения товара в порядке imageIDs
+func (r *GoodsRepository) SetGoodImageOrder(ctx context.Context, goodID int64, imageIDs []int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE good_images SET position = ordered.position
+		FROM unnest($2::int[]) WITH ORDINALITY AS ordered(id, position)
+		WHERE good_images.id = ordered.id AND good_images.good_id = $1
+	`, goodID, pq.Array(imageIDs))
+	return err
+}
+
+// loadImages заполняет Images товаров одним запросом
+func (r *GoodsRepository) loadImages(ctx context.Context, goods []*model.Good) error {
+	if len(goods) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Good, len(goods))
+	ids := make([]int64, len(goods))
+	for i, good := range goods {
+		byID[good.ID] = good
+		ids[i] = good.ID
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+imageColumns+" FROM good_images WHERE good_id = ANY($1) ORDER BY good_id, position, id",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		image, err := scanImage(rows)
+		if err != nil {
+			return err
+		}
+		good := byID[image.GoodID]
+		good.Images = append(good.Images, image)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+const importJobColumns = "id, format, dry_run, status, total_rows, processed_rows, created_count, updated_count, failed_count, errors, error, created_at, updated_at, finished_at"
+
+func scanImportJob(row scanner) (*model.ImportJob, error) {
+	job := &model.ImportJob{}
+	var errorsJSON []byte
+	var finishedAt sql.NullTime
+	if err := row.Scan(
+		&job.ID,
+		&job.Format,
+		&job.DryRun,
+		&job.Status,
+		&job.TotalRows,
+		&job.ProcessedRows,
+		&job.Created,
+		&job.Updated,
+		&job.Failed,
+		&errorsJSON,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&finishedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(errorsJSON, &job.Errors); err != nil {
+		return nil, err
+	}
+	job.FinishedAt = finishedAt.Time
+	return job, nil
+}
+
+// importErrorsJSON сериализует ошибки строк; пустой список - [], как значение по умолчанию в таблице
+func importErrorsJSON(errors []model.ImportRowError) ([]byte, error) {
+	if errors == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(errors)
+}
+
+// finishedAt - время завершения задания для записи в БД: NULL, пока задание выполняется
+func finishedAt(job *model.ImportJob) any {
+	if job.FinishedAt.IsZero() {
+		return nil
+	}
+	return job.FinishedAt
+}
+
+func (r *GoodsRepository) CreateImportJob(ctx context.Context, job *model.ImportJob) error {
+	errorsJSON, err := importErrorsJSON(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO import_jobs (format, dry_run, status, total_rows, processed_rows, created_count, updated_count, failed_count, errors, error, created_at, updated_at, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id`,
+		job.Format,
+		job.DryRun,
+		job.Status,
+		job.TotalRows,
+		job.ProcessedRows,
+		job.Created,
+		job.Updated,
+		job.Failed,
+		errorsJSON,
+		job.Error,
+		now,
+		now,
+		finishedAt(job),
+	).Scan(&job.ID)
+	if err != nil {
+		return err
+	}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	return nil
+}
+
+// UpdateImportJob сохраняет статус, прогресс и ошибки задания
+func (r *GoodsRepository) UpdateImportJob(ctx context.Context, job *model.ImportJob) error {
+	errorsJSON, err := importErrorsJSON(job.Errors)
+	if err != nil {
+		return err
+	}
+
+	job.UpdatedAt = time.Now()
+	_, err = r.db.ExecContext(
+		ctx,
+		`UPDATE import_jobs SET status = $1, processed_rows = $2, created_count = $3, updated_count = $4, failed_count = $5,
+			errors = $6, error = $7, updated_at = $8, finished_at = $9
+		WHERE id = $10`,
+		job.Status,
+		job.ProcessedRows,
+		job.Created,
+		job.Updated,
+		job.Failed,
+		errorsJSON,
+		job.Error,
+		job.UpdatedAt,
+		finishedAt(job),
+		job.ID,
+	)
+	return err
+}
+
+func (r *GoodsRepository) GetImportJob(ctx context.Context, id int64) (*model.ImportJob, error) {
+	job, err := scanImportJob(r.db.QueryRowContext(ctx, "SELECT "+importJobColumns+" FROM import_jobs WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
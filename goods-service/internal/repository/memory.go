@@ -0,0 +1,1307 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// MemoryRepository - репозиторий товаров в памяти (для тестов и запуска без БД)
+type MemoryRepository struct {
+	mu           sync.Mutex
+	goods        map[int64]*model.Good
+	categories   map[int64]*model.Category
+	warehouses   map[int64]*model.Warehouse
+	reservations []*model.StockReservation
+	movements    []*model.StockMovement
+	// archivedVariants - удаленные варианты: их артикулы остаются заняты
+	archivedVariants []*model.Variant
+	lastID           int64
+	lastReservation  int64
+	lastCategory     int64
+	lastVariant      int64
+	lastImage        int64
+	lastMovement     int64
+	lastWarehouse    int64
+	importJobs       map[int64]*model.ImportJob
+	lastImportJob    int64
+}
+
+// NewMemory создает репозиторий с одним складом main, как миграция 008
+func NewMemory() *MemoryRepository {
+	now := time.Now()
+	return &MemoryRepository{
+		goods:      make(map[int64]*model.Good),
+		categories: make(map[int64]*model.Category),
+		warehouses: map[int64]*model.Warehouse{
+			1: {ID: 1, Code: "main", Name: "Основной склад", CreatedAt: now, UpdatedAt: now},
+		},
+		lastWarehouse: 1,
+		importJobs:    make(map[int64]*model.ImportJob),
+	}
+}
+
+func (r *MemoryRepository) CreateGood(ctx context.Context, good *model.Good) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sku := good.SKU
+	if sku == "" {
+		sku = fmt.Sprintf("GOOD-%06d", r.lastID+1)
+	}
+	variantSKU := sku
+	if len(good.Variants) > 0 {
+		variantSKU = good.Variants[0].SKU
+	}
+	// Артикул товара уникален среди товаров, а артикул его первого варианта - среди вариантов
+	if r.skuTaken(variantSKU, 0) {
+		return ErrSKUTaken
+	}
+	for _, existing := range r.goods {
+		if existing.SKU == sku {
+			return ErrSKUTaken
+		}
+	}
+
+	r.lastID++
+	good.ID = r.lastID
+	good.SKU = sku
+	now := time.Now()
+	good.CreatedAt = now
+	good.UpdatedAt = now
+
+	r.lastVariant++
+	variant := firstVariant(good)
+	variant.ID = r.lastVariant
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+	good.Variants = []*model.Variant{variant}
+	r.recordReceipt(good.Variants[0])
+
+	r.goods[good.ID] = copyGood(good)
+	return nil
+}
+
+func (r *MemoryRepository) GetGood(ctx context.Context, id int64) (*model.Good, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, ok := r.goods[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyGood(good), nil
+}
+
+func (r *MemoryRepository) GetGoodBySKU(ctx context.Context, sku string) (*model.Good, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, good := range r.goods {
+		if good.SKU == sku {
+			return copyGood(good), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) ListGoods(ctx context.Context, filter *model.GoodsFilter) ([]*model.Good, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	goods := r.filteredGoods(filter)
+	if int(filter.Offset) >= len(goods) {
+		return nil, nil
+	}
+	goods = goods[filter.Offset:]
+	if int(filter.Limit) < len(goods) {
+		goods = goods[:filter.Limit]
+	}
+	return goods, nil
+}
+
+func (r *MemoryRepository) UpdateGood(ctx context.Context, good *model.Good) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.goods[good.ID]
+	if !ok {
+		return nil
+	}
+	existing.SKU = good.SKU
+	existing.Name = good.Name
+	existing.Description = good.Description
+	existing.Attributes = maps.Clone(good.Attributes)
+	existing.UpdatedAt = time.Now()
+	return nil
+}
+
+// ArchiveGood, как и GoodsRepository, не архивирует товар с незавершенными резервированиями
+func (r *MemoryRepository) ArchiveGood(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, ok := r.goods[id]
+	if !ok || !good.ArchivedAt.IsZero() {
+		return nil
+	}
+	for _, reservation := range r.reservations {
+		if reservation.GoodID == id && reservation.Status == model.ReservationReserved {
+			return ErrGoodReserved
+		}
+	}
+	good.ArchivedAt = time.Now()
+	good.UpdatedAt = good.ArchivedAt
+	return nil
+}
+
+func (r *MemoryRepository) RestoreGood(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, ok := r.goods[id]
+	if !ok || good.ArchivedAt.IsZero() {
+		return nil
+	}
+	good.ArchivedAt = time.Time{}
+	good.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryRepository) GetTotalGoods(ctx context.Context, filter *model.GoodsFilter) (int32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int32(len(r.filteredGoods(filter))), nil
+}
+
+func (r *MemoryRepository) StockLevels(ctx context.Context) ([]*model.StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	levels := make([]*model.StockLevel, 0, len(r.goods))
+	for _, good := range r.goods {
+		for _, variant := range good.Variants {
+			levels = append(levels, &model.StockLevel{SKU: variant.SKU, Name: good.Name, Stock: variant.Stock})
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].SKU < levels[j].SKU
+	})
+	return levels, nil
+}
+
+// CreateCategory, как и GoodsRepository, возвращает ErrSlugTaken, если slug занят
+func (r *MemoryRepository) CreateCategory(ctx context.Context, category *model.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.slugTaken(category.Slug, 0) {
+		return ErrSlugTaken
+	}
+
+	r.lastCategory++
+	category.ID = r.lastCategory
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+
+	r.categories[category.ID] = copyCategory(category)
+	return nil
+}
+
+func (r *MemoryRepository) GetCategory(ctx context.Context, id int64) (*model.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyCategory(category), nil
+}
+
+func (r *MemoryRepository) GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, category := range r.categories {
+		if category.Slug == slug {
+			return copyCategory(category), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) ListCategories(ctx context.Context) ([]*model.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	categories := make([]*model.Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		categories = append(categories, copyCategory(category))
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+func (r *MemoryRepository) UpdateCategory(ctx context.Context, category *model.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.categories[category.ID]
+	if !ok {
+		return nil
+	}
+	if r.slugTaken(category.Slug, category.ID) {
+		return ErrSlugTaken
+	}
+
+	category.UpdatedAt = time.Now()
+	existing.ParentID = category.ParentID
+	existing.Slug = category.Slug
+	existing.Name = category.Name
+	existing.Description = category.Description
+	existing.Attributes = copyCategory(category).Attributes
+	existing.UpdatedAt = category.UpdatedAt
+	return nil
+}
+
+func (r *MemoryRepository) DeleteCategory(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.categories, id)
+	for _, good := range r.goods {
+		good.CategoryIDs = slices.DeleteFunc(good.CategoryIDs, func(categoryID int64) bool {
+			return categoryID == id
+		})
+	}
+	return nil
+}
+
+func (r *MemoryRepository) SetGoodCategories(ctx context.Context, goodID int64, categoryIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, ok := r.goods[goodID]
+	if !ok {
+		return nil
+	}
+	ids := slices.Clone(categoryIDs)
+	slices.Sort(ids)
+	good.CategoryIDs = slices.Compact(ids)
+	return nil
+}
+
+func (r *MemoryRepository) GetVariant(ctx context.Context, id int64) (*model.Variant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, variant := r.findVariant(id)
+	if variant == nil {
+		return nil, nil
+	}
+	return copyVariant(variant), nil
+}
+
+func (r *MemoryRepository) GetVariantBySKU(ctx context.Context, sku string) (*model.Variant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, good := range r.goods {
+		for _, variant := range good.Variants {
+			if variant.SKU == sku {
+				return copyVariant(variant), nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// CreateVariant, как и GoodsRepository, возвращает ErrSKUTaken, если артикул занят
+func (r *MemoryRepository) CreateVariant(ctx context.Context, variant *model.Variant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, ok := r.goods[variant.GoodID]
+	if !ok {
+		return nil
+	}
+	if r.skuTaken(variant.SKU, 0) {
+		return ErrSKUTaken
+	}
+
+	r.lastVariant++
+	variant.ID = r.lastVariant
+	now := time.Now()
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+	stored := copyVariant(variant)
+	r.recordReceipt(stored)
+	good.Variants = append(good.Variants, stored)
+	refreshTotals(good)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateVariant(ctx context.Context, variant *model.Variant, stockDelta int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, existing := r.findVariant(variant.ID)
+	if existing == nil {
+		return nil
+	}
+	if r.skuTaken(variant.SKU, variant.ID) {
+		return ErrSKUTaken
+	}
+
+	if stockDelta != 0 {
+		warehouseID := r.defaultWarehouse()
+		balance, err := r.addWarehouseStock(existing, warehouseID, stockDelta)
+		if err != nil {
+			return err
+		}
+		r.record(&model.StockMovement{
+			GoodID:      good.ID,
+			VariantID:   variant.ID,
+			WarehouseID: warehouseID,
+			Type:        model.MovementAdjustment,
+			Quantity:    stockDelta,
+			Balance:     balance,
+			Reason:      stockUpdateReason,
+		})
+	}
+
+	variant.Stock = existing.Stock
+	variant.UpdatedAt = time.Now()
+	existing.SKU = variant.SKU
+	existing.Price = variant.Price
+	existing.Attributes = maps.Clone(variant.Attributes)
+	existing.UpdatedAt = variant.UpdatedAt
+	refreshTotals(good)
+	return nil
+}
+
+func (r *MemoryRepository) DeleteVariant(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, variant := r.findVariant(id)
+	if variant == nil {
+		return nil
+	}
+	// Как и GoodsRepository, не архивирует вариант с незавершенными резервированиями;
+	// резервирования и журнал движений варианта остаются
+	for _, reservation := range r.reservations {
+		if reservation.VariantID == id && reservation.Status == model.ReservationReserved {
+			return ErrVariantReserved
+		}
+	}
+
+	r.archivedVariants = append(r.archivedVariants, variant)
+	good.Variants = slices.DeleteFunc(good.Variants, func(v *model.Variant) bool {
+		return v.ID == id
+	})
+	refreshTotals(good)
+	return nil
+}
+
+// CreateGoodImage добавляет изображение в конец галереи товара
+func (r *MemoryRepository) CreateGoodImage(ctx context.Context, image *model.GoodImage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, ok := r.goods[image.GoodID]
+	if !ok {
+		return fmt.Errorf("good %d not found", image.GoodID)
+	}
+	r.lastImage++
+	image.ID = r.lastImage
+	image.Position = 1
+	for _, existing := range good.Images {
+		image.Position = max(image.Position, existing.Position+1)
+	}
+	image.CreatedAt = time.Now()
+
+	stored := *image
+	good.Images = append(good.Images, &stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetGoodImage(ctx context.Context, id int64) (*model.GoodImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, good := range r.goods {
+		for _, image := range good.Images {
+			if image.ID == id {
+				copied := *image
+				return &copied, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) DeleteGoodImage(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, good := range r.goods {
+		good.Images = slices.DeleteFunc(good.Images, func(image *model.GoodImage) bool {
+			return image.ID == id
+		})
+	}
+	return nil
+}
+
+// SetGoodImageOrder расставляет изображения товара в порядке imageIDs
+func (r *MemoryRepository) SetGoodImageOrder(ctx context.Context, goodID int64, imageIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, ok := r.goods[goodID]
+	if !ok {
+		return nil
+	}
+	for _, image := range good.Images {
+		if i := slices.Index(imageIDs, image.ID); i >= 0 {
+			image.Position = int32(i + 1)
+		}
+	}
+	sort.SliceStable(good.Images, func(i, j int) bool {
+		if good.Images[i].Position != good.Images[j].Position {
+			return good.Images[i].Position < good.Images[j].Position
+		}
+		return good.Images[i].ID < good.Images[j].ID
+	})
+	return nil
+}
+
+// AdjustStock, как и GoodsRepository, возвращает ErrInsufficientStock, если остаток на складе стал бы
+// отрицательным, и sql.ErrNoRows, если варианта нет
+func (r *MemoryRepository) AdjustStock(ctx context.Context, movement *model.StockMovement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	good, variant := r.findVariant(movement.VariantID)
+	if variant == nil {
+		return sql.ErrNoRows
+	}
+	balance, err := r.addWarehouseStock(variant, movement.WarehouseID, movement.Quantity)
+	if err != nil {
+		return err
+	}
+
+	variant.UpdatedAt = time.Now()
+	refreshTotals(good)
+	movement.GoodID = good.ID
+	movement.Balance = balance
+	r.record(movement)
+	return nil
+}
+
+func (r *MemoryRepository) ReleaseStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	return r.closeReservations(orderID, model.ReservationReleased), nil
+}
+
+func (r *MemoryRepository) CommitStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	return r.closeReservations(orderID, model.ReservationSold), nil
+}
+
+// closeReservations переводит незавершенные резервирования заказа в status и записывает движения
+func (r *MemoryRepository) closeReservations(orderID int64, status string) []*model.StockMovement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var movements []*model.StockMovement
+	for _, reservation := range r.reservations {
+		if reservation.OrderID != orderID || reservation.Status != model.ReservationReserved {
+			continue
+		}
+		good, variant := r.findVariant(reservation.VariantID)
+		if variant == nil {
+			continue
+		}
+
+		movement := &model.StockMovement{
+			GoodID:      good.ID,
+			VariantID:   variant.ID,
+			WarehouseID: reservation.WarehouseID,
+			Type:        model.MovementSale,
+			Reserved:    -reservation.Quantity,
+			OrderID:     orderID,
+		}
+		if status == model.ReservationReleased {
+			movement.Type = model.MovementRelease
+			movement.Quantity = reservation.Quantity
+		}
+		movement.Balance, _ = r.addWarehouseStock(variant, reservation.WarehouseID, movement.Quantity)
+		refreshTotals(good)
+		reservation.Status = status
+		r.record(movement)
+
+		copied := *movement
+		movements = append(movements, &copied)
+	}
+	return movements
+}
+
+// ListStockMovements возвращает движения товара, новые первыми
+func (r *MemoryRepository) ListStockMovements(ctx context.Context, goodID, variantID int64, limit, offset int32) ([]*model.StockMovement, int32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var movements []*model.StockMovement
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		movement := r.movements[i]
+		if movement.GoodID == goodID && (variantID == 0 || movement.VariantID == variantID) {
+			copied := *movement
+			movements = append(movements, &copied)
+		}
+	}
+
+	total := int32(len(movements))
+	if int(offset) >= len(movements) {
+		return nil, total, nil
+	}
+	movements = movements[offset:]
+	if int(limit) < len(movements) {
+		movements = movements[:limit]
+	}
+	return movements, total, nil
+}
+
+// StockDiscrepancies сверяет остатки и резервы вариантов на складах с журналом
+func (r *MemoryRepository) StockDiscrepancies(ctx context.Context) ([]*model.StockDiscrepancy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type place struct{ variantID, warehouseID int64 }
+	ledgerStock := make(map[place]int32)
+	ledgerReserved := make(map[place]int32)
+	reserved := make(map[place]int32)
+	places := make(map[place]bool)
+	for _, movement := range r.movements {
+		key := place{movement.VariantID, movement.WarehouseID}
+		ledgerStock[key] += movement.Quantity
+		ledgerReserved[key] += movement.Reserved
+		places[key] = true
+	}
+	for _, reservation := range r.reservations {
+		if reservation.Status == model.ReservationReserved {
+			key := place{reservation.VariantID, reservation.WarehouseID}
+			reserved[key] += reservation.Quantity
+			places[key] = true
+		}
+	}
+
+	var discrepancies []*model.StockDiscrepancy
+	for _, good := range r.goods {
+		for _, variant := range good.Variants {
+			stock := make(map[int64]int32)
+			for _, level := range variant.Warehouses {
+				stock[level.WarehouseID] = level.Stock
+				places[place{variant.ID, level.WarehouseID}] = true
+			}
+			for key := range places {
+				if key.variantID != variant.ID {
+					continue
+				}
+				if stock[key.warehouseID] == ledgerStock[key] && reserved[key] == ledgerReserved[key] {
+					continue
+				}
+				discrepancies = append(discrepancies, &model.StockDiscrepancy{
+					GoodID:         good.ID,
+					VariantID:      variant.ID,
+					WarehouseID:    key.warehouseID,
+					SKU:            variant.SKU,
+					Stock:          stock[key.warehouseID],
+					LedgerStock:    ledgerStock[key],
+					Reserved:       reserved[key],
+					LedgerReserved: ledgerReserved[key],
+				})
+			}
+		}
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		if discrepancies[i].VariantID != discrepancies[j].VariantID {
+			return discrepancies[i].VariantID < discrepancies[j].VariantID
+		}
+		return discrepancies[i].WarehouseID < discrepancies[j].WarehouseID
+	})
+	return discrepancies, nil
+}
+
+// record добавляет движение в журнал; вызывается под r.mu
+func (r *MemoryRepository) record(movement *model.StockMovement) {
+	r.lastMovement++
+	movement.ID = r.lastMovement
+	movement.CreatedAt = time.Now()
+	copied := *movement
+	r.movements = append(r.movements, &copied)
+}
+
+// recordReceipt оприходует начальный остаток нового варианта на склад по умолчанию; вызывается под r.mu
+func (r *MemoryRepository) recordReceipt(variant *model.Variant) {
+	if variant.Stock == 0 {
+		return
+	}
+	warehouseID := r.defaultWarehouse()
+	quantity := variant.Stock
+	variant.Stock = 0
+	balance, _ := r.addWarehouseStock(variant, warehouseID, quantity)
+	r.record(&model.StockMovement{
+		GoodID:      variant.GoodID,
+		VariantID:   variant.ID,
+		WarehouseID: warehouseID,
+		Type:        model.MovementReceipt,
+		Quantity:    quantity,
+		Balance:     balance,
+		Reason:      "initial stock",
+	})
+}
+
+// CreateWarehouse, как и GoodsRepository, возвращает ErrWarehouseCodeTaken, если код занят
+func (r *MemoryRepository) CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.warehouses {
+		if existing.Code == warehouse.Code {
+			return ErrWarehouseCodeTaken
+		}
+	}
+	r.lastWarehouse++
+	warehouse.ID = r.lastWarehouse
+	now := time.Now()
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+	r.warehouses[warehouse.ID] = copyWarehouse(warehouse)
+	return nil
+}
+
+func (r *MemoryRepository) GetWarehouse(ctx context.Context, id int64) (*model.Warehouse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	warehouse, ok := r.warehouses[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyWarehouse(warehouse), nil
+}
+
+func (r *MemoryRepository) UpdateWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.warehouses[warehouse.ID]
+	if !ok {
+		return nil
+	}
+	warehouse.UpdatedAt = time.Now()
+	existing.Name = warehouse.Name
+	existing.Address = warehouse.Address
+	existing.Regions = slices.Clone(warehouse.Regions)
+	existing.Priority = warehouse.Priority
+	existing.UpdatedAt = warehouse.UpdatedAt
+
+	// Остатки вариантов упорядочены по приоритету складов
+	for _, good := range r.goods {
+		for _, variant := range good.Variants {
+			r.sortWarehouseStock(variant)
+		}
+	}
+	return nil
+}
+
+// ListWarehouses возвращает склады в порядке приоритета
+func (r *MemoryRepository) ListWarehouses(ctx context.Context) ([]*model.Warehouse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	warehouses := make([]*model.Warehouse, 0, len(r.warehouses))
+	for _, warehouse := range r.warehouses {
+		warehouses = append(warehouses, copyWarehouse(warehouse))
+	}
+	slices.SortFunc(warehouses, r.compareWarehouses)
+	return warehouses, nil
+}
+
+// ReserveAllocations, как и GoodsRepository, резервирует все позиции или, если остатка где-то
+// не хватает, возвращает ErrInsufficientStock и ничего не меняет
+func (r *MemoryRepository) ReserveAllocations(ctx context.Context, orderID int64, allocations []*model.Allocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type place struct{ variantID, warehouseID int64 }
+	needed := make(map[place]int32)
+	for _, allocation := range allocations {
+		needed[place{allocation.VariantID, allocation.WarehouseID}] += allocation.Quantity
+	}
+	for key, quantity := range needed {
+		good, variant := r.findVariant(key.variantID)
+		if variant == nil || warehouseStock(variant, key.warehouseID) < quantity {
+			return ErrInsufficientStock
+		}
+		if !good.ArchivedAt.IsZero() {
+			return ErrGoodArchived
+		}
+	}
+
+	for _, allocation := range allocations {
+		good, variant := r.findVariant(allocation.VariantID)
+		balance, _ := r.addWarehouseStock(variant, allocation.WarehouseID, -allocation.Quantity)
+		refreshTotals(good)
+		allocation.GoodID = good.ID
+
+		r.lastReservation++
+		r.reservations = append(r.reservations, &model.StockReservation{
+			ID:          r.lastReservation,
+			GoodID:      good.ID,
+			VariantID:   variant.ID,
+			WarehouseID: allocation.WarehouseID,
+			OrderID:     orderID,
+			Quantity:    allocation.Quantity,
+			Status:      model.ReservationReserved,
+			CreatedAt:   time.Now(),
+		})
+		r.record(&model.StockMovement{
+			GoodID:      good.ID,
+			VariantID:   variant.ID,
+			WarehouseID: allocation.WarehouseID,
+			Type:        model.MovementReservation,
+			Quantity:    -allocation.Quantity,
+			Reserved:    allocation.Quantity,
+			Balance:     balance,
+			OrderID:     orderID,
+		})
+	}
+	return nil
+}
+
+// defaultWarehouse возвращает склад с наименьшим приоритетом, как defaultWarehouse в GoodsRepository;
+// вызывается под r.mu
+func (r *MemoryRepository) defaultWarehouse() int64 {
+	var first *model.Warehouse
+	for _, warehouse := range r.warehouses {
+		if first == nil || r.compareWarehouses(warehouse, first) < 0 {
+			first = warehouse
+		}
+	}
+	return first.ID
+}
+
+// addWarehouseStock меняет остаток варианта на складе и общий остаток варианта на delta.
+// Возвращает ErrInsufficientStock, если остаток на складе стал бы отрицательным; вызывается под r.mu
+func (r *MemoryRepository) addWarehouseStock(variant *model.Variant, warehouseID int64, delta int32) (int32, error) {
+	for _, level := range variant.Warehouses {
+		if level.WarehouseID == warehouseID {
+			if level.Stock+delta < 0 {
+				return 0, ErrInsufficientStock
+			}
+			level.Stock += delta
+			variant.Stock += delta
+			return level.Stock, nil
+		}
+	}
+	if delta < 0 {
+		return 0, ErrInsufficientStock
+	}
+
+	variant.Warehouses = append(variant.Warehouses, &model.WarehouseStock{
+		WarehouseID:   warehouseID,
+		WarehouseCode: r.warehouses[warehouseID].Code,
+		Stock:         delta,
+	})
+	variant.Stock += delta
+	r.sortWarehouseStock(variant)
+	return delta, nil
+}
+
+// sortWarehouseStock упорядочивает остатки варианта по приоритету складов; вызывается под r.mu
+func (r *MemoryRepository) sortWarehouseStock(variant *model.Variant) {
+	slices.SortFunc(variant.Warehouses, func(a, b *model.WarehouseStock) int {
+		return r.compareWarehouses(r.warehouses[a.WarehouseID], r.warehouses[b.WarehouseID])
+	})
+}
+
+func (r *MemoryRepository) compareWarehouses(a, b *model.Warehouse) int {
+	return cmp.Or(cmp.Compare(a.Priority, b.Priority), cmp.Compare(a.ID, b.ID))
+}
+
+// warehouseStock возвращает остаток варианта на складе
+func warehouseStock(variant *model.Variant, warehouseID int64) int32 {
+	for _, level := range variant.Warehouses {
+		if level.WarehouseID == warehouseID {
+			return level.Stock
+		}
+	}
+	return 0
+}
+
+func copyWarehouse(warehouse *model.Warehouse) *model.Warehouse {
+	copied := *warehouse
+	copied.Regions = slices.Clone(warehouse.Regions)
+	return &copied
+}
+
+func (r *MemoryRepository) CreateImportJob(ctx context.Context, job *model.ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastImportJob++
+	job.ID = r.lastImportJob
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	r.importJobs[job.ID] = copyImportJob(job)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateImportJob(ctx context.Context, job *model.ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.importJobs[job.ID]; !ok {
+		return nil
+	}
+	job.UpdatedAt = time.Now()
+	r.importJobs[job.ID] = copyImportJob(job)
+	return nil
+}
+
+func (r *MemoryRepository) GetImportJob(ctx context.Context, id int64) (*model.ImportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.importJobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyImportJob(job), nil
+}
+
+func copyImportJob(job *model.ImportJob) *model.ImportJob {
+	copied := *job
+	copied.Errors = slices.Clone(job.Errors)
+	return &copied
+}
+
+func (r *MemoryRepository) GoodsFacets(ctx context.Context, filter *model.GoodsFilter, priceBounds []float64) (*model.GoodsFacets, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	facets := &model.GoodsFacets{Prices: priceFacets(priceBounds)}
+
+	withoutCategories := *filter
+	withoutCategories.SubtreeCategoryIDs = nil
+	counts := make(map[int64]int32)
+	for _, good := range r.filteredGoods(&withoutCategories) {
+		// Товар учитывается в своих категориях и всех их предках, но не больше одного раза в каждой
+		seen := make(map[int64]bool)
+		for _, id := range good.CategoryIDs {
+			for category := r.categories[id]; category != nil && !seen[category.ID]; category = r.categories[category.ParentID] {
+				seen[category.ID] = true
+				counts[category.ID]++
+			}
+		}
+	}
+	for id, count := range counts {
+		facets.Categories = append(facets.Categories, model.CategoryFacet{CategoryID: id, Count: count})
+	}
+	sort.Slice(facets.Categories, func(i, j int) bool {
+		return facets.Categories[i].CategoryID < facets.Categories[j].CategoryID
+	})
+
+	withoutPrice := *filter
+	withoutPrice.MinPrice, withoutPrice.MaxPrice = 0, 0
+	for _, good := range r.filteredGoods(&withoutPrice) {
+		// Как width_bucket: номер первой границы, которая больше цены
+		bucket, _ := slices.BinarySearchFunc(priceBounds, good.Price, func(bound, price float64) int {
+			if bound <= price {
+				return -1
+			}
+			return 1
+		})
+		facets.Prices[bucket].Count++
+	}
+
+	withoutStock := *filter
+	withoutStock.InStock = false
+	for _, good := range r.filteredGoods(&withoutStock) {
+		if good.Stock > 0 {
+			facets.InStock++
+		} else {
+			facets.OutOfStock++
+		}
+	}
+
+	return facets, nil
+}
+
+// SearchGoods приближает полнотекстовый поиск GoodsRepository без морфологии: слово запроса совпадает
+// со словом товара, с которого то начинается ("пуэр" находит "пуэра"). Все слова запроса должны найтись,
+// совпадение в названии весит больше, чем в описании
+func (r *MemoryRepository) SearchGoods(ctx context.Context, query string, limit, offset int32) ([]*model.SearchHit, int32, error) {
+	terms := searchWords(query)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var hits []*model.SearchHit
+	for _, good := range r.filteredGoods(&model.GoodsFilter{}) {
+		nameWords := searchWords(good.Name)
+		descriptionWords := searchWords(good.Description)
+
+		var rank float64
+		matched := true
+		for _, term := range terms {
+			switch {
+			case matchesTerm(nameWords, term):
+				rank += 1
+			case matchesTerm(descriptionWords, term):
+				rank += 0.4
+			default:
+				matched = false
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		hits = append(hits, &model.SearchHit{
+			Good:          good,
+			Rank:          rank,
+			NameHighlight: highlightWords(good.Name, terms),
+			Snippet:       firstWords(highlightWords(good.Description, terms), 20),
+		})
+	}
+	return pageHits(hits, limit, offset), int32(len(hits)), nil
+}
+
+// SearchGoodsFuzzy, как и GoodsRepository, находит товары с похожими по триграммам словами в названии
+func (r *MemoryRepository) SearchGoodsFuzzy(ctx context.Context, query string, limit, offset int32) ([]*model.SearchHit, int32, error) {
+	terms := searchWords(query)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var hits []*model.SearchHit
+	for _, good := range r.filteredGoods(&model.GoodsFilter{}) {
+		nameWords := searchWords(good.Name)
+
+		// Сходство запроса - среднее по его словам сходство с самым похожим словом названия
+		var rank float64
+		for _, term := range terms {
+			var best float64
+			for _, word := range nameWords {
+				best = max(best, wordSimilarity(term, word))
+			}
+			rank += best
+		}
+		rank /= float64(len(terms))
+		if rank < FuzzyThreshold {
+			continue
+		}
+
+		hits = append(hits, &model.SearchHit{Good: good, Rank: rank})
+	}
+	return pageHits(hits, limit, offset), int32(len(hits)), nil
+}
+
+// filteredGoods возвращает копии подходящих под фильтр товаров в порядке filter.Sort; вызывается под r.mu
+func (r *MemoryRepository) filteredGoods(filter *model.GoodsFilter) []*model.Good {
+	goods := make([]*model.Good, 0, len(r.goods))
+	for _, good := range r.goods {
+		if matchesFilter(good, filter) {
+			goods = append(goods, copyGood(good))
+		}
+	}
+
+	var popularity map[int64]int32
+	if filter.Sort == model.SortPopularity {
+		popularity = make(map[int64]int32)
+		for _, reservation := range r.reservations {
+			if reservation.Status != model.ReservationReleased {
+				popularity[reservation.GoodID] += reservation.Quantity
+			}
+		}
+	}
+	sort.Slice(goods, func(i, j int) bool {
+		a, b := goods[i], goods[j]
+		switch filter.Sort {
+		case model.SortPriceAsc:
+			if a.Price != b.Price {
+				return a.Price < b.Price
+			}
+		case model.SortPriceDesc:
+			if a.Price != b.Price {
+				return a.Price > b.Price
+			}
+		case model.SortNewest:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		case model.SortName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		case model.SortPopularity:
+			if popularity[a.ID] != popularity[b.ID] {
+				return popularity[a.ID] > popularity[b.ID]
+			}
+		}
+		return a.ID < b.ID
+	})
+	return goods
+}
+
+// matchesFilter повторяет условие goodsWhere
+func matchesFilter(good *model.Good, filter *model.GoodsFilter) bool {
+	if good.ArchivedAt.IsZero() == filter.Archived {
+		return false
+	}
+	if len(filter.SubtreeCategoryIDs) > 0 && !slices.ContainsFunc(good.CategoryIDs, func(id int64) bool {
+		return slices.Contains(filter.SubtreeCategoryIDs, id)
+	}) {
+		return false
+	}
+	if filter.MinPrice > 0 && good.Price < filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice > 0 && good.Price > filter.MaxPrice {
+		return false
+	}
+	if filter.InStock && good.Stock <= 0 {
+		return false
+	}
+	if good.ID <= filter.AfterID {
+		return false
+	}
+	for _, attribute := range filter.Attributes {
+		value, ok := good.Attributes[attribute.Code]
+		if !ok {
+			return false
+		}
+		if len(attribute.Values) > 0 && !slices.Contains(attribute.Values, value) {
+			return false
+		}
+		if attribute.Min != nil || attribute.Max != nil {
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil || (attribute.Min != nil && number < *attribute.Min) || (attribute.Max != nil && number > *attribute.Max) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// slugTaken сообщает, занят ли slug категорией, отличной от exceptID; вызывается под r.mu
+func (r *MemoryRepository) slugTaken(slug string, exceptID int64) bool {
+	for _, category := range r.categories {
+		if category.Slug == slug && category.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// findVariant возвращает вариант и его товар или nil, если варианта нет; вызывается под r.mu
+func (r *MemoryRepository) findVariant(id int64) (*model.Good, *model.Variant) {
+	for _, good := range r.goods {
+		for _, variant := range good.Variants {
+			if variant.ID == id {
+				return good, variant
+			}
+		}
+	}
+	return nil, nil
+}
+
+// skuTaken сообщает, занят ли артикул вариантом, отличным от exceptVariantID; вызывается под r.mu
+func (r *MemoryRepository) skuTaken(sku string, exceptVariantID int64) bool {
+	for _, variant := range r.archivedVariants {
+		if variant.SKU == sku {
+			return true
+		}
+	}
+	for _, good := range r.goods {
+		for _, variant := range good.Variants {
+			if variant.SKU == sku && variant.ID != exceptVariantID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// refreshTotals пересчитывает цену и остаток товара по вариантам, как refreshGoodTotals в GoodsRepository
+func refreshTotals(good *model.Good) {
+	good.Stock = 0
+	for i, variant := range good.Variants {
+		if i == 0 || variant.Price < good.Price {
+			good.Price = variant.Price
+		}
+		good.Stock += variant.Stock
+	}
+}
+
+func copyGood(good *model.Good) *model.Good {
+	copied := *good
+	copied.CategoryIDs = slices.Clone(good.CategoryIDs)
+	copied.Attributes = maps.Clone(good.Attributes)
+	copied.Variants = make([]*model.Variant, len(good.Variants))
+	for i, variant := range good.Variants {
+		copied.Variants[i] = copyVariant(variant)
+	}
+	copied.Images = make([]*model.GoodImage, len(good.Images))
+	for i, image := range good.Images {
+		imageCopy := *image
+		copied.Images[i] = &imageCopy
+	}
+	return &copied
+}
+
+func copyCategory(category *model.Category) *model.Category {
+	copied := *category
+	copied.Attributes = slices.Clone(category.Attributes)
+	for i := range copied.Attributes {
+		copied.Attributes[i].AllowedValues = slices.Clone(category.Attributes[i].AllowedValues)
+	}
+	return &copied
+}
+
+func copyVariant(variant *model.Variant) *model.Variant {
+	copied := *variant
+	copied.Attributes = maps.Clone(variant.Attributes)
+	copied.Warehouses = nil
+	for _, level := range variant.Warehouses {
+		levelCopy := *level
+		copied.Warehouses = append(copied.Warehouses, &levelCopy)
+	}
+	return &copied
+}
+
+// pageHits упорядочивает найденные товары по убыванию релевантности и возвращает страницу
+func pageHits(hits []*model.SearchHit, limit, offset int32) []*model.SearchHit {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].Good.ID < hits[j].Good.ID
+	})
+	if int(offset) >= len(hits) {
+		return nil
+	}
+	hits = hits[offset:]
+	if int(limit) < len(hits) {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// searchWords разбивает текст на слова в нижнем регистре
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+func matchesTerm(words []string, term string) bool {
+	return slices.ContainsFunc(words, func(word string) bool {
+		return strings.HasPrefix(word, term)
+	})
+}
+
+// highlightWords обрамляет <b></b> слова текста, совпавшие со словами запроса, как ts_headline
+func highlightWords(text string, terms []string) string {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		if slices.ContainsFunc(terms, func(term string) bool {
+			return strings.HasPrefix(strings.ToLower(word), term)
+		}) {
+			word = "<b>" + word + "</b>"
+		}
+		b.WriteString(word)
+		start = -1
+	}
+
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	return b.String()
+}
+
+func firstWords(text string, n int) string {
+	words := strings.Fields(text)
+	if len(words) > n {
+		words = words[:n]
+	}
+	return strings.Join(words, " ")
+}
+
+// wordSimilarity считает сходство слова запроса со словом текста как word_similarity из pg_trgm:
+// доля триграмм запроса (слово дополняется пробелами), которые есть и в слове текста
+func wordSimilarity(term, word string) float64 {
+	termTrigrams, wordTrigrams := trigrams(term), trigrams(word)
+	if len(termTrigrams) == 0 {
+		return 0
+	}
+	common := 0
+	for trigram := range termTrigrams {
+		if _, ok := wordTrigrams[trigram]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(termTrigrams))
+}
+
+func trigrams(word string) map[string]struct{} {
+	runes := []rune("  " + word + " ")
+	set := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_CreateAndList(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	for _, name := range []string{"Пуэр", "Улун", "Сенча"} {
+		require.NoError(t, repo.CreateGood(ctx, &model.Good{Name: name, Price: 100, Stock: 5}))
+	}
+
+	good, err := repo.GetGood(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "GOOD-000002", good.SKU)
+
+	page, err := repo.ListGoods(ctx, &model.GoodsFilter{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "Улун", page[0].Name)
+	assert.Equal(t, "Сенча", page[1].Name)
+
+	total, err := repo.GetTotalGoods(ctx, &model.GoodsFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), total)
+
+	missing, err := repo.GetGood(ctx, 42)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+// reserve резервирует quantity варианта на складе main
+func reserve(t *testing.T, repo *MemoryRepository, variantID int64, quantity int32, orderID int64) {
+	t.Helper()
+	allocations := []*model.Allocation{{VariantID: variantID, WarehouseID: 1, Quantity: quantity}}
+	require.NoError(t, repo.ReserveAllocations(context.Background(), orderID, allocations))
+}
+
+func TestMemory_ReserveAllocations(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	good := &model.Good{Name: "Пуэр", Price: 100, Stock: 5}
+	require.NoError(t, repo.CreateGood(ctx, good))
+	spb := &model.Warehouse{Code: "spb", Name: "Санкт-Петербург", Priority: 1}
+	require.NoError(t, repo.CreateWarehouse(ctx, spb))
+	assert.ErrorIs(t, repo.CreateWarehouse(ctx, &model.Warehouse{Code: "spb"}), ErrWarehouseCodeTaken)
+
+	variantID := good.Variants[0].ID
+	require.NoError(t, repo.AdjustStock(ctx, &model.StockMovement{VariantID: variantID, WarehouseID: spb.ID, Type: model.MovementReceipt, Quantity: 4}))
+
+	reserve(t, repo, variantID, 3, 1)
+	// Позиции резервируются все или ни одной
+	err := repo.ReserveAllocations(ctx, 2, []*model.Allocation{
+		{VariantID: variantID, WarehouseID: spb.ID, Quantity: 4},
+		{VariantID: variantID, WarehouseID: 1, Quantity: 3},
+	})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	assert.ErrorIs(t, repo.ReserveAllocations(ctx, 3, []*model.Allocation{{VariantID: 42, WarehouseID: 1, Quantity: 1}}), ErrInsufficientStock)
+
+	stored, err := repo.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(6), stored.Stock)
+	assert.Equal(t, int32(6), stored.Variants[0].Stock)
+	assert.Equal(t, []*model.WarehouseStock{
+		{WarehouseID: 1, WarehouseCode: "main", Stock: 2},
+		{WarehouseID: spb.ID, WarehouseCode: "spb", Stock: 4},
+	}, stored.Variants[0].Warehouses)
+
+	// Снятый резерв возвращается на свой склад
+	released, err := repo.ReleaseStock(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, released, 1)
+	assert.Equal(t, int64(1), released[0].WarehouseID)
+	assert.Equal(t, int32(5), released[0].Balance)
+
+	discrepancies, err := repo.StockDiscrepancies(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies)
+
+	// Склад с меньшим приоритетом становится первым
+	spb.Priority = -1
+	require.NoError(t, repo.UpdateWarehouse(ctx, spb))
+	warehouses, err := repo.ListWarehouses(ctx)
+	require.NoError(t, err)
+	require.Len(t, warehouses, 2)
+	assert.Equal(t, "spb", warehouses[0].Code)
+	variant, err := repo.GetVariant(ctx, variantID)
+	require.NoError(t, err)
+	assert.Equal(t, "spb", variant.Warehouses[0].WarehouseCode)
+
+	// Архивный товар остается в истории резервирований, но больше не резервируется
+	require.NoError(t, repo.ArchiveGood(ctx, good.ID))
+	assert.NotEmpty(t, repo.reservations)
+	err = repo.ReserveAllocations(ctx, 4, []*model.Allocation{{VariantID: variantID, WarehouseID: 1, Quantity: 1}})
+	assert.ErrorIs(t, err, ErrGoodArchived)
+}
+
+func TestMemory_Variants(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	good := &model.Good{Name: "Сенча", Price: 500, Stock: 10}
+	require.NoError(t, repo.CreateGood(ctx, good))
+	require.Len(t, good.Variants, 1)
+	assert.Equal(t, good.SKU, good.Variants[0].SKU)
+	assert.ErrorIs(t, repo.CreateGood(ctx, &model.Good{SKU: good.SKU, Name: "Дубль", Price: 1}), ErrSKUTaken)
+
+	large := &model.Variant{GoodID: good.ID, SKU: "SENCHA-250", Price: 1100, Stock: 4, Attributes: map[string]string{"weight": "250 г"}}
+	require.NoError(t, repo.CreateVariant(ctx, large))
+	assert.ErrorIs(t, repo.CreateVariant(ctx, &model.Variant{GoodID: good.ID, SKU: "SENCHA-250", Price: 1}), ErrSKUTaken)
+
+	stored, err := repo.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 500.0, stored.Price)
+	assert.Equal(t, int32(14), stored.Stock)
+	require.Len(t, stored.Variants, 2)
+	assert.Equal(t, "250 г", stored.Variants[1].Attributes["weight"])
+
+	// Копии не связаны с хранилищем
+	stored.Variants[1].Attributes["weight"] = "1 кг"
+	variant, err := repo.GetVariant(ctx, large.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "250 г", variant.Attributes["weight"])
+
+	reserve(t, repo, large.ID, 3, 1)
+	small := stored.Variants[0]
+	small.Price = 1200
+	require.NoError(t, repo.UpdateVariant(ctx, small, 0))
+
+	stored, err = repo.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1100.0, stored.Price)
+	assert.Equal(t, int32(11), stored.Stock)
+
+	levels, err := repo.StockLevels(ctx)
+	require.NoError(t, err)
+	require.Len(t, levels, 2)
+	assert.Equal(t, &model.StockLevel{SKU: "SENCHA-250", Name: "Сенча", Stock: 1}, levels[1])
+
+	assert.ErrorIs(t, repo.DeleteVariant(ctx, large.ID), ErrVariantReserved)
+	_, err = repo.CommitStock(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteVariant(ctx, large.ID))
+	assert.Len(t, repo.reservations, 1)
+	variant, err = repo.GetVariant(ctx, large.ID)
+	require.NoError(t, err)
+	assert.Nil(t, variant)
+	assert.True(t, repo.skuTaken("SENCHA-250", 0))
+	stored, err = repo.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1200.0, stored.Price)
+	assert.Equal(t, int32(10), stored.Stock)
+}
+
+func TestMemory_Categories(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	tea := &model.Category{Slug: "tea", Name: "Чай"}
+	require.NoError(t, repo.CreateCategory(ctx, tea))
+	green := &model.Category{ParentID: tea.ID, Slug: "green", Name: "Зелёный"}
+	require.NoError(t, repo.CreateCategory(ctx, green))
+	assert.ErrorIs(t, repo.CreateCategory(ctx, &model.Category{Slug: "green", Name: "Дубль"}), ErrSlugTaken)
+
+	found, err := repo.GetCategoryBySlug(ctx, "green")
+	require.NoError(t, err)
+	assert.Equal(t, tea.ID, found.ParentID)
+
+	sencha := &model.Good{Name: "Сенча", Price: 100}
+	puer := &model.Good{Name: "Пуэр", Price: 200}
+	require.NoError(t, repo.CreateGood(ctx, sencha))
+	require.NoError(t, repo.CreateGood(ctx, puer))
+	require.NoError(t, repo.SetGoodCategories(ctx, sencha.ID, []int64{green.ID, tea.ID, green.ID}))
+
+	stored, err := repo.GetGood(ctx, sencha.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{tea.ID, green.ID}, stored.CategoryIDs)
+
+	filter := &model.GoodsFilter{Limit: 10, SubtreeCategoryIDs: []int64{green.ID}}
+	goods, err := repo.ListGoods(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, goods, 1)
+	assert.Equal(t, sencha.ID, goods[0].ID)
+	total, err := repo.GetTotalGoods(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+
+	require.NoError(t, repo.DeleteCategory(ctx, green.ID))
+	stored, err = repo.GetGood(ctx, sencha.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{tea.ID}, stored.CategoryIDs)
+}
+
+func TestMemory_FilterAndSort(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	sencha := &model.Good{Name: "Сенча", Price: 700, Stock: 5}
+	puer := &model.Good{Name: "Пуэр", Price: 2500, Stock: 0}
+	oolong := &model.Good{Name: "Улун", Price: 1200, Stock: 3}
+	for _, good := range []*model.Good{sencha, puer, oolong} {
+		require.NoError(t, repo.CreateGood(ctx, good))
+	}
+	reserve(t, repo, oolong.Variants[0].ID, 2, 1)
+	reserve(t, repo, sencha.Variants[0].ID, 1, 2)
+
+	ids := func(filter *model.GoodsFilter) []int64 {
+		filter.Limit = 10
+		goods, err := repo.ListGoods(ctx, filter)
+		require.NoError(t, err)
+		var ids []int64
+		for _, good := range goods {
+			ids = append(ids, good.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int64{sencha.ID, oolong.ID, puer.ID}, ids(&model.GoodsFilter{Sort: model.SortPriceAsc}))
+	assert.Equal(t, []int64{puer.ID, oolong.ID, sencha.ID}, ids(&model.GoodsFilter{Sort: model.SortPriceDesc}))
+	assert.Equal(t, []int64{oolong.ID, puer.ID, sencha.ID}, ids(&model.GoodsFilter{Sort: model.SortNewest}))
+	assert.Equal(t, []int64{puer.ID, sencha.ID, oolong.ID}, ids(&model.GoodsFilter{Sort: model.SortName}))
+	assert.Equal(t, []int64{oolong.ID, sencha.ID, puer.ID}, ids(&model.GoodsFilter{Sort: model.SortPopularity}))
+
+	assert.Equal(t, []int64{sencha.ID, oolong.ID}, ids(&model.GoodsFilter{InStock: true}))
+	assert.Equal(t, []int64{puer.ID, oolong.ID}, ids(&model.GoodsFilter{MinPrice: 1000}))
+	assert.Equal(t, []int64{sencha.ID, oolong.ID}, ids(&model.GoodsFilter{MaxPrice: 1200}))
+}
+
+func TestMemory_GoodsFacets(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	tea := &model.Category{Slug: "tea", Name: "Чай"}
+	require.NoError(t, repo.CreateCategory(ctx, tea))
+	green := &model.Category{ParentID: tea.ID, Slug: "green", Name: "Зеленый"}
+	require.NoError(t, repo.CreateCategory(ctx, green))
+	teaware := &model.Category{Slug: "teaware", Name: "Посуда"}
+	require.NoError(t, repo.CreateCategory(ctx, teaware))
+
+	sencha := &model.Good{Name: "Сенча", Price: 700, Stock: 5}
+	puer := &model.Good{Name: "Пуэр", Price: 2500, Stock: 0}
+	gaiwan := &model.Good{Name: "Гайвань", Price: 1000, Stock: 2}
+	for _, good := range []*model.Good{sencha, puer, gaiwan} {
+		require.NoError(t, repo.CreateGood(ctx, good))
+	}
+	require.NoError(t, repo.SetGoodCategories(ctx, sencha.ID, []int64{tea.ID, green.ID}))
+	require.NoError(t, repo.SetGoodCategories(ctx, puer.ID, []int64{tea.ID}))
+	require.NoError(t, repo.SetGoodCategories(ctx, gaiwan.ID, []int64{teaware.ID}))
+
+	// Фасет не сужается собственным фильтром: в категориях видны все категории,
+	// в наличии - товары без остатка
+	filter := &model.GoodsFilter{SubtreeCategoryIDs: []int64{green.ID}, InStock: true}
+	facets, err := repo.GoodsFacets(ctx, filter, []float64{1000, 2000})
+	require.NoError(t, err)
+
+	assert.Equal(t, []model.CategoryFacet{
+		{CategoryID: tea.ID, Count: 1},
+		{CategoryID: green.ID, Count: 1},
+		{CategoryID: teaware.ID, Count: 1},
+	}, facets.Categories)
+	assert.Equal(t, []model.PriceFacet{
+		{Max: 1000, Count: 1},
+		{Min: 1000, Max: 2000},
+		{Min: 2000},
+	}, facets.Prices)
+	assert.Equal(t, int32(1), facets.InStock)
+	assert.Equal(t, int32(0), facets.OutOfStock)
+
+	facets, err = repo.GoodsFacets(ctx, &model.GoodsFilter{}, []float64{1000, 2000})
+	require.NoError(t, err)
+	assert.Equal(t, model.CategoryFacet{CategoryID: tea.ID, Count: 2}, facets.Categories[0])
+	assert.Equal(t, []int32{1, 1, 1}, []int32{facets.Prices[0].Count, facets.Prices[1].Count, facets.Prices[2].Count})
+	assert.Equal(t, int32(2), facets.InStock)
+	assert.Equal(t, int32(1), facets.OutOfStock)
+}
+
+func TestMemory_SearchGoods(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	for _, good := range []*model.Good{
+		{Name: "Шу Пуэр", Description: "Выдержанный чай из Юньнани", Price: 1200},
+		{Name: "Сенча", Description: "Японский зелёный чай, похож на пуэра только ценой", Price: 700},
+		{Name: "Гайвань", Description: "Фарфоровая чашка с крышкой", Price: 900},
+	} {
+		require.NoError(t, repo.CreateGood(ctx, good))
+	}
+
+	hits, total, err := repo.SearchGoods(ctx, "Пуэр", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), total)
+	require.Len(t, hits, 2)
+	// Совпадение в названии выше совпадения в описании
+	assert.Equal(t, "Шу Пуэр", hits[0].Good.Name)
+	assert.Equal(t, "Шу <b>Пуэр</b>", hits[0].NameHighlight)
+	assert.Contains(t, hits[1].Snippet, "<b>пуэра</b>")
+
+	hits, total, err = repo.SearchGoods(ctx, "зелёный чай", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	assert.Equal(t, "Сенча", hits[0].Good.Name)
+
+	hits, total, err = repo.SearchGoods(ctx, "пуер", 10, 0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, hits)
+}
+
+func TestMemory_SearchGoodsFuzzy(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateGood(ctx, &model.Good{Name: "Шу Пуэр", Price: 1200}))
+	require.NoError(t, repo.CreateGood(ctx, &model.Good{Name: "Сенча", Price: 700}))
+
+	hits, total, err := repo.SearchGoodsFuzzy(ctx, "пуер", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	assert.Equal(t, "Шу Пуэр", hits[0].Good.Name)
+	assert.GreaterOrEqual(t, hits[0].Rank, FuzzyThreshold)
+
+	_, total, err = repo.SearchGoodsFuzzy(ctx, "кофе", 10, 0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestWordSimilarity(t *testing.T) {
+	// Пример из документации pg_trgm: word_similarity('word', 'two words') = 0.8
+	assert.InDelta(t, 0.8, wordSimilarity("word", "words"), 1e-9)
+	assert.InDelta(t, 1.0, wordSimilarity("улун", "улун"), 1e-9)
+	assert.InDelta(t, 0.4, wordSimilarity("пуер", "пуэр"), 1e-9)
+	assert.Zero(t, wordSimilarity("чай", "кофе"))
+}
@@ -3,23 +3,73 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"github.com/che1nov/tea-shop/goods-service/internal/model"
 )
 
+var (
+	// ErrGoodReserved - у товара есть незавершенные резервирования заказов
+	ErrGoodReserved = errors.New("good has active reservations")
+	// ErrGoodArchived - товар в архиве и не продается
+	ErrGoodArchived = errors.New("good is archived")
+)
+
 // GoodsRepositoryInterface определяет методы репозитория
 type GoodsRepositoryInterface interface {
 	CreateGood(ctx context.Context, good *model.Good) error
 	GetGood(ctx context.Context, id int64) (*model.Good, error)
-	ListGoods(ctx context.Context, limit, offset int32) ([]*model.Good, error)
+	GetGoodBySKU(ctx context.Context, sku string) (*model.Good, error)
+	ListGoods(ctx context.Context, filter *model.GoodsFilter) ([]*model.Good, error)
 	UpdateGood(ctx context.Context, good *model.Good) error
-	DeleteGood(ctx context.Context, id int64) error
-	ReserveStock(ctx context.Context, goodID int64, quantity int32, orderID int64) error
-	GetTotalGoods(ctx context.Context) (int32, error)
+	ArchiveGood(ctx context.Context, id int64) error
+	RestoreGood(ctx context.Context, id int64) error
+	GetTotalGoods(ctx context.Context, filter *model.GoodsFilter) (int32, error)
+	GoodsFacets(ctx context.Context, filter *model.GoodsFilter, priceBounds []float64) (*model.GoodsFacets, error)
+	SearchGoods(ctx context.Context, query string, limit, offset int32) ([]*model.SearchHit, int32, error)
+	SearchGoodsFuzzy(ctx context.Context, query string, limit, offset int32) ([]*model.SearchHit, int32, error)
+
+	CreateCategory(ctx context.Context, category *model.Category) error
+	GetCategory(ctx context.Context, id int64) (*model.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error)
+	ListCategories(ctx context.Context) ([]*model.Category, error)
+	UpdateCategory(ctx context.Context, category *model.Category) error
+	DeleteCategory(ctx context.Context, id int64) error
+	SetGoodCategories(ctx context.Context, goodID int64, categoryIDs []int64) error
+
+	GetVariant(ctx context.Context, id int64) (*model.Variant, error)
+	GetVariantBySKU(ctx context.Context, sku string) (*model.Variant, error)
+	CreateVariant(ctx context.Context, variant *model.Variant) error
+	UpdateVariant(ctx context.Context, variant *model.Variant, stockDelta int32) error
+	DeleteVariant(ctx context.Context, id int64) error
+
+	CreateGoodImage(ctx context.Context, image *model.GoodImage) error
+	GetGoodImage(ctx context.Context, id int64) (*model.GoodImage, error)
+	DeleteGoodImage(ctx context.Context, id int64) error
+	SetGoodImageOrder(ctx context.Context, goodID int64, imageIDs []int64) error
+
+	AdjustStock(ctx context.Context, movement *model.StockMovement) error
+	ReleaseStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error)
+	CommitStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error)
+	ListStockMovements(ctx context.Context, goodID, variantID int64, limit, offset int32) ([]*model.StockMovement, int32, error)
+	StockDiscrepancies(ctx context.Context) ([]*model.StockDiscrepancy, error)
+
+	CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) error
+	GetWarehouse(ctx context.Context, id int64) (*model.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouse *model.Warehouse) error
+	ListWarehouses(ctx context.Context) ([]*model.Warehouse, error)
+	ReserveAllocations(ctx context.Context, orderID int64, allocations []*model.Allocation) error
+
+	CreateImportJob(ctx context.Context, job *model.ImportJob) error
+	UpdateImportJob(ctx context.Context, job *model.ImportJob) error
+	GetImportJob(ctx context.Context, id int64) (*model.ImportJob, error)
 }
 
 type GoodsRepository struct {
@@ -41,6 +91,8 @@ func (r *GoodsRepository) generateSKU(ctx context.Context) (string, error) {
 	return fmt.Sprintf("GOOD-%06d", maxID+1), nil
 }
 
+// CreateGood создает товар с одним вариантом с теми же ценой и остатком. Артикул и атрибуты
+// варианта берутся из good.Variants[0], если он задан, иначе артикул совпадает с артикулом товара
 func (r *GoodsRepository) CreateGood(ctx context.Context, good *model.Good) error {
 	// Если SKU не указан, генерируем автоматически
 	if good.SKU == "" {
@@ -51,13 +103,24 @@ func (r *GoodsRepository) CreateGood(ctx context.Context, good *model.Good) erro
 		good.SKU = sku
 	}
 
+	attributes, err := attributesJSON(good.Attributes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO goods (sku, name, description, price, stock, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO goods (sku, name, description, price, stock, attributes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id
 	`
 	now := time.Now()
-	return r.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		good.SKU,
@@ -65,15 +128,61 @@ func (r *GoodsRepository) CreateGood(ctx context.Context, good *model.Good) erro
 		good.Description,
 		good.Price,
 		good.Stock,
+		attributes,
 		now,
 		now,
 	).Scan(&good.ID)
+	if isUniqueViolation(err) {
+		return ErrSKUTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	variant := firstVariant(good)
+	if err := insertVariant(ctx, tx, variant); err != nil {
+		return err
+	}
+	if err := insertReceipt(ctx, tx, variant); err != nil {
+		return err
+	}
+	for _, categoryID := range good.CategoryIDs {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO good_categories (good_id, category_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			good.ID,
+			categoryID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	good.CreatedAt = now
+	good.UpdatedAt = now
+	good.Variants = []*model.Variant{variant}
+	return nil
+}
+
+// firstVariant возвращает первый вариант создаваемого товара
+func firstVariant(good *model.Good) *model.Variant {
+	variant := &model.Variant{GoodID: good.ID, SKU: good.SKU, Price: good.Price, Stock: good.Stock}
+	if len(good.Variants) > 0 {
+		variant.SKU = good.Variants[0].SKU
+		variant.Attributes = maps.Clone(good.Variants[0].Attributes)
+	}
+	return variant
 }
 
 func (r *GoodsRepository) GetGood(ctx context.Context, id int64) (*model.Good, error) {
-	query := `SELECT id, sku, name, description, price, stock, created_at, updated_at FROM goods WHERE id = $1`
+	query := `SELECT id, sku, name, description, price, stock, attributes, created_at, updated_at, archived_at FROM goods WHERE id = $1`
 
 	good := &model.Good{}
+	var attributes []byte
+	var archivedAt sql.NullTime
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&good.ID,
 		&good.SKU,
@@ -81,8 +190,10 @@ func (r *GoodsRepository) GetGood(ctx context.Context, id int64) (*model.Good, e
 		&good.Description,
 		&good.Price,
 		&good.Stock,
+		&attributes,
 		&good.CreatedAt,
 		&good.UpdatedAt,
+		&archivedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -91,19 +202,121 @@ func (r *GoodsRepository) GetGood(ctx context.Context, id int64) (*model.Good, e
 	if err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(attributes, &good.Attributes); err != nil {
+		return nil, err
+	}
+	good.ArchivedAt = archivedAt.Time
+
+	if err := r.loadRelations(ctx, []*model.Good{good}); err != nil {
+		return nil, err
+	}
 
 	return good, nil
 }
 
-func (r *GoodsRepository) ListGoods(ctx context.Context, limit, offset int32) ([]*model.Good, error) {
-	query := `
-		SELECT id, sku, name, description, price, stock, created_at, updated_at 
-		FROM goods 
-		ORDER BY id 
-		LIMIT $1 OFFSET $2
-	`
+// GetGoodBySKU находит товар по артикулу товара; nil, если такого нет
+func (r *GoodsRepository) GetGoodBySKU(ctx context.Context, sku string) (*model.Good, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM goods WHERE sku = $1", sku).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetGood(ctx, id)
+}
+
+// goodsWhere собирает условие выборки товаров по фильтру; аргументы нумеруются с $1.
+// Архивные товары выбираются только с filter.Archived
+func goodsWhere(filter *model.GoodsFilter) (string, []any) {
+	conditions := []string{"archived_at IS NULL"}
+	if filter.Archived {
+		conditions[0] = "archived_at IS NOT NULL"
+	}
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(filter.SubtreeCategoryIDs) > 0 {
+		conditions = append(conditions,
+			"id IN (SELECT good_id FROM good_categories WHERE category_id = ANY("+arg(pq.Array(filter.SubtreeCategoryIDs))+"))")
+	}
+	if filter.MinPrice > 0 {
+		conditions = append(conditions, "price >= "+arg(filter.MinPrice))
+	}
+	if filter.MaxPrice > 0 {
+		conditions = append(conditions, "price <= "+arg(filter.MaxPrice))
+	}
+	if filter.InStock {
+		conditions = append(conditions, "stock > 0")
+	}
+	if filter.AfterID > 0 {
+		conditions = append(conditions, "id > "+arg(filter.AfterID))
+	}
+	for _, attribute := range filter.Attributes {
+		// Значения - проверка вхождения по GIN индексу, диапазон - по числовым значениям атрибута
+		if len(attribute.Values) > 0 {
+			matches := make([]string, len(attribute.Values))
+			for i, value := range attribute.Values {
+				matches[i] = "attributes @> " + arg(attributeContains(attribute.Code, value)) + "::jsonb"
+			}
+			conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+		}
+		if attribute.Min != nil || attribute.Max != nil {
+			number := fmt.Sprintf(`CASE WHEN attributes->>%[1]s ~ '^-?[0-9]+(\.[0-9]+)?$' THEN (attributes->>%[1]s)::numeric END`, arg(attribute.Code))
+			if attribute.Min != nil {
+				conditions = append(conditions, number+" >= "+arg(*attribute.Min))
+			}
+			if attribute.Max != nil {
+				conditions = append(conditions, number+" <= "+arg(*attribute.Max))
+			}
+		}
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+// attributeContains - JSON для проверки вхождения {"code": "value"}
+func attributeContains(code, value string) string {
+	// Сериализация строк не возвращает ошибок
+	data, _ := json.Marshal(map[string]string{code: value})
+	return string(data)
+}
+
+// goodsOrder возвращает порядок выдачи; id в конце делает порядок страниц стабильным
+func goodsOrder(sort string) string {
+	switch sort {
+	case model.SortPriceAsc:
+		return "price, id"
+	case model.SortPriceDesc:
+		return "price DESC, id"
+	case model.SortNewest:
+		return "created_at DESC, id DESC"
+	case model.SortName:
+		return "name, id"
+	case model.SortPopularity:
+		// Популярность - сколько единиц товара зарезервировано заказами
+		return "(SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations WHERE good_id = goods.id AND status <> 'released') DESC, id"
+	default:
+		return "id"
+	}
+}
+
+func (r *GoodsRepository) ListGoods(ctx context.Context, filter *model.GoodsFilter) ([]*model.Good, error) {
+	where, args := goodsWhere(filter)
+	query := fmt.Sprintf(`
+		SELECT id, sku, name, description, price, stock, attributes, created_at, updated_at, archived_at
+		FROM goods
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, where, goodsOrder(filter.Sort), len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -112,6 +325,8 @@ func (r *GoodsRepository) ListGoods(ctx context.Context, limit, offset int32) ([
 	var goods []*model.Good
 	for rows.Next() {
 		good := &model.Good{}
+		var attributes []byte
+		var archivedAt sql.NullTime
 		if err := rows.Scan(
 			&good.ID,
 			&good.SKU,
@@ -119,106 +334,189 @@ func (r *GoodsRepository) ListGoods(ctx context.Context, limit, offset int32) ([
 			&good.Description,
 			&good.Price,
 			&good.Stock,
+			&attributes,
 			&good.CreatedAt,
 			&good.UpdatedAt,
+			&archivedAt,
 		); err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal(attributes, &good.Attributes); err != nil {
+			return nil, err
+		}
+		good.ArchivedAt = archivedAt.Time
 		goods = append(goods, good)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return goods, rows.Err()
+	if err := r.loadRelations(ctx, goods); err != nil {
+		return nil, err
+	}
+	return goods, nil
 }
 
-func (r *GoodsRepository) ReserveStock(ctx context.Context, goodID int64, quantity int32, orderID int64) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
+// loadRelations заполняет категории, варианты с остатками по складам и изображения товаров
+func (r *GoodsRepository) loadRelations(ctx context.Context, goods []*model.Good) error {
+	if err := r.loadCategoryIDs(ctx, goods); err != nil {
 		return err
 	}
-	defer tx.Rollback()
-
-	// Проверяем, есть ли товар и достаточно ли его
-	var currentStock int32
-	err = tx.QueryRowContext(ctx, "SELECT stock FROM goods WHERE id = $1 FOR UPDATE", goodID).Scan(&currentStock)
-	if err != nil {
+	if err := r.loadVariants(ctx, goods); err != nil {
 		return err
 	}
+	var variants []*model.Variant
+	for _, good := range goods {
+		variants = append(variants, good.Variants...)
+	}
+	if err := r.loadWarehouseStock(ctx, variants); err != nil {
+		return err
+	}
+	return r.loadImages(ctx, goods)
+}
 
-	if currentStock < quantity {
-		return sql.ErrNoRows // Товара недостаточно
+// loadCategoryIDs заполняет CategoryIDs товаров одним запросом
+func (r *GoodsRepository) loadCategoryIDs(ctx context.Context, goods []*model.Good) error {
+	if len(goods) == 0 {
+		return nil
 	}
 
-	// Уменьшаем остаток
-	_, err = tx.ExecContext(
-		ctx,
-		"UPDATE goods SET stock = stock - $1 WHERE id = $2",
-		quantity,
-		goodID,
-	)
-	if err != nil {
-		return err
+	byID := make(map[int64]*model.Good, len(goods))
+	ids := make([]int64, len(goods))
+	for i, good := range goods {
+		byID[good.ID] = good
+		ids[i] = good.ID
 	}
 
-	// Сохраняем информацию о резервировании
-	_, err = tx.ExecContext(
+	rows, err := r.db.QueryContext(
 		ctx,
-		"INSERT INTO stock_reservations (good_id, order_id, quantity, created_at) VALUES ($1, $2, $3, $4)",
-		goodID,
-		orderID,
-		quantity,
-		time.Now(),
+		"SELECT good_id, category_id FROM good_categories WHERE good_id = ANY($1) ORDER BY good_id, category_id",
+		pq.Array(ids),
 	)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	return tx.Commit()
+	for rows.Next() {
+		var goodID, categoryID int64
+		if err := rows.Scan(&goodID, &categoryID); err != nil {
+			return err
+		}
+		good := byID[goodID]
+		good.CategoryIDs = append(good.CategoryIDs, categoryID)
+	}
+
+	return rows.Err()
 }
 
+// UpdateGood меняет артикул, название, описание и атрибуты; цена и остаток меняются через варианты
 func (r *GoodsRepository) UpdateGood(ctx context.Context, good *model.Good) error {
 	query := `
 		UPDATE goods 
-		SET sku = $1, name = $2, description = $3, price = $4, stock = $5, updated_at = $6
-		WHERE id = $7
+		SET sku = $1, name = $2, description = $3, attributes = $4, updated_at = $5
+		WHERE id = $6
 	`
-	_, err := r.db.ExecContext(
+	attributes, err := attributesJSON(good.Attributes)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(
 		ctx,
 		query,
 		good.SKU,
 		good.Name,
 		good.Description,
-		good.Price,
-		good.Stock,
+		attributes,
 		time.Now(),
 		good.ID,
 	)
 	return err
 }
 
-func (r *GoodsRepository) DeleteGood(ctx context.Context, id int64) error {
+// ArchiveGood убирает товар из каталога. Строка товара блокируется до проверки резервирований:
+// резервирование тоже меняет строку товара, поэтому параллельный заказ либо успевает раньше
+// и архивирование вернет ErrGoodReserved, либо ждет и получит ErrGoodArchived
+func (r *GoodsRepository) ArchiveGood(ctx context.Context, id int64) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Сначала удаляем все резервации для этого товара
-	_, err = tx.ExecContext(ctx, "DELETE FROM stock_reservations WHERE good_id = $1", id)
+	var archivedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT archived_at FROM goods WHERE id = $1 FOR UPDATE", id).Scan(&archivedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	if archivedAt.Valid {
+		return nil
+	}
 
-	// Затем удаляем сам товар
-	_, err = tx.ExecContext(ctx, "DELETE FROM goods WHERE id = $1", id)
+	var reserved bool
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM stock_reservations WHERE good_id = $1 AND status = $2)",
+		id,
+		model.ReservationReserved,
+	).Scan(&reserved)
 	if err != nil {
 		return err
 	}
+	if reserved {
+		return ErrGoodReserved
+	}
 
+	if _, err := tx.ExecContext(ctx, "UPDATE goods SET archived_at = $1, updated_at = $1 WHERE id = $2", time.Now(), id); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
-func (r *GoodsRepository) GetTotalGoods(ctx context.Context) (int32, error) {
+// RestoreGood возвращает архивный товар в каталог
+func (r *GoodsRepository) RestoreGood(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE goods SET archived_at = NULL, updated_at = $1 WHERE id = $2 AND archived_at IS NOT NULL",
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+// GetTotalGoods возвращает количество товаров, подходящих под фильтр, без учёта пагинации
+func (r *GoodsRepository) GetTotalGoods(ctx context.Context, filter *model.GoodsFilter) (int32, error) {
+	where, args := goodsWhere(filter)
 	var total int32
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM goods").Scan(&total)
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM goods "+where, args...).Scan(&total)
 	return total, err
 }
+
+// StockLevels возвращает остатки всех вариантов
+func (r *GoodsRepository) StockLevels(ctx context.Context) ([]*model.StockLevel, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT v.sku, g.name, v.stock
+		FROM good_variants v
+		JOIN goods g ON g.id = v.good_id
+		WHERE v.archived_at IS NULL
+		ORDER BY v.sku
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var levels []*model.StockLevel
+	for rows.Next() {
+		level := &model.StockLevel{}
+		if err := rows.Scan(&level.SKU, &level.Name, &level.Stock); err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+
+	return levels, rows.Err()
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/che1nov/tea-shop/goods-service/internal/model"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
@@ -129,13 +131,25 @@ func TestListGoods_Success(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
-	goods, err := repo.ListGoods(ctx, 10, 0)
+	goods, err := repo.ListGoods(ctx, &model.GoodsFilter{Limit: 10})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, goods)
 	assert.GreaterOrEqual(t, len(goods), 2)
 }
 
+func TestListGoods_AfterID(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectQuery(sqlPattern("WHERE archived_at IS NULL AND id > $1")).
+		WithArgs(int64(5), int32(100), int32(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sku", "name", "description", "price", "stock", "attributes", "created_at", "updated_at", "archived_at"}))
+
+	goods, err := repo.ListGoods(context.Background(), &model.GoodsFilter{Limit: 100, AfterID: 5})
+	require.NoError(t, err)
+	assert.Empty(t, goods)
+}
+
 func TestGetTotalGoods_Success(t *testing.T) {
 	db := setupTestDBWithCleanup(t)
 	defer db.Close()
@@ -153,9 +167,84 @@ func TestGetTotalGoods_Success(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
-	total, err := repo.GetTotalGoods(ctx)
+	total, err := repo.GetTotalGoods(ctx, &model.GoodsFilter{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, int32(2), total)
 }
 
+func TestArchiveGood_LocksGoodBeforeCheckingReservations(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT archived_at FROM goods WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"archived_at"}).AddRow(nil))
+	mock.ExpectQuery(sqlPattern("SELECT EXISTS (SELECT 1 FROM stock_reservations WHERE good_id = $1 AND status = $2)")).
+		WithArgs(int64(3), model.ReservationReserved).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(sqlPattern("UPDATE goods SET archived_at = $1, updated_at = $1 WHERE id = $2")).
+		WithArgs(sqlmock.AnyArg(), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.ArchiveGood(context.Background(), 3))
+}
+
+func TestArchiveGood_ReservedGood(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT archived_at FROM goods WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"archived_at"}).AddRow(nil))
+	mock.ExpectQuery(sqlPattern("SELECT EXISTS (SELECT 1 FROM stock_reservations")).
+		WithArgs(int64(3), model.ReservationReserved).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.ArchiveGood(context.Background(), 3), ErrGoodReserved)
+}
+
+func TestArchiveGood_MissingOrArchivedIsNoop(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT archived_at FROM goods WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(404)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT archived_at FROM goods WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"archived_at"}).AddRow(time.Now()))
+	mock.ExpectRollback()
+
+	require.NoError(t, repo.ArchiveGood(context.Background(), 404))
+	require.NoError(t, repo.ArchiveGood(context.Background(), 3))
+}
+
+func TestRestoreGood(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectExec(sqlPattern("UPDATE goods SET archived_at = NULL, updated_at = $1 WHERE id = $2 AND archived_at IS NOT NULL")).
+		WithArgs(sqlmock.AnyArg(), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlPattern("UPDATE goods SET archived_at = NULL")).
+		WithArgs(sqlmock.AnyArg(), int64(4)).
+		WillReturnError(errors.New("connection reset"))
+
+	require.NoError(t, repo.RestoreGood(context.Background(), 3))
+	assert.Error(t, repo.RestoreGood(context.Background(), 4))
+}
+
+func TestGetTotalGoods_Archived(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectQuery(sqlPattern("SELECT COUNT(*) FROM goods WHERE archived_at IS NOT NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	total, err := repo.GetTotalGoods(context.Background(), &model.GoodsFilter{Archived: true})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), total)
+}
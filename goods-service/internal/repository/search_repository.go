@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// FuzzyThreshold - минимальное сходство триграмм запроса и названия для поиска по опечаткам
+const FuzzyThreshold = 0.3
+
+// tsQuery - запрос в синтаксисе поисковых строк ("зеленый чай", "улун -молочный", "пуэр or хун ча"),
+// разобранный русской и английской конфигурациями; товар подходит, если совпал по любой из них
+const tsQuery = `(websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1))`
+
+// headlineOptions - до двух фрагментов описания по 5-20 слов вокруг совпадений
+const headlineOptions = `MaxFragments=2, MinWords=5, MaxWords=20, FragmentDelimiter=" ... "`
+
+// SearchGoods ищет товары каталога полнотекстовым поиском, упорядочивая по релевантности
+func (r *GoodsRepository) SearchGoods(ctx context.Context, query string, limit, offset int32) ([]*model.SearchHit, int32, error) {
+	var total int32
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM goods WHERE archived_at IS NULL AND search_vector @@ "+tsQuery, query).Scan(&total)
+	if err != nil || total == 0 {
+		return nil, total, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, sku, name, description, price, stock, attributes, created_at, updated_at,
+			ts_rank_cd(search_vector, search.query) AS rank,
+			ts_headline('russian', name, search.query, 'HighlightAll=true'),
+			ts_headline('russian', COALESCE(description, ''), search.query, '`+headlineOptions+`')
+		FROM goods, (SELECT `+tsQuery+` AS query) AS search
+		WHERE archived_at IS NULL AND search_vector @@ search.query
+		ORDER BY rank DESC, id
+		LIMIT $2 OFFSET $3
+	`, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits, err := r.scanHits(ctx, rows, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return hits, total, nil
+}
+
+// SearchGoodsFuzzy ищет товары, в названии которых есть слово, похожее на запрос (опечатки, другая транслитерация).
+// Подсветки нет: NameHighlight и Snippet пустые
+func (r *GoodsRepository) SearchGoodsFuzzy(ctx context.Context, query string, limit, offset int32) ([]*model.SearchHit, int32, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	// Порог оператора <% задается на время транзакции, чтобы поиск использовал триграммный индекс
+	threshold := strconv.FormatFloat(FuzzyThreshold, 'f', -1, 64)
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)", threshold); err != nil {
+		return nil, 0, err
+	}
+
+	var total int32
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM goods WHERE archived_at IS NULL AND $1 <% name", query).Scan(&total); err != nil || total == 0 {
+		return nil, total, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, sku, name, description, price, stock, attributes, created_at, updated_at,
+			word_similarity($1, name) AS rank
+		FROM goods
+		WHERE archived_at IS NULL AND $1 <% name
+		ORDER BY rank DESC, id
+		LIMIT $2 OFFSET $3
+	`, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits, err := r.scanHits(ctx, rows, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	return hits, total, tx.Commit()
+}
+
+// scanHits читает найденные товары; highlighted - в строках есть подсветка названия и фрагменты описания
+func (r *GoodsRepository) scanHits(ctx context.Context, rows *sql.Rows, highlighted bool) ([]*model.SearchHit, error) {
+	defer rows.Close()
+
+	var hits []*model.SearchHit
+	var goods []*model.Good
+	for rows.Next() {
+		good := &model.Good{}
+		hit := &model.SearchHit{Good: good}
+		var attributes []byte
+		dest := []any{
+			&good.ID,
+			&good.SKU,
+			&good.Name,
+			&good.Description,
+			&good.Price,
+			&good.Stock,
+			&attributes,
+			&good.CreatedAt,
+			&good.UpdatedAt,
+			&hit.Rank,
+		}
+		if highlighted {
+			dest = append(dest, &hit.NameHighlight, &hit.Snippet)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(attributes, &good.Attributes); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+		goods = append(goods, good)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadRelations(ctx, goods); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var searchHitColumns = []string{"id", "sku", "name", "description", "price", "stock", "attributes", "created_at", "updated_at", "rank"}
+
+// expectNoRelations ожидает загрузку категорий, вариантов и изображений найденных товаров, у которых их нет
+func expectNoRelations(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(sqlPattern("FROM good_categories WHERE good_id = ANY($1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"good_id", "category_id"}))
+	mock.ExpectQuery(sqlPattern("FROM good_variants WHERE good_id = ANY($1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(sqlPattern("FROM good_images WHERE good_id = ANY($1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+}
+
+func TestSearchGoods_RanksAndHighlights(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	now := time.Now()
+
+	mock.ExpectQuery(sqlPattern("SELECT COUNT(*) FROM goods WHERE archived_at IS NULL AND search_vector @@ (websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1))")).
+		WithArgs("улун").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(sqlPattern("ts_rank_cd(search_vector, search.query) AS rank")).
+		WithArgs("улун", int32(10), int32(0)).
+		WillReturnRows(sqlmock.NewRows(append(searchHitColumns, "name_highlight", "snippet")).
+			AddRow(7, "TEA-7", "Молочный улун", "Нежный улун", 450.0, 3, []byte("{}"), now, now, 0.8, "Молочный <b>улун</b>", "Нежный <b>улун</b>"))
+	expectNoRelations(mock)
+
+	hits, total, err := repo.SearchGoods(context.Background(), "улун", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	require.Len(t, hits, 1)
+	assert.Equal(t, int64(7), hits[0].Good.ID)
+	assert.Equal(t, 0.8, hits[0].Rank)
+	assert.Equal(t, "Молочный <b>улун</b>", hits[0].NameHighlight)
+	assert.Equal(t, "Нежный <b>улун</b>", hits[0].Snippet)
+}
+
+func TestSearchGoods_NothingFoundSkipsPage(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectQuery(sqlPattern("SELECT COUNT(*) FROM goods WHERE archived_at IS NULL AND search_vector @@")).
+		WithArgs("кофе").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	hits, total, err := repo.SearchGoods(context.Background(), "кофе", 10, 0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, hits)
+}
+
+func TestSearchGoodsFuzzy_UsesTrigramThreshold(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlPattern("SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)")).
+		WithArgs("0.3").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(sqlPattern("SELECT COUNT(*) FROM goods WHERE archived_at IS NULL AND $1 <% name")).
+		WithArgs("пуэp").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(sqlPattern("word_similarity($1, name) AS rank")).
+		WithArgs("пуэp", int32(5), int32(0)).
+		WillReturnRows(sqlmock.NewRows(searchHitColumns).
+			AddRow(2, "TEA-2", "Шу пуэр", "", 900.0, 1, []byte("{}"), now, now, 0.6))
+	expectNoRelations(mock)
+	mock.ExpectCommit()
+
+	hits, total, err := repo.SearchGoodsFuzzy(context.Background(), "пуэp", 5, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "Шу пуэр", hits[0].Good.Name)
+	assert.Empty(t, hits[0].NameHighlight)
+}
+
+func TestSearchGoodsFuzzy_ThresholdError(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(sqlPattern("SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)")).
+		WillReturnError(errors.New("pg_trgm is not installed"))
+	mock.ExpectRollback()
+
+	_, _, err := repo.SearchGoodsFuzzy(context.Background(), "пуэр", 5, 0)
+	assert.Error(t, err)
+}
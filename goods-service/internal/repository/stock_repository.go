@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// ErrInsufficientStock - движение увело бы остаток варианта в минус
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// stockUpdateReason - причина корректировки, когда остаток задан напрямую через UpdateGood или UpdateVariant
+const stockUpdateReason = "stock set by update"
+
+const movementColumns = "id, good_id, variant_id, warehouse_id, type, quantity, reserved, balance, COALESCE(order_id, 0), reason, created_at"
+
+func scanMovement(row scanner) (*model.StockMovement, error) {
+	movement := &model.StockMovement{}
+	if err := row.Scan(
+		&movement.ID,
+		&movement.GoodID,
+		&movement.VariantID,
+		&movement.WarehouseID,
+		&movement.Type,
+		&movement.Quantity,
+		&movement.Reserved,
+		&movement.Balance,
+		&movement.OrderID,
+		&movement.Reason,
+		&movement.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// insertMovement записывает движение в журнал в транзакции, изменившей остаток
+func insertMovement(ctx context.Context, tx *sql.Tx, movement *model.StockMovement) error {
+	var orderID any
+	if movement.OrderID != 0 {
+		orderID = movement.OrderID
+	}
+	movement.CreatedAt = time.Now()
+	return tx.QueryRowContext(
+		ctx,
+		`INSERT INTO stock_movements (good_id, variant_id, warehouse_id, type, quantity, reserved, balance, order_id, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		movement.GoodID,
+		movement.VariantID,
+		movement.WarehouseID,
+		movement.Type,
+		movement.Quantity,
+		movement.Reserved,
+		movement.Balance,
+		orderID,
+		movement.Reason,
+		movement.CreatedAt,
+	).Scan(&movement.ID)
+}
+
+// AdjustStock проводит движение movement.Quantity по остатку варианта movement.VariantID на складе
+// movement.WarehouseID. Возвращает ErrInsufficientStock, если остаток на складе стал бы отрицательным,
+// и sql.ErrNoRows, если варианта нет
+func (r *GoodsRepository) AdjustStock(ctx context.Context, movement *model.StockMovement) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT good_id FROM good_variants WHERE id = $1 FOR UPDATE",
+		movement.VariantID,
+	).Scan(&movement.GoodID)
+	if err != nil {
+		return err
+	}
+
+	movement.Balance, err = addWarehouseStock(ctx, tx, movement.WarehouseID, movement.VariantID, movement.Quantity)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE good_variants SET stock = stock + $1, updated_at = $2 WHERE id = $3", movement.Quantity, time.Now(), movement.VariantID)
+	if err != nil {
+		return err
+	}
+	if err := insertMovement(ctx, tx, movement); err != nil {
+		return err
+	}
+	if err := refreshGoodTotals(ctx, tx, movement.GoodID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// openReservation - незавершенное резервирование заказа
+type openReservation struct {
+	id          int64
+	goodID      int64
+	variantID   int64
+	warehouseID int64
+	quantity    int32
+}
+
+// openReservations блокирует и возвращает незавершенные резервирования заказа
+func openReservations(ctx context.Context, tx *sql.Tx, orderID int64) ([]openReservation, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		"SELECT id, good_id, variant_id, warehouse_id, quantity FROM stock_reservations WHERE order_id = $1 AND status = $2 ORDER BY variant_id, id FOR UPDATE",
+		orderID,
+		model.ReservationReserved,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []openReservation
+	for rows.Next() {
+		var reservation openReservation
+		if err := rows.Scan(&reservation.id, &reservation.goodID, &reservation.variantID, &reservation.warehouseID, &reservation.quantity); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, rows.Err()
+}
+
+// ReleaseStock снимает незавершенные резервирования заказа и возвращает количество в остаток.
+// Повторный вызов ничего не меняет
+func (r *GoodsRepository) ReleaseStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	return r.closeReservations(ctx, orderID, model.ReservationReleased)
+}
+
+// CommitStock отмечает незавершенные резервирования заказа проданными: резерв уходит со склада,
+// остаток не меняется. Повторный вызов ничего не меняет
+func (r *GoodsRepository) CommitStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	return r.closeReservations(ctx, orderID, model.ReservationSold)
+}
+
+// closeReservations переводит незавершенные резервирования заказа в status и записывает движения
+func (r *GoodsRepository) closeReservations(ctx context.Context, orderID int64, status string) ([]*model.StockMovement, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reservations, err := openReservations(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	movements := make([]*model.StockMovement, 0, len(reservations))
+	for _, reservation := range reservations {
+		movement := &model.StockMovement{
+			GoodID:      reservation.goodID,
+			VariantID:   reservation.variantID,
+			WarehouseID: reservation.warehouseID,
+			Reserved:    -reservation.quantity,
+			OrderID:     orderID,
+		}
+		if status == model.ReservationReleased {
+			movement.Type = model.MovementRelease
+			movement.Quantity = reservation.quantity
+		} else {
+			movement.Type = model.MovementSale
+		}
+
+		// Резерв возвращается на тот склад, с которого был взят
+		var err error
+		movement.Balance, err = addWarehouseStock(ctx, tx, movement.WarehouseID, movement.VariantID, movement.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if movement.Quantity != 0 {
+			_, err = tx.ExecContext(ctx, "UPDATE good_variants SET stock = stock + $1 WHERE id = $2", movement.Quantity, movement.VariantID)
+			if err != nil {
+				return nil, err
+			}
+			_, err = tx.ExecContext(ctx, "UPDATE goods SET stock = stock + $1 WHERE id = $2", movement.Quantity, movement.GoodID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE stock_reservations SET status = $1 WHERE id = $2", status, reservation.id); err != nil {
+			return nil, err
+		}
+		if err := insertMovement(ctx, tx, movement); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// ListStockMovements возвращает движения товара, новые первыми, и их общее количество.
+// variantID = 0 - движения всех вариантов
+func (r *GoodsRepository) ListStockMovements(ctx context.Context, goodID, variantID int64, limit, offset int32) ([]*model.StockMovement, int32, error) {
+	where := "WHERE good_id = $1 AND ($2 = 0 OR variant_id = $2)"
+
+	var total int32
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stock_movements "+where, goodID, variantID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+movementColumns+" FROM stock_movements "+where+" ORDER BY id DESC LIMIT $3 OFFSET $4",
+		goodID,
+		variantID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var movements []*model.StockMovement
+	for rows.Next() {
+		movement, err := scanMovement(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, movement)
+	}
+	return movements, total, rows.Err()
+}
+
+// StockDiscrepancies сверяет остатки и резервы вариантов на складах с журналом и возвращает расхождения
+func (r *GoodsRepository) StockDiscrepancies(ctx context.Context) ([]*model.StockDiscrepancy, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH ledger AS (
+			SELECT variant_id, warehouse_id, SUM(quantity) AS stock, SUM(reserved) AS reserved
+			FROM stock_movements
+			GROUP BY variant_id, warehouse_id
+		), reservations AS (
+			SELECT variant_id, warehouse_id, SUM(quantity) AS reserved
+			FROM stock_reservations
+			WHERE status = $1
+			GROUP BY variant_id, warehouse_id
+		), places AS (
+			SELECT variant_id, warehouse_id FROM warehouse_stock
+			UNION SELECT variant_id, warehouse_id FROM ledger
+			UNION SELECT variant_id, warehouse_id FROM reservations
+		)
+		SELECT
+			v.good_id, v.id, p.warehouse_id, v.sku, COALESCE(ws.stock, 0), COALESCE(ledger.stock, 0),
+			COALESCE(reservations.reserved, 0), COALESCE(ledger.reserved, 0)
+		FROM places p
+		JOIN good_variants v ON v.id = p.variant_id
+		LEFT JOIN warehouse_stock ws ON ws.variant_id = p.variant_id AND ws.warehouse_id = p.warehouse_id
+		LEFT JOIN ledger ON ledger.variant_id = p.variant_id AND ledger.warehouse_id = p.warehouse_id
+		LEFT JOIN reservations ON reservations.variant_id = p.variant_id AND reservations.warehouse_id = p.warehouse_id
+		WHERE COALESCE(ws.stock, 0) <> COALESCE(ledger.stock, 0)
+			OR COALESCE(reservations.reserved, 0) <> COALESCE(ledger.reserved, 0)
+		ORDER BY v.id, p.warehouse_id
+	`, model.ReservationReserved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var discrepancies []*model.StockDiscrepancy
+	for rows.Next() {
+		discrepancy := &model.StockDiscrepancy{}
+		if err := rows.Scan(
+			&discrepancy.GoodID,
+			&discrepancy.VariantID,
+			&discrepancy.WarehouseID,
+			&discrepancy.SKU,
+			&discrepancy.Stock,
+			&discrepancy.LedgerStock,
+			&discrepancy.Reserved,
+			&discrepancy.LedgerReserved,
+		); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, discrepancy)
+	}
+	return discrepancies, rows.Err()
+}
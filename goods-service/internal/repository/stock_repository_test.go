@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+var reservationColumns = []string{"id", "good_id", "variant_id", "warehouse_id", "quantity"}
+
+// expectMovement ожидает запись движения в журнал и возвращает ему id
+func expectMovement(mock sqlmock.Sqlmock, id int64, args ...driver.Value) {
+	mock.ExpectQuery(sqlPattern("INSERT INTO stock_movements (good_id, variant_id, warehouse_id, type, quantity, reserved, balance, order_id, reason, created_at)")).
+		WithArgs(append(args, sqlmock.AnyArg())...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+}
+
+func TestAdjustStock_ReceiptWritesLedger(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT good_id FROM good_variants WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"good_id"}).AddRow(3))
+	mock.ExpectQuery(sqlPattern("INSERT INTO warehouse_stock (warehouse_id, variant_id, stock) VALUES ($1, $2, $3)")).
+		WithArgs(int64(2), int64(4), int32(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(15))
+	mock.ExpectExec(sqlPattern("UPDATE good_variants SET stock = stock + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(int32(10), sqlmock.AnyArg(), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectMovement(mock, 9, int64(3), int64(4), int64(2), model.MovementReceipt, int32(10), int32(0), int32(15), nil, "поставка")
+	mock.ExpectExec(sqlPattern("SELECT id FROM goods WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlPattern("UPDATE goods SET")).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	movement := &model.StockMovement{VariantID: 4, WarehouseID: 2, Type: model.MovementReceipt, Quantity: 10, Reason: "поставка"}
+	require.NoError(t, repo.AdjustStock(context.Background(), movement))
+	assert.Equal(t, int64(9), movement.ID)
+	assert.Equal(t, int64(3), movement.GoodID)
+	assert.Equal(t, int32(15), movement.Balance)
+}
+
+func TestAdjustStock_InsufficientStock(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT good_id FROM good_variants WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"good_id"}).AddRow(3))
+	// Остаток склада не уходит в минус: строка не обновляется
+	mock.ExpectQuery(sqlPattern("UPDATE warehouse_stock SET stock = stock + $3 WHERE warehouse_id = $1 AND variant_id = $2 AND stock + $3 >= 0")).
+		WithArgs(int64(2), int64(4), int32(-50)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := repo.AdjustStock(context.Background(), &model.StockMovement{VariantID: 4, WarehouseID: 2, Type: model.MovementAdjustment, Quantity: -50})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+}
+
+func TestAdjustStock_UnknownVariant(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT good_id FROM good_variants WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(404)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := repo.AdjustStock(context.Background(), &model.StockMovement{VariantID: 404, WarehouseID: 2, Quantity: 1})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestReleaseStock_ReturnsReservationToWarehouse(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("FROM stock_reservations WHERE order_id = $1 AND status = $2 ORDER BY variant_id, id FOR UPDATE")).
+		WithArgs(int64(77), model.ReservationReserved).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).AddRow(1, 3, 4, 2, 2))
+	mock.ExpectQuery(sqlPattern("INSERT INTO warehouse_stock")).
+		WithArgs(int64(2), int64(4), int32(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(7))
+	mock.ExpectExec(sqlPattern("UPDATE good_variants SET stock = stock + $1 WHERE id = $2")).
+		WithArgs(int32(2), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlPattern("UPDATE goods SET stock = stock + $1 WHERE id = $2")).
+		WithArgs(int32(2), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlPattern("UPDATE stock_reservations SET status = $1 WHERE id = $2")).
+		WithArgs(model.ReservationReleased, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectMovement(mock, 10, int64(3), int64(4), int64(2), model.MovementRelease, int32(2), int32(-2), int32(7), int64(77), "")
+	mock.ExpectCommit()
+
+	movements, err := repo.ReleaseStock(context.Background(), 77)
+	require.NoError(t, err)
+	require.Len(t, movements, 1)
+	assert.Equal(t, model.MovementRelease, movements[0].Type)
+	assert.Equal(t, int32(7), movements[0].Balance)
+}
+
+func TestCommitStock_KeepsStockAndClosesReservation(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("FROM stock_reservations WHERE order_id = $1 AND status = $2")).
+		WithArgs(int64(77), model.ReservationReserved).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).AddRow(1, 3, 4, 2, 2))
+	// Продажа не меняет остаток: склад только возвращает текущий баланс, остатки вариантов не трогаются
+	mock.ExpectQuery(sqlPattern("INSERT INTO warehouse_stock")).
+		WithArgs(int64(2), int64(4), int32(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(5))
+	mock.ExpectExec(sqlPattern("UPDATE stock_reservations SET status = $1 WHERE id = $2")).
+		WithArgs(model.ReservationSold, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectMovement(mock, 11, int64(3), int64(4), int64(2), model.MovementSale, int32(0), int32(-2), int32(5), int64(77), "")
+	mock.ExpectCommit()
+
+	movements, err := repo.CommitStock(context.Background(), 77)
+	require.NoError(t, err)
+	require.Len(t, movements, 1)
+	assert.Equal(t, model.MovementSale, movements[0].Type)
+}
+
+func TestReleaseStock_RepeatedCallIsNoop(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("FROM stock_reservations WHERE order_id = $1 AND status = $2")).
+		WithArgs(int64(77), model.ReservationReserved).
+		WillReturnRows(sqlmock.NewRows(reservationColumns))
+	mock.ExpectCommit()
+
+	movements, err := repo.ReleaseStock(context.Background(), 77)
+	require.NoError(t, err)
+	assert.Empty(t, movements)
+}
+
+func TestListStockMovements(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	now := time.Now()
+
+	mock.ExpectQuery(sqlPattern("SELECT COUNT(*) FROM stock_movements WHERE good_id = $1 AND ($2 = 0 OR variant_id = $2)")).
+		WithArgs(int64(3), int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(sqlPattern("FROM stock_movements WHERE good_id = $1 AND ($2 = 0 OR variant_id = $2) ORDER BY id DESC LIMIT $3 OFFSET $4")).
+		WithArgs(int64(3), int64(0), int32(1), int32(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "good_id", "variant_id", "warehouse_id", "type", "quantity", "reserved", "balance", "order_id", "reason", "created_at"}).
+			AddRow(9, 3, 4, 2, model.MovementReceipt, 10, 0, 15, 0, "поставка", now))
+
+	movements, total, err := repo.ListStockMovements(context.Background(), 3, 0, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), total)
+	require.Len(t, movements, 1)
+	assert.Equal(t, "поставка", movements[0].Reason)
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+var (
+	// ErrSKUTaken - артикул уже занят другим вариантом
+	ErrSKUTaken = errors.New("sku already exists")
+	// ErrVariantReserved - у варианта есть незавершенные резервирования заказов
+	ErrVariantReserved = errors.New("variant has active reservations")
+)
+
+const variantColumns = "id, good_id, sku, price, stock, attributes, created_at, updated_at"
+
+// scanner - общая часть *sql.Row и *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanVariant(row scanner) (*model.Variant, error) {
+	variant := &model.Variant{}
+	var attributes []byte
+	if err := row.Scan(
+		&variant.ID,
+		&variant.GoodID,
+		&variant.SKU,
+		&variant.Price,
+		&variant.Stock,
+		&attributes,
+		&variant.CreatedAt,
+		&variant.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(attributes, &variant.Attributes); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// attributesJSON сериализует атрибуты; nil сохраняется как пустой объект
+func attributesJSON(attributes map[string]string) ([]byte, error) {
+	if attributes == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(attributes)
+}
+
+// GetVariant возвращает вариант с остатками по складам или nil, если его нет или он в архиве
+func (r *GoodsRepository) GetVariant(ctx context.Context, id int64) (*model.Variant, error) {
+	variant, err := scanVariant(r.db.QueryRowContext(ctx, "SELECT "+variantColumns+" FROM good_variants WHERE id = $1 AND archived_at IS NULL", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadWarehouseStock(ctx, []*model.Variant{variant}); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// GetVariantBySKU находит вариант по артикулу; nil, если такого нет или он в архиве
+func (r *GoodsRepository) GetVariantBySKU(ctx context.Context, sku string) (*model.Variant, error) {
+	variant, err := scanVariant(r.db.QueryRowContext(ctx, "SELECT "+variantColumns+" FROM good_variants WHERE sku = $1 AND archived_at IS NULL", sku))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadWarehouseStock(ctx, []*model.Variant{variant}); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// CreateVariant добавляет вариант товару variant.GoodID
+func (r *GoodsRepository) CreateVariant(ctx context.Context, variant *model.Variant) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertVariant(ctx, tx, variant); err != nil {
+		return err
+	}
+	if err := insertReceipt(ctx, tx, variant); err != nil {
+		return err
+	}
+	if err := refreshGoodTotals(ctx, tx, variant.GoodID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertVariant(ctx context.Context, tx *sql.Tx, variant *model.Variant) error {
+	attributes, err := attributesJSON(variant.Attributes)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO good_variants (good_id, sku, price, stock, attributes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		variant.GoodID,
+		variant.SKU,
+		variant.Price,
+		variant.Stock,
+		attributes,
+		now,
+		now,
+	).Scan(&variant.ID)
+	if isUniqueViolation(err) {
+		return ErrSKUTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+	return nil
+}
+
+// insertReceipt оприходует начальный остаток нового варианта на склад по умолчанию
+func insertReceipt(ctx context.Context, tx *sql.Tx, variant *model.Variant) error {
+	if variant.Stock == 0 {
+		return nil
+	}
+	warehouseID, err := defaultWarehouse(ctx, tx)
+	if err != nil {
+		return err
+	}
+	balance, err := addWarehouseStock(ctx, tx, warehouseID, variant.ID, variant.Stock)
+	if err != nil {
+		return err
+	}
+	return insertMovement(ctx, tx, &model.StockMovement{
+		GoodID:      variant.GoodID,
+		VariantID:   variant.ID,
+		WarehouseID: warehouseID,
+		Type:        model.MovementReceipt,
+		Quantity:    variant.Stock,
+		Balance:     balance,
+		Reason:      "initial stock",
+	})
+}
+
+// UpdateVariant заменяет артикул, цену и атрибуты варианта и меняет его остаток на stockDelta.
+// Изменение проводится под блокировкой строки по складу по умолчанию, поэтому не затирает
+// резервы, сделанные после чтения варианта; если остатка склада не хватает, возвращает
+// ErrInsufficientStock. variant.Stock получает итоговый остаток
+func (r *GoodsRepository) UpdateVariant(ctx context.Context, variant *model.Variant, stockDelta int32) error {
+	attributes, err := attributesJSON(variant.Attributes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stock int32
+	err = tx.QueryRowContext(ctx, "SELECT stock FROM good_variants WHERE id = $1 AND archived_at IS NULL FOR UPDATE", variant.ID).Scan(&stock)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	variant.Stock = stock + stockDelta
+	variant.UpdatedAt = time.Now()
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE good_variants SET sku = $1, price = $2, stock = $3, attributes = $4, updated_at = $5 WHERE id = $6",
+		variant.SKU,
+		variant.Price,
+		variant.Stock,
+		attributes,
+		variant.UpdatedAt,
+		variant.ID,
+	)
+	if isUniqueViolation(err) {
+		return ErrSKUTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	if stockDelta != 0 {
+		warehouseID, err := defaultWarehouse(ctx, tx)
+		if err != nil {
+			return err
+		}
+		balance, err := addWarehouseStock(ctx, tx, warehouseID, variant.ID, stockDelta)
+		if err != nil {
+			return err
+		}
+		err = insertMovement(ctx, tx, &model.StockMovement{
+			GoodID:      variant.GoodID,
+			VariantID:   variant.ID,
+			WarehouseID: warehouseID,
+			Type:        model.MovementAdjustment,
+			Quantity:    stockDelta,
+			Balance:     balance,
+			Reason:      stockUpdateReason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := refreshGoodTotals(ctx, tx, variant.GoodID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteVariant убирает вариант в архив: строка остается для журнала движений и истории заказов.
+// Вариант с незавершенными резервированиями не архивируется - возвращает ErrVariantReserved.
+// Как и в ArchiveGood, резервирование блокирует строку варианта, поэтому проверка видит все резервы
+func (r *GoodsRepository) DeleteVariant(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var goodID int64
+	err = tx.QueryRowContext(ctx, "SELECT good_id FROM good_variants WHERE id = $1 AND archived_at IS NULL FOR UPDATE", id).Scan(&goodID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var reserved bool
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM stock_reservations WHERE variant_id = $1 AND status = $2)",
+		id,
+		model.ReservationReserved,
+	).Scan(&reserved)
+	if err != nil {
+		return err
+	}
+	if reserved {
+		return ErrVariantReserved
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE good_variants SET archived_at = $1, updated_at = $1 WHERE id = $2", time.Now(), id); err != nil {
+		return err
+	}
+	if err := refreshGoodTotals(ctx, tx, goodID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// refreshGoodTotals пересчитывает цену и остаток товара по вариантам. Строка товара блокируется
+// отдельным запросом, чтобы пересчет видел резервирования, завершившиеся во время ожидания блокировки
+func refreshGoodTotals(ctx context.Context, tx *sql.Tx, goodID int64) error {
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM goods WHERE id = $1 FOR UPDATE", goodID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE goods SET
+			price = COALESCE((SELECT MIN(price) FROM good_variants WHERE good_id = $1 AND archived_at IS NULL), price),
+			stock = COALESCE((SELECT SUM(stock) FROM good_variants WHERE good_id = $1 AND archived_at IS NULL), 0)
+		WHERE id = $1
+	`, goodID)
+	return err
+}
+
+// loadVariants заполняет Variants товаров одним запросом; архивные варианты пропускаются
+func (r *GoodsRepository) loadVariants(ctx context.Context, goods []*model.Good) error {
+	if len(goods) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Good, len(goods))
+	ids := make([]int64, len(goods))
+	for i, good := range goods {
+		byID[good.ID] = good
+		ids[i] = good.ID
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+variantColumns+" FROM good_variants WHERE good_id = ANY($1) AND archived_at IS NULL ORDER BY good_id, id",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		variant, err := scanVariant(rows)
+		if err != nil {
+			return err
+		}
+		good := byID[variant.GoodID]
+		good.Variants = append(good.Variants, variant)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+func TestDeleteVariant_ArchivesVariant(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT good_id FROM good_variants WHERE id = $1 AND archived_at IS NULL FOR UPDATE")).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"good_id"}).AddRow(3))
+	mock.ExpectQuery(sqlPattern("SELECT EXISTS (SELECT 1 FROM stock_reservations WHERE variant_id = $1 AND status = $2)")).
+		WithArgs(int64(4), model.ReservationReserved).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(sqlPattern("UPDATE good_variants SET archived_at = $1, updated_at = $1 WHERE id = $2")).
+		WithArgs(sqlmock.AnyArg(), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlPattern("SELECT id FROM goods WHERE id = $1 FOR UPDATE")).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlPattern("UPDATE goods SET")).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.DeleteVariant(context.Background(), 4))
+}
+
+func TestDeleteVariant_Reserved(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("SELECT good_id FROM good_variants WHERE id = $1 AND archived_at IS NULL FOR UPDATE")).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"good_id"}).AddRow(3))
+	mock.ExpectQuery(sqlPattern("SELECT EXISTS (SELECT 1 FROM stock_reservations WHERE variant_id = $1 AND status = $2)")).
+		WithArgs(int64(4), model.ReservationReserved).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.DeleteVariant(context.Background(), 4), ErrVariantReserved)
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// ErrWarehouseCodeTaken - код склада уже занят
+var ErrWarehouseCodeTaken = errors.New("warehouse code already exists")
+
+const warehouseColumns = "id, code, name, address, regions, priority, created_at, updated_at"
+
+func scanWarehouse(row scanner) (*model.Warehouse, error) {
+	warehouse := &model.Warehouse{}
+	var regions pq.StringArray
+	if err := row.Scan(
+		&warehouse.ID,
+		&warehouse.Code,
+		&warehouse.Name,
+		&warehouse.Address,
+		&regions,
+		&warehouse.Priority,
+		&warehouse.CreatedAt,
+		&warehouse.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	warehouse.Regions = regions
+	return warehouse, nil
+}
+
+// CreateWarehouse добавляет склад; возвращает ErrWarehouseCodeTaken, если код занят
+func (r *GoodsRepository) CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	now := time.Now()
+	err := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO warehouses (code, name, address, regions, priority, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		warehouse.Code,
+		warehouse.Name,
+		warehouse.Address,
+		pq.Array(warehouse.Regions),
+		warehouse.Priority,
+		now,
+		now,
+	).Scan(&warehouse.ID)
+	if isUniqueViolation(err) {
+		return ErrWarehouseCodeTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+	return nil
+}
+
+// GetWarehouse возвращает склад или nil, если его нет
+func (r *GoodsRepository) GetWarehouse(ctx context.Context, id int64) (*model.Warehouse, error) {
+	warehouse, err := scanWarehouse(r.db.QueryRowContext(ctx, "SELECT "+warehouseColumns+" FROM warehouses WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return warehouse, err
+}
+
+// UpdateWarehouse меняет название, адрес, регионы и приоритет склада; код не меняется
+func (r *GoodsRepository) UpdateWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	warehouse.UpdatedAt = time.Now()
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE warehouses SET name = $1, address = $2, regions = $3, priority = $4, updated_at = $5 WHERE id = $6",
+		warehouse.Name,
+		warehouse.Address,
+		pq.Array(warehouse.Regions),
+		warehouse.Priority,
+		warehouse.UpdatedAt,
+		warehouse.ID,
+	)
+	return err
+}
+
+// ListWarehouses возвращает склады в порядке приоритета
+func (r *GoodsRepository) ListWarehouses(ctx context.Context) ([]*model.Warehouse, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+warehouseColumns+" FROM warehouses ORDER BY priority, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warehouses []*model.Warehouse
+	for rows.Next() {
+		warehouse, err := scanWarehouse(rows)
+		if err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+	return warehouses, rows.Err()
+}
+
+// defaultWarehouse возвращает склад с наименьшим приоритетом: на него поступает остаток,
+// заданный при создании товара или варианта и через UpdateVariant
+func defaultWarehouse(ctx context.Context, tx *sql.Tx) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx, "SELECT id FROM warehouses ORDER BY priority, id LIMIT 1").Scan(&id)
+	return id, err
+}
+
+// addWarehouseStock меняет остаток варианта на складе на delta и возвращает новый остаток.
+// Возвращает ErrInsufficientStock, если остаток стал бы отрицательным
+func addWarehouseStock(ctx context.Context, tx *sql.Tx, warehouseID, variantID int64, delta int32) (int32, error) {
+	var balance int32
+	var err error
+	if delta < 0 {
+		err = tx.QueryRowContext(
+			ctx,
+			"UPDATE warehouse_stock SET stock = stock + $3 WHERE warehouse_id = $1 AND variant_id = $2 AND stock + $3 >= 0 RETURNING stock",
+			warehouseID,
+			variantID,
+			delta,
+		).Scan(&balance)
+		if err == sql.ErrNoRows {
+			return 0, ErrInsufficientStock
+		}
+		return balance, err
+	}
+
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO warehouse_stock (warehouse_id, variant_id, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (warehouse_id, variant_id) DO UPDATE SET stock = warehouse_stock.stock + EXCLUDED.stock
+		RETURNING stock`,
+		warehouseID,
+		variantID,
+		delta,
+	).Scan(&balance)
+	return balance, err
+}
+
+// ReserveAllocations резервирует позиции заказа на выбранных складах одной транзакцией: списывает
+// остатки складов и вариантов и записывает движения reservation. Если на каком-то складе остатка
+// уже не хватает, возвращает ErrInsufficientStock, если товар в архиве - ErrGoodArchived; в обоих
+// случаях ничего не резервирует
+func (r *GoodsRepository) ReserveAllocations(ctx context.Context, orderID int64, allocations []*model.Allocation) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Строки блокируются в одном порядке, чтобы параллельные заказы не ждали друг друга по кругу
+	sorted := slices.Clone(allocations)
+	slices.SortFunc(sorted, func(a, b *model.Allocation) int {
+		return cmp.Or(cmp.Compare(a.VariantID, b.VariantID), cmp.Compare(a.WarehouseID, b.WarehouseID))
+	})
+
+	now := time.Now()
+	for _, allocation := range sorted {
+		balance, err := addWarehouseStock(ctx, tx, allocation.WarehouseID, allocation.VariantID, -allocation.Quantity)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(
+			ctx,
+			"UPDATE good_variants SET stock = stock - $1 WHERE id = $2 RETURNING good_id",
+			allocation.Quantity,
+			allocation.VariantID,
+		).Scan(&allocation.GoodID)
+		if err != nil {
+			return err
+		}
+		// Архивный товар не резервируется; блокировка строки товара согласована с ArchiveGood
+		result, err := tx.ExecContext(ctx, "UPDATE goods SET stock = stock - $1 WHERE id = $2 AND archived_at IS NULL", allocation.Quantity, allocation.GoodID)
+		if err != nil {
+			return err
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return ErrGoodArchived
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO stock_reservations (good_id, variant_id, warehouse_id, order_id, quantity, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			allocation.GoodID,
+			allocation.VariantID,
+			allocation.WarehouseID,
+			orderID,
+			allocation.Quantity,
+			model.ReservationReserved,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = insertMovement(ctx, tx, &model.StockMovement{
+			GoodID:      allocation.GoodID,
+			VariantID:   allocation.VariantID,
+			WarehouseID: allocation.WarehouseID,
+			Type:        model.MovementReservation,
+			Quantity:    -allocation.Quantity,
+			Reserved:    allocation.Quantity,
+			Balance:     balance,
+			OrderID:     orderID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// loadWarehouseStock заполняет Warehouses вариантов одним запросом
+func (r *GoodsRepository) loadWarehouseStock(ctx context.Context, variants []*model.Variant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Variant, len(variants))
+	ids := make([]int64, len(variants))
+	for i, variant := range variants {
+		byID[variant.ID] = variant
+		ids[i] = variant.ID
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT ws.variant_id, ws.warehouse_id, w.code, ws.stock
+		FROM warehouse_stock ws
+		JOIN warehouses w ON w.id = ws.warehouse_id
+		WHERE ws.variant_id = ANY($1)
+		ORDER BY ws.variant_id, w.priority, w.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var variantID int64
+		level := &model.WarehouseStock{}
+		if err := rows.Scan(&variantID, &level.WarehouseID, &level.WarehouseCode, &level.Stock); err != nil {
+			return err
+		}
+		variant := byID[variantID]
+		variant.Warehouses = append(variant.Warehouses, level)
+	}
+	return rows.Err()
+}
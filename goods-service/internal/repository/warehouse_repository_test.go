@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+func TestWarehouses_CreateCodeTaken(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectQuery(sqlPattern("INSERT INTO warehouses (code, name, address, regions, priority, created_at, updated_at)")).
+		WithArgs("spb", "Санкт-Петербург", "", pq.Array([]string{"spb"}), int32(2), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	err := repo.CreateWarehouse(context.Background(), &model.Warehouse{Code: "spb", Name: "Санкт-Петербург", Regions: []string{"spb"}, Priority: 2})
+	assert.ErrorIs(t, err, ErrWarehouseCodeTaken)
+}
+
+func TestWarehouses_GetMissing(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectQuery(sqlPattern("FROM warehouses WHERE id = $1")).
+		WithArgs(int64(404)).
+		WillReturnError(sql.ErrNoRows)
+
+	warehouse, err := repo.GetWarehouse(context.Background(), 404)
+	require.NoError(t, err)
+	assert.Nil(t, warehouse)
+}
+
+// expectAllocation ожидает резерв одной позиции: склад, вариант, товар, резервирование и движение
+func expectAllocation(mock sqlmock.Sqlmock, orderID, goodID, variantID, warehouseID int64, quantity, balance int32) {
+	mock.ExpectQuery(sqlPattern("UPDATE warehouse_stock SET stock = stock + $3 WHERE warehouse_id = $1 AND variant_id = $2 AND stock + $3 >= 0 RETURNING stock")).
+		WithArgs(warehouseID, variantID, -quantity).
+		WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(balance))
+	mock.ExpectQuery(sqlPattern("UPDATE good_variants SET stock = stock - $1 WHERE id = $2 RETURNING good_id")).
+		WithArgs(quantity, variantID).
+		WillReturnRows(sqlmock.NewRows([]string{"good_id"}).AddRow(goodID))
+	mock.ExpectExec(sqlPattern("UPDATE goods SET stock = stock - $1 WHERE id = $2 AND archived_at IS NULL")).
+		WithArgs(quantity, goodID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sqlPattern("INSERT INTO stock_reservations (good_id, variant_id, warehouse_id, order_id, quantity, status, created_at)")).
+		WithArgs(goodID, variantID, warehouseID, orderID, quantity, model.ReservationReserved, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectMovement(mock, 1, goodID, variantID, warehouseID, model.MovementReservation, -quantity, quantity, balance, orderID, "")
+}
+
+func TestReserveAllocations_LocksInVariantWarehouseOrder(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	// Позиции приходят в порядке заказа, а блокируются по (variant_id, warehouse_id)
+	mock.ExpectBegin()
+	expectAllocation(mock, 77, 3, 4, 1, 1, 0)
+	expectAllocation(mock, 77, 3, 4, 2, 2, 5)
+	mock.ExpectCommit()
+
+	err := repo.ReserveAllocations(context.Background(), 77, []*model.Allocation{
+		{VariantID: 4, WarehouseID: 2, Quantity: 2},
+		{VariantID: 4, WarehouseID: 1, Quantity: 1},
+	})
+	require.NoError(t, err)
+}
+
+func TestReserveAllocations_WarehouseRunsOut(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	expectAllocation(mock, 77, 3, 4, 1, 1, 0)
+	mock.ExpectQuery(sqlPattern("UPDATE warehouse_stock SET stock = stock + $3")).
+		WithArgs(int64(2), int64(4), int32(-2)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := repo.ReserveAllocations(context.Background(), 77, []*model.Allocation{
+		{VariantID: 4, WarehouseID: 1, Quantity: 1},
+		{VariantID: 4, WarehouseID: 2, Quantity: 2},
+	})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+}
+
+func TestReserveAllocations_ArchivedGood(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(sqlPattern("UPDATE warehouse_stock SET stock = stock + $3")).
+		WithArgs(int64(1), int64(4), int32(-1)).
+		WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(3))
+	mock.ExpectQuery(sqlPattern("UPDATE good_variants SET stock = stock - $1 WHERE id = $2 RETURNING good_id")).
+		WithArgs(int32(1), int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"good_id"}).AddRow(3))
+	mock.ExpectExec(sqlPattern("UPDATE goods SET stock = stock - $1 WHERE id = $2 AND archived_at IS NULL")).
+		WithArgs(int32(1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.ReserveAllocations(context.Background(), 77, []*model.Allocation{{VariantID: 4, WarehouseID: 1, Quantity: 1}})
+	assert.ErrorIs(t, err, ErrGoodArchived)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// attributeCodePattern - код атрибута: brew_temp, harvest_year
+var attributeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// validateAttributeSchema проверяет схему атрибутов категории: уникальные коды, известные типы,
+// допустимые значения только у enum, единица измерения только у чисел
+func validateAttributeSchema(definitions []model.AttributeDefinition) error {
+	seen := make(map[string]bool, len(definitions))
+	for _, definition := range definitions {
+		if !attributeCodePattern.MatchString(definition.Code) {
+			return fmt.Errorf("%w: code %q must contain only lowercase latin letters, digits and underscores", ErrInvalidAttributeSchema, definition.Code)
+		}
+		if seen[definition.Code] {
+			return fmt.Errorf("%w: duplicate code %s", ErrInvalidAttributeSchema, definition.Code)
+		}
+		seen[definition.Code] = true
+
+		if strings.TrimSpace(definition.Name) == "" {
+			return fmt.Errorf("%w: %s has no name", ErrInvalidAttributeSchema, definition.Code)
+		}
+		switch definition.Type {
+		case model.AttributeEnum:
+			if len(definition.AllowedValues) == 0 {
+				return fmt.Errorf("%w: enum %s has no allowed values", ErrInvalidAttributeSchema, definition.Code)
+			}
+		case model.AttributeString, model.AttributeNumber, model.AttributeBoolean:
+			if len(definition.AllowedValues) > 0 {
+				return fmt.Errorf("%w: allowed values are only for enum, %s is %s", ErrInvalidAttributeSchema, definition.Code, definition.Type)
+			}
+		default:
+			return fmt.Errorf("%w: %s has unknown type %q", ErrInvalidAttributeSchema, definition.Code, definition.Type)
+		}
+		if definition.Unit != "" && definition.Type != model.AttributeNumber {
+			return fmt.Errorf("%w: unit is only for number, %s is %s", ErrInvalidAttributeSchema, definition.Code, definition.Type)
+		}
+	}
+	return nil
+}
+
+// goodAttributeSchema собирает атрибуты категорий товара и их родителей. Если атрибут описан
+// на нескольких уровнях, действует описание ближайшей к товару категории, а среди нескольких
+// категорий - первой в списке
+func goodAttributeSchema(categories []*model.Category, categoryIDs []int64) (map[string]model.AttributeDefinition, error) {
+	byID := make(map[int64]*model.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	schema := make(map[string]model.AttributeDefinition)
+	for _, id := range categoryIDs {
+		if byID[id] == nil {
+			return nil, ErrCategoryNotFound
+		}
+		for category := byID[id]; category != nil; category = byID[category.ParentID] {
+			for _, definition := range category.Attributes {
+				if _, ok := schema[definition.Code]; !ok {
+					schema[definition.Code] = definition
+				}
+			}
+		}
+	}
+	return schema, nil
+}
+
+// validateAttributes проверяет значения атрибутов по схеме и возвращает их в каноническом виде.
+// Атрибуты вне схемы и отсутствие обязательных - ошибка
+func validateAttributes(schema map[string]model.AttributeDefinition, values map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(values))
+	for _, code := range slices.Sorted(maps.Keys(values)) {
+		definition, ok := schema[code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not defined for the good's categories", ErrInvalidAttribute, code)
+		}
+		value, ok := normalizeAttributeValue(definition, values[code])
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not a valid %s value for %s", ErrInvalidAttribute, values[code], definition.Type, code)
+		}
+		normalized[code] = value
+	}
+
+	for _, code := range slices.Sorted(maps.Keys(schema)) {
+		if _, ok := normalized[code]; !ok && schema[code].Required {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidAttribute, code)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeAttributeValue приводит значение к каноническому виду: число без лишних нулей,
+// true/false для boolean. Канонический вид позволяет фильтровать по точному совпадению
+func normalizeAttributeValue(definition model.AttributeDefinition, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	switch definition.Type {
+	case model.AttributeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), true
+	case model.AttributeBoolean:
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatBool(flag), true
+	case model.AttributeEnum:
+		return value, slices.Contains(definition.AllowedValues, value)
+	default:
+		return value, value != ""
+	}
+}
+
+// normalizeAttributeFilters проверяет фильтры по атрибутам и приводит их значения к каноническому виду.
+// Атрибут ищется среди схем всех категорий: фильтр без категории ищет по всему каталогу
+func normalizeAttributeFilters(categories []*model.Category, filters []model.AttributeFilter) error {
+	definitions := make(map[string]model.AttributeDefinition)
+	for _, category := range categories {
+		for _, definition := range category.Attributes {
+			if _, ok := definitions[definition.Code]; !ok {
+				definitions[definition.Code] = definition
+			}
+		}
+	}
+
+	for i := range filters {
+		filter := &filters[i]
+		definition, ok := definitions[filter.Code]
+		if !ok {
+			return fmt.Errorf("%w: unknown attribute %s", ErrInvalidAttributeFilter, filter.Code)
+		}
+		if len(filter.Values) == 0 && filter.Min == nil && filter.Max == nil {
+			return fmt.Errorf("%w: %s has neither values nor range", ErrInvalidAttributeFilter, filter.Code)
+		}
+		if filter.Min != nil || filter.Max != nil {
+			if definition.Type != model.AttributeNumber {
+				return fmt.Errorf("%w: range is only for number attributes, %s is %s", ErrInvalidAttributeFilter, filter.Code, definition.Type)
+			}
+			if filter.Min != nil && filter.Max != nil && *filter.Min > *filter.Max {
+				return fmt.Errorf("%w: %s min is greater than max", ErrInvalidAttributeFilter, filter.Code)
+			}
+		}
+		for j, value := range filter.Values {
+			canonical, ok := normalizeAttributeValue(definition, value)
+			if !ok {
+				return fmt.Errorf("%w: %q is not a valid %s value for %s", ErrInvalidAttributeFilter, value, definition.Type, filter.Code)
+			}
+			filter.Values[j] = canonical
+		}
+	}
+	return nil
+}
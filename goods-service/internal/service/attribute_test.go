@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTeaSchema создает чай с общими атрибутами и улуны с собственной степенью окисления
+func createTeaSchema(t *testing.T, s *GoodsService) (tea, oolong *model.Category) {
+	t.Helper()
+	ctx := context.Background()
+
+	tea, err := s.CreateCategory(ctx, &model.CreateCategoryRequest{
+		Slug: "tea",
+		Name: "Чай",
+		Attributes: []model.AttributeDefinition{
+			{Code: "brew_temp", Name: "Температура заваривания", Type: model.AttributeNumber, Unit: "°C", Required: true},
+			{Code: "origin", Name: "Происхождение", Type: model.AttributeString},
+			{Code: "caffeine", Name: "Кофеин", Type: model.AttributeEnum, AllowedValues: []string{"low", "medium", "high"}},
+		},
+	})
+	require.NoError(t, err)
+
+	oolong, err = s.CreateCategory(ctx, &model.CreateCategoryRequest{
+		ParentID: tea.ID,
+		Slug:     "oolong",
+		Name:     "Улун",
+		Attributes: []model.AttributeDefinition{
+			{Code: "oxidation", Name: "Окисление", Type: model.AttributeNumber, Unit: "%"},
+			{Code: "roasted", Name: "Прожарка", Type: model.AttributeBoolean},
+		},
+	})
+	require.NoError(t, err)
+	return tea, oolong
+}
+
+func TestCreateCategory_InvalidAttributeSchema(t *testing.T) {
+	s := New(repository.NewMemory())
+
+	tests := []struct {
+		name       string
+		definition model.AttributeDefinition
+	}{
+		{"bad code", model.AttributeDefinition{Code: "Brew-Temp", Name: "Температура", Type: model.AttributeNumber}},
+		{"no name", model.AttributeDefinition{Code: "origin", Type: model.AttributeString}},
+		{"unknown type", model.AttributeDefinition{Code: "origin", Name: "Происхождение", Type: "date"}},
+		{"enum without values", model.AttributeDefinition{Code: "caffeine", Name: "Кофеин", Type: model.AttributeEnum}},
+		{"values for string", model.AttributeDefinition{Code: "origin", Name: "Происхождение", Type: model.AttributeString, AllowedValues: []string{"Китай"}}},
+		{"unit for boolean", model.AttributeDefinition{Code: "roasted", Name: "Прожарка", Type: model.AttributeBoolean, Unit: "°C"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateCategory(context.Background(), &model.CreateCategoryRequest{
+				Slug:       "tea",
+				Name:       "Чай",
+				Attributes: []model.AttributeDefinition{tt.definition},
+			})
+			assert.ErrorIs(t, err, ErrInvalidAttributeSchema)
+		})
+	}
+
+	_, err := s.CreateCategory(context.Background(), &model.CreateCategoryRequest{
+		Slug: "tea",
+		Name: "Чай",
+		Attributes: []model.AttributeDefinition{
+			{Code: "origin", Name: "Происхождение", Type: model.AttributeString},
+			{Code: "origin", Name: "Регион", Type: model.AttributeString},
+		},
+	})
+	assert.ErrorIs(t, err, ErrInvalidAttributeSchema)
+}
+
+func TestCreateGood_Attributes(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	_, oolong := createTeaSchema(t, s)
+
+	// Атрибуты родительской категории наследуются, значения приводятся к каноническому виду
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{
+		Name:        "Те Гуань Инь",
+		Price:       900,
+		Stock:       3,
+		CategoryIDs: []int64{oolong.ID},
+		Attributes:  map[string]string{"brew_temp": " 90.0 ", "oxidation": "30", "roasted": "1", "caffeine": "medium"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{oolong.ID}, good.CategoryIDs)
+	assert.Equal(t, map[string]string{"brew_temp": "90", "oxidation": "30", "roasted": "true", "caffeine": "medium"}, good.Attributes)
+
+	stored, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, good.Attributes, stored.Attributes)
+	assert.Equal(t, []int64{oolong.ID}, stored.CategoryIDs)
+
+	tests := []struct {
+		name       string
+		categories []int64
+		attributes map[string]string
+		err        error
+	}{
+		{"required missing", []int64{oolong.ID}, map[string]string{"oxidation": "30"}, ErrInvalidAttribute},
+		{"not a number", []int64{oolong.ID}, map[string]string{"brew_temp": "горячо"}, ErrInvalidAttribute},
+		{"not allowed", []int64{oolong.ID}, map[string]string{"brew_temp": "90", "caffeine": "none"}, ErrInvalidAttribute},
+		{"not a boolean", []int64{oolong.ID}, map[string]string{"brew_temp": "90", "roasted": "слегка"}, ErrInvalidAttribute},
+		{"empty string", []int64{oolong.ID}, map[string]string{"brew_temp": "90", "origin": " "}, ErrInvalidAttribute},
+		{"unknown attribute", []int64{oolong.ID}, map[string]string{"brew_temp": "90", "color": "green"}, ErrInvalidAttribute},
+		{"no categories", nil, map[string]string{"brew_temp": "90"}, ErrInvalidAttribute},
+		{"unknown category", []int64{42}, nil, ErrCategoryNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateGood(ctx, &model.CreateGoodRequest{
+				Name:        "Да Хун Пао",
+				Price:       2500,
+				Stock:       1,
+				CategoryIDs: tt.categories,
+				Attributes:  tt.attributes,
+			})
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestUpdateGood_Attributes(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	tea, _ := createTeaSchema(t, s)
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{
+		Name:        "Сенча",
+		Price:       700,
+		Stock:       5,
+		CategoryIDs: []int64{tea.ID},
+		Attributes:  map[string]string{"brew_temp": "80", "origin": "Япония"},
+	})
+	require.NoError(t, err)
+
+	// Без атрибутов в запросе атрибуты не меняются
+	updated, err := s.UpdateGood(ctx, good.ID, &model.UpdateGoodRequest{Name: "Сенча Асамуси"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"brew_temp": "80", "origin": "Япония"}, updated.Attributes)
+
+	updated, err = s.UpdateGood(ctx, good.ID, &model.UpdateGoodRequest{Attributes: map[string]string{"brew_temp": "75"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"brew_temp": "75"}, updated.Attributes)
+
+	// Атрибут улунов не относится к категории товара
+	_, err = s.UpdateGood(ctx, good.ID, &model.UpdateGoodRequest{Attributes: map[string]string{"brew_temp": "75", "oxidation": "10"}})
+	assert.ErrorIs(t, err, ErrInvalidAttribute)
+
+	stored, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"brew_temp": "75"}, stored.Attributes)
+}
+
+func TestListGoods_AttributeFilters(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	tea, oolong := createTeaSchema(t, s)
+
+	sencha, err := s.CreateGood(ctx, &model.CreateGoodRequest{
+		Name:        "Сенча",
+		Price:       700,
+		Stock:       5,
+		CategoryIDs: []int64{tea.ID},
+		Attributes:  map[string]string{"brew_temp": "80", "caffeine": "high"},
+	})
+	require.NoError(t, err)
+	tieguanyin, err := s.CreateGood(ctx, &model.CreateGoodRequest{
+		Name:        "Те Гуань Инь",
+		Price:       900,
+		Stock:       3,
+		CategoryIDs: []int64{oolong.ID},
+		Attributes:  map[string]string{"brew_temp": "90", "oxidation": "30", "roasted": "false", "caffeine": "medium"},
+	})
+	require.NoError(t, err)
+	dahongpao, err := s.CreateGood(ctx, &model.CreateGoodRequest{
+		Name:        "Да Хун Пао",
+		Price:       2500,
+		Stock:       1,
+		CategoryIDs: []int64{oolong.ID},
+		Attributes:  map[string]string{"brew_temp": "95", "oxidation": "70", "roasted": "true"},
+	})
+	require.NoError(t, err)
+
+	ids := func(filter *model.GoodsFilter) []int64 {
+		t.Helper()
+		filter.Limit = 10
+		page, err := s.ListGoods(ctx, filter)
+		require.NoError(t, err)
+		var ids []int64
+		for _, good := range page.Goods {
+			ids = append(ids, good.ID)
+		}
+		return ids
+	}
+	number := func(v float64) *float64 { return &v }
+
+	assert.Equal(t, []int64{sencha.ID, tieguanyin.ID}, ids(&model.GoodsFilter{
+		Attributes: []model.AttributeFilter{{Code: "brew_temp", Max: number(90)}},
+	}))
+	// Фильтры по разным атрибутам объединяются через И
+	assert.Equal(t, []int64{tieguanyin.ID}, ids(&model.GoodsFilter{
+		Attributes: []model.AttributeFilter{{Code: "caffeine", Values: []string{"medium"}}, {Code: "brew_temp", Min: number(85)}},
+	}))
+	// Значения фильтра приводятся к каноническому виду, как и значения товара
+	assert.Equal(t, []int64{dahongpao.ID}, ids(&model.GoodsFilter{
+		Attributes: []model.AttributeFilter{{Code: "roasted", Values: []string{"1"}}},
+	}))
+	assert.Equal(t, []int64{sencha.ID, dahongpao.ID}, ids(&model.GoodsFilter{
+		Attributes: []model.AttributeFilter{{Code: "brew_temp", Values: []string{"80.0", "95"}}},
+	}))
+	assert.Equal(t, []int64{tieguanyin.ID}, ids(&model.GoodsFilter{
+		CategoryIDs: []int64{tea.ID},
+		Attributes:  []model.AttributeFilter{{Code: "oxidation", Min: number(10), Max: number(50)}},
+	}))
+
+	invalid := [][]model.AttributeFilter{
+		{{Code: "color", Values: []string{"green"}}},
+		{{Code: "brew_temp"}},
+		{{Code: "caffeine", Min: number(1)}},
+		{{Code: "brew_temp", Min: number(90), Max: number(80)}},
+		{{Code: "brew_temp", Values: []string{"горячо"}}},
+	}
+	for _, filters := range invalid {
+		_, err := s.ListGoods(ctx, &model.GoodsFilter{Limit: 10, Attributes: filters})
+		assert.ErrorIs(t, err, ErrInvalidAttributeFilter)
+	}
+}
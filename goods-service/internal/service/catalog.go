@@ -0,0 +1,692 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/metrics"
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/spreadsheet"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+)
+
+// Столбцы таблицы каталога. Строка таблицы - вариант товара: товар с несколькими вариантами
+// занимает несколько строк с одним good_sku. Категории и атрибуты перечисляются через «;»,
+// атрибуты - парами code=value
+const (
+	columnSKU               = "sku"
+	columnGoodSKU           = "good_sku"
+	columnName              = "name"
+	columnDescription       = "description"
+	columnCategories        = "categories"
+	columnAttributes        = "attributes"
+	columnPrice             = "price"
+	columnStock             = "stock"
+	columnVariantAttributes = "variant_attributes"
+	// stockColumnPrefix - остатки по складам в выгрузке: stock_main, stock_spb. Они справочные
+	// и при импорте не учитываются: остаток по складам меняется складскими движениями
+	stockColumnPrefix = "stock_"
+)
+
+var catalogColumns = []string{
+	columnSKU,
+	columnGoodSKU,
+	columnName,
+	columnDescription,
+	columnCategories,
+	columnAttributes,
+	columnPrice,
+	columnStock,
+	columnVariantAttributes,
+}
+
+const (
+	// MaxCatalogFileSize - наибольший размер файла импорта
+	MaxCatalogFileSize = 10 << 20
+	// maxImportErrors - сколько ошибок строк сохраняется в задании
+	maxImportErrors = 500
+	// importProgressEvery - через сколько строк сохраняется прогресс задания
+	importProgressEvery = 50
+	// exportPageSize - сколько товаров читается за один запрос при выгрузке
+	exportPageSize = 500
+	// importInterrupted - причина задания, прерванного остановкой сервиса
+	importInterrupted = "import interrupted by service shutdown"
+)
+
+// Результаты строки импорта
+const (
+	importCreated = "created"
+	importUpdated = "updated"
+	importFailed  = "failed"
+)
+
+// ImportCatalog разбирает файл и запускает задание импорта в фоне. Ошибки формата файла и заголовка
+// возвращаются сразу, ошибки строк - в задании. Строки сопоставляются по артикулу варианта sku:
+// найденный вариант изменяется, новый добавляется товару good_sku (по умолчанию - товару с артикулом sku)
+// или создается вместе с товаром. Пустая ячейка оставляет значение без изменений. При dryRun строки
+// только проверяются
+func (s *GoodsService) ImportCatalog(ctx context.Context, format string, data []byte, dryRun bool) (*model.ImportJob, error) {
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		return nil, ErrUnsupportedCatalog
+	}
+	if len(data) > MaxCatalogFileSize {
+		return nil, ErrCatalogFileTooLarge
+	}
+	table, err := spreadsheet.Read(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCatalogFile, err)
+	}
+	if len(table) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidCatalogFile)
+	}
+	header, err := parseCatalogHeader(table[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []catalogRow
+	for i, cells := range table[1:] {
+		if !slices.ContainsFunc(cells, func(cell string) bool { return strings.TrimSpace(cell) != "" }) {
+			continue
+		}
+		// Номер строки - как в редакторе таблиц: заголовок - первая строка
+		rows = append(rows, catalogRow{number: int32(i + 2), header: header, cells: cells})
+	}
+
+	job := &model.ImportJob{
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    model.ImportRunning,
+		TotalRows: int32(len(rows)),
+	}
+	if err := s.repo.CreateImportJob(ctx, job); err != nil {
+		return nil, err
+	}
+	started := *job
+
+	// Задание переживает запрос, но сохраняет его значения, например трассировку
+	s.imports.Add(1)
+	go func() {
+		defer s.imports.Done()
+		s.runImport(context.WithoutCancel(ctx), job, rows)
+	}()
+	return &started, nil
+}
+
+// GetImportJob возвращает задание импорта с прогрессом и ошибками строк
+func (s *GoodsService) GetImportJob(ctx context.Context, id int64) (*model.ImportJob, error) {
+	job, err := s.repo.GetImportJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrImportJobNotFound
+	}
+	return job, nil
+}
+
+// runImport обрабатывает строки по одной и периодически сохраняет прогресс
+func (s *GoodsService) runImport(ctx context.Context, job *model.ImportJob, rows []catalogRow) {
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		job.Status = model.ImportFailed
+		job.Error = err.Error()
+	}
+	importer := &catalogImporter{
+		service:    s,
+		dryRun:     job.DryRun,
+		categories: categories,
+		seen:       make(map[string]int32),
+		pending:    make(map[string]*model.Good),
+	}
+
+	for _, row := range rows {
+		if job.Status != model.ImportRunning {
+			break
+		}
+		if s.importsCtx.Err() != nil {
+			job.Status = model.ImportFailed
+			job.Error = importInterrupted
+			break
+		}
+
+		result, err := importer.importRow(ctx, &row)
+		var rowErr *importRowError
+		switch {
+		case errors.As(err, &rowErr):
+			job.Failed++
+			if len(job.Errors) < maxImportErrors {
+				job.Errors = append(job.Errors, model.ImportRowError{
+					Row:     row.number,
+					Column:  rowErr.column,
+					SKU:     row.cell(columnSKU),
+					Message: rowErr.message,
+				})
+			}
+			result = importFailed
+		case err != nil:
+			// Ошибка не относится к строке, например недоступна БД: продолжать бессмысленно
+			job.Status = model.ImportFailed
+			job.Error = fmt.Sprintf("row %d: %v", row.number, err)
+			continue
+		case result == importCreated:
+			job.Created++
+		default:
+			job.Updated++
+		}
+		if !job.DryRun {
+			metrics.CatalogImportRows.WithLabelValues(result).Inc()
+		}
+
+		job.ProcessedRows++
+		if job.ProcessedRows%importProgressEvery == 0 {
+			if err := s.repo.UpdateImportJob(ctx, job); err != nil {
+				logger.Error("Failed to save import progress", "job_id", job.ID, "error", err)
+			}
+		}
+	}
+
+	if job.Status == model.ImportRunning {
+		job.Status = model.ImportCompleted
+	}
+	job.FinishedAt = time.Now()
+	if err := s.repo.UpdateImportJob(ctx, job); err != nil {
+		logger.Error("Failed to save import job", "job_id", job.ID, "error", err)
+	}
+}
+
+// importRowError - ошибка данных строки; остальные строки импорта продолжают обрабатываться
+type importRowError struct {
+	column  string
+	message string
+}
+
+func (e *importRowError) Error() string {
+	if e.column == "" {
+		return e.message
+	}
+	return e.column + ": " + e.message
+}
+
+// rowErrorFrom превращает ошибку проверки сервиса в ошибку строки; прочие ошибки возвращаются как есть
+func rowErrorFrom(column string, err error) error {
+	for _, target := range []error{ErrSKUTaken, ErrInsufficientStock, ErrInvalidAttribute, ErrInvalidVariant, ErrCategoryNotFound} {
+		if errors.Is(err, target) {
+			return &importRowError{column: column, message: err.Error()}
+		}
+	}
+	return err
+}
+
+// archivedRowError - строка относится к архивному товару: импорт не меняет товары вне каталога
+func archivedRowError(column string, good *model.Good) error {
+	return &importRowError{column: column, message: fmt.Sprintf("good %s is archived, restore it first", good.SKU)}
+}
+
+// catalogRow - непустая строка таблицы с номером и столбцами заголовка
+type catalogRow struct {
+	number int32
+	header map[string]int
+	cells  []string
+}
+
+// cell возвращает значение ячейки без пробелов по краям; пустую строку, если столбца нет
+func (r *catalogRow) cell(column string) string {
+	index, ok := r.header[column]
+	if !ok || index >= len(r.cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.cells[index])
+}
+
+// parseCatalogHeader сопоставляет столбцы с их номерами. Регистр не важен, столбцы stock_* пропускаются,
+// неизвестный или повторяющийся столбец - ошибка файла, чтобы опечатка в заголовке не теряла данные
+func parseCatalogHeader(cells []string) (map[string]int, error) {
+	header := make(map[string]int, len(cells))
+	for i, cell := range cells {
+		column := strings.ToLower(strings.TrimSpace(cell))
+		switch {
+		case column == "":
+			continue
+		case strings.HasPrefix(column, stockColumnPrefix):
+			continue
+		case !slices.Contains(catalogColumns, column):
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidCatalogFile, cell)
+		}
+		if _, ok := header[column]; ok {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidCatalogFile, cell)
+		}
+		header[column] = i
+	}
+	if _, ok := header[columnSKU]; !ok {
+		return nil, fmt.Errorf("%w: column %q is required", ErrInvalidCatalogFile, columnSKU)
+	}
+	return header, nil
+}
+
+// catalogImporter применяет строки одного задания импорта
+type catalogImporter struct {
+	service    *GoodsService
+	dryRun     bool
+	categories []*model.Category
+	// seen - в какой строке файла уже встретился артикул варианта
+	seen map[string]int32
+	// pending - товары, которые создали бы предыдущие строки пробного импорта, по артикулу
+	pending map[string]*model.Good
+}
+
+// rowValues - разобранные значения строки. Пустые значения и nil не меняют товар
+type rowValues struct {
+	sku               string
+	goodSKU           string
+	name              string
+	description       string
+	categoryIDs       []int64
+	attributes        map[string]string
+	price             float64
+	stock             *int32
+	variantAttributes map[string]string
+}
+
+// importRow создает или изменяет вариант строки и возвращает importCreated или importUpdated
+func (imp *catalogImporter) importRow(ctx context.Context, row *catalogRow) (string, error) {
+	values, err := imp.parseRow(row)
+	if err != nil {
+		return "", err
+	}
+	repo := imp.service.repo
+
+	variant, err := repo.GetVariantBySKU(ctx, values.sku)
+	if err != nil {
+		return "", err
+	}
+	if variant != nil {
+		good, err := repo.GetGood(ctx, variant.GoodID)
+		if err != nil {
+			return "", err
+		}
+		if good == nil {
+			return "", &importRowError{column: columnSKU, message: "variant's good no longer exists"}
+		}
+		if row.cell(columnGoodSKU) != "" && values.goodSKU != good.SKU {
+			return "", &importRowError{column: columnGoodSKU, message: fmt.Sprintf("variant %s belongs to good %s", values.sku, good.SKU)}
+		}
+		if !good.ArchivedAt.IsZero() {
+			return "", archivedRowError(columnSKU, good)
+		}
+		return importUpdated, imp.updateVariant(ctx, good, variant, values)
+	}
+
+	good, err := repo.GetGoodBySKU(ctx, values.goodSKU)
+	if err != nil {
+		return "", err
+	}
+	if good != nil && !good.ArchivedAt.IsZero() {
+		return "", archivedRowError(columnGoodSKU, good)
+	}
+	if good == nil {
+		good = imp.pending[values.goodSKU]
+	}
+	if good != nil {
+		return importCreated, imp.addVariant(ctx, good, values)
+	}
+	return importCreated, imp.createGood(ctx, values)
+}
+
+// parseRow проверяет и разбирает ячейки строки
+func (imp *catalogImporter) parseRow(row *catalogRow) (*rowValues, error) {
+	values := &rowValues{
+		sku:         row.cell(columnSKU),
+		goodSKU:     row.cell(columnGoodSKU),
+		name:        row.cell(columnName),
+		description: row.cell(columnDescription),
+	}
+	if values.sku == "" {
+		return nil, &importRowError{column: columnSKU, message: "sku is required"}
+	}
+	if first, ok := imp.seen[values.sku]; ok {
+		return nil, &importRowError{column: columnSKU, message: fmt.Sprintf("sku is repeated, first seen in row %d", first)}
+	}
+	imp.seen[values.sku] = row.number
+	if values.goodSKU == "" {
+		values.goodSKU = values.sku
+	}
+
+	if cell := row.cell(columnCategories); cell != "" {
+		for _, slug := range splitList(cell) {
+			index := slices.IndexFunc(imp.categories, func(category *model.Category) bool { return category.Slug == slug })
+			if index < 0 {
+				return nil, &importRowError{column: columnCategories, message: fmt.Sprintf("unknown category %q", slug)}
+			}
+			values.categoryIDs = append(values.categoryIDs, imp.categories[index].ID)
+		}
+	}
+
+	var err error
+	if values.attributes, err = parseAttributeCell(row.cell(columnAttributes)); err != nil {
+		return nil, &importRowError{column: columnAttributes, message: err.Error()}
+	}
+	if values.variantAttributes, err = parseAttributeCell(row.cell(columnVariantAttributes)); err != nil {
+		return nil, &importRowError{column: columnVariantAttributes, message: err.Error()}
+	}
+
+	if cell := row.cell(columnPrice); cell != "" {
+		// Excel в русской локали пишет дробную часть через запятую
+		price, err := strconv.ParseFloat(strings.ReplaceAll(cell, ",", "."), 64)
+		if err != nil || math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 {
+			return nil, &importRowError{column: columnPrice, message: fmt.Sprintf("price %q must be a positive number", cell)}
+		}
+		values.price = price
+	}
+	if cell := row.cell(columnStock); cell != "" {
+		stock, err := strconv.ParseFloat(cell, 64)
+		if err != nil || stock < 0 || stock > math.MaxInt32 || stock != math.Trunc(stock) {
+			return nil, &importRowError{column: columnStock, message: fmt.Sprintf("stock %q must be a non-negative integer", cell)}
+		}
+		quantity := int32(stock)
+		values.stock = &quantity
+	}
+	return values, nil
+}
+
+// goodChanges - изменения товара, подготовленные prepareGood и еще не записанные
+type goodChanges struct {
+	categories bool
+	good       bool
+}
+
+// prepareGood проверяет и применяет к товару в памяти название, описание, категории и атрибуты
+// строки. Атрибуты проверяются по схеме итоговых категорий, даже если в строке менялись только категории
+func (imp *catalogImporter) prepareGood(good *model.Good, values *rowValues) (goodChanges, error) {
+	var changes goodChanges
+	changes.categories = values.categoryIDs != nil && !slices.Equal(sortedIDs(values.categoryIDs), sortedIDs(good.CategoryIDs))
+	attributes := good.Attributes
+	if values.attributes != nil {
+		attributes = values.attributes
+	}
+	if changes.categories || values.attributes != nil {
+		categoryIDs := good.CategoryIDs
+		if changes.categories {
+			categoryIDs = values.categoryIDs
+		}
+		schema, err := goodAttributeSchema(imp.categories, categoryIDs)
+		if err != nil {
+			return changes, rowErrorFrom(columnCategories, err)
+		}
+		if attributes, err = validateAttributes(schema, attributes); err != nil {
+			return changes, rowErrorFrom(columnAttributes, err)
+		}
+	}
+
+	changes.good = !maps.Equal(attributes, good.Attributes)
+	if values.name != "" && values.name != good.Name {
+		good.Name = values.name
+		changes.good = true
+	}
+	if values.description != "" && values.description != good.Description {
+		good.Description = values.description
+		changes.good = true
+	}
+	good.Attributes = attributes
+	if changes.categories {
+		good.CategoryIDs = values.categoryIDs
+	}
+	return changes, nil
+}
+
+// saveGood записывает изменения товара, подготовленные prepareGood
+func (imp *catalogImporter) saveGood(ctx context.Context, good *model.Good, changes goodChanges) error {
+	repo := imp.service.repo
+	if changes.categories {
+		if err := repo.SetGoodCategories(ctx, good.ID, good.CategoryIDs); err != nil {
+			return err
+		}
+	}
+	if changes.good {
+		return repo.UpdateGood(ctx, good)
+	}
+	return nil
+}
+
+// updateVariant изменяет найденный вариант и его товар. Остаток задается общий, но записывается
+// не он, а разница с прочитанным при разборе строки: хранилище проводит ее корректировкой на складе
+// по умолчанию под блокировкой варианта, и резервы, сделанные после чтения, не затираются.
+// Вариант записывается первым: его может отклонить хранилище (остаток ниже резерва), и тогда
+// товар строки не меняется. Частично строка применяется только при сбое хранилища между
+// записями; повторная загрузка того же файла ее дозаписывает
+func (imp *catalogImporter) updateVariant(ctx context.Context, good *model.Good, variant *model.Variant, values *rowValues) error {
+	if values.price > 0 {
+		variant.Price = values.price
+	}
+	var stockDelta int32
+	if values.stock != nil {
+		stockDelta = *values.stock - variant.Stock
+		variant.Stock = *values.stock
+	}
+	if values.variantAttributes != nil {
+		variant.Attributes = values.variantAttributes
+	}
+	if err := validateVariant(variant); err != nil {
+		return rowErrorFrom("", err)
+	}
+	changes, err := imp.prepareGood(good, values)
+	if err != nil {
+		return err
+	}
+	if imp.dryRun {
+		return nil
+	}
+
+	if err := imp.service.repo.UpdateVariant(ctx, variant, stockDelta); err != nil {
+		return rowErrorFrom(columnStock, err)
+	}
+	return imp.saveGood(ctx, good, changes)
+}
+
+// addVariant добавляет вариант существующему товару или товару, созданному предыдущей строкой.
+// Как и в updateVariant, вариант записывается до изменений товара
+func (imp *catalogImporter) addVariant(ctx context.Context, good *model.Good, values *rowValues) error {
+	if values.price == 0 {
+		return &importRowError{column: columnPrice, message: "price is required for a new variant"}
+	}
+	variant := &model.Variant{
+		GoodID:     good.ID,
+		SKU:        values.sku,
+		Price:      values.price,
+		Attributes: values.variantAttributes,
+	}
+	if values.stock != nil {
+		variant.Stock = *values.stock
+	}
+	changes, err := imp.prepareGood(good, values)
+	if err != nil {
+		return err
+	}
+	if imp.dryRun {
+		return nil
+	}
+
+	if err := imp.service.repo.CreateVariant(ctx, variant); err != nil {
+		return rowErrorFrom(columnSKU, err)
+	}
+	return imp.saveGood(ctx, good, changes)
+}
+
+// createGood создает товар с артикулом good_sku и единственным вариантом с артикулом sku
+// одной транзакцией хранилища
+func (imp *catalogImporter) createGood(ctx context.Context, values *rowValues) error {
+	if values.name == "" {
+		return &importRowError{column: columnName, message: "name is required for a new good"}
+	}
+	if values.price == 0 {
+		return &importRowError{column: columnPrice, message: "price is required for a new good"}
+	}
+	schema, err := goodAttributeSchema(imp.categories, values.categoryIDs)
+	if err != nil {
+		return rowErrorFrom(columnCategories, err)
+	}
+	attributes, err := validateAttributes(schema, values.attributes)
+	if err != nil {
+		return rowErrorFrom(columnAttributes, err)
+	}
+
+	good := &model.Good{
+		SKU:         values.goodSKU,
+		Name:        values.name,
+		Description: values.description,
+		Price:       values.price,
+		CategoryIDs: values.categoryIDs,
+		Attributes:  attributes,
+		Variants:    []*model.Variant{{SKU: values.sku, Attributes: values.variantAttributes}},
+	}
+	if values.stock != nil {
+		good.Stock = *values.stock
+	}
+	if imp.dryRun {
+		imp.pending[good.SKU] = good
+		return nil
+	}
+	return rowErrorFrom(columnGoodSKU, imp.service.repo.CreateGood(ctx, good))
+}
+
+// ExportCatalog выгружает весь каталог строкой на вариант: цена, общий остаток и остатки по складам
+// в столбцах stock_<код склада>. Выгрузку можно изменить и загрузить обратно через ImportCatalog.
+// Архивные товары не выгружаются: импорт их все равно не меняет
+func (s *GoodsService) ExportCatalog(ctx context.Context, format string) ([]byte, error) {
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		return nil, ErrUnsupportedCatalog
+	}
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slugs := make(map[int64]string, len(categories))
+	for _, category := range categories {
+		slugs[category.ID] = category.Slug
+	}
+	warehouses, err := s.repo.ListWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	header := slices.Clone(catalogColumns)
+	numeric := make([]bool, len(header), len(header)+len(warehouses))
+	numeric[slices.Index(header, columnPrice)] = true
+	numeric[slices.Index(header, columnStock)] = true
+	for _, warehouse := range warehouses {
+		header = append(header, stockColumnPrefix+warehouse.Code)
+		numeric = append(numeric, true)
+	}
+	rows := [][]string{header}
+
+	// Страницы выбираются по id после последнего выгруженного товара, а не по смещению: товар,
+	// добавленный или архивированный во время выгрузки, не сдвигает следующие страницы
+	var lastID int64
+	for {
+		goods, err := s.repo.ListGoods(ctx, &model.GoodsFilter{Limit: exportPageSize, AfterID: lastID})
+		if err != nil {
+			return nil, err
+		}
+		for _, good := range goods {
+			goodSlugs := make([]string, 0, len(good.CategoryIDs))
+			for _, id := range good.CategoryIDs {
+				goodSlugs = append(goodSlugs, slugs[id])
+			}
+			for _, variant := range good.Variants {
+				row := []string{
+					variant.SKU,
+					good.SKU,
+					good.Name,
+					good.Description,
+					strings.Join(goodSlugs, "; "),
+					formatAttributeCell(good.Attributes),
+					strconv.FormatFloat(variant.Price, 'f', -1, 64),
+					strconv.Itoa(int(variant.Stock)),
+					formatAttributeCell(variant.Attributes),
+				}
+				for _, warehouse := range warehouses {
+					stock := int32(0)
+					for _, level := range variant.Warehouses {
+						if level.WarehouseID == warehouse.ID {
+							stock = level.Stock
+						}
+					}
+					row = append(row, strconv.Itoa(int(stock)))
+				}
+				rows = append(rows, row)
+			}
+		}
+		if len(goods) < exportPageSize {
+			break
+		}
+		lastID = goods[len(goods)-1].ID
+	}
+
+	var buf bytes.Buffer
+	if format == spreadsheet.FormatXLSX {
+		err = spreadsheet.WriteXLSX(&buf, "Каталог", rows, numeric)
+	} else {
+		err = spreadsheet.WriteCSV(&buf, rows)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// splitList разбирает список через «;» или «,», пропуская пустые элементы
+func splitList(cell string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(cell, func(r rune) bool { return r == ';' || r == ',' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseAttributeCell разбирает атрибуты вида "brew_temp=85; origin=Китай"; пустая ячейка - nil
+func parseAttributeCell(cell string) (map[string]string, error) {
+	if cell == "" {
+		return nil, nil
+	}
+	attributes := make(map[string]string)
+	for _, pair := range strings.Split(cell, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		code, value, ok := strings.Cut(pair, "=")
+		code, value = strings.TrimSpace(code), strings.TrimSpace(value)
+		if !ok || code == "" || value == "" {
+			return nil, fmt.Errorf("%q must be code=value", strings.TrimSpace(pair))
+		}
+		if _, ok := attributes[code]; ok {
+			return nil, fmt.Errorf("attribute %s is repeated", code)
+		}
+		attributes[code] = value
+	}
+	return attributes, nil
+}
+
+// formatAttributeCell записывает атрибуты в порядке кодов, как их читает parseAttributeCell
+func formatAttributeCell(attributes map[string]string) string {
+	pairs := make([]string, 0, len(attributes))
+	for _, code := range slices.Sorted(maps.Keys(attributes)) {
+		pairs = append(pairs, code+"="+attributes[code])
+	}
+	return strings.Join(pairs, "; ")
+}
+
+func sortedIDs(ids []int64) []int64 {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
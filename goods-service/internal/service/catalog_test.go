@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/che1nov/tea-shop/goods-service/internal/spreadsheet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitImport ждет завершения задания импорта
+func waitImport(t *testing.T, s *GoodsService, id int64) *model.ImportJob {
+	t.Helper()
+	var job *model.ImportJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = s.GetImportJob(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status != model.ImportRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestImportCatalog_CreatesAndUpdates(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	createTeaSchema(t, s)
+
+	existing, err := s.CreateGood(ctx, &model.CreateGoodRequest{SKU: "SENCHA", Name: "Сенча", Price: 500, Stock: 3})
+	require.NoError(t, err)
+
+	csv := "SKU;good_sku;name;categories;attributes;price;stock;variant_attributes;stock_main\n" +
+		"PUER-100;PUER;Шу пуэр;tea;\"brew_temp=95; origin=Юньнань\";1200,50;10;weight=100 г;\n" +
+		"PUER-250;PUER;;;;2800;4;weight=250 г;\n" +
+		"\n" +
+		"SENCHA;;Сенча Учи;;;;7;;999\n"
+	job, err := s.ImportCatalog(ctx, spreadsheet.FormatCSV, []byte(csv), false)
+	require.NoError(t, err)
+	assert.Equal(t, model.ImportRunning, job.Status)
+	assert.Equal(t, int32(3), job.TotalRows)
+
+	job = waitImport(t, s, job.ID)
+	assert.Equal(t, model.ImportCompleted, job.Status)
+	assert.Empty(t, job.Errors)
+	assert.Equal(t, int32(3), job.ProcessedRows)
+	assert.Equal(t, int32(2), job.Created)
+	assert.Equal(t, int32(1), job.Updated)
+	assert.False(t, job.FinishedAt.IsZero())
+
+	page, err := s.ListGoods(ctx, &model.GoodsFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Goods, 2)
+	puer := page.Goods[1]
+	assert.Equal(t, "PUER", puer.SKU)
+	assert.Equal(t, map[string]string{"brew_temp": "95", "origin": "Юньнань"}, puer.Attributes)
+	require.Len(t, puer.Variants, 2)
+	assert.Equal(t, "PUER-100", puer.Variants[0].SKU)
+	assert.Equal(t, 1200.5, puer.Variants[0].Price)
+	assert.Equal(t, map[string]string{"weight": "100 г"}, puer.Variants[0].Attributes)
+	assert.Equal(t, "PUER-250", puer.Variants[1].SKU)
+	assert.Equal(t, int32(14), puer.Stock)
+
+	sencha, err := s.GetGood(ctx, existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Сенча Учи", sencha.Name)
+	assert.Equal(t, 500.0, sencha.Price)
+	assert.Equal(t, int32(7), sencha.Stock)
+}
+
+func TestImportCatalog_DryRunReportsRowErrors(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	createTeaSchema(t, s)
+
+	csv := "sku,good_sku,name,categories,attributes,price,stock\n" +
+		"DHP-1,DHP,Да Хун Пао,oolong,brew_temp=90,1500,5\n" +
+		"DHP-2,DHP,,,,1700,\n" +
+		",,Без артикула,,,100,1\n" +
+		"DHP-1,,Повтор,,,100,1\n" +
+		"TGY-1,,Те Гуань Инь,oolong,,900,1\n" +
+		"TGY-2,,Те Гуань Инь,black,brew_temp=85,900,1\n" +
+		"TGY-3,,Те Гуань Инь,oolong,brew_temp=горячо,900,1\n" +
+		"TGY-4,,Те Гуань Инь,,,-5,1\n" +
+		"TGY-5,,Те Гуань Инь,,,900,1.5\n" +
+		"TGY-6,,,,,900,1\n" +
+		"TGY-7,,Те Гуань Инь,,brew_temp,900,1\n"
+	job, err := s.ImportCatalog(ctx, spreadsheet.FormatCSV, []byte(csv), true)
+	require.NoError(t, err)
+
+	job = waitImport(t, s, job.ID)
+	assert.Equal(t, model.ImportCompleted, job.Status)
+	assert.True(t, job.DryRun)
+	assert.Equal(t, int32(11), job.ProcessedRows)
+	assert.Equal(t, int32(2), job.Created)
+	assert.Equal(t, int32(9), job.Failed)
+
+	columns := make(map[int32]string)
+	for _, rowErr := range job.Errors {
+		columns[rowErr.Row] = rowErr.Column
+	}
+	assert.Equal(t, map[int32]string{
+		4:  "sku",
+		5:  "sku",
+		6:  "attributes",
+		7:  "categories",
+		8:  "attributes",
+		9:  "price",
+		10: "stock",
+		11: "name",
+		12: "attributes",
+	}, columns)
+	assert.Equal(t, "TGY-2", job.Errors[3].SKU)
+
+	// Пробный импорт ничего не меняет
+	page, err := s.ListGoods(ctx, &model.GoodsFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Goods)
+}
+
+func TestImportCatalog_ArchivedGoods(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{SKU: "SENCHA", Name: "Сенча", Price: 500, Stock: 3})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteGood(ctx, good.ID))
+
+	csv := "sku;good_sku;name;price\n" +
+		"SENCHA;;Сенча Учи;600\n" +
+		"SENCHA-250;SENCHA;;1200\n"
+	job, err := s.ImportCatalog(ctx, spreadsheet.FormatCSV, []byte(csv), false)
+	require.NoError(t, err)
+
+	// Импорт не меняет архивные товары и не добавляет им варианты
+	job = waitImport(t, s, job.ID)
+	assert.Equal(t, int32(2), job.Failed)
+	require.Len(t, job.Errors, 2)
+	assert.Equal(t, "sku", job.Errors[0].Column)
+	assert.Equal(t, "good_sku", job.Errors[1].Column)
+
+	archived, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Сенча", archived.Name)
+	assert.Len(t, archived.Variants, 1)
+}
+
+func TestImportCatalog_RejectedVariantKeepsGood(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{SKU: "SENCHA", Name: "Сенча", Price: 500})
+	require.NoError(t, err)
+	spb, err := s.CreateWarehouse(ctx, &model.CreateWarehouseRequest{Code: "spb", Name: "Петербург"})
+	require.NoError(t, err)
+	_, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{GoodID: good.ID, VariantID: good.Variants[0].ID, WarehouseID: spb.ID, Type: model.MovementReceipt, Quantity: 4})
+	require.NoError(t, err)
+
+	// Остаток списывается со склада по умолчанию, где товара нет: строка отклоняется целиком
+	csv := "sku;name;stock\n" +
+		"SENCHA;Сенча Учи;1\n"
+	job, err := s.ImportCatalog(ctx, spreadsheet.FormatCSV, []byte(csv), false)
+	require.NoError(t, err)
+
+	job = waitImport(t, s, job.ID)
+	assert.Equal(t, int32(1), job.Failed)
+	require.Len(t, job.Errors, 1)
+	assert.Equal(t, "stock", job.Errors[0].Column)
+
+	sencha, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Сенча", sencha.Name)
+	assert.Equal(t, int32(4), sencha.Stock)
+}
+
+// reservingRepository резервирует товар перед записью варианта, как заказ, оформленный во время импорта
+type reservingRepository struct {
+	*repository.MemoryRepository
+	allocation *model.Allocation
+}
+
+func (r *reservingRepository) UpdateVariant(ctx context.Context, variant *model.Variant, stockDelta int32) error {
+	if err := r.ReserveAllocations(ctx, 1, []*model.Allocation{r.allocation}); err != nil {
+		return err
+	}
+	return r.MemoryRepository.UpdateVariant(ctx, variant, stockDelta)
+}
+
+func TestImportCatalog_KeepsConcurrentReservation(t *testing.T) {
+	repo := &reservingRepository{MemoryRepository: repository.NewMemory()}
+	s := New(repo)
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{SKU: "SENCHA", Name: "Сенча", Price: 500, Stock: 10})
+	require.NoError(t, err)
+	repo.allocation = &model.Allocation{VariantID: good.Variants[0].ID, WarehouseID: 1, Quantity: 2}
+
+	// Остаток из файла - поступление 5 к прочитанным 10; резерв 2, сделанный до записи, сохраняется
+	csv := "sku;stock\n" +
+		"SENCHA;15\n"
+	job, err := s.ImportCatalog(ctx, spreadsheet.FormatCSV, []byte(csv), false)
+	require.NoError(t, err)
+
+	job = waitImport(t, s, job.ID)
+	assert.Empty(t, job.Errors)
+	sencha, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(13), sencha.Stock)
+}
+
+func TestImportCatalog_InvalidFile(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	_, err := s.ImportCatalog(ctx, "xls", []byte("sku\n"), false)
+	assert.ErrorIs(t, err, ErrUnsupportedCatalog)
+	_, err = s.ImportCatalog(ctx, spreadsheet.FormatCSV, []byte("sku;prise\nA;1\n"), false)
+	assert.ErrorIs(t, err, ErrInvalidCatalogFile)
+	_, err = s.ImportCatalog(ctx, spreadsheet.FormatCSV, []byte("name;price\nЧай;1\n"), false)
+	assert.ErrorIs(t, err, ErrInvalidCatalogFile)
+	_, err = s.ImportCatalog(ctx, spreadsheet.FormatXLSX, []byte("sku;price\n"), false)
+	assert.ErrorIs(t, err, ErrInvalidCatalogFile)
+	_, err = s.ImportCatalog(ctx, spreadsheet.FormatCSV, make([]byte, MaxCatalogFileSize+1), false)
+	assert.ErrorIs(t, err, ErrCatalogFileTooLarge)
+
+	_, err = s.GetImportJob(ctx, 42)
+	assert.ErrorIs(t, err, ErrImportJobNotFound)
+}
+
+func TestExportCatalog_RoundTrip(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	tea, _ := createTeaSchema(t, s)
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{
+		SKU:         "PUER",
+		Name:        "Шу пуэр",
+		Price:       1200,
+		Stock:       10,
+		CategoryIDs: []int64{tea.ID},
+		Attributes:  map[string]string{"brew_temp": "95"},
+	})
+	require.NoError(t, err)
+	_, err = s.CreateVariant(ctx, good.ID, &model.CreateVariantRequest{SKU: "PUER-357", Price: 3500, Stock: 2, Attributes: map[string]string{"weight": "357 г"}})
+	require.NoError(t, err)
+	spb, err := s.CreateWarehouse(ctx, &model.CreateWarehouseRequest{Code: "spb", Name: "Петербург"})
+	require.NoError(t, err)
+	_, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{GoodID: good.ID, VariantID: good.Variants[0].ID, WarehouseID: spb.ID, Type: model.MovementReceipt, Quantity: 4})
+	require.NoError(t, err)
+	// Архивный товар в выгрузку не попадает
+	archived, err := s.CreateGood(ctx, &model.CreateGoodRequest{SKU: "SENCHA", Name: "Сенча", Price: 500, Stock: 3})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteGood(ctx, archived.ID))
+
+	data, err := s.ExportCatalog(ctx, spreadsheet.FormatXLSX)
+	require.NoError(t, err)
+	rows, err := spreadsheet.ReadXLSX(data)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"sku", "good_sku", "name", "description", "categories", "attributes", "price", "stock", "variant_attributes", "stock_main", "stock_spb"},
+		{"PUER", "PUER", "Шу пуэр", "", "tea", "brew_temp=95", "1200", "14", "", "10", "4"},
+		{"PUER-357", "PUER", "Шу пуэр", "", "tea", "brew_temp=95", "3500", "2", "weight=357 г", "2", "0"},
+	}, rows)
+
+	// Неизмененная выгрузка загружается обратно без ошибок и без изменений остатков
+	job, err := s.ImportCatalog(ctx, spreadsheet.FormatXLSX, data, false)
+	require.NoError(t, err)
+	job = waitImport(t, s, job.ID)
+	assert.Empty(t, job.Errors)
+	assert.Equal(t, int32(2), job.Updated)
+
+	reloaded, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(16), reloaded.Stock)
+	require.Len(t, reloaded.Variants[0].Warehouses, 2)
+
+	_, err = s.ExportCatalog(ctx, "pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedCatalog)
+}
+
+func TestClose_InterruptsImports(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	require.NoError(t, s.Close())
+
+	job, err := s.ImportCatalog(ctx, spreadsheet.FormatCSV, []byte("sku;name;price\nPUER;Пуэр;100\n"), false)
+	require.NoError(t, err)
+	job = waitImport(t, s, job.ID)
+	assert.Equal(t, model.ImportFailed, job.Status)
+	assert.Equal(t, importInterrupted, job.Error)
+	assert.Equal(t, int32(0), job.ProcessedRows)
+}
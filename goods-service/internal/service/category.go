@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// slugPattern - slug для адресов каталога: green-tea, pu-erh, teaware
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func (s *GoodsService) CreateCategory(ctx context.Context, req *model.CreateCategoryRequest) (*model.Category, error) {
+	category := &model.Category{
+		ParentID:    req.ParentID,
+		Slug:        strings.TrimSpace(req.Slug),
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Attributes:  req.Attributes,
+	}
+	if err := validateCategory(category); err != nil {
+		return nil, err
+	}
+
+	if category.ParentID != 0 {
+		parent, err := s.repo.GetCategory(ctx, category.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, ErrParentNotFound
+		}
+	}
+
+	if err := s.repo.CreateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *GoodsService) GetCategory(ctx context.Context, id int64) (*model.Category, error) {
+	category, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+func (s *GoodsService) GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	category, err := s.repo.GetCategoryBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// ListCategories возвращает все категории в порядке обхода дерева: родитель перед потомками,
+// соседние категории по названию
+func (s *GoodsService) ListCategories(ctx context.Context) ([]*model.Category, error) {
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := childrenByParent(categories)
+	for _, siblings := range children {
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return siblings[i].Name < siblings[j].Name
+		})
+	}
+
+	ordered := make([]*model.Category, 0, len(categories))
+	var walk func(parentID int64)
+	walk = func(parentID int64) {
+		for _, category := range children[parentID] {
+			ordered = append(ordered, category)
+			walk(category.ID)
+		}
+	}
+	walk(0)
+	return ordered, nil
+}
+
+// UpdateCategory заменяет все поля категории. Категорию можно перенести в другую ветку,
+// но не под саму себя или свою подкатегорию
+func (s *GoodsService) UpdateCategory(ctx context.Context, id int64, req *model.UpdateCategoryRequest) (*model.Category, error) {
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var category *model.Category
+	for _, c := range categories {
+		if c.ID == id {
+			category = c
+		}
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	if req.ParentID != 0 {
+		if subtree(categories, req.ParentID) == nil {
+			return nil, ErrParentNotFound
+		}
+		// Новый родитель не должен лежать в поддереве переносимой категории
+		for _, descendant := range subtree(categories, id) {
+			if descendant == req.ParentID {
+				return nil, ErrCategoryCycle
+			}
+		}
+	}
+
+	category.ParentID = req.ParentID
+	category.Slug = strings.TrimSpace(req.Slug)
+	category.Name = strings.TrimSpace(req.Name)
+	category.Description = req.Description
+	category.Attributes = req.Attributes
+	if err := validateCategory(category); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory удаляет категорию без подкатегорий; товары остаются в каталоге без неё
+func (s *GoodsService) DeleteCategory(ctx context.Context, id int64) error {
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := subtree(categories, id)
+	if ids == nil {
+		return ErrCategoryNotFound
+	}
+	if len(ids) > 1 {
+		return ErrCategoryHasChildren
+	}
+	return s.repo.DeleteCategory(ctx, id)
+}
+
+// SetGoodCategories заменяет категории товара; пустой список убирает товар из всех категорий
+func (s *GoodsService) SetGoodCategories(ctx context.Context, goodID int64, categoryIDs []int64) (*model.Good, error) {
+	good, err := s.repo.GetGood(ctx, goodID)
+	if err != nil {
+		return nil, err
+	}
+	if good == nil {
+		return nil, ErrGoodNotFound
+	}
+
+	for _, id := range categoryIDs {
+		category, err := s.repo.GetCategory(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if category == nil {
+			return nil, ErrCategoryNotFound
+		}
+	}
+
+	if err := s.repo.SetGoodCategories(ctx, goodID, categoryIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGood(ctx, goodID)
+}
+
+func validateCategory(category *model.Category) error {
+	if category.Name == "" {
+		return ErrCategoryNameRequired
+	}
+	if !slugPattern.MatchString(category.Slug) {
+		return ErrInvalidSlug
+	}
+	return validateAttributeSchema(category.Attributes)
+}
+
+func childrenByParent(categories []*model.Category) map[int64][]*model.Category {
+	children := make(map[int64][]*model.Category)
+	for _, category := range categories {
+		children[category.ParentID] = append(children[category.ParentID], category)
+	}
+	return children
+}
+
+// subtree возвращает id категории и всех её подкатегорий или nil, если категории нет
+func subtree(categories []*model.Category, id int64) []int64 {
+	found := false
+	for _, category := range categories {
+		if category.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	children := childrenByParent(categories)
+	ids := []int64{id}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			ids = append(ids, child.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createCategory(t *testing.T, s *GoodsService, parentID int64, slug, name string) *model.Category {
+	t.Helper()
+	category, err := s.CreateCategory(context.Background(), &model.CreateCategoryRequest{
+		ParentID: parentID,
+		Slug:     slug,
+		Name:     name,
+	})
+	require.NoError(t, err)
+	return category
+}
+
+func TestCreateCategory_Validation(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	createCategory(t, s, 0, "tea", "Чай")
+
+	tests := []struct {
+		name string
+		req  *model.CreateCategoryRequest
+		err  error
+	}{
+		{"empty name", &model.CreateCategoryRequest{Slug: "green"}, ErrCategoryNameRequired},
+		{"uppercase slug", &model.CreateCategoryRequest{Slug: "Green", Name: "Зелёный"}, ErrInvalidSlug},
+		{"cyrillic slug", &model.CreateCategoryRequest{Slug: "зелёный", Name: "Зелёный"}, ErrInvalidSlug},
+		{"double hyphen", &model.CreateCategoryRequest{Slug: "pu--erh", Name: "Пуэр"}, ErrInvalidSlug},
+		{"unknown parent", &model.CreateCategoryRequest{ParentID: 42, Slug: "green", Name: "Зелёный"}, ErrParentNotFound},
+		{"taken slug", &model.CreateCategoryRequest{Slug: "tea", Name: "Чай"}, ErrSlugTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateCategory(ctx, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestListCategories_TreeOrder(t *testing.T) {
+	s := New(repository.NewMemory())
+	teaware := createCategory(t, s, 0, "teaware", "Посуда")
+	tea := createCategory(t, s, 0, "tea", "Чай")
+	createCategory(t, s, tea.ID, "oolong", "Улун")
+	green := createCategory(t, s, tea.ID, "green", "Зелёный")
+	createCategory(t, s, green.ID, "sencha", "Сенча")
+	createCategory(t, s, teaware.ID, "gaiwan", "Гайвани")
+
+	categories, err := s.ListCategories(context.Background())
+	require.NoError(t, err)
+
+	var slugs []string
+	for _, category := range categories {
+		slugs = append(slugs, category.Slug)
+	}
+	assert.Equal(t, []string{"teaware", "gaiwan", "tea", "green", "sencha", "oolong"}, slugs)
+}
+
+func TestUpdateCategory_Move(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	tea := createCategory(t, s, 0, "tea", "Чай")
+	green := createCategory(t, s, tea.ID, "green", "Зелёный")
+	sencha := createCategory(t, s, green.ID, "sencha", "Сенча")
+	teaware := createCategory(t, s, 0, "teaware", "Посуда")
+
+	_, err := s.UpdateCategory(ctx, tea.ID, &model.UpdateCategoryRequest{ParentID: sencha.ID, Slug: "tea", Name: "Чай"})
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	_, err = s.UpdateCategory(ctx, tea.ID, &model.UpdateCategoryRequest{ParentID: tea.ID, Slug: "tea", Name: "Чай"})
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	_, err = s.UpdateCategory(ctx, green.ID, &model.UpdateCategoryRequest{ParentID: tea.ID, Slug: "teaware", Name: "Зелёный"})
+	assert.ErrorIs(t, err, ErrSlugTaken)
+	_, err = s.UpdateCategory(ctx, 42, &model.UpdateCategoryRequest{Slug: "missing", Name: "Нет"})
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+
+	moved, err := s.UpdateCategory(ctx, green.ID, &model.UpdateCategoryRequest{ParentID: teaware.ID, Slug: "green-tea", Name: "Зелёный"})
+	require.NoError(t, err)
+	assert.Equal(t, teaware.ID, moved.ParentID)
+
+	stored, err := s.GetCategoryBySlug(ctx, "green-tea")
+	require.NoError(t, err)
+	assert.Equal(t, teaware.ID, stored.ParentID)
+}
+
+func TestDeleteCategory(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	tea := createCategory(t, s, 0, "tea", "Чай")
+	green := createCategory(t, s, tea.ID, "green", "Зелёный")
+
+	assert.ErrorIs(t, s.DeleteCategory(ctx, tea.ID), ErrCategoryHasChildren)
+	assert.ErrorIs(t, s.DeleteCategory(ctx, 42), ErrCategoryNotFound)
+	require.NoError(t, s.DeleteCategory(ctx, green.ID))
+	require.NoError(t, s.DeleteCategory(ctx, tea.ID))
+
+	_, err := s.GetCategory(ctx, tea.ID)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+}
+
+func TestSetGoodCategories_FilterBySubtree(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+	tea := createCategory(t, s, 0, "tea", "Чай")
+	green := createCategory(t, s, tea.ID, "green", "Зелёный")
+	teaware := createCategory(t, s, 0, "teaware", "Посуда")
+
+	sencha, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Сенча", Price: 100, Stock: 1})
+	require.NoError(t, err)
+	gaiwan, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Гайвань", Price: 900, Stock: 1})
+	require.NoError(t, err)
+
+	_, err = s.SetGoodCategories(ctx, 42, []int64{green.ID})
+	assert.ErrorIs(t, err, ErrGoodNotFound)
+	_, err = s.SetGoodCategories(ctx, sencha.ID, []int64{42})
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+
+	good, err := s.SetGoodCategories(ctx, sencha.ID, []int64{green.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{green.ID}, good.CategoryIDs)
+	_, err = s.SetGoodCategories(ctx, gaiwan.ID, []int64{teaware.ID})
+	require.NoError(t, err)
+
+	page, err := s.ListGoods(ctx, &model.GoodsFilter{Limit: 10, CategoryIDs: []int64{tea.ID}})
+	require.NoError(t, err)
+	require.Len(t, page.Goods, 1)
+	assert.Equal(t, sencha.ID, page.Goods[0].ID)
+	assert.Equal(t, int32(1), page.Total)
+
+	page, err = s.ListGoods(ctx, &model.GoodsFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Goods, 2)
+	assert.Equal(t, int32(2), page.Total)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListGoods_Facets(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	tea := createCategory(t, s, 0, "tea", "Чай")
+	green := createCategory(t, s, tea.ID, "green", "Зеленый")
+	teaware := createCategory(t, s, 0, "teaware", "Посуда")
+
+	sencha, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Сенча", Price: 700, Stock: 5})
+	require.NoError(t, err)
+	gaiwan, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Гайвань", Price: 6000, Stock: 0})
+	require.NoError(t, err)
+	_, err = s.SetGoodCategories(ctx, sencha.ID, []int64{green.ID})
+	require.NoError(t, err)
+	_, err = s.SetGoodCategories(ctx, gaiwan.ID, []int64{teaware.ID})
+	require.NoError(t, err)
+
+	page, err := s.ListGoods(ctx, &model.GoodsFilter{
+		Limit:         10,
+		CategoryIDs:   []int64{tea.ID, teaware.ID},
+		Sort:          model.SortPriceDesc,
+		IncludeFacets: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Goods, 2)
+	assert.Equal(t, gaiwan.ID, page.Goods[0].ID)
+	assert.Equal(t, int32(2), page.Total)
+
+	require.NotNil(t, page.Facets)
+	assert.Len(t, page.Facets.Prices, len(priceFacetBounds)+1)
+	assert.Equal(t, int32(1), page.Facets.Prices[1].Count)
+	assert.Equal(t, int32(1), page.Facets.Prices[len(priceFacetBounds)].Count)
+	assert.Equal(t, []model.CategoryFacet{
+		{CategoryID: tea.ID, Count: 1},
+		{CategoryID: green.ID, Count: 1},
+		{CategoryID: teaware.ID, Count: 1},
+	}, page.Facets.Categories)
+	assert.Equal(t, int32(1), page.Facets.InStock)
+	assert.Equal(t, int32(1), page.Facets.OutOfStock)
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/metrics"
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// reserveAttempts - сколько раз распределение строится заново, если остаток на складе
+// изменился между чтением и резервированием
+const reserveAttempts = 3
+
+// SetFulfilmentStrategy задает стратегию выбора склада для резервирований без своей стратегии
+func (s *GoodsService) SetFulfilmentStrategy(strategy string) {
+	s.fulfilment = strategy
+}
+
+// ReserveOrder резервирует позиции заказа на складах, выбранных стратегией, одной транзакцией.
+// Возвращает распределение позиций по складам или nil, если товара недостаточно или он в архиве
+func (s *GoodsService) ReserveOrder(ctx context.Context, req *model.ReserveOrderRequest) ([]*model.Allocation, error) {
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = s.fulfilment
+	}
+	if !slices.Contains([]string{model.FulfilmentNearest, model.FulfilmentMostStock, model.FulfilmentFewestSplits}, strategy) {
+		return nil, ErrInvalidStrategy
+	}
+	if len(req.Items) == 0 {
+		return nil, ErrInvalidReservation
+	}
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, ErrInvalidReservation
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		allocations, err := s.allocateOrder(ctx, req, strategy)
+		if err != nil {
+			metrics.Reservations.WithLabelValues("error").Inc()
+			return nil, err
+		}
+		if allocations == nil {
+			metrics.Reservations.WithLabelValues("insufficient").Inc()
+			return nil, nil
+		}
+
+		err = s.repo.ReserveAllocations(ctx, req.OrderID, allocations)
+		if errors.Is(err, ErrInsufficientStock) {
+			if attempt < reserveAttempts {
+				continue
+			}
+			metrics.Reservations.WithLabelValues("insufficient").Inc()
+			return nil, nil
+		}
+		if errors.Is(err, ErrGoodArchived) {
+			// Товар убрали в архив после проверки остатка: заказ не резервируется, как при нехватке
+			metrics.Reservations.WithLabelValues("insufficient").Inc()
+			return nil, nil
+		}
+		if err != nil {
+			metrics.Reservations.WithLabelValues("error").Inc()
+			return nil, err
+		}
+
+		warehouses := make(map[int64]bool)
+		for _, allocation := range allocations {
+			warehouses[allocation.WarehouseID] = true
+			metrics.ReservedUnits.Add(float64(allocation.Quantity))
+		}
+		metrics.Reservations.WithLabelValues("reserved").Inc()
+		metrics.ReservationWarehouses.Observe(float64(len(warehouses)))
+		return allocations, nil
+	}
+}
+
+// allocateOrder читает остатки позиций по складам и распределяет позиции стратегией
+func (s *GoodsService) allocateOrder(ctx context.Context, req *model.ReserveOrderRequest, strategy string) ([]*model.Allocation, error) {
+	demands := make([]*demand, len(req.Items))
+	for i, item := range req.Items {
+		variant, err := s.resolveVariant(ctx, item.GoodID, item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if variant == nil {
+			return nil, nil
+		}
+		demands[i] = &demand{variant: variant, quantity: item.Quantity}
+	}
+
+	warehouses, err := s.repo.ListWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return allocate(strategy, rankWarehouses(warehouses, req.Address), demands), nil
+}
+
+// demand - позиция заказа и вариант с остатками по складам
+type demand struct {
+	variant  *model.Variant
+	quantity int32
+}
+
+// stockKey - остаток варианта на складе
+type stockKey struct {
+	variantID   int64
+	warehouseID int64
+}
+
+// rankWarehouses упорядочивает склады для стратегии nearest: сначала обслуживающие регион
+// адреса доставки, затем остальные; внутри групп сохраняется порядок приоритета
+func rankWarehouses(warehouses []*model.Warehouse, address string) []*model.Warehouse {
+	address = strings.ToLower(address)
+	serves := func(warehouse *model.Warehouse) bool {
+		for _, region := range warehouse.Regions {
+			if address != "" && strings.Contains(address, strings.ToLower(region)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ranked := slices.Clone(warehouses)
+	slices.SortStableFunc(ranked, func(a, b *model.Warehouse) int {
+		switch servesA, servesB := serves(a), serves(b); {
+		case servesA && !servesB:
+			return -1
+		case servesB && !servesA:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return ranked
+}
+
+// allocate распределяет позиции по складам ranked стратегией strategy. Позиция может делиться между
+// складами, если ни на одном нет всего количества. Возвращает nil, если остатков не хватает
+func allocate(strategy string, ranked []*model.Warehouse, demands []*demand) []*model.Allocation {
+	stock := make(map[stockKey]int32)
+	for _, d := range demands {
+		for _, level := range d.variant.Warehouses {
+			stock[stockKey{d.variant.ID, level.WarehouseID}] = level.Stock
+		}
+	}
+
+	var allocations []*model.Allocation
+	remaining := make([]int32, len(demands))
+	// take забирает со склада сколько есть, но не больше остатка позиции i
+	take := func(i int, warehouse *model.Warehouse) {
+		key := stockKey{demands[i].variant.ID, warehouse.ID}
+		quantity := min(remaining[i], stock[key])
+		if quantity <= 0 {
+			return
+		}
+		stock[key] -= quantity
+		remaining[i] -= quantity
+		allocations = append(allocations, &model.Allocation{
+			GoodID:        demands[i].variant.GoodID,
+			VariantID:     demands[i].variant.ID,
+			WarehouseID:   warehouse.ID,
+			WarehouseCode: warehouse.Code,
+			Quantity:      quantity,
+		})
+	}
+
+	for i, d := range demands {
+		remaining[i] = d.quantity
+	}
+
+	switch strategy {
+	case model.FulfilmentNearest:
+		for i := range demands {
+			for _, warehouse := range ranked {
+				take(i, warehouse)
+			}
+		}
+	case model.FulfilmentMostStock:
+		for i, d := range demands {
+			byStock := slices.Clone(ranked)
+			slices.SortStableFunc(byStock, func(a, b *model.Warehouse) int {
+				return int(stock[stockKey{d.variant.ID, b.ID}]) - int(stock[stockKey{d.variant.ID, a.ID}])
+			})
+			for _, warehouse := range byStock {
+				take(i, warehouse)
+			}
+		}
+	case model.FulfilmentFewestSplits:
+		// Жадно: каждый следующий склад покрывает больше всего оставшихся единиц заказа.
+		// Склад, на котором есть весь заказ, выбирается первым и остается единственным
+		candidates := slices.Clone(ranked)
+		for slices.ContainsFunc(remaining, func(quantity int32) bool { return quantity > 0 }) {
+			best, bestCovered := -1, int32(0)
+			for c, warehouse := range candidates {
+				var covered int32
+				for i, d := range demands {
+					covered += min(remaining[i], stock[stockKey{d.variant.ID, warehouse.ID}])
+				}
+				if covered > bestCovered {
+					best, bestCovered = c, covered
+				}
+			}
+			if best < 0 {
+				break
+			}
+			for i := range demands {
+				take(i, candidates[best])
+			}
+			candidates = slices.Delete(candidates, best, best+1)
+		}
+	}
+
+	if slices.ContainsFunc(remaining, func(quantity int32) bool { return quantity > 0 }) {
+		return nil
+	}
+	return allocations
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stockedVariant - вариант, весь остаток которого лежит на складе 1
+func stockedVariant(id, goodID int64, stock int32) *model.Variant {
+	return &model.Variant{
+		ID:         id,
+		GoodID:     goodID,
+		Stock:      stock,
+		Warehouses: []*model.WarehouseStock{{WarehouseID: 1, WarehouseCode: "main", Stock: stock}},
+	}
+}
+
+// allocationSummary сводит распределение к "склад/вариант" -> количество
+func allocationSummary(allocations []*model.Allocation) map[string]int32 {
+	summary := make(map[string]int32)
+	for _, allocation := range allocations {
+		summary[fmt.Sprintf("%s/%d", allocation.WarehouseCode, allocation.VariantID)] += allocation.Quantity
+	}
+	return summary
+}
+
+func TestAllocate(t *testing.T) {
+	moscow := &model.Warehouse{ID: 1, Code: "msk", Regions: []string{"Москва"}}
+	spb := &model.Warehouse{ID: 2, Code: "spb", Regions: []string{"Санкт-Петербург"}, Priority: 1}
+	warehouses := []*model.Warehouse{moscow, spb}
+
+	variant := func(id int64, msk, spb int32) *model.Variant {
+		return &model.Variant{ID: id, GoodID: id, Warehouses: []*model.WarehouseStock{
+			{WarehouseID: 1, Stock: msk},
+			{WarehouseID: 2, Stock: spb},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		address  string
+		demands  []*demand
+		want     map[string]int32
+	}{
+		{
+			name:     "nearest prefers warehouse serving the address",
+			strategy: model.FulfilmentNearest,
+			address:  "г. Санкт-Петербург, Невский пр., 1",
+			demands:  []*demand{{variant: variant(1, 10, 10), quantity: 3}},
+			want:     map[string]int32{"spb/1": 3},
+		},
+		{
+			name:     "nearest falls back to priority without a region match",
+			strategy: model.FulfilmentNearest,
+			address:  "Казань",
+			demands:  []*demand{{variant: variant(1, 10, 10), quantity: 3}},
+			want:     map[string]int32{"msk/1": 3},
+		},
+		{
+			name:     "nearest splits an item when the closest warehouse runs short",
+			strategy: model.FulfilmentNearest,
+			address:  "Санкт-Петербург",
+			demands:  []*demand{{variant: variant(1, 10, 2), quantity: 5}},
+			want:     map[string]int32{"spb/1": 2, "msk/1": 3},
+		},
+		{
+			name:     "most stock picks the fullest warehouse per item",
+			strategy: model.FulfilmentMostStock,
+			demands:  []*demand{{variant: variant(1, 4, 9), quantity: 3}, {variant: variant(2, 8, 1), quantity: 3}},
+			want:     map[string]int32{"spb/1": 3, "msk/2": 3},
+		},
+		{
+			name:     "fewest splits ships the whole order from one warehouse",
+			strategy: model.FulfilmentFewestSplits,
+			address:  "Москва",
+			demands:  []*demand{{variant: variant(1, 5, 5), quantity: 2}, {variant: variant(2, 0, 5), quantity: 2}},
+			want:     map[string]int32{"spb/1": 2, "spb/2": 2},
+		},
+		{
+			name:     "fewest splits uses a second warehouse only for the rest",
+			strategy: model.FulfilmentFewestSplits,
+			demands:  []*demand{{variant: variant(1, 5, 0), quantity: 2}, {variant: variant(2, 1, 5), quantity: 2}},
+			want:     map[string]int32{"msk/1": 2, "msk/2": 1, "spb/2": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocations := allocate(tt.strategy, rankWarehouses(warehouses, tt.address), tt.demands)
+			require.NotNil(t, allocations)
+			assert.Equal(t, tt.want, allocationSummary(allocations))
+		})
+	}
+
+	for _, strategy := range []string{model.FulfilmentNearest, model.FulfilmentMostStock, model.FulfilmentFewestSplits} {
+		allocations := allocate(strategy, warehouses, []*demand{{variant: variant(1, 2, 2), quantity: 5}})
+		assert.Nil(t, allocations, strategy)
+	}
+}
+
+func TestReserveOrder_TwoWarehouses(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	spb, err := s.CreateWarehouse(ctx, &model.CreateWarehouseRequest{
+		Code:     "spb",
+		Name:     "Склад в Петербурге",
+		Regions:  []string{" Санкт-Петербург ", ""},
+		Priority: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Санкт-Петербург"}, spb.Regions)
+
+	// Начальный остаток поступает на основной склад, поступление на второй склад - через журнал
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Сенча", Price: 500, Stock: 4})
+	require.NoError(t, err)
+	_, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{GoodID: good.ID, Type: model.MovementReceipt, Quantity: 6})
+	assert.ErrorIs(t, err, ErrWarehouseRequired)
+	_, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{GoodID: good.ID, WarehouseID: 42, Type: model.MovementReceipt, Quantity: 6})
+	assert.ErrorIs(t, err, ErrWarehouseNotFound)
+	_, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{GoodID: good.ID, WarehouseID: spb.ID, Type: model.MovementReceipt, Quantity: 6})
+	require.NoError(t, err)
+
+	availability, err := s.CheckStock(ctx, good.ID, 0, 10)
+	require.NoError(t, err)
+	assert.True(t, availability.Available)
+	assert.Equal(t, int32(10), availability.Stock)
+	require.Len(t, availability.Warehouses, 2)
+	assert.Equal(t, int32(4), availability.Warehouses[0].Stock)
+	assert.Equal(t, int32(6), availability.Warehouses[1].Stock)
+
+	allocations, err := s.ReserveOrder(ctx, &model.ReserveOrderRequest{
+		OrderID: 1,
+		Items:   []*model.ReservationItem{{GoodID: good.ID, Quantity: 3}},
+		Address: "Санкт-Петербург, ул. Садовая, 5",
+	})
+	require.NoError(t, err)
+	require.Len(t, allocations, 1)
+	assert.Equal(t, "spb", allocations[0].WarehouseCode)
+
+	// Остаток в Петербурге - 3, на основном складе - 4: заказ на 5 делится
+	allocations, err = s.ReserveOrder(ctx, &model.ReserveOrderRequest{
+		OrderID:  2,
+		Items:    []*model.ReservationItem{{GoodID: good.ID, Quantity: 5}},
+		Strategy: model.FulfilmentMostStock,
+	})
+	require.NoError(t, err)
+	assert.Len(t, allocations, 2)
+
+	allocations, err = s.ReserveOrder(ctx, &model.ReserveOrderRequest{OrderID: 3, Items: []*model.ReservationItem{{GoodID: good.ID, Quantity: 5}}})
+	require.NoError(t, err)
+	assert.Nil(t, allocations)
+
+	// Снятый резерв возвращается на тот склад, с которого был взят
+	_, err = s.ReleaseStock(ctx, 2)
+	require.NoError(t, err)
+	availability, err = s.CheckStock(ctx, good.ID, 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), availability.Warehouses[0].Stock)
+	assert.Equal(t, int32(3), availability.Warehouses[1].Stock)
+
+	discrepancies, err := s.VerifyStock(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
+
+func TestReserveOrder_Validation(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	_, err := s.ReserveOrder(ctx, &model.ReserveOrderRequest{OrderID: 1})
+	assert.ErrorIs(t, err, ErrInvalidReservation)
+	_, err = s.ReserveOrder(ctx, &model.ReserveOrderRequest{OrderID: 1, Items: []*model.ReservationItem{{GoodID: 1, Quantity: 0}}})
+	assert.ErrorIs(t, err, ErrInvalidReservation)
+	_, err = s.ReserveOrder(ctx, &model.ReserveOrderRequest{OrderID: 1, Items: []*model.ReservationItem{{GoodID: 1, Quantity: 1}}, Strategy: "cheapest"})
+	assert.ErrorIs(t, err, ErrInvalidStrategy)
+
+	s.SetFulfilmentStrategy("cheapest")
+	_, err = s.ReserveOrder(ctx, &model.ReserveOrderRequest{OrderID: 1, Items: []*model.ReservationItem{{GoodID: 1, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrInvalidStrategy)
+}
+
+func TestReserveOrder_RetriesWhenStockChanges(t *testing.T) {
+	mockRepo := new(MockRepository)
+	s := New(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetVariant", ctx, int64(11)).Return(stockedVariant(11, 1, 5), nil)
+	mockRepo.On("ListWarehouses", ctx).Return([]*model.Warehouse{{ID: 1, Code: "main"}}, nil)
+	mockRepo.On("ReserveAllocations", ctx, int64(7), mock.Anything).Return(ErrInsufficientStock).Once()
+	mockRepo.On("ReserveAllocations", ctx, int64(7), mock.Anything).Return(nil).Once()
+
+	allocations, err := s.ReserveOrder(ctx, &model.ReserveOrderRequest{OrderID: 7, Items: []*model.ReservationItem{{VariantID: 11, Quantity: 2}}})
+	require.NoError(t, err)
+	assert.Len(t, allocations, 1)
+	mockRepo.AssertNumberOfCalls(t, "ReserveAllocations", 2)
+
+	// После reserveAttempts неудач заказ считается не зарезервированным
+	mockRepo.On("ReserveAllocations", ctx, int64(8), mock.Anything).Return(ErrInsufficientStock)
+	allocations, err = s.ReserveOrder(ctx, &model.ReserveOrderRequest{OrderID: 8, Items: []*model.ReservationItem{{VariantID: 11, Quantity: 2}}})
+	require.NoError(t, err)
+	assert.Nil(t, allocations)
+	mockRepo.AssertNumberOfCalls(t, "ReserveAllocations", 2+reserveAttempts)
+}
+
+func TestWarehouses(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	_, err := s.CreateWarehouse(ctx, &model.CreateWarehouseRequest{Code: "Склад", Name: "Склад"})
+	assert.ErrorIs(t, err, ErrInvalidWarehouse)
+	_, err = s.CreateWarehouse(ctx, &model.CreateWarehouseRequest{Code: "spb"})
+	assert.ErrorIs(t, err, ErrInvalidWarehouse)
+	_, err = s.CreateWarehouse(ctx, &model.CreateWarehouseRequest{Code: "main", Name: "Еще один основной"})
+	assert.ErrorIs(t, err, ErrWarehouseCodeTaken)
+
+	spb, err := s.CreateWarehouse(ctx, &model.CreateWarehouseRequest{Code: "spb", Name: "Петербург", Priority: 5})
+	require.NoError(t, err)
+
+	// Новый приоритет ставит склад первым: теперь он склад по умолчанию
+	updated, err := s.UpdateWarehouse(ctx, spb.ID, &model.UpdateWarehouseRequest{Name: "Петербург", Priority: -1})
+	require.NoError(t, err)
+	assert.Equal(t, "spb", updated.Code)
+
+	warehouses, err := s.ListWarehouses(ctx)
+	require.NoError(t, err)
+	require.Len(t, warehouses, 2)
+	assert.Equal(t, "spb", warehouses[0].Code)
+
+	_, err = s.UpdateWarehouse(ctx, 42, &model.UpdateWarehouseRequest{Name: "Нет такого"})
+	assert.ErrorIs(t, err, ErrWarehouseNotFound)
+	_, err = s.UpdateWarehouse(ctx, spb.ID, &model.UpdateWarehouseRequest{})
+	assert.ErrorIs(t, err, ErrInvalidWarehouse)
+}
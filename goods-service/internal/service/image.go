@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/imaging"
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+)
+
+// MaxImageSize - наибольший размер загружаемого файла изображения
+const MaxImageSize = 10 << 20
+
+// imageExtensions - расширения исходников по типу содержимого
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// UploadGoodImage сохраняет исходник, копию для карточки и миниатюру в хранилище и добавляет
+// изображение в конец галереи товара
+func (s *GoodsService) UploadGoodImage(ctx context.Context, goodID int64, data []byte) (*model.GoodImage, error) {
+	if len(data) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+	good, err := s.repo.GetGood(ctx, goodID)
+	if err != nil {
+		return nil, err
+	}
+	if good == nil {
+		return nil, ErrGoodNotFound
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+	img, err := imaging.Decode(data)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return nil, ErrImageTooLarge
+	}
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	// Случайный каталог: новая загрузка никогда не перезаписывает закешированные клиентами файлы
+	prefix := fmt.Sprintf("goods/%d/%s", goodID, randomHex(16))
+	image := &model.GoodImage{
+		GoodID:       goodID,
+		OriginalKey:  prefix + "/original" + extension,
+		MediumKey:    prefix + "/medium" + img.Extension(),
+		ThumbnailKey: prefix + "/thumbnail" + img.Extension(),
+		ContentType:  contentType,
+		Width:        int32(img.Width()),
+		Height:       int32(img.Height()),
+	}
+
+	if err := s.storeImage(ctx, image, data, img); err != nil {
+		s.removeImageFiles(ctx, image)
+		return nil, err
+	}
+	if err := s.repo.CreateGoodImage(ctx, image); err != nil {
+		s.removeImageFiles(ctx, image)
+		return nil, err
+	}
+	return image, nil
+}
+
+// storeImage кладет в хранилище исходник и уменьшенные копии
+func (s *GoodsService) storeImage(ctx context.Context, image *model.GoodImage, data []byte, img *imaging.Image) error {
+	if err := s.storage.Put(ctx, image.OriginalKey, image.ContentType, data); err != nil {
+		return err
+	}
+	for key, size := range map[string]int{image.MediumKey: imaging.MediumSize, image.ThumbnailKey: imaging.ThumbnailSize} {
+		resized, err := img.Fit(size).Encode()
+		if err != nil {
+			return err
+		}
+		if err := s.storage.Put(ctx, key, img.ContentType(), resized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteGoodImage удаляет изображение из галереи и его файлы из хранилища
+func (s *GoodsService) DeleteGoodImage(ctx context.Context, id int64) error {
+	image, err := s.repo.GetGoodImage(ctx, id)
+	if err != nil {
+		return err
+	}
+	if image == nil {
+		return ErrImageNotFound
+	}
+	if err := s.repo.DeleteGoodImage(ctx, id); err != nil {
+		return err
+	}
+	s.removeImageFiles(ctx, image)
+	return nil
+}
+
+// ReorderGoodImages задает порядок галереи. imageIDs должен перечислять все изображения товара ровно по разу
+func (s *GoodsService) ReorderGoodImages(ctx context.Context, goodID int64, imageIDs []int64) (*model.Good, error) {
+	good, err := s.repo.GetGood(ctx, goodID)
+	if err != nil {
+		return nil, err
+	}
+	if good == nil {
+		return nil, ErrGoodNotFound
+	}
+
+	current := make([]int64, len(good.Images))
+	for i, image := range good.Images {
+		current[i] = image.ID
+	}
+	requested := slices.Clone(imageIDs)
+	slices.Sort(current)
+	slices.Sort(requested)
+	if !slices.Equal(current, requested) {
+		return nil, fmt.Errorf("%w: expected each of the good's %d images exactly once", ErrInvalidImageOrder, len(current))
+	}
+
+	if err := s.repo.SetGoodImageOrder(ctx, goodID, imageIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGood(ctx, goodID)
+}
+
+// ImageURL возвращает публичный адрес файла изображения
+func (s *GoodsService) ImageURL(key string) string {
+	return s.storage.URL(key)
+}
+
+// removeImageFiles удаляет файлы изображения. Ошибка только логируется: запись в БД уже удалена
+// или не создана, а оставшийся файл ни на что не ссылается
+func (s *GoodsService) removeImageFiles(ctx context.Context, image *model.GoodImage) {
+	for _, key := range []string{image.OriginalKey, image.MediumKey, image.ThumbnailKey} {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			logger.Error("Failed to delete image file", "key", key, "error", err)
+		}
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/che1nov/tea-shop/goods-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestUploadGoodImage(t *testing.T) {
+	files := storage.NewMemory("http://cdn.test/media")
+	s := NewWithStorage(repository.NewMemory(), files)
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Пуэр", Price: 1200, Stock: 2})
+	require.NoError(t, err)
+
+	first, err := s.UploadGoodImage(ctx, good.ID, pngImage(t, 1600, 1200))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), first.Position)
+	assert.Equal(t, "image/png", first.ContentType)
+	assert.Equal(t, int32(1600), first.Width)
+	assert.Equal(t, int32(1200), first.Height)
+	assert.Equal(t, "http://cdn.test/media/"+first.ThumbnailKey, s.ImageURL(first.ThumbnailKey))
+
+	thumbnail, err := png.DecodeConfig(bytes.NewReader(files.Get(first.ThumbnailKey)))
+	require.NoError(t, err)
+	assert.Equal(t, 200, thumbnail.Width)
+	assert.Equal(t, 150, thumbnail.Height)
+	medium, err := png.DecodeConfig(bytes.NewReader(files.Get(first.MediumKey)))
+	require.NoError(t, err)
+	assert.Equal(t, 800, medium.Width)
+	assert.NotEmpty(t, files.Get(first.OriginalKey))
+
+	second, err := s.UploadGoodImage(ctx, good.ID, pngImage(t, 100, 100))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), second.Position)
+
+	stored, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Images, 2)
+	assert.Equal(t, first.ID, stored.Images[0].ID)
+
+	_, err = s.UploadGoodImage(ctx, good.ID, []byte("not an image"))
+	assert.ErrorIs(t, err, ErrUnsupportedImage)
+	_, err = s.UploadGoodImage(ctx, good.ID, make([]byte, MaxImageSize+1))
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+	_, err = s.UploadGoodImage(ctx, 42, pngImage(t, 10, 10))
+	assert.ErrorIs(t, err, ErrGoodNotFound)
+	assert.Equal(t, 6, files.Len())
+}
+
+func TestReorderAndDeleteGoodImages(t *testing.T) {
+	files := storage.NewMemory("/media")
+	s := NewWithStorage(repository.NewMemory(), files)
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Пуэр", Price: 1200, Stock: 2})
+	require.NoError(t, err)
+	var ids []int64
+	for range 3 {
+		image, err := s.UploadGoodImage(ctx, good.ID, pngImage(t, 10, 10))
+		require.NoError(t, err)
+		ids = append(ids, image.ID)
+	}
+
+	reordered, err := s.ReorderGoodImages(ctx, good.ID, []int64{ids[2], ids[0], ids[1]})
+	require.NoError(t, err)
+	require.Len(t, reordered.Images, 3)
+	assert.Equal(t, ids[2], reordered.Images[0].ID)
+	assert.Equal(t, int32(1), reordered.Images[0].Position)
+	assert.Equal(t, ids[1], reordered.Images[2].ID)
+
+	for _, order := range [][]int64{{ids[0], ids[1]}, {ids[0], ids[0], ids[1]}, {ids[0], ids[1], 99}} {
+		_, err := s.ReorderGoodImages(ctx, good.ID, order)
+		assert.ErrorIs(t, err, ErrInvalidImageOrder)
+	}
+
+	require.NoError(t, s.DeleteGoodImage(ctx, ids[2]))
+	assert.ErrorIs(t, s.DeleteGoodImage(ctx, ids[2]), ErrImageNotFound)
+	assert.Equal(t, 6, files.Len())
+
+	// Архивный товар сохраняет изображения: они вернутся в каталог вместе с товаром
+	require.NoError(t, s.DeleteGood(ctx, good.ID))
+	assert.Equal(t, 6, files.Len())
+}
+
+// failingStorage отказывает в записи после limit успешных Put
+type failingStorage struct {
+	*storage.Memory
+	limit int
+}
+
+func (f *failingStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	if f.limit == 0 {
+		return errors.New("storage is unavailable")
+	}
+	f.limit--
+	return f.Memory.Put(ctx, key, contentType, data)
+}
+
+func TestUploadGoodImage_StorageFailure(t *testing.T) {
+	files := &failingStorage{Memory: storage.NewMemory("/media"), limit: 2}
+	s := NewWithStorage(repository.NewMemory(), files)
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Пуэр", Price: 1200, Stock: 2})
+	require.NoError(t, err)
+
+	_, err = s.UploadGoodImage(ctx, good.ID, pngImage(t, 10, 10))
+	require.Error(t, err)
+	// Уже записанные файлы удаляются, в галерею ничего не попадает
+	assert.Zero(t, files.Len())
+	stored, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Images)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/metrics"
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+const (
+	// maxSearchQueryLength - ограничение длины запроса в символах
+	maxSearchQueryLength = 200
+	defaultSearchLimit   = 20
+	maxSearchLimit       = 100
+	// fuzzySnippetWords - сколько слов описания показывать, если подсветки нет
+	fuzzySnippetWords = 20
+)
+
+// highlightTags возвращает разметку подсветки после экранирования остального текста
+var highlightTags = strings.NewReplacer("&lt;b&gt;", "<b>", "&lt;/b&gt;", "</b>")
+
+// SearchGoods ищет товары по названию и описанию с учётом морфологии. Если точных совпадений нет,
+// ищет названия, похожие по написанию, чтобы запрос с опечаткой ("пуер", "сенчя") не оставался без ответа
+func (s *GoodsService) SearchGoods(ctx context.Context, query string, limit, offset int32) (*model.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return nil, ErrSearchQueryTooLong
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+	offset = max(offset, 0)
+
+	result := &model.SearchResult{}
+	var err error
+	result.Hits, result.Total, err = s.repo.SearchGoods(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Total == 0 {
+		result.Hits, result.Total, err = s.repo.SearchGoodsFuzzy(ctx, query, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		result.Fuzzy = result.Total > 0
+	}
+
+	for _, hit := range result.Hits {
+		// Без подсветки показываются название и начало описания
+		if hit.NameHighlight == "" {
+			hit.NameHighlight = hit.Good.Name
+		}
+		if hit.Snippet == "" {
+			hit.Snippet = strings.Join(firstN(strings.Fields(hit.Good.Description), fuzzySnippetWords), " ")
+		}
+		hit.NameHighlight = escapeHighlight(hit.NameHighlight)
+		hit.Snippet = escapeHighlight(hit.Snippet)
+	}
+
+	switch {
+	case result.Total == 0:
+		metrics.Searches.WithLabelValues("empty").Inc()
+	case result.Fuzzy:
+		metrics.Searches.WithLabelValues("fuzzy").Inc()
+	default:
+		metrics.Searches.WithLabelValues("found").Inc()
+	}
+	return result, nil
+}
+
+// escapeHighlight экранирует текст товара для HTML, сохраняя только теги подсветки
+func escapeHighlight(text string) string {
+	return highlightTags.Replace(html.EscapeString(text))
+}
+
+func firstN(words []string, n int) []string {
+	if len(words) > n {
+		return words[:n]
+	}
+	return words
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchGoods_Validation(t *testing.T) {
+	service := New(new(MockRepository))
+	ctx := context.Background()
+
+	_, err := service.SearchGoods(ctx, "   ", 10, 0)
+	assert.ErrorIs(t, err, ErrEmptySearchQuery)
+
+	_, err = service.SearchGoods(ctx, strings.Repeat("чай", 100), 10, 0)
+	assert.ErrorIs(t, err, ErrSearchQueryTooLong)
+}
+
+func TestSearchGoods_EscapesHighlight(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	good := &model.Good{ID: 1, Name: "Пуэр <script>", Description: "Шу пуэр & габа"}
+	mockRepo.On("SearchGoods", ctx, "пуэр", int32(defaultSearchLimit), int32(0)).Return([]*model.SearchHit{{
+		Good:          good,
+		Rank:          0.5,
+		NameHighlight: "<b>Пуэр</b> <script>",
+		Snippet:       "Шу <b>пуэр</b> & габа",
+	}}, int32(1), nil)
+
+	result, err := service.SearchGoods(ctx, " пуэр ", 0, 0)
+
+	require.NoError(t, err)
+	assert.False(t, result.Fuzzy)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "<b>Пуэр</b> &lt;script&gt;", result.Hits[0].NameHighlight)
+	assert.Equal(t, "Шу <b>пуэр</b> &amp; габа", result.Hits[0].Snippet)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchGoods_FallsBackToFuzzy(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	good := &model.Good{ID: 1, Name: "Шу Пуэр", Description: "Выдержанный чай из Юньнани"}
+	mockRepo.On("SearchGoods", ctx, "пуер", int32(maxSearchLimit), int32(0)).Return(nil, int32(0), nil)
+	mockRepo.On("SearchGoodsFuzzy", ctx, "пуер", int32(maxSearchLimit), int32(0)).
+		Return([]*model.SearchHit{{Good: good, Rank: 0.4}}, int32(1), nil)
+
+	result, err := service.SearchGoods(ctx, "пуер", 500, 0)
+
+	require.NoError(t, err)
+	assert.True(t, result.Fuzzy)
+	assert.Equal(t, int32(1), result.Total)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "Шу Пуэр", result.Hits[0].NameHighlight)
+	assert.Equal(t, "Выдержанный чай из Юньнани", result.Hits[0].Snippet)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchGoods_NothingFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("SearchGoods", ctx, "кофе", int32(10), int32(0)).Return(nil, int32(0), nil)
+	mockRepo.On("SearchGoodsFuzzy", ctx, "кофе", int32(10), int32(0)).Return(nil, int32(0), nil)
+
+	result, err := service.SearchGoods(ctx, "кофе", 10, 0)
+
+	require.NoError(t, err)
+	assert.False(t, result.Fuzzy)
+	assert.Empty(t, result.Hits)
+	mockRepo.AssertExpectations(t)
+}
@@ -2,40 +2,161 @@ package service
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"slices"
+	"sync"
 
 	"github.com/che1nov/tea-shop/goods-service/internal/model"
 	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/che1nov/tea-shop/goods-service/internal/storage"
+)
+
+var (
+	ErrGoodNotFound           = errors.New("good not found")
+	ErrGoodReserved           = repository.ErrGoodReserved
+	ErrVariantReserved        = repository.ErrVariantReserved
+	ErrGoodArchived           = repository.ErrGoodArchived
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrParentNotFound         = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("category cannot be moved under itself or its subcategory")
+	ErrCategoryHasChildren    = errors.New("category has subcategories")
+	ErrCategoryNameRequired   = errors.New("category name is required")
+	ErrInvalidSlug            = errors.New("slug must contain only lowercase latin letters, digits and single hyphens")
+	ErrSlugTaken              = repository.ErrSlugTaken
+	ErrEmptySearchQuery       = errors.New("search query is empty")
+	ErrSearchQueryTooLong     = errors.New("search query is too long")
+	ErrInvalidPriceRange      = errors.New("price range is invalid")
+	ErrInvalidSort            = errors.New("unknown sort order")
+	ErrVariantNotFound        = errors.New("variant not found")
+	ErrVariantRequired        = errors.New("good has several variants, variant_id is required")
+	ErrLastVariant            = errors.New("good must have at least one variant")
+	ErrInvalidVariant         = errors.New("variant price must be positive and stock must not be negative")
+	ErrSKUTaken               = repository.ErrSKUTaken
+	ErrInvalidAttributeSchema = errors.New("attribute schema is invalid")
+	ErrInvalidAttribute       = errors.New("attribute value is invalid")
+	ErrInvalidAttributeFilter = errors.New("attribute filter is invalid")
+	ErrImageNotFound          = errors.New("image not found")
+	ErrUnsupportedImage       = errors.New("image must be a JPEG, PNG or GIF file")
+	ErrImageTooLarge          = errors.New("image is too large")
+	ErrInvalidImageOrder      = errors.New("image order is invalid")
+	ErrInsufficientStock      = repository.ErrInsufficientStock
+	ErrInvalidMovement        = errors.New("stock movement is invalid")
+	ErrWarehouseNotFound      = errors.New("warehouse not found")
+	ErrWarehouseRequired      = errors.New("there are several warehouses, warehouse_id is required")
+	ErrWarehouseCodeTaken     = repository.ErrWarehouseCodeTaken
+	ErrInvalidWarehouse       = errors.New("warehouse code must be a slug and name is required")
+	ErrInvalidStrategy        = errors.New("unknown fulfilment strategy")
+	ErrInvalidReservation     = errors.New("reservation must contain items with positive quantity")
+	ErrUnsupportedCatalog     = errors.New("catalog format must be csv or xlsx")
+	ErrInvalidCatalogFile     = errors.New("catalog file is invalid")
+	ErrCatalogFileTooLarge    = errors.New("catalog file is too large")
+	ErrImportJobNotFound      = errors.New("import job not found")
 )
 
 // GoodsServiceInterface определяет методы сервиса
 type GoodsServiceInterface interface {
 	CreateGood(ctx context.Context, req *model.CreateGoodRequest) (*model.Good, error)
 	GetGood(ctx context.Context, id int64) (*model.Good, error)
-	ListGoods(ctx context.Context, limit, offset int32) ([]*model.Good, error)
+	ListGoods(ctx context.Context, filter *model.GoodsFilter) (*model.GoodsPage, error)
 	UpdateGood(ctx context.Context, id int64, req *model.UpdateGoodRequest) (*model.Good, error)
 	DeleteGood(ctx context.Context, id int64) error
-	GetTotalGoods(ctx context.Context) (int32, error)
-	CheckStock(ctx context.Context, goodID int64, quantity int32) (bool, error)
-	ReserveStock(ctx context.Context, goodID int64, quantity int32, orderID int64) (bool, error)
+	RestoreGood(ctx context.Context, id int64) (*model.Good, error)
+	CheckStock(ctx context.Context, goodID, variantID int64, quantity int32) (*model.StockAvailability, error)
+	ReserveStock(ctx context.Context, goodID, variantID int64, quantity int32, orderID int64) (bool, error)
+	ReserveOrder(ctx context.Context, req *model.ReserveOrderRequest) ([]*model.Allocation, error)
+	SearchGoods(ctx context.Context, query string, limit, offset int32) (*model.SearchResult, error)
+
+	CreateCategory(ctx context.Context, req *model.CreateCategoryRequest) (*model.Category, error)
+	GetCategory(ctx context.Context, id int64) (*model.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error)
+	ListCategories(ctx context.Context) ([]*model.Category, error)
+	UpdateCategory(ctx context.Context, id int64, req *model.UpdateCategoryRequest) (*model.Category, error)
+	DeleteCategory(ctx context.Context, id int64) error
+	SetGoodCategories(ctx context.Context, goodID int64, categoryIDs []int64) (*model.Good, error)
+
+	CreateVariant(ctx context.Context, goodID int64, req *model.CreateVariantRequest) (*model.Variant, error)
+	UpdateVariant(ctx context.Context, id int64, req *model.UpdateVariantRequest) (*model.Variant, error)
+	DeleteVariant(ctx context.Context, id int64) error
+
+	UploadGoodImage(ctx context.Context, goodID int64, data []byte) (*model.GoodImage, error)
+	DeleteGoodImage(ctx context.Context, id int64) error
+	ReorderGoodImages(ctx context.Context, goodID int64, imageIDs []int64) (*model.Good, error)
+	ImageURL(key string) string
+
+	AdjustStock(ctx context.Context, req *model.StockAdjustmentRequest) (*model.StockMovement, error)
+	ReleaseStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error)
+	CommitStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error)
+	ListStockMovements(ctx context.Context, goodID, variantID int64, limit, offset int32) (*model.StockMovementsPage, error)
+	VerifyStock(ctx context.Context) ([]*model.StockDiscrepancy, error)
+
+	CreateWarehouse(ctx context.Context, req *model.CreateWarehouseRequest) (*model.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, id int64, req *model.UpdateWarehouseRequest) (*model.Warehouse, error)
+	ListWarehouses(ctx context.Context) ([]*model.Warehouse, error)
+
+	ImportCatalog(ctx context.Context, format string, data []byte, dryRun bool) (*model.ImportJob, error)
+	GetImportJob(ctx context.Context, id int64) (*model.ImportJob, error)
+	ExportCatalog(ctx context.Context, format string) ([]byte, error)
 }
 
 type GoodsService struct {
-	repo repository.GoodsRepositoryInterface
+	repo       repository.GoodsRepositoryInterface
+	storage    storage.Storage
+	fulfilment string
+
+	// imports - выполняющиеся задания импорта; stopImports прерывает их при остановке сервиса
+	imports     sync.WaitGroup
+	importsCtx  context.Context
+	stopImports context.CancelFunc
 }
 
+// New создает сервис, хранящий изображения в памяти
 func New(repo repository.GoodsRepositoryInterface) *GoodsService {
+	return NewWithStorage(repo, storage.NewMemory("/media"))
+}
+
+// NewWithStorage создает сервис с хранилищем изображений store
+func NewWithStorage(repo repository.GoodsRepositoryInterface, store storage.Storage) *GoodsService {
+	importsCtx, stopImports := context.WithCancel(context.Background())
 	return &GoodsService{
-		repo: repo,
+		repo:        repo,
+		storage:     store,
+		fulfilment:  model.FulfilmentNearest,
+		importsCtx:  importsCtx,
+		stopImports: stopImports,
 	}
 }
 
+// Close прерывает выполняющиеся задания импорта и ждет, пока они сохранят свой статус
+func (s *GoodsService) Close() error {
+	s.stopImports()
+	s.imports.Wait()
+	return nil
+}
+
 func (s *GoodsService) CreateGood(ctx context.Context, req *model.CreateGoodRequest) (*model.Good, error) {
 	good := &model.Good{
+		SKU:         req.SKU,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
 		Stock:       req.Stock,
+		CategoryIDs: req.CategoryIDs,
+	}
+
+	// Атрибуты проверяются по схеме категорий товара, в том числе обязательные
+	if len(req.CategoryIDs) > 0 || len(req.Attributes) > 0 {
+		categories, err := s.repo.ListCategories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := goodAttributeSchema(categories, req.CategoryIDs)
+		if err != nil {
+			return nil, err
+		}
+		good.Attributes, err = validateAttributes(schema, req.Attributes)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.repo.CreateGood(ctx, good); err != nil {
@@ -49,8 +170,58 @@ func (s *GoodsService) GetGood(ctx context.Context, id int64) (*model.Good, erro
 	return s.repo.GetGood(ctx, id)
 }
 
-func (s *GoodsService) ListGoods(ctx context.Context, limit, offset int32) ([]*model.Good, error) {
-	return s.repo.ListGoods(ctx, limit, offset)
+// priceFacetBounds - границы диапазонов цены в фасетах каталога
+var priceFacetBounds = []float64{500, 1000, 2000, 5000}
+
+// ListGoods возвращает страницу товаров, общее количество подходящих под фильтр и, по запросу, фасеты.
+// Фильтр по категориям включает товары всех их подкатегорий
+func (s *GoodsService) ListGoods(ctx context.Context, filter *model.GoodsFilter) (*model.GoodsPage, error) {
+	if filter.MinPrice < 0 || filter.MaxPrice < 0 || (filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice) {
+		return nil, ErrInvalidPriceRange
+	}
+	switch filter.Sort {
+	case "", model.SortPriceAsc, model.SortPriceDesc, model.SortNewest, model.SortName, model.SortPopularity:
+	default:
+		return nil, ErrInvalidSort
+	}
+
+	filter.SubtreeCategoryIDs = nil
+	if len(filter.CategoryIDs) > 0 || len(filter.Attributes) > 0 {
+		categories, err := s.repo.ListCategories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := normalizeAttributeFilters(categories, filter.Attributes); err != nil {
+			return nil, err
+		}
+		for _, id := range filter.CategoryIDs {
+			ids := subtree(categories, id)
+			if ids == nil {
+				return nil, ErrCategoryNotFound
+			}
+			filter.SubtreeCategoryIDs = append(filter.SubtreeCategoryIDs, ids...)
+		}
+		slices.Sort(filter.SubtreeCategoryIDs)
+		filter.SubtreeCategoryIDs = slices.Compact(filter.SubtreeCategoryIDs)
+	}
+
+	goods, err := s.repo.ListGoods(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repo.GetTotalGoods(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.GoodsPage{Goods: goods, Total: total}
+
+	if filter.IncludeFacets {
+		page.Facets, err = s.repo.GoodsFacets(ctx, filter, priceFacetBounds)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
 }
 
 func (s *GoodsService) UpdateGood(ctx context.Context, id int64, req *model.UpdateGoodRequest) (*model.Good, error) {
@@ -69,51 +240,109 @@ func (s *GoodsService) UpdateGood(ctx context.Context, id int64, req *model.Upda
 	if req.Description != "" {
 		good.Description = req.Description
 	}
-	if req.Price > 0 {
-		good.Price = req.Price
-	}
-	if req.Stock >= 0 {
-		good.Stock = req.Stock
-	}
 	if req.SKU != "" {
 		good.SKU = req.SKU
 	}
+	if len(req.Attributes) > 0 {
+		categories, err := s.repo.ListCategories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := goodAttributeSchema(categories, good.CategoryIDs)
+		if err != nil {
+			return nil, err
+		}
+		good.Attributes, err = validateAttributes(schema, req.Attributes)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.repo.UpdateGood(ctx, good); err != nil {
 		return nil, err
 	}
 
+	// Цена принадлежит вариантам: у товара с одним вариантом меняем ее в нём,
+	// у товара с несколькими вариантами она задается через UpdateVariant.
+	// Остаток здесь не меняется - только через AdjustStock
+	if len(good.Variants) == 1 && req.Price > 0 {
+		variant := good.Variants[0]
+		variant.Price = req.Price
+		if err := s.repo.UpdateVariant(ctx, variant, 0); err != nil {
+			return nil, err
+		}
+		good.Price = variant.Price
+		good.Stock = variant.Stock
+	}
+
 	return good, nil
 }
 
+// DeleteGood убирает товар в архив: он пропадает из каталога и поиска, но остается доступен
+// по id для истории заказов. Товар с незавершенными резервированиями не архивируется
 func (s *GoodsService) DeleteGood(ctx context.Context, id int64) error {
-	return s.repo.DeleteGood(ctx, id)
+	good, err := s.repo.GetGood(ctx, id)
+	if err != nil {
+		return err
+	}
+	if good == nil {
+		return ErrGoodNotFound
+	}
+	return s.repo.ArchiveGood(ctx, id)
 }
 
-func (s *GoodsService) GetTotalGoods(ctx context.Context) (int32, error) {
-	return s.repo.GetTotalGoods(ctx)
+// RestoreGood возвращает архивный товар в каталог
+func (s *GoodsService) RestoreGood(ctx context.Context, id int64) (*model.Good, error) {
+	good, err := s.repo.GetGood(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if good == nil {
+		return nil, ErrGoodNotFound
+	}
+	if good.ArchivedAt.IsZero() {
+		return good, nil
+	}
+	if err := s.repo.RestoreGood(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGood(ctx, id)
 }
 
-func (s *GoodsService) CheckStock(ctx context.Context, goodID int64, quantity int32) (bool, error) {
-	good, err := s.repo.GetGood(ctx, goodID)
+// CheckStock сообщает, хватает ли остатка, и возвращает остаток всего и по складам
+func (s *GoodsService) CheckStock(ctx context.Context, goodID, variantID int64, quantity int32) (*model.StockAvailability, error) {
+	variant, err := s.resolveVariant(ctx, goodID, variantID)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	if good == nil {
-		return false, nil
+	if variant == nil {
+		return &model.StockAvailability{}, nil
+	}
+	// Архивный товар не продается, хотя его остаток числится на складах
+	good, err := s.repo.GetGood(ctx, variant.GoodID)
+	if err != nil {
+		return nil, err
+	}
+	if good == nil || !good.ArchivedAt.IsZero() {
+		return &model.StockAvailability{}, nil
 	}
 
-	return good.Stock >= quantity, nil
+	return &model.StockAvailability{
+		Available:  variant.Stock >= quantity,
+		Stock:      variant.Stock,
+		Warehouses: variant.Warehouses,
+	}, nil
 }
 
-func (s *GoodsService) ReserveStock(ctx context.Context, goodID int64, quantity int32, orderID int64) (bool, error) {
-	err := s.repo.ReserveStock(ctx, goodID, quantity, orderID)
-	if err == sql.ErrNoRows {
-		return false, nil
-	}
+// ReserveStock резервирует одну позицию стратегией по умолчанию
+func (s *GoodsService) ReserveStock(ctx context.Context, goodID, variantID int64, quantity int32, orderID int64) (bool, error) {
+	allocations, err := s.ReserveOrder(ctx, &model.ReserveOrderRequest{
+		OrderID: orderID,
+		Items:   []*model.ReservationItem{{GoodID: goodID, VariantID: variantID, Quantity: quantity}},
+	})
 	if err != nil {
 		return false, err
 	}
-	return true, nil
+	return allocations != nil, nil
 }
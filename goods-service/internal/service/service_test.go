@@ -2,9 +2,9 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/che1nov/tea-shop/goods-service/internal/model"
 	"github.com/stretchr/testify/assert"
@@ -32,24 +32,250 @@ func (m *MockRepository) GetGood(ctx context.Context, id int64) (*model.Good, er
 	return args.Get(0).(*model.Good), args.Error(1)
 }
 
-func (m *MockRepository) ListGoods(ctx context.Context, limit, offset int32) ([]*model.Good, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockRepository) ListGoods(ctx context.Context, filter *model.GoodsFilter) ([]*model.Good, error) {
+	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*model.Good), args.Error(1)
 }
 
-func (m *MockRepository) ReserveStock(ctx context.Context, goodID int64, quantity int32, orderID int64) error {
-	args := m.Called(ctx, goodID, quantity, orderID)
+func (m *MockRepository) ReserveAllocations(ctx context.Context, orderID int64, allocations []*model.Allocation) error {
+	args := m.Called(ctx, orderID, allocations)
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetTotalGoods(ctx context.Context) (int32, error) {
-	args := m.Called(ctx)
+func (m *MockRepository) UpdateGood(ctx context.Context, good *model.Good) error {
+	args := m.Called(ctx, good)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ArchiveGood(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RestoreGood(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetTotalGoods(ctx context.Context, filter *model.GoodsFilter) (int32, error) {
+	args := m.Called(ctx, filter)
 	return args.Get(0).(int32), args.Error(1)
 }
 
+func (m *MockRepository) GetVariant(ctx context.Context, id int64) (*model.Variant, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Variant), args.Error(1)
+}
+
+func (m *MockRepository) CreateVariant(ctx context.Context, variant *model.Variant) error {
+	args := m.Called(ctx, variant)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateVariant(ctx context.Context, variant *model.Variant, stockDelta int32) error {
+	args := m.Called(ctx, variant, stockDelta)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteVariant(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GoodsFacets(ctx context.Context, filter *model.GoodsFilter, priceBounds []float64) (*model.GoodsFacets, error) {
+	args := m.Called(ctx, filter, priceBounds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.GoodsFacets), args.Error(1)
+}
+
+func (m *MockRepository) SearchGoods(ctx context.Context, query string, limit, offset int32) ([]*model.SearchHit, int32, error) {
+	args := m.Called(ctx, query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int32), args.Error(2)
+	}
+	return args.Get(0).([]*model.SearchHit), args.Get(1).(int32), args.Error(2)
+}
+
+func (m *MockRepository) SearchGoodsFuzzy(ctx context.Context, query string, limit, offset int32) ([]*model.SearchHit, int32, error) {
+	args := m.Called(ctx, query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int32), args.Error(2)
+	}
+	return args.Get(0).([]*model.SearchHit), args.Get(1).(int32), args.Error(2)
+}
+
+func (m *MockRepository) CreateCategory(ctx context.Context, category *model.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetCategory(ctx context.Context, id int64) (*model.Category, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockRepository) GetCategoryBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	args := m.Called(ctx, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockRepository) ListCategories(ctx context.Context) ([]*model.Category, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Category), args.Error(1)
+}
+
+func (m *MockRepository) UpdateCategory(ctx context.Context, category *model.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteCategory(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetGoodCategories(ctx context.Context, goodID int64, categoryIDs []int64) error {
+	args := m.Called(ctx, goodID, categoryIDs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateGoodImage(ctx context.Context, image *model.GoodImage) error {
+	args := m.Called(ctx, image)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetGoodImage(ctx context.Context, id int64) (*model.GoodImage, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.GoodImage), args.Error(1)
+}
+
+func (m *MockRepository) DeleteGoodImage(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetGoodImageOrder(ctx context.Context, goodID int64, imageIDs []int64) error {
+	args := m.Called(ctx, goodID, imageIDs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AdjustStock(ctx context.Context, movement *model.StockMovement) error {
+	args := m.Called(ctx, movement)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReleaseStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StockMovement), args.Error(1)
+}
+
+func (m *MockRepository) CommitStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StockMovement), args.Error(1)
+}
+
+func (m *MockRepository) ListStockMovements(ctx context.Context, goodID, variantID int64, limit, offset int32) ([]*model.StockMovement, int32, error) {
+	args := m.Called(ctx, goodID, variantID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int32), args.Error(2)
+	}
+	return args.Get(0).([]*model.StockMovement), args.Get(1).(int32), args.Error(2)
+}
+
+func (m *MockRepository) StockDiscrepancies(ctx context.Context) ([]*model.StockDiscrepancy, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StockDiscrepancy), args.Error(1)
+}
+
+func (m *MockRepository) CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	args := m.Called(ctx, warehouse)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetWarehouse(ctx context.Context, id int64) (*model.Warehouse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Warehouse), args.Error(1)
+}
+
+func (m *MockRepository) UpdateWarehouse(ctx context.Context, warehouse *model.Warehouse) error {
+	args := m.Called(ctx, warehouse)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListWarehouses(ctx context.Context) ([]*model.Warehouse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Warehouse), args.Error(1)
+}
+
+func (m *MockRepository) GetGoodBySKU(ctx context.Context, sku string) (*model.Good, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Good), args.Error(1)
+}
+
+func (m *MockRepository) GetVariantBySKU(ctx context.Context, sku string) (*model.Variant, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Variant), args.Error(1)
+}
+
+func (m *MockRepository) CreateImportJob(ctx context.Context, job *model.ImportJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateImportJob(ctx context.Context, job *model.ImportJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetImportJob(ctx context.Context, id int64) (*model.ImportJob, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ImportJob), args.Error(1)
+}
+
 func TestNew(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := New(mockRepo)
@@ -119,27 +345,69 @@ func TestListGoods_Success(t *testing.T) {
 		{ID: 2, Name: "Good 2", Price: 20.0, Stock: 30},
 	}
 
-	mockRepo.On("ListGoods", ctx, int32(10), int32(0)).Return(expectedGoods, nil)
+	filter := &model.GoodsFilter{Limit: 10}
+	mockRepo.On("ListGoods", ctx, filter).Return(expectedGoods, nil)
+	mockRepo.On("GetTotalGoods", ctx, filter).Return(int32(100), nil)
 
-	goods, err := service.ListGoods(ctx, 10, 0)
+	page, err := service.ListGoods(ctx, filter)
 
 	assert.NoError(t, err)
-	assert.NotNil(t, goods)
-	assert.Equal(t, 2, len(goods))
+	assert.NotNil(t, page)
+	assert.Equal(t, 2, len(page.Goods))
+	assert.Equal(t, int32(100), page.Total)
+	assert.Nil(t, page.Facets)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestGetTotalGoods_Success(t *testing.T) {
+func TestListGoods_CategoryIncludesSubcategories(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := New(mockRepo)
 	ctx := context.Background()
 
-	mockRepo.On("GetTotalGoods", ctx).Return(int32(100), nil)
+	mockRepo.On("ListCategories", ctx).Return([]*model.Category{
+		{ID: 1, Slug: "tea"},
+		{ID: 2, ParentID: 1, Slug: "green"},
+		{ID: 3, ParentID: 2, Slug: "sencha"},
+		{ID: 4, Slug: "teaware"},
+	}, nil)
+	withIDs := mock.MatchedBy(func(filter *model.GoodsFilter) bool {
+		return assert.ObjectsAreEqual([]int64{1, 2, 3}, filter.SubtreeCategoryIDs)
+	})
+	mockRepo.On("ListGoods", ctx, withIDs).Return([]*model.Good{{ID: 1}}, nil)
+	mockRepo.On("GetTotalGoods", ctx, withIDs).Return(int32(1), nil)
 
-	total, err := service.GetTotalGoods(ctx)
+	page, err := service.ListGoods(ctx, &model.GoodsFilter{Limit: 10, CategoryIDs: []int64{1}})
 
 	assert.NoError(t, err)
-	assert.Equal(t, int32(100), total)
+	assert.Len(t, page.Goods, 1)
+	assert.Equal(t, int32(1), page.Total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListGoods_UnknownCategory(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("ListCategories", ctx).Return([]*model.Category{{ID: 1, Slug: "tea"}}, nil)
+
+	_, err := service.ListGoods(ctx, &model.GoodsFilter{Limit: 10, CategoryIDs: []int64{1, 42}})
+
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListGoods_InvalidFilter(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	_, err := service.ListGoods(ctx, &model.GoodsFilter{Limit: 10, MinPrice: -1})
+	assert.ErrorIs(t, err, ErrInvalidPriceRange)
+	_, err = service.ListGoods(ctx, &model.GoodsFilter{Limit: 10, MinPrice: 500, MaxPrice: 100})
+	assert.ErrorIs(t, err, ErrInvalidPriceRange)
+	_, err = service.ListGoods(ctx, &model.GoodsFilter{Limit: 10, Sort: "rating"})
+	assert.ErrorIs(t, err, ErrInvalidSort)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -149,16 +417,18 @@ func TestCheckStock_Available(t *testing.T) {
 	ctx := context.Background()
 
 	good := &model.Good{
-		ID:    1,
-		Stock: 100,
+		ID:       1,
+		Stock:    100,
+		Variants: []*model.Variant{{ID: 11, GoodID: 1, Stock: 100}},
 	}
 
 	mockRepo.On("GetGood", ctx, int64(1)).Return(good, nil)
 
-	available, err := service.CheckStock(ctx, 1, 50)
+	availability, err := service.CheckStock(ctx, 1, 0, 50)
 
 	assert.NoError(t, err)
-	assert.True(t, available)
+	assert.True(t, availability.Available)
+	assert.Equal(t, int32(100), availability.Stock)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -167,17 +437,34 @@ func TestCheckStock_NotAvailable(t *testing.T) {
 	service := New(mockRepo)
 	ctx := context.Background()
 
+	mockRepo.On("GetVariant", ctx, int64(11)).Return(&model.Variant{ID: 11, GoodID: 1, Stock: 10}, nil)
+	mockRepo.On("GetGood", ctx, int64(1)).Return(&model.Good{ID: 1, Stock: 10}, nil)
+
+	availability, err := service.CheckStock(ctx, 1, 11, 50)
+
+	assert.NoError(t, err)
+	assert.False(t, availability.Available)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckStock_ArchivedGood(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
 	good := &model.Good{
-		ID:    1,
-		Stock: 10,
+		ID:         1,
+		Stock:      100,
+		Variants:   []*model.Variant{{ID: 11, GoodID: 1, Stock: 100}},
+		ArchivedAt: time.Now(),
 	}
-
 	mockRepo.On("GetGood", ctx, int64(1)).Return(good, nil)
 
-	available, err := service.CheckStock(ctx, 1, 50)
+	availability, err := service.CheckStock(ctx, 1, 0, 10)
 
 	assert.NoError(t, err)
-	assert.False(t, available)
+	assert.False(t, availability.Available)
+	assert.Zero(t, availability.Stock)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -188,10 +475,41 @@ func TestCheckStock_GoodNotFound(t *testing.T) {
 
 	mockRepo.On("GetGood", ctx, int64(999)).Return(nil, nil)
 
-	available, err := service.CheckStock(ctx, 999, 10)
+	availability, err := service.CheckStock(ctx, 999, 0, 10)
+
+	assert.NoError(t, err)
+	assert.False(t, availability.Available)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckStock_VariantOfAnotherGood(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetVariant", ctx, int64(11)).Return(&model.Variant{ID: 11, GoodID: 2, Stock: 100}, nil)
+
+	availability, err := service.CheckStock(ctx, 1, 11, 10)
 
 	assert.NoError(t, err)
-	assert.False(t, available)
+	assert.False(t, availability.Available)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckStock_VariantRequired(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	good := &model.Good{
+		ID:       1,
+		Variants: []*model.Variant{{ID: 11, GoodID: 1, Stock: 5}, {ID: 12, GoodID: 1, Stock: 5}},
+	}
+	mockRepo.On("GetGood", ctx, int64(1)).Return(good, nil)
+
+	_, err := service.CheckStock(ctx, 1, 0, 1)
+
+	assert.ErrorIs(t, err, ErrVariantRequired)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -200,9 +518,13 @@ func TestReserveStock_Success(t *testing.T) {
 	service := New(mockRepo)
 	ctx := context.Background()
 
-	mockRepo.On("ReserveStock", ctx, int64(1), int32(10), int64(100)).Return(nil)
+	mockRepo.On("GetVariant", ctx, int64(11)).Return(stockedVariant(11, 1, 50), nil)
+	mockRepo.On("ListWarehouses", ctx).Return([]*model.Warehouse{{ID: 1, Code: "main"}}, nil)
+	mockRepo.On("ReserveAllocations", ctx, int64(100), []*model.Allocation{
+		{GoodID: 1, VariantID: 11, WarehouseID: 1, WarehouseCode: "main", Quantity: 10},
+	}).Return(nil)
 
-	success, err := service.ReserveStock(ctx, 1, 10, 100)
+	success, err := service.ReserveStock(ctx, 1, 11, 10, 100)
 
 	assert.NoError(t, err)
 	assert.True(t, success)
@@ -214,9 +536,10 @@ func TestReserveStock_InsufficientStock(t *testing.T) {
 	service := New(mockRepo)
 	ctx := context.Background()
 
-	mockRepo.On("ReserveStock", ctx, int64(1), int32(100), int64(100)).Return(sql.ErrNoRows)
+	mockRepo.On("GetVariant", ctx, int64(11)).Return(stockedVariant(11, 1, 50), nil)
+	mockRepo.On("ListWarehouses", ctx).Return([]*model.Warehouse{{ID: 1, Code: "main"}}, nil)
 
-	success, err := service.ReserveStock(ctx, 1, 100, 100)
+	success, err := service.ReserveStock(ctx, 0, 11, 100, 100)
 
 	assert.NoError(t, err)
 	assert.False(t, success)
@@ -228,9 +551,11 @@ func TestReserveStock_Error(t *testing.T) {
 	service := New(mockRepo)
 	ctx := context.Background()
 
-	mockRepo.On("ReserveStock", ctx, int64(1), int32(10), int64(100)).Return(errors.New("database error"))
+	mockRepo.On("GetVariant", ctx, int64(11)).Return(stockedVariant(11, 1, 50), nil)
+	mockRepo.On("ListWarehouses", ctx).Return([]*model.Warehouse{{ID: 1, Code: "main"}}, nil)
+	mockRepo.On("ReserveAllocations", ctx, int64(100), mock.Anything).Return(errors.New("database error"))
 
-	success, err := service.ReserveStock(ctx, 1, 10, 100)
+	success, err := service.ReserveStock(ctx, 1, 11, 10, 100)
 
 	assert.Error(t, err)
 	assert.False(t, success)
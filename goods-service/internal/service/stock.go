@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/metrics"
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+const (
+	defaultMovementsLimit = 50
+	maxMovementsLimit     = 200
+)
+
+// AdjustStock проводит поступление, ручную корректировку или возврат на складе. Корректировка требует
+// причины и может быть отрицательной (списание), поступление и возврат только увеличивают остаток
+func (s *GoodsService) AdjustStock(ctx context.Context, req *model.StockAdjustmentRequest) (*model.StockMovement, error) {
+	movement := &model.StockMovement{
+		Type:     req.Type,
+		Quantity: req.Quantity,
+		Reason:   strings.TrimSpace(req.Reason),
+		OrderID:  req.OrderID,
+	}
+	switch movement.Type {
+	case model.MovementReceipt, model.MovementReturn:
+		if movement.Quantity <= 0 {
+			return nil, ErrInvalidMovement
+		}
+	case model.MovementAdjustment:
+		if movement.Quantity == 0 || movement.Reason == "" {
+			return nil, ErrInvalidMovement
+		}
+	default:
+		return nil, ErrInvalidMovement
+	}
+
+	variant, err := s.resolveVariant(ctx, req.GoodID, req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	if variant == nil {
+		if req.VariantID != 0 {
+			return nil, ErrVariantNotFound
+		}
+		return nil, ErrGoodNotFound
+	}
+
+	movement.WarehouseID, err = s.resolveWarehouse(ctx, req.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	movement.VariantID = variant.ID
+	err = s.repo.AdjustStock(ctx, movement)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	metrics.StockMovements.WithLabelValues(movement.Type).Inc()
+	return movement, nil
+}
+
+// ReleaseStock снимает незавершенные резервы заказа, количество возвращается в остаток.
+// Повторный вызов ничего не меняет
+func (s *GoodsService) ReleaseStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	movements, err := s.repo.ReleaseStock(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	metrics.StockMovements.WithLabelValues(model.MovementRelease).Add(float64(len(movements)))
+	return movements, nil
+}
+
+// CommitStock списывает незавершенные резервы оплаченного заказа как продажу.
+// Повторный вызов ничего не меняет
+func (s *GoodsService) CommitStock(ctx context.Context, orderID int64) ([]*model.StockMovement, error) {
+	movements, err := s.repo.CommitStock(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	metrics.StockMovements.WithLabelValues(model.MovementSale).Add(float64(len(movements)))
+	return movements, nil
+}
+
+// ListStockMovements возвращает историю движений товара, при variantID != 0 - только одного варианта
+func (s *GoodsService) ListStockMovements(ctx context.Context, goodID, variantID int64, limit, offset int32) (*model.StockMovementsPage, error) {
+	good, err := s.repo.GetGood(ctx, goodID)
+	if err != nil {
+		return nil, err
+	}
+	if good == nil {
+		return nil, ErrGoodNotFound
+	}
+	if variantID != 0 && !hasVariant(good, variantID) {
+		return nil, ErrVariantNotFound
+	}
+
+	if limit <= 0 {
+		limit = defaultMovementsLimit
+	}
+	limit = min(limit, maxMovementsLimit)
+	offset = max(offset, 0)
+
+	page := &model.StockMovementsPage{}
+	page.Movements, page.Total, err = s.repo.ListStockMovements(ctx, goodID, variantID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// VerifyStock сверяет остатки и резервы с журналом и возвращает расходящиеся варианты
+func (s *GoodsService) VerifyStock(ctx context.Context) ([]*model.StockDiscrepancy, error) {
+	return s.repo.StockDiscrepancies(ctx)
+}
+
+func hasVariant(good *model.Good, variantID int64) bool {
+	for _, variant := range good.Variants {
+		if variant.ID == variantID {
+			return true
+		}
+	}
+	return false
+}
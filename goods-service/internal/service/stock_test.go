@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func movementTypes(movements []*model.StockMovement) []string {
+	types := make([]string, len(movements))
+	for i, movement := range movements {
+		types[i] = movement.Type
+	}
+	return types
+}
+
+func TestStockLedger_OrderLifecycle(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Сенча", Price: 500, Stock: 10})
+	require.NoError(t, err)
+
+	reserved, err := s.ReserveStock(ctx, good.ID, 0, 3, 1)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	reserved, err = s.ReserveStock(ctx, good.ID, 0, 2, 2)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// Заказ 1 оплачен, заказ 2 нет
+	sold, err := s.CommitStock(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, sold, 1)
+	assert.Equal(t, int32(0), sold[0].Quantity)
+	assert.Equal(t, int32(-3), sold[0].Reserved)
+
+	released, err := s.ReleaseStock(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, released, 1)
+	assert.Equal(t, int32(2), released[0].Quantity)
+	assert.Equal(t, int32(7), released[0].Balance)
+
+	// Повторное снятие резерва ничего не меняет
+	released, err = s.ReleaseStock(ctx, 2)
+	require.NoError(t, err)
+	assert.Empty(t, released)
+
+	good, err = s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), good.Stock)
+
+	page, err := s.ListStockMovements(ctx, good.ID, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), page.Total)
+	assert.Equal(t, []string{
+		model.MovementRelease,
+		model.MovementSale,
+		model.MovementReservation,
+		model.MovementReservation,
+		model.MovementReceipt,
+	}, movementTypes(page.Movements))
+
+	discrepancies, err := s.VerifyStock(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
+
+func TestDeleteGood_ArchivesGood(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Сенча", Description: "Японский зеленый чай", Price: 500, Stock: 10})
+	require.NoError(t, err)
+	reserved, err := s.ReserveStock(ctx, good.ID, 0, 2, 1)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// Пока заказ не оплачен и не отменен, товар не архивируется
+	assert.ErrorIs(t, s.DeleteGood(ctx, good.ID), ErrGoodReserved)
+	_, err = s.CommitStock(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteGood(ctx, good.ID))
+	require.NoError(t, s.DeleteGood(ctx, good.ID))
+	assert.ErrorIs(t, s.DeleteGood(ctx, 999), ErrGoodNotFound)
+
+	// Архивный товар доступен по id для истории заказов, но не виден в каталоге и поиске и не продается
+	archived, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.False(t, archived.ArchivedAt.IsZero())
+	page, err := s.ListGoods(ctx, &model.GoodsFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Goods)
+	assert.Zero(t, page.Total)
+	found, err := s.SearchGoods(ctx, "сенча", 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, found.Hits)
+	availability, err := s.CheckStock(ctx, good.ID, 0, 1)
+	require.NoError(t, err)
+	assert.False(t, availability.Available)
+	reserved, err = s.ReserveStock(ctx, good.ID, 0, 1, 2)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	page, err = s.ListGoods(ctx, &model.GoodsFilter{Limit: 10, Archived: true})
+	require.NoError(t, err)
+	require.Len(t, page.Goods, 1)
+	assert.Equal(t, good.ID, page.Goods[0].ID)
+
+	restored, err := s.RestoreGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.True(t, restored.ArchivedAt.IsZero())
+	assert.Equal(t, int32(8), restored.Stock)
+	page, err = s.ListGoods(ctx, &model.GoodsFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Goods, 1)
+	_, err = s.RestoreGood(ctx, 999)
+	assert.ErrorIs(t, err, ErrGoodNotFound)
+}
+
+func TestAdjustStock(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Пуэр", Price: 900, Stock: 5})
+	require.NoError(t, err)
+	variantID := good.Variants[0].ID
+
+	movement, err := s.AdjustStock(ctx, &model.StockAdjustmentRequest{GoodID: good.ID, Type: model.MovementReceipt, Quantity: 10})
+	require.NoError(t, err)
+	assert.Equal(t, variantID, movement.VariantID)
+	assert.Equal(t, int32(15), movement.Balance)
+
+	movement, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{
+		VariantID: variantID,
+		Type:      model.MovementAdjustment,
+		Quantity:  -4,
+		Reason:    " инвентаризация ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "инвентаризация", movement.Reason)
+	assert.Equal(t, int32(11), movement.Balance)
+
+	_, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{GoodID: good.ID, Type: model.MovementAdjustment, Quantity: -20, Reason: "брак"})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	invalid := []*model.StockAdjustmentRequest{
+		{GoodID: good.ID, Type: model.MovementAdjustment, Quantity: -1},
+		{GoodID: good.ID, Type: model.MovementAdjustment, Reason: "пусто"},
+		{GoodID: good.ID, Type: model.MovementReturn, Quantity: -1},
+		{GoodID: good.ID, Type: model.MovementSale, Quantity: 1},
+		{GoodID: good.ID, Type: "gift", Quantity: 1},
+	}
+	for _, req := range invalid {
+		_, err = s.AdjustStock(ctx, req)
+		assert.ErrorIs(t, err, ErrInvalidMovement, req)
+	}
+
+	_, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{GoodID: 42, Type: model.MovementReceipt, Quantity: 1})
+	assert.ErrorIs(t, err, ErrGoodNotFound)
+	_, err = s.AdjustStock(ctx, &model.StockAdjustmentRequest{VariantID: 42, Type: model.MovementReceipt, Quantity: 1})
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+
+	// Прямая правка остатка варианта тоже попадает в журнал
+	_, err = s.UpdateVariant(ctx, variantID, &model.UpdateVariantRequest{Price: good.Price, Stock: 8})
+	require.NoError(t, err)
+
+	page, err := s.ListStockMovements(ctx, good.ID, variantID, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), page.Total)
+	require.Len(t, page.Movements, 1)
+	assert.Equal(t, model.MovementAdjustment, page.Movements[0].Type)
+	assert.Equal(t, int32(-3), page.Movements[0].Quantity)
+
+	_, err = s.ListStockMovements(ctx, 42, 0, 0, 0)
+	assert.ErrorIs(t, err, ErrGoodNotFound)
+	_, err = s.ListStockMovements(ctx, good.ID, 42, 0, 0)
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+
+	discrepancies, err := s.VerifyStock(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies)
+}
+
+func TestVerifyStock_ReportsDiscrepancies(t *testing.T) {
+	mockRepo := new(MockRepository)
+	s := New(mockRepo)
+	ctx := context.Background()
+
+	discrepancies := []*model.StockDiscrepancy{{GoodID: 1, VariantID: 1, SKU: "GOOD-000001", Stock: 5, LedgerStock: 3}}
+	mockRepo.On("StockDiscrepancies", ctx).Return(discrepancies, nil)
+
+	result, err := s.VerifyStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, discrepancies, result)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// CreateVariant добавляет вариант товару. Без артикула он генерируется из артикула товара: GOOD-000001-2
+func (s *GoodsService) CreateVariant(ctx context.Context, goodID int64, req *model.CreateVariantRequest) (*model.Variant, error) {
+	good, err := s.repo.GetGood(ctx, goodID)
+	if err != nil {
+		return nil, err
+	}
+	if good == nil {
+		return nil, ErrGoodNotFound
+	}
+
+	variant := &model.Variant{
+		GoodID:     goodID,
+		SKU:        strings.TrimSpace(req.SKU),
+		Price:      req.Price,
+		Stock:      req.Stock,
+		Attributes: req.Attributes,
+	}
+	if variant.SKU == "" {
+		variant.SKU = fmt.Sprintf("%s-%d", good.SKU, len(good.Variants)+1)
+	}
+	if err := validateVariant(variant); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateVariant(ctx, variant); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// UpdateVariant заменяет цену, остаток и атрибуты варианта; пустой артикул оставляет текущий
+func (s *GoodsService) UpdateVariant(ctx context.Context, id int64, req *model.UpdateVariantRequest) (*model.Variant, error) {
+	variant, err := s.repo.GetVariant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if variant == nil {
+		return nil, ErrVariantNotFound
+	}
+
+	if sku := strings.TrimSpace(req.SKU); sku != "" {
+		variant.SKU = sku
+	}
+	// Новый остаток задан относительно прочитанного: разница применяется под блокировкой
+	// и сохраняет резервы, сделанные после чтения
+	stockDelta := req.Stock - variant.Stock
+	variant.Price = req.Price
+	variant.Stock = req.Stock
+	variant.Attributes = req.Attributes
+	if err := validateVariant(variant); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateVariant(ctx, variant, stockDelta); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// DeleteVariant убирает вариант в архив; последний вариант товара и вариант
+// с незавершенными резервированиями удалить нельзя
+func (s *GoodsService) DeleteVariant(ctx context.Context, id int64) error {
+	variant, err := s.repo.GetVariant(ctx, id)
+	if err != nil {
+		return err
+	}
+	if variant == nil {
+		return ErrVariantNotFound
+	}
+
+	good, err := s.repo.GetGood(ctx, variant.GoodID)
+	if err != nil {
+		return err
+	}
+	if good == nil || len(good.Variants) <= 1 {
+		return ErrLastVariant
+	}
+	return s.repo.DeleteVariant(ctx, id)
+}
+
+// resolveVariant находит вариант для операций с остатком. Без variantID берется единственный вариант
+// товара goodID. Возвращает nil, если товара или варианта нет или вариант относится к другому товару
+func (s *GoodsService) resolveVariant(ctx context.Context, goodID, variantID int64) (*model.Variant, error) {
+	if variantID != 0 {
+		variant, err := s.repo.GetVariant(ctx, variantID)
+		if err != nil || variant == nil {
+			return nil, err
+		}
+		if goodID != 0 && variant.GoodID != goodID {
+			return nil, nil
+		}
+		return variant, nil
+	}
+
+	good, err := s.repo.GetGood(ctx, goodID)
+	if err != nil || good == nil {
+		return nil, err
+	}
+	if len(good.Variants) != 1 {
+		return nil, ErrVariantRequired
+	}
+	return good.Variants[0], nil
+}
+
+func validateVariant(variant *model.Variant) error {
+	if variant.Price <= 0 || variant.Stock < 0 {
+		return ErrInvalidVariant
+	}
+	return nil
+}
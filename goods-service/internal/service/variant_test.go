@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+	"github.com/che1nov/tea-shop/goods-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariants_Lifecycle(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Сенча", Price: 500, Stock: 10})
+	require.NoError(t, err)
+	require.Len(t, good.Variants, 1)
+
+	large, err := s.CreateVariant(ctx, good.ID, &model.CreateVariantRequest{
+		Price:      1100,
+		Stock:      4,
+		Attributes: map[string]string{"weight": "250 г"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, good.SKU+"-2", large.SKU)
+
+	_, err = s.CreateVariant(ctx, good.ID, &model.CreateVariantRequest{SKU: large.SKU, Price: 100})
+	assert.ErrorIs(t, err, ErrSKUTaken)
+	_, err = s.CreateVariant(ctx, good.ID, &model.CreateVariantRequest{Price: 0})
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+	_, err = s.CreateVariant(ctx, 42, &model.CreateVariantRequest{Price: 100})
+	assert.ErrorIs(t, err, ErrGoodNotFound)
+
+	updated, err := s.UpdateVariant(ctx, large.ID, &model.UpdateVariantRequest{Price: 1000, Stock: 6})
+	require.NoError(t, err)
+	assert.Equal(t, large.SKU, updated.SKU)
+	assert.Nil(t, updated.Attributes)
+	_, err = s.UpdateVariant(ctx, 42, &model.UpdateVariantRequest{Price: 1})
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+
+	good, err = s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 500.0, good.Price)
+	assert.Equal(t, int32(16), good.Stock)
+
+	// С несколькими вариантами остаток проверяется и резервируется только по варианту
+	_, err = s.CheckStock(ctx, good.ID, 0, 1)
+	assert.ErrorIs(t, err, ErrVariantRequired)
+	availability, err := s.CheckStock(ctx, good.ID, large.ID, 6)
+	require.NoError(t, err)
+	assert.True(t, availability.Available)
+	reserved, err := s.ReserveStock(ctx, good.ID, large.ID, 5, 1)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	reserved, err = s.ReserveStock(ctx, good.ID, large.ID, 5, 2)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	require.NoError(t, s.DeleteVariant(ctx, good.Variants[0].ID))
+	assert.ErrorIs(t, s.DeleteVariant(ctx, large.ID), ErrLastVariant)
+	assert.ErrorIs(t, s.DeleteVariant(ctx, 42), ErrVariantNotFound)
+
+	good, err = s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, good.Price)
+	assert.Equal(t, int32(1), good.Stock)
+}
+
+func TestUpdateGood_SingleVariant(t *testing.T) {
+	s := New(repository.NewMemory())
+	ctx := context.Background()
+
+	good, err := s.CreateGood(ctx, &model.CreateGoodRequest{Name: "Пуэр", Price: 900, Stock: 3})
+	require.NoError(t, err)
+
+	// Цена товара с одним вариантом меняется в этом варианте, остаток не трогается
+	reserved, err := s.ReserveStock(ctx, good.ID, 0, 1, 1)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	updated, err := s.UpdateGood(ctx, good.ID, &model.UpdateGoodRequest{Price: 1200})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), updated.Stock)
+	stored, err := s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1200.0, stored.Price)
+	assert.Equal(t, 1200.0, stored.Variants[0].Price)
+	assert.Equal(t, int32(2), stored.Variants[0].Stock)
+
+	// У товара с несколькими вариантами они не меняются
+	_, err = s.CreateVariant(ctx, good.ID, &model.CreateVariantRequest{Price: 2000, Stock: 1})
+	require.NoError(t, err)
+	_, err = s.UpdateGood(ctx, good.ID, &model.UpdateGoodRequest{Name: "Шу Пуэр", Price: 1})
+	require.NoError(t, err)
+	stored, err = s.GetGood(ctx, good.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Шу Пуэр", stored.Name)
+	assert.Equal(t, 1200.0, stored.Price)
+	assert.Equal(t, int32(3), stored.Stock)
+}
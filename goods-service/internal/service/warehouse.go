@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/che1nov/tea-shop/goods-service/internal/model"
+)
+
+// CreateWarehouse добавляет склад. Код - как slug: латиница в нижнем регистре, цифры и дефисы
+func (s *GoodsService) CreateWarehouse(ctx context.Context, req *model.CreateWarehouseRequest) (*model.Warehouse, error) {
+	warehouse := &model.Warehouse{
+		Code:     strings.TrimSpace(req.Code),
+		Name:     strings.TrimSpace(req.Name),
+		Address:  strings.TrimSpace(req.Address),
+		Regions:  cleanRegions(req.Regions),
+		Priority: req.Priority,
+	}
+	if !slugPattern.MatchString(warehouse.Code) || warehouse.Name == "" {
+		return nil, ErrInvalidWarehouse
+	}
+
+	if err := s.repo.CreateWarehouse(ctx, warehouse); err != nil {
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+// UpdateWarehouse заменяет название, адрес, регионы и приоритет склада; код склада не меняется
+func (s *GoodsService) UpdateWarehouse(ctx context.Context, id int64, req *model.UpdateWarehouseRequest) (*model.Warehouse, error) {
+	warehouse, err := s.repo.GetWarehouse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if warehouse == nil {
+		return nil, ErrWarehouseNotFound
+	}
+
+	warehouse.Name = strings.TrimSpace(req.Name)
+	warehouse.Address = strings.TrimSpace(req.Address)
+	warehouse.Regions = cleanRegions(req.Regions)
+	warehouse.Priority = req.Priority
+	if warehouse.Name == "" {
+		return nil, ErrInvalidWarehouse
+	}
+
+	if err := s.repo.UpdateWarehouse(ctx, warehouse); err != nil {
+		return nil, err
+	}
+	return warehouse, nil
+}
+
+// ListWarehouses возвращает склады в порядке приоритета
+func (s *GoodsService) ListWarehouses(ctx context.Context) ([]*model.Warehouse, error) {
+	return s.repo.ListWarehouses(ctx)
+}
+
+// resolveWarehouse возвращает склад warehouseID, а без него - единственный склад
+func (s *GoodsService) resolveWarehouse(ctx context.Context, warehouseID int64) (int64, error) {
+	if warehouseID != 0 {
+		warehouse, err := s.repo.GetWarehouse(ctx, warehouseID)
+		if err != nil {
+			return 0, err
+		}
+		if warehouse == nil {
+			return 0, ErrWarehouseNotFound
+		}
+		return warehouse.ID, nil
+	}
+
+	warehouses, err := s.repo.ListWarehouses(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(warehouses) != 1 {
+		return 0, ErrWarehouseRequired
+	}
+	return warehouses[0].ID, nil
+}
+
+// cleanRegions убирает пробелы по краям и пустые регионы
+func cleanRegions(regions []string) []string {
+	var cleaned []string
+	for _, region := range regions {
+		if region = strings.TrimSpace(region); region != "" {
+			cleaned = append(cleaned, region)
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,80 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// csvSeparator - разделитель выгрузки: так CSV сохраняет и открывает Excel в русской локали
+const csvSeparator = ';'
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ReadCSV разбирает CSV в UTF-8. BOM в начале пропускается, разделитель - «;» или «,» -
+// определяется по строке заголовка
+func ReadCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: csv must be encoded in UTF-8", ErrInvalidFile)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectSeparator(data)
+	reader.FieldsPerRecord = -1
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		rows = append(rows, record)
+		if err := checkSize(len(rows), len(record)); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// detectSeparator выбирает разделитель, которого в первой строке больше вне кавычек
+func detectSeparator(data []byte) rune {
+	semicolons, commas := 0, 0
+	quoted := false
+	for _, b := range data {
+		if b == '\n' && !quoted {
+			break
+		}
+		switch {
+		case b == '"':
+			quoted = !quoted
+		case b == ';' && !quoted:
+			semicolons++
+		case b == ',' && !quoted:
+			commas++
+		}
+	}
+	if semicolons > commas {
+		return ';'
+	}
+	return ','
+}
+
+// WriteCSV пишет таблицу в UTF-8 с BOM и разделителем «;»
+func WriteCSV(w io.Writer, rows [][]string) error {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = csvSeparator
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
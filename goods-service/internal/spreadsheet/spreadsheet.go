@@ -0,0 +1,73 @@
+// Package spreadsheet читает и пишет таблицы каталога в CSV и XLSX без внешних зависимостей.
+// Таблица - строки ячеек; первая строка - заголовок. Строки нумеруются с 1, как в редакторе таблиц:
+// rows[i] - строка i+1, пустые строки XLSX сохраняются пустыми срезами
+package spreadsheet
+
+import (
+	"errors"
+	"strings"
+)
+
+// Форматы файлов
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+const (
+	// MaxRows ограничивает число строк в файле вместе с заголовком
+	MaxRows = 50_000
+	// MaxColumns ограничивает число столбцов
+	MaxColumns = 256
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("file format must be csv or xlsx")
+	ErrInvalidFile       = errors.New("file is not a valid spreadsheet")
+	ErrTooManyRows       = errors.New("spreadsheet has too many rows")
+	ErrTooManyColumns    = errors.New("spreadsheet has too many columns")
+)
+
+// Read разбирает файл в формате format
+func Read(format string, data []byte) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return ReadCSV(data)
+	case FormatXLSX:
+		return ReadXLSX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ContentType возвращает MIME тип файла формата format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FormatFromFilename определяет формат по расширению файла; пустая строка - формат не поддерживается
+func FormatFromFilename(filename string) string {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(lower, ".xlsx"):
+		return FormatXLSX
+	default:
+		return ""
+	}
+}
+
+// checkSize проверяет ограничения на размер таблицы
+func checkSize(rows int, columns int) error {
+	if rows > MaxRows {
+		return ErrTooManyRows
+	}
+	if columns > MaxColumns {
+		return ErrTooManyColumns
+	}
+	return nil
+}
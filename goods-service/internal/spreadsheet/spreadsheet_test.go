@@ -0,0 +1,122 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSV_RoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"sku", "name", "price"},
+		{"PUER-1", "Шу пуэр; прессованный", "1200.5"},
+		{"OOLONG-1", "Улун \"Те Гуань Инь\"", "900"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, rows))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), utf8BOM))
+
+	read, err := ReadCSV(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, rows, read)
+}
+
+func TestReadCSV_CommaSeparated(t *testing.T) {
+	rows, err := ReadCSV([]byte("sku,name,categories\nPUER-1,Пуэр,\"puer;dark\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"sku", "name", "categories"}, {"PUER-1", "Пуэр", "puer;dark"}}, rows)
+}
+
+func TestReadCSV_Invalid(t *testing.T) {
+	_, err := ReadCSV([]byte("sku;name\nPUER-1;\"Пуэр\n"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	_, err = ReadCSV([]byte{'s', 'k', 'u', 0xff})
+	assert.ErrorIs(t, err, ErrInvalidFile)
+}
+
+func TestXLSX_RoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"sku", "name", "price", "stock"},
+		{"PUER-1", "Пуэр <2015>", "1200.5", "10"},
+		{"OOLONG-1", "", "900", "не число"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteXLSX(&buf, "Каталог", rows, []bool{false, false, true, true}))
+
+	read, err := ReadXLSX(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, rows, read)
+}
+
+// writeZip собирает XLSX из частей так, как их сохраняет Excel: общие строки и адреса ячеек с пропусками
+func writeZip(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestReadXLSX_SharedStrings(t *testing.T) {
+	data := writeZip(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Товары" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId3" Target="/xl/worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+			<si><t>sku</t></si><si><t>name</t></si><si><r><t>Да </t></r><r><t>Хун Пао</t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+			<row r="3"><c r="A3" t="str"><v>DHP-1</v></c><c r="C3"><v>1500</v></c><c r="B3" t="s"><v>2</v></c></row>
+		</sheetData></worksheet>`,
+	})
+
+	rows, err := ReadXLSX(data)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"sku", "name"}, nil, {"DHP-1", "Да Хун Пао", "1500"}}, rows)
+}
+
+func TestReadXLSX_Invalid(t *testing.T) {
+	_, err := ReadXLSX([]byte("not a zip"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	_, err = ReadXLSX(writeZip(t, map[string]string{"xl/workbook.xml": `<workbook/>`}))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	data := writeZip(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="1"><c r="ZZZ1"><v>1</v></c></row></sheetData></worksheet>`,
+	})
+	_, err = ReadXLSX(data)
+	assert.ErrorIs(t, err, ErrTooManyColumns)
+}
+
+func TestColumnNames(t *testing.T) {
+	for index, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 255: "IV"} {
+		assert.Equal(t, name, columnName(index))
+		got, err := columnIndex(name + "12")
+		require.NoError(t, err)
+		assert.Equal(t, index, got)
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	assert.Equal(t, FormatCSV, FormatFromFilename("assortment.CSV"))
+	assert.Equal(t, FormatXLSX, FormatFromFilename("Ассортимент.xlsx"))
+	assert.Equal(t, "", FormatFromFilename("assortment.xls"))
+}
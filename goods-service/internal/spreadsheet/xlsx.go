@@ -0,0 +1,312 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxPartSize ограничивает распакованный размер одной части XLSX, чтобы небольшой архив
+// не развернулся в гигабайты
+const maxPartSize = 64 << 20
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelationID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxText - строка: простой текст в <t> или форматированный по фрагментам в <r><t>
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	var b strings.Builder
+	b.WriteString(t.Text)
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX читает первый лист книги XLSX. Числа возвращаются так, как они записаны в файле,
+// формулы - последним вычисленным значением
+func ReadXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodePart(archive, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("%w: workbook has no sheets", ErrInvalidFile)
+	}
+	var relationships xlsxRelationships
+	if err := decodePart(archive, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID == workbook.Sheets[0].RelationID {
+			sheetPath = partPath(relationship.Target)
+		}
+	}
+	if sheetPath == "" {
+		return nil, fmt.Errorf("%w: first sheet is missing", ErrInvalidFile)
+	}
+
+	// Общих строк может не быть, если все строки записаны в ячейках
+	var shared xlsxSharedStrings
+	if findPart(archive, "xl/sharedStrings.xml") != nil {
+		if err := decodePart(archive, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodePart(archive, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = len(rows) + 1
+		}
+		if number < len(rows)+1 {
+			return nil, fmt.Errorf("%w: rows are out of order", ErrInvalidFile)
+		}
+		if err := checkSize(number, 0); err != nil {
+			return nil, err
+		}
+		for len(rows) < number {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for _, cell := range row.Cells {
+			column := len(cells)
+			if cell.Ref != "" {
+				column, err = columnIndex(cell.Ref)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if err := checkSize(number, column+1); err != nil {
+				return nil, err
+			}
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("%w: cell %s refers to a missing shared string", ErrInvalidFile, cell.Ref)
+				}
+				cells[column] = shared.Items[index].String()
+			case "inlineStr":
+				cells[column] = cell.Inline.String()
+			default:
+				cells[column] = cell.Value
+			}
+		}
+		rows[number-1] = cells
+	}
+	return rows, nil
+}
+
+// partPath приводит путь из связей книги к пути в архиве: цели указываются относительно xl/ или от корня
+func partPath(target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join("xl", target)
+}
+
+func findPart(archive *zip.Reader, name string) *zip.File {
+	for _, file := range archive.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
+
+// decodePart разбирает XML часть архива, не читая больше maxPartSize
+func decodePart(archive *zip.Reader, name string, v any) error {
+	file := findPart(archive, name)
+	if file == nil {
+		return fmt.Errorf("%w: %s is missing", ErrInvalidFile, name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxPartSize+1))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(data) > maxPartSize {
+		return fmt.Errorf("%w: %s is too large", ErrInvalidFile, name)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidFile, name, err)
+	}
+	return nil
+}
+
+// columnIndex возвращает номер столбца с 0 по адресу ячейки: A1 - 0, AB7 - 27
+func columnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+		if column > MaxColumns {
+			return 0, ErrTooManyColumns
+		}
+	}
+	if letters == 0 {
+		return 0, fmt.Errorf("%w: invalid cell reference %q", ErrInvalidFile, ref)
+	}
+	return column - 1, nil
+}
+
+// columnName возвращает буквенное имя столбца по номеру с 0: 0 - A, 27 - AB
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbookPart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// WriteXLSX пишет таблицу книгой с одним листом sheetName. Ячейки столбцов, отмеченных в numeric,
+// кроме заголовка, записываются числами, остальные - строками в самих ячейках
+func WriteXLSX(w io.Writer, sheetName string, rows [][]string, numeric []bool) error {
+	archive := zip.NewWriter(w)
+
+	var name bytes.Buffer
+	if err := xml.EscapeText(&name, []byte(sheetName)); err != nil {
+		return err
+	}
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRelationships},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbookPart, name.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRelationships},
+	}
+	for _, part := range parts {
+		writer, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(writer, part.content); err != nil {
+			return err
+		}
+	}
+
+	writer, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeSheet(writer, rows, numeric); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeSheet(w io.Writer, rows [][]string, numeric []bool) error {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, value := range row {
+			if value == "" {
+				continue
+			}
+			ref := columnName(j) + strconv.Itoa(i+1)
+			if i > 0 && j < len(numeric) && numeric[j] {
+				if _, err := strconv.ParseFloat(value, 64); err == nil {
+					fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, value)
+					continue
+				}
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&b, []byte(value)); err != nil {
+				return err
+			}
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+
+		// Лист пишется частями, чтобы не держать в памяти весь XML большого каталога
+		if b.Len() > 1<<20 {
+			if _, err := w.Write(b.Bytes()); err != nil {
+				return err
+			}
+			b.Reset()
+		}
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	_, err := w.Write(b.Bytes())
+	return err
+}
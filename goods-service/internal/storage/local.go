@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local хранит файлы в каталоге на диске. Раздаются они обработчиком Handler
+// по адресу baseURL, например http://localhost:9002/media
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (l *Local) Put(ctx context.Context, key, contentType string, data []byte) error {
+	filename, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+
+	// Запись во временный файл и переименование: читатели не увидят файл наполовину записанным
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	filename, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/" + key
+}
+
+// Handler раздает файлы по ключу из пути запроса; путь монтируется с http.StripPrefix.
+// Каталоги не раздаются
+func (l *Local) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filename, err := l.path(strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		info, err := os.Stat(filename)
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		http.ServeFile(w, r, filename)
+	})
+}
+
+// path переводит ключ в путь внутри каталога хранилища; ключи с .. отклоняются
+func (l *Local) path(key string) (string, error) {
+	if key == "" || !fs.ValidPath(key) || path.Clean(key) != key {
+		return "", errors.New("invalid storage key " + key)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLocal(dir, "http://localhost:9002/media/")
+	ctx := context.Background()
+
+	require.NoError(t, l.Put(ctx, "goods/1/a/medium.jpg", "image/jpeg", []byte("jpeg")))
+	data, err := os.ReadFile(filepath.Join(dir, "goods", "1", "a", "medium.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", string(data))
+	assert.Equal(t, "http://localhost:9002/media/goods/1/a/medium.jpg", l.URL("goods/1/a/medium.jpg"))
+
+	server := httptest.NewServer(http.StripPrefix("/media/", l.Handler()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/media/goods/1/a/medium.jpg")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "jpeg", string(body))
+
+	// Каталоги не раздаются
+	resp, err = http.Get(server.URL + "/media/goods/1/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.NoError(t, l.Delete(ctx, "goods/1/a/medium.jpg"))
+	require.NoError(t, l.Delete(ctx, "goods/1/a/medium.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "goods", "1", "a", "medium.jpg"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Error(t, l.Put(ctx, "../escape.jpg", "image/jpeg", []byte("jpeg")))
+	assert.Error(t, l.Put(ctx, "goods/../../escape.jpg", "image/jpeg", []byte("jpeg")))
+}
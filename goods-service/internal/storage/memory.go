@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Memory хранит файлы в памяти процесса: для тестов и сборки allinone без диска
+type Memory struct {
+	mu      sync.Mutex
+	baseURL string
+	objects map[string][]byte
+}
+
+func NewMemory(baseURL string) *Memory {
+	return &Memory{baseURL: strings.TrimSuffix(baseURL, "/"), objects: make(map[string][]byte)}
+}
+
+func (m *Memory) Put(ctx context.Context, key, contentType string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *Memory) URL(key string) string {
+	return m.baseURL + "/" + key
+}
+
+// Get возвращает сохраненный файл или nil
+func (m *Memory) Get(key string) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.objects[key]
+}
+
+// Len возвращает количество файлов
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.objects)
+}
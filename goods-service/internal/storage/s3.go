@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config - настройки S3-совместимого хранилища (AWS S3, MinIO, Yandex Object Storage)
+type S3Config struct {
+	Endpoint  string // http://minio:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL - адрес, по которому файлы доступны клиентам; по умолчанию Endpoint/Bucket
+	PublicURL string
+}
+
+// S3 хранит файлы в бакете S3-совместимого хранилища. Запросы подписываются AWS Signature V4,
+// бакет адресуется в пути (path-style), как принято у MinIO
+type S3 struct {
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3(config S3Config) *S3 {
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.PublicURL == "" {
+		config.PublicURL = config.Endpoint + "/" + config.Bucket
+	}
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+	return &S3{config: config, client: &http.Client{Timeout: 30 * time.Second}, now: time.Now}
+}
+
+func (s *S3) Put(ctx context.Context, key, contentType string, data []byte) error {
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return s.do(req, data)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, nil)
+}
+
+func (s *S3) URL(key string) string {
+	return s.config.PublicURL + "/" + key
+}
+
+func (s *S3) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	target := s.config.Endpoint + "/" + s.config.Bucket + "/" + escapePath(key)
+	return http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+}
+
+func (s *S3) do(req *http.Request, body []byte) error {
+	s.sign(req, body, s.now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// DELETE отсутствующего объекта S3 отвечает 204, поэтому 404 здесь - отсутствие бакета
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// sign добавляет к запросу заголовки подписи AWS Signature V4
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := now.Format("20060102")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format("20060102T150405Z") + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Del("Host")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, value := range vals {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath кодирует ключ объекта, сохраняя разделители каталогов
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
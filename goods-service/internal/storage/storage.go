@@ -0,0 +1,15 @@
+// Package storage хранит файлы изображений товаров: на локальном диске, в S3-совместимом
+// хранилище или в памяти. Файлы адресуются ключом вида goods/1/9f8e.../medium.jpg
+package storage
+
+import "context"
+
+// Storage - хранилище файлов
+type Storage interface {
+	// Put сохраняет файл под ключом, перезаписывая существующий
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Delete удаляет файл; отсутствие файла ошибкой не считается
+	Delete(ctx context.Context, key string) error
+	// URL возвращает публичный адрес файла
+	URL(key string) string
+}
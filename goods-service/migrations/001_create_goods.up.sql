@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS goods (
+    id SERIAL PRIMARY KEY,
+    sku VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    price DECIMAL(10, 2) NOT NULL,
+    stock INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id SERIAL PRIMARY KEY,
+    good_id INT NOT NULL REFERENCES goods(id),
+    order_id INT NOT NULL,
+    quantity INT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- Базы, созданные до появления артикула: добавляем sku и заполняем его для существующих товаров
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS sku VARCHAR(50) UNIQUE;
+UPDATE goods SET sku = 'GOOD-' || LPAD(id::text, 6, '0') WHERE sku IS NULL;
+ALTER TABLE goods ALTER COLUMN sku SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_goods_name ON goods(name);
+CREATE INDEX IF NOT EXISTS idx_goods_sku ON goods(sku);
+CREATE INDEX IF NOT EXISTS idx_reservations_order ON stock_reservations(order_id);
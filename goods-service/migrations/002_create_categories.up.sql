@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    parent_id INT REFERENCES categories(id),
+    slug VARCHAR(100) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- Товар может входить в несколько категорий; связи удаляются вместе с товаром или категорией
+CREATE TABLE IF NOT EXISTS good_categories (
+    good_id INT NOT NULL REFERENCES goods(id) ON DELETE CASCADE,
+    category_id INT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (good_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+CREATE INDEX IF NOT EXISTS idx_good_categories_category ON good_categories(category_id);
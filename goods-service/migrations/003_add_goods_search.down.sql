@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_goods_name_trgm;
+DROP INDEX IF EXISTS idx_goods_search;
+ALTER TABLE goods DROP COLUMN IF EXISTS search_vector;
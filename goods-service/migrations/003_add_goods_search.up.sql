@@ -0,0 +1,15 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Поисковый вектор: название важнее описания (вес A против B). Русская конфигурация приводит
+-- русские слова к основе, английская - латинские названия сортов (Da Hong Pao, Earl Grey)
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian'::regconfig, COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('english'::regconfig, COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('russian'::regconfig, COALESCE(description, '')), 'B') ||
+        setweight(to_tsvector('english'::regconfig, COALESCE(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_goods_search ON goods USING GIN (search_vector);
+-- Поиск по опечаткам: сходство триграмм с названием
+CREATE INDEX IF NOT EXISTS idx_goods_name_trgm ON goods USING GIN (name gin_trgm_ops);
@@ -0,0 +1,2 @@
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS variant_id;
+DROP TABLE IF EXISTS good_variants;
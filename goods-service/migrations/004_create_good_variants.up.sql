@@ -0,0 +1,30 @@
+-- Варианты товара: у каждого свой артикул, цена и остаток. goods.price и goods.stock
+-- остаются сводными значениями по вариантам для каталога, поиска и фасетов
+CREATE TABLE IF NOT EXISTS good_variants (
+    id SERIAL PRIMARY KEY,
+    good_id INT NOT NULL REFERENCES goods(id) ON DELETE CASCADE,
+    sku VARCHAR(50) UNIQUE NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    stock INT NOT NULL DEFAULT 0,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_good_variants_good ON good_variants(good_id);
+
+-- Существующие товары становятся товарами с одним вариантом
+INSERT INTO good_variants (good_id, sku, price, stock, created_at, updated_at)
+SELECT id, sku, price, stock, created_at, updated_at
+FROM goods
+WHERE NOT EXISTS (SELECT 1 FROM good_variants WHERE good_variants.good_id = goods.id);
+
+-- Резервирования относятся к варианту; старые - к единственному варианту своего товара
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES good_variants(id);
+UPDATE stock_reservations
+SET variant_id = good_variants.id
+FROM good_variants
+WHERE good_variants.good_id = stock_reservations.good_id AND stock_reservations.variant_id IS NULL;
+ALTER TABLE stock_reservations ALTER COLUMN variant_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_reservations_variant ON stock_reservations(variant_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_goods_attributes;
+ALTER TABLE goods DROP COLUMN IF EXISTS attributes;
+ALTER TABLE categories DROP COLUMN IF EXISTS attribute_schema;
@@ -0,0 +1,8 @@
+-- Схема атрибутов категории: [{"code": "brew_temp", "name": "Температура заваривания", "type": "number", "unit": "°C", "required": true}]
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS attribute_schema JSONB NOT NULL DEFAULT '[]';
+
+-- Значения атрибутов товара в каноническом виде: {"brew_temp": "85", "origin": "Юньнань"}
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+-- Фильтр по значениям атрибутов - проверка вхождения attributes @> '{"origin": "Юньнань"}'
+CREATE INDEX IF NOT EXISTS idx_goods_attributes ON goods USING GIN (attributes jsonb_path_ops);
@@ -0,0 +1,16 @@
+-- Галерея изображений товара. Файлы лежат в хранилище (диск или S3), в таблице - их ключи:
+-- исходник и уменьшенные копии для карточки товара и списков
+CREATE TABLE IF NOT EXISTS good_images (
+    id SERIAL PRIMARY KEY,
+    good_id INT NOT NULL REFERENCES goods(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    original_key VARCHAR(255) NOT NULL,
+    medium_key VARCHAR(255) NOT NULL,
+    thumbnail_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_good_images_good ON good_images(good_id, position);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_movements;
+DROP FUNCTION IF EXISTS forbid_stock_movement_update();
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS status;
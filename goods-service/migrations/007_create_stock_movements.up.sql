@@ -0,0 +1,55 @@
+-- Резервирование завершается продажей (оплаченный заказ) или снятием (заказ не оплачен).
+-- Строки резервирований остаются: по проданным и открытым считается популярность товара.
+-- До статусов резервирования не закрывались, а заказы сервису не видны, поэтому прежние строки
+-- считаются проданными: иначе они навсегда остались бы открытыми и не давали архивировать товар.
+-- Статус выставляется до значения по умолчанию, которое относится только к новым строкам
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS status VARCHAR(20);
+UPDATE stock_reservations SET status = 'sold' WHERE status IS NULL;
+ALTER TABLE stock_reservations ALTER COLUMN status SET DEFAULT 'reserved';
+ALTER TABLE stock_reservations ALTER COLUMN status SET NOT NULL;
+
+-- Журнал складских движений. Каждое изменение остатка варианта записывается сюда в той же
+-- транзакции: quantity - изменение остатка (good_variants.stock), reserved - изменение
+-- зарезервированного количества, balance - остаток варианта после движения
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    good_id INT NOT NULL REFERENCES goods(id) ON DELETE RESTRICT,
+    variant_id INT NOT NULL REFERENCES good_variants(id) ON DELETE RESTRICT,
+    type VARCHAR(20) NOT NULL,
+    quantity INT NOT NULL,
+    reserved INT NOT NULL DEFAULT 0,
+    balance INT NOT NULL,
+    order_id BIGINT,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_good ON stock_movements(good_id, id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_variant ON stock_movements(variant_id, id);
+
+-- Журнал только дополняется: исправление - новое движение, а не правка или удаление старого.
+-- Поэтому товары и варианты с движениями не удаляются, а архивируются
+CREATE OR REPLACE FUNCTION forbid_stock_movement_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements;
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION forbid_stock_movement_update();
+
+-- Начальные остатки: движение, с которого журнал сходится с текущим остатком и открытым резервом вариантов
+INSERT INTO stock_movements (good_id, variant_id, type, quantity, reserved, balance, reason, created_at)
+SELECT
+    good_variants.good_id,
+    good_variants.id,
+    'adjustment',
+    good_variants.stock,
+    COALESCE((SELECT SUM(quantity) FROM stock_reservations WHERE variant_id = good_variants.id AND status = 'reserved'), 0),
+    good_variants.stock,
+    'opening balance',
+    NOW()
+FROM good_variants
+WHERE NOT EXISTS (SELECT 1 FROM stock_movements WHERE variant_id = good_variants.id);
@@ -0,0 +1,4 @@
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS warehouse_id;
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS warehouse_id;
+DROP TABLE IF EXISTS warehouse_stock;
+DROP TABLE IF EXISTS warehouses;
@@ -0,0 +1,47 @@
+-- Склады, с которых отгружаются заказы. regions - города и регионы, которые склад обслуживает
+-- ближе других: с ними сравнивается адрес доставки. priority упорядочивает склады при прочих равных
+CREATE TABLE IF NOT EXISTS warehouses (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    regions TEXT[] NOT NULL DEFAULT '{}',
+    priority INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO warehouses (code, name, priority, created_at, updated_at)
+SELECT 'main', 'Основной склад', 0, NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM warehouses);
+
+-- Остатки вариантов по складам; good_variants.stock - их сумма
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+    warehouse_id INT NOT NULL REFERENCES warehouses(id),
+    variant_id INT NOT NULL REFERENCES good_variants(id) ON DELETE CASCADE,
+    stock INT NOT NULL CHECK (stock >= 0),
+    PRIMARY KEY (warehouse_id, variant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_variant ON warehouse_stock(variant_id);
+
+-- Весь текущий остаток числится на складе с наименьшим приоритетом
+INSERT INTO warehouse_stock (warehouse_id, variant_id, stock)
+SELECT (SELECT id FROM warehouses ORDER BY priority, id LIMIT 1), id, stock
+FROM good_variants
+WHERE stock > 0
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS warehouse_id INT REFERENCES warehouses(id);
+UPDATE stock_reservations SET warehouse_id = (SELECT id FROM warehouses ORDER BY priority, id LIMIT 1)
+WHERE warehouse_id IS NULL;
+ALTER TABLE stock_reservations ALTER COLUMN warehouse_id SET NOT NULL;
+
+-- Движения журнала относятся к складу; существующие записываются на тот же склад, что и остаток.
+-- Журнал только дополняется, поэтому запрет изменений снимается лишь на время заполнения колонки
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS warehouse_id INT REFERENCES warehouses(id);
+ALTER TABLE stock_movements DISABLE TRIGGER stock_movements_append_only;
+UPDATE stock_movements SET warehouse_id = (SELECT id FROM warehouses ORDER BY priority, id LIMIT 1)
+WHERE warehouse_id IS NULL;
+ALTER TABLE stock_movements ENABLE TRIGGER stock_movements_append_only;
+ALTER TABLE stock_movements ALTER COLUMN warehouse_id SET NOT NULL;
@@ -0,0 +1,19 @@
+-- Задания импорта каталога из таблиц. Строки обрабатываются в фоне, processed_rows - прогресс.
+-- errors - ошибки строк [{"row": 3, "column": "price", "sku": "PUER-1", "message": "..."}],
+-- error - причина, по которой задание не завершилось
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id SERIAL PRIMARY KEY,
+    format VARCHAR(10) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL,
+    total_rows INT NOT NULL DEFAULT 0,
+    processed_rows INT NOT NULL DEFAULT 0,
+    created_count INT NOT NULL DEFAULT 0,
+    updated_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- Удаленный товар архивируется: пропадает из каталога и поиска, но остается доступен по id
+-- для истории заказов. NULL - товар в каталоге
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
@@ -0,0 +1 @@
+ALTER TABLE good_variants DROP COLUMN IF EXISTS archived_at;
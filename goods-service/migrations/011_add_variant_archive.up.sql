@@ -0,0 +1,3 @@
+-- Удаленный вариант архивируется: на него ссылаются журнал движений и резервирования заказов.
+-- Архивный вариант не показывается у товара и не учитывается в его цене и остатке; артикул остается занят
+ALTER TABLE good_variants ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
@@ -0,0 +1,28 @@
+// Package app собирает слои notify-service для запуска внутри другого процесса:
+// e2e тестов и сборок, где сервисы работают в одном бинарнике
+package app
+
+import (
+	"context"
+
+	"github.com/che1nov/tea-shop/notify-service/internal/kafka"
+	"github.com/che1nov/tea-shop/notify-service/internal/service"
+	"github.com/che1nov/tea-shop/shared/pkg/eventbus"
+)
+
+// Sender отправляет уведомление пользователю
+type Sender = service.Sender
+
+// RunInMemory читает события заказов из шины bus и отправляет уведомления через sender,
+// пока не будет отменён ctx. Если sender равен nil, письма только пишутся в лог
+func RunInMemory(ctx context.Context, bus *eventbus.Bus, sender Sender) error {
+	if sender == nil {
+		sender = service.LogSender{}
+	}
+
+	consumer := kafka.NewBusConsumer(bus, "notify-service")
+	defer consumer.Close()
+
+	svc := service.New(sender)
+	return consumer.Start(ctx, svc.HandleEvent)
+}
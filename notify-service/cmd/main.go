@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,75 +10,80 @@ import (
 	"github.com/che1nov/tea-shop/notify-service/config"
 	"github.com/che1nov/tea-shop/notify-service/internal/kafka"
 	"github.com/che1nov/tea-shop/notify-service/internal/service"
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/health"
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/che1nov/tea-shop/shared/pkg/server"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
 )
 
 func main() {
 	// Инициализируем logger
-	logger.Init()
+	logger.Init("notify-service")
 
-	cfg := config.Load()
-
-	// Инициализируем Kafka consumer
-	consumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.Group)
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	// Инициализируем сервис
-	svc := service.New(cfg.Email.From)
+	// Подкоманды: notify-service config
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Создаем контекст с отменой для graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
+	// Контекст отменяется по сигналу ОС или при ошибке consumer
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// Запускаем HTTP сервер для метрик Prometheus
-	metricsPort := 9006
-	metricsMux := http.NewServeMux()
-	metricsMux.Handle("/metrics", promhttp.Handler())
-	metricsServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", metricsPort),
-		Handler: metricsMux,
+	// Трейсинг: экспортер выбирается через TRACING_EXPORTER
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Error("Failed to initialize tracing", "error", err)
+		panic(err)
 	}
+	defer shutdownTracing(context.Background())
+
+	// Инициализируем Kafka consumer
+	consumer := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.Group)
 
-	// Канал для получения сигналов ОС
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	// Инициализируем сервис
+	svc := service.New(service.LogSender{From: cfg.Email.From})
 
-	// Запускаем HTTP сервер для метрик в отдельной горутине
-	go func() {
-		logger.Info("Notify Service metrics server started", "port", metricsPort)
-		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Metrics server error", "error", err)
-		}
-	}()
+	// gRPC сервер нужен только для health check: статус зависит от доступности Kafka
+	srv := server.New(server.Config{
+		Name:        "notify-service",
+		Port:        cfg.Server.Port,
+		MetricsPort: cfg.Server.MetricsPort,
+	}, health.Check{Name: "kafka", Func: consumer.HealthCheck})
+	srv.OnShutdown("kafka consumer", consumer.Close)
 
 	// Запускаем consumer в отдельной горутине
-	errChan := make(chan error, 1)
 	go func() {
 		logger.Info("Notify Service started, listening for events...")
-		if err := consumer.Start(ctx, svc.HandleEvent); err != nil {
-			errChan <- err
+		if err := consumer.Start(ctx, svc.HandleEvent); err != nil && ctx.Err() == nil {
+			logger.Error("Consumer error", "error", err)
+			cancel()
 		}
 	}()
 
-	// Ожидаем сигнал или ошибку
-	select {
-	case <-sigChan:
-		logger.Info("Shutting down Notify Service...")
-		cancel() // Отменяем контекст, чтобы consumer остановился
-	case err := <-errChan:
-		logger.Error("Consumer error", "error", err)
-		cancel()
-	}
-
-	// Graceful shutdown HTTP сервера метрик
-	if err := metricsServer.Close(); err != nil {
-		logger.Error("Error closing metrics server", "error", err)
+	if err := srv.Run(ctx); err != nil {
+		logger.Error("Notify Service stopped with error", "error", err)
 	}
+}
 
-	// Закрываем consumer
-	if err := consumer.Close(); err != nil {
-		logger.Error("Error closing Kafka consumer", "error", err)
+// runCommand выполняет подкоманду вместо запуска сервера
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "config":
+		return sharedconfig.Print(os.Stdout, cfg)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], sharedconfig.Usage)
 	}
-
-	logger.Info("Notify Service stopped")
 }
@@ -1,40 +1,55 @@
 package config
 
-import "os"
+import (
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
 
 type Config struct {
-	Server struct {
-		Port int
-	}
+	sharedconfig.App `yaml:",inline"`
+	Server           struct {
+		Port int `yaml:"port" env:"SERVER_PORT" validate:"port"`
+		// Порт HTTP сервера метрик (/metrics, /loglevel)
+		MetricsPort int `yaml:"metrics_port" env:"METRICS_PORT" validate:"port"`
+	} `yaml:"server"`
 	Kafka struct {
-		Brokers []string
-		Group   string
-	}
+		Brokers []string `yaml:"brokers" env:"KAFKA_BROKERS" validate:"required,hostport"`
+		Group   string   `yaml:"group" env:"KAFKA_GROUP" validate:"required"`
+	} `yaml:"kafka"`
 	Email struct {
-		SMTPHost string
-		SMTPPort string
-		From     string
-		Password string
-	}
+		SMTPHost string `yaml:"smtp_host" env:"SMTP_HOST"`
+		SMTPPort string `yaml:"smtp_port" env:"SMTP_PORT"`
+		From     string `yaml:"from" env:"EMAIL_FROM" validate:"required"`
+		Password string `yaml:"password" env:"EMAIL_PASSWORD" secret:"true"`
+	} `yaml:"email"`
+	// Трейсинг OpenTelemetry
+	Tracing tracing.Config `yaml:"tracing"`
 }
 
-func Load() *Config {
+// Load собирает конфигурацию: значения по умолчанию → YAML файл (--config или CONFIG_FILE) →
+// переменные окружения → флаги. Возвращает аргументы после флагов (подкоманду)
+func Load(args []string) (*Config, []string, error) {
 	cfg := &Config{}
 
+	cfg.Env = sharedconfig.EnvDevelopment
 	cfg.Server.Port = 8006
+	cfg.Server.MetricsPort = 9006
 	cfg.Kafka.Brokers = []string{"localhost:9092"}
 	cfg.Kafka.Group = "notify-service"
-	cfg.Email.SMTPHost = getEnv("SMTP_HOST", "smtp.gmail.com")
-	cfg.Email.SMTPPort = getEnv("SMTP_PORT", "587")
-	cfg.Email.From = getEnv("EMAIL_FROM", "noreply@ecommerce.com")
-	cfg.Email.Password = getEnv("EMAIL_PASSWORD", "")
+	cfg.Email.SMTPHost = "smtp.gmail.com"
+	cfg.Email.SMTPPort = "587"
+	cfg.Email.From = "noreply@ecommerce.com"
 
-	return cfg
-}
+	cfg.Tracing = tracing.Config{
+		ServiceName: "notify-service",
+		Exporter:    tracing.ExporterNone,
+		Endpoint:    "localhost:4317",
+		FilePath:    "traces.json",
+	}
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	args, err := sharedconfig.Load(cfg, args)
+	if err != nil {
+		return nil, nil, err
 	}
-	return defaultValue
+	return cfg, args, nil
 }
@@ -0,0 +1,46 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/che1nov/tea-shop/shared/pkg/eventbus"
+	"github.com/segmentio/kafka-go"
+)
+
+// NewBusConsumer создаёт consumer, читающий события из шины в памяти процесса вместо Kafka
+func NewBusConsumer(bus *eventbus.Bus, groupID string) *Consumer {
+	return &Consumer{
+		reader: busReader{subscription: bus.Subscribe(Topic, groupID)},
+		ping: func(ctx context.Context) error {
+			return nil
+		},
+	}
+}
+
+// busReader отдаёт сообщения шины в виде сообщений kafka-go
+type busReader struct {
+	subscription *eventbus.Subscription
+}
+
+func (r busReader) ReadMessage(ctx context.Context) (kafka.Message, error) {
+	msg, err := r.subscription.Next(ctx)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return kafka.Message{
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Offset:  msg.Offset,
+	}, nil
+}
+
+func (r busReader) Close() error {
+	return r.subscription.Close()
+}
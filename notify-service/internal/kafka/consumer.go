@@ -3,9 +3,18 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type OrderEvent struct {
@@ -16,41 +25,113 @@ type OrderEvent struct {
 	TotalPrice float64 `json:"total_price"`
 }
 
+// Topic - топик событий заказов
+const Topic = "order-events"
+
+// messageReader - источник сообщений: kafka.Reader или шина событий в памяти
+type messageReader interface {
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+	Close() error
+}
+
 type Consumer struct {
-	reader *kafka.Reader
+	reader messageReader
+	ping   func(ctx context.Context) error
 }
 
 func NewConsumer(brokers []string, groupID string) *Consumer {
 	return &Consumer{
 		reader: kafka.NewReader(kafka.ReaderConfig{
 			Brokers: brokers,
-			Topic:   "order-events",
+			Topic:   Topic,
 			GroupID: groupID,
 		}),
+		ping: func(ctx context.Context) error {
+			return pingBrokers(ctx, brokers)
+		},
 	}
 }
 
-func (c *Consumer) Start(ctx context.Context, handleEvent func(*OrderEvent) error) error {
+func (c *Consumer) Start(ctx context.Context, handleEvent func(context.Context, *OrderEvent) error) error {
 	for {
 		msg, err := c.reader.ReadMessage(ctx)
 		if err != nil {
 			return err
 		}
 
-		event := &OrderEvent{}
-		if err := json.Unmarshal(msg.Value, event); err != nil {
-			logger.Error("Failed to unmarshal event", "error", err)
-			continue
-		}
+		c.process(ctx, msg, handleEvent)
+	}
+}
 
-		logger.Info("Received event", "event_type", event.EventType, "order_id", event.OrderID)
+// process обрабатывает сообщение в consumer спане, продолжающем трейс из заголовков сообщения
+func (c *Consumer) process(ctx context.Context, msg kafka.Message, handleEvent func(context.Context, *OrderEvent) error) {
+	carrier := headerCarrier{message: &msg}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	if requestID := carrier.Get(correlation.HeaderRequestID); correlation.ValidRequestID(requestID) {
+		ctx = correlation.WithRequestID(ctx, requestID)
+	}
+	ctx, span := tracing.Tracer("notify-service/kafka").Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingKafkaOffset(int(msg.Offset)),
+		),
+	)
+	defer span.End()
 
-		if err := handleEvent(event); err != nil {
-			logger.Error("Failed to handle event", "error", err, "event_type", event.EventType, "order_id", event.OrderID)
-		}
+	event := &OrderEvent{}
+	if err := json.Unmarshal(msg.Value, event); err != nil {
+		logger.ErrorContext(ctx, "Failed to unmarshal event", "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.SetAttributes(
+		attribute.String("event_type", event.EventType),
+		attribute.Int64("order_id", event.OrderID),
+	)
+
+	ctx = correlation.WithUserID(ctx, event.UserID)
+
+	logger.InfoContext(ctx, "Received event", "event_type", event.EventType, "order_id", event.OrderID)
+
+	if err := handleEvent(ctx, event); err != nil {
+		logger.ErrorContext(ctx, "Failed to handle event", "error", err, "event_type", event.EventType, "order_id", event.OrderID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
 }
 
+// HealthCheck проверяет, что хотя бы один брокер Kafka доступен
+func (c *Consumer) HealthCheck(ctx context.Context) error {
+	return c.ping(ctx)
+}
+
 func (c *Consumer) Close() error {
 	return c.reader.Close()
 }
+
+// pingBrokers подключается к брокерам по очереди и запрашивает метаданные кластера
+func pingBrokers(ctx context.Context, brokers []string) error {
+	if len(brokers) == 0 {
+		return errors.New("no kafka brokers configured")
+	}
+
+	var lastErr error
+	for _, broker := range brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_, err = conn.Brokers()
+		conn.Close()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("kafka is unavailable: %w", lastErr)
+}
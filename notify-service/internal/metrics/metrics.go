@@ -0,0 +1,13 @@
+// Package metrics - метрики отправки уведомлений для Prometheus.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// NotificationsSent - результаты отправки уведомлений по типу события (sent, failed)
+var NotificationsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "notifications_sent_total",
+	Help: "Total number of notification attempts, by event type and result.",
+}, []string{"event_type", "result"})
@@ -1,58 +1,88 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/che1nov/tea-shop/notify-service/internal/kafka"
+	"github.com/che1nov/tea-shop/notify-service/internal/metrics"
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
 )
 
-type NotifyService struct {
-	emailFrom string
+// Sender отправляет уведомление пользователю
+type Sender interface {
+	Send(ctx context.Context, userID int64, subject, body string) error
 }
 
-func New(emailFrom string) *NotifyService {
-	return &NotifyService{
-		emailFrom: emailFrom,
-	}
+// LogSender имитирует отправку email: письмо только пишется в лог
+type LogSender struct {
+	From string
 }
 
-func (s *NotifyService) HandleOrderCreated(event *kafka.OrderEvent) error {
-	// Имитируем отправку email
-	logger.Info("Sending email notification for order created", "order_id", event.OrderID, "total_price", event.TotalPrice)
-
+func (s LogSender) Send(ctx context.Context, userID int64, subject, body string) error {
 	// В реальной системе здесь будет:
 	// - подключение к SMTP серверу
 	// - формирование HTML письма
 	// - отправка email
-
+	logger.InfoContext(ctx, "Sending email notification", "from", s.From, "user_id", userID, "subject", subject)
 	return nil
 }
 
-func (s *NotifyService) HandleOrderCompleted(event *kafka.OrderEvent) error {
-	// Имитируем отправку email
-	logger.Info("Sending email notification for order completed", "order_id", event.OrderID, "status", event.Status)
+type NotifyService struct {
+	sender Sender
+}
 
-	// В реальной системе здесь будет отправка email с информацией о завершении заказа
+func New(sender Sender) *NotifyService {
+	return &NotifyService{
+		sender: sender,
+	}
+}
 
-	return nil
+func (s *NotifyService) HandleOrderCreated(ctx context.Context, event *kafka.OrderEvent) error {
+	return s.sender.Send(ctx, event.UserID,
+		fmt.Sprintf("Заказ #%d оформлен", event.OrderID),
+		fmt.Sprintf("Заказ #%d на сумму %.2f оформлен, статус: %s", event.OrderID, event.TotalPrice, event.Status),
+	)
 }
 
-func (s *NotifyService) HandleOrderPaymentFailed(event *kafka.OrderEvent) error {
-	logger.Info("Sending email notification for order payment failed", "order_id", event.OrderID)
+func (s *NotifyService) HandleOrderCompleted(ctx context.Context, event *kafka.OrderEvent) error {
+	return s.sender.Send(ctx, event.UserID,
+		fmt.Sprintf("Заказ #%d выполнен", event.OrderID),
+		fmt.Sprintf("Заказ #%d выполнен, статус: %s", event.OrderID, event.Status),
+	)
+}
 
-	return nil
+func (s *NotifyService) HandleOrderPaymentFailed(ctx context.Context, event *kafka.OrderEvent) error {
+	return s.sender.Send(ctx, event.UserID,
+		fmt.Sprintf("Оплата заказа #%d не прошла", event.OrderID),
+		fmt.Sprintf("Не удалось оплатить заказ #%d на сумму %.2f", event.OrderID, event.TotalPrice),
+	)
 }
 
-func (s *NotifyService) HandleEvent(event *kafka.OrderEvent) error {
+// HandleEvent выбирает уведомление по типу события; отправка видна в трейсе заказа отдельным спаном
+func (s *NotifyService) HandleEvent(ctx context.Context, event *kafka.OrderEvent) error {
+	ctx, span := tracing.Tracer("notify-service").Start(ctx, "notify "+event.EventType)
+	defer span.End()
+
+	var err error
 	switch event.EventType {
 	case "order.created":
-		return s.HandleOrderCreated(event)
+		err = s.HandleOrderCreated(ctx, event)
 	case "order.completed":
-		return s.HandleOrderCompleted(event)
+		err = s.HandleOrderCompleted(ctx, event)
 	case "order.payment_failed":
-		return s.HandleOrderPaymentFailed(event)
+		err = s.HandleOrderPaymentFailed(ctx, event)
 	default:
+		// Неизвестные типы не попадают в лейбл, чтобы не плодить ряды
+		metrics.NotificationsSent.WithLabelValues("unknown", "failed").Inc()
 		return fmt.Errorf("unknown event type: %s", event.EventType)
 	}
+
+	result := "sent"
+	if err != nil {
+		result = "failed"
+	}
+	metrics.NotificationsSent.WithLabelValues(event.EventType, result).Inc()
+	return err
 }
@@ -0,0 +1,44 @@
+// Package app собирает слои order-service для запуска внутри другого процесса:
+// e2e тестов и сборок, где сервисы работают в одном бинарнике
+package app
+
+import (
+	"database/sql"
+
+	"github.com/che1nov/tea-shop/order-service/internal/handler"
+	"github.com/che1nov/tea-shop/order-service/internal/kafka"
+	"github.com/che1nov/tea-shop/order-service/internal/repository"
+	"github.com/che1nov/tea-shop/order-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/eventbus"
+)
+
+// Clients - клиенты сервисов, к которым обращается order-service
+type Clients struct {
+	Goods    pb.GoodsServiceClient
+	Payments pb.PaymentsServiceClient
+	Delivery pb.DeliveryServiceClient
+}
+
+// New собирает gRPC API order-service поверх БД;
+// события заказов публикуются в шину bus вместо Kafka
+func New(db *sql.DB, bus *eventbus.Bus, clients Clients) pb.OrdersServiceServer {
+	return build(repository.New(db), bus, clients)
+}
+
+// NewInMemory собирает gRPC API order-service поверх репозитория в памяти;
+// события заказов публикуются в шину bus вместо Kafka
+func NewInMemory(bus *eventbus.Bus, clients Clients) pb.OrdersServiceServer {
+	return build(repository.NewMemory(), bus, clients)
+}
+
+func build(repo repository.OrderRepositoryInterface, bus *eventbus.Bus, clients Clients) pb.OrdersServiceServer {
+	svc := service.New(
+		repo,
+		kafka.NewBusProducer(bus),
+		clients.Goods,
+		clients.Payments,
+		clients.Delivery,
+	)
+	return handler.New(svc)
+}
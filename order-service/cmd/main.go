@@ -1,104 +1,89 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
-
-	_ "github.com/lib/pq"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/health"
-	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
 
 	"github.com/che1nov/tea-shop/order-service/config"
 	"github.com/che1nov/tea-shop/order-service/internal/handler"
 	"github.com/che1nov/tea-shop/order-service/internal/kafka"
 	"github.com/che1nov/tea-shop/order-service/internal/repository"
 	"github.com/che1nov/tea-shop/order-service/internal/service"
+	"github.com/che1nov/tea-shop/order-service/migrations"
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/grpcclient"
+	"github.com/che1nov/tea-shop/shared/pkg/health"
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/migrate"
+	"github.com/che1nov/tea-shop/shared/pkg/server"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
 )
 
 func main() {
 	// Инициализируем logger
-	logger.Init()
+	logger.Init("order-service")
 
-	cfg := config.Load()
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// Подкоманды: order-service migrate up|down|status, config
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Подключение к БД
-	dbConnStr := fmt.Sprintf(
-		"user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Name,
-		cfg.Database.Host,
-		cfg.Database.Port,
-	)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	db, err := sql.Open("postgres", dbConnStr)
+	// Трейсинг: экспортер выбирается через TRACING_EXPORTER
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
 	if err != nil {
-		logger.Error("Failed to open database", "error", err)
+		logger.Error("Failed to initialize tracing", "error", err)
 		panic(err)
 	}
+	defer shutdownTracing(context.Background())
 
-	// Проверяем подключение к БД
-	if err := db.Ping(); err != nil {
-		logger.Error("Failed to connect to database", "error", err)
-		panic(fmt.Sprintf("failed to connect to database: %v", err))
+	db, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		logger.Error("Failed to initialize database", "error", err)
+		panic(err)
 	}
 	logger.Info("Database connection established")
 
-	// Создаём таблицы
-	createTablesSQL := `
-		CREATE TABLE IF NOT EXISTS orders (
-			id SERIAL PRIMARY KEY,
-			user_id INT NOT NULL,
-			items JSONB NOT NULL,
-			status VARCHAR(50) NOT NULL,
-			total_price DECIMAL(10, 2) NOT NULL,
-			address TEXT,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_orders_user ON orders(user_id);
-		CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
-
-		-- Миграция: добавляем колонку address для существующих заказов, если её нет
-		DO $$
-		BEGIN
-			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='orders' AND column_name='address') THEN
-				ALTER TABLE orders ADD COLUMN address TEXT;
-			END IF;
-		END $$;
-	`
-	if _, err := db.Exec(createTablesSQL); err != nil {
-		panic(err)
+	// Схема БД: версионированные миграции из migrations/
+	if cfg.Database.AutoMigrate {
+		if err := migrate.Apply(ctx, db, migrations.FS); err != nil {
+			logger.Error("Failed to apply migrations", "error", err)
+			panic(err)
+		}
 	}
 
 	// Инициализируем Kafka producer
 	producer := kafka.NewProducer(cfg.Kafka.Brokers)
 
 	// Подключаемся к другим сервисам через gRPC
-	goodsConn, err := grpc.Dial(cfg.Services.GoodsService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	goodsConn, err := grpcclient.Dial("goods-service", cfg.Services.GoodsService, cfg.Clients)
 	if err != nil {
 		panic(err)
 	}
 
-	paymentConn, err := grpc.Dial(cfg.Services.PaymentService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	paymentConn, err := grpcclient.Dial("payment-service", cfg.Services.PaymentService, cfg.Clients)
 	if err != nil {
 		panic(err)
 	}
 
-	deliveryConn, err := grpc.Dial(cfg.Services.DeliveryService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	deliveryConn, err := grpcclient.Dial("delivery-service", cfg.Services.DeliveryService, cfg.Clients)
 	if err != nil {
 		panic(err)
 	}
@@ -111,95 +96,32 @@ func main() {
 	svc := service.New(repo, producer, goodsClient, paymentClient, deliveryClient)
 	hdlr := handler.New(svc)
 
-	// Запускаем HTTP сервер для метрик Prometheus ПЕРВЫМ
-	metricsPort := 9003
-	metricsMux := http.NewServeMux()
-	metricsMux.Handle("/metrics", promhttp.Handler())
-	metricsServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", metricsPort),
-		Handler: metricsMux,
-	}
-
-	// Канал для получения сигналов ОС
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	// Запускаем HTTP сервер для метрик в отдельной горутине (ПЕРВЫМ)
-	go func() {
-		logger.Info("Order Service metrics server starting", "port", metricsPort)
-		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Metrics server error", "error", err)
-		}
-	}()
-
-	// Небольшая задержка для запуска HTTP сервера метрик
-	time.Sleep(100 * time.Millisecond)
-
-	// Запускаем gRPC сервер
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
-	if err != nil {
-		logger.Error("Failed to create gRPC listener", "error", err, "port", cfg.Server.Port)
-		logger.Warn("gRPC server will not start, but metrics server is running")
-		// Закрываем соединения
-		if err := producer.Close(); err != nil {
-			logger.Error("Error closing Kafka producer", "error", err)
-		}
-		<-sigChan
-		logger.Info("Shutting down Order Service...")
-		if err := metricsServer.Close(); err != nil {
-			logger.Error("Error closing metrics server", "error", err)
-		}
-		logger.Info("Order Service stopped")
-		return
-	}
-
-	grpcServer := grpc.NewServer()
-	pb.RegisterOrdersServiceServer(grpcServer, hdlr)
-
-	// Health check
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("pb.OrdersService", grpc_health_v1.HealthCheckResponse_SERVING)
-
-	// Enable gRPC reflection for easier testing
-	reflection.Register(grpcServer)
-
-	// Запускаем gRPC сервер в отдельной горутине
-	go func() {
-		logger.Info("Order Service gRPC server started", "port", cfg.Server.Port)
-		if err := grpcServer.Serve(listener); err != nil {
-			logger.Error("gRPC server error", "error", err)
-		}
-	}()
-
-	// Ожидаем сигнал для graceful shutdown
-	<-sigChan
-	logger.Info("Shutting down Order Service...")
-
-	// Graceful shutdown HTTP сервера метрик
-	if err := metricsServer.Close(); err != nil {
-		logger.Error("Error closing metrics server", "error", err)
-	}
-
-	// Graceful shutdown gRPC сервера
-	grpcServer.GracefulStop()
-
-	// Закрываем соединения
-	if err := producer.Close(); err != nil {
-		logger.Error("Error closing Kafka producer", "error", err)
-	}
-
-	if err := goodsConn.Close(); err != nil {
-		logger.Error("Error closing goods service connection", "error", err)
-	}
-
-	if err := paymentConn.Close(); err != nil {
-		logger.Error("Error closing payment service connection", "error", err)
+	srv := server.New(server.Config{
+		Name:        "order-service",
+		Port:        cfg.Server.Port,
+		MetricsPort: cfg.Server.MetricsPort,
+	}, health.Database(db), health.Check{Name: "kafka", Func: producer.HealthCheck})
+	// Закрываются в обратном порядке: клиенты и Kafka, затем БД
+	srv.OnShutdown("database", db.Close)
+	srv.OnShutdown("kafka producer", producer.Close)
+	srv.OnShutdown("goods-service connection", goodsConn.Close)
+	srv.OnShutdown("payment-service connection", paymentConn.Close)
+	srv.OnShutdown("delivery-service connection", deliveryConn.Close)
+	pb.RegisterOrdersServiceServer(srv, hdlr)
+
+	if err := srv.Run(ctx); err != nil {
+		logger.Error("Order Service stopped with error", "error", err)
 	}
+}
 
-	if err := db.Close(); err != nil {
-		logger.Error("Error closing database", "error", err)
+// runCommand выполняет подкоманду вместо запуска сервера
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "config":
+		return sharedconfig.Print(os.Stdout, cfg)
+	case "migrate":
+		return migrate.RunCommand(context.Background(), cfg.Database, migrations.FS, args[1:], os.Stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s\n%s", args[0], migrate.Usage, sharedconfig.Usage)
 	}
-
-	logger.Info("Order Service stopped")
 }
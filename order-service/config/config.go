@@ -1,50 +1,66 @@
 package config
 
-import "os"
+import (
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/grpcclient"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
 
 type Config struct {
-	Database struct {
-		Host     string
-		Port     string
-		User     string
-		Password string
-		Name     string
-	}
-	Server struct {
-		Port int
-	}
+	sharedconfig.App `yaml:",inline"`
+	Database         database.Config `yaml:"database"`
+	Server           struct {
+		Port int `yaml:"port" env:"SERVER_PORT" validate:"port"`
+		// Порт HTTP сервера метрик (/metrics, /loglevel)
+		MetricsPort int `yaml:"metrics_port" env:"METRICS_PORT" validate:"port"`
+	} `yaml:"server"`
 	Kafka struct {
-		Brokers []string
-	}
+		Brokers []string `yaml:"brokers" env:"KAFKA_BROKERS" validate:"required,hostport"`
+	} `yaml:"kafka"`
 	Services struct {
-		GoodsService    string
-		PaymentService  string
-		DeliveryService string
-		UserService     string
-	}
+		GoodsService    string `yaml:"goods" env:"GOODS_SERVICE_ADDR" validate:"required,hostport"`
+		PaymentService  string `yaml:"payments" env:"PAYMENTS_SERVICE_ADDR" validate:"required,hostport"`
+		DeliveryService string `yaml:"delivery" env:"DELIVERY_SERVICE_ADDR" validate:"required,hostport"`
+		UserService     string `yaml:"users" env:"USERS_SERVICE_ADDR" validate:"required,hostport"`
+	} `yaml:"services"`
+	// Повторы, hedging, circuit breaker и keepalive клиентов сервисов
+	Clients grpcclient.Config `yaml:"clients"`
+	// Трейсинг OpenTelemetry
+	Tracing tracing.Config `yaml:"tracing"`
 }
 
-func Load() *Config {
+// Load собирает конфигурацию: значения по умолчанию → YAML файл (--config или CONFIG_FILE) →
+// переменные окружения → флаги. Возвращает аргументы после флагов (подкоманду)
+func Load(args []string) (*Config, []string, error) {
 	cfg := &Config{}
 
-	cfg.Database.Host = getEnv("DB_HOST", "localhost")
-	cfg.Database.Port = getEnv("DB_PORT", "5434")
-	cfg.Database.User = getEnv("DB_USER", "user")
-	cfg.Database.Password = getEnv("DB_PASSWORD", "password")
-	cfg.Database.Name = getEnv("DB_NAME", "orders_db")
+	cfg.Env = sharedconfig.EnvDevelopment
+	cfg.Database.Host = "localhost"
+	cfg.Database.Port = "5434"
+	cfg.Database.User = "user"
+	cfg.Database.Password = "password"
+	cfg.Database.Name = "orders_db"
+	cfg.Database.AutoMigrate = true
 	cfg.Server.Port = 8003
+	cfg.Server.MetricsPort = 9003
 	cfg.Kafka.Brokers = []string{"localhost:9092"}
 	cfg.Services.GoodsService = "localhost:8002"
 	cfg.Services.PaymentService = "localhost:8004"
 	cfg.Services.DeliveryService = "localhost:8005"
 	cfg.Services.UserService = "localhost:8001"
+	cfg.Clients = grpcclient.DefaultConfig()
 
-	return cfg
-}
+	cfg.Tracing = tracing.Config{
+		ServiceName: "order-service",
+		Exporter:    tracing.ExporterNone,
+		Endpoint:    "localhost:4317",
+		FilePath:    "traces.json",
+	}
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	args, err := sharedconfig.Load(cfg, args)
+	if err != nil {
+		return nil, nil, err
 	}
-	return defaultValue
+	return cfg, args, nil
 }
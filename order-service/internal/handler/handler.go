@@ -2,10 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"github.com/che1nov/tea-shop/order-service/internal/model"
 	"github.com/che1nov/tea-shop/order-service/internal/service"
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type OrdersHandler struct {
@@ -23,9 +26,10 @@ func (h *OrdersHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderRequ
 	items := make([]model.OrderItem, len(req.Items))
 	for i, item := range req.Items {
 		items[i] = model.OrderItem{
-			GoodID:   item.GoodId,
-			Quantity: item.Quantity,
-			Price:    item.Price,
+			GoodID:    item.GoodId,
+			VariantID: item.VariantId,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
 		}
 	}
 
@@ -34,6 +38,9 @@ func (h *OrdersHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderRequ
 		Items:   items,
 		Address: req.Address,
 	})
+	if errors.Is(err, service.ErrVariantNotFound) || errors.Is(err, service.ErrVariantRequired) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -63,13 +70,30 @@ func (h *OrdersHandler) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrd
 	return h.orderToProto(order), nil
 }
 
+func (h *OrdersHandler) ReplayOrderEvents(ctx context.Context, req *pb.ReplayOrderEventsRequest) (*pb.ReplayOrderEventsResponse, error) {
+	if len(req.OrderIds) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "order_ids is required")
+	}
+
+	published, err := h.service.ReplayOrderEvents(ctx, req.OrderIds)
+	if errors.Is(err, service.ErrOrderNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to replay order events: %v", err)
+	}
+
+	return &pb.ReplayOrderEventsResponse{Published: int32(published)}, nil
+}
+
 func (h *OrdersHandler) orderToProto(order *model.Order) *pb.Order {
 	items := make([]*pb.OrderItem, len(order.Items))
 	for i, item := range order.Items {
 		items[i] = &pb.OrderItem{
-			GoodId:   item.GoodID,
-			Quantity: item.Quantity,
-			Price:    item.Price,
+			GoodId:    item.GoodID,
+			VariantId: item.VariantID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
 		}
 	}
 
@@ -2,13 +2,17 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/che1nov/tea-shop/order-service/internal/model"
+	"github.com/che1nov/tea-shop/order-service/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // MockOrderService - мок для сервиса
@@ -48,6 +52,11 @@ func (m *MockOrderService) ListUserOrders(ctx context.Context, userID int64) ([]
 	return args.Get(0).([]*model.Order), args.Error(1)
 }
 
+func (m *MockOrderService) ReplayOrderEvents(ctx context.Context, ids []int64) (int, error) {
+	args := m.Called(ctx, ids)
+	return args.Int(0), args.Error(1)
+}
+
 func TestNew(t *testing.T) {
 	mockService := new(MockOrderService)
 	handler := New(mockService)
@@ -132,6 +141,40 @@ func TestUpdateOrderStatus_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestReplayOrderEvents_Success(t *testing.T) {
+	mockService := new(MockOrderService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ReplayOrderEvents", ctx, []int64{1, 2}).Return(2, nil)
+
+	resp, err := handler.ReplayOrderEvents(ctx, &pb.ReplayOrderEventsRequest{OrderIds: []int64{1, 2}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Published)
+	mockService.AssertExpectations(t)
+}
+
+func TestReplayOrderEvents_NotFound(t *testing.T) {
+	mockService := new(MockOrderService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ReplayOrderEvents", ctx, []int64{999}).Return(0, fmt.Errorf("%w: 999", service.ErrOrderNotFound))
+
+	_, err := handler.ReplayOrderEvents(ctx, &pb.ReplayOrderEventsRequest{OrderIds: []int64{999}})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestReplayOrderEvents_EmptyRequest(t *testing.T) {
+	handler := New(new(MockOrderService))
+
+	_, err := handler.ReplayOrderEvents(context.Background(), &pb.ReplayOrderEventsRequest{})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestOrderToProto(t *testing.T) {
 	mockService := new(MockOrderService)
 	handler := New(mockService)
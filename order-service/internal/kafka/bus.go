@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/che1nov/tea-shop/shared/pkg/eventbus"
+	"github.com/segmentio/kafka-go"
+)
+
+// NewBusProducer создаёт producer, публикующий события в шину в памяти процесса вместо Kafka
+func NewBusProducer(bus *eventbus.Bus) *Producer {
+	return &Producer{
+		writer: busWriter{bus: bus, topic: Topic},
+		topic:  Topic,
+		ping: func(ctx context.Context) error {
+			return nil
+		},
+	}
+}
+
+// busWriter перекладывает сообщения kafka-go в шину событий
+type busWriter struct {
+	bus   *eventbus.Bus
+	topic string
+}
+
+func (w busWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		headers := make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		err := w.bus.Publish(ctx, eventbus.Message{
+			Topic:   w.topic,
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: headers,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w busWriter) Close() error {
+	return nil
+}
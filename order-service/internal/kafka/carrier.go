@@ -0,0 +1,35 @@
+package kafka
+
+import "github.com/segmentio/kafka-go"
+
+// headerCarrier позволяет propagator'у OpenTelemetry писать контекст трейса в заголовки сообщения
+type headerCarrier struct {
+	message *kafka.Message
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range c.message.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range c.message.Headers {
+		if h.Key == key {
+			c.message.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.message.Headers = append(c.message.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.message.Headers))
+	for _, h := range c.message.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
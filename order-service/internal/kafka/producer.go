@@ -3,8 +3,17 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type OrderEvent struct {
@@ -15,48 +24,112 @@ type OrderEvent struct {
 	TotalPrice float64 `json:"total_price"`
 }
 
+// Topic - топик событий заказов
+const Topic = "order-events"
+
+// messageWriter - транспорт сообщений: kafka.Writer или шина событий в памяти
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
 type Producer struct {
-	writer *kafka.Writer
+	writer messageWriter
+	topic  string
+	ping   func(ctx context.Context) error
 }
 
 func NewProducer(brokers []string) *Producer {
 	return &Producer{
 		writer: &kafka.Writer{
 			Addr:     kafka.TCP(brokers...),
-			Topic:    "order-events",
+			Topic:    Topic,
 			Balancer: &kafka.LeastBytes{},
 		},
+		topic: Topic,
+		ping: func(ctx context.Context) error {
+			return pingBrokers(ctx, brokers)
+		},
 	}
 }
 
 func (p *Producer) PublishOrderCreated(ctx context.Context, event *OrderEvent) error {
 	event.EventType = "order.created"
-
-	data, err := json.Marshal(event)
-	if err != nil {
-		return err
-	}
-
-	return p.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte("order_" + string(rune(event.OrderID))),
-		Value: data,
-	})
+	return p.publish(ctx, event)
 }
 
 func (p *Producer) PublishOrderCompleted(ctx context.Context, event *OrderEvent) error {
 	event.EventType = "order.completed"
+	return p.publish(ctx, event)
+}
+
+// publish отправляет событие в отдельном producer спане, контекст трейса передается в заголовках сообщения
+func (p *Producer) publish(ctx context.Context, event *OrderEvent) error {
+	ctx, span := tracing.Tracer("order-service/kafka").Start(ctx, p.topic+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(p.topic),
+			attribute.String("event_type", event.EventType),
+			attribute.Int64("order_id", event.OrderID),
+		),
+	)
+	defer span.End()
 
 	data, err := json.Marshal(event)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
 
-	return p.writer.WriteMessages(ctx, kafka.Message{
+	msg := kafka.Message{
 		Key:   []byte("order_" + string(rune(event.OrderID))),
 		Value: data,
-	})
+	}
+	carrier := headerCarrier{message: &msg}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if requestID := correlation.RequestID(ctx); requestID != "" {
+		carrier.Set(correlation.HeaderRequestID, requestID)
+	}
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// HealthCheck проверяет, что хотя бы один брокер Kafka доступен
+func (p *Producer) HealthCheck(ctx context.Context) error {
+	return p.ping(ctx)
 }
 
 func (p *Producer) Close() error {
 	return p.writer.Close()
 }
+
+// pingBrokers подключается к брокерам по очереди и запрашивает метаданные кластера
+func pingBrokers(ctx context.Context, brokers []string) error {
+	if len(brokers) == 0 {
+		return errors.New("no kafka brokers configured")
+	}
+
+	var lastErr error
+	for _, broker := range brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_, err = conn.Brokers()
+		conn.Close()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("kafka is unavailable: %w", lastErr)
+}
@@ -0,0 +1,34 @@
+// Package metrics - бизнес метрики заказов для Prometheus.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// OrdersCreated - созданные заказы по итоговому статусу (paid, payment_failed)
+	OrdersCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_created_total",
+		Help: "Total number of created orders, by resulting status.",
+	}, []string{"status"})
+
+	// Revenue - сумма оплаченных заказов
+	Revenue = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "orders_revenue_total",
+		Help: "Total amount of paid orders.",
+	})
+
+	// OrderValue - распределение сумм заказов
+	OrderValue = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "orders_value",
+		Help:    "Distribution of order totals.",
+		Buckets: []float64{100, 250, 500, 1000, 2500, 5000, 10000, 25000},
+	})
+
+	// OrderStatusChanges - ручные смены статуса заказа
+	OrderStatusChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_status_changes_total",
+		Help: "Total number of order status updates, by new status.",
+	}, []string{"status"})
+)
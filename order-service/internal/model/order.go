@@ -2,10 +2,12 @@ package model
 
 import "time"
 
+// OrderItem хранится в JSONB заказа; у заказов, созданных до вариантов товара, VariantID = 0
 type OrderItem struct {
-	GoodID   int64
-	Quantity int32
-	Price    float64
+	GoodID    int64
+	VariantID int64
+	Quantity  int32
+	Price     float64
 }
 
 type Order struct {
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/che1nov/tea-shop/order-service/internal/model"
+)
+
+// MemoryRepository - репозиторий заказов в памяти (для тестов и запуска без БД)
+type MemoryRepository struct {
+	mu     sync.Mutex
+	orders map[int64]*model.Order
+	lastID int64
+}
+
+func NewMemory() *MemoryRepository {
+	return &MemoryRepository{orders: make(map[int64]*model.Order)}
+}
+
+func (r *MemoryRepository) CreateOrder(ctx context.Context, order *model.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	order.ID = r.lastID
+	now := time.Now()
+	order.CreatedAt = now
+	order.UpdatedAt = now
+
+	r.orders[order.ID] = copyOrder(order)
+	return nil
+}
+
+func (r *MemoryRepository) GetOrder(ctx context.Context, id int64) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyOrder(order), nil
+}
+
+func (r *MemoryRepository) UpdateOrderStatus(ctx context.Context, id int64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if order, ok := r.orders[id]; ok {
+		order.Status = status
+		order.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListUserOrders(ctx context.Context, userID int64) ([]*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var orders []*model.Order
+	for _, order := range r.orders {
+		if order.UserID == userID {
+			orders = append(orders, copyOrder(order))
+		}
+	}
+	// Новые заказы первыми; id разрешает совпадение времени создания
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID > orders[j].ID
+	})
+	return orders, nil
+}
+
+func copyOrder(order *model.Order) *model.Order {
+	copied := *order
+	copied.Items = append([]model.OrderItem(nil), order.Items...)
+	return &copied
+}
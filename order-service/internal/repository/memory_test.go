@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/order-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_Orders(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	first := &model.Order{UserID: 1, Items: []model.OrderItem{{GoodID: 1, Quantity: 2, Price: 50}}, Status: "pending"}
+	second := &model.Order{UserID: 1, Status: "pending"}
+	other := &model.Order{UserID: 2, Status: "pending"}
+	for _, order := range []*model.Order{first, second, other} {
+		require.NoError(t, repo.CreateOrder(ctx, order))
+	}
+
+	require.NoError(t, repo.UpdateOrderStatus(ctx, first.ID, "paid"))
+	stored, err := repo.GetOrder(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "paid", stored.Status)
+	assert.Equal(t, first.Items, stored.Items)
+
+	orders, err := repo.ListUserOrders(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, second.ID, orders[0].ID)
+
+	missing, err := repo.GetOrder(ctx, 42)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
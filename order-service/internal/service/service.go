@@ -2,10 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
 
 	"github.com/che1nov/tea-shop/order-service/internal/kafka"
+	"github.com/che1nov/tea-shop/order-service/internal/metrics"
 	"github.com/che1nov/tea-shop/order-service/internal/model"
 	"github.com/che1nov/tea-shop/order-service/internal/repository"
 )
@@ -23,8 +32,29 @@ type OrderServiceInterface interface {
 	GetOrder(ctx context.Context, id int64) (*model.Order, error)
 	UpdateOrderStatus(ctx context.Context, id int64, status string) (*model.Order, error)
 	ListUserOrders(ctx context.Context, userID int64) ([]*model.Order, error)
+	ReplayOrderEvents(ctx context.Context, ids []int64) (int, error)
 }
 
+var (
+	// ErrOrderNotFound - заказ не найден
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrVariantNotFound - в позиции заказа вариант другого товара или несуществующий вариант
+	ErrVariantNotFound = errors.New("good variant not found")
+	// ErrVariantRequired - у товара несколько вариантов, а позиция заказа не указывает какой
+	ErrVariantRequired = errors.New("good has several variants, variant_id is required")
+)
+
+const (
+	// stockAttempts - сколько раз списываются или снимаются резервы заказа, прежде чем ошибка
+	// попадет в лог; оба вызова goods-service идемпотентны, поэтому повтор безопасен
+	stockAttempts = 3
+	// stockCallTimeout ограничивает один вызов goods-service, выполняемый без контекста запроса
+	stockCallTimeout = 5 * time.Second
+)
+
+// stockRetryDelay - пауза перед повтором, растет с номером попытки
+var stockRetryDelay = 200 * time.Millisecond
+
 type OrderService struct {
 	repo               repository.OrderRepositoryInterface
 	producer           KafkaProducerInterface
@@ -54,7 +84,8 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 	var totalPrice float64
 
 	// Проверяем наличие всех товаров
-	for _, item := range req.Items {
+	for i := range req.Items {
+		item := &req.Items[i]
 		good, err := s.goodsServiceConn.GetGood(ctx, &pb.GetGoodRequest{GoodId: item.GoodID})
 		if err != nil {
 			return nil, err
@@ -64,13 +95,20 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 			return nil, nil
 		}
 
-		item.Price = good.Price
-		totalPrice += good.Price * float64(item.Quantity)
+		// Цена и остаток - у варианта товара; в заказе сохраняется, какой вариант куплен
+		variant, err := orderVariant(good, item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		item.VariantID = variant.Id
+		item.Price = variant.Price
+		totalPrice += variant.Price * float64(item.Quantity)
 
 		// Проверяем наличие товара
 		checkResp, err := s.goodsServiceConn.CheckStock(ctx, &pb.CheckStockRequest{
-			GoodId:   item.GoodID,
-			Quantity: item.Quantity,
+			GoodId:    item.GoodID,
+			VariantId: item.VariantID,
+			Quantity:  item.Quantity,
 		})
 		if err != nil {
 			return nil, err
@@ -94,16 +132,26 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 		return nil, err
 	}
 
-	// Зарезервировали товары
+	// Резервируем все позиции одним запросом: goods-service выбирает склады по своей стратегии,
+	// учитывая адрес доставки
+	reserveReq := &pb.ReserveOrderRequest{OrderId: order.ID, Address: order.Address}
 	for _, item := range req.Items {
-		_, err := s.goodsServiceConn.ReserveStock(ctx, &pb.ReserveStockRequest{
-			GoodId:   item.GoodID,
-			Quantity: item.Quantity,
-			OrderId:  order.ID,
+		reserveReq.Items = append(reserveReq.Items, &pb.ReserveOrderItem{
+			GoodId:    item.GoodID,
+			VariantId: item.VariantID,
+			Quantity:  item.Quantity,
 		})
-		if err != nil {
-			return nil, err
-		}
+	}
+	reserveResp, err := s.goodsServiceConn.ReserveOrder(ctx, reserveReq)
+	if err != nil {
+		// Ответ потерян, но резерв мог состояться: снимаем его вместе с отменой заказа
+		s.abortOrder(ctx, order.ID, "cancelled")
+		return nil, err
+	}
+	if !reserveResp.Success {
+		// Остаток закончился после проверки: заказ отменяется, как если бы товара не хватило сразу
+		s.abortOrder(ctx, order.ID, "cancelled")
+		return nil, nil
 	}
 
 	// Обрабатываем платёж
@@ -113,27 +161,53 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 		Method:  "card",
 	})
 	if err != nil {
+		if !paymentRejected(err) {
+			// Исход платежа неизвестен: деньги могли быть списаны. Заказ остается pending
+			// с резервом до сверки с payment-service, снимать резерв сейчас нельзя
+			logger.ErrorContext(ctx, "Payment outcome unknown, order left pending", "order_id", order.ID, "error", err)
+			return nil, err
+		}
+		s.abortOrder(ctx, order.ID, "payment_failed")
 		return nil, err
 	}
 
 	if paymentResp.Status == "completed" {
 		order.Status = "paid"
-		s.repo.UpdateOrderStatus(ctx, order.ID, "paid")
-		
+		if err := s.repo.UpdateOrderStatus(ctx, order.ID, order.Status); err != nil {
+			// Платеж прошел, поэтому резерв не снимается: заказ остается pending до сверки
+			logger.ErrorContext(ctx, "Failed to mark order paid", "order_id", order.ID, "error", err)
+			return nil, fmt.Errorf("failed to mark order %d paid: %w", order.ID, err)
+		}
+
+		// Резервы оплаченного заказа списываются как продажа. Если это не удалось и после повторов,
+		// резервы остаются в журнале незавершенными: CommitStock заказа нужно повторить вручную
+		if err := s.commitStock(ctx, order.ID); err != nil {
+			logger.ErrorContext(ctx, "Failed to commit order stock", "order_id", order.ID, "error", err)
+		}
+
 		// После успешной оплаты автоматически создаем доставку
 		if order.Address != "" {
+			warehouses := allocationWarehouses(reserveResp.Allocations)
 			_, err := s.deliveryServiceConn.CreateDelivery(ctx, &pb.CreateDeliveryRequest{
-				OrderId: order.ID,
-				Address: order.Address,
+				OrderId:    order.ID,
+				Address:    order.Address,
+				Warehouses: warehouses,
 			})
 			if err != nil {
-				// Логируем ошибку, но не прерываем создание заказа
-				// Доставка может быть создана позже вручную
+				// Заказ уже оплачен, поэтому создание не прерывается: доставка создается позже вручную
+				logger.ErrorContext(ctx, "Failed to create delivery", "order_id", order.ID, "warehouses", warehouses, "error", err)
 			}
 		}
 	} else {
+		// Неоплаченный заказ возвращает зарезервированное количество в остаток
 		order.Status = "payment_failed"
-		s.repo.UpdateOrderStatus(ctx, order.ID, "payment_failed")
+		s.abortOrder(ctx, order.ID, order.Status)
+	}
+
+	metrics.OrdersCreated.WithLabelValues(order.Status).Inc()
+	metrics.OrderValue.Observe(order.TotalPrice)
+	if order.Status == "paid" {
+		metrics.Revenue.Add(order.TotalPrice)
 	}
 
 	// Публикуем событие в Kafka
@@ -147,6 +221,91 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 	return order, nil
 }
 
+// abortOrder переводит заказ, который не будет оплачен, в status и снимает его резервы.
+// Ошибки только логируются: вызывающий уже возвращает свою ошибку или отказ
+func (s *OrderService) abortOrder(ctx context.Context, orderID int64, status string) {
+	if err := s.repo.UpdateOrderStatus(ctx, orderID, status); err != nil {
+		logger.ErrorContext(ctx, "Failed to update order status", "order_id", orderID, "status", status, "error", err)
+	}
+	if err := s.releaseStock(ctx, orderID); err != nil {
+		logger.ErrorContext(ctx, "Failed to release order stock", "order_id", orderID, "error", err)
+	}
+}
+
+// paymentRejected сообщает, что payment-service окончательно отклонил платеж. Остальные ошибки -
+// сбой связи или сервиса, после которого платеж мог пройти
+func paymentRejected(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition:
+		return true
+	default:
+		return false
+	}
+}
+
+// commitStock списывает резервы оплаченного заказа как продажу
+func (s *OrderService) commitStock(ctx context.Context, orderID int64) error {
+	return retryStock(ctx, func(ctx context.Context) error {
+		_, err := s.goodsServiceConn.CommitStock(ctx, &pb.CommitStockRequest{OrderId: orderID})
+		return err
+	})
+}
+
+// releaseStock возвращает резервы заказа в остаток
+func (s *OrderService) releaseStock(ctx context.Context, orderID int64) error {
+	return retryStock(ctx, func(ctx context.Context) error {
+		_, err := s.goodsServiceConn.ReleaseStock(ctx, &pb.ReleaseStockRequest{OrderId: orderID})
+		return err
+	})
+}
+
+// retryStock выполняет вызов до stockAttempts раз. Контекст отделяется от запроса: отмена
+// запроса клиентом не должна оставлять резервы заказа открытыми
+func retryStock(ctx context.Context, call func(ctx context.Context) error) error {
+	ctx = context.WithoutCancel(ctx)
+	var err error
+	for attempt := 1; attempt <= stockAttempts; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, stockCallTimeout)
+		err = call(callCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt < stockAttempts {
+			time.Sleep(time.Duration(attempt) * stockRetryDelay)
+		}
+	}
+	return err
+}
+
+// allocationWarehouses возвращает коды складов, с которых отгружается заказ, без повторов
+func allocationWarehouses(allocations []*pb.Allocation) []string {
+	var codes []string
+	for _, allocation := range allocations {
+		if !slices.Contains(codes, allocation.WarehouseCode) {
+			codes = append(codes, allocation.WarehouseCode)
+		}
+	}
+	return codes
+}
+
+// orderVariant возвращает вариант товара из позиции заказа; без variantID - единственный вариант товара
+func orderVariant(good *pb.Good, variantID int64) (*pb.Variant, error) {
+	if variantID == 0 {
+		if len(good.Variants) != 1 {
+			return nil, ErrVariantRequired
+		}
+		return good.Variants[0], nil
+	}
+
+	for _, variant := range good.Variants {
+		if variant.Id == variantID {
+			return variant, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrVariantNotFound, variantID)
+}
+
 func (s *OrderService) GetOrder(ctx context.Context, id int64) (*model.Order, error) {
 	return s.repo.GetOrder(ctx, id)
 }
@@ -155,6 +314,7 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, id int64, status s
 	if err := s.repo.UpdateOrderStatus(ctx, id, status); err != nil {
 		return nil, err
 	}
+	metrics.OrderStatusChanges.WithLabelValues(status).Inc()
 
 	return s.repo.GetOrder(ctx, id)
 }
@@ -162,3 +322,37 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, id int64, status s
 func (s *OrderService) ListUserOrders(ctx context.Context, userID int64) ([]*model.Order, error) {
 	return s.repo.ListUserOrders(ctx, userID)
 }
+
+// ReplayOrderEvents повторно публикует события заказов по их текущему состоянию:
+// order.completed для завершённых заказов, order.created для остальных. Возвращает число опубликованных событий
+func (s *OrderService) ReplayOrderEvents(ctx context.Context, ids []int64) (int, error) {
+	// Сначала загружаем все заказы, чтобы не опубликовать события только части из них
+	orders := make([]*model.Order, 0, len(ids))
+	for _, id := range ids {
+		order, err := s.repo.GetOrder(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		if order == nil {
+			return 0, fmt.Errorf("%w: %d", ErrOrderNotFound, id)
+		}
+		orders = append(orders, order)
+	}
+
+	for i, order := range orders {
+		publish := s.producer.PublishOrderCreated
+		if order.Status == "completed" {
+			publish = s.producer.PublishOrderCompleted
+		}
+		err := publish(ctx, &kafka.OrderEvent{
+			OrderID:    order.ID,
+			UserID:     order.UserID,
+			Status:     order.Status,
+			TotalPrice: order.TotalPrice,
+		})
+		if err != nil {
+			return i, fmt.Errorf("failed to publish event for order %d: %w", order.ID, err)
+		}
+	}
+	return len(orders), nil
+}
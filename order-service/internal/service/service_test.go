@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/che1nov/tea-shop/order-service/internal/kafka"
@@ -9,6 +10,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
 )
@@ -67,9 +70,11 @@ func (m *MockProducer) Close() error {
 	return args.Error(0)
 }
 
-// MockGoodsServiceClient - мок для gRPC клиента goods service
+// MockGoodsServiceClient - мок для gRPC клиента goods service. Встроенный интерфейс закрывает методы,
+// которые сервис заказов не вызывает
 type MockGoodsServiceClient struct {
 	mock.Mock
+	pb.GoodsServiceClient
 }
 
 func (m *MockGoodsServiceClient) GetGood(ctx context.Context, req *pb.GetGoodRequest, opts ...grpc.CallOption) (*pb.Good, error) {
@@ -96,17 +101,34 @@ func (m *MockGoodsServiceClient) CheckStock(ctx context.Context, req *pb.CheckSt
 	return args.Get(0).(*pb.CheckStockResponse), args.Error(1)
 }
 
-func (m *MockGoodsServiceClient) ReserveStock(ctx context.Context, req *pb.ReserveStockRequest, opts ...grpc.CallOption) (*pb.ReserveStockResponse, error) {
+func (m *MockGoodsServiceClient) ReserveOrder(ctx context.Context, req *pb.ReserveOrderRequest, opts ...grpc.CallOption) (*pb.ReserveOrderResponse, error) {
 	args := m.Called(ctx, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*pb.ReserveStockResponse), args.Error(1)
+	return args.Get(0).(*pb.ReserveOrderResponse), args.Error(1)
+}
+
+func (m *MockGoodsServiceClient) CommitStock(ctx context.Context, req *pb.CommitStockRequest, opts ...grpc.CallOption) (*pb.CommitStockResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.CommitStockResponse), args.Error(1)
+}
+
+func (m *MockGoodsServiceClient) ReleaseStock(ctx context.Context, req *pb.ReleaseStockRequest, opts ...grpc.CallOption) (*pb.ReleaseStockResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.ReleaseStockResponse), args.Error(1)
 }
 
 // MockPaymentsServiceClient - мок для gRPC клиента payment service
 type MockPaymentsServiceClient struct {
 	mock.Mock
+	pb.PaymentsServiceClient
 }
 
 func (m *MockPaymentsServiceClient) ProcessPayment(ctx context.Context, req *pb.ProcessPaymentRequest, opts ...grpc.CallOption) (*pb.Payment, error) {
@@ -125,13 +147,27 @@ func (m *MockPaymentsServiceClient) GetPayment(ctx context.Context, req *pb.GetP
 	return args.Get(0).(*pb.Payment), args.Error(1)
 }
 
+// MockDeliveryServiceClient - мок для gRPC клиента delivery service
+type MockDeliveryServiceClient struct {
+	mock.Mock
+	pb.DeliveryServiceClient
+}
+
+func (m *MockDeliveryServiceClient) CreateDelivery(ctx context.Context, req *pb.CreateDeliveryRequest, opts ...grpc.CallOption) (*pb.Delivery, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.Delivery), args.Error(1)
+}
+
 func TestNew(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockProducer := new(MockProducer)
 	mockGoodsClient := new(MockGoodsServiceClient)
 	mockPaymentClient := new(MockPaymentsServiceClient)
 
-	service := New(mockRepo, mockProducer, mockGoodsClient, mockPaymentClient)
+	service := New(mockRepo, mockProducer, mockGoodsClient, mockPaymentClient, new(MockDeliveryServiceClient))
 
 	assert.NotNil(t, service)
 	assert.Equal(t, mockRepo, service.repo)
@@ -140,7 +176,7 @@ func TestNew(t *testing.T) {
 
 func TestGetOrder_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := New(mockRepo, new(MockProducer), new(MockGoodsServiceClient), new(MockPaymentsServiceClient))
+	service := New(mockRepo, new(MockProducer), new(MockGoodsServiceClient), new(MockPaymentsServiceClient), new(MockDeliveryServiceClient))
 	ctx := context.Background()
 
 	expectedOrder := &model.Order{
@@ -162,7 +198,7 @@ func TestGetOrder_Success(t *testing.T) {
 
 func TestUpdateOrderStatus_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := New(mockRepo, new(MockProducer), new(MockGoodsServiceClient), new(MockPaymentsServiceClient))
+	service := New(mockRepo, new(MockProducer), new(MockGoodsServiceClient), new(MockPaymentsServiceClient), new(MockDeliveryServiceClient))
 	ctx := context.Background()
 
 	expectedOrder := &model.Order{
@@ -185,7 +221,7 @@ func TestUpdateOrderStatus_Success(t *testing.T) {
 
 func TestListUserOrders_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := New(mockRepo, new(MockProducer), new(MockGoodsServiceClient), new(MockPaymentsServiceClient))
+	service := New(mockRepo, new(MockProducer), new(MockGoodsServiceClient), new(MockPaymentsServiceClient), new(MockDeliveryServiceClient))
 	ctx := context.Background()
 
 	expectedOrders := []*model.Order{
@@ -203,6 +239,234 @@ func TestListUserOrders_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
-// Note: CreateOrder тест более сложный, так как требует множества моков
-// Для полного тестирования нужны моки для goods и payment клиентов
+func TestCreateOrder_SingleVariantGood(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockProducer := new(MockProducer)
+	mockGoodsClient := new(MockGoodsServiceClient)
+	mockPaymentClient := new(MockPaymentsServiceClient)
+	mockDeliveryClient := new(MockDeliveryServiceClient)
+	service := New(mockRepo, mockProducer, mockGoodsClient, mockPaymentClient, mockDeliveryClient)
+	ctx := context.Background()
+
+	mockGoodsClient.On("GetGood", ctx, &pb.GetGoodRequest{GoodId: 1}).Return(&pb.Good{
+		Id:       1,
+		Price:    300,
+		Variants: []*pb.Variant{{Id: 11, GoodId: 1, Price: 300, Stock: 5}},
+	}, nil)
+	mockGoodsClient.On("CheckStock", ctx, &pb.CheckStockRequest{GoodId: 1, VariantId: 11, Quantity: 2}).
+		Return(&pb.CheckStockResponse{Available: true}, nil)
+	mockRepo.On("CreateOrder", ctx, mock.MatchedBy(func(order *model.Order) bool {
+		return assert.ObjectsAreEqual([]model.OrderItem{{GoodID: 1, VariantID: 11, Quantity: 2, Price: 300}}, order.Items)
+	})).Return(nil)
+	mockGoodsClient.On("ReserveOrder", ctx, &pb.ReserveOrderRequest{
+		OrderId: 1,
+		Items:   []*pb.ReserveOrderItem{{GoodId: 1, VariantId: 11, Quantity: 2}},
+		Address: "Москва",
+	}).Return(&pb.ReserveOrderResponse{Success: true, Allocations: []*pb.Allocation{
+		{GoodId: 1, VariantId: 11, WarehouseCode: "main", Quantity: 1},
+		{GoodId: 1, VariantId: 11, WarehouseCode: "spb", Quantity: 1},
+	}}, nil)
+	mockPaymentClient.On("ProcessPayment", ctx, mock.Anything).Return(&pb.Payment{Status: "completed"}, nil)
+	mockRepo.On("UpdateOrderStatus", ctx, int64(1), "paid").Return(nil)
+	mockGoodsClient.On("CommitStock", mock.Anything, &pb.CommitStockRequest{OrderId: 1}).Return(&pb.CommitStockResponse{Success: true, Sold: 2}, nil)
+	mockDeliveryClient.On("CreateDelivery", ctx, &pb.CreateDeliveryRequest{
+		OrderId:    1,
+		Address:    "Москва",
+		Warehouses: []string{"main", "spb"},
+	}).Return(&pb.Delivery{}, nil)
+	mockProducer.On("PublishOrderCreated", ctx, mock.Anything).Return(nil)
+
+	order, err := service.CreateOrder(ctx, &model.CreateOrderRequest{
+		UserID:  100,
+		Items:   []model.OrderItem{{GoodID: 1, Quantity: 2}},
+		Address: "Москва",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "paid", order.Status)
+	assert.Equal(t, 600.0, order.TotalPrice)
+	mock.AssertExpectationsForObjects(t, mockRepo, mockProducer, mockGoodsClient, mockPaymentClient, mockDeliveryClient)
+}
+
+func TestCreateOrder_PaymentFailedReleasesStock(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockProducer := new(MockProducer)
+	mockGoodsClient := new(MockGoodsServiceClient)
+	mockPaymentClient := new(MockPaymentsServiceClient)
+	service := New(mockRepo, mockProducer, mockGoodsClient, mockPaymentClient, new(MockDeliveryServiceClient))
+	ctx := context.Background()
+
+	mockGoodsClient.On("GetGood", ctx, &pb.GetGoodRequest{GoodId: 1}).Return(&pb.Good{
+		Id:       1,
+		Price:    300,
+		Variants: []*pb.Variant{{Id: 11, GoodId: 1, Price: 300, Stock: 5}},
+	}, nil)
+	mockGoodsClient.On("CheckStock", ctx, &pb.CheckStockRequest{GoodId: 1, VariantId: 11, Quantity: 2}).
+		Return(&pb.CheckStockResponse{Available: true}, nil)
+	mockRepo.On("CreateOrder", ctx, mock.Anything).Return(nil)
+	mockGoodsClient.On("ReserveOrder", ctx, mock.Anything).Return(&pb.ReserveOrderResponse{Success: true}, nil)
+	mockPaymentClient.On("ProcessPayment", ctx, mock.Anything).Return(&pb.Payment{Status: "failed"}, nil)
+	mockRepo.On("UpdateOrderStatus", ctx, int64(1), "payment_failed").Return(nil)
+	mockGoodsClient.On("ReleaseStock", mock.Anything, &pb.ReleaseStockRequest{OrderId: 1}).Return(&pb.ReleaseStockResponse{Success: true, Released: 2}, nil)
+	mockProducer.On("PublishOrderCreated", ctx, mock.Anything).Return(nil)
+
+	order, err := service.CreateOrder(ctx, &model.CreateOrderRequest{UserID: 100, Items: []model.OrderItem{{GoodID: 1, Quantity: 2}}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "payment_failed", order.Status)
+	mock.AssertExpectationsForObjects(t, mockRepo, mockProducer, mockGoodsClient, mockPaymentClient)
+}
+
+func TestCreateOrder_ReservationLostCancelsOrder(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockGoodsClient := new(MockGoodsServiceClient)
+	service := New(mockRepo, new(MockProducer), mockGoodsClient, new(MockPaymentsServiceClient), new(MockDeliveryServiceClient))
+	ctx := context.Background()
+
+	mockGoodsClient.On("GetGood", ctx, &pb.GetGoodRequest{GoodId: 1}).Return(&pb.Good{
+		Id:       1,
+		Variants: []*pb.Variant{{Id: 11, GoodId: 1, Price: 300, Stock: 5}},
+	}, nil)
+	mockGoodsClient.On("CheckStock", ctx, mock.Anything).Return(&pb.CheckStockResponse{Available: true}, nil)
+	mockRepo.On("CreateOrder", ctx, mock.Anything).Return(nil)
+	// Параллельный заказ успел забрать остаток между проверкой и резервированием
+	mockGoodsClient.On("ReserveOrder", ctx, mock.Anything).Return(&pb.ReserveOrderResponse{Success: false}, nil)
+	mockRepo.On("UpdateOrderStatus", ctx, int64(1), "cancelled").Return(nil)
+	mockGoodsClient.On("ReleaseStock", mock.Anything, &pb.ReleaseStockRequest{OrderId: 1}).Return(&pb.ReleaseStockResponse{Success: true}, nil)
+
+	order, err := service.CreateOrder(ctx, &model.CreateOrderRequest{UserID: 100, Items: []model.OrderItem{{GoodID: 1, Quantity: 2}}})
+
+	assert.NoError(t, err)
+	assert.Nil(t, order)
+	mock.AssertExpectationsForObjects(t, mockRepo, mockGoodsClient)
+}
+
+func TestCreateOrder_PaymentRejectedReleasesStock(t *testing.T) {
+	stockRetryDelay = 0
+	mockRepo := new(MockRepository)
+	mockGoodsClient := new(MockGoodsServiceClient)
+	mockPaymentClient := new(MockPaymentsServiceClient)
+	service := New(mockRepo, new(MockProducer), mockGoodsClient, mockPaymentClient, new(MockDeliveryServiceClient))
+	ctx := context.Background()
+
+	mockGoodsClient.On("GetGood", ctx, &pb.GetGoodRequest{GoodId: 1}).Return(&pb.Good{
+		Id:       1,
+		Variants: []*pb.Variant{{Id: 11, GoodId: 1, Price: 300, Stock: 5}},
+	}, nil)
+	mockGoodsClient.On("CheckStock", ctx, mock.Anything).Return(&pb.CheckStockResponse{Available: true}, nil)
+	mockRepo.On("CreateOrder", ctx, mock.Anything).Return(nil)
+	mockGoodsClient.On("ReserveOrder", ctx, mock.Anything).Return(&pb.ReserveOrderResponse{Success: true}, nil)
+	mockPaymentClient.On("ProcessPayment", ctx, mock.Anything).Return(nil, status.Error(codes.InvalidArgument, "invalid amount"))
+	mockRepo.On("UpdateOrderStatus", ctx, int64(1), "payment_failed").Return(nil)
+	// Первая попытка снять резерв не удалась, вторая проходит
+	mockGoodsClient.On("ReleaseStock", mock.Anything, &pb.ReleaseStockRequest{OrderId: 1}).Return(nil, errors.New("goods service unavailable")).Once()
+	mockGoodsClient.On("ReleaseStock", mock.Anything, &pb.ReleaseStockRequest{OrderId: 1}).Return(&pb.ReleaseStockResponse{Success: true, Released: 2}, nil).Once()
+
+	order, err := service.CreateOrder(ctx, &model.CreateOrderRequest{UserID: 100, Items: []model.OrderItem{{GoodID: 1, Quantity: 2}}})
+
+	assert.Error(t, err)
+	assert.Nil(t, order)
+	mock.AssertExpectationsForObjects(t, mockRepo, mockGoodsClient, mockPaymentClient)
+}
+
+func TestCreateOrder_PaymentUnavailableKeepsReservation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockGoodsClient := new(MockGoodsServiceClient)
+	mockPaymentClient := new(MockPaymentsServiceClient)
+	service := New(mockRepo, new(MockProducer), mockGoodsClient, mockPaymentClient, new(MockDeliveryServiceClient))
+	ctx := context.Background()
+
+	mockGoodsClient.On("GetGood", ctx, &pb.GetGoodRequest{GoodId: 1}).Return(&pb.Good{
+		Id:       1,
+		Variants: []*pb.Variant{{Id: 11, GoodId: 1, Price: 300, Stock: 5}},
+	}, nil)
+	mockGoodsClient.On("CheckStock", ctx, mock.Anything).Return(&pb.CheckStockResponse{Available: true}, nil)
+	mockRepo.On("CreateOrder", ctx, mock.Anything).Return(nil)
+	mockGoodsClient.On("ReserveOrder", ctx, mock.Anything).Return(&pb.ReserveOrderResponse{Success: true}, nil)
+	mockPaymentClient.On("ProcessPayment", ctx, mock.Anything).Return(nil, status.Error(codes.Unavailable, "connection refused"))
+
+	order, err := service.CreateOrder(ctx, &model.CreateOrderRequest{UserID: 100, Items: []model.OrderItem{{GoodID: 1, Quantity: 2}}})
+
+	// Платеж мог пройти: заказ не отменяется и резерв остается до сверки
+	assert.Error(t, err)
+	assert.Nil(t, order)
+	mockRepo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything, mock.Anything)
+	mockGoodsClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything)
+	mock.AssertExpectationsForObjects(t, mockRepo, mockGoodsClient, mockPaymentClient)
+}
+
+func TestCreateOrder_PaidStatusFailureKeepsReservation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockGoodsClient := new(MockGoodsServiceClient)
+	mockPaymentClient := new(MockPaymentsServiceClient)
+	service := New(mockRepo, new(MockProducer), mockGoodsClient, mockPaymentClient, new(MockDeliveryServiceClient))
+	ctx := context.Background()
+
+	mockGoodsClient.On("GetGood", ctx, &pb.GetGoodRequest{GoodId: 1}).Return(&pb.Good{
+		Id:       1,
+		Variants: []*pb.Variant{{Id: 11, GoodId: 1, Price: 300, Stock: 5}},
+	}, nil)
+	mockGoodsClient.On("CheckStock", ctx, mock.Anything).Return(&pb.CheckStockResponse{Available: true}, nil)
+	mockRepo.On("CreateOrder", ctx, mock.Anything).Return(nil)
+	mockGoodsClient.On("ReserveOrder", ctx, mock.Anything).Return(&pb.ReserveOrderResponse{Success: true}, nil)
+	mockPaymentClient.On("ProcessPayment", ctx, mock.Anything).Return(&pb.Payment{Status: "completed"}, nil)
+	mockRepo.On("UpdateOrderStatus", ctx, int64(1), "paid").Return(errors.New("connection reset"))
+
+	order, err := service.CreateOrder(ctx, &model.CreateOrderRequest{UserID: 100, Items: []model.OrderItem{{GoodID: 1, Quantity: 2}}})
+
+	assert.Error(t, err)
+	assert.Nil(t, order)
+	mockGoodsClient.AssertNotCalled(t, "CommitStock", mock.Anything, mock.Anything)
+	mockGoodsClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything)
+	mock.AssertExpectationsForObjects(t, mockRepo, mockGoodsClient, mockPaymentClient)
+}
+
+func TestCreateOrder_CommitFailureKeepsOrderPaid(t *testing.T) {
+	stockRetryDelay = 0
+	mockRepo := new(MockRepository)
+	mockProducer := new(MockProducer)
+	mockGoodsClient := new(MockGoodsServiceClient)
+	mockPaymentClient := new(MockPaymentsServiceClient)
+	service := New(mockRepo, mockProducer, mockGoodsClient, mockPaymentClient, new(MockDeliveryServiceClient))
+	ctx := context.Background()
+
+	mockGoodsClient.On("GetGood", ctx, &pb.GetGoodRequest{GoodId: 1}).Return(&pb.Good{
+		Id:       1,
+		Variants: []*pb.Variant{{Id: 11, GoodId: 1, Price: 300, Stock: 5}},
+	}, nil)
+	mockGoodsClient.On("CheckStock", ctx, mock.Anything).Return(&pb.CheckStockResponse{Available: true}, nil)
+	mockRepo.On("CreateOrder", ctx, mock.Anything).Return(nil)
+	mockGoodsClient.On("ReserveOrder", ctx, mock.Anything).Return(&pb.ReserveOrderResponse{Success: true}, nil)
+	mockPaymentClient.On("ProcessPayment", ctx, mock.Anything).Return(&pb.Payment{Status: "completed"}, nil)
+	mockRepo.On("UpdateOrderStatus", ctx, int64(1), "paid").Return(nil)
+	mockGoodsClient.On("CommitStock", mock.Anything, &pb.CommitStockRequest{OrderId: 1}).Return(nil, errors.New("goods service unavailable"))
+	mockProducer.On("PublishOrderCreated", ctx, mock.Anything).Return(nil)
+
+	order, err := service.CreateOrder(ctx, &model.CreateOrderRequest{UserID: 100, Items: []model.OrderItem{{GoodID: 1, Quantity: 2}}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "paid", order.Status)
+	mockGoodsClient.AssertNumberOfCalls(t, "CommitStock", stockAttempts)
+	mock.AssertExpectationsForObjects(t, mockRepo, mockProducer, mockGoodsClient, mockPaymentClient)
+}
+
+func TestCreateOrder_InvalidVariant(t *testing.T) {
+	mockGoodsClient := new(MockGoodsServiceClient)
+	service := New(new(MockRepository), new(MockProducer), mockGoodsClient, new(MockPaymentsServiceClient), new(MockDeliveryServiceClient))
+	ctx := context.Background()
+
+	mockGoodsClient.On("GetGood", ctx, &pb.GetGoodRequest{GoodId: 1}).Return(&pb.Good{
+		Id: 1,
+		Variants: []*pb.Variant{
+			{Id: 11, GoodId: 1, Price: 300, Stock: 5},
+			{Id: 12, GoodId: 1, Price: 550, Stock: 5},
+		},
+	}, nil)
+
+	_, err := service.CreateOrder(ctx, &model.CreateOrderRequest{UserID: 100, Items: []model.OrderItem{{GoodID: 1, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrVariantRequired)
+
+	_, err = service.CreateOrder(ctx, &model.CreateOrderRequest{UserID: 100, Items: []model.OrderItem{{GoodID: 1, VariantID: 42, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+}
 
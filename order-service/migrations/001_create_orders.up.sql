@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    items JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    total_price DECIMAL(10, 2) NOT NULL,
+    address TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- Базы, созданные до появления адреса в заказе
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS address TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_orders_user ON orders(user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
@@ -0,0 +1,41 @@
+// Package app собирает слои payment-service для запуска внутри другого процесса:
+// e2e тестов и сборок, где сервисы работают в одном бинарнике
+package app
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/che1nov/tea-shop/payment-service/internal/handler"
+	"github.com/che1nov/tea-shop/payment-service/internal/repository"
+	"github.com/che1nov/tea-shop/payment-service/internal/service"
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// Processor решает, проходит ли платёж
+type Processor = service.Processor
+
+// ProcessorFunc позволяет использовать функцию как Processor
+type ProcessorFunc func(ctx context.Context, orderID int64, amount float64, method string) (bool, error)
+
+func (f ProcessorFunc) Authorize(ctx context.Context, orderID int64, amount float64, method string) (bool, error) {
+	return f(ctx, orderID, amount, method)
+}
+
+// New собирает gRPC API payment-service поверх БД.
+// Если processor равен nil, платежи проходят случайно, как в обычном запуске
+func New(db *sql.DB, processor Processor) pb.PaymentsServiceServer {
+	if processor == nil {
+		processor = service.RandomProcessor{}
+	}
+	return handler.New(service.NewWithProcessor(repository.New(db), processor))
+}
+
+// NewInMemory собирает gRPC API payment-service поверх репозитория в памяти.
+// Если processor равен nil, платежи проходят случайно, как в обычном запуске
+func NewInMemory(processor Processor) pb.PaymentsServiceServer {
+	if processor == nil {
+		processor = service.RandomProcessor{}
+	}
+	return handler.New(service.NewWithProcessor(repository.NewMemory(), processor))
+}
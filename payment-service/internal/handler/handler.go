@@ -2,6 +2,10 @@ package handler
 
 import (
 	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
 
@@ -79,3 +83,25 @@ func (h *PaymentsHandler) GetPaymentByOrderID(ctx context.Context, req *pb.GetPa
 		UpdatedAt: payment.UpdatedAt.Unix(),
 	}, nil
 }
+
+func (h *PaymentsHandler) RefundPayment(ctx context.Context, req *pb.RefundPaymentRequest) (*pb.Payment, error) {
+	payment, err := h.service.RefundPayment(ctx, req.PaymentId)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPaymentNotFound):
+			return nil, status.Errorf(codes.NotFound, "%v", err)
+		case errors.Is(err, service.ErrPaymentNotRefundable):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, err
+	}
+
+	return &pb.Payment{
+		Id:        payment.ID,
+		OrderId:   payment.OrderID,
+		Amount:    payment.Amount,
+		Status:    payment.Status,
+		CreatedAt: payment.CreatedAt.Unix(),
+		UpdatedAt: payment.UpdatedAt.Unix(),
+	}, nil
+}
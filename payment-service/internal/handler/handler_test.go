@@ -5,8 +5,11 @@ import (
 	"testing"
 
 	"github.com/che1nov/tea-shop/payment-service/internal/model"
+	"github.com/che1nov/tea-shop/payment-service/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
 )
@@ -40,6 +43,14 @@ func (m *MockPaymentService) GetPaymentByOrderID(ctx context.Context, orderID in
 	return args.Get(0).(*model.Payment), args.Error(1)
 }
 
+func (m *MockPaymentService) RefundPayment(ctx context.Context, id int64) (*model.Payment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Payment), args.Error(1)
+}
+
 func TestNew(t *testing.T) {
 	mockService := new(MockPaymentService)
 	handler := New(mockService)
@@ -130,3 +141,19 @@ func TestGetPayment_NotFound(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+
+func TestRefundPayment_MapsErrors(t *testing.T) {
+	mockService := new(MockPaymentService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("RefundPayment", ctx, int64(999)).Return(nil, service.ErrPaymentNotFound)
+	mockService.On("RefundPayment", ctx, int64(2)).Return(nil, service.ErrPaymentNotRefundable)
+
+	_, err := handler.RefundPayment(ctx, &pb.RefundPaymentRequest{PaymentId: 999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = handler.RefundPayment(ctx, &pb.RefundPaymentRequest{PaymentId: 2})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	mockService.AssertExpectations(t)
+}
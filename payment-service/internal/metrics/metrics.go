@@ -0,0 +1,21 @@
+// Package metrics - бизнес метрики платежей для Prometheus.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// PaymentsProcessed - обработанные платежи по результату (completed, failed, refunded) и способу оплаты
+	PaymentsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payments_processed_total",
+		Help: "Total number of processed payments, by outcome and method.",
+	}, []string{"status", "method"})
+
+	// PaymentsAmount - сумма платежей по результату
+	PaymentsAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payments_amount_total",
+		Help: "Total amount of processed payments, by outcome.",
+	}, []string{"status"})
+)
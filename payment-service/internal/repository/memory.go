@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/che1nov/tea-shop/payment-service/internal/model"
+)
+
+// errDuplicateOrderID - аналог нарушения уникальности payments.order_id
+var errDuplicateOrderID = errors.New("payment for this order already exists")
+
+// MemoryRepository - репозиторий платежей в памяти (для тестов и запуска без БД)
+type MemoryRepository struct {
+	mu       sync.Mutex
+	payments map[int64]*model.Payment
+	lastID   int64
+}
+
+func NewMemory() *MemoryRepository {
+	return &MemoryRepository{payments: make(map[int64]*model.Payment)}
+}
+
+func (r *MemoryRepository) CreatePayment(ctx context.Context, payment *model.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.payments {
+		if existing.OrderID == payment.OrderID {
+			return errDuplicateOrderID
+		}
+	}
+
+	r.lastID++
+	payment.ID = r.lastID
+	now := time.Now()
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+
+	copied := *payment
+	r.payments[payment.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) GetPayment(ctx context.Context, id int64) (*model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *payment
+	return &copied, nil
+}
+
+func (r *MemoryRepository) UpdatePaymentStatus(ctx context.Context, id int64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if payment, ok := r.payments[id]; ok {
+		payment.Status = status
+		payment.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetPaymentByOrderID(ctx context.Context, orderID int64) (*model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, payment := range r.payments {
+		if payment.OrderID == orderID {
+			copied := *payment
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/che1nov/tea-shop/payment-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_Payments(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	payment := &model.Payment{OrderID: 7, Amount: 250, Status: "pending", Method: "card"}
+	require.NoError(t, repo.CreatePayment(ctx, payment))
+	require.NoError(t, repo.UpdatePaymentStatus(ctx, payment.ID, "completed"))
+
+	stored, err := repo.GetPaymentByOrderID(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, payment.ID, stored.ID)
+	assert.Equal(t, "completed", stored.Status)
+
+	missing, err := repo.GetPayment(ctx, 42)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	assert.Error(t, repo.CreatePayment(ctx, &model.Payment{OrderID: 7, Amount: 1, Status: "pending", Method: "card"}))
+}
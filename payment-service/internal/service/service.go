@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"math/rand"
 
+	"github.com/che1nov/tea-shop/payment-service/internal/metrics"
 	"github.com/che1nov/tea-shop/payment-service/internal/model"
 	"github.com/che1nov/tea-shop/payment-service/internal/repository"
 )
@@ -13,15 +15,40 @@ type PaymentServiceInterface interface {
 	ProcessPayment(ctx context.Context, req *model.ProcessPaymentRequest) (*model.Payment, error)
 	GetPayment(ctx context.Context, id int64) (*model.Payment, error)
 	GetPaymentByOrderID(ctx context.Context, orderID int64) (*model.Payment, error)
+	RefundPayment(ctx context.Context, id int64) (*model.Payment, error)
+}
+
+var (
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPaymentNotRefundable = errors.New("only completed payments can be refunded")
+)
+
+// Processor решает, проходит ли платёж (в реальной системе - интеграция с платёжным шлюзом)
+type Processor interface {
+	Authorize(ctx context.Context, orderID int64, amount float64, method string) (bool, error)
+}
+
+// RandomProcessor имитирует платёжный шлюз: 90% успешных, 10% неудачных платежей
+type RandomProcessor struct{}
+
+func (RandomProcessor) Authorize(ctx context.Context, orderID int64, amount float64, method string) (bool, error) {
+	return rand.Intn(100) < 90, nil
 }
 
 type PaymentService struct {
-	repo repository.PaymentRepositoryInterface
+	repo      repository.PaymentRepositoryInterface
+	processor Processor
 }
 
 func New(repo repository.PaymentRepositoryInterface) *PaymentService {
+	return NewWithProcessor(repo, RandomProcessor{})
+}
+
+// NewWithProcessor создаёт сервис с заданным процессором платежей (например, детерминированным в тестах)
+func NewWithProcessor(repo repository.PaymentRepositoryInterface, processor Processor) *PaymentService {
 	return &PaymentService{
-		repo: repo,
+		repo:      repo,
+		processor: processor,
 	}
 }
 
@@ -38,10 +65,11 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, req *model.ProcessP
 		return nil, err
 	}
 
-	// Имитируем обработку платежа
-	// 90% успешных, 10% неудачных
-	// Для тестируемости можно передавать seed или использовать интерфейс
-	if rand.Intn(100) < 90 {
+	approved, err := s.processor.Authorize(ctx, payment.OrderID, payment.Amount, payment.Method)
+	if err != nil {
+		return nil, err
+	}
+	if approved {
 		payment.Status = "completed"
 	} else {
 		payment.Status = "failed"
@@ -51,6 +79,9 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, req *model.ProcessP
 		return nil, err
 	}
 
+	metrics.PaymentsProcessed.WithLabelValues(payment.Status, payment.Method).Inc()
+	metrics.PaymentsAmount.WithLabelValues(payment.Status).Add(payment.Amount)
+
 	return payment, nil
 }
 
@@ -61,3 +92,28 @@ func (s *PaymentService) GetPayment(ctx context.Context, id int64) (*model.Payme
 func (s *PaymentService) GetPaymentByOrderID(ctx context.Context, orderID int64) (*model.Payment, error) {
 	return s.repo.GetPaymentByOrderID(ctx, orderID)
 }
+
+// RefundPayment возвращает деньги по проведенному платежу. Вернуть можно только платеж
+// в статусе completed, поэтому повторный возврат отклоняется
+func (s *PaymentService) RefundPayment(ctx context.Context, id int64) (*model.Payment, error) {
+	payment, err := s.repo.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, ErrPaymentNotFound
+	}
+	if payment.Status != "completed" {
+		return nil, ErrPaymentNotRefundable
+	}
+
+	payment.Status = "refunded"
+	if err := s.repo.UpdatePaymentStatus(ctx, payment.ID, payment.Status); err != nil {
+		return nil, err
+	}
+
+	metrics.PaymentsProcessed.WithLabelValues(payment.Status, payment.Method).Inc()
+	metrics.PaymentsAmount.WithLabelValues(payment.Status).Add(payment.Amount)
+
+	return payment, nil
+}
@@ -102,6 +102,45 @@ func TestProcessPayment_CreateError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// staticProcessor - процессор с заранее заданным решением
+type staticProcessor struct {
+	approved bool
+	err      error
+}
+
+func (p staticProcessor) Authorize(ctx context.Context, orderID int64, amount float64, method string) (bool, error) {
+	return p.approved, p.err
+}
+
+func TestProcessPayment_Declined(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewWithProcessor(mockRepo, staticProcessor{approved: false})
+	ctx := context.Background()
+
+	mockRepo.On("CreatePayment", ctx, mock.AnythingOfType("*model.Payment")).Return(nil)
+	mockRepo.On("UpdatePaymentStatus", ctx, int64(1), "failed").Return(nil)
+
+	payment, err := service.ProcessPayment(ctx, &model.ProcessPaymentRequest{OrderID: 1, Amount: 10, Method: "card"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "failed", payment.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcessPayment_ProcessorError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewWithProcessor(mockRepo, staticProcessor{err: errors.New("gateway timeout")})
+	ctx := context.Background()
+
+	mockRepo.On("CreatePayment", ctx, mock.AnythingOfType("*model.Payment")).Return(nil)
+
+	payment, err := service.ProcessPayment(ctx, &model.ProcessPaymentRequest{OrderID: 1, Amount: 10, Method: "card"})
+
+	assert.Error(t, err)
+	assert.Nil(t, payment)
+	mockRepo.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestGetPayment_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := New(mockRepo)
@@ -162,3 +201,39 @@ func TestGetPaymentByOrderID_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+
+func TestRefundPayment_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetPayment", ctx, int64(1)).Return(&model.Payment{ID: 1, OrderID: 100, Amount: 99.99, Status: "completed", Method: "card"}, nil)
+	mockRepo.On("UpdatePaymentStatus", ctx, int64(1), "refunded").Return(nil)
+
+	payment, err := service.RefundPayment(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "refunded", payment.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefundPayment_Rejected(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := New(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetPayment", ctx, int64(999)).Return(nil, nil)
+	mockRepo.On("GetPayment", ctx, int64(2)).Return(&model.Payment{ID: 2, Status: "refunded"}, nil)
+	mockRepo.On("GetPayment", ctx, int64(3)).Return(&model.Payment{ID: 3, Status: "failed"}, nil)
+
+	_, err := service.RefundPayment(ctx, 999)
+	assert.ErrorIs(t, err, ErrPaymentNotFound)
+
+	_, err = service.RefundPayment(ctx, 2)
+	assert.ErrorIs(t, err, ErrPaymentNotRefundable)
+
+	_, err = service.RefundPayment(ctx, 3)
+	assert.ErrorIs(t, err, ErrPaymentNotRefundable)
+
+	mockRepo.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,320 @@
+// Package config загружает конфигурацию сервиса слоями:
+// значения по умолчанию → YAML файл → переменные окружения → флаги командной строки.
+//
+// Поля структуры описываются тегами:
+//
+//	yaml:"host"                       ключ в YAML файле
+//	env:"DB_HOST"                     переменная окружения; из нее же выводится флаг --db-host
+//	validate:"required,hostport"      правила проверки через запятую
+//	secret:"true"                     значение маскируется при печати и проверяется в production
+//
+// Значения по умолчанию - это то, что лежит в структуре до вызова Load.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Окружения запуска
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Usage - справка по подкоманде config
+const Usage = `Usage:
+  config                напечатать итоговую конфигурацию (секреты скрыты)`
+
+// Переменная окружения с путем к YAML файлу (флаг --config имеет приоритет)
+const FileEnv = "CONFIG_FILE"
+
+var (
+	ErrInvalidConfig = errors.New("invalid configuration")
+	ErrDefaultSecret = errors.New("default secret is not allowed in production")
+)
+
+// insecureSecrets - значения, которые никогда не должны попасть в production
+var insecureSecrets = map[string]bool{
+	"password":                             true,
+	"secret":                               true,
+	"changeme":                             true,
+	"your-secret-key-change-in-production": true,
+}
+
+// App - общие параметры всех сервисов; встраивается в Config сервиса
+type App struct {
+	Env string `yaml:"env" env:"APP_ENV" validate:"oneof=development production"`
+}
+
+// Production сообщает, запущен ли сервис в production окружении
+func (a App) Production() bool {
+	return a.Env == EnvProduction
+}
+
+type production interface {
+	Production() bool
+}
+
+// field - лист структуры конфигурации
+type field struct {
+	path     []string
+	env      string
+	rules    []string
+	secret   bool
+	value    reflect.Value
+	fallback string
+}
+
+func (f field) name() string {
+	return strings.Join(f.path, ".")
+}
+
+func (f field) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(f.env), "_", "-")
+}
+
+// Load заполняет cfg (указатель на структуру) и возвращает аргументы после флагов -
+// это подкоманда сервиса, если она есть
+func Load(cfg any, args []string) ([]string, error) {
+	root := reflect.ValueOf(cfg)
+	if root.Kind() != reflect.Pointer || root.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config must be a pointer to struct, got %T", cfg)
+	}
+
+	fields := collect(root.Elem(), nil)
+	for i := range fields {
+		fields[i].fallback = format(fields[i].value)
+	}
+
+	// Флаги разбираются первыми (в них путь к файлу), но применяются последними
+	flagValues := make(map[string]string)
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv(FileEnv), "path to YAML config file")
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		name := f.flagName()
+		usage := fmt.Sprintf("%s (env %s)", f.name(), f.env)
+		set := func(value string) error {
+			flagValues[name] = value
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(name, usage, set)
+		} else {
+			fs.Func(name, usage, set)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if value, ok := os.LookupEnv(f.env); ok && value != "" {
+			if err := set(f.value, value); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, f.env, err)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if value, ok := flagValues[f.flagName()]; ok && f.env != "" {
+			if err := set(f.value, value); err != nil {
+				return nil, fmt.Errorf("%w: --%s: %v", ErrInvalidConfig, f.flagName(), err)
+			}
+		}
+	}
+
+	if err := validate(cfg, fields); err != nil {
+		return nil, err
+	}
+	return fs.Args(), nil
+}
+
+// Print печатает итоговую конфигурацию в YAML, секреты заменяются на ******
+func Print(w io.Writer, cfg any) error {
+	root := reflect.ValueOf(cfg)
+	if root.Kind() == reflect.Pointer {
+		root = root.Elem()
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range collect(root, nil) {
+		node := doc
+		for _, key := range f.path[:len(f.path)-1] {
+			node = child(node, key)
+		}
+
+		value := scalar(f.value)
+		if f.secret && value.Value != "" {
+			value.Value = "******"
+		} else if f.value.Kind() == reflect.Slice {
+			value = &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			for i := 0; i < f.value.Len(); i++ {
+				value.Content = append(value.Content, scalar(f.value.Index(i)))
+			}
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.path[len(f.path)-1]}, value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// scalar кодирует значение; строки помечаются явно, чтобы "true" или "8080" не сменили тип при повторном чтении
+func scalar(v reflect.Value) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: format(v)}
+	if v.Kind() == reflect.String {
+		node.Tag = "!!str"
+	}
+	return node
+}
+
+// child возвращает вложенный mapping по ключу, создавая его при необходимости
+func child(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	next := &yaml.Node{Kind: yaml.MappingNode}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, next)
+	return next
+}
+
+// collect обходит структуру и возвращает ее листья; встроенные структуры раскрываются на месте
+func collect(v reflect.Value, path []string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		key, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(sf.Name)
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Duration(0)) {
+			if sf.Anonymous {
+				fields = append(fields, collect(fv, path)...)
+			} else {
+				fields = append(fields, collect(fv, append(append([]string{}, path...), key))...)
+			}
+			continue
+		}
+
+		var rules []string
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			rules = strings.Split(tag, ",")
+		}
+		fields = append(fields, field{
+			path:   append(append([]string{}, path...), key),
+			env:    sf.Tag.Get("env"),
+			rules:  rules,
+			secret: sf.Tag.Get("secret") == "true",
+			value:  fv,
+		})
+	}
+	return fields
+}
+
+func loadFile(cfg any, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// Опечатка в ключе не должна молча оставлять значение по умолчанию
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+	return nil
+}
+
+// set разбирает строковое значение из окружения или флага в поле
+func set(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func format(v reflect.Value) string {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = format(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	App    `yaml:",inline"`
+	Server struct {
+		Port    int           `yaml:"port" env:"TEST_PORT" validate:"port"`
+		Timeout time.Duration `yaml:"timeout" env:"TEST_TIMEOUT" validate:"positive"`
+		Debug   bool          `yaml:"debug" env:"TEST_DEBUG"`
+	} `yaml:"server"`
+	Brokers  []string `yaml:"brokers" env:"TEST_BROKERS" validate:"required,hostport"`
+	JWKSURL  string   `yaml:"jwks_url" env:"TEST_JWKS_URL" validate:"url"`
+	Password string   `yaml:"password" env:"TEST_PASSWORD" secret:"true"`
+}
+
+func defaults() *testConfig {
+	cfg := &testConfig{}
+	cfg.Env = EnvDevelopment
+	cfg.Server.Port = 8080
+	cfg.Server.Timeout = 5 * time.Second
+	cfg.Brokers = []string{"localhost:9092"}
+	cfg.JWKSURL = "http://localhost:9001/.well-known/jwks.json"
+	cfg.Password = "password"
+	return cfg
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg := defaults()
+
+	rest, err := Load(cfg, nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, []string{"localhost:9092"}, cfg.Brokers)
+}
+
+func TestLoad_LayerPrecedence(t *testing.T) {
+	path := writeFile(t, `
+server:
+  port: 7000
+  timeout: 3s
+brokers: [kafka:29092]
+`)
+	t.Setenv("TEST_PORT", "7001")
+	t.Setenv("TEST_BROKERS", "kafka-1:9092, kafka-2:9092")
+	cfg := defaults()
+
+	rest, err := Load(cfg, []string{"--config", path, "--test-port", "7002", "--test-debug", "migrate", "up"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "up"}, rest)
+	// флаг > окружение > файл > значение по умолчанию
+	assert.Equal(t, 7002, cfg.Server.Port)
+	assert.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, cfg.Brokers)
+	assert.Equal(t, 3*time.Second, cfg.Server.Timeout)
+	assert.True(t, cfg.Server.Debug)
+}
+
+func TestLoad_FileFromEnv(t *testing.T) {
+	t.Setenv(FileEnv, writeFile(t, "server:\n  port: 7000\n"))
+	cfg := defaults()
+
+	_, err := Load(cfg, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, 7000, cfg.Server.Port)
+}
+
+func TestLoad_UnknownFileKey(t *testing.T) {
+	path := writeFile(t, "server:\n  prot: 7000\n")
+
+	_, err := Load(defaults(), []string{"--config", path})
+
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestLoad_InvalidEnvValue(t *testing.T) {
+	t.Setenv("TEST_TIMEOUT", "five seconds")
+
+	_, err := Load(defaults(), nil)
+
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "TEST_TIMEOUT")
+}
+
+func TestLoad_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "port out of range", env: map[string]string{"TEST_PORT": "70000"}, want: "server.port"},
+		{name: "not host:port", env: map[string]string{"TEST_BROKERS": "kafka"}, want: "brokers"},
+		{name: "relative url", env: map[string]string{"TEST_JWKS_URL": "/jwks.json"}, want: "jwks_url"},
+		{name: "unknown env", env: map[string]string{"APP_ENV": "staging"}, want: "env"},
+		{name: "non positive duration", env: map[string]string{"TEST_TIMEOUT": "0s"}, want: "server.timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load(defaults(), nil)
+
+			require.ErrorIs(t, err, ErrInvalidConfig)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestLoad_RequiredSlice(t *testing.T) {
+	cfg := defaults()
+	cfg.Brokers = nil
+
+	_, err := Load(cfg, nil)
+
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "brokers (TEST_BROKERS): is required")
+}
+
+func TestLoad_ProductionRejectsDefaultSecrets(t *testing.T) {
+	t.Setenv("APP_ENV", EnvProduction)
+
+	_, err := Load(defaults(), nil)
+
+	assert.ErrorIs(t, err, ErrDefaultSecret)
+}
+
+func TestLoad_ProductionRejectsKnownInsecureSecret(t *testing.T) {
+	t.Setenv("APP_ENV", EnvProduction)
+	t.Setenv("TEST_PASSWORD", "your-secret-key-change-in-production")
+	cfg := defaults()
+	cfg.Password = ""
+
+	_, err := Load(cfg, nil)
+
+	assert.ErrorIs(t, err, ErrDefaultSecret)
+}
+
+func TestLoad_ProductionAcceptsCustomSecret(t *testing.T) {
+	t.Setenv("APP_ENV", EnvProduction)
+	t.Setenv("TEST_PASSWORD", "s3cr3t-from-vault")
+	cfg := defaults()
+
+	_, err := Load(cfg, nil)
+
+	require.NoError(t, err)
+	assert.True(t, cfg.Production())
+}
+
+func TestPrint_MasksSecrets(t *testing.T) {
+	cfg := defaults()
+	cfg.Password = "s3cr3t-from-vault"
+	var out bytes.Buffer
+
+	require.NoError(t, Print(&out, cfg))
+
+	assert.NotContains(t, out.String(), "s3cr3t-from-vault")
+	assert.Contains(t, out.String(), "password: '******'")
+	assert.Contains(t, out.String(), "timeout: 5s")
+	assert.Contains(t, out.String(), "brokers: ['localhost:9092']")
+	assert.Contains(t, out.String(), "env: development")
+}
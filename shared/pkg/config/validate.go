@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// validate проверяет правила из тегов validate и секреты в production;
+// возвращает все найденные ошибки сразу
+func validate(cfg any, fields []field) error {
+	var errs []error
+	for _, f := range fields {
+		for _, rule := range f.rules {
+			if err := check(f.value, rule); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", describe(f), err))
+			}
+		}
+	}
+
+	if p, ok := cfg.(production); ok && p.Production() {
+		for _, f := range fields {
+			if !f.secret {
+				continue
+			}
+			value := f.value.String()
+			if value != "" && (value == f.fallback || insecureSecrets[strings.ToLower(value)]) {
+				errs = append(errs, fmt.Errorf("%w: %s", ErrDefaultSecret, describe(f)))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w\n%w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return nil
+}
+
+func describe(f field) string {
+	if f.env != "" {
+		return fmt.Sprintf("%s (%s)", f.name(), f.env)
+	}
+	return f.name()
+}
+
+func check(v reflect.Value, rule string) error {
+	name, arg, _ := strings.Cut(rule, "=")
+
+	if v.Kind() == reflect.Slice {
+		if name == "required" {
+			if v.Len() == 0 {
+				return errors.New("is required")
+			}
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := check(v.Index(i), rule); err != nil {
+				return fmt.Errorf("item %d %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return errors.New("is required")
+		}
+	case "port":
+		if n := v.Int(); n < 1 || n > 65535 {
+			return fmt.Errorf("must be a port between 1 and 65535, got %d", n)
+		}
+	case "positive":
+		if v.Int() <= 0 {
+			return fmt.Errorf("must be positive, got %s", format(v))
+		}
+	case "hostport":
+		if v.String() == "" {
+			return nil
+		}
+		host, port, err := net.SplitHostPort(v.String())
+		if err != nil || host == "" {
+			return fmt.Errorf("must be host:port, got %q", v.String())
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port in %q", v.String())
+		}
+	case "url":
+		if v.String() == "" {
+			return nil
+		}
+		u, err := url.Parse(v.String())
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("must be an absolute URL, got %q", v.String())
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		for _, a := range allowed {
+			if v.String() == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s, got %q", strings.Join(allowed, ", "), v.String())
+	default:
+		return fmt.Errorf("unknown validation rule %q", rule)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Ключи, под которыми идентификаторы передаются между сервисами
+const (
+	// HeaderRequestID - HTTP заголовок, ключ gRPC metadata и заголовок Kafka сообщения
+	HeaderRequestID = "X-Request-ID"
+	// MetadataRequestID - ключ в gRPC metadata (metadata всегда в нижнем регистре)
+	MetadataRequestID = "x-request-id"
+	// MetadataUserID - ключ в gRPC metadata с id пользователя, которого аутентифицировал gateway.
+	// Используется только для логов, не для авторизации
+	MetadataUserID = "x-user-id"
+
+	// maxRequestIDLength ограничивает длину чужого X-Request-ID, чтобы не раздувать логи
+	maxRequestIDLength = 128
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+)
+
+// NewRequestID генерирует случайный идентификатор запроса
+func NewRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// ValidRequestID проверяет, что пришедший от клиента идентификатор можно безопасно писать в логи
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserID возвращает id пользователя из контекста
+func UserID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userIDKey).(int64)
+	return id, ok
+}
+
+// UnaryServerInterceptor переносит идентификаторы из входящей gRPC metadata в контекст
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(FromIncomingContext(ctx), req)
+	}
+}
+
+// UnaryClientInterceptor добавляет идентификаторы из контекста в исходящую gRPC metadata
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(ToOutgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// FromIncomingContext читает идентификаторы из входящей metadata. Если request id не передан,
+// генерируется новый, чтобы логи сервиса по одному вызову все равно можно было связать
+func FromIncomingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := first(md, MetadataRequestID)
+	if !ValidRequestID(requestID) {
+		requestID = NewRequestID()
+	}
+	ctx = WithRequestID(ctx, requestID)
+
+	if userID, err := strconv.ParseInt(first(md, MetadataUserID), 10, 64); err == nil {
+		ctx = WithUserID(ctx, userID)
+	}
+	return ctx
+}
+
+// ToOutgoingContext добавляет идентификаторы из контекста в исходящую metadata
+func ToOutgoingContext(ctx context.Context) context.Context {
+	var pairs []string
+	if id := RequestID(ctx); id != "" {
+		pairs = append(pairs, MetadataRequestID, id)
+	}
+	if userID, ok := UserID(ctx); ok {
+		pairs = append(pairs, MetadataUserID, strconv.FormatInt(userID, 10))
+	}
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package correlation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, ValidRequestID("3f2a-1b"))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID("with space"))
+	assert.False(t, ValidRequestID("line\nbreak"))
+	assert.False(t, ValidRequestID(strings.Repeat("a", maxRequestIDLength+1)))
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	ctx := WithUserID(WithRequestID(context.Background(), "req-1"), 42)
+
+	out := ToOutgoingContext(ctx)
+	md, ok := metadata.FromOutgoingContext(out)
+	assert.True(t, ok)
+
+	in := FromIncomingContext(metadata.NewIncomingContext(context.Background(), md))
+	assert.Equal(t, "req-1", RequestID(in))
+	userID, ok := UserID(in)
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), userID)
+}
+
+func TestFromIncomingContext_GeneratesRequestID(t *testing.T) {
+	md := metadata.Pairs(MetadataRequestID, "bad id")
+	ctx := FromIncomingContext(metadata.NewIncomingContext(context.Background(), md))
+
+	assert.NotEmpty(t, RequestID(ctx))
+	assert.NotEqual(t, "bad id", RequestID(ctx))
+	_, ok := UserID(ctx)
+	assert.False(t, ok)
+}
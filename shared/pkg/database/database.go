@@ -0,0 +1,50 @@
+// Package database открывает подключение к PostgreSQL с трейсингом запросов.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	// Драйвер PostgreSQL
+	_ "github.com/lib/pq"
+
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
+
+// Config - параметры подключения к PostgreSQL
+type Config struct {
+	Host     string `yaml:"host" env:"DB_HOST" validate:"required"`
+	Port     string `yaml:"port" env:"DB_PORT" validate:"required"`
+	User     string `yaml:"user" env:"DB_USER" validate:"required"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME" validate:"required"`
+	// Применять миграции при старте сервиса (иначе - только подкомандой migrate)
+	AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+}
+
+// DSN возвращает строку подключения для lib/pq
+func (c Config) DSN() string {
+	return fmt.Sprintf(
+		"user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
+		c.User,
+		c.Password,
+		c.Name,
+		c.Host,
+		c.Port,
+	)
+}
+
+// Open подключается к БД и проверяет соединение
+func Open(ctx context.Context, cfg Config) (*sql.DB, error) {
+	db, err := tracing.OpenDB("postgres", cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database %s at %s:%s: %w", cfg.Name, cfg.Host, cfg.Port, err)
+	}
+	return db, nil
+}
@@ -1,6 +1,12 @@
 package errors
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
 
 type ErrorCode string
 
@@ -40,3 +46,36 @@ func NewWithErr(code ErrorCode, message string, err error) *AppError {
 		Err:     err,
 	}
 }
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCCode возвращает gRPC код, соответствующий коду ошибки
+func (e *AppError) GRPCCode() codes.Code {
+	switch e.Code {
+	case ErrInvalidInput:
+		return codes.InvalidArgument
+	case ErrNotFound:
+		return codes.NotFound
+	case ErrUnauthorized:
+		return codes.Unauthenticated
+	case ErrConflict:
+		return codes.AlreadyExists
+	case ErrServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// ToStatus превращает AppError (в том числе обёрнутую) в gRPC статус.
+// Остальные ошибки возвращаются без изменений. Для внутренних ошибок клиенту
+// отдаётся только Message, без текста исходной ошибки
+func ToStatus(err error) error {
+	var appErr *AppError
+	if !errors.As(err, &appErr) {
+		return err
+	}
+	return status.Error(appErr.GRPCCode(), appErr.Message)
+}
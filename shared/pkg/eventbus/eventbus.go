@@ -0,0 +1,126 @@
+// Package eventbus - шина событий в памяти процесса для тестов и запуска без Kafka.
+// Семантика повторяет Kafka в объёме, нужном сервисам: сообщения топика хранятся в журнале,
+// каждая группа подписчиков читает журнал с начала, а участники группы делят общее смещение.
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed возвращается из Next после закрытия подписки
+var ErrClosed = errors.New("eventbus: subscription closed")
+
+// Message - сообщение топика. Offset заполняется шиной при публикации
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+	Offset  int64
+}
+
+// Bus - журнал сообщений по топикам. Нулевое значение не готово к работе, используйте New
+type Bus struct {
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+type topic struct {
+	log []Message
+	// groups - смещение следующего непрочитанного сообщения для каждой группы
+	groups map[string]int64
+	// wake закрывается при публикации, чтобы разбудить ожидающих подписчиков
+	wake chan struct{}
+}
+
+func New() *Bus {
+	return &Bus{topics: make(map[string]*topic)}
+}
+
+// Publish добавляет сообщение в журнал топика
+func (b *Bus) Publish(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	headers := make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	msg.Headers = headers
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topic(msg.Topic)
+	msg.Offset = int64(len(t.log))
+	t.log = append(t.log, msg)
+	close(t.wake)
+	t.wake = make(chan struct{})
+	return nil
+}
+
+// Messages возвращает все сообщения топика, не сдвигая смещения групп
+func (b *Bus) Messages(name string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Message(nil), b.topic(name).log...)
+}
+
+// Subscribe подписывает участника группы на топик
+func (b *Bus) Subscribe(topic, group string) *Subscription {
+	return &Subscription{bus: b, topic: topic, group: group, closed: make(chan struct{})}
+}
+
+// topic возвращает топик, создавая его при первом обращении; вызывается под b.mu
+func (b *Bus) topic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{groups: make(map[string]int64), wake: make(chan struct{})}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// Subscription - участник группы подписчиков топика
+type Subscription struct {
+	bus       *Bus
+	topic     string
+	group     string
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Next блокируется до появления непрочитанного группой сообщения, отмены ctx или закрытия подписки
+func (s *Subscription) Next(ctx context.Context) (Message, error) {
+	for {
+		s.bus.mu.Lock()
+		t := s.bus.topic(s.topic)
+		offset := t.groups[s.group]
+		if offset < int64(len(t.log)) {
+			t.groups[s.group] = offset + 1
+			msg := t.log[offset]
+			s.bus.mu.Unlock()
+			return msg, nil
+		}
+		wake := t.wake
+		s.bus.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-s.closed:
+			return Message{}, ErrClosed
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		}
+	}
+}
+
+// Close прерывает ожидание в Next; смещение группы сохраняется для других участников
+func (s *Subscription) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
@@ -0,0 +1,114 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func publish(t *testing.T, bus *Bus, topic, value string) {
+	t.Helper()
+	if err := bus.Publish(context.Background(), Message{Topic: topic, Value: []byte(value)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func next(t *testing.T, sub *Subscription) Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestGroupsReadFromBeginning(t *testing.T) {
+	bus := New()
+	publish(t, bus, "orders", "first")
+	publish(t, bus, "orders", "second")
+	publish(t, bus, "payments", "other")
+
+	a := bus.Subscribe("orders", "a")
+	b := bus.Subscribe("orders", "b")
+
+	for _, want := range []string{"first", "second"} {
+		if got := string(next(t, a).Value); got != want {
+			t.Fatalf("group a: got %q, want %q", got, want)
+		}
+	}
+	msg := next(t, b)
+	if string(msg.Value) != "first" || msg.Offset != 0 {
+		t.Fatalf("group b: got %q at offset %d", msg.Value, msg.Offset)
+	}
+}
+
+func TestGroupMembersShareOffset(t *testing.T) {
+	bus := New()
+	publish(t, bus, "orders", "first")
+	publish(t, bus, "orders", "second")
+
+	first := bus.Subscribe("orders", "notify")
+	second := bus.Subscribe("orders", "notify")
+
+	if got := string(next(t, first).Value); got != "first" {
+		t.Fatalf("got %q", got)
+	}
+	if got := string(next(t, second).Value); got != "second" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestNextWaitsForPublish(t *testing.T) {
+	bus := New()
+	sub := bus.Subscribe("orders", "notify")
+
+	received := make(chan Message, 1)
+	go func() {
+		msg, err := sub.Next(context.Background())
+		if err == nil {
+			received <- msg
+		}
+	}()
+
+	publish(t, bus, "orders", "late")
+	select {
+	case msg := <-received:
+		if string(msg.Value) != "late" {
+			t.Fatalf("got %q", msg.Value)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message was not delivered")
+	}
+}
+
+func TestNextStopsOnCloseAndCancel(t *testing.T) {
+	bus := New()
+	sub := bus.Subscribe("orders", "notify")
+
+	go sub.Close()
+	if _, err := sub.Next(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := bus.Subscribe("orders", "other").Next(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPublishCopiesHeaders(t *testing.T) {
+	bus := New()
+	headers := map[string]string{"traceparent": "a"}
+	if err := bus.Publish(context.Background(), Message{Topic: "orders", Headers: headers}); err != nil {
+		t.Fatal(err)
+	}
+	headers["traceparent"] = "b"
+
+	if got := bus.Messages("orders")[0].Headers["traceparent"]; got != "a" {
+		t.Fatalf("header was modified after publish: %q", got)
+	}
+}
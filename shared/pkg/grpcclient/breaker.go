@@ -0,0 +1,182 @@
+package grpcclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+)
+
+// State - состояние circuit breaker; значение экспортируется в метрику grpc_client_circuit_breaker_state
+type State int
+
+const (
+	// StateClosed - вызовы проходят, отказы подряд считаются
+	StateClosed State = 0
+	// StateHalfOpen - пропускается один пробный вызов, остальные отклоняются
+	StateHalfOpen State = 1
+	// StateOpen - вызовы отклоняются без обращения к сервису
+	StateOpen State = 2
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "open"
+	}
+}
+
+var (
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_circuit_breaker_state",
+		Help: "Circuit breaker state per downstream service: 0 - closed, 1 - half-open, 2 - open.",
+	}, []string{"target"})
+
+	breakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_circuit_breaker_transitions_total",
+		Help: "Total number of circuit breaker state changes, by new state.",
+	}, []string{"target", "state"})
+
+	breakerRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_circuit_breaker_rejected_total",
+		Help: "Total number of calls rejected by an open circuit breaker.",
+	}, []string{"target"})
+)
+
+// breaker - circuit breaker одного downstream сервиса. После failures отказов подряд размыкается
+// и отклоняет вызовы openTimeout, затем пропускает один пробный: успех замыкает breaker, отказ - снова размыкает
+type breaker struct {
+	target      string
+	failures    int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	consecutive int
+	openedAt    time.Time
+	probing     bool
+	// generation меняется при каждом переходе: результаты вызовов, начатых в прошлом состоянии, не учитываются
+	generation uint64
+}
+
+func newBreaker(target string, failures int, openTimeout time.Duration) *breaker {
+	breakerState.WithLabelValues(target).Set(float64(StateClosed))
+	return &breaker{
+		target:      target,
+		failures:    failures,
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+}
+
+// allow решает, пропустить ли вызов; возвращает поколение, к которому относится вызов
+func (b *breaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			breakerRejected.WithLabelValues(b.target).Inc()
+			return 0, false
+		}
+		// Первый вызов после паузы становится пробным
+		b.setState(StateHalfOpen)
+		b.probing = true
+		return b.generation, true
+	case StateHalfOpen:
+		// Пока пробный вызов выполняется, остальные отклоняются
+		if b.probing {
+			breakerRejected.WithLabelValues(b.target).Inc()
+			return 0, false
+		}
+		b.probing = true
+		return b.generation, true
+	default:
+		return b.generation, true
+	}
+}
+
+// record учитывает результат вызова
+func (b *breaker) record(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	// Отмененный клиентом пробный вызов ничего не говорит о сервисе: следующий вызов станет пробным
+	if status.Code(err) == codes.Canceled && b.state == StateHalfOpen {
+		b.probing = false
+		return
+	}
+
+	if !isFailure(err) {
+		b.consecutive = 0
+		if b.state == StateHalfOpen {
+			b.setState(StateClosed)
+		}
+		return
+	}
+
+	b.consecutive++
+	if b.state == StateHalfOpen || b.consecutive >= b.failures {
+		b.openedAt = b.now()
+		b.setState(StateOpen)
+	}
+}
+
+// setState переключает состояние; вызывается под b.mu
+func (b *breaker) setState(state State) {
+	if state == StateClosed || state == StateOpen {
+		b.consecutive = 0
+	}
+	b.state = state
+	b.probing = false
+	b.generation++
+
+	breakerState.WithLabelValues(b.target).Set(float64(state))
+	breakerTransitions.WithLabelValues(b.target, state.String()).Inc()
+	if state == StateOpen {
+		logger.Warn("Circuit breaker opened", "target", b.target, "open_timeout", b.openTimeout)
+	} else {
+		logger.Info("Circuit breaker state changed", "target", b.target, "state", state.String())
+	}
+}
+
+func (b *breaker) interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		generation, ok := b.allow()
+		if !ok {
+			return status.Errorf(codes.Unavailable, "circuit breaker for %s is open", b.target)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(generation, err)
+		return err
+	}
+}
+
+// isFailure отличает недоступность сервиса от ответов бизнес-логики (NotFound, InvalidArgument и т.п.):
+// только она размыкает breaker. Отмена вызова клиентом отказом сервиса не считается
+func isFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
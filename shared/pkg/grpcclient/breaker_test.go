@@ -0,0 +1,123 @@
+package grpcclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeClock - управляемое время для breaker
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(t *testing.T, failures int) (*breaker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	b := newBreaker(t.Name(), failures, 10*time.Second)
+	b.now = clock.now
+	return b, clock
+}
+
+// call пропускает через breaker вызов с результатом err; false - вызов отклонен
+func call(b *breaker, err error) bool {
+	generation, ok := b.allow()
+	if ok {
+		b.record(generation, err)
+	}
+	return ok
+}
+
+var errUnavailable = status.Error(codes.Unavailable, "connection refused")
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(t, 3)
+	rejected := testutil.ToFloat64(breakerRejected.WithLabelValues(t.Name()))
+
+	assert.True(t, call(b, errUnavailable))
+	assert.True(t, call(b, errUnavailable))
+	// Успех сбрасывает счетчик
+	assert.True(t, call(b, nil))
+	assert.True(t, call(b, errUnavailable))
+	assert.True(t, call(b, errUnavailable))
+	assert.Equal(t, StateClosed, b.state)
+
+	assert.True(t, call(b, errUnavailable))
+	assert.Equal(t, StateOpen, b.state)
+	assert.False(t, call(b, nil))
+
+	assert.Equal(t, float64(StateOpen), testutil.ToFloat64(breakerState.WithLabelValues(t.Name())))
+	assert.Equal(t, rejected+1, testutil.ToFloat64(breakerRejected.WithLabelValues(t.Name())))
+}
+
+func TestBreaker_IgnoresBusinessErrors(t *testing.T) {
+	b, _ := newTestBreaker(t, 2)
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, call(b, status.Error(codes.NotFound, "good not found")))
+		assert.True(t, call(b, status.Error(codes.Canceled, "context canceled")))
+	}
+	assert.Equal(t, StateClosed, b.state)
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	b, clock := newTestBreaker(t, 1)
+
+	require.True(t, call(b, errUnavailable))
+	require.Equal(t, StateOpen, b.state)
+
+	clock.advance(5 * time.Second)
+	assert.False(t, call(b, nil))
+
+	// После паузы проходит один пробный вызов, остальные отклоняются, пока он выполняется
+	clock.advance(5 * time.Second)
+	probe, ok := b.allow()
+	require.True(t, ok)
+	assert.Equal(t, StateHalfOpen, b.state)
+	_, ok = b.allow()
+	assert.False(t, ok)
+
+	// Неудачная проба снова размыкает breaker
+	b.record(probe, errUnavailable)
+	assert.Equal(t, StateOpen, b.state)
+
+	clock.advance(10 * time.Second)
+	assert.True(t, call(b, nil))
+	assert.Equal(t, StateClosed, b.state)
+	assert.Equal(t, float64(StateClosed), testutil.ToFloat64(breakerState.WithLabelValues(t.Name())))
+}
+
+func TestBreaker_CanceledProbeAllowsNextProbe(t *testing.T) {
+	b, clock := newTestBreaker(t, 1)
+
+	require.True(t, call(b, errUnavailable))
+	clock.advance(10 * time.Second)
+
+	require.True(t, call(b, status.Error(codes.Canceled, "context canceled")))
+	assert.Equal(t, StateHalfOpen, b.state)
+	assert.True(t, call(b, nil))
+	assert.Equal(t, StateClosed, b.state)
+}
+
+func TestBreaker_IgnoresStaleResults(t *testing.T) {
+	b, clock := newTestBreaker(t, 1)
+
+	// Вызов начат до размыкания и завершился успешно уже в half-open
+	stale, ok := b.allow()
+	require.True(t, ok)
+	require.True(t, call(b, errUnavailable))
+	clock.advance(10 * time.Second)
+	probe, ok := b.allow()
+	require.True(t, ok)
+
+	b.record(stale, nil)
+	assert.Equal(t, StateHalfOpen, b.state)
+
+	b.record(probe, nil)
+	assert.Equal(t, StateClosed, b.state)
+}
@@ -0,0 +1,148 @@
+// Package grpcclient создает gRPC соединения между сервисами с общей обвязкой: трейсинг и request id,
+// повторы и hedging идемпотентных чтений, circuit breaker на каждый сервис, keepalive
+// и round-robin балансировка по адресам из DNS.
+package grpcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
+
+// Config - настройки клиентов downstream сервисов
+type Config struct {
+	// RetryAttempts - сколько раз всего выполняется чтение, если сервис ответил UNAVAILABLE (1 - без повторов)
+	RetryAttempts int `yaml:"retry_attempts" env:"GRPC_CLIENT_RETRY_ATTEMPTS" validate:"positive"`
+	// HedgeDelay - через сколько без ответа на чтение параллельно отправляется второй запрос (0 - без hedging)
+	HedgeDelay time.Duration `yaml:"hedge_delay" env:"GRPC_CLIENT_HEDGE_DELAY"`
+	// BreakerFailures - после скольких отказов подряд breaker размыкается и вызовы сразу завершаются с UNAVAILABLE
+	BreakerFailures int `yaml:"breaker_failures" env:"GRPC_CLIENT_BREAKER_FAILURES" validate:"positive"`
+	// BreakerOpenTimeout - сколько breaker остается разомкнутым, прежде чем пропустить пробный вызов
+	BreakerOpenTimeout time.Duration `yaml:"breaker_open_timeout" env:"GRPC_CLIENT_BREAKER_OPEN_TIMEOUT" validate:"positive"`
+	// KeepaliveTime - как часто пинговать соединение без трафика, чтобы заметить обрыв до вызова
+	KeepaliveTime time.Duration `yaml:"keepalive_time" env:"GRPC_CLIENT_KEEPALIVE_TIME" validate:"positive"`
+	// KeepaliveTimeout - сколько ждать ответа на ping, прежде чем закрыть соединение
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout" env:"GRPC_CLIENT_KEEPALIVE_TIMEOUT" validate:"positive"`
+}
+
+// DefaultConfig возвращает настройки по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		RetryAttempts:      3,
+		HedgeDelay:         300 * time.Millisecond,
+		BreakerFailures:    5,
+		BreakerOpenTimeout: 10 * time.Second,
+		KeepaliveTime:      30 * time.Second,
+		KeepaliveTimeout:   10 * time.Second,
+	}
+}
+
+// readMethods - идемпотентные чтения по сервисам: только их можно повторять и дублировать.
+// Изменяющие вызовы (CreateOrder, ReserveStock, ProcessPayment) не повторяются, чтобы не выполнить их дважды
+var readMethods = map[string][]string{
+	"pb.UsersService":    {"GetUser", "ValidateToken", "CheckTokenRevoked", "ListRoles"},
+	"pb.GoodsService":    {"GetGood", "ListGoods", "CheckStock", "GetCategory", "ListCategories", "SearchGoods"},
+	"pb.OrdersService":   {"GetOrder"},
+	"pb.PaymentsService": {"GetPayment", "GetPaymentByOrderID"},
+	"pb.DeliveryService": {"GetDelivery", "ListDeliveries"},
+}
+
+// isRead сообщает, является ли полный метод вида /pb.GoodsService/GetGood идемпотентным чтением
+func isRead(fullMethod string) bool {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return false
+	}
+	for _, m := range readMethods[service] {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Dial создает соединение с сервисом name ("goods-service" - для метрик и ошибок) по адресу target.
+// Адрес вида host:port разрешается через DNS, запросы распределяются round-robin по всем адресам.
+// Соединение устанавливается при первом вызове. opts добавляются после общих (например, сеть в памяти)
+func Dial(name, target string, cfg Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	serviceConfig, err := serviceConfigJSON(cfg.RetryAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	b := newBreaker(name, cfg.BreakerFailures, cfg.BreakerOpenTimeout)
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// Контекст трейса и request id передаются во все downstream вызовы
+		tracing.DialOption(),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.KeepaliveTime,
+			Timeout:             cfg.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+		// Политики задаются только здесь: конфигурация из DNS TXT записей игнорируется
+		grpc.WithDisableServiceConfig(),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		// Breaker видит итог вызова после всех повторов и hedging
+		grpc.WithChainUnaryInterceptor(
+			correlation.UnaryClientInterceptor(),
+			b.interceptor(),
+			hedgingInterceptor(name, cfg.HedgeDelay),
+		),
+	}
+	dialOptions = append(dialOptions, opts...)
+
+	conn, err := grpc.NewClient(target, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s client: %w", name, err)
+	}
+	return conn, nil
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+// serviceConfigJSON собирает service config: round_robin и политику повторов чтений
+// при UNAVAILABLE с экспоненциальной паузой. Throttling прекращает повторы, если сервис отказывает массово
+func serviceConfigJSON(attempts int) (string, error) {
+	sc := map[string]any{
+		"loadBalancingConfig": []any{map[string]any{"round_robin": map[string]any{}}},
+	}
+
+	// gRPC принимает от 2 до 5 попыток
+	if attempts > 1 {
+		var names []methodName
+		for service, methods := range readMethods {
+			for _, method := range methods {
+				names = append(names, methodName{Service: service, Method: method})
+			}
+		}
+		sc["methodConfig"] = []any{map[string]any{
+			"name": names,
+			"retryPolicy": map[string]any{
+				"maxAttempts":          min(attempts, 5),
+				"initialBackoff":       "0.1s",
+				"maxBackoff":           "1s",
+				"backoffMultiplier":    2,
+				"retryableStatusCodes": []string{"UNAVAILABLE"},
+			},
+		}}
+		sc["retryThrottling"] = map[string]any{"maxTokens": 10, "tokenRatio": 0.1}
+	}
+
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,168 @@
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/inproc"
+)
+
+// goodsServer - goods-service, поведение которого задает тест
+type goodsServer struct {
+	pb.UnimplementedGoodsServiceServer
+	getCalls    atomic.Int32
+	createCalls atomic.Int32
+	get         func(ctx context.Context, call int32) (*pb.Good, error)
+	create      func() (*pb.Good, error)
+}
+
+func (s *goodsServer) GetGood(ctx context.Context, req *pb.GetGoodRequest) (*pb.Good, error) {
+	return s.get(ctx, s.getCalls.Add(1))
+}
+
+func (s *goodsServer) CreateGood(ctx context.Context, req *pb.CreateGoodRequest) (*pb.Good, error) {
+	s.createCalls.Add(1)
+	return s.create()
+}
+
+// dialGoods запускает goodsServer в сети в памяти и подключается к нему через Dial
+func dialGoods(t *testing.T, srv *goodsServer, cfg Config) pb.GoodsServiceClient {
+	t.Helper()
+	network := inproc.NewNetwork()
+
+	s := grpc.NewServer()
+	pb.RegisterGoodsServiceServer(s, srv)
+	go s.Serve(network.Listen("goods-service"))
+	t.Cleanup(s.Stop)
+
+	conn, err := Dial(t.Name(), "passthrough:///goods-service", cfg, network.DialOption())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGoodsServiceClient(conn)
+}
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.HedgeDelay = 0
+	return cfg
+}
+
+func TestDial_RetriesReads(t *testing.T) {
+	srv := &goodsServer{
+		get: func(_ context.Context, call int32) (*pb.Good, error) {
+			if call < 3 {
+				return nil, status.Error(codes.Unavailable, "restarting")
+			}
+			return &pb.Good{Id: 1, Name: "Сенча"}, nil
+		},
+		create: func() (*pb.Good, error) {
+			return nil, status.Error(codes.Unavailable, "restarting")
+		},
+	}
+	client := dialGoods(t, srv, testConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	good, err := client.GetGood(ctx, &pb.GetGoodRequest{GoodId: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Сенча", good.Name)
+	assert.Equal(t, int32(3), srv.getCalls.Load())
+
+	// Изменяющие вызовы не повторяются
+	_, err = client.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Сенча"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(1), srv.createCalls.Load())
+}
+
+func TestDial_HedgesSlowReads(t *testing.T) {
+	srv := &goodsServer{
+		get: func(ctx context.Context, call int32) (*pb.Good, error) {
+			if call == 1 {
+				// Первая попытка зависает, пока ее не отменят
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &pb.Good{Id: 1, Name: "Сенча"}, nil
+		},
+	}
+	cfg := testConfig()
+	cfg.HedgeDelay = 20 * time.Millisecond
+	client := dialGoods(t, srv, cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hedged := testutil.ToFloat64(hedgedTotal.WithLabelValues(t.Name()))
+	start := time.Now()
+	good, err := client.GetGood(ctx, &pb.GetGoodRequest{GoodId: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Сенча", good.Name)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), srv.getCalls.Load())
+	assert.Equal(t, hedged+1, testutil.ToFloat64(hedgedTotal.WithLabelValues(t.Name())))
+}
+
+func TestDial_BreakerFailsFast(t *testing.T) {
+	srv := &goodsServer{
+		create: func() (*pb.Good, error) {
+			return nil, status.Error(codes.Unavailable, "overloaded")
+		},
+	}
+	cfg := testConfig()
+	cfg.BreakerFailures = 2
+	client := dialGoods(t, srv, cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Сенча"})
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	}
+
+	_, err := client.CreateGood(ctx, &pb.CreateGoodRequest{Name: "Сенча"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "circuit breaker")
+	assert.Equal(t, int32(2), srv.createCalls.Load())
+}
+
+func TestServiceConfigJSON(t *testing.T) {
+	data, err := serviceConfigJSON(1)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"loadBalancingConfig":[{"round_robin":{}}]}`, data)
+
+	data, err = serviceConfigJSON(10)
+	require.NoError(t, err)
+	var sc struct {
+		MethodConfig []struct {
+			Name        []methodName `json:"name"`
+			RetryPolicy struct {
+				MaxAttempts int `json:"maxAttempts"`
+			} `json:"retryPolicy"`
+		} `json:"methodConfig"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(data), &sc))
+	require.Len(t, sc.MethodConfig, 1)
+	assert.Equal(t, 5, sc.MethodConfig[0].RetryPolicy.MaxAttempts)
+	assert.Contains(t, sc.MethodConfig[0].Name, methodName{Service: "pb.GoodsService", Method: "CheckStock"})
+	assert.NotContains(t, sc.MethodConfig[0].Name, methodName{Service: "pb.GoodsService", Method: "ReserveStock"})
+}
+
+func TestIsRead(t *testing.T) {
+	assert.True(t, isRead("/pb.GoodsService/GetGood"))
+	assert.False(t, isRead("/pb.GoodsService/ReserveStock"))
+	assert.False(t, isRead("/pb.OrdersService/GetGood"))
+	assert.False(t, isRead("invalid"))
+}
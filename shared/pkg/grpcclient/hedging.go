@@ -0,0 +1,71 @@
+package grpcclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+var hedgedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "grpc_client_hedged_requests_total",
+	Help: "Total number of additional read requests sent because the first one was slow.",
+}, []string{"target"})
+
+// hedgingInterceptor дублирует медленные чтения: если ответа нет за delay, отправляется второй запрос,
+// используется первый успешный ответ, второй отменяется. grpc-go не поддерживает hedgingPolicy
+// из service config, поэтому hedging сделан интерсептором
+func hedgingInterceptor(target string, delay time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		out, ok := reply.(proto.Message)
+		if delay <= 0 || !ok || !isRead(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			reply proto.Message
+			err   error
+		}
+		// Буфер на обе попытки: проигравшая завершится и без читателя
+		results := make(chan result, 2)
+		attempt := func() {
+			// У каждой попытки свой ответ, в reply копируется только победивший
+			r := out.ProtoReflect().New().Interface()
+			err := invoker(ctx, method, req, r, cc, opts...)
+			results <- result{reply: r, err: err}
+		}
+
+		go attempt()
+		pending := 1
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		hedge := timer.C
+
+		for {
+			select {
+			case <-hedge:
+				hedge = nil
+				hedgedTotal.WithLabelValues(target).Inc()
+				go attempt()
+				pending++
+			case res := <-results:
+				pending--
+				if res.err == nil {
+					proto.Merge(out, res.reply)
+					return nil
+				}
+				// Ответ бизнес-логики вернет и вторая попытка; при недоступности ждем вторую
+				if pending == 0 || !isFailure(res.err) {
+					return res.err
+				}
+			}
+		}
+	}
+}
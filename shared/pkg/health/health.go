@@ -0,0 +1,135 @@
+// Package health содержит gRPC health server, состояние которого определяется проверками зависимостей (БД, Kafka).
+package health
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+)
+
+const (
+	defaultInterval = 5 * time.Second
+	defaultTimeout  = 2 * time.Second
+)
+
+// CheckFunc проверяет доступность зависимости
+type CheckFunc func(ctx context.Context) error
+
+// Check - именованная проверка зависимости
+type Check struct {
+	Name string
+	Func CheckFunc
+}
+
+// Database проверяет доступность БД
+func Database(db *sql.DB) Check {
+	return Check{Name: "database", Func: db.PingContext}
+}
+
+// Server - обёртка над grpc health server, периодически выполняющая проверки.
+// Если хотя бы одна проверка не проходит, все сервисы переводятся в NOT_SERVING
+type Server struct {
+	health   *grpchealth.Server
+	services []string
+	checks   []Check
+	interval time.Duration
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	results map[string]error
+}
+
+// NewServer создаёт health server для перечисленных gRPC сервисов (например, "pb.UsersService")
+func NewServer(services []string, checks ...Check) *Server {
+	s := &Server{
+		health:   grpchealth.NewServer(),
+		services: services,
+		checks:   checks,
+		interval: defaultInterval,
+		timeout:  defaultTimeout,
+		results:  make(map[string]error),
+	}
+	// До первой проверки сервис не готов принимать трафик
+	s.setStatus(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	return s
+}
+
+// Register регистрирует health service на gRPC сервере
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	grpc_health_v1.RegisterHealthServer(registrar, s.health)
+}
+
+// Run выполняет проверки сразу и затем с интервалом до отмены контекста
+func (s *Server) Run(ctx context.Context) {
+	s.CheckNow(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckNow(ctx)
+		}
+	}
+}
+
+// CheckNow выполняет все проверки и обновляет статус сервисов
+func (s *Server) CheckNow(ctx context.Context) bool {
+	healthy := true
+	for _, check := range s.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		err := check.Func(checkCtx)
+		cancel()
+
+		s.recordResult(check.Name, err)
+		if err != nil {
+			healthy = false
+		}
+	}
+
+	if healthy {
+		s.setStatus(grpc_health_v1.HealthCheckResponse_SERVING)
+	} else {
+		s.setStatus(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	}
+	return healthy
+}
+
+// Shutdown переводит все сервисы в NOT_SERVING (вызывается перед остановкой сервера)
+func (s *Server) Shutdown() {
+	s.health.Shutdown()
+}
+
+// recordResult логирует только изменения состояния проверки, чтобы не засорять лог
+func (s *Server) recordResult(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, seen := s.results[name]
+	s.results[name] = err
+
+	switch {
+	case err != nil && (!seen || previous == nil):
+		logger.Warn("Health check failed", "check", name, "error", err)
+	case err == nil && seen && previous != nil:
+		logger.Info("Health check recovered", "check", name)
+	}
+}
+
+func (s *Server) setStatus(status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	// Пустое имя - общий статус сервера
+	s.health.SetServingStatus("", status)
+	for _, service := range s.services {
+		s.health.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func status(t *testing.T, s *Server, service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := s.health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Status
+}
+
+func TestServer_StatusFollowsChecks(t *testing.T) {
+	var dbErr error
+	s := NewServer([]string{"pb.TestService"}, Check{
+		Name: "database",
+		Func: func(ctx context.Context) error { return dbErr },
+	})
+
+	if got := status(t, s, "pb.TestService"); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING before first check, got %s", got)
+	}
+
+	if !s.CheckNow(context.Background()) {
+		t.Fatal("expected healthy")
+	}
+	if got := status(t, s, "pb.TestService"); got != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %s", got)
+	}
+	if got := status(t, s, ""); got != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("expected overall SERVING, got %s", got)
+	}
+
+	dbErr = errors.New("connection refused")
+	if s.CheckNow(context.Background()) {
+		t.Fatal("expected unhealthy")
+	}
+	if got := status(t, s, "pb.TestService"); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING after failed check, got %s", got)
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	s := NewServer([]string{"pb.TestService"})
+	s.CheckNow(context.Background())
+	s.Shutdown()
+
+	// После Shutdown статус не возвращается в SERVING
+	s.CheckNow(context.Background())
+	if got := status(t, s, "pb.TestService"); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING after shutdown, got %s", got)
+	}
+}
@@ -0,0 +1,54 @@
+// Package inproc - сеть в памяти процесса для gRPC: сервисы слушают и вызываются по имени, без TCP портов.
+// Используется, когда несколько сервисов работают в одном процессе (allinone, e2e тесты)
+package inproc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufferSize - размер буфера одного соединения
+const bufferSize = 1 << 20
+
+// Network - набор listener'ов в памяти, адресуемых по имени сервиса
+type Network struct {
+	mu        sync.Mutex
+	listeners map[string]*bufconn.Listener
+}
+
+func NewNetwork() *Network {
+	return &Network{listeners: make(map[string]*bufconn.Listener)}
+}
+
+// Listen создаёт listener с адресом name. Повторный вызов с тем же именем заменяет listener
+func (n *Network) Listen(name string) net.Listener {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	listener := bufconn.Listen(bufferSize)
+	n.listeners[name] = listener
+	return listener
+}
+
+// Dial открывает соединение с listener'ом name
+func (n *Network) Dial(ctx context.Context, name string) (net.Conn, error) {
+	n.mu.Lock()
+	listener, ok := n.listeners[name]
+	n.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("inproc: no listener for %q", name)
+	}
+	return listener.DialContext(ctx)
+}
+
+// DialOption направляет соединения gRPC клиента в сеть: адрес цели - имя сервиса,
+// например grpc.Dial("goods-service", ...) или grpc.NewClient("passthrough:///goods-service", ...)
+func (n *Network) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(n.Dial)
+}
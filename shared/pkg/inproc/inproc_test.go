@@ -0,0 +1,46 @@
+package inproc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestDialByName(t *testing.T) {
+	network := NewNetwork()
+
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(network.Listen("goods-service"))
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///goods-service",
+		network.DialOption(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("unexpected status %v", resp.Status)
+	}
+}
+
+func TestDialUnknownName(t *testing.T) {
+	if _, err := NewNetwork().Dial(context.Background(), "ghost"); err == nil {
+		t.Fatal("expected error for unknown listener")
+	}
+}
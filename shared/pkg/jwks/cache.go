@@ -0,0 +1,161 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("signing key not found in JWKS")
+
+type cachedKey struct {
+	alg       string
+	publicKey crypto.PublicKey
+}
+
+// Cache загружает JWKS по URL и кеширует ключи.
+// Ключи перезагружаются раз в refreshInterval, а также при встрече неизвестного kid.
+// Одновременные перезагрузки объединяются в один запрос, а новая попытка делается не чаще,
+// чем раз в minRefreshInterval: ни токены с мусорным kid, ни недоступный источник
+// не создают нагрузку, пока запросы обслуживаются устаревшим кешем.
+type Cache struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]cachedKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	// inflight - идущая перезагрузка, nil - перезагрузки нет
+	inflight *refreshCall
+}
+
+// refreshCall - перезагрузка ключей, которую ждут одновременные запросы
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+func NewCache(url string, refreshInterval time.Duration) *Cache {
+	return &Cache{
+		url:                url,
+		client:             &http.Client{Timeout: 5 * time.Second},
+		refreshInterval:    refreshInterval,
+		minRefreshInterval: 10 * time.Second,
+		keys:               make(map[string]cachedKey),
+	}
+}
+
+// Lookup возвращает публичный ключ и алгоритм по kid
+func (c *Cache) Lookup(ctx context.Context, kid string) (crypto.PublicKey, string, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	stale := time.Since(c.fetchedAt) > c.refreshInterval
+	c.mu.RUnlock()
+
+	if ok && !stale {
+		return key.publicKey, key.alg, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		// Если ключ уже был известен, продолжаем работать на кеше при недоступности источника
+		if ok {
+			return key.publicKey, key.alg, nil
+		}
+		return nil, "", err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, "", ErrKeyNotFound
+	}
+	return key.publicKey, key.alg, nil
+}
+
+// refresh перезагружает ключи или дожидается уже идущей перезагрузки. Чаще, чем раз
+// в minRefreshInterval, источник не запрашивается: тогда возвращается ErrKeyNotFound
+func (c *Cache) refresh(ctx context.Context) error {
+	c.mu.Lock()
+	if call := c.inflight; call != nil {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if time.Since(c.lastAttempt) < c.minRefreshInterval {
+		c.mu.Unlock()
+		return ErrKeyNotFound
+	}
+	c.lastAttempt = time.Now()
+	call := &refreshCall{done: make(chan struct{})}
+	c.inflight = call
+	c.mu.Unlock()
+
+	// Загрузку ждут и другие запросы, поэтому отмена запроса, который ее начал, ее не прерывает
+	call.err = c.load(context.WithoutCancel(ctx))
+
+	c.mu.Lock()
+	c.inflight = nil
+	c.mu.Unlock()
+	close(call.done)
+	return call.err
+}
+
+// load загружает JWKS и заменяет кеш ключей
+func (c *Cache) load(ctx context.Context) error {
+	set, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]cachedKey, len(set.Keys))
+	for _, k := range set.Keys {
+		publicKey, err := k.PublicKey()
+		if err != nil {
+			// Неподдерживаемые ключи пропускаем, остальные продолжают работать
+			continue
+		}
+		keys[k.Kid] = cachedKey{alg: k.Alg, publicKey: publicKey}
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cache) fetch(ctx context.Context) (*Set, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	set := &Set{}
+	if err := json.NewDecoder(resp.Body).Decode(set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	return set, nil
+}
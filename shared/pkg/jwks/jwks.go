@@ -0,0 +1,100 @@
+// Package jwks содержит типы JSON Web Key Set (RFC 7517) и кеширующий клиент для их загрузки.
+package jwks
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported key type")
+
+// Key - публичный ключ в формате JWK
+type Key struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// Set - документ JWKS
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// NewKey собирает JWK из публичного ключа RSA или Ed25519
+func NewKey(kid, alg string, publicKey crypto.PublicKey) (Key, error) {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return Key{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return Key{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, nil
+	default:
+		return Key{}, ErrUnsupportedKey
+	}
+}
+
+// PublicKey восстанавливает публичный ключ из JWK
+func (k Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, ErrUnsupportedKey
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
@@ -0,0 +1,202 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyRoundTrip_RSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := NewKey("kid-rsa", AlgorithmRS256, &private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := key.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !private.PublicKey.Equal(restored) {
+		t.Fatal("restored RSA key does not match original")
+	}
+}
+
+func TestKeyRoundTrip_Ed25519(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := NewKey("kid-ed", AlgorithmEdDSA, public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Kty != "OKP" || key.Crv != "Ed25519" {
+		t.Fatalf("unexpected key type %s/%s", key.Kty, key.Crv)
+	}
+
+	restored, err := key.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !public.Equal(restored) {
+		t.Fatal("restored Ed25519 key does not match original")
+	}
+}
+
+func TestCache_RefreshesOnUnknownKid(t *testing.T) {
+	first, _, _ := ed25519.GenerateKey(rand.Reader)
+	second, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	var requests atomic.Int32
+	set := Set{}
+	key, _ := NewKey("first", AlgorithmEdDSA, first)
+	set.Keys = append(set.Keys, key)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer server.Close()
+
+	cache := NewCache(server.URL, time.Hour)
+	cache.minRefreshInterval = 0
+	ctx := context.Background()
+
+	if _, _, err := cache.Lookup(ctx, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cache.Lookup(ctx, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("expected 1 request, got %d", requests.Load())
+	}
+
+	// Ротация ключа: новый kid появляется в JWKS
+	key, _ = NewKey("second", AlgorithmEdDSA, second)
+	set.Keys = append(set.Keys, key)
+
+	publicKey, alg, err := cache.Lookup(ctx, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alg != AlgorithmEdDSA || !second.Equal(publicKey) {
+		t.Fatal("unexpected key returned for rotated kid")
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests.Load())
+	}
+
+	if _, _, err := cache.Lookup(ctx, "unknown"); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestCache_CoalescesConcurrentRefreshes(t *testing.T) {
+	first, _, _ := ed25519.GenerateKey(rand.Reader)
+	second, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	var requests atomic.Int32
+	release := make(chan struct{})
+	set := Set{}
+	key, _ := NewKey("first", AlgorithmEdDSA, first)
+	set.Keys = append(set.Keys, key)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			<-release
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer server.Close()
+
+	cache := NewCache(server.URL, time.Hour)
+	cache.minRefreshInterval = time.Hour
+	ctx := context.Background()
+	if _, _, err := cache.Lookup(ctx, "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ротация ключа: все запросы с новым kid ждут одну перезагрузку
+	key, _ = NewKey("second", AlgorithmEdDSA, second)
+	set.Keys = append(set.Keys, key)
+	cache.mu.Lock()
+	cache.lastAttempt = time.Time{}
+	cache.mu.Unlock()
+
+	errs := make(chan error, 10)
+	for range 10 {
+		go func() {
+			_, _, err := cache.Lookup(ctx, "second")
+			errs <- err
+		}()
+	}
+	for requests.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for range 10 {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests.Load())
+	}
+}
+
+func TestCache_ThrottlesStaleRefreshes(t *testing.T) {
+	first, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	var requests atomic.Int32
+	set := Set{}
+	key, _ := NewKey("first", AlgorithmEdDSA, first)
+	set.Keys = append(set.Keys, key)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer server.Close()
+
+	// Ключи всегда устаревшие, а источник после первой загрузки недоступен
+	cache := NewCache(server.URL, 0)
+	ctx := context.Background()
+	if _, _, err := cache.Lookup(ctx, "first"); err != nil {
+		t.Fatal(err)
+	}
+	cache.mu.Lock()
+	cache.lastAttempt = time.Time{}
+	cache.mu.Unlock()
+
+	for range 5 {
+		publicKey, _, err := cache.Lookup(ctx, "first")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !first.Equal(publicKey) {
+			t.Fatal("expected cached key while JWKS is unavailable")
+		}
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests.Load())
+	}
+}
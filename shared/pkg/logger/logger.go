@@ -2,38 +2,136 @@ package logger
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
+
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var defaultLogger *slog.Logger
+var (
+	defaultLogger *slog.Logger
+	// level общий для всех логеров процесса, меняется на лету через SetLevel
+	level = new(slog.LevelVar)
+)
 
-// Init инициализирует логер с нужными параметрами
-func Init() {
-	opts := &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-		// Используй LevelDebug для разработки
-		// Level: slog.LevelDebug,
+// Init инициализирует логер сервиса. Уровень берется из LOG_LEVEL (по умолчанию info)
+// и может быть изменен во время работы через SetLevel или LevelHandler
+func Init(service string) {
+	if err := SetLevel(os.Getenv("LOG_LEVEL")); err != nil {
+		level.Set(slog.LevelInfo)
 	}
 
+	defaultLogger = newLogger(os.Stdout, service)
+	slog.SetDefault(defaultLogger)
+}
+
+func newLogger(w io.Writer, service string) *slog.Logger {
 	// JSON handler для продакшна
-	handler := slog.NewJSONHandler(os.Stdout, opts)
+	var handler slog.Handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
 
 	// Или текстовый handler для разработки
-	// handler := slog.NewTextHandler(os.Stdout, opts)
+	// handler = slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})
 
-	defaultLogger = slog.New(handler)
-	slog.SetDefault(defaultLogger)
+	l := slog.New(&contextHandler{Handler: handler})
+	if service != "" {
+		l = l.With("service", service)
+	}
+	return l
 }
 
 // GetLogger возвращает дефолтный логер
 func GetLogger() *slog.Logger {
 	if defaultLogger == nil {
-		Init()
+		defaultLogger = newLogger(os.Stdout, "")
 	}
 	return defaultLogger
 }
 
+// SetLevel меняет уровень логирования: debug, info, warn или error. Пустая строка - info
+func SetLevel(name string) error {
+	if name == "" {
+		level.Set(slog.LevelInfo)
+		return nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level возвращает текущий уровень логирования
+func Level() string {
+	return level.Level().String()
+}
+
+// LevelHandler позволяет смотреть (GET) и менять (PUT {"level":"debug"}) уровень логирования на лету
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Level string `json:"level"`
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			if err := SetLevel(body.Level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			Warn("Log level changed", "log_level", Level())
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body.Level = Level()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
+
+// contextHandler добавляет к каждой записи идентификаторы из контекста:
+// request_id, user_id и trace_id/span_id текущего спана
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := correlation.RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if userID, ok := correlation.UserID(ctx); ok {
+			r.AddAttrs(slog.Int64("user_id", userID))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(
+				slog.String("trace_id", sc.TraceID().String()),
+				slog.String("span_id", sc.SpanID().String()),
+			)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
 // Удобные функции для логирования
 func Info(msg string, args ...any) {
 	GetLogger().Info(msg, args...)
@@ -51,6 +149,23 @@ func Warn(msg string, args ...any) {
 	GetLogger().Warn(msg, args...)
 }
 
+// Варианты с контекстом: к записи добавляются request_id, user_id и trace_id
+func InfoContext(ctx context.Context, msg string, args ...any) {
+	GetLogger().InfoContext(ctx, msg, args...)
+}
+
+func ErrorContext(ctx context.Context, msg string, args ...any) {
+	GetLogger().ErrorContext(ctx, msg, args...)
+}
+
+func DebugContext(ctx context.Context, msg string, args ...any) {
+	GetLogger().DebugContext(ctx, msg, args...)
+}
+
+func WarnContext(ctx context.Context, msg string, args ...any) {
+	GetLogger().WarnContext(ctx, msg, args...)
+}
+
 // LogRequest логирует HTTP/gRPC запрос
 func LogRequest(ctx context.Context, method string, params ...any) {
 	GetLogger().InfoContext(ctx, "request", append([]any{"method", method}, params...)...)
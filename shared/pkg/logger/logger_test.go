@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextHandler_AddsCorrelationFields(t *testing.T) {
+	require.NoError(t, SetLevel("info"))
+	var buf bytes.Buffer
+	l := newLogger(&buf, "orders-service")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = correlation.WithRequestID(ctx, "req-1")
+	ctx = correlation.WithUserID(ctx, 42)
+
+	l.InfoContext(ctx, "order created", "order_id", 7)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "orders-service", line["service"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, float64(42), line["user_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", line["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", line["span_id"])
+	assert.Equal(t, float64(7), line["order_id"])
+}
+
+func TestContextHandler_WithoutContextFields(t *testing.T) {
+	require.NoError(t, SetLevel("info"))
+	var buf bytes.Buffer
+	l := newLogger(&buf, "orders-service")
+
+	l.Info("started")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.NotContains(t, line, "request_id")
+	assert.NotContains(t, line, "trace_id")
+}
+
+func TestSetLevel(t *testing.T) {
+	defer SetLevel("info")
+
+	var buf bytes.Buffer
+	l := newLogger(&buf, "")
+
+	l.Debug("hidden")
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, SetLevel("DEBUG"))
+	assert.Equal(t, "DEBUG", Level())
+	l.Debug("visible")
+	assert.Contains(t, buf.String(), "visible")
+
+	assert.Error(t, SetLevel("verbose"))
+	assert.Equal(t, "DEBUG", Level())
+}
+
+func TestLevelHandler(t *testing.T) {
+	defer SetLevel("info")
+	handler := LevelHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level":"warn"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"WARN"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/loglevel", nil))
+	assert.JSONEq(t, `{"level":"WARN"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level":"loud"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/loglevel", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
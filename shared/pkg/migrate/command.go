@@ -0,0 +1,103 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+)
+
+// Usage - справка по подкоманде migrate
+const Usage = `Usage:
+  migrate up            применить все новые миграции
+  migrate down [N]      откатить N последних миграций (по умолчанию 1)
+  migrate status        показать примененные и ожидающие миграции`
+
+// Apply применяет новые миграции при старте сервиса
+func Apply(ctx context.Context, db *sql.DB, fsys fs.FS) error {
+	migrator, err := New(db, fsys)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up(ctx)
+	return err
+}
+
+// RunCommand выполняет подкоманду migrate up|down|status
+func RunCommand(ctx context.Context, cfg database.Config, fsys fs.FS, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(Usage)
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			return errors.New(Usage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return errors.New(Usage)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q\n\n%s", args[1], Usage)
+			}
+			steps = n
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], Usage)
+	}
+
+	db, err := database.Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := New(db, fsys)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied  %03d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %03d_%s\n", m.Version, m.Name)
+		}
+		return err
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,263 @@
+// Package migrate применяет версионированные SQL миграции, встроенные в бинарник через embed.
+//
+// Файлы называются NNN_name.up.sql и NNN_name.down.sql. Примененные версии хранятся
+// в таблице schema_migrations, а одновременный запуск нескольких инстансов
+// сериализуется advisory lock'ом PostgreSQL.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+)
+
+// lockKey - ключ advisory lock'а; у каждого сервиса своя БД, поэтому ключ общий
+const lockKey int64 = 7_465_610_001
+
+const createSchemaMigrationsSQL = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)
+`
+
+var (
+	ErrIrreversible     = errors.New("migration has no down script")
+	ErrInvalidMigration = errors.New("invalid migration file")
+)
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration - одна версия схемы
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status - миграция и время ее применения (nil, если еще не применена)
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New читает миграции из fsys (обычно embed.FS пакета migrations сервиса)
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load разбирает файлы миграций и сортирует их по версии
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			// Служебные файлы пакета (migrations.go) пропускаем
+			if strings.HasSuffix(entry.Name(), ".sql") {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidMigration, entry.Name())
+			}
+			continue
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %d has different names %q and %q", ErrInvalidMigration, version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("%w: version %d has no up script", ErrInvalidMigration, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up применяет все непримененные миграции по возрастанию версии
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := apply(ctx, conn, migration, migration.Up, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down откатывает steps последних примененных миграций
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %03d_%s", ErrIrreversible, migration.Version, migration.Name)
+			}
+			if err := apply(ctx, conn, migration, migration.Down, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status возвращает все известные миграции с отметкой о применении
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := Status{Migration: migration}
+			if appliedAt, ok := done[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// withLock выполняет fn на отдельном соединении под session advisory lock'ом:
+// миграции идут в нескольких транзакциях, и блокировка должна пережить их все
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	if _, err := conn.ExecContext(ctx, createSchemaMigrationsSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	return versions, rows.Err()
+}
+
+// apply выполняет скрипт и обновляет schema_migrations в одной транзакции
+func apply(ctx context.Context, conn *sql.Conn, migration Migration, script string, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+	logger.Info("Migration applied", "version", migration.Version, "name", migration.Name, "direction", direction)
+	return nil
+}
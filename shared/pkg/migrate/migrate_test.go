@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+)
+
+func TestLoad_SortsByVersionAndPairsScripts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_index.up.sql":      {Data: []byte("CREATE INDEX idx ON t(a);")},
+		"001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (a INT);")},
+		"001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"migrations.go":             {Data: []byte("package migrations")},
+	}
+
+	migrations, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_table", migrations[0].Name)
+	assert.Equal(t, "CREATE TABLE t (a INT);", migrations[0].Up)
+	assert.Equal(t, "DROP TABLE t;", migrations[0].Down)
+
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Empty(t, migrations[1].Down)
+}
+
+func TestLoad_InvalidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "bad file name",
+			fsys: fstest.MapFS{"create_table.sql": {Data: []byte("SELECT 1")}},
+		},
+		{
+			name: "down without up",
+			fsys: fstest.MapFS{"001_create_table.down.sql": {Data: []byte("DROP TABLE t;")}},
+		},
+		{
+			name: "same version with different names",
+			fsys: fstest.MapFS{
+				"001_create_table.up.sql": {Data: []byte("CREATE TABLE t (a INT);")},
+				"001_other.up.sql":        {Data: []byte("CREATE TABLE o (a INT);")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			assert.ErrorIs(t, err, ErrInvalidMigration)
+		})
+	}
+}
+
+func TestRunCommand_InvalidArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no command", args: nil},
+		{name: "unknown command", args: []string{"redo"}},
+		{name: "extra args for up", args: []string{"up", "1"}},
+		{name: "invalid steps", args: []string{"down", "zero"}},
+		{name: "non positive steps", args: []string{"down", "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			// Аргументы проверяются до подключения к БД
+			err := RunCommand(context.Background(), database.Config{}, fstest.MapFS{}, tt.args, &out)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "Usage:")
+			assert.Empty(t, out.String())
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Package rbac описывает роли и права доступа, общие для users-service и api-gateway.
+// Источник истины для назначения прав ролям - таблица role_permissions в users_db;
+// DefaultRoles используется для первоначального заполнения.
+package rbac
+
+const (
+	RoleUser      = "user"
+	RoleAdmin     = "admin"
+	RoleWarehouse = "warehouse"
+	RoleCourier   = "courier"
+	RoleSupport   = "support"
+)
+
+const (
+	// Каталог и склад. goods:read - выгрузка каталога вместе с остатками по складам
+	PermGoodsRead  = "goods:read"
+	PermGoodsWrite = "goods:write"
+	PermStockWrite = "stock:write"
+
+	// Заказы и платежи
+	PermOrdersRead     = "orders:read"
+	PermOrdersWrite    = "orders:write"
+	PermPaymentsRefund = "payments:refund"
+
+	// Доставки. Без deliveries:assign доступны только доставки, назначенные пользователю
+	PermDeliveriesRead         = "deliveries:read"
+	PermDeliveriesUpdateStatus = "deliveries:update_status"
+	PermDeliveriesAssign       = "deliveries:assign"
+
+	// Управление пользователями
+	PermUsersManageRoles = "users:manage_roles"
+)
+
+// AllPermissions - полный список известных прав
+var AllPermissions = []string{
+	PermGoodsRead,
+	PermGoodsWrite,
+	PermStockWrite,
+	PermOrdersRead,
+	PermOrdersWrite,
+	PermPaymentsRefund,
+	PermDeliveriesRead,
+	PermDeliveriesUpdateStatus,
+	PermDeliveriesAssign,
+	PermUsersManageRoles,
+}
+
+// Role - роль и набор её прав
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// DefaultRoles - роли по умолчанию. users-service создаёт при старте недостающие роли и права;
+// это единственный источник начального заполнения, миграции ролей не заполняют
+var DefaultRoles = []Role{
+	{
+		Name:        RoleUser,
+		Description: "Покупатель",
+	},
+	{
+		Name:        RoleAdmin,
+		Description: "Администратор",
+		Permissions: AllPermissions,
+	},
+	{
+		Name:        RoleWarehouse,
+		Description: "Сотрудник склада: товары и остатки",
+		Permissions: []string{PermGoodsRead, PermGoodsWrite, PermStockWrite},
+	},
+	{
+		Name:        RoleCourier,
+		Description: "Курьер: просмотр доставок и смена их статуса",
+		Permissions: []string{PermDeliveriesRead, PermDeliveriesUpdateStatus},
+	},
+	{
+		Name:        RoleSupport,
+		Description: "Поддержка: просмотр заказов и возвраты",
+		Permissions: []string{PermGoodsRead, PermOrdersRead, PermPaymentsRefund},
+	},
+}
+
+// HasPermission проверяет наличие права в наборе
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apperrors "github.com/che1nov/tea-shop/shared/pkg/errors"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+)
+
+// recoveryInterceptor превращает панику в обработчике в codes.Internal, не роняя процесс
+func recoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(ctx, "Panic in gRPC handler",
+					"method", info.FullMethod,
+					"panic", r,
+					"stack", string(debug.Stack()),
+				)
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// appErrorInterceptor переводит AppError из сервисного слоя в gRPC статус
+func appErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, apperrors.ToStatus(err)
+		}
+		return resp, nil
+	}
+}
+
+// deadlineInterceptor ставит дедлайн по умолчанию, если клиент его не передал,
+// и сразу отклоняет вызовы с уже истекшим дедлайном
+func deadlineInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if deadline, ok := ctx.Deadline(); ok {
+			if time.Until(deadline) <= 0 {
+				return nil, status.Error(codes.DeadlineExceeded, "deadline exceeded before handling")
+			}
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// accessLogInterceptor пишет строку на каждый вызов; request_id и trace_id добавляет логер из контекста
+func accessLogInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		duration := float64(time.Since(start).Microseconds()) / 1000
+
+		code := status.Code(err)
+		switch {
+		case strings.HasPrefix(info.FullMethod, "/grpc.health.v1."):
+			// Пробы балансировщиков логируются только при ошибке
+			if err != nil {
+				logger.LogError(ctx, "Health check request failed", err, "method", info.FullMethod)
+			}
+		case isServerError(code):
+			logger.LogError(ctx, "gRPC request failed", err,
+				"method", info.FullMethod, "code", code.String(), "duration_ms", duration)
+		default:
+			logger.LogResponse(ctx, info.FullMethod, duration, "code", code.String())
+		}
+		return resp, err
+	}
+}
+
+// isServerError - ошибки, за которые отвечает сервис, а не клиент
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// splitMethod разбивает "/pb.GoodsService/GetGood" на сервис и метод
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// RED метрики gRPC сервера: rate и errors - по grpc_server_handled_total с лейблом grpc_code,
+// duration - по гистограмме grpc_server_handling_seconds
+var (
+	handledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of gRPC calls completed on the server, by status code.",
+	}, []string{"grpc_service", "grpc_method", "grpc_code"})
+
+	handlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Duration of gRPC calls handled by the server.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"grpc_service", "grpc_method"})
+
+	inFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_server_in_flight_requests",
+		Help: "Number of gRPC calls currently being handled.",
+	}, []string{"grpc_service"})
+)
+
+func metricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		service, method := splitMethod(info.FullMethod)
+
+		inFlight.WithLabelValues(service).Inc()
+		defer inFlight.WithLabelValues(service).Dec()
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		handlingSeconds.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+		handledTotal.WithLabelValues(service, method, status.Code(err).String()).Inc()
+		return resp, err
+	}
+}
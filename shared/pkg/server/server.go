@@ -0,0 +1,229 @@
+// Package server собирает gRPC сервер сервиса: цепочка интерсепторов, health, reflection,
+// HTTP сервер метрик и graceful shutdown.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"github.com/che1nov/tea-shop/shared/pkg/health"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+)
+
+const (
+	defaultRequestTimeout  = 10 * time.Second
+	defaultShutdownTimeout = 15 * time.Second
+	defaultDrainDelay      = 2 * time.Second
+	keepaliveMinTime       = 10 * time.Second
+)
+
+// Config - параметры сервера сервиса
+type Config struct {
+	// Name - имя сервиса для логов, например "goods-service"
+	Name string
+	// Port - порт gRPC сервера
+	Port int
+	// MetricsPort - порт HTTP сервера с /metrics и /loglevel (0 - не запускать)
+	MetricsPort int
+	// RequestTimeout - дедлайн вызова, если клиент не передал свой
+	RequestTimeout time.Duration
+	// DrainDelay - пауза между переводом health в NOT_SERVING и остановкой приема запросов,
+	// чтобы балансировщики успели убрать инстанс
+	DrainDelay time.Duration
+	// ShutdownTimeout - сколько ждать завершения активных вызовов, потом сервер останавливается принудительно
+	ShutdownTimeout time.Duration
+	// MaxRecvMsgSize - наибольший размер входящего сообщения в байтах (0 - 4 МБ, как в gRPC по умолчанию)
+	MaxRecvMsgSize int
+}
+
+type closer struct {
+	name string
+	fn   func() error
+}
+
+// Server - gRPC сервер с общей для всех сервисов обвязкой.
+// Реализует grpc.ServiceRegistrar, поэтому сервисы регистрируются обычным pb.RegisterXxxServer(srv, handler)
+type Server struct {
+	cfg      Config
+	grpc     *grpc.Server
+	checks   []health.Check
+	services []string
+	mux      *http.ServeMux
+
+	mu      sync.Mutex
+	closers []closer
+}
+
+// New создает сервер. checks определяют готовность сервиса (БД, Kafka)
+func New(cfg Config, checks ...health.Check) *Server {
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = defaultRequestTimeout
+	}
+	if cfg.DrainDelay == 0 {
+		cfg.DrainDelay = defaultDrainDelay
+	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	s := &Server{
+		cfg:    cfg,
+		checks: checks,
+		mux:    http.NewServeMux(),
+	}
+	// Порядок важен: внешние интерсепторы видят итоговый код ответа,
+	// включая панику и преобразованную AppError
+	options := []grpc.ServerOption{
+		tracing.ServerOption(),
+		// Клиенты grpcclient пингуют соединения без активных вызовов; по умолчанию сервер
+		// закрыл бы такие соединения с too_many_pings
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             keepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+		grpc.ChainUnaryInterceptor(
+			correlation.UnaryServerInterceptor(),
+			accessLogInterceptor(),
+			metricsInterceptor(),
+			appErrorInterceptor(),
+			recoveryInterceptor(),
+			deadlineInterceptor(cfg.RequestTimeout),
+		),
+	}
+	if cfg.MaxRecvMsgSize > 0 {
+		options = append(options, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize))
+	}
+	s.grpc = grpc.NewServer(options...)
+
+	s.mux.Handle("/metrics", promhttp.Handler())
+	s.mux.Handle("/loglevel", logger.LevelHandler())
+	return s
+}
+
+// RegisterService регистрирует gRPC сервис; его имя попадает в health протокол
+func (s *Server) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	s.grpc.RegisterService(desc, impl)
+	s.services = append(s.services, desc.ServiceName)
+}
+
+// HandleHTTP добавляет обработчик на HTTP сервер метрик (например, JWKS)
+func (s *Server) HandleHTTP(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// OnShutdown добавляет ресурс, который закрывается после остановки gRPC сервера.
+// Ресурсы закрываются в обратном порядке, как defer: сначала Kafka, потом БД
+func (s *Server) OnShutdown(name string, fn func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closers = append(s.closers, closer{name: name, fn: fn})
+}
+
+// Run слушает cfg.Port и обслуживает запросы до отмены контекста
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.Port))
+	if err != nil {
+		s.closeResources()
+		return fmt.Errorf("failed to listen on port %d: %w", s.cfg.Port, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve обслуживает запросы на listener до отмены контекста, затем останавливается:
+// health NOT_SERVING → пауза DrainDelay → GracefulStop → закрытие ресурсов
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	healthServer := health.NewServer(s.services, s.checks...)
+	healthServer.Register(s.grpc)
+	reflection.Register(s.grpc)
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	go healthServer.Run(healthCtx)
+
+	var metricsServer *http.Server
+	if s.cfg.MetricsPort != 0 {
+		metricsServer = &http.Server{
+			Addr:    fmt.Sprintf(":%d", s.cfg.MetricsPort),
+			Handler: s.mux,
+		}
+		go func() {
+			logger.Info("Metrics server started", "service", s.cfg.Name, "port", s.cfg.MetricsPort)
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Metrics server error", "error", err)
+			}
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("gRPC server started", "service", s.cfg.Name, "addr", listener.Addr().String())
+		serveErr <- s.grpc.Serve(listener)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-serveErr:
+		err = fmt.Errorf("gRPC server stopped: %w", err)
+	}
+
+	logger.Info("Shutting down", "service", s.cfg.Name)
+
+	// Сначала сообщаем балансировщикам, что инстанс уходит, и даем им время это заметить
+	healthServer.Shutdown()
+	if err == nil {
+		time.Sleep(s.cfg.DrainDelay)
+	}
+	s.stopGRPC()
+
+	if metricsServer != nil {
+		if err := metricsServer.Close(); err != nil {
+			logger.Error("Error closing metrics server", "error", err)
+		}
+	}
+	s.closeResources()
+
+	logger.Info("Stopped", "service", s.cfg.Name)
+	return err
+}
+
+// stopGRPC дожидается завершения активных вызовов, но не дольше ShutdownTimeout
+func (s *Server) stopGRPC() {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(s.cfg.ShutdownTimeout):
+		logger.Warn("Graceful shutdown timed out, forcing stop", "service", s.cfg.Name)
+		s.grpc.Stop()
+	}
+}
+
+func (s *Server) closeResources() {
+	s.mu.Lock()
+	closers := s.closers
+	s.closers = nil
+	s.mu.Unlock()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].fn(); err != nil {
+			logger.Error("Error closing resource", "resource", closers[i].name, "error", err)
+		}
+	}
+}
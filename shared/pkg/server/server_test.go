@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	apperrors "github.com/che1nov/tea-shop/shared/pkg/errors"
+	"github.com/che1nov/tea-shop/shared/pkg/health"
+)
+
+// testService - сервис с единственным методом Check, поведение которого задает тест
+type testService struct {
+	grpc_health_v1.UnimplementedHealthServer
+	check func(ctx context.Context) error
+}
+
+func (s *testService) Check(ctx context.Context, _ *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if err := s.check(ctx); err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+// startServer запускает сервер на bufconn и возвращает клиентское соединение и функцию остановки
+func startServer(t *testing.T, srv *Server, svc *testService) (*grpc.ClientConn, func() error) {
+	desc := grpc_health_v1.Health_ServiceDesc
+	desc.ServiceName = "test.Service"
+	srv.RegisterService(&desc, svc)
+
+	listener := bufconn.Listen(1024 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, listener) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	stop := func() error {
+		cancel()
+		err := <-done
+		conn.Close()
+		return err
+	}
+	return conn, stop
+}
+
+func call(ctx context.Context, conn *grpc.ClientConn) error {
+	var resp grpc_health_v1.HealthCheckResponse
+	return conn.Invoke(ctx, "/test.Service/Check", &grpc_health_v1.HealthCheckRequest{}, &resp)
+}
+
+func testConfig() Config {
+	return Config{Name: "test", DrainDelay: time.Millisecond, RequestTimeout: time.Second}
+}
+
+func TestServer_RecoversFromPanic(t *testing.T) {
+	conn, stop := startServer(t, New(testConfig()), &testService{check: func(context.Context) error {
+		panic("boom")
+	}})
+	defer stop()
+
+	err := call(context.Background(), conn)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	// Сервер продолжает обслуживать запросы после паники
+	err = call(context.Background(), conn)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestServer_MapsAppError(t *testing.T) {
+	conn, stop := startServer(t, New(testConfig()), &testService{check: func(context.Context) error {
+		return apperrors.NewWithErr(apperrors.ErrNotFound, "good not found", errors.New("sql: no rows"))
+	}})
+	defer stop()
+
+	err := call(context.Background(), conn)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "good not found", st.Message())
+}
+
+func TestServer_SetsDefaultDeadline(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	conn, stop := startServer(t, New(testConfig()), &testService{check: func(ctx context.Context) error {
+		deadline, hasDeadline = ctx.Deadline()
+		return nil
+	}})
+	defer stop()
+
+	require.NoError(t, call(context.Background(), conn))
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, time.Second)
+}
+
+func TestServer_HealthAndShutdown(t *testing.T) {
+	srv := New(testConfig(), health.Check{Name: "database", Func: func(context.Context) error { return nil }})
+	var closed []string
+	srv.OnShutdown("database", func() error { closed = append(closed, "database"); return nil })
+	srv.OnShutdown("kafka", func() error { closed = append(closed, "kafka"); return nil })
+
+	conn, stop := startServer(t, srv, &testService{check: func(context.Context) error { return nil }})
+
+	client := grpc_health_v1.NewHealthClient(conn)
+	assert.Eventually(t, func() bool {
+		resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "test.Service"})
+		return err == nil && resp.GetStatus() == grpc_health_v1.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, stop())
+	assert.Equal(t, []string{"kafka", "database"}, closed)
+}
@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Поддерживаемые экспортеры трейсов
+const (
+	// ExporterNone - спаны не записываются, но контекст трейса пробрасывается дальше
+	ExporterNone = "none"
+	// ExporterStdout - спаны пишутся в stdout в JSON
+	ExporterStdout = "stdout"
+	// ExporterFile - спаны пишутся в файл, по одному JSON объекту на строку (для офлайн тестов)
+	ExporterFile = "file"
+	// ExporterOTLP - спаны отправляются по OTLP/gRPC (Jaeger, OpenTelemetry Collector)
+	ExporterOTLP = "otlp"
+)
+
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+// Config - настройки трейсинга сервиса
+type Config struct {
+	ServiceName string `yaml:"-"`
+	Exporter    string `yaml:"exporter" env:"TRACING_EXPORTER" validate:"oneof=none stdout file otlp"`
+	// Endpoint - адрес OTLP/gRPC приемника (для ExporterOTLP)
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT" validate:"hostport"`
+	// FilePath - файл для спанов (для ExporterFile)
+	FilePath string `yaml:"file" env:"TRACING_FILE"`
+}
+
+// ShutdownFunc сбрасывает накопленные спаны и освобождает ресурсы экспортера
+type ShutdownFunc func(ctx context.Context) error
+
+// Init настраивает глобальный TracerProvider и W3C propagator.
+// Возвращаемую функцию нужно вызвать при остановке сервиса.
+func Init(ctx context.Context, cfg Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		spanProcessor sdktrace.SpanProcessor
+		closer        io.Closer
+	)
+
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		spanProcessor = sdktrace.NewSimpleSpanProcessor(exporter)
+	case ExporterFile:
+		if cfg.FilePath == "" {
+			return nil, errors.New("tracing file path is required for file exporter")
+		}
+		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tracing file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		spanProcessor = sdktrace.NewSimpleSpanProcessor(exporter)
+		closer = file
+	case ExporterOTLP:
+		exporter, err := otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(cfg.Endpoint),
+			otlptracegrpc.WithInsecure(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		spanProcessor = sdktrace.NewBatchSpanProcessor(exporter)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, cfg.Exporter)
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(spanProcessor),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+// Tracer возвращает tracer из глобального провайдера
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// ServerOption инструментирует gRPC сервер: извлекает контекст трейса из metadata
+// и создает серверный спан на каждый вызов. Health check пробы не трейсятся.
+func ServerOption() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler(
+		otelgrpc.WithFilter(filters.Not(filters.HealthCheck())),
+	))
+}
+
+// DialOption инструментирует gRPC клиент: создает клиентский спан и кладет контекст трейса в metadata
+func DialOption() grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+		otelgrpc.WithFilter(filters.Not(filters.HealthCheck())),
+	))
+}
+
+// OpenDB открывает *sql.DB, у которого каждый запрос к БД становится спаном
+func OpenDB(driverName, dataSourceName string) (*sql.DB, error) {
+	return otelsql.Open(driverName, dataSourceName,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestInit_FileExporterWritesSpans(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Init(context.Background(), Config{
+		ServiceName: "test-service",
+		Exporter:    ExporterFile,
+		FilePath:    path,
+	})
+	require.NoError(t, err)
+
+	_, span := Tracer("test").Start(context.Background(), "checkout")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"checkout"`)
+	assert.Contains(t, string(data), "test-service")
+}
+
+func TestInit_UnknownExporter(t *testing.T) {
+	_, err := Init(context.Background(), Config{ServiceName: "test-service", Exporter: "zipkin"})
+	assert.ErrorIs(t, err, ErrUnknownExporter)
+}
+
+func TestInit_FileExporterRequiresPath(t *testing.T) {
+	_, err := Init(context.Background(), Config{ServiceName: "test-service", Exporter: ExporterFile})
+	assert.Error(t, err)
+}
+
+// traceIDServer запоминает trace id входящего вызова
+type traceIDServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	traceID trace.TraceID
+}
+
+func (s *traceIDServer) Check(ctx context.Context, _ *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	s.traceID = trace.SpanContextFromContext(ctx).TraceID()
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func TestGRPC_PropagatesTraceContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Init(context.Background(), Config{
+		ServiceName: "test-service",
+		Exporter:    ExporterFile,
+		FilePath:    path,
+	})
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	// Регистрируем health сервис под другим именем: настоящие health пробы не трейсятся
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(ServerOption())
+	recorder := &traceIDServer{}
+	desc := grpc_health_v1.Health_ServiceDesc
+	desc.ServiceName = "test.Traced"
+	server.RegisterService(&desc, recorder)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		DialOption(),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, span := Tracer("test").Start(context.Background(), "request")
+	var resp grpc_health_v1.HealthCheckResponse
+	err = conn.Invoke(ctx, "/test.Traced/Check", &grpc_health_v1.HealthCheckRequest{}, &resp)
+	span.End()
+	require.NoError(t, err)
+
+	assert.Equal(t, span.SpanContext().TraceID(), recorder.traceID)
+
+	require.NoError(t, shutdown(context.Background()))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(string(data), "test.Traced/Check"))
+}
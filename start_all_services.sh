@@ -41,7 +41,7 @@ start_service() {
 }
 
 # Запуск всех сервисов
-start_service "users-service" "users-service" "8001" "ADMIN_EMAIL=admin@example.com ADMIN_PASSWORD=admin123"
+start_service "users-service" "users-service" "8001"
 start_service "goods-service" "goods-service" "8002"
 start_service "order-service" "order-service" "8003"
 start_service "payment-service" "payment-service" "8004"
@@ -60,6 +60,9 @@ echo "  - Delivery Service: http://localhost:8005"
 echo "  - Notify Service:   http://localhost:8006 (Kafka consumer)"
 echo "  - API Gateway:      http://localhost:8080"
 echo ""
+echo "To create the first admin account:"
+echo "  cd users-service && echo \"<password>\" | go run ./cmd/main.go admin create --email admin@example.com"
+echo ""
 echo "To stop all services:"
 echo "  ./stop_all_services.sh"
 echo ""
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+)
+
+const deliveriesUsage = `Usage:
+  teashopctl deliveries list [--status <status>] [--limit 20] [--offset 0]
+  teashopctl deliveries get <id>
+  teashopctl deliveries set-status <id> <status>`
+
+func (a *app) deliveriesCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(deliveriesUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("deliveries list", flag.ContinueOnError)
+		status := fs.String("status", "", "фильтр по статусу")
+		limit := fs.Int("limit", 20, "количество доставок")
+		offset := fs.Int("offset", 0, "смещение")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		ctx, err := a.authorize(ctx, rbac.PermDeliveriesRead)
+		if err != nil {
+			return err
+		}
+		resp, err := a.client.Delivery.ListDeliveries(ctx, &pb.ListDeliveriesRequest{
+			Limit:  int32(*limit),
+			Offset: int32(*offset),
+			Status: *status,
+		})
+		if err != nil {
+			return err
+		}
+		return a.out.Print(resp, deliveriesRows(resp.Deliveries...))
+
+	case "get":
+		if len(args) != 2 {
+			return errors.New(deliveriesUsage)
+		}
+		id, err := parseID(args[1])
+		if err != nil {
+			return err
+		}
+		ctx, err = a.authorize(ctx, rbac.PermDeliveriesRead)
+		if err != nil {
+			return err
+		}
+		delivery, err := a.client.Delivery.GetDelivery(ctx, &pb.GetDeliveryRequest{DeliveryId: id})
+		if err != nil {
+			return err
+		}
+		return a.out.Print(delivery, deliveriesRows(delivery))
+
+	case "set-status":
+		if len(args) != 3 || args[2] == "" {
+			return errors.New(deliveriesUsage)
+		}
+		id, err := parseID(args[1])
+		if err != nil {
+			return err
+		}
+		ctx, err = a.authorize(ctx, rbac.PermDeliveriesUpdateStatus)
+		if err != nil {
+			return err
+		}
+		delivery, err := a.client.Delivery.UpdateDeliveryStatus(ctx, &pb.UpdateDeliveryStatusRequest{DeliveryId: id, Status: args[2]})
+		if err != nil {
+			return err
+		}
+		return a.out.Print(delivery, deliveriesRows(delivery))
+
+	default:
+		return fmt.Errorf("unknown deliveries command %q\n\n%s", args[0], deliveriesUsage)
+	}
+}
+
+func deliveriesRows(deliveries ...*pb.Delivery) output.Rows {
+	rows := output.Rows{Header: []string{"ID", "ORDER_ID", "STATUS", "ADDRESS", "CREATED", "UPDATED"}}
+	for _, delivery := range deliveries {
+		rows.Add(
+			output.ID(delivery.Id),
+			output.ID(delivery.OrderId),
+			delivery.Status,
+			delivery.Address,
+			output.Time(delivery.CreatedAt),
+			output.Time(delivery.UpdatedAt),
+		)
+	}
+	return rows
+}
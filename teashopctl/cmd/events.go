@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+)
+
+const eventsUsage = `Usage:
+  teashopctl events replay <order_id>...
+
+Событие выбирается по статусу заказа: order.completed для выполненных, иначе order.created`
+
+func (a *app) eventsCommand(ctx context.Context, args []string) error {
+	if len(args) < 2 || args[0] != "replay" {
+		return errors.New(eventsUsage)
+	}
+
+	ids := make([]int64, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+
+	ctx, err := a.authorize(ctx, rbac.PermOrdersWrite)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Orders.ReplayOrderEvents(ctx, &pb.ReplayOrderEventsRequest{OrderIds: ids})
+	if err != nil {
+		return fmt.Errorf("replay failed: %w", err)
+	}
+
+	rows := output.Rows{Header: []string{"ORDERS", "PUBLISHED"}}
+	rows.Add(fmt.Sprint(len(ids)), fmt.Sprint(resp.Published))
+	return a.out.Print(resp, rows)
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/catalog"
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+)
+
+const goodsUsage = `Usage:
+  teashopctl goods list [--limit 20] [--offset 0]
+  teashopctl goods get <id>
+  teashopctl goods create --name <name> --price <price> --stock <stock> [--sku <sku>] [--description <text>]
+  teashopctl goods update <id> [--name <name>] [--price <price>] [--stock <stock>] [--sku <sku>] [--description <text>]
+  teashopctl goods import <file.csv|->
+  teashopctl goods export [--file <file.csv>]
+
+CSV: заголовок sku,name,description,price,stock; товары с известным sku обновляются, остальные создаются.
+Новый остаток существующего товара проводится корректировкой склада и требует права stock:write`
+
+// stockAdjustmentReason - причина корректировок остатка, сделанных teashopctl
+const stockAdjustmentReason = "teashopctl"
+
+// exportPageSize - размер страницы при выгрузке каталога
+const exportPageSize = 100
+
+func (a *app) goodsCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(goodsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		fs := flag.NewFlagSet("goods list", flag.ContinueOnError)
+		limit := fs.Int("limit", 20, "количество товаров")
+		offset := fs.Int("offset", 0, "смещение")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		resp, err := a.client.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: int32(*limit), Offset: int32(*offset)})
+		if err != nil {
+			return err
+		}
+		return a.out.Print(resp, goodsRows(resp.Goods...))
+
+	case "get":
+		if len(args) != 2 {
+			return errors.New(goodsUsage)
+		}
+		id, err := parseID(args[1])
+		if err != nil {
+			return err
+		}
+		good, err := a.client.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: id})
+		if err != nil {
+			return err
+		}
+		return a.out.Print(good, goodsRows(good))
+
+	case "create":
+		return a.goodsCreate(ctx, args[1:])
+	case "update":
+		return a.goodsUpdate(ctx, args[1:])
+	case "import":
+		return a.goodsImport(ctx, args[1:])
+	case "export":
+		return a.goodsExport(ctx, args[1:])
+	default:
+		return fmt.Errorf("unknown goods command %q\n\n%s", args[0], goodsUsage)
+	}
+}
+
+func (a *app) goodsCreate(ctx context.Context, args []string) error {
+	req := &pb.CreateGoodRequest{}
+	var stock int
+	fs := flag.NewFlagSet("goods create", flag.ContinueOnError)
+	fs.StringVar(&req.Name, "name", "", "название")
+	fs.StringVar(&req.Description, "description", "", "описание")
+	fs.StringVar(&req.Sku, "sku", "", "артикул")
+	fs.Float64Var(&req.Price, "price", 0, "цена")
+	fs.IntVar(&stock, "stock", 0, "остаток")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if req.Name == "" || req.Price <= 0 || stock < 0 {
+		return errors.New(goodsUsage)
+	}
+	req.Stock = int32(stock)
+
+	ctx, err := a.authorize(ctx, rbac.PermGoodsWrite)
+	if err != nil {
+		return err
+	}
+	good, err := a.client.Goods.CreateGood(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.out.Print(good, goodsRows(good))
+}
+
+// goodsUpdate меняет только переданные флагами поля: UpdateGood всегда перезаписывает остаток,
+// поэтому запрос собирается из текущего состояния товара
+func (a *app) goodsUpdate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(goodsUsage)
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("goods update", flag.ContinueOnError)
+	name := fs.String("name", "", "название")
+	description := fs.String("description", "", "описание")
+	sku := fs.String("sku", "", "артикул")
+	price := fs.Float64("price", 0, "цена")
+	stock := fs.Int("stock", 0, "остаток")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NFlag() == 0 {
+		return errors.New(goodsUsage)
+	}
+
+	ctx, err = a.authorize(ctx, rbac.PermGoodsWrite)
+	if err != nil {
+		return err
+	}
+	current, err := a.client.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: id})
+	if err != nil {
+		return err
+	}
+
+	req := &pb.UpdateGoodRequest{
+		Id:          id,
+		Name:        current.Name,
+		Description: current.Description,
+		Sku:         current.Sku,
+		Price:       current.Price,
+	}
+	newStock := current.Stock
+	var invalid error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			req.Name = *name
+		case "description":
+			req.Description = *description
+		case "sku":
+			req.Sku = *sku
+		case "price":
+			if *price <= 0 {
+				invalid = errors.New("price must be positive")
+			}
+			req.Price = *price
+		case "stock":
+			if *stock < 0 {
+				invalid = errors.New("stock must not be negative")
+			}
+			newStock = int32(*stock)
+		}
+	})
+	if invalid != nil {
+		return invalid
+	}
+	if newStock != current.Stock {
+		if _, err := a.authorize(ctx, rbac.PermStockWrite); err != nil {
+			return err
+		}
+	}
+
+	good, err := a.client.Goods.UpdateGood(ctx, req)
+	if err != nil {
+		return err
+	}
+	if good, err = a.adjustStock(ctx, good, newStock-current.Stock); err != nil {
+		return err
+	}
+	return a.out.Print(good, goodsRows(good))
+}
+
+// goodsImport загружает каталог из CSV: товар с известным sku обновляется, без sku или с новым - создаётся.
+// Файл проверяется целиком до первого изменения
+func (a *app) goodsImport(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New(goodsUsage)
+	}
+
+	var r io.Reader = a.stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	items, err := catalog.Read(r)
+	if err != nil {
+		return fmt.Errorf("invalid catalog: %w", err)
+	}
+
+	ctx, err = a.authorize(ctx, rbac.PermGoodsWrite)
+	if err != nil {
+		return err
+	}
+
+	goods, err := a.allGoods(ctx)
+	if err != nil {
+		return err
+	}
+	bySKU := make(map[string]*pb.Good, len(goods))
+	for _, good := range goods {
+		if good.Sku != "" {
+			bySKU[good.Sku] = good
+		}
+	}
+	for _, item := range items {
+		if current, ok := bySKU[item.SKU]; ok && item.SKU != "" && item.Stock != current.Stock {
+			if _, err := a.authorize(ctx, rbac.PermStockWrite); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	var created, updated int
+	for _, item := range items {
+		if current, ok := bySKU[item.SKU]; ok && item.SKU != "" {
+			good, err := a.client.Goods.UpdateGood(ctx, &pb.UpdateGoodRequest{
+				Id:          current.Id,
+				Sku:         item.SKU,
+				Name:        item.Name,
+				Description: item.Description,
+				Price:       item.Price,
+			})
+			if err == nil {
+				_, err = a.adjustStock(ctx, good, item.Stock-current.Stock)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update %s (created %d, updated %d): %w", item.SKU, created, updated, err)
+			}
+			updated++
+			continue
+		}
+
+		_, err = a.client.Goods.CreateGood(ctx, &pb.CreateGoodRequest{
+			Sku:         item.SKU,
+			Name:        item.Name,
+			Description: item.Description,
+			Price:       item.Price,
+			Stock:       item.Stock,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create %q (created %d, updated %d): %w", item.Name, created, updated, err)
+		}
+		created++
+	}
+
+	fmt.Fprintf(a.stdout, "Imported %d goods: created %d, updated %d\n", len(items), created, updated)
+	return nil
+}
+
+func (a *app) goodsExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("goods export", flag.ContinueOnError)
+	file := fs.String("file", "", "файл CSV (по умолчанию stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	goods, err := a.allGoods(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return catalog.Write(a.stdout, goods)
+	}
+	f, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+	if err := catalog.Write(f, goods); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Exported %d goods to %s\n", len(goods), *file)
+	return nil
+}
+
+// allGoods постранично читает весь каталог
+func (a *app) allGoods(ctx context.Context) ([]*pb.Good, error) {
+	var goods []*pb.Good
+	for {
+		resp, err := a.client.Goods.ListGoods(ctx, &pb.ListGoodsRequest{Limit: exportPageSize, Offset: int32(len(goods))})
+		if err != nil {
+			return nil, err
+		}
+		goods = append(goods, resp.Goods...)
+		if len(resp.Goods) < exportPageSize || len(goods) >= int(resp.Total) {
+			return goods, nil
+		}
+	}
+}
+
+func goodsRows(goods ...*pb.Good) output.Rows {
+	rows := output.Rows{Header: []string{"ID", "SKU", "NAME", "PRICE", "STOCK", "CREATED"}}
+	for _, good := range goods {
+		rows.Add(output.ID(good.Id), good.Sku, good.Name, output.Money(good.Price), output.ID(int64(good.Stock)), output.Time(good.CreatedAt))
+	}
+	return rows
+}
+
+// adjustStock меняет остаток товара на delta корректировкой склада. Разница считается от прочитанного
+// остатка и применяется goods-service под блокировкой, поэтому не затирает резервы заказов
+func (a *app) adjustStock(ctx context.Context, good *pb.Good, delta int32) (*pb.Good, error) {
+	if delta == 0 {
+		return good, nil
+	}
+	_, err := a.client.Goods.AdjustStock(ctx, &pb.AdjustStockRequest{
+		GoodId:   good.Id,
+		Type:     "adjustment",
+		Quantity: delta,
+		Reason:   stockAdjustmentReason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.client.Goods.GetGood(ctx, &pb.GetGoodRequest{GoodId: good.Id})
+}
@@ -0,0 +1,144 @@
+// teashopctl - утилита администрирования магазина поверх gRPC API сервисов
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/client"
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+	"github.com/che1nov/tea-shop/teashopctl/internal/profile"
+)
+
+const usage = `Usage:
+  teashopctl [--profile <name>] [--output table|json] [--timeout 10s] <command> ...
+
+Commands:
+  login --email <email>                   вход, пароль читается из stdin
+  logout                                  выход с отзывом токенов
+  whoami                                  текущий пользователь и его права
+  profile list|show|use|set|delete        профили окружений
+  goods list|get|create|update|import|export
+  orders get|set-status
+  deliveries list|get|set-status
+  payments get|by-order
+  users create-admin --email <email>      администратор, пароль читается из stdin
+  events replay <order_id>...             повторная публикация событий заказов
+
+Профили хранятся в $TEASHOPCTL_CONFIG или ~/.config/teashopctl/config.yaml`
+
+// app - состояние одного запуска teashopctl
+type app struct {
+	file        *profile.File
+	profileName string
+	profile     *profile.Profile
+	out         *output.Printer
+	stdin       io.Reader
+	stdout      io.Writer
+
+	client *client.Client
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("teashopctl", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), usage) }
+	profileName := fs.String("profile", os.Getenv("TEASHOPCTL_PROFILE"), "профиль (по умолчанию текущий)")
+	format := fs.String("output", "", "формат вывода: table или json")
+	fs.StringVar(format, "o", "", "сокращение для --output")
+	timeout := fs.Duration("timeout", 10*time.Second, "таймаут команды")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	path, err := profile.DefaultPath()
+	if err != nil {
+		return err
+	}
+	file, err := profile.Load(path)
+	if err != nil {
+		return err
+	}
+
+	a := &app{file: file, stdin: os.Stdin, stdout: os.Stdout}
+
+	// Команды профилей работают с файлом целиком и не подключаются к сервисам
+	if args[0] == "profile" {
+		return a.profileCommand(args[1:])
+	}
+
+	a.profile, a.profileName, err = file.Get(*profileName)
+	if err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = a.profile.Output
+	}
+	if *format == "" {
+		*format = output.Table
+	}
+	if a.out, err = output.NewPrinter(a.stdout, *format); err != nil {
+		return err
+	}
+
+	a.client, err = client.Dial(a.profile.Services)
+	if err != nil {
+		return err
+	}
+	defer a.client.Close()
+
+	// Один request id на команду: по нему вызовы находятся в логах и трейсах всех сервисов
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx = correlation.WithRequestID(ctx, correlation.NewRequestID())
+
+	switch args[0] {
+	case "login":
+		return a.login(ctx, args[1:])
+	case "logout":
+		return a.logout(ctx)
+	case "whoami":
+		return a.whoami(ctx)
+	case "goods":
+		return a.goodsCommand(ctx, args[1:])
+	case "orders":
+		return a.ordersCommand(ctx, args[1:])
+	case "deliveries":
+		return a.deliveriesCommand(ctx, args[1:])
+	case "payments":
+		return a.paymentsCommand(ctx, args[1:])
+	case "users":
+		return a.usersCommand(ctx, args[1:])
+	case "events":
+		return a.eventsCommand(ctx, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+}
+
+// parseID разбирает идентификатор из аргумента команды
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return id, nil
+}
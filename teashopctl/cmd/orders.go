@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+)
+
+const ordersUsage = `Usage:
+  teashopctl orders get <id>
+  teashopctl orders set-status <id> <status>`
+
+func (a *app) ordersCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New(ordersUsage)
+	}
+
+	switch args[0] {
+	case "get":
+		if len(args) != 2 {
+			return errors.New(ordersUsage)
+		}
+		id, err := parseID(args[1])
+		if err != nil {
+			return err
+		}
+		ctx, err = a.authorize(ctx, rbac.PermOrdersRead)
+		if err != nil {
+			return err
+		}
+		order, err := a.client.Orders.GetOrder(ctx, &pb.GetOrderRequest{OrderId: id})
+		if err != nil {
+			return err
+		}
+		return a.out.Print(order, ordersRows(order))
+
+	case "set-status":
+		if len(args) != 3 || args[2] == "" {
+			return errors.New(ordersUsage)
+		}
+		id, err := parseID(args[1])
+		if err != nil {
+			return err
+		}
+		ctx, err = a.authorize(ctx, rbac.PermOrdersWrite)
+		if err != nil {
+			return err
+		}
+		order, err := a.client.Orders.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{OrderId: id, Status: args[2]})
+		if err != nil {
+			return err
+		}
+		return a.out.Print(order, ordersRows(order))
+
+	default:
+		return fmt.Errorf("unknown orders command %q\n\n%s", args[0], ordersUsage)
+	}
+}
+
+func ordersRows(orders ...*pb.Order) output.Rows {
+	rows := output.Rows{Header: []string{"ID", "USER_ID", "STATUS", "ITEMS", "TOTAL", "ADDRESS", "CREATED", "UPDATED"}}
+	for _, order := range orders {
+		rows.Add(
+			output.ID(order.Id),
+			output.ID(order.UserId),
+			order.Status,
+			strconv.Itoa(len(order.Items)),
+			output.Money(order.TotalPrice),
+			order.Address,
+			output.Time(order.CreatedAt),
+			output.Time(order.UpdatedAt),
+		)
+	}
+	return rows
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+)
+
+const paymentsUsage = `Usage:
+  teashopctl payments get <id>
+  teashopctl payments by-order <order_id>`
+
+func (a *app) paymentsCommand(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errors.New(paymentsUsage)
+	}
+	id, err := parseID(args[1])
+	if err != nil {
+		return err
+	}
+
+	var payment *pb.Payment
+	switch args[0] {
+	case "get":
+		if ctx, err = a.authorize(ctx, rbac.PermOrdersRead); err != nil {
+			return err
+		}
+		payment, err = a.client.Payments.GetPayment(ctx, &pb.GetPaymentRequest{PaymentId: id})
+	case "by-order":
+		if ctx, err = a.authorize(ctx, rbac.PermOrdersRead); err != nil {
+			return err
+		}
+		payment, err = a.client.Payments.GetPaymentByOrderID(ctx, &pb.GetPaymentByOrderIDRequest{OrderId: id})
+	default:
+		return fmt.Errorf("unknown payments command %q\n\n%s", args[0], paymentsUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := output.Rows{Header: []string{"ID", "ORDER_ID", "AMOUNT", "STATUS", "CREATED", "UPDATED"}}
+	rows.Add(
+		output.ID(payment.Id),
+		output.ID(payment.OrderId),
+		output.Money(payment.Amount),
+		payment.Status,
+		output.Time(payment.CreatedAt),
+		output.Time(payment.UpdatedAt),
+	)
+	return a.out.Print(payment, rows)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+	"github.com/che1nov/tea-shop/teashopctl/internal/profile"
+)
+
+const profileUsage = `Usage:
+  teashopctl profile list
+  teashopctl profile show [<name>]
+  teashopctl profile use <name>
+  teashopctl profile set <name> [--gateway <url>] [--auth login|none] [--output table|json]
+                                [--users <addr>] [--goods <addr>] [--orders <addr>] [--payments <addr>] [--delivery <addr>]
+  teashopctl profile delete <name>
+
+Новый профиль создаётся с адресами локального запуска; --gateway "" включает вход напрямую через users-service`
+
+func (a *app) profileCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(profileUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		rows := output.Rows{Header: []string{"NAME", "CURRENT", "GATEWAY", "AUTH", "USER"}}
+		for _, name := range a.file.Names() {
+			p := a.file.Profiles[name]
+			current, user := "", ""
+			if name == a.file.Current {
+				current = "*"
+			}
+			if p.Session != nil {
+				user = p.Session.Email
+			}
+			rows.Add(name, current, p.Gateway, p.Auth, user)
+		}
+		return output.WriteTable(a.stdout, rows)
+
+	case "show":
+		name := ""
+		if len(args) > 1 {
+			name = args[1]
+		}
+		p, _, err := a.file.Get(name)
+		if err != nil {
+			return err
+		}
+		// Токены не печатаются
+		shown := *p
+		if p.Session != nil {
+			shown.Session = &profile.Session{Email: p.Session.Email, AccessToken: "***", RefreshToken: "***"}
+		}
+		return yaml.NewEncoder(a.stdout).Encode(shown)
+
+	case "use":
+		if len(args) != 2 {
+			return errors.New(profileUsage)
+		}
+		if _, _, err := a.file.Get(args[1]); err != nil {
+			return err
+		}
+		a.file.Current = args[1]
+		return a.file.Save()
+
+	case "set":
+		return a.profileSet(args[1:])
+
+	case "delete":
+		if len(args) != 2 {
+			return errors.New(profileUsage)
+		}
+		if _, _, err := a.file.Get(args[1]); err != nil {
+			return err
+		}
+		if args[1] == a.file.Current {
+			return fmt.Errorf("profile %q is current, switch to another one first", args[1])
+		}
+		delete(a.file.Profiles, args[1])
+		return a.file.Save()
+
+	default:
+		return fmt.Errorf("unknown profile command %q\n\n%s", args[0], profileUsage)
+	}
+}
+
+// profileSet создаёт профиль или меняет переданные флагами поля существующего
+func (a *app) profileSet(args []string) error {
+	if len(args) == 0 {
+		return errors.New(profileUsage)
+	}
+	name := args[0]
+
+	p, ok := a.file.Profiles[name]
+	if !ok {
+		p = profile.Local()
+	}
+	updated := *p
+
+	fs := flag.NewFlagSet("profile set", flag.ContinueOnError)
+	fs.StringVar(&updated.Gateway, "gateway", p.Gateway, "адрес HTTP API gateway для входа")
+	fs.StringVar(&updated.Auth, "auth", p.Auth, "login или none")
+	fs.StringVar(&updated.Output, "output", p.Output, "формат вывода по умолчанию")
+	fs.StringVar(&updated.Services.Users, "users", p.Services.Users, "адрес users-service")
+	fs.StringVar(&updated.Services.Goods, "goods", p.Services.Goods, "адрес goods-service")
+	fs.StringVar(&updated.Services.Orders, "orders", p.Services.Orders, "адрес order-service")
+	fs.StringVar(&updated.Services.Payments, "payments", p.Services.Payments, "адрес payment-service")
+	fs.StringVar(&updated.Services.Delivery, "delivery", p.Services.Delivery, "адрес delivery-service")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+
+	// Сессия выдана другим окружением: при смене адресов входа она больше не действует
+	if updated.Gateway != p.Gateway || updated.Services.Users != p.Services.Users {
+		updated.Session = nil
+	}
+
+	a.file.Profiles[name] = &updated
+	if a.file.Current == "" {
+		a.file.Current = name
+	}
+	return a.file.Save()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/auth"
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+	"github.com/che1nov/tea-shop/teashopctl/internal/profile"
+)
+
+const loginUsage = `Usage:
+  teashopctl login --email <email>
+
+Пароль читается из stdin, например:
+  echo "$ADMIN_PASSWORD" | teashopctl login --email admin@example.com`
+
+// authenticator выбирает способ входа: через gateway, если он задан в профиле, иначе через users-service
+func (a *app) authenticator() auth.Authenticator {
+	if a.profile.Gateway != "" {
+		return auth.NewGateway(a.profile.Gateway)
+	}
+	return auth.NewService(a.client.Users)
+}
+
+func (a *app) login(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	email := fs.String("email", "", "email пользователя")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New(loginUsage)
+	}
+
+	password, err := readPassword(a.stdin)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := a.authenticator().Login(ctx, *email, password)
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	if err := a.saveSession(&profile.Session{
+		Email:        *email,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "Logged in as %s (profile %s)\n", *email, a.profileName)
+	return nil
+}
+
+func (a *app) logout(ctx context.Context) error {
+	session := a.profile.Session
+	if session == nil {
+		return errNotLoggedIn
+	}
+
+	// Локальная сессия удаляется, даже если отозвать токены не удалось (например, они уже истекли)
+	err := a.authenticator().Logout(ctx, &auth.Tokens{AccessToken: session.AccessToken, RefreshToken: session.RefreshToken})
+	if saveErr := a.saveSession(nil); saveErr != nil {
+		return saveErr
+	}
+	if err != nil {
+		return fmt.Errorf("session removed locally, but token revocation failed: %w", err)
+	}
+
+	fmt.Fprintf(a.stdout, "Logged out %s\n", session.Email)
+	return nil
+}
+
+func (a *app) whoami(ctx context.Context) error {
+	claims, err := a.validSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := output.Rows{Header: []string{"USER_ID", "EMAIL", "ROLE", "PERMISSIONS", "PROFILE"}}
+	rows.Add(output.ID(claims.UserId), claims.Email, claims.Role, strings.Join(claims.Permissions, ","), a.profileName)
+	return a.out.Print(claims, rows)
+}
+
+var errNotLoggedIn = errors.New("not logged in, run teashopctl login")
+
+// authorize возвращает контекст вызова от имени вошедшего пользователя, проверив его право permission.
+// Проверка только подсказка: сервисы доверяют внутренней сети и права не проверяют, поэтому любой
+// клиент с доступом к их gRPC портам, в том числе профиль с auth: none, вызывает их без ограничений.
+// Защита доступа - сетевая изоляция портов сервисов, а не эта проверка
+func (a *app) authorize(ctx context.Context, permission string) (context.Context, error) {
+	if a.profile.Auth == profile.AuthNone {
+		return ctx, nil
+	}
+
+	claims, err := a.validSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if permission != "" && !rbac.HasPermission(claims.Permissions, permission) {
+		return nil, fmt.Errorf("%s (role %s) has no permission %s", claims.Email, claims.Role, permission)
+	}
+	return correlation.WithUserID(ctx, claims.UserId), nil
+}
+
+// validSession проверяет access токен сессии; истёкший токен продлевается по refresh токену
+func (a *app) validSession(ctx context.Context) (*pb.ValidateTokenResponse, error) {
+	session := a.profile.Session
+	if session == nil {
+		return nil, errNotLoggedIn
+	}
+
+	claims, err := a.client.Users.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: session.AccessToken})
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate session: %w", err)
+	}
+	if claims.Valid {
+		return claims, nil
+	}
+
+	tokens, err := a.authenticator().Refresh(ctx, session.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("session expired, run teashopctl login: %w", err)
+	}
+	session.AccessToken = tokens.AccessToken
+	session.RefreshToken = tokens.RefreshToken
+	if err := a.saveSession(session); err != nil {
+		return nil, err
+	}
+
+	claims, err = a.client.Users.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: session.AccessToken})
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate session: %w", err)
+	}
+	if !claims.Valid {
+		return nil, errors.New("session is not valid, run teashopctl login")
+	}
+	return claims, nil
+}
+
+// saveSession сохраняет сессию активного профиля; nil удаляет её
+func (a *app) saveSession(session *profile.Session) error {
+	a.profile.Session = session
+	return a.file.Save()
+}
+
+// readPassword читает пароль из первой строки stdin
+func readPassword(r io.Reader) (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password is required")
+	}
+	return password, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/output"
+	"github.com/che1nov/tea-shop/teashopctl/internal/profile"
+)
+
+const usersUsage = `Usage:
+  teashopctl users create-admin --email <email> [--name <name>]
+
+Пароль нового администратора (не короче 8 символов) читается из stdin.
+Команда требует входа администратора: users-service проверяет его токен и записывает его автором.
+Первого администратора создает команда users-service admin create`
+
+func (a *app) usersCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "create-admin" {
+		return errors.New(usersUsage)
+	}
+
+	fs := flag.NewFlagSet("users create-admin", flag.ContinueOnError)
+	email := fs.String("email", "", "email администратора")
+	name := fs.String("name", "", "имя (по умолчанию часть email до @)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New(usersUsage)
+	}
+	if *name == "" {
+		*name, _, _ = strings.Cut(*email, "@")
+	}
+
+	// В профиле без входа нет токена, которым users-service проверяет автора
+	if a.profile.Auth == profile.AuthNone {
+		return errors.New("users create-admin requires a logged-in profile; the first admin is created by users-service admin create")
+	}
+	ctx, err := a.authorize(ctx, rbac.PermUsersManageRoles)
+	if err != nil {
+		return err
+	}
+	password, err := readPassword(a.stdin)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.client.Users.CreateAdmin(ctx, &pb.CreateAdminRequest{
+		Email:       *email,
+		Name:        *name,
+		Password:    password,
+		AccessToken: a.profile.Session.AccessToken,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create admin: %w", err)
+	}
+
+	rows := output.Rows{Header: []string{"ID", "EMAIL", "NAME", "ROLE", "CREATED"}}
+	rows.Add(output.ID(user.Id), user.Email, user.Name, user.Role, output.Time(user.CreatedAt))
+	return a.out.Print(user, rows)
+}
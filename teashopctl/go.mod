@@ -0,0 +1,22 @@
+module github.com/che1nov/tea-shop/teashopctl
+
+go 1.25.1
+
+require (
+	github.com/che1nov/tea-shop/shared v0.0.0
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/grpc v1.77.0
+	google.golang.org/protobuf v1.36.10
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.47.0 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251202230838-ff82c1b0f217 // indirect
+)
+
+replace github.com/che1nov/tea-shop/shared => ../shared
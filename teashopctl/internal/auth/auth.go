@@ -0,0 +1,135 @@
+// Package auth выдаёт токены teashopctl: через HTTP API gateway (тот же вход, что у фронтенда)
+// или напрямую через users-service
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// Tokens - пара токенов сессии
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// Authenticator входит, продлевает и завершает сессию
+type Authenticator interface {
+	Login(ctx context.Context, email, password string) (*Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, error)
+	Logout(ctx context.Context, tokens *Tokens) error
+}
+
+// Gateway - вход через HTTP API gateway
+type Gateway struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewGateway(baseURL string) *Gateway {
+	return &Gateway{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// tokenResponse - ответ /auth/login и /auth/refresh
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (g *Gateway) Login(ctx context.Context, email, password string) (*Tokens, error) {
+	var resp tokenResponse
+	err := g.post(ctx, "/api/v1/auth/login", "", map[string]string{"email": email, "password": password}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{AccessToken: resp.Token, RefreshToken: resp.RefreshToken}, nil
+}
+
+func (g *Gateway) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	var resp tokenResponse
+	err := g.post(ctx, "/api/v1/auth/refresh", "", map[string]string{"refresh_token": refreshToken}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{AccessToken: resp.Token, RefreshToken: resp.RefreshToken}, nil
+}
+
+func (g *Gateway) Logout(ctx context.Context, tokens *Tokens) error {
+	return g.post(ctx, "/api/v1/auth/logout", tokens.AccessToken, map[string]string{"refresh_token": tokens.RefreshToken}, nil)
+}
+
+// post отправляет JSON запрос и разбирает ответ в out; ошибки gateway возвращаются с текстом из поля error
+func (g *Gateway) post(ctx context.Context, path, accessToken string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("gateway: %s", apiErr.Error)
+		}
+		return fmt.Errorf("gateway: %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Service - вход напрямую через users-service
+type Service struct {
+	users pb.UsersServiceClient
+}
+
+func NewService(users pb.UsersServiceClient) *Service {
+	return &Service{users: users}
+}
+
+func (s *Service) Login(ctx context.Context, email, password string) (*Tokens, error) {
+	resp, err := s.users.Login(ctx, &pb.LoginRequest{Email: email, Password: password})
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{AccessToken: resp.Token, RefreshToken: resp.RefreshToken}, nil
+}
+
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	resp, err := s.users.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{AccessToken: resp.Token, RefreshToken: resp.RefreshToken}, nil
+}
+
+func (s *Service) Logout(ctx context.Context, tokens *Tokens) error {
+	_, err := s.users.Logout(ctx, &pb.LogoutRequest{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken})
+	return err
+}
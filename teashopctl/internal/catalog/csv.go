@@ -0,0 +1,121 @@
+// Package catalog читает и пишет каталог товаров в CSV для импорта и экспорта
+package catalog
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+)
+
+// Header - колонки CSV при экспорте
+var Header = []string{"sku", "name", "description", "price", "stock"}
+
+// required - колонки, без которых файл не импортируется
+var required = []string{"name", "price", "stock"}
+
+// Item - товар из файла импорта
+type Item struct {
+	SKU         string
+	Name        string
+	Description string
+	Price       float64
+	Stock       int32
+}
+
+// Read разбирает CSV с заголовком. Порядок колонок произвольный, sku и description необязательны.
+// Ошибки содержат номер строки файла
+func Read(r io.Reader) ([]Item, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var items []Item
+	seen := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		cell := func(name string) string {
+			i, ok := columns[name]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		item := Item{
+			SKU:         cell("sku"),
+			Name:        cell("name"),
+			Description: cell("description"),
+		}
+		if item.Name == "" {
+			return nil, fmt.Errorf("line %d: name is required", line)
+		}
+		if item.Price, err = strconv.ParseFloat(cell("price"), 64); err != nil || item.Price <= 0 {
+			return nil, fmt.Errorf("line %d: price must be a positive number, got %q", line, cell("price"))
+		}
+		stock, err := strconv.ParseInt(cell("stock"), 10, 32)
+		if err != nil || stock < 0 {
+			return nil, fmt.Errorf("line %d: stock must be a non-negative integer, got %q", line, cell("stock"))
+		}
+		item.Stock = int32(stock)
+
+		if item.SKU != "" {
+			if prev, ok := seen[item.SKU]; ok {
+				return nil, fmt.Errorf("line %d: duplicate sku %s (first seen on line %d)", line, item.SKU, prev)
+			}
+			seen[item.SKU] = line
+		}
+		items = append(items, item)
+	}
+}
+
+// Write пишет товары в CSV с колонками Header
+func Write(w io.Writer, goods []*pb.Good) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Header); err != nil {
+		return err
+	}
+	for _, good := range goods {
+		err := writer.Write([]string{
+			good.Sku,
+			good.Name,
+			good.Description,
+			strconv.FormatFloat(good.Price, 'f', -1, 64),
+			strconv.FormatInt(int64(good.Stock), 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,56 @@
+package catalog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	items, err := Read(strings.NewReader("name,price,stock,sku\nШу Пуэр,1200.50,10,PUER-1\nСенча, 700, 0,\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Item{
+		{SKU: "PUER-1", Name: "Шу Пуэр", Price: 1200.5, Stock: 10},
+		{Name: "Сенча", Price: 700, Stock: 0},
+	}, items)
+}
+
+func TestRead_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"empty", "", "empty file"},
+		{"missing column", "name,price\nСенча,700\n", `missing column "stock"`},
+		{"bad price", "name,price,stock\nСенча,free,1\n", "line 2: price"},
+		{"negative stock", "name,price,stock\nСенча,700,-1\n", "line 2: stock"},
+		{"no name", "name,price,stock\n,700,1\n", "line 2: name is required"},
+		{"duplicate sku", "sku,name,price,stock\nA,Сенча,700,1\nA,Пуэр,900,1\n", "line 3: duplicate sku A (first seen on line 2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	goods := []*pb.Good{
+		{Sku: "GOOD-000001", Name: "Те Гуань Инь", Description: "Улун, \"весенний\"", Price: 900, Stock: 3},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, goods))
+
+	items, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, []Item{{SKU: "GOOD-000001", Name: "Те Гуань Инь", Description: "Улун, \"весенний\"", Price: 900, Stock: 3}}, items)
+}
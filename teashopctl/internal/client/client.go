@@ -0,0 +1,69 @@
+// Package client подключается к сервисам магазина по адресам профиля
+package client
+
+import (
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/correlation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/che1nov/tea-shop/teashopctl/internal/profile"
+)
+
+// Client - клиенты gRPC API сервисов
+type Client struct {
+	Users    pb.UsersServiceClient
+	Goods    pb.GoodsServiceClient
+	Orders   pb.OrdersServiceClient
+	Payments pb.PaymentsServiceClient
+	Delivery pb.DeliveryServiceClient
+
+	conns []*grpc.ClientConn
+}
+
+// Dial создаёт соединения с сервисами; подключение происходит при первом вызове.
+// Request id и user_id из контекста передаются в metadata, как при вызовах из api-gateway
+func Dial(services profile.Services) (*Client, error) {
+	c := &Client{}
+	dial := func(addr string) (*grpc.ClientConn, error) {
+		conn, err := grpc.Dial(addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithChainUnaryInterceptor(correlation.UnaryClientInterceptor()),
+		)
+		if err != nil {
+			return nil, err
+		}
+		c.conns = append(c.conns, conn)
+		return conn, nil
+	}
+
+	for _, s := range []struct {
+		addr string
+		bind func(*grpc.ClientConn)
+	}{
+		{services.Users, func(conn *grpc.ClientConn) { c.Users = pb.NewUsersServiceClient(conn) }},
+		{services.Goods, func(conn *grpc.ClientConn) { c.Goods = pb.NewGoodsServiceClient(conn) }},
+		{services.Orders, func(conn *grpc.ClientConn) { c.Orders = pb.NewOrdersServiceClient(conn) }},
+		{services.Payments, func(conn *grpc.ClientConn) { c.Payments = pb.NewPaymentsServiceClient(conn) }},
+		{services.Delivery, func(conn *grpc.ClientConn) { c.Delivery = pb.NewDeliveryServiceClient(conn) }},
+	} {
+		conn, err := dial(s.addr)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		s.bind(conn)
+	}
+	return c, nil
+}
+
+// Close закрывает соединения
+func (c *Client) Close() error {
+	var firstErr error
+	for _, conn := range c.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,86 @@
+// Package output печатает результаты команд teashopctl таблицей или JSON
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Форматы вывода
+const (
+	Table = "table"
+	JSON  = "json"
+)
+
+// Rows - таблица: заголовок и строки
+type Rows struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Add добавляет строку
+func (r *Rows) Add(cells ...string) {
+	r.Rows = append(r.Rows, cells)
+}
+
+// Printer печатает ответы сервисов в выбранном формате
+type Printer struct {
+	w      io.Writer
+	format string
+}
+
+func NewPrinter(w io.Writer, format string) (*Printer, error) {
+	if format != Table && format != JSON {
+		return nil, fmt.Errorf("unknown output format %q (table or json)", format)
+	}
+	return &Printer{w: w, format: format}, nil
+}
+
+// Print печатает msg как JSON (имена полей как в proto) или как таблицу rows
+func (p *Printer) Print(msg proto.Message, rows Rows) error {
+	if p.format == JSON {
+		data, err := protojson.MarshalOptions{Multiline: true, UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.w, string(data))
+		return err
+	}
+
+	return WriteTable(p.w, rows)
+}
+
+// WriteTable печатает rows таблицей с выровненными колонками
+func WriteTable(w io.Writer, rows Rows) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(rows.Header, "\t"))
+	for _, row := range rows.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// ID форматирует идентификатор
+func ID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// Money форматирует сумму
+func Money(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Time форматирует Unix время; 0 - пусто
+func Time(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).Format("2006-01-02 15:04")
+}
@@ -0,0 +1,154 @@
+// Package profile хранит профили teashopctl: адреса сервисов окружения, способ входа и сессию
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Способы аутентификации
+const (
+	// AuthLogin - команды выполняются от имени пользователя, вошедшего через teashopctl login
+	AuthLogin = "login"
+	// AuthNone - прямой доступ к сервисам из доверенной сети, без входа
+	AuthNone = "none"
+)
+
+// DefaultName - профиль, создаваемый при первом запуске
+const DefaultName = "local"
+
+// Services - gRPC адреса сервисов
+type Services struct {
+	Users    string `yaml:"users"`
+	Goods    string `yaml:"goods"`
+	Orders   string `yaml:"orders"`
+	Payments string `yaml:"payments"`
+	Delivery string `yaml:"delivery"`
+}
+
+// Session - токены вошедшего пользователя
+type Session struct {
+	Email        string `yaml:"email"`
+	AccessToken  string `yaml:"access_token"`
+	RefreshToken string `yaml:"refresh_token"`
+}
+
+// Profile - настройки одного окружения
+type Profile struct {
+	// Gateway - адрес HTTP API gateway для входа; пусто - вход напрямую через users-service
+	Gateway  string   `yaml:"gateway,omitempty"`
+	Auth     string   `yaml:"auth"`
+	Services Services `yaml:"services"`
+	// Output - формат вывода по умолчанию (table или json)
+	Output  string   `yaml:"output,omitempty"`
+	Session *Session `yaml:"session,omitempty"`
+}
+
+// File - файл профилей
+type File struct {
+	Current  string              `yaml:"current"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+
+	path string
+}
+
+// Local возвращает профиль для сервисов, запущенных локально на портах по умолчанию
+func Local() *Profile {
+	return &Profile{
+		Gateway: "http://localhost:8080",
+		Auth:    AuthLogin,
+		Services: Services{
+			Users:    "localhost:8001",
+			Goods:    "localhost:8002",
+			Orders:   "localhost:8003",
+			Payments: "localhost:8004",
+			Delivery: "localhost:8005",
+		},
+	}
+}
+
+// DefaultPath возвращает путь к файлу профилей: $TEASHOPCTL_CONFIG или teashopctl/config.yaml
+// в пользовательском каталоге конфигурации
+func DefaultPath() (string, error) {
+	if path := os.Getenv("TEASHOPCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "teashopctl", "config.yaml"), nil
+}
+
+// Load читает файл профилей. Если файла нет, возвращается файл с единственным профилем local
+func Load(path string) (*File, error) {
+	f := &File{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		f.Current = DefaultName
+		f.Profiles = map[string]*Profile{DefaultName: Local()}
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string]*Profile{}
+	}
+	return f, nil
+}
+
+// Save записывает файл профилей. Файл содержит токены, поэтому доступен только владельцу
+func (f *File) Save() error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, data, 0o600)
+}
+
+// Get возвращает профиль name, пустое имя - текущий профиль
+func (f *File) Get(name string) (*Profile, string, error) {
+	if name == "" {
+		name = f.Current
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		return nil, name, fmt.Errorf("profile %q not found", name)
+	}
+	return p, name, nil
+}
+
+// Names возвращает имена профилей по алфавиту
+func (f *File) Names() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate проверяет, что профиль пригоден для работы
+func (p *Profile) Validate() error {
+	if p.Auth != AuthLogin && p.Auth != AuthNone {
+		return fmt.Errorf("auth must be %q or %q, got %q", AuthLogin, AuthNone, p.Auth)
+	}
+	if p.Output != "" && p.Output != "table" && p.Output != "json" {
+		return fmt.Errorf("output must be table or json, got %q", p.Output)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+
+	p, name, err := f.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultName, name)
+	assert.Equal(t, Local(), p)
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "teashopctl", "config.yaml")
+	f, err := Load(path)
+	require.NoError(t, err)
+
+	f.Profiles["staging"] = &Profile{
+		Auth:     AuthNone,
+		Services: Services{Goods: "goods.staging:8002"},
+		Output:   "json",
+	}
+	f.Profiles[DefaultName].Session = &Session{Email: "admin@example.com", AccessToken: "a", RefreshToken: "r"}
+	f.Current = "staging"
+	require.NoError(t, f.Save())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultName, "staging"}, loaded.Names())
+
+	p, name, err := loaded.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", name)
+	assert.Equal(t, f.Profiles["staging"], p)
+	assert.Equal(t, "admin@example.com", loaded.Profiles[DefaultName].Session.Email)
+
+	_, _, err = loaded.Get("prod")
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Local().Validate())
+	assert.Error(t, (&Profile{Auth: "token"}).Validate())
+	assert.Error(t, (&Profile{Auth: AuthLogin, Output: "yaml"}).Validate())
+}
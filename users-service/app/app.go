@@ -0,0 +1,75 @@
+// Package app собирает слои users-service. Используется cmd/main.go и сборками,
+// где сервисы работают в одном процессе (allinone, e2e тесты)
+package app
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/users-service/config"
+	"github.com/che1nov/tea-shop/users-service/internal/handler"
+	"github.com/che1nov/tea-shop/users-service/internal/keys"
+	"github.com/che1nov/tea-shop/users-service/internal/repository"
+	"github.com/che1nov/tea-shop/users-service/internal/service"
+)
+
+// Service - собранный users-service
+type Service struct {
+	// Server - реализация gRPC API
+	Server pb.UsersServiceServer
+	// JWKS отдаёт публичные ключи подписи токенов (/.well-known/jwks.json)
+	JWKS http.Handler
+}
+
+// New собирает users-service поверх БД: загружает ключи подписи и заполняет роли по умолчанию.
+// Плановая ротация ключей и удаление истекших токенов работают в фоне до отмены ctx
+func New(ctx context.Context, db *sql.DB, cfg *config.Config) (*Service, error) {
+	return build(ctx, repository.NewSigningKeyRepository(db), repository.New(db), cfg)
+}
+
+// NewInMemory собирает users-service поверх репозиториев в памяти с настройками токенов по умолчанию
+func NewInMemory(ctx context.Context) (*Service, error) {
+	return build(ctx, keys.NewMemoryStore(), repository.NewMemory(), config.Default())
+}
+
+func build(
+	ctx context.Context,
+	keyStore repository.SigningKeyRepositoryInterface,
+	repo repository.UserRepositoryInterface,
+	cfg *config.Config,
+) (*Service, error) {
+	// Ключи подписи JWT: загружаем из хранилища, при необходимости создаём первый ключ
+	keyManager, err := keys.NewManager(
+		keyStore,
+		cfg.JWT.SigningAlgorithm,
+		cfg.JWT.KeyRotationInterval,
+		cfg.JWT.KeyRotationOverlap,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := keyManager.Init(ctx); err != nil {
+		return nil, err
+	}
+
+	go keyManager.Run(ctx)
+
+	if err := repository.SeedRoles(ctx, repo); err != nil {
+		return nil, err
+	}
+
+	svc := service.New(
+		repo,
+		keyManager,
+		cfg.JWT.AccessTokenTTL,
+		cfg.JWT.RefreshTokenTTL,
+	)
+	go svc.RunTokenCleanup(ctx, cfg.JWT.TokenCleanupInterval)
+
+	return &Service{
+		Server: handler.New(svc),
+		JWKS:   keyManager.JWKSHandler(),
+	}, nil
+}
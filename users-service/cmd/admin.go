@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/che1nov/tea-shop/users-service/config"
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+	"github.com/che1nov/tea-shop/users-service/internal/repository"
+	"github.com/che1nov/tea-shop/users-service/internal/service"
+)
+
+const adminUsage = `Usage:
+  users-service admin create --email <email> [--name <name>]
+
+Пароль читается из stdin, например:
+  echo "$ADMIN_PASSWORD" | users-service admin create --email admin@example.com`
+
+// runAdminCommand выполняет подкоманды `users-service admin ...`
+func runAdminCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return errors.New(adminUsage)
+	}
+
+	fs := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	email := fs.String("email", "", "email администратора")
+	name := fs.String("name", "Administrator", "имя администратора")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New(adminUsage)
+	}
+
+	password, err := readPassword(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	db, err := openDatabase(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	repo := repository.New(db)
+	if err := repository.SeedRoles(ctx, repo); err != nil {
+		return fmt.Errorf("failed to seed roles: %w", err)
+	}
+
+	// Для создания учётной записи ключи подписи токенов не нужны
+	svc := service.New(repo, nil, cfg.JWT.AccessTokenTTL, cfg.JWT.RefreshTokenTTL)
+	user, err := svc.CreateAdmin(ctx, 0, &model.CreateUserRequest{
+		Email:    *email,
+		Name:     *name,
+		Password: password,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create admin: %w", err)
+	}
+
+	fmt.Printf("Admin created: id=%d email=%s\n", user.ID, user.Email)
+	return nil
+}
+
+// readPassword читает пароль из первой строки stdin
+func readPassword(r io.Reader) (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
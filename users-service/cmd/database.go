@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/migrate"
+	"github.com/che1nov/tea-shop/users-service/config"
+	"github.com/che1nov/tea-shop/users-service/migrations"
+)
+
+// openDatabase подключается к БД и применяет миграции
+func openDatabase(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	db, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Database connection established")
+
+	if cfg.Database.AutoMigrate {
+		if err := migrate.Apply(ctx, db, migrations.FS); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to apply migrations: %w", err)
+		}
+	}
+
+	return db, nil
+}
@@ -1,158 +1,92 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
-
-	_ "github.com/lib/pq"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
-	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/reflection"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/health"
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/migrate"
+	"github.com/che1nov/tea-shop/shared/pkg/server"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
+	"github.com/che1nov/tea-shop/users-service/app"
 	"github.com/che1nov/tea-shop/users-service/config"
-	"github.com/che1nov/tea-shop/users-service/internal/handler"
-	"github.com/che1nov/tea-shop/users-service/internal/repository"
-	"github.com/che1nov/tea-shop/users-service/internal/service"
+	"github.com/che1nov/tea-shop/users-service/migrations"
 )
 
 func main() {
 	// Инициализируем logger
-	logger.Init()
-
-	cfg := config.Load()
+	logger.Init("users-service")
 
-	// Подключение к БД
-	dbConnStr := fmt.Sprintf(
-		"user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Name,
-		cfg.Database.Host,
-		cfg.Database.Port,
-	)
-
-	db, err := sql.Open("postgres", dbConnStr)
+	cfg, args, err := config.Load(os.Args[1:])
 	if err != nil {
-		logger.Error("Failed to open database", "error", err)
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	// Проверяем подключение к БД
-	if err := db.Ping(); err != nil {
-		logger.Error("Failed to connect to database", "error", err)
-		panic(fmt.Sprintf("failed to connect to database: %v", err))
+	// Подкоманды: users-service admin create ..., users-service migrate up|down|status, config
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
 	}
-	logger.Info("Database connection established")
 
-	// Создаём таблицы
-	createTablesSQL := `
-		CREATE TABLE IF NOT EXISTS users (
-			id SERIAL PRIMARY KEY,
-			email VARCHAR(255) UNIQUE NOT NULL,
-			name VARCHAR(255) NOT NULL,
-			password_hash VARCHAR(255) NOT NULL,
-			created_at TIMESTAMP NOT NULL,
-			updated_at TIMESTAMP NOT NULL
-		);
-		CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
-	`
-	if _, err := db.Exec(createTablesSQL); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Трейсинг: экспортер выбирается через TRACING_EXPORTER
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Error("Failed to initialize tracing", "error", err)
 		panic(err)
 	}
+	defer shutdownTracing(context.Background())
 
-	// Инициализируем слои
-	repo := repository.New(db)
-	// Логируем админские credentials для отладки (только email, не password)
-	logger.Info("Admin credentials configured", "email", cfg.Admin.Email, "password_set", cfg.Admin.Password != "")
-	svc := service.New(repo, cfg.JWT.Secret, cfg.Admin.Email, cfg.Admin.Password)
-	hdlr := handler.New(svc)
-
-	// Запускаем HTTP сервер для метрик Prometheus ПЕРВЫМ (даже если gRPC не запустится)
-	metricsPort := 9001
-	metricsMux := http.NewServeMux()
-	metricsMux.Handle("/metrics", promhttp.Handler())
-	metricsServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", metricsPort),
-		Handler: metricsMux,
+	db, err := openDatabase(ctx, cfg)
+	if err != nil {
+		logger.Error("Failed to initialize database", "error", err)
+		panic(err)
 	}
 
-	// Канал для получения сигналов ОС
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	// Запускаем HTTP сервер для метрик в отдельной горутине (ПЕРВЫМ)
-	go func() {
-		logger.Info("Users Service metrics server starting", "port", metricsPort)
-		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Metrics server error", "error", err)
-		}
-	}()
-
-	// Небольшая задержка для запуска HTTP сервера метрик
-	time.Sleep(100 * time.Millisecond)
-
-	// Запускаем gRPC сервер
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
+	// Инициализируем слои: ключи подписи JWT, роли по умолчанию, сервис и gRPC handler
+	users, err := app.New(ctx, db, cfg)
 	if err != nil {
-		logger.Error("Failed to create gRPC listener", "error", err, "port", cfg.Server.Port)
-		logger.Warn("gRPC server will not start, but metrics server is running")
-		<-sigChan // Ждем сигнал остановки
-		logger.Info("Shutting down Users Service...")
-		if err := metricsServer.Close(); err != nil {
-			logger.Error("Error closing metrics server", "error", err)
-		}
-		if err := db.Close(); err != nil {
-			logger.Error("Error closing database", "error", err)
-		}
-		logger.Info("Users Service stopped")
-		return
+		logger.Error("Failed to initialize users service", "error", err)
+		panic(err)
 	}
 
-	grpcServer := grpc.NewServer()
-	pb.RegisterUsersServiceServer(grpcServer, hdlr)
-
-	// Health check
-	healthServer := health.NewServer()
-	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
-	healthServer.SetServingStatus("pb.UsersService", grpc_health_v1.HealthCheckResponse_SERVING)
-
-	// Enable gRPC reflection for easier testing
-	reflection.Register(grpcServer)
-
-	// Запускаем gRPC сервер в отдельной горутине
-	go func() {
-		logger.Info("Users Service gRPC server started", "port", cfg.Server.Port)
-		if err := grpcServer.Serve(listener); err != nil {
-			logger.Error("gRPC server error", "error", err)
-		}
-	}()
-
-	// Ожидаем сигнал для graceful shutdown
-	<-sigChan
-	logger.Info("Shutting down Users Service...")
-
-	// Graceful shutdown HTTP сервера метрик
-	if err := metricsServer.Close(); err != nil {
-		logger.Error("Error closing metrics server", "error", err)
+	srv := server.New(server.Config{
+		Name:        "users-service",
+		Port:        cfg.Server.Port,
+		MetricsPort: cfg.Server.MetricsPort,
+	}, health.Database(db))
+	srv.OnShutdown("database", db.Close)
+	// Публичные ключи для проверки подписи токенов в других сервисах
+	srv.HandleHTTP("/.well-known/jwks.json", users.JWKS)
+	pb.RegisterUsersServiceServer(srv, users.Server)
+
+	if err := srv.Run(ctx); err != nil {
+		logger.Error("Users Service stopped with error", "error", err)
 	}
+}
 
-	// Graceful shutdown gRPC сервера
-	grpcServer.GracefulStop()
-
-	// Закрываем соединение с БД
-	if err := db.Close(); err != nil {
-		logger.Error("Error closing database", "error", err)
+// runCommand выполняет подкоманду вместо запуска сервера
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "admin":
+		return runAdminCommand(cfg, args[1:])
+	case "config":
+		return sharedconfig.Print(os.Stdout, cfg)
+	case "migrate":
+		return migrate.RunCommand(context.Background(), cfg.Database, migrations.FS, args[1:], os.Stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s\n%s\n%s", args[0], adminUsage, migrate.Usage, sharedconfig.Usage)
 	}
-
-	logger.Info("Users Service stopped")
 }
@@ -1,48 +1,76 @@
 package config
 
 import (
-	"os"
+	"time"
+
+	sharedconfig "github.com/che1nov/tea-shop/shared/pkg/config"
+	"github.com/che1nov/tea-shop/shared/pkg/database"
+	"github.com/che1nov/tea-shop/shared/pkg/tracing"
 )
 
 type Config struct {
-	Database struct {
-		Host     string
-		Port     string
-		User     string
-		Password string
-		Name     string
-	}
-	Server struct {
-		Port int
-	}
+	sharedconfig.App `yaml:",inline"`
+	Database         database.Config `yaml:"database"`
+	Server           struct {
+		Port int `yaml:"port" env:"SERVER_PORT" validate:"port"`
+		// Порт HTTP сервера метрик (/metrics, /loglevel)
+		MetricsPort int `yaml:"metrics_port" env:"METRICS_PORT" validate:"port"`
+	} `yaml:"server"`
 	JWT struct {
-		Secret string
-	}
-	Admin struct {
-		Email    string
-		Password string
-	}
+		SigningAlgorithm    string        `yaml:"signing_algorithm" env:"JWT_SIGNING_ALG" validate:"oneof=EdDSA RS256"`
+		KeyRotationInterval time.Duration `yaml:"key_rotation_interval" env:"JWT_KEY_ROTATION_INTERVAL" validate:"positive"`
+		// Сколько выведенный из оборота ключ остаётся в JWKS
+		KeyRotationOverlap time.Duration `yaml:"key_rotation_overlap" env:"JWT_KEY_ROTATION_OVERLAP"`
+		AccessTokenTTL     time.Duration `yaml:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL" validate:"positive"`
+		RefreshTokenTTL    time.Duration `yaml:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL" validate:"positive"`
+		// Как часто удаляются истекшие и отозванные токены
+		TokenCleanupInterval time.Duration `yaml:"token_cleanup_interval" env:"JWT_TOKEN_CLEANUP_INTERVAL" validate:"positive"`
+	} `yaml:"jwt"`
+	// Трейсинг OpenTelemetry
+	Tracing tracing.Config `yaml:"tracing"`
 }
 
-func Load() *Config {
+// Default возвращает конфигурацию со значениями по умолчанию
+func Default() *Config {
 	cfg := &Config{}
 
-	cfg.Database.Host = getEnv("DB_HOST", "localhost")
-	cfg.Database.Port = getEnv("DB_PORT", "5432")
-	cfg.Database.User = getEnv("DB_USER", "user")
-	cfg.Database.Password = getEnv("DB_PASSWORD", "password")
-	cfg.Database.Name = getEnv("DB_NAME", "users_db")
+	cfg.Env = sharedconfig.EnvDevelopment
+	cfg.Database.Host = "localhost"
+	cfg.Database.Port = "5432"
+	cfg.Database.User = "user"
+	cfg.Database.Password = "password"
+	cfg.Database.Name = "users_db"
+	cfg.Database.AutoMigrate = true
 	cfg.Server.Port = 8001
-	cfg.JWT.Secret = getEnv("JWT_SECRET", "sfndknjs4234njndfgk")
-	cfg.Admin.Email = getEnv("ADMIN_EMAIL", "admin@example.com")
-	cfg.Admin.Password = getEnv("ADMIN_PASSWORD", "admin123")
+	cfg.Server.MetricsPort = 9001
+	cfg.JWT.SigningAlgorithm = "EdDSA"
+	cfg.JWT.KeyRotationInterval = 7 * 24 * time.Hour
+	cfg.JWT.AccessTokenTTL = 15 * time.Minute
+	cfg.JWT.RefreshTokenTTL = 30 * 24 * time.Hour
+	cfg.JWT.KeyRotationOverlap = time.Hour
+	cfg.JWT.TokenCleanupInterval = time.Hour
 
+	cfg.Tracing = tracing.Config{
+		ServiceName: "users-service",
+		Exporter:    tracing.ExporterNone,
+		Endpoint:    "localhost:4317",
+		FilePath:    "traces.json",
+	}
 	return cfg
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Load собирает конфигурацию: значения по умолчанию → YAML файл (--config или CONFIG_FILE) →
+// переменные окружения → флаги. Возвращает аргументы после флагов (подкоманду)
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	args, err := sharedconfig.Load(cfg, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Старый ключ должен оставаться в JWKS, пока не истекут все подписанные им access токены
+	if cfg.JWT.KeyRotationOverlap < cfg.JWT.AccessTokenTTL {
+		cfg.JWT.KeyRotationOverlap = cfg.JWT.AccessTokenTTL
 	}
-	return defaultValue
+	return cfg, args, nil
 }
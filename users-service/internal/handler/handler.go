@@ -2,11 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
 	"github.com/che1nov/tea-shop/users-service/internal/model"
 	"github.com/che1nov/tea-shop/users-service/internal/service"
 )
@@ -52,6 +54,46 @@ func (h *UsersHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest
 		PasswordHash: user.PasswordHash,
 		Role:         user.Role,
 		CreatedAt:    user.CreatedAt.Unix(),
+		Permissions:  user.Permissions,
+	}, nil
+}
+
+// CreateAdmin создает администратора от имени владельца access_token с правом users:manage_roles
+func (h *UsersHandler) CreateAdmin(ctx context.Context, req *pb.CreateAdminRequest) (*pb.User, error) {
+	if req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+	if req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required")
+	}
+	actorID, err := h.authorize(ctx, req.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.service.CreateAdmin(ctx, actorID, &model.CreateUserRequest{
+		Email:    req.Email,
+		Name:     req.Name,
+		Password: req.Password,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPasswordTooShort):
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		case errors.Is(err, service.ErrEmailAlreadyExists):
+			return nil, status.Errorf(codes.AlreadyExists, "user with email %s already exists", req.Email)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to create admin: %v", err)
+		}
+	}
+
+	return &pb.User{
+		Id:          user.ID,
+		Email:       user.Email,
+		Name:        user.Name,
+		Role:        user.Role,
+		CreatedAt:   user.CreatedAt.Unix(),
+		Permissions: user.Permissions,
 	}, nil
 }
 
@@ -76,6 +118,7 @@ func (h *UsersHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb
 		PasswordHash: user.PasswordHash,
 		Role:         user.Role,
 		CreatedAt:    user.CreatedAt.Unix(),
+		Permissions:  user.Permissions,
 	}, nil
 }
 
@@ -87,40 +130,27 @@ func (h *UsersHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Log
 		return nil, status.Errorf(codes.InvalidArgument, "password is required")
 	}
 
-	token, err := h.service.Login(ctx, req.Email, req.Password)
+	tokens, err := h.service.Login(ctx, req.Email, req.Password)
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "invalid email or password")
 	}
 
-	// Получаем данные пользователя из токена (включая role)
-	userID, email, role, err := h.service.ValidateTokenWithRole(token)
+	// Получаем ID пользователя из выпущенного токена
+	claims, err := h.service.ParseAccessToken(tokens.AccessToken)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to validate token: %v", err)
 	}
 
-	// Если userID = 0, это админ (виртуальный пользователь)
-	if userID == 0 {
-		return &pb.LoginResponse{
-			Token: token,
-			User: &pb.User{
-				Id:           0,
-				Email:        email,
-				Name:         "Administrator",
-				PasswordHash: "", // Не возвращаем пароль для админа
-				Role:         role,
-				CreatedAt:    0,
-			},
-		}, nil
-	}
-
-	// Для обычных пользователей получаем данные из БД
-	user, err := h.service.GetUser(ctx, userID)
+	// Получаем данные пользователя из БД
+	user, err := h.service.GetUser(ctx, claims.UserID)
 	if err != nil || user == nil {
 		return nil, status.Errorf(codes.Internal, "failed to get user: %v", err)
 	}
 
 	return &pb.LoginResponse{
-		Token: token,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 		User: &pb.User{
 			Id:           user.ID,
 			Email:        user.Email,
@@ -128,6 +158,7 @@ func (h *UsersHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Log
 			PasswordHash: user.PasswordHash,
 			Role:         user.Role,
 			CreatedAt:    user.CreatedAt.Unix(),
+			Permissions:  user.Permissions,
 		},
 	}, nil
 }
@@ -137,15 +168,147 @@ func (h *UsersHandler) ValidateToken(ctx context.Context, req *pb.ValidateTokenR
 		return &pb.ValidateTokenResponse{Valid: false}, nil
 	}
 
-	userID, email, role, err := h.service.ValidateTokenWithRole(req.Token)
+	claims, err := h.service.ParseAccessToken(req.Token)
+	if err != nil {
+		return &pb.ValidateTokenResponse{Valid: false}, nil
+	}
+
+	// Отозванный токен считается невалидным
+	revoked, err := h.service.IsTokenRevoked(ctx, claims.JTI)
 	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check token revocation: %v", err)
+	}
+	if revoked {
 		return &pb.ValidateTokenResponse{Valid: false}, nil
 	}
 
 	return &pb.ValidateTokenResponse{
-		Valid:  true,
-		UserId: userID,
-		Email:  email,
-		Role:   role,
+		Valid:       true,
+		UserId:      claims.UserID,
+		Email:       claims.Email,
+		Role:        claims.Role,
+		Permissions: claims.Permissions,
+	}, nil
+}
+
+func (h *UsersHandler) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	tokens, err := h.service.RefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken),
+			errors.Is(err, service.ErrRefreshTokenExpired),
+			errors.Is(err, service.ErrRefreshTokenReused):
+			return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to refresh token: %v", err)
+		}
+	}
+
+	return &pb.RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 	}, nil
 }
+
+func (h *UsersHandler) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	if req.AccessToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "access_token is required")
+	}
+
+	if err := h.service.Logout(ctx, req.AccessToken, req.RefreshToken); err != nil {
+		if errors.Is(err, service.ErrInvalidAccessToken) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid access token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to logout: %v", err)
+	}
+
+	return &pb.LogoutResponse{Success: true}, nil
+}
+
+func (h *UsersHandler) CheckTokenRevoked(ctx context.Context, req *pb.CheckTokenRevokedRequest) (*pb.CheckTokenRevokedResponse, error) {
+	if req.Jti == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "jti is required")
+	}
+
+	revoked, err := h.service.IsTokenRevoked(ctx, req.Jti)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check token revocation: %v", err)
+	}
+
+	return &pb.CheckTokenRevokedResponse{Revoked: revoked}, nil
+}
+
+func (h *UsersHandler) ListRoles(ctx context.Context, req *pb.ListRolesRequest) (*pb.ListRolesResponse, error) {
+	roles, err := h.service.ListRoles(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list roles: %v", err)
+	}
+
+	response := &pb.ListRolesResponse{Roles: make([]*pb.Role, 0, len(roles))}
+	for _, role := range roles {
+		response.Roles = append(response.Roles, &pb.Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+
+	return response, nil
+}
+
+func (h *UsersHandler) AssignRole(ctx context.Context, req *pb.AssignRoleRequest) (*pb.User, error) {
+	if req.UserId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id must be greater than 0")
+	}
+	if req.Role == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "role is required")
+	}
+
+	actorID, err := h.authorize(ctx, req.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.service.AssignRole(ctx, actorID, req.UserId, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRoleNotFound):
+			return nil, status.Errorf(codes.InvalidArgument, "role %s does not exist", req.Role)
+		case errors.Is(err, service.ErrUserNotFound):
+			return nil, status.Errorf(codes.NotFound, "user with id %d not found", req.UserId)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to assign role: %v", err)
+		}
+	}
+
+	return &pb.User{
+		Id:          user.ID,
+		Email:       user.Email,
+		Name:        user.Name,
+		Role:        user.Role,
+		CreatedAt:   user.CreatedAt.Unix(),
+		Permissions: user.Permissions,
+	}, nil
+}
+
+// authorize возвращает id администратора из access токена запроса с правом users:manage_roles
+func (h *UsersHandler) authorize(ctx context.Context, accessToken string) (int64, error) {
+	if accessToken == "" {
+		return 0, status.Errorf(codes.Unauthenticated, "access_token is required")
+	}
+	actorID, err := h.service.Authorize(ctx, accessToken, rbac.PermUsersManageRoles)
+	switch {
+	case errors.Is(err, service.ErrInvalidAccessToken):
+		return 0, status.Errorf(codes.Unauthenticated, "%v", err)
+	case errors.Is(err, service.ErrPermissionDenied):
+		return 0, status.Errorf(codes.PermissionDenied, "%s is required", rbac.PermUsersManageRoles)
+	case err != nil:
+		return 0, status.Errorf(codes.Internal, "failed to check access token: %v", err)
+	}
+	return actorID, nil
+}
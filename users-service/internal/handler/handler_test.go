@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	pb "github.com/che1nov/tea-shop/shared/pb"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -46,9 +47,72 @@ func (m *MockUserService) GenerateToken(user *model.User) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockUserService) Login(ctx context.Context, email, password string) (string, error) {
+func (m *MockUserService) ValidateTokenWithRole(tokenString string) (int64, string, string, error) {
+	args := m.Called(tokenString)
+	return args.Get(0).(int64), args.String(1), args.String(2), args.Error(3)
+}
+
+func (m *MockUserService) ParseAccessToken(tokenString string) (*model.AccessClaims, error) {
+	args := m.Called(tokenString)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AccessClaims), args.Error(1)
+}
+
+func (m *MockUserService) Login(ctx context.Context, email, password string) (*model.TokenPair, error) {
 	args := m.Called(ctx, email, password)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TokenPair), args.Error(1)
+}
+
+func (m *MockUserService) RefreshToken(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TokenPair), args.Error(1)
+}
+
+func (m *MockUserService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	args := m.Called(ctx, accessToken, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockUserService) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserService) CreateAdmin(ctx context.Context, actorID int64, req *model.CreateUserRequest) (*model.User, error) {
+	args := m.Called(ctx, actorID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) Authorize(ctx context.Context, accessToken, permission string) (int64, error) {
+	args := m.Called(ctx, accessToken, permission)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockUserService) AssignRole(ctx context.Context, actorID, userID int64, role string) (*model.User, error) {
+	args := m.Called(ctx, actorID, userID, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
 }
 
 func TestNew(t *testing.T) {
@@ -261,7 +325,13 @@ func TestValidateToken_ValidToken(t *testing.T) {
 		Token: "valid-token",
 	}
 	
-	mockService.On("ValidateToken", "valid-token").Return(int64(1), "test@example.com", nil)
+	mockService.On("ParseAccessToken", "valid-token").Return(&model.AccessClaims{
+		UserID: 1,
+		Email:  "test@example.com",
+		Role:   model.RoleUser,
+		JTI:    "jti-1",
+	}, nil)
+	mockService.On("IsTokenRevoked", ctx, "jti-1").Return(false, nil)
 	
 	resp, err := handler.ValidateToken(ctx, req)
 	
@@ -282,7 +352,7 @@ func TestValidateToken_InvalidToken(t *testing.T) {
 		Token: "invalid-token",
 	}
 	
-	mockService.On("ValidateToken", "invalid-token").Return(int64(0), "", errors.New("invalid token"))
+	mockService.On("ParseAccessToken", "invalid-token").Return(nil, errors.New("invalid token"))
 	
 	resp, err := handler.ValidateToken(ctx, req)
 	
@@ -305,6 +375,276 @@ func TestValidateToken_EmptyToken(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.False(t, resp.Valid)
-	mockService.AssertNotCalled(t, "ValidateToken")
+	mockService.AssertNotCalled(t, "ParseAccessToken")
+}
+
+func TestValidateToken_RevokedToken(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ParseAccessToken", "revoked-token").Return(&model.AccessClaims{UserID: 1, JTI: "jti-1"}, nil)
+	mockService.On("IsTokenRevoked", ctx, "jti-1").Return(true, nil)
+
+	resp, err := handler.ValidateToken(ctx, &pb.ValidateTokenRequest{Token: "revoked-token"})
+
+	assert.NoError(t, err)
+	assert.False(t, resp.Valid)
+	mockService.AssertExpectations(t)
+}
+
+func TestRefreshToken_Success(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("RefreshToken", ctx, "refresh").Return(&model.TokenPair{
+		AccessToken:  "new-access",
+		RefreshToken: "new-refresh",
+		ExpiresIn:    900,
+	}, nil)
+
+	resp, err := handler.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: "refresh"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "new-access", resp.Token)
+	assert.Equal(t, "new-refresh", resp.RefreshToken)
+	assert.Equal(t, int64(900), resp.ExpiresIn)
+	mockService.AssertExpectations(t)
+}
+
+func TestRefreshToken_Reused(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("RefreshToken", ctx, "reused").Return(nil, service.ErrRefreshTokenReused)
+
+	resp, err := handler.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: "reused"})
+
+	assert.Nil(t, resp)
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+}
+
+func TestRefreshToken_Missing(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+
+	resp, err := handler.RefreshToken(context.Background(), &pb.RefreshTokenRequest{})
+
+	assert.Nil(t, resp)
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	mockService.AssertNotCalled(t, "RefreshToken")
 }
 
+func TestLogout_Success(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("Logout", ctx, "access", "refresh").Return(nil)
+
+	resp, err := handler.Logout(ctx, &pb.LogoutRequest{AccessToken: "access", RefreshToken: "refresh"})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.Success)
+	mockService.AssertExpectations(t)
+}
+
+func TestLogout_InvalidAccessToken(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("Logout", ctx, "bad", "").Return(service.ErrInvalidAccessToken)
+
+	resp, err := handler.Logout(ctx, &pb.LogoutRequest{AccessToken: "bad"})
+
+	assert.Nil(t, resp)
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+}
+
+func TestCheckTokenRevoked(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("IsTokenRevoked", ctx, "jti-1").Return(true, nil)
+
+	resp, err := handler.CheckTokenRevoked(ctx, &pb.CheckTokenRevokedRequest{Jti: "jti-1"})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.Revoked)
+	mockService.AssertExpectations(t)
+}
+
+
+func TestListRoles(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("ListRoles", ctx).Return([]*model.Role{
+		{Name: "admin", Description: "Администратор", Permissions: []string{"goods:write"}},
+		{Name: "user", Description: "Покупатель"},
+	}, nil)
+
+	resp, err := handler.ListRoles(ctx, &pb.ListRolesRequest{})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Roles, 2)
+	assert.Equal(t, []string{"goods:write"}, resp.Roles[0].Permissions)
+	mockService.AssertExpectations(t)
+}
+
+func TestAssignRole_Success(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("Authorize", ctx, "admin-token", rbac.PermUsersManageRoles).Return(int64(7), nil)
+	mockService.On("AssignRole", ctx, int64(7), int64(1), "support").Return(&model.User{
+		ID:          1,
+		Email:       "test@example.com",
+		Role:        "support",
+		Permissions: []string{"orders:read"},
+	}, nil)
+
+	resp, err := handler.AssignRole(ctx, &pb.AssignRoleRequest{UserId: 1, Role: "support", AccessToken: "admin-token"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "support", resp.Role)
+	assert.Equal(t, []string{"orders:read"}, resp.Permissions)
+	assert.Empty(t, resp.PasswordHash)
+}
+
+func TestAssignRole_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"unknown role", service.ErrRoleNotFound, codes.InvalidArgument},
+		{"unknown user", service.ErrUserNotFound, codes.NotFound},
+		{"internal", errors.New("db error"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := New(mockService)
+			mockService.On("Authorize", ctx, "admin-token", rbac.PermUsersManageRoles).Return(int64(7), nil)
+			mockService.On("AssignRole", ctx, int64(7), int64(1), "courier").Return(nil, tt.err)
+
+			_, err := handler.AssignRole(ctx, &pb.AssignRoleRequest{UserId: 1, Role: "courier", AccessToken: "admin-token"})
+
+			st, _ := status.FromError(err)
+			assert.Equal(t, tt.code, st.Code())
+		})
+	}
+
+	handler := New(new(MockUserService))
+	_, err := handler.AssignRole(ctx, &pb.AssignRoleRequest{UserId: 1})
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+}
+
+func TestAssignRole_RequiresAdminToken(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+		code  codes.Code
+	}{
+		{"no token", "", nil, codes.Unauthenticated},
+		{"invalid token", "bad-token", service.ErrInvalidAccessToken, codes.Unauthenticated},
+		{"no permission", "support-token", service.ErrPermissionDenied, codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := New(mockService)
+			mockService.On("Authorize", ctx, tt.token, rbac.PermUsersManageRoles).Return(int64(0), tt.err)
+
+			_, err := handler.AssignRole(ctx, &pb.AssignRoleRequest{UserId: 1, Role: "admin", AccessToken: tt.token})
+
+			st, _ := status.FromError(err)
+			assert.Equal(t, tt.code, st.Code())
+			mockService.AssertNotCalled(t, "AssignRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateAdmin_Success(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	ctx := context.Background()
+
+	mockService.On("Authorize", ctx, "admin-token", rbac.PermUsersManageRoles).Return(int64(7), nil)
+	mockService.On("CreateAdmin", ctx, int64(7), &model.CreateUserRequest{
+		Email:    "admin@example.com",
+		Name:     "Admin",
+		Password: "strong-password",
+	}).Return(&model.User{ID: 2, Email: "admin@example.com", Name: "Admin", Role: model.RoleAdmin, PasswordHash: "hash"}, nil)
+
+	resp, err := handler.CreateAdmin(ctx, &pb.CreateAdminRequest{
+		Email:       "admin@example.com",
+		Name:        "Admin",
+		Password:    "strong-password",
+		AccessToken: "admin-token",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Id)
+	assert.Equal(t, model.RoleAdmin, resp.Role)
+	assert.Empty(t, resp.PasswordHash)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateAdmin_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"short password", service.ErrPasswordTooShort, codes.InvalidArgument},
+		{"email taken", service.ErrEmailAlreadyExists, codes.AlreadyExists},
+		{"internal", errors.New("db error"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := New(mockService)
+			mockService.On("Authorize", ctx, "admin-token", rbac.PermUsersManageRoles).Return(int64(7), nil)
+			mockService.On("CreateAdmin", ctx, int64(7), mock.Anything).Return(nil, tt.err)
+
+			_, err := handler.CreateAdmin(ctx, &pb.CreateAdminRequest{Email: "admin@example.com", Name: "Admin", Password: "short", AccessToken: "admin-token"})
+
+			st, _ := status.FromError(err)
+			assert.Equal(t, tt.code, st.Code())
+		})
+	}
+
+	// Без токена администратора учётная запись не создается
+	mockService := new(MockUserService)
+	handler := New(mockService)
+	mockService.On("Authorize", ctx, "support-token", rbac.PermUsersManageRoles).Return(int64(0), service.ErrPermissionDenied)
+	_, err := handler.CreateAdmin(ctx, &pb.CreateAdminRequest{Email: "admin@example.com", Name: "Admin", Password: "strong-password", AccessToken: "support-token"})
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code())
+	mockService.AssertNotCalled(t, "CreateAdmin", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,342 @@
+// Package keys управляет ключами подписи JWT: генерирует, ротирует и публикует их в виде JWKS.
+package keys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/che1nov/tea-shop/shared/pkg/jwks"
+	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+	"github.com/che1nov/tea-shop/users-service/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	rsaKeyBits = 2048
+	// minReloadInterval ограничивает перечитывание ключей из БД при встрече неизвестного kid
+	minReloadInterval = 10 * time.Second
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrNoActiveKey          = errors.New("no active signing key")
+	ErrUnknownKey           = errors.New("unknown signing key")
+)
+
+// SupportedAlgorithms - алгоритмы, которыми могут быть подписаны токены
+var SupportedAlgorithms = []string{model.SigningAlgorithmEdDSA, model.SigningAlgorithmRS256}
+
+type signingKey struct {
+	kid        string
+	algorithm  string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+	createdAt  time.Time
+	retiredAt  *time.Time
+}
+
+// Manager хранит активный ключ подписи и ключи, которые ещё могут встречаться в выданных токенах.
+// Активный ключ ротируется раз в rotationInterval, старые ключи публикуются в JWKS ещё overlap
+// после вывода из оборота, чтобы ранее выданные токены продолжали проходить проверку.
+type Manager struct {
+	store            repository.SigningKeyRepositoryInterface
+	algorithm        string
+	rotationInterval time.Duration
+	overlap          time.Duration
+
+	mu         sync.RWMutex
+	current    *signingKey
+	keys       map[string]*signingKey
+	lastReload time.Time
+}
+
+func NewManager(
+	store repository.SigningKeyRepositoryInterface,
+	algorithm string,
+	rotationInterval, overlap time.Duration,
+) (*Manager, error) {
+	if _, err := signingMethod(algorithm); err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		store:            store,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		overlap:          overlap,
+		keys:             make(map[string]*signingKey),
+	}, nil
+}
+
+// Init загружает ключи из хранилища и создаёт первый ключ, если активного ключа ещё нет
+func (m *Manager) Init(ctx context.Context) error {
+	if err := m.reload(ctx); err != nil {
+		return err
+	}
+	return m.RotateIfDue(ctx)
+}
+
+// Run периодически проверяет необходимость ротации и подхватывает ключи, созданные другими репликами
+func (m *Manager) Run(ctx context.Context) {
+	interval := m.rotationInterval / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.reload(ctx); err != nil {
+				logger.Error("Failed to reload signing keys", "error", err)
+				continue
+			}
+			if err := m.RotateIfDue(ctx); err != nil {
+				logger.Error("Failed to rotate signing key", "error", err)
+			}
+		}
+	}
+}
+
+// RotateIfDue создаёт новый ключ, если активный ключ старше rotationInterval или отсутствует
+func (m *Manager) RotateIfDue(ctx context.Context) error {
+	now := time.Now()
+
+	m.mu.RLock()
+	current := m.current
+	m.mu.RUnlock()
+
+	if current != nil && now.Sub(current.createdAt) < m.rotationInterval {
+		return nil
+	}
+
+	newKey, err := GenerateKey(m.algorithm)
+	if err != nil {
+		return err
+	}
+
+	rotated, err := m.store.RotateSigningKey(ctx, newKey, now.Add(-m.rotationInterval))
+	if err != nil {
+		return err
+	}
+	if rotated {
+		logger.Info("Signing key rotated", "kid", newKey.KID, "algorithm", newKey.Algorithm)
+	}
+
+	if err := m.store.DeleteSigningKeysRetiredBefore(ctx, now.Add(-m.overlap)); err != nil {
+		return err
+	}
+
+	return m.reload(ctx)
+}
+
+// Sign подписывает claims активным ключом и проставляет kid в заголовок
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	current := m.current
+	m.mu.RUnlock()
+
+	if current == nil {
+		return "", ErrNoActiveKey
+	}
+
+	token := jwt.NewWithClaims(current.method, claims)
+	token.Header["kid"] = current.kid
+	return token.SignedString(current.privateKey)
+}
+
+// Keyfunc возвращает публичный ключ для проверки токена по его kid
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+
+	key := m.lookup(kid)
+	if key == nil && m.reloadAllowed() {
+		// Ключ мог быть создан другой репликой
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := m.reload(ctx); err != nil {
+			return nil, err
+		}
+		key = m.lookup(kid)
+	}
+	if key == nil {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != key.algorithm {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.publicKey, nil
+}
+
+// JWKS возвращает публичные ключи, которыми могут быть подписаны действующие токены
+func (m *Manager) JWKS() jwks.Set {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := jwks.Set{Keys: make([]jwks.Key, 0, len(m.keys))}
+	for _, key := range m.keys {
+		jwk, err := jwks.NewKey(key.kid, key.algorithm, key.publicKey)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// JWKSHandler отдаёт JWKS документ для /.well-known/jwks.json
+func (m *Manager) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		if err := json.NewEncoder(w).Encode(m.JWKS()); err != nil {
+			logger.Error("Failed to encode JWKS", "error", err)
+		}
+	})
+}
+
+func (m *Manager) lookup(kid string) *signingKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keys[kid]
+}
+
+func (m *Manager) reloadAllowed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return time.Since(m.lastReload) >= minReloadInterval
+}
+
+// reload перечитывает ключи из хранилища
+func (m *Manager) reload(ctx context.Context) error {
+	stored, err := m.store.ListSigningKeys(ctx, time.Now().Add(-m.overlap))
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*signingKey, len(stored))
+	var current *signingKey
+	for _, s := range stored {
+		key, err := parseSigningKey(s)
+		if err != nil {
+			logger.Error("Failed to parse signing key", "kid", s.KID, "error", err)
+			continue
+		}
+		keys[key.kid] = key
+
+		if key.retiredAt == nil && key.algorithm == m.algorithm {
+			if current == nil || key.createdAt.After(current.createdAt) {
+				current = key
+			}
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.current = current
+	m.lastReload = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// GenerateKey создаёт новую пару ключей для алгоритма и кодирует её в PEM
+func GenerateKey(algorithm string) (*model.SigningKey, error) {
+	var (
+		privateKey crypto.Signer
+		err        error
+	)
+
+	switch algorithm {
+	case model.SigningAlgorithmRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case model.SigningAlgorithmEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	// kid - отпечаток публичного ключа, он стабилен и не раскрывает порядок создания ключей
+	sum := sha256.Sum256(publicDER)
+
+	return &model.SigningKey{
+		KID:           base64.RawURLEncoding.EncodeToString(sum[:16]),
+		Algorithm:     algorithm,
+		PrivateKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		PublicKeyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}, nil
+}
+
+func parseSigningKey(s *model.SigningKey) (*signingKey, error) {
+	method, err := signingMethod(s.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(s.PrivateKeyPEM))
+	if block == nil {
+		return nil, errors.New("invalid private key PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unexpected private key type %T", parsed)
+	}
+
+	return &signingKey{
+		kid:        s.KID,
+		algorithm:  s.Algorithm,
+		method:     method,
+		privateKey: privateKey,
+		publicKey:  privateKey.Public(),
+		createdAt:  s.CreatedAt,
+		retiredAt:  s.RetiredAt,
+	}, nil
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case model.SigningAlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case model.SigningAlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
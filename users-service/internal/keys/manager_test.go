@@ -0,0 +1,117 @@
+package keys
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T, algorithm string, rotationInterval, overlap time.Duration) *Manager {
+	manager, err := NewManager(NewMemoryStore(), algorithm, rotationInterval, overlap)
+	require.NoError(t, err)
+	require.NoError(t, manager.Init(context.Background()))
+	return manager
+}
+
+func parse(manager *Manager, tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, manager.Keyfunc, jwt.WithValidMethods(SupportedAlgorithms))
+}
+
+func TestManager_SignAndVerify(t *testing.T) {
+	for _, algorithm := range SupportedAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			manager := newTestManager(t, algorithm, time.Hour, time.Hour)
+
+			tokenString, err := manager.Sign(jwt.MapClaims{"user_id": 1})
+			require.NoError(t, err)
+
+			token, err := parse(manager, tokenString)
+			require.NoError(t, err)
+			assert.True(t, token.Valid)
+			assert.Equal(t, algorithm, token.Method.Alg())
+			assert.NotEmpty(t, token.Header["kid"])
+
+			set := manager.JWKS()
+			require.Len(t, set.Keys, 1)
+			assert.Equal(t, token.Header["kid"], set.Keys[0].Kid)
+		})
+	}
+}
+
+func TestManager_RejectsHMACToken(t *testing.T) {
+	manager := newTestManager(t, model.SigningAlgorithmEdDSA, time.Hour, time.Hour)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1})
+	forged.Header["kid"] = manager.JWKS().Keys[0].Kid
+	tokenString, err := forged.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = parse(manager, tokenString)
+	assert.Error(t, err)
+}
+
+func TestManager_RotationKeepsOldKeyDuringOverlap(t *testing.T) {
+	manager := newTestManager(t, model.SigningAlgorithmEdDSA, time.Millisecond, time.Hour)
+
+	oldToken, err := manager.Sign(jwt.MapClaims{"user_id": 1})
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, manager.RotateIfDue(context.Background()))
+
+	newToken, err := manager.Sign(jwt.MapClaims{"user_id": 1})
+	require.NoError(t, err)
+
+	oldParsed, err := parse(manager, oldToken)
+	require.NoError(t, err)
+	newParsed, err := parse(manager, newToken)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, oldParsed.Header["kid"], newParsed.Header["kid"])
+	assert.Len(t, manager.JWKS().Keys, 2)
+}
+
+func TestManager_DropsKeysAfterOverlap(t *testing.T) {
+	manager := newTestManager(t, model.SigningAlgorithmEdDSA, time.Millisecond, time.Millisecond)
+
+	oldToken, err := manager.Sign(jwt.MapClaims{"user_id": 1})
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, manager.RotateIfDue(context.Background()))
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, manager.reload(context.Background()))
+
+	assert.Len(t, manager.JWKS().Keys, 1)
+	_, err = parse(manager, oldToken)
+	assert.Error(t, err)
+}
+
+func TestManager_PicksUpKeyRotatedByAnotherReplica(t *testing.T) {
+	store := NewMemoryStore()
+	first, err := NewManager(store, model.SigningAlgorithmRS256, time.Hour, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, first.Init(context.Background()))
+
+	second, err := NewManager(store, model.SigningAlgorithmRS256, time.Hour, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, second.Init(context.Background()))
+
+	// Вторая реплика не должна создавать собственный ключ
+	assert.Len(t, second.JWKS().Keys, 1)
+
+	tokenString, err := first.Sign(jwt.MapClaims{"user_id": 1})
+	require.NoError(t, err)
+	_, err = parse(second, tokenString)
+	assert.NoError(t, err)
+}
+
+func TestNewManager_UnsupportedAlgorithm(t *testing.T) {
+	_, err := NewManager(NewMemoryStore(), "HS256", time.Hour, time.Hour)
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
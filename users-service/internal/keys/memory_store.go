@@ -0,0 +1,75 @@
+package keys
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+)
+
+// MemoryStore - хранилище ключей в памяти (для тестов и запуска без БД)
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys []*model.SigningKey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) ListSigningKeys(ctx context.Context, retiredAfter time.Time) ([]*model.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []*model.SigningKey
+	for _, key := range s.keys {
+		if key.RetiredAt == nil || key.RetiredAt.After(retiredAfter) {
+			copied := *key
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (s *MemoryStore) RotateSigningKey(ctx context.Context, newKey *model.SigningKey, rotateBefore time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.RetiredAt == nil && key.Algorithm == newKey.Algorithm && key.CreatedAt.After(rotateBefore) {
+			return false, nil
+		}
+	}
+
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.RetiredAt == nil {
+			retiredAt := now
+			key.RetiredAt = &retiredAt
+		}
+	}
+
+	newKey.CreatedAt = now
+	copied := *newKey
+	s.keys = append(s.keys, &copied)
+	return true, nil
+}
+
+func (s *MemoryStore) DeleteSigningKeysRetiredBefore(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.keys[:0]
+	for _, key := range s.keys {
+		if key.RetiredAt == nil || !key.RetiredAt.Before(before) {
+			kept = append(kept, key)
+		}
+	}
+	s.keys = kept
+	return nil
+}
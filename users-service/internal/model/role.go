@@ -0,0 +1,8 @@
+package model
+
+// Role - роль пользователя и набор прав, которые она предоставляет
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
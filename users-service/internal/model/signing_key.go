@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey - ключ подписи JWT. Приватная часть хранится в PEM (PKCS#8), публичная публикуется в JWKS
+type SigningKey struct {
+	KID           string
+	Algorithm     string
+	PrivateKeyPEM string
+	PublicKeyPEM  string
+	CreatedAt     time.Time
+	RetiredAt     *time.Time // Момент, когда ключ перестал использоваться для подписи
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// TokenPair - пара токенов, выдаваемая при входе и обновлении
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64 // Время жизни access токена в секундах
+}
+
+// AccessClaims - данные, извлечённые из access токена
+type AccessClaims struct {
+	UserID      int64
+	Email       string
+	Role        string
+	Permissions []string
+	JTI         string
+	ExpiresAt   time.Time
+}
+
+// RefreshToken - refresh токен, хранящийся в БД в виде хеша
+type RefreshToken struct {
+	ID         int64
+	UserID     int64
+	FamilyID   string // Все токены одной цепочки ротации имеют общий FamilyID
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *int64
+	CreatedAt  time.Time
+}
+
+// IsRevoked возвращает true, если токен уже отозван или использован
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired возвращает true, если срок действия токена истёк
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return now.After(t.ExpiresAt)
+}
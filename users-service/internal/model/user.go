@@ -1,10 +1,14 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+)
 
 const (
-	RoleUser  = "user"
-	RoleAdmin = "admin"
+	RoleUser  = rbac.RoleUser
+	RoleAdmin = rbac.RoleAdmin
 )
 
 type User struct {
@@ -12,7 +16,8 @@ type User struct {
 	Email        string
 	Name         string
 	PasswordHash string
-	Role         string // "user" или "admin"
+	Role         string
+	Permissions  []string // Права роли, вшиваются в access токен
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+)
+
+// MemoryRepository - репозиторий пользователей в памяти (для тестов и запуска без БД).
+// Повторяет семантику UserRepository, включая ошибки уникальности и ротации токенов
+type MemoryRepository struct {
+	mu            sync.Mutex
+	users         map[int64]*model.User
+	roles         map[string]*model.Role
+	refreshTokens map[int64]*model.RefreshToken
+	revokedTokens map[string]time.Time
+	lastUserID    int64
+	lastTokenID   int64
+}
+
+func NewMemory() *MemoryRepository {
+	return &MemoryRepository{
+		users:         make(map[int64]*model.User),
+		roles:         make(map[string]*model.Role),
+		refreshTokens: make(map[int64]*model.RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+	}
+}
+
+func (r *MemoryRepository) CreateUser(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return ErrEmailAlreadyExists
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = model.RoleUser
+	}
+	r.lastUserID++
+	user.ID = r.lastUserID
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *MemoryRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) UpdateUserRole(ctx context.Context, userID int64, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryRepository) GetRole(ctx context.Context, name string) (*model.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, nil
+	}
+	return copyRole(role), nil
+}
+
+func (r *MemoryRepository) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	roles := make([]*model.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, copyRole(role))
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+// EnsureRoles, как и в БД, только добавляет отсутствующие роли и права
+func (r *MemoryRepository) EnsureRoles(ctx context.Context, roles []*model.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, role := range roles {
+		existing, ok := r.roles[role.Name]
+		if !ok {
+			existing = &model.Role{Name: role.Name, Description: role.Description}
+			r.roles[role.Name] = existing
+		}
+		for _, permission := range role.Permissions {
+			if !containsString(existing.Permissions, permission) {
+				existing.Permissions = append(existing.Permissions, permission)
+			}
+		}
+		sort.Strings(existing.Permissions)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.storeRefreshToken(token)
+	return nil
+}
+
+func (r *MemoryRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return copyRefreshToken(token), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) RotateRefreshToken(ctx context.Context, oldID int64, newToken *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.refreshTokens[oldID]
+	if !ok || old.RevokedAt != nil {
+		return ErrRefreshTokenAlreadyRotated
+	}
+
+	r.storeRefreshToken(newToken)
+	revokedAt := newToken.CreatedAt
+	replacedBy := newToken.ID
+	old.RevokedAt = &revokedAt
+	old.ReplacedBy = &replacedBy
+	return nil
+}
+
+func (r *MemoryRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.refreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			revokedAt := now
+			token.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.revokedTokens[jti]; !ok {
+		r.revokedTokens[jti] = expiresAt
+	}
+	return nil
+}
+
+func (r *MemoryRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, revoked := r.revokedTokens[jti]
+	return revoked, nil
+}
+
+// PurgeExpiredTokens удаляет токены так же, как UserRepository
+func (r *MemoryRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for jti, expiresAt := range r.revokedTokens {
+		if expiresAt.Before(now) {
+			delete(r.revokedTokens, jti)
+			purged++
+		}
+	}
+
+	active := make(map[string]bool)
+	for _, token := range r.refreshTokens {
+		if token.RevokedAt == nil && !token.ExpiresAt.Before(now) {
+			active[token.FamilyID] = true
+		}
+	}
+	for id, token := range r.refreshTokens {
+		if token.ExpiresAt.Before(now) || !active[token.FamilyID] {
+			delete(r.refreshTokens, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// storeRefreshToken присваивает токену идентификатор и сохраняет копию; вызывается под r.mu
+func (r *MemoryRepository) storeRefreshToken(token *model.RefreshToken) {
+	r.lastTokenID++
+	token.ID = r.lastTokenID
+	token.CreatedAt = time.Now()
+	r.refreshTokens[token.ID] = copyRefreshToken(token)
+}
+
+func copyRole(role *model.Role) *model.Role {
+	copied := *role
+	copied.Permissions = append([]string(nil), role.Permissions...)
+	return &copied
+}
+
+func copyRefreshToken(token *model.RefreshToken) *model.RefreshToken {
+	copied := *token
+	if token.RevokedAt != nil {
+		revokedAt := *token.RevokedAt
+		copied.RevokedAt = &revokedAt
+	}
+	if token.ReplacedBy != nil {
+		replacedBy := *token.ReplacedBy
+		copied.ReplacedBy = &replacedBy
+	}
+	return &copied
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_CreateUser(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	user := &model.User{Email: "memory@example.com", Name: "Memory", PasswordHash: "hash"}
+	require.NoError(t, repo.CreateUser(ctx, user))
+	assert.Equal(t, int64(1), user.ID)
+	assert.Equal(t, model.RoleUser, user.Role)
+
+	err := repo.CreateUser(ctx, &model.User{Email: "memory@example.com"})
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+
+	found, err := repo.GetUserByEmail(ctx, "memory@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	missing, err := repo.GetUserByID(ctx, 42)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	assert.ErrorIs(t, repo.UpdateUserRole(ctx, 42, model.RoleAdmin), ErrUserNotFound)
+}
+
+func TestMemory_RotateRefreshToken(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	old := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "old", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.CreateRefreshToken(ctx, old))
+
+	next := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "next", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.RotateRefreshToken(ctx, old.ID, next))
+
+	stored, err := repo.GetRefreshTokenByHash(ctx, "old")
+	require.NoError(t, err)
+	require.True(t, stored.IsRevoked())
+	assert.Equal(t, next.ID, *stored.ReplacedBy)
+
+	// Повторная ротация того же токена - как при параллельном запросе
+	again := &model.RefreshToken{UserID: 1, FamilyID: "family", TokenHash: "again", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.ErrorIs(t, repo.RotateRefreshToken(ctx, old.ID, again), ErrRefreshTokenAlreadyRotated)
+
+	require.NoError(t, repo.RevokeRefreshTokenFamily(ctx, "family"))
+	stored, err = repo.GetRefreshTokenByHash(ctx, "next")
+	require.NoError(t, err)
+	assert.True(t, stored.IsRevoked())
+}
+
+func TestMemory_Roles(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	require.NoError(t, repo.EnsureRoles(ctx, []*model.Role{
+		{Name: "user", Description: "Покупатель", Permissions: []string{"orders:create"}},
+	}))
+	// Описание существующей роли не перезаписывается, новые права добавляются
+	require.NoError(t, repo.EnsureRoles(ctx, []*model.Role{
+		{Name: "user", Description: "Другое", Permissions: []string{"orders:create", "goods:read"}},
+	}))
+
+	role, err := repo.GetRole(ctx, "user")
+	require.NoError(t, err)
+	assert.Equal(t, "Покупатель", role.Description)
+	assert.Equal(t, []string{"goods:read", "orders:create"}, role.Permissions)
+
+	missing, err := repo.GetRole(ctx, "ghost")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestMemory_RevokeAccessToken(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	revoked, err := repo.IsAccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, repo.RevokeAccessToken(ctx, "jti", time.Now().Add(time.Hour)))
+	require.NoError(t, repo.RevokeAccessToken(ctx, "jti", time.Now().Add(time.Hour)))
+
+	revoked, err = repo.IsAccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestMemory_PurgeExpiredTokens(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, repo.RevokeAccessToken(ctx, "expired", now.Add(-time.Minute)))
+	require.NoError(t, repo.RevokeAccessToken(ctx, "active", now.Add(time.Minute)))
+
+	// Цепочка с действующим токеном: отозванный при ротации токен нужен для обнаружения повтора
+	rotated := &model.RefreshToken{UserID: 1, FamilyID: "live", TokenHash: "rotated", ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.CreateRefreshToken(ctx, rotated))
+	require.NoError(t, repo.RotateRefreshToken(ctx, rotated.ID, &model.RefreshToken{UserID: 1, FamilyID: "live", TokenHash: "current", ExpiresAt: now.Add(time.Hour)}))
+	// Истекший токен и цепочка, отозванная при выходе
+	require.NoError(t, repo.CreateRefreshToken(ctx, &model.RefreshToken{UserID: 1, FamilyID: "old", TokenHash: "expired", ExpiresAt: now.Add(-time.Minute)}))
+	require.NoError(t, repo.CreateRefreshToken(ctx, &model.RefreshToken{UserID: 2, FamilyID: "logout", TokenHash: "logout", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.RevokeRefreshTokenFamily(ctx, "logout"))
+
+	purged, err := repo.PurgeExpiredTokens(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+
+	revoked, err := repo.IsAccessTokenRevoked(ctx, "expired")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = repo.IsAccessTokenRevoked(ctx, "active")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	for hash, kept := range map[string]bool{"rotated": true, "current": true, "expired": false, "logout": false} {
+		token, err := repo.GetRefreshTokenByHash(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, kept, token != nil, hash)
+	}
+}
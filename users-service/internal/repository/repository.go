@@ -10,13 +10,31 @@ import (
 	"github.com/che1nov/tea-shop/users-service/internal/model"
 )
 
-var ErrEmailAlreadyExists = errors.New("email already exists")
+var (
+	ErrEmailAlreadyExists = errors.New("email already exists")
+	// ErrRefreshTokenAlreadyRotated возвращается, если refresh токен был отозван параллельным запросом
+	ErrRefreshTokenAlreadyRotated = errors.New("refresh token already rotated")
+	ErrUserNotFound               = errors.New("user not found")
+)
 
 // UserRepositoryInterface определяет методы репозитория
 type UserRepositoryInterface interface {
 	CreateUser(ctx context.Context, user *model.User) error
 	GetUserByID(ctx context.Context, id int64) (*model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	UpdateUserRole(ctx context.Context, userID int64, role string) error
+
+	GetRole(ctx context.Context, name string) (*model.Role, error)
+	ListRoles(ctx context.Context) ([]*model.Role, error)
+	EnsureRoles(ctx context.Context, roles []*model.Role) error
+
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID int64, newToken *model.RefreshToken) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error)
 }
 
 type UserRepository struct {
@@ -29,10 +47,13 @@ func New(db *sql.DB) *UserRepository {
 
 func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error {
 	query := `
-		INSERT INTO users (email, name, password_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (email, name, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`
+	if user.Role == "" {
+		user.Role = model.RoleUser
+	}
 	now := time.Now()
 	err := r.db.QueryRowContext(
 		ctx,
@@ -40,6 +61,7 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error
 		user.Email,
 		user.Name,
 		user.PasswordHash,
+		user.Role,
 		now,
 		now,
 	).Scan(&user.ID)
@@ -58,7 +80,7 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error
 }
 
 func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
-	query := `SELECT id, email, name, password_hash, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT id, email, name, password_hash, role, created_at, updated_at FROM users WHERE id = $1`
 
 	user := &model.User{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
@@ -66,6 +88,7 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*model.User
 		&user.Email,
 		&user.Name,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -77,13 +100,11 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*model.User
 		return nil, err
 	}
 
-	// Все пользователи из БД имеют роль "user"
-	user.Role = model.RoleUser
 	return user, nil
 }
 
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
-	query := `SELECT id, email, name, password_hash, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT id, email, name, password_hash, role, created_at, updated_at FROM users WHERE email = $1`
 
 	user := &model.User{}
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
@@ -91,6 +112,7 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 		&user.Email,
 		&user.Name,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -102,7 +124,183 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 		return nil, err
 	}
 
-	// Все пользователи из БД имеют роль "user"
-	user.Role = model.RoleUser
 	return user, nil
 }
+
+func (r *UserRepository) UpdateUserRole(ctx context.Context, userID int64, role string) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE users SET role = $1, updated_at = $2 WHERE id = $3",
+		role,
+		time.Now(),
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *UserRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	token.CreatedAt = time.Now()
+	return r.db.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+}
+
+func (r *UserRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	token := &model.RefreshToken{}
+	var revokedAt sql.NullTime
+	var replacedBy sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&revokedAt,
+		&replacedBy,
+		&token.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	if replacedBy.Valid {
+		token.ReplacedBy = &replacedBy.Int64
+	}
+	return token, nil
+}
+
+// RotateRefreshToken в одной транзакции отзывает старый токен и сохраняет новый.
+// Если старый токен уже отозван (например, параллельным запросом), возвращает ErrRefreshTokenAlreadyRotated.
+func (r *UserRepository) RotateRefreshToken(ctx context.Context, oldID int64, newToken *model.RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	newToken.CreatedAt = now
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		newToken.UserID,
+		newToken.FamilyID,
+		newToken.TokenHash,
+		newToken.ExpiresAt,
+		newToken.CreatedAt,
+	).Scan(&newToken.ID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3 AND revoked_at IS NULL",
+		now,
+		newToken.ID,
+		oldID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRefreshTokenAlreadyRotated
+	}
+
+	return tx.Commit()
+}
+
+func (r *UserRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, time.Now(), familyID)
+	return err
+}
+
+func (r *UserRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, expires_at, revoked_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, jti, expiresAt, time.Now())
+	return err
+}
+
+func (r *UserRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&revoked)
+	return revoked, err
+}
+
+// PurgeExpiredTokens удаляет истекшие записи denylist access токенов, истекшие refresh токены и
+// цепочки refresh токенов, в которых не осталось действующего токена. Отозванный токен цепочки с
+// действующим токеном остается: по нему обнаруживается повторное использование. Возвращает число удаленных строк
+func (r *UserRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	revoked, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+	refresh, err := r.db.ExecContext(ctx, `
+		DELETE FROM refresh_tokens t
+		WHERE t.expires_at < $1
+			OR NOT EXISTS (
+				SELECT 1 FROM refresh_tokens a
+				WHERE a.family_id = t.family_id AND a.revoked_at IS NULL AND a.expires_at >= $1
+			)
+	`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, result := range []sql.Result{revoked, refresh} {
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		purged += affected
+	}
+	return purged, nil
+}
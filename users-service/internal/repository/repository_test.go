@@ -21,6 +21,16 @@ func setupTestDB(t *testing.T) *sql.DB {
 	// Используем существующую таблицу users
 	// Создаем её если не существует
 	createTable := `
+		CREATE TABLE IF NOT EXISTS roles (
+			name VARCHAR(32) PRIMARY KEY,
+			description VARCHAR(255) NOT NULL DEFAULT ''
+		);
+		CREATE TABLE IF NOT EXISTS role_permissions (
+			role VARCHAR(32) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+			permission VARCHAR(64) NOT NULL,
+			PRIMARY KEY (role, permission)
+		);
+		INSERT INTO roles (name, description) VALUES ('user', 'Покупатель') ON CONFLICT (name) DO NOTHING;
 		CREATE TABLE IF NOT EXISTS users (
 			id SERIAL PRIMARY KEY,
 			email VARCHAR(255) UNIQUE NOT NULL,
@@ -29,6 +39,22 @@ func setupTestDB(t *testing.T) *sql.DB {
 			created_at TIMESTAMP NOT NULL,
 			updated_at TIMESTAMP NOT NULL
 		);
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user' REFERENCES roles(name);
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INT NOT NULL,
+			family_id VARCHAR(64) NOT NULL,
+			token_hash VARCHAR(64) UNIQUE NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP,
+			replaced_by INT,
+			created_at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			jti VARCHAR(64) PRIMARY KEY,
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP NOT NULL
+		);
 	`
 	_, err = db.Exec(createTable)
 	require.NoError(t, err)
@@ -38,7 +64,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 
 // cleanupTestDB очищает тестовые данные
 func cleanupTestDB(t *testing.T, db *sql.DB) {
-	_, err := db.Exec("TRUNCATE TABLE users RESTART IDENTITY CASCADE")
+	_, err := db.Exec("TRUNCATE TABLE users, refresh_tokens, revoked_tokens RESTART IDENTITY CASCADE")
 	require.NoError(t, err)
 }
 
@@ -46,7 +72,7 @@ func cleanupTestDB(t *testing.T, db *sql.DB) {
 func setupTestDBWithCleanup(t *testing.T) *sql.DB {
 	db := setupTestDB(t)
 	// Очищаем таблицу перед тестом
-	_, err := db.Exec("TRUNCATE TABLE users RESTART IDENTITY CASCADE")
+	_, err := db.Exec("TRUNCATE TABLE users, refresh_tokens, revoked_tokens RESTART IDENTITY CASCADE")
 	require.NoError(t, err)
 	return db
 }
@@ -184,3 +210,165 @@ func TestGetUserByEmail_NotFound(t *testing.T) {
 	assert.Nil(t, user)
 }
 
+func TestRotateRefreshToken_Success(t *testing.T) {
+	db := setupTestDBWithCleanup(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+
+	repo := &UserRepository{db: db}
+	ctx := context.Background()
+
+	oldToken := &model.RefreshToken{
+		UserID:    1,
+		FamilyID:  "family-1",
+		TokenHash: "old-hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, repo.CreateRefreshToken(ctx, oldToken))
+
+	newToken := &model.RefreshToken{
+		UserID:    1,
+		FamilyID:  "family-1",
+		TokenHash: "new-hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	err := repo.RotateRefreshToken(ctx, oldToken.ID, newToken)
+	assert.NoError(t, err)
+
+	stored, err := repo.GetRefreshTokenByHash(ctx, "old-hash")
+	require.NoError(t, err)
+	assert.True(t, stored.IsRevoked())
+	require.NotNil(t, stored.ReplacedBy)
+	assert.Equal(t, newToken.ID, *stored.ReplacedBy)
+
+	// Повторная ротация того же токена должна быть обнаружена
+	err = repo.RotateRefreshToken(ctx, oldToken.ID, &model.RefreshToken{
+		UserID:    1,
+		FamilyID:  "family-1",
+		TokenHash: "another-hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, ErrRefreshTokenAlreadyRotated, err)
+}
+
+func TestRevokeRefreshTokenFamily(t *testing.T) {
+	db := setupTestDBWithCleanup(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+
+	repo := &UserRepository{db: db}
+	ctx := context.Background()
+
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		require.NoError(t, repo.CreateRefreshToken(ctx, &model.RefreshToken{
+			UserID:    1,
+			FamilyID:  "family-1",
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}))
+	}
+
+	err := repo.RevokeRefreshTokenFamily(ctx, "family-1")
+	assert.NoError(t, err)
+
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		stored, err := repo.GetRefreshTokenByHash(ctx, hash)
+		require.NoError(t, err)
+		assert.True(t, stored.IsRevoked())
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	db := setupTestDBWithCleanup(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+
+	repo := &UserRepository{db: db}
+	ctx := context.Background()
+
+	revoked, err := repo.IsAccessTokenRevoked(ctx, "jti-1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, repo.RevokeAccessToken(ctx, "jti-1", time.Now().Add(time.Hour)))
+	// Повторный отзыв не должен приводить к ошибке
+	assert.NoError(t, repo.RevokeAccessToken(ctx, "jti-1", time.Now().Add(time.Hour)))
+
+	revoked, err = repo.IsAccessTokenRevoked(ctx, "jti-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestPurgeExpiredTokens(t *testing.T) {
+	db := setupTestDBWithCleanup(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+
+	repo := &UserRepository{db: db}
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, repo.RevokeAccessToken(ctx, "expired", now.Add(-time.Minute)))
+	require.NoError(t, repo.RevokeAccessToken(ctx, "active", now.Add(time.Minute)))
+
+	rotated := &model.RefreshToken{UserID: 1, FamilyID: "live", TokenHash: "rotated", ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repo.CreateRefreshToken(ctx, rotated))
+	require.NoError(t, repo.RotateRefreshToken(ctx, rotated.ID, &model.RefreshToken{UserID: 1, FamilyID: "live", TokenHash: "current", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.CreateRefreshToken(ctx, &model.RefreshToken{UserID: 1, FamilyID: "old", TokenHash: "expired", ExpiresAt: now.Add(-time.Minute)}))
+	require.NoError(t, repo.CreateRefreshToken(ctx, &model.RefreshToken{UserID: 2, FamilyID: "logout", TokenHash: "logout", ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.RevokeRefreshTokenFamily(ctx, "logout"))
+
+	purged, err := repo.PurgeExpiredTokens(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+
+	revoked, err := repo.IsAccessTokenRevoked(ctx, "active")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	for hash, kept := range map[string]bool{"rotated": true, "current": true, "expired": false, "logout": false} {
+		token, err := repo.GetRefreshTokenByHash(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, kept, token != nil, hash)
+	}
+}
+
+func TestRoles_EnsureGetAndAssign(t *testing.T) {
+	db := setupTestDBWithCleanup(t)
+	defer db.Close()
+	defer cleanupTestDB(t, db)
+
+	repo := &UserRepository{db: db}
+	ctx := context.Background()
+
+	roles := []*model.Role{
+		{Name: "user", Description: "Покупатель"},
+		{Name: "courier", Description: "Курьер", Permissions: []string{"deliveries:read", "deliveries:update_status"}},
+	}
+	require.NoError(t, repo.EnsureRoles(ctx, roles))
+	// Повторный вызов не должен приводить к ошибке
+	require.NoError(t, repo.EnsureRoles(ctx, roles))
+
+	courier, err := repo.GetRole(ctx, "courier")
+	require.NoError(t, err)
+	require.NotNil(t, courier)
+	assert.Equal(t, []string{"deliveries:read", "deliveries:update_status"}, courier.Permissions)
+
+	missing, err := repo.GetRole(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	all, err := repo.ListRoles(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(all), 2)
+
+	user := &model.User{Email: "courier@example.com", Name: "Courier", PasswordHash: "hash"}
+	require.NoError(t, repo.CreateUser(ctx, user))
+	assert.Equal(t, model.RoleUser, user.Role)
+
+	require.NoError(t, repo.UpdateUserRole(ctx, user.ID, "courier"))
+	stored, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "courier", stored.Role)
+
+	assert.Equal(t, ErrUserNotFound, repo.UpdateUserRole(ctx, 99999, "courier"))
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+)
+
+// GetRole возвращает роль с её правами или nil, если роли нет
+func (r *UserRepository) GetRole(ctx context.Context, name string) (*model.Role, error) {
+	role := &model.Role{}
+	err := r.db.QueryRowContext(ctx, "SELECT name, description FROM roles WHERE name = $1", name).
+		Scan(&role.Name, &role.Description)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT permission FROM role_permissions WHERE role = $1 ORDER BY permission",
+		name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		role.Permissions = append(role.Permissions, permission)
+	}
+
+	return role, rows.Err()
+}
+
+func (r *UserRepository) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	query := `
+		SELECT r.name, r.description, rp.permission
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		ORDER BY r.name, rp.permission
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*model.Role
+	var current *model.Role
+	for rows.Next() {
+		var name, description string
+		var permission sql.NullString
+		if err := rows.Scan(&name, &description, &permission); err != nil {
+			return nil, err
+		}
+
+		if current == nil || current.Name != name {
+			current = &model.Role{Name: name, Description: description}
+			roles = append(roles, current)
+		}
+		if permission.Valid {
+			current.Permissions = append(current.Permissions, permission.String)
+		}
+	}
+
+	return roles, rows.Err()
+}
+
+// EnsureRoles создаёт отсутствующие роли и права. Существующие записи не изменяются,
+// поэтому права, настроенные в БД вручную, сохраняются между перезапусками
+func (r *UserRepository) EnsureRoles(ctx context.Context, roles []*model.Role) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, role := range roles {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO roles (name, description) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING",
+			role.Name,
+			role.Description,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, permission := range role.Permissions {
+			_, err := tx.ExecContext(
+				ctx,
+				"INSERT INTO role_permissions (role, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				role.Name,
+				permission,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SeedRoles заполняет роли по умолчанию (существующие права в БД не перезаписываются)
+func SeedRoles(ctx context.Context, repo UserRepositoryInterface) error {
+	roles := make([]*model.Role, 0, len(rbac.DefaultRoles))
+	for _, role := range rbac.DefaultRoles {
+		roles = append(roles, &model.Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+	return repo.EnsureRoles(ctx, roles)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+)
+
+// signingKeyRotationLockID - ключ advisory lock, чтобы несколько реплик не ротировали ключи одновременно
+const signingKeyRotationLockID = 72010027
+
+// SigningKeyRepositoryInterface определяет методы хранилища ключей подписи JWT
+type SigningKeyRepositoryInterface interface {
+	// ListSigningKeys возвращает активный ключ и ключи, выведенные из оборота после retiredAfter
+	ListSigningKeys(ctx context.Context, retiredAfter time.Time) ([]*model.SigningKey, error)
+	// RotateSigningKey сохраняет новый ключ и выводит из оборота предыдущий, если активный ключ создан раньше rotateBefore.
+	// Возвращает false, если ротация не требуется (например, её уже выполнила другая реплика).
+	RotateSigningKey(ctx context.Context, newKey *model.SigningKey, rotateBefore time.Time) (bool, error)
+	DeleteSigningKeysRetiredBefore(ctx context.Context, before time.Time) error
+}
+
+type SigningKeyRepository struct {
+	db *sql.DB
+}
+
+func NewSigningKeyRepository(db *sql.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+func (r *SigningKeyRepository) ListSigningKeys(ctx context.Context, retiredAfter time.Time) ([]*model.SigningKey, error) {
+	query := `
+		SELECT kid, algorithm, private_key_pem, public_key_pem, created_at, retired_at
+		FROM signing_keys
+		WHERE retired_at IS NULL OR retired_at > $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, retiredAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*model.SigningKey
+	for rows.Next() {
+		key := &model.SigningKey{}
+		var retiredAt sql.NullTime
+		if err := rows.Scan(
+			&key.KID,
+			&key.Algorithm,
+			&key.PrivateKeyPEM,
+			&key.PublicKeyPEM,
+			&key.CreatedAt,
+			&retiredAt,
+		); err != nil {
+			return nil, err
+		}
+		if retiredAt.Valid {
+			key.RetiredAt = &retiredAt.Time
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *SigningKeyRepository) RotateSigningKey(ctx context.Context, newKey *model.SigningKey, rotateBefore time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", signingKeyRotationLockID); err != nil {
+		return false, err
+	}
+
+	// Под блокировкой повторно проверяем, нужна ли ротация
+	var activeCreatedAt sql.NullTime
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT MAX(created_at) FROM signing_keys WHERE retired_at IS NULL AND algorithm = $1",
+		newKey.Algorithm,
+	).Scan(&activeCreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if activeCreatedAt.Valid && activeCreatedAt.Time.After(rotateBefore) {
+		return false, nil
+	}
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, "UPDATE signing_keys SET retired_at = $1 WHERE retired_at IS NULL", now); err != nil {
+		return false, err
+	}
+
+	newKey.CreatedAt = now
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO signing_keys (kid, algorithm, private_key_pem, public_key_pem, created_at)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		newKey.KID,
+		newKey.Algorithm,
+		newKey.PrivateKeyPEM,
+		newKey.PublicKeyPEM,
+		newKey.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *SigningKeyRepository) DeleteSigningKeysRetiredBefore(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM signing_keys WHERE retired_at IS NOT NULL AND retired_at < $1", before)
+	return err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/che1nov/tea-shop/users-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSigningKeysTable(t *testing.T) *SigningKeyRepository {
+	db := setupTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS signing_keys (
+			kid VARCHAR(64) PRIMARY KEY,
+			algorithm VARCHAR(16) NOT NULL,
+			private_key_pem TEXT NOT NULL,
+			public_key_pem TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			retired_at TIMESTAMP
+		);
+		TRUNCATE TABLE signing_keys;
+	`)
+	require.NoError(t, err)
+
+	return NewSigningKeyRepository(db)
+}
+
+func TestRotateSigningKey(t *testing.T) {
+	repo := setupSigningKeysTable(t)
+	ctx := context.Background()
+
+	first := &model.SigningKey{KID: "kid-1", Algorithm: model.SigningAlgorithmEdDSA, PrivateKeyPEM: "priv", PublicKeyPEM: "pub"}
+	rotated, err := repo.RotateSigningKey(ctx, first, time.Now())
+	require.NoError(t, err)
+	assert.True(t, rotated)
+
+	// Активный ключ свежий - повторная ротация не выполняется
+	second := &model.SigningKey{KID: "kid-2", Algorithm: model.SigningAlgorithmEdDSA, PrivateKeyPEM: "priv", PublicKeyPEM: "pub"}
+	rotated, err = repo.RotateSigningKey(ctx, second, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, rotated)
+
+	rotated, err = repo.RotateSigningKey(ctx, second, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, rotated)
+
+	keys, err := repo.ListSigningKeys(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "kid-2", keys[0].KID)
+	assert.Nil(t, keys[0].RetiredAt)
+	assert.NotNil(t, keys[1].RetiredAt)
+
+	require.NoError(t, repo.DeleteSigningKeysRetiredBefore(ctx, time.Now().Add(time.Second)))
+	keys, err = repo.ListSigningKeys(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "kid-2", keys[0].KID)
+}
@@ -2,44 +2,101 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/che1nov/tea-shop/shared/pkg/logger"
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+	"github.com/che1nov/tea-shop/users-service/internal/keys"
 	"github.com/che1nov/tea-shop/users-service/internal/model"
 	"github.com/che1nov/tea-shop/users-service/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var ErrEmailAlreadyExists = repository.ErrEmailAlreadyExists
+var (
+	ErrEmailAlreadyExists  = repository.ErrEmailAlreadyExists
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrInvalidAccessToken  = errors.New("invalid access token")
+	ErrUserNotFound        = repository.ErrUserNotFound
+	ErrRoleNotFound        = errors.New("role not found")
+	ErrPasswordTooShort    = errors.New("password must be at least 8 characters")
+	ErrPermissionDenied    = errors.New("permission denied")
+)
+
+// minAdminPasswordLength - минимальная длина пароля администратора
+const minAdminPasswordLength = 8
 
 // UserServiceInterface определяет методы сервиса
 type UserServiceInterface interface {
 	CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.User, error)
+	CreateAdmin(ctx context.Context, actorID int64, req *model.CreateUserRequest) (*model.User, error)
 	GetUser(ctx context.Context, id int64) (*model.User, error)
 	GenerateToken(user *model.User) (string, error)
 	ValidateToken(tokenString string) (int64, string, error)
 	ValidateTokenWithRole(tokenString string) (int64, string, string, error)
-	Login(ctx context.Context, email, password string) (string, error)
+	ParseAccessToken(tokenString string) (*model.AccessClaims, error)
+	Login(ctx context.Context, email, password string) (*model.TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*model.TokenPair, error)
+	Logout(ctx context.Context, accessToken, refreshToken string) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	Authorize(ctx context.Context, accessToken, permission string) (int64, error)
+	ListRoles(ctx context.Context) ([]*model.Role, error)
+	AssignRole(ctx context.Context, actorID, userID int64, role string) (*model.User, error)
+}
+
+// TokenSigner подписывает токены и возвращает ключи для их проверки (реализуется keys.Manager)
+type TokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
+	Keyfunc(token *jwt.Token) (interface{}, error)
 }
 
 type UserService struct {
-	repo          repository.UserRepositoryInterface
-	jwtSecret     string
-	adminEmail    string
-	adminPassword string
+	repo            repository.UserRepositoryInterface
+	signer          TokenSigner
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
 }
 
-func New(repo repository.UserRepositoryInterface, jwtSecret string, adminEmail, adminPassword string) *UserService {
+func New(
+	repo repository.UserRepositoryInterface,
+	signer TokenSigner,
+	accessTokenTTL, refreshTokenTTL time.Duration,
+) *UserService {
 	return &UserService{
-		repo:          repo,
-		jwtSecret:     jwtSecret,
-		adminEmail:    adminEmail,
-		adminPassword: adminPassword,
+		repo:            repo,
+		signer:          signer,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.User, error) {
+	// Все создаваемые через API пользователи имеют роль "user"
+	return s.createUser(ctx, req, model.RoleUser)
+}
+
+// CreateAdmin создает учётную запись администратора от имени actorID; 0 - команда `users-service admin create`
+func (s *UserService) CreateAdmin(ctx context.Context, actorID int64, req *model.CreateUserRequest) (*model.User, error) {
+	if len(req.Password) < minAdminPasswordLength {
+		return nil, ErrPasswordTooShort
+	}
+	user, err := s.createUser(ctx, req, model.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "Admin created", "target_user_id", user.ID, "created_by", actorID)
+	return user, nil
+}
+
+func (s *UserService) createUser(ctx context.Context, req *model.CreateUserRequest, role string) (*model.User, error) {
 	// Хешируем пароль
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -50,7 +107,7 @@ func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserReque
 		Email:        req.Email,
 		Name:         req.Name,
 		PasswordHash: string(hash),
-		Role:         model.RoleUser, // Все создаваемые пользователи имеют роль "user"
+		Role:         role,
 	}
 
 	if err := s.repo.CreateUser(ctx, user); err != nil {
@@ -61,18 +118,57 @@ func (s *UserService) CreateUser(ctx context.Context, req *model.CreateUserReque
 }
 
 func (s *UserService) GetUser(ctx context.Context, id int64) (*model.User, error) {
-	return s.repo.GetUserByID(ctx, id)
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	if err := s.loadPermissions(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
+// ListRoles возвращает все роли с их правами
+func (s *UserService) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	return s.repo.ListRoles(ctx)
+}
+
+// AssignRole назначает пользователю роль от имени actorID. Новые права попадут в токен при следующем входе или обновлении токена
+func (s *UserService) AssignRole(ctx context.Context, actorID, userID int64, role string) (*model.User, error) {
+	existing, err := s.repo.GetRole(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	if err := s.repo.UpdateUserRole(ctx, userID, role); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "User role changed", "target_user_id", userID, "role", role, "changed_by", actorID)
+	return s.GetUser(ctx, userID)
+}
+
+// GenerateToken выпускает короткоживущий access токен с уникальным jti
 func (s *UserService) GenerateToken(user *model.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"role":    user.Role, // Добавляем роль в токен
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
+	jti, err := generateRandomString(16)
+	if err != nil {
+		return "", err
+	}
 
-	return token.SignedString([]byte(s.jwtSecret))
+	now := time.Now()
+	return s.signer.Sign(jwt.MapClaims{
+		"user_id":     user.ID,
+		"email":       user.Email,
+		"role":        user.Role,
+		"permissions": user.Permissions,
+		"jti":         jti,
+		"iat":         now.Unix(),
+		"exp":         now.Add(s.accessTokenTTL).Unix(),
+	})
 }
 
 func (s *UserService) ValidateToken(tokenString string) (int64, string, error) {
@@ -82,95 +178,321 @@ func (s *UserService) ValidateToken(tokenString string) (int64, string, error) {
 
 // ValidateTokenWithRole возвращает user_id, email и role из токена
 func (s *UserService) ValidateTokenWithRole(tokenString string) (int64, string, string, error) {
+	claims, err := s.ParseAccessToken(tokenString)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return claims.UserID, claims.Email, claims.Role, nil
+}
+
+// ParseAccessToken проверяет подпись access токена и возвращает его claims
+func (s *UserService) ParseAccessToken(tokenString string) (*model.AccessClaims, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		jwt.MapClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(s.jwtSecret), nil
-		},
+		s.signer.Keyfunc,
+		jwt.WithValidMethods(keys.SupportedAlgorithms),
 	)
 
 	if err != nil {
-		return 0, "", "", err
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return 0, "", "", jwt.ErrSignatureInvalid
+		return nil, jwt.ErrSignatureInvalid
 	}
 
 	// Безопасное извлечение user_id
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
-		return 0, "", "", jwt.ErrSignatureInvalid
+		return nil, jwt.ErrSignatureInvalid
 	}
-	userID := int64(userIDFloat)
 
 	// Безопасное извлечение email
 	email, ok := claims["email"].(string)
 	if !ok {
-		return 0, "", "", jwt.ErrSignatureInvalid
+		return nil, jwt.ErrSignatureInvalid
 	}
 
-	// Безопасное извлечение role (если есть, иначе "user")
-	role := model.RoleUser
+	result := &model.AccessClaims{
+		UserID: int64(userIDFloat),
+		Email:  email,
+		Role:   model.RoleUser, // Если роли нет в токене, считаем пользователя обычным
+	}
 	if roleVal, ok := claims["role"].(string); ok {
-		role = roleVal
+		result.Role = roleVal
+	}
+	if permissions, ok := claims["permissions"].([]interface{}); ok {
+		for _, p := range permissions {
+			if permission, ok := p.(string); ok {
+				result.Permissions = append(result.Permissions, permission)
+			}
+		}
+	}
+	if jti, ok := claims["jti"].(string); ok {
+		result.JTI = jti
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		result.ExpiresAt = exp.Time
+	}
+
+	return result, nil
+}
+
+func (s *UserService) Login(ctx context.Context, email, password string) (*model.TokenPair, error) {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadPermissions(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.issueTokenPair(ctx, user, "")
+}
+
+// RefreshToken обменивает refresh токен на новую пару токенов (ротация).
+// Повторное использование уже обменянного токена отзывает всю цепочку.
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.IsRevoked() {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+
+	if stored.IsExpired(time.Now()) {
+		return nil, ErrRefreshTokenExpired
+	}
+
+	user, err := s.tokenSubject(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rawToken, newToken, err := s.newRefreshToken(user.ID, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RotateRefreshToken(ctx, stored.ID, newToken); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenAlreadyRotated) {
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, err
+	}
+
+	accessToken, err := s.GenerateToken(user)
+	if err != nil {
+		return nil, err
 	}
 
-	return userID, email, role, nil
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawToken,
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+	}, nil
 }
 
-func (s *UserService) Login(ctx context.Context, email, password string) (string, error) {
-	// Проверяем админские credentials
-	// Временное логирование для отладки
-	if s.adminEmail == "" || s.adminPassword == "" {
-		// Если переменные окружения не установлены, используем значения по умолчанию
-		if s.adminEmail == "" {
-			s.adminEmail = "admin@example.com"
+// Logout отзывает access токен (добавляет jti в denylist) и, если передан, всю цепочку refresh токена
+func (s *UserService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	claims, err := s.ParseAccessToken(accessToken)
+	if err != nil {
+		return ErrInvalidAccessToken
+	}
+
+	if claims.JTI != "" {
+		if err := s.repo.RevokeAccessToken(ctx, claims.JTI, claims.ExpiresAt); err != nil {
+			return err
 		}
-		if s.adminPassword == "" {
-			s.adminPassword = "admin123"
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	// Не позволяем отозвать чужую цепочку токенов
+	if stored == nil || stored.UserID != claims.UserID {
+		return nil
+	}
+
+	return s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+}
+
+// IsTokenRevoked проверяет, находится ли jti access токена в denylist
+func (s *UserService) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	return s.repo.IsAccessTokenRevoked(ctx, jti)
+}
+
+// PurgeExpiredTokens удаляет из хранилища токены, которые больше не нужны для проверок
+func (s *UserService) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	return s.repo.PurgeExpiredTokens(ctx, time.Now())
+}
+
+// RunTokenCleanup раз в interval удаляет истекшие и отозванные токены, пока не отменен ctx
+func (s *UserService) RunTokenCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpiredTokens(ctx)
+			if err != nil {
+				logger.Error("Failed to purge expired tokens", "error", err)
+				continue
+			}
+			if purged > 0 {
+				logger.Info("Expired tokens purged", "count", purged)
+			}
 		}
 	}
-	
-	// Логирование для отладки (только при попытке входа с админским email)
-	if email == s.adminEmail {
-		logger.Info("Admin login attempt", 
-			"input_email", email, 
-			"config_email", s.adminEmail,
-			"email_match", email == s.adminEmail, 
-			"input_password_len", len(password),
-			"config_password_len", len(s.adminPassword),
-			"password_match", password == s.adminPassword)
-	}
-	
-	if email == s.adminEmail && password == s.adminPassword {
-		// Создаем виртуального пользователя-админа для токена
-		adminUser := &model.User{
-			ID:    0, // Специальный ID для админа
-			Email: email,
-			Name:  "Administrator",
-			Role:  model.RoleAdmin,
+}
+
+// Authorize проверяет access токен вызывающего, его отзыв и право permission. Возвращает id
+// пользователя из токена: автор действия берется из токена, а не из запроса
+func (s *UserService) Authorize(ctx context.Context, accessToken, permission string) (int64, error) {
+	claims, err := s.ParseAccessToken(accessToken)
+	if err != nil {
+		return 0, ErrInvalidAccessToken
+	}
+	revoked, err := s.IsTokenRevoked(ctx, claims.JTI)
+	if err != nil {
+		return 0, err
+	}
+	if revoked {
+		return 0, ErrInvalidAccessToken
+	}
+	if !rbac.HasPermission(claims.Permissions, permission) {
+		return 0, ErrPermissionDenied
+	}
+	return claims.UserID, nil
+}
+
+// issueTokenPair выпускает access токен и новый refresh токен.
+// Пустой familyID означает начало новой цепочки ротации.
+func (s *UserService) issueTokenPair(ctx context.Context, user *model.User, familyID string) (*model.TokenPair, error) {
+	accessToken, err := s.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if familyID == "" {
+		familyID, err = generateRandomString(16)
+		if err != nil {
+			return nil, err
 		}
-		return s.GenerateToken(adminUser)
 	}
 
-	// Обычный пользователь
-	user, err := s.repo.GetUserByEmail(ctx, email)
+	rawToken, refreshToken, err := s.newRefreshToken(user.ID, familyID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if user == nil {
-		return "", jwt.ErrSignatureInvalid
+	if err := s.repo.CreateRefreshToken(ctx, refreshToken); err != nil {
+		return nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawToken,
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// newRefreshToken генерирует случайный refresh токен и его запись для БД (хранится только хеш)
+func (s *UserService) newRefreshToken(userID int64, familyID string) (string, *model.RefreshToken, error) {
+	rawToken, err := generateRandomString(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rawToken, &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}, nil
+}
+
+// revokeReusedFamily отзывает всю цепочку, если кто-то предъявил уже использованный refresh токен
+func (s *UserService) revokeReusedFamily(ctx context.Context, token *model.RefreshToken) error {
+	logger.WarnContext(ctx, "Refresh token reuse detected, revoking token family",
+		"user_id", token.UserID,
+		"family_id", token.FamilyID)
+
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// tokenSubject возвращает пользователя, для которого выпускаются токены.
+// Роль и права перечитываются из БД, поэтому их изменение применяется при обновлении токена
+func (s *UserService) tokenSubject(ctx context.Context, userID int64) (*model.User, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	if err := s.loadPermissions(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// loadPermissions заполняет права пользователя по его роли
+func (s *UserService) loadPermissions(ctx context.Context, user *model.User) error {
+	role, err := s.repo.GetRole(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		// Неизвестная роль не даёт никаких прав
+		user.Permissions = nil
+		return nil
+	}
+	user.Permissions = role.Permissions
+	return nil
+}
+
+// generateRandomString возвращает криптографически случайную строку в base64url
+func generateRandomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
 		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
 
-	// Убеждаемся, что у обычного пользователя роль "user"
-	user.Role = model.RoleUser
-	return s.GenerateToken(user)
+// hashToken возвращает SHA-256 хеш токена; в БД хранятся только хеши
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
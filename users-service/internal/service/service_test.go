@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/che1nov/tea-shop/shared/pkg/rbac"
+	"github.com/che1nov/tea-shop/users-service/internal/keys"
 	"github.com/che1nov/tea-shop/users-service/internal/model"
+	"github.com/che1nov/tea-shop/users-service/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // MockRepository - мок для репозитория, реализует UserRepositoryInterface
@@ -40,20 +46,103 @@ func (m *MockRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
+func (m *MockRepository) UpdateUserRole(ctx context.Context, userID int64, role string) error {
+	args := m.Called(ctx, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRole(ctx context.Context, name string) (*model.Role, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Role), args.Error(1)
+}
+
+func (m *MockRepository) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Role), args.Error(1)
+}
+
+func (m *MockRepository) EnsureRoles(ctx context.Context, roles []*model.Role) error {
+	args := m.Called(ctx, roles)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRepository) RotateRefreshToken(ctx context.Context, oldID int64, newToken *model.RefreshToken) error {
+	args := m.Called(ctx, oldID, newToken)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// newTestSigner создает менеджер ключей с хранилищем в памяти
+func newTestSigner() *keys.Manager {
+	manager, err := keys.NewManager(keys.NewMemoryStore(), model.SigningAlgorithmEdDSA, time.Hour, time.Hour)
+	if err != nil {
+		panic(err)
+	}
+	if err := manager.Init(context.Background()); err != nil {
+		panic(err)
+	}
+	return manager
+}
+
+// newTestService создает сервис с тестовыми TTL токенов и собственным ключом подписи
+func newTestService(repo *MockRepository) *UserService {
+	return New(repo, newTestSigner(), 15*time.Minute, 24*time.Hour)
+}
+
 func TestNew(t *testing.T) {
 	mockRepo := new(MockRepository)
-	jwtSecret := "test-secret"
+	signer := newTestSigner()
 
-	service := New(mockRepo, jwtSecret)
+	service := New(mockRepo, signer, 15*time.Minute, 24*time.Hour)
 
 	assert.NotNil(t, service)
 	assert.Equal(t, mockRepo, service.repo)
-	assert.Equal(t, jwtSecret, service.jwtSecret)
+	assert.Equal(t, signer, service.signer)
+	assert.Equal(t, 15*time.Minute, service.accessTokenTTL)
+	assert.Equal(t, 24*time.Hour, service.refreshTokenTTL)
 }
 
 func TestCreateUser_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := New(mockRepo, "test-secret")
+	service := newTestService(mockRepo)
 	ctx := context.Background()
 
 	req := &model.CreateUserRequest{
@@ -80,7 +169,7 @@ func TestCreateUser_Success(t *testing.T) {
 
 func TestCreateUser_RepositoryError(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := New(mockRepo, "test-secret")
+	service := newTestService(mockRepo)
 	ctx := context.Background()
 
 	req := &model.CreateUserRequest{
@@ -100,7 +189,7 @@ func TestCreateUser_RepositoryError(t *testing.T) {
 
 func TestGetUser_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := New(mockRepo, "test-secret")
+	service := newTestService(mockRepo)
 	ctx := context.Background()
 
 	expectedUser := &model.User{
@@ -108,9 +197,11 @@ func TestGetUser_Success(t *testing.T) {
 		Email:        "test@example.com",
 		Name:         "Test User",
 		PasswordHash: "hashed_password",
+		Role:         model.RoleUser,
 	}
 
 	mockRepo.On("GetUserByID", ctx, int64(1)).Return(expectedUser, nil)
+	mockRepo.On("GetRole", ctx, model.RoleUser).Return(&model.Role{Name: model.RoleUser}, nil)
 
 	user, err := service.GetUser(ctx, 1)
 
@@ -121,7 +212,7 @@ func TestGetUser_Success(t *testing.T) {
 }
 
 func TestGenerateToken_Success(t *testing.T) {
-	service := New(new(MockRepository), "test-secret-key")
+	service := newTestService(new(MockRepository))
 
 	user := &model.User{
 		ID:    1,
@@ -134,13 +225,13 @@ func TestGenerateToken_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 
-	// Проверяем, что токен можно распарсить
-	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return []byte("test-secret-key"), nil
-	})
+	// Проверяем, что токен подписан асимметричным ключом и содержит kid
+	parsedToken, err := jwt.Parse(token, service.signer.Keyfunc)
 
 	assert.NoError(t, err)
 	assert.True(t, parsedToken.Valid)
+	assert.Equal(t, model.SigningAlgorithmEdDSA, parsedToken.Method.Alg())
+	assert.NotEmpty(t, parsedToken.Header["kid"])
 
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
 	assert.True(t, ok)
@@ -149,7 +240,7 @@ func TestGenerateToken_Success(t *testing.T) {
 }
 
 func TestValidateToken_ValidToken(t *testing.T) {
-	service := New(new(MockRepository), "test-secret-key")
+	service := newTestService(new(MockRepository))
 
 	user := &model.User{
 		ID:    1,
@@ -169,7 +260,7 @@ func TestValidateToken_ValidToken(t *testing.T) {
 }
 
 func TestValidateToken_InvalidToken(t *testing.T) {
-	service := New(new(MockRepository), "test-secret-key")
+	service := newTestService(new(MockRepository))
 
 	userID, email, err := service.ValidateToken("invalid-token")
 
@@ -178,20 +269,20 @@ func TestValidateToken_InvalidToken(t *testing.T) {
 	assert.Empty(t, email)
 }
 
-func TestValidateToken_WrongSecret(t *testing.T) {
-	service1 := New(new(MockRepository), "secret1")
-	service2 := New(new(MockRepository), "secret2")
+func TestValidateToken_WrongKey(t *testing.T) {
+	service1 := newTestService(new(MockRepository))
+	service2 := newTestService(new(MockRepository))
 
 	user := &model.User{
 		ID:    1,
 		Email: "test@example.com",
 	}
 
-	// Генерируем токен с одним секретом
+	// Генерируем токен одним ключом
 	token, err := service1.GenerateToken(user)
 	assert.NoError(t, err)
 
-	// Пытаемся валидировать с другим секретом
+	// Пытаемся валидировать набором ключей другого сервиса
 	userID, email, err := service2.ValidateToken(token)
 
 	assert.Error(t, err)
@@ -199,9 +290,26 @@ func TestValidateToken_WrongSecret(t *testing.T) {
 	assert.Empty(t, email)
 }
 
+func TestValidateToken_RejectsHMACToken(t *testing.T) {
+	service := newTestService(new(MockRepository))
+
+	// Токен, подписанный общим секретом, не должен приниматься даже с корректным kid
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"email":   "test@example.com",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = service.signer.(*keys.Manager).JWKS().Keys[0].Kid
+	tokenString, err := token.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	_, _, err = service.ValidateToken(tokenString)
+	assert.Error(t, err)
+}
+
 func TestLogin_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := New(mockRepo, "test-secret")
+	service := newTestService(mockRepo)
 	ctx := context.Background()
 
 	password := "password123"
@@ -215,6 +323,7 @@ func TestLogin_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetUserByEmail", ctx, "test@example.com").Return(user, nil)
+	mockRepo.On("GetRole", ctx, mock.Anything).Return(&model.Role{Name: model.RoleUser}, nil).Maybe()
 
 	// Для реального теста нужен настоящий bcrypt hash
 	// Здесь мы используем мок, поэтому просто проверяем структуру
@@ -232,7 +341,7 @@ func TestLogin_Success(t *testing.T) {
 
 func TestLogin_UserNotFound(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := New(mockRepo, "test-secret")
+	service := newTestService(mockRepo)
 	ctx := context.Background()
 
 	mockRepo.On("GetUserByEmail", ctx, "notfound@example.com").Return(nil, nil)
@@ -243,3 +352,395 @@ func TestLogin_UserNotFound(t *testing.T) {
 	assert.Empty(t, token)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestLogin_IssuesRefreshToken(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	user := &model.User{ID: 1, Email: "test@example.com", PasswordHash: string(hash), Role: "warehouse"}
+	mockRepo.On("GetUserByEmail", ctx, "test@example.com").Return(user, nil)
+	mockRepo.On("GetRole", ctx, "warehouse").Return(&model.Role{
+		Name:        "warehouse",
+		Permissions: []string{"goods:write", "stock:write"},
+	}, nil)
+	var stored *model.RefreshToken
+	mockRepo.On("CreateRefreshToken", ctx, mock.AnythingOfType("*model.RefreshToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*model.RefreshToken) }).
+		Return(nil)
+
+	tokens, err := service.Login(ctx, "test@example.com", "password123")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, int64(900), tokens.ExpiresIn)
+
+	// Роль и права попадают в access токен
+	claims, err := service.ParseAccessToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "warehouse", claims.Role)
+	assert.Equal(t, []string{"goods:write", "stock:write"}, claims.Permissions)
+
+	// В БД сохраняется только хеш refresh токена
+	assert.Equal(t, hashToken(tokens.RefreshToken), stored.TokenHash)
+	assert.NotEqual(t, tokens.RefreshToken, stored.TokenHash)
+	assert.NotEmpty(t, stored.FamilyID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefreshToken_Rotates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	stored := &model.RefreshToken{
+		ID:        10,
+		UserID:    1,
+		FamilyID:  "family-1",
+		TokenHash: hashToken("old-refresh"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockRepo.On("GetRefreshTokenByHash", ctx, hashToken("old-refresh")).Return(stored, nil)
+	mockRepo.On("GetUserByID", ctx, int64(1)).Return(&model.User{ID: 1, Email: "test@example.com", Role: model.RoleUser}, nil)
+	mockRepo.On("GetRole", ctx, model.RoleUser).Return(&model.Role{Name: model.RoleUser}, nil)
+	mockRepo.On("RotateRefreshToken", ctx, int64(10), mock.MatchedBy(func(token *model.RefreshToken) bool {
+		return token.FamilyID == "family-1" && token.UserID == 1
+	})).Return(nil)
+
+	tokens, err := service.RefreshToken(ctx, "old-refresh")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEqual(t, "old-refresh", tokens.RefreshToken)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	revokedAt := time.Now().Add(-time.Minute)
+	stored := &model.RefreshToken{
+		ID:        10,
+		UserID:    1,
+		FamilyID:  "family-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+		RevokedAt: &revokedAt,
+	}
+	mockRepo.On("GetRefreshTokenByHash", ctx, hashToken("reused")).Return(stored, nil)
+	mockRepo.On("RevokeRefreshTokenFamily", ctx, "family-1").Return(nil)
+
+	tokens, err := service.RefreshToken(ctx, "reused")
+
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.Nil(t, tokens)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefreshToken_ConcurrentRotationRevokesFamily(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	stored := &model.RefreshToken{ID: 10, UserID: 1, FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}
+	mockRepo.On("GetRefreshTokenByHash", ctx, hashToken("raced")).Return(stored, nil)
+	mockRepo.On("GetUserByID", ctx, int64(1)).Return(&model.User{ID: 1, Email: "test@example.com", Role: model.RoleUser}, nil)
+	mockRepo.On("GetRole", ctx, model.RoleUser).Return(&model.Role{Name: model.RoleUser}, nil)
+	mockRepo.On("RotateRefreshToken", ctx, int64(10), mock.AnythingOfType("*model.RefreshToken")).Return(repository.ErrRefreshTokenAlreadyRotated)
+	mockRepo.On("RevokeRefreshTokenFamily", ctx, "family-1").Return(nil)
+
+	_, err := service.RefreshToken(ctx, "raced")
+
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefreshToken_Expired(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	stored := &model.RefreshToken{ID: 10, UserID: 1, FamilyID: "family-1", ExpiresAt: time.Now().Add(-time.Hour)}
+	mockRepo.On("GetRefreshTokenByHash", ctx, hashToken("expired")).Return(stored, nil)
+
+	_, err := service.RefreshToken(ctx, "expired")
+
+	assert.ErrorIs(t, err, ErrRefreshTokenExpired)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRefreshToken_Unknown(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetRefreshTokenByHash", ctx, hashToken("unknown")).Return(nil, nil)
+
+	_, err := service.RefreshToken(ctx, "unknown")
+
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogout_RevokesAccessTokenAndFamily(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	accessToken, err := service.GenerateToken(&model.User{ID: 1, Email: "test@example.com"})
+	assert.NoError(t, err)
+	claims, err := service.ParseAccessToken(accessToken)
+	assert.NoError(t, err)
+
+	stored := &model.RefreshToken{ID: 10, UserID: 1, FamilyID: "family-1"}
+	mockRepo.On("RevokeAccessToken", ctx, claims.JTI, claims.ExpiresAt).Return(nil)
+	mockRepo.On("GetRefreshTokenByHash", ctx, hashToken("refresh")).Return(stored, nil)
+	mockRepo.On("RevokeRefreshTokenFamily", ctx, "family-1").Return(nil)
+
+	err = service.Logout(ctx, accessToken, "refresh")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogout_ForeignRefreshTokenIgnored(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	accessToken, err := service.GenerateToken(&model.User{ID: 1, Email: "test@example.com"})
+	assert.NoError(t, err)
+
+	mockRepo.On("RevokeAccessToken", ctx, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("GetRefreshTokenByHash", ctx, hashToken("foreign")).Return(&model.RefreshToken{UserID: 2, FamilyID: "other"}, nil)
+
+	err = service.Logout(ctx, accessToken, "foreign")
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "RevokeRefreshTokenFamily", mock.Anything, mock.Anything)
+}
+
+func TestLogout_InvalidAccessToken(t *testing.T) {
+	service := newTestService(new(MockRepository))
+
+	err := service.Logout(context.Background(), "invalid-token", "")
+
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("IsAccessTokenRevoked", ctx, "jti-1").Return(true, nil)
+
+	revoked, err := service.IsTokenRevoked(ctx, "jti-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Токены без jti не проверяются по denylist
+	revoked, err = service.IsTokenRevoked(ctx, "")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPurgeExpiredTokens(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	before := time.Now()
+	mockRepo.On("PurgeExpiredTokens", ctx, mock.MatchedBy(func(now time.Time) bool {
+		return !now.Before(before)
+	})).Return(int64(3), nil)
+
+	purged, err := service.PurgeExpiredTokens(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRunTokenCleanup(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	purged := make(chan struct{}, 1)
+	mockRepo.On("PurgeExpiredTokens", mock.Anything, mock.Anything).Return(int64(1), nil).Run(func(mock.Arguments) {
+		select {
+		case purged <- struct{}{}:
+		default:
+		}
+	})
+
+	go service.RunTokenCleanup(ctx, 10*time.Millisecond)
+	select {
+	case <-purged:
+	case <-time.After(time.Second):
+		t.Fatal("tokens were not purged")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	admin, err := service.GenerateToken(&model.User{ID: 7, Email: "admin@example.com", Role: model.RoleAdmin, Permissions: []string{rbac.PermUsersManageRoles}})
+	require.NoError(t, err)
+	support, err := service.GenerateToken(&model.User{ID: 8, Email: "support@example.com", Role: rbac.RoleSupport, Permissions: []string{rbac.PermOrdersRead}})
+	require.NoError(t, err)
+	mockRepo.On("IsAccessTokenRevoked", ctx, mock.Anything).Return(false, nil)
+
+	actorID, err := service.Authorize(ctx, admin, rbac.PermUsersManageRoles)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), actorID)
+
+	_, err = service.Authorize(ctx, support, rbac.PermUsersManageRoles)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, err = service.Authorize(ctx, "invalid-token", rbac.PermUsersManageRoles)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestAuthorize_RevokedToken(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	token, err := service.GenerateToken(&model.User{ID: 7, Email: "admin@example.com", Role: model.RoleAdmin, Permissions: []string{rbac.PermUsersManageRoles}})
+	require.NoError(t, err)
+	mockRepo.On("IsAccessTokenRevoked", ctx, mock.Anything).Return(true, nil)
+
+	_, err = service.Authorize(ctx, token, rbac.PermUsersManageRoles)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestAssignRole_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	courier := &model.Role{Name: "courier", Permissions: []string{"deliveries:read", "deliveries:update_status"}}
+	mockRepo.On("GetRole", ctx, "courier").Return(courier, nil)
+	mockRepo.On("UpdateUserRole", ctx, int64(1), "courier").Return(nil)
+	mockRepo.On("GetUserByID", ctx, int64(1)).Return(&model.User{ID: 1, Email: "test@example.com", Role: "courier"}, nil)
+
+	user, err := service.AssignRole(ctx, 7, 1, "courier")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "courier", user.Role)
+	assert.Equal(t, courier.Permissions, user.Permissions)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAssignRole_UnknownRole(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetRole", ctx, "superuser").Return(nil, nil)
+
+	_, err := service.AssignRole(ctx, 7, 1, "superuser")
+
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	mockRepo.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAssignRole_UserNotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetRole", ctx, "support").Return(&model.Role{Name: "support"}, nil)
+	mockRepo.On("UpdateUserRole", ctx, int64(42), "support").Return(repository.ErrUserNotFound)
+
+	_, err := service.AssignRole(ctx, 7, 42, "support")
+
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAdmin_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("CreateUser", ctx, mock.MatchedBy(func(user *model.User) bool {
+		return user.Role == model.RoleAdmin &&
+			bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("strong-password")) == nil
+	})).Return(nil)
+
+	user, err := service.CreateAdmin(ctx, 7, &model.CreateUserRequest{
+		Email:    "admin@example.com",
+		Name:     "Admin",
+		Password: "strong-password",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), user.ID)
+	assert.Equal(t, model.RoleAdmin, user.Role)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAdmin_ShortPassword(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+
+	_, err := service.CreateAdmin(context.Background(), 0, &model.CreateUserRequest{
+		Email:    "admin@example.com",
+		Password: "short",
+	})
+
+	assert.ErrorIs(t, err, ErrPasswordTooShort)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+func TestLogin_AdminFromDatabase(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("strong-password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	admin := &model.User{ID: 5, Email: "admin@example.com", PasswordHash: string(hash), Role: model.RoleAdmin}
+	mockRepo.On("GetUserByEmail", ctx, "admin@example.com").Return(admin, nil)
+	mockRepo.On("GetRole", ctx, model.RoleAdmin).Return(&model.Role{
+		Name:        model.RoleAdmin,
+		Permissions: []string{"goods:write", "users:manage_roles"},
+	}, nil)
+	mockRepo.On("CreateRefreshToken", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+	tokens, err := service.Login(ctx, "admin@example.com", "strong-password")
+	assert.NoError(t, err)
+
+	// Токен админа выпускается для реального пользователя из БД
+	claims, err := service.ParseAccessToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), claims.UserID)
+	assert.Equal(t, model.RoleAdmin, claims.Role)
+	assert.Contains(t, claims.Permissions, "users:manage_roles")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogin_NoHardcodedAdmin(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetUserByEmail", ctx, "admin@example.com").Return(nil, nil)
+
+	tokens, err := service.Login(ctx, "admin@example.com", "admin123")
+
+	assert.Error(t, err)
+	assert.Nil(t, tokens)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,18 @@
+-- Роли и их права заполняет users-service при старте из shared/pkg/rbac (EnsureRoles),
+-- поэтому здесь только таблицы
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(32) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(32) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+-- Роль 'user' появится только при старте сервиса, поэтому внешний ключ не проверяется
+-- для уже существующих пользователей (NOT VALID); новые строки проверяются как обычно
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey;
+ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles(name) NOT VALID;